@@ -0,0 +1,102 @@
+package vugu
+
+import "context"
+
+// Async runs a function which loads data, such as a request to a server, in its own goroutine and keeps
+// track of whether it is still in progress, so a component can show something else in the meantime, and
+// of its result.  The result is stored with the EventEnv write lock held and a render is requested, so
+// the component does not need to do any locking itself.  The zero value is ready to use, typically as a
+// field of the component:
+//
+//	type UserPage struct {
+//		UserID string
+//		user   vugu.Async
+//	}
+//
+//	func (c *UserPage) Compute(ctx vugu.ComputeCtx) {
+//		c.user.Load(ctx.EventEnv(), c.UserID, func(ctx context.Context) (interface{}, error) {
+//			return fetchUser(ctx, c.UserID)
+//		})
+//	}
+//
+// with the template showing a fallback until it is done:
+//
+//	<div vg-if="c.user.Pending()">Loading...</div>
+//	<div vg-if="c.user.Err() != nil" vg-content="c.user.Err()"></div>
+//	<div vg-if="c.user.Done()" vg-content="c.user.Value().(*User).Name"></div>
+//
+// Methods must be called with the EventEnv write lock held, or from Init, Compute or Build.
+type Async struct {
+	started bool
+	key     interface{}
+	run     int // incremented for each start, so the results of earlier ones are ignored
+	cancel  context.CancelFunc
+
+	pending bool
+	value   interface{}
+	err     error
+}
+
+// Start calls fn in a new goroutine, cancelling the context of the call in progress, if any, whose
+// result is then ignored.  The value and error of the previous call are kept until fn returns.
+func (a *Async) Start(eventEnv EventEnv, fn func(ctx context.Context) (interface{}, error)) {
+
+	if a.cancel != nil {
+		a.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	a.started = true
+	a.pending = true
+	a.run++
+	run := a.run
+
+	go func() {
+		value, err := fn(ctx)
+		eventEnv.Lock()
+		if run != a.run {
+			eventEnv.UnlockOnly()
+			return
+		}
+		defer eventEnv.UnlockRender()
+		cancel()
+		a.cancel = nil
+		a.pending = false
+		a.value, a.err = value, err
+	}()
+}
+
+// Load is like Start but only calls fn if it has not been started yet or key is different from the
+// previous call to Load, so it can be called on every build with what the data depends on as the key.
+// key must be comparable.
+func (a *Async) Load(eventEnv EventEnv, key interface{}, fn func(ctx context.Context) (interface{}, error)) {
+	if a.started && a.key == key {
+		return
+	}
+	a.key = key
+	a.Start(eventEnv, fn)
+}
+
+// Cancel cancels the call in progress, if any, and ignores its result.  The value and error of the
+// previous call are kept, and the next call to Load calls its function whatever the key.
+func (a *Async) Cancel() {
+	if a.cancel != nil {
+		a.cancel()
+		a.cancel = nil
+	}
+	a.run++
+	a.started = false
+	a.pending = false
+}
+
+// Pending returns true while a call is in progress.
+func (a *Async) Pending() bool { return a.pending }
+
+// Done returns true if the last call returned without an error and none is in progress.
+func (a *Async) Done() bool { return a.started && !a.pending && a.err == nil }
+
+// Value returns what the last call returned.
+func (a *Async) Value() interface{} { return a.value }
+
+// Err returns the error the last call returned.
+func (a *Async) Err() error { return a.err }
@@ -0,0 +1,85 @@
+package vugu
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsync(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var mu sync.RWMutex
+	renderCh := make(chan bool, 1)
+	ee := NewEventEnvImpl(&mu, renderCh)
+
+	var a Async
+	assert.False(a.Pending())
+	assert.False(a.Done())
+
+	// each call waits to be released by closing the channel returned with it
+	load := func(ret interface{}, err error) (func(ctx context.Context) (interface{}, error), chan struct{}) {
+		release := make(chan struct{})
+		return func(ctx context.Context) (interface{}, error) {
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			return ret, err
+		}, release
+	}
+
+	one, release1 := load("one", nil)
+	notCalled, _ := load("not called", nil)
+	ee.Lock()
+	a.Load(ee, 1, one)
+	a.Load(ee, 1, notCalled)
+	assert.True(a.Pending())
+	ee.UnlockOnly()
+
+	close(release1)
+	<-renderCh
+	ee.RLock()
+	assert.False(a.Pending())
+	assert.True(a.Done())
+	assert.Equal("one", a.Value())
+	ee.RUnlock()
+
+	// a new key replaces the call in progress, whose result is ignored
+	two, _ := load("two", nil)
+	failed, release3 := load(nil, errors.New("failed"))
+	ee.Lock()
+	a.Load(ee, 2, two)
+	a.Load(ee, 3, failed)
+	assert.True(a.Pending())
+	assert.Equal("one", a.Value())
+	ee.UnlockOnly()
+
+	close(release3)
+	<-renderCh
+	ee.RLock()
+	assert.False(a.Done())
+	assert.EqualError(a.Err(), "failed")
+	ee.RUnlock()
+
+	// cancelled calls are not rendered
+	cancelled, _ := load("cancelled", nil)
+	three, releaseThree := load("three", nil)
+	ee.Lock()
+	a.Start(ee, cancelled)
+	a.Cancel()
+	assert.False(a.Pending())
+	a.Load(ee, 3, three)
+	ee.UnlockOnly()
+	close(releaseThree)
+	<-renderCh
+	ee.RLock()
+	assert.Equal("three", a.Value())
+	assert.NoError(a.Err())
+	ee.RUnlock()
+}
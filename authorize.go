@@ -0,0 +1,38 @@
+package vugu
+
+// Authorizer decides what the current user is allowed to do, for vg-can.  Permissions are whatever
+// strings the application uses, e.g. "orders.edit" or a role name.
+type Authorizer interface {
+	Can(permission string) bool
+}
+
+// AuthorizerFunc implements Authorizer as a function.
+type AuthorizerFunc func(permission string) bool
+
+// Can implements Authorizer.
+func (f AuthorizerFunc) Can(permission string) bool { return f(permission) }
+
+type authorizerKey struct{}
+
+// ProvideAuthorizer makes a available to vg-can (and BuildIn.Can) in the descendants of the component,
+// called from its Provide method:
+//
+//	func (c *Root) Provide(ctx vugu.ProvideCtx) {
+//		vugu.ProvideAuthorizer(ctx, vugu.AuthorizerFunc(c.session.HasPermission))
+//	}
+//
+// An element with vg-can="orders.edit" is then only output if the Authorizer allows "orders.edit", and
+// one with vg-can.disable="orders.edit" is output with the disabled and aria-disabled attributes if it
+// does not.  As this happens when the component is built, elements which are not allowed are left out of
+// the HTML made by staticrender in the same way, and never reach the client.
+func ProvideAuthorizer(ctx ProvideCtx, a Authorizer) {
+	ctx.Provide(authorizerKey{}, a)
+}
+
+// Can reports whether the Authorizer provided by an ancestor (see ProvideAuthorizer) allows permission.
+// It returns false if there is none, so protected content is not shown by mistake.
+func (bi *BuildIn) Can(permission string) bool {
+	v, _ := bi.Inject(authorizerKey{})
+	a, ok := v.(Authorizer)
+	return ok && a.Can(permission)
+}
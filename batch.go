@@ -0,0 +1,61 @@
+package vugu
+
+import "sync"
+
+// batch is the state of Batch, render requests made while depth > 0 are held in pending
+var batch struct {
+	mu      sync.Mutex
+	depth   int
+	pending []*EventEnvImpl
+}
+
+// Batch calls fn and holds back the renders requested by EventEnv.UnlockRender in the meantime until
+// it returns, so a series of state changes each made with Lock and UnlockRender, e.g. by a handler
+// and the stores and watchers it updates, is rendered once at the end rather than after each change:
+//
+//	vugu.Batch(func() {
+//		cart.Add(item)     // each locks and calls UnlockRender
+//		stock.Reserve(item)
+//	})
+//
+// Batches may be nested, renders are requested when the outermost one returns.  Render requests from
+// other goroutines are held back too while fn runs.  fn must not wait for a render, as it will not
+// happen until fn returns.
+func Batch(fn func()) {
+
+	batch.mu.Lock()
+	batch.depth++
+	batch.mu.Unlock()
+
+	defer func() {
+		batch.mu.Lock()
+		batch.depth--
+		var pending []*EventEnvImpl
+		if batch.depth == 0 {
+			pending, batch.pending = batch.pending, nil
+		}
+		batch.mu.Unlock()
+		for _, ee := range pending {
+			ee.requestRender()
+		}
+	}()
+
+	fn()
+}
+
+// holdRender returns true if a Batch is in progress, in which case the render for ee is requested when
+// it ends.
+func holdRender(ee *EventEnvImpl) bool {
+	batch.mu.Lock()
+	defer batch.mu.Unlock()
+	if batch.depth == 0 {
+		return false
+	}
+	for _, p := range batch.pending {
+		if p == ee {
+			return true
+		}
+	}
+	batch.pending = append(batch.pending, ee)
+	return true
+}
@@ -0,0 +1,45 @@
+package vugu
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatch(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var mu sync.RWMutex
+	ch := make(chan bool, 10)
+	ee := NewEventEnvImpl(&mu, ch)
+
+	change := func() {
+		ee.Lock()
+		ee.UnlockRender()
+	}
+
+	change()
+	assert.Len(ch, 1)
+	<-ch
+
+	Batch(func() {
+		change()
+		Batch(func() {
+			change()
+		})
+		assert.Len(ch, 0, "render requested by nested batch")
+		change()
+		assert.Len(ch, 0, "render requested during batch")
+	})
+	assert.Len(ch, 1, "expected one render after batch")
+	<-ch
+
+	// UnlockOnly does not request a render in a batch either
+	Batch(func() {
+		ee.Lock()
+		ee.UnlockOnly()
+	})
+	assert.Len(ch, 0)
+}
@@ -3,6 +3,7 @@ package vugu
 import (
 	"encoding/binary"
 	"fmt"
+	"time"
 
 	"github.com/vugu/xxhash"
 )
@@ -46,13 +47,57 @@ type BuildEnv struct {
 
 	// used to determine "seen in this pass"
 	passNum uint8
+
+	// components marked with KeepAlive and what they need to be restored
+	keepAlive map[Builder]*keepAliveState
+
+	// keep-alive components whose subtrees are being built, innermost last
+	keepAliveStack []*keepAliveState
+
+	// the key each component was used with in this pass
+	usedKeys map[Builder]CompKey
+
+	// component currently being built
+	building Builder
+
+	// values provided by the components whose subtrees are being built, see Provider
+	provided []provision
+
+	// set by SetTimeComponents, the build time of each component and the component whose Build output it
+	timeComponents bool
+	buildTimes     map[Builder]time.Duration
+	buildParents   map[Builder]Builder
+}
+
+// keepAliveState records a keep-alive component and the components in its subtree, so they can
+// be kept in the cache and not destroyed while it is not rendered.
+type keepAliveState struct {
+	comp    Builder
+	key     CompKey
+	hasKey  bool
+	parent  Builder              // component whose Build output this one
+	passNum uint8                // last pass KeepAlive was called
+	keys    map[CompKey]Builder  // cache entries used in the subtree
+	comps   map[Builder]struct{} // components built in the subtree
 }
 
 // BuildResults contains the BuildOut values for full tree of components built.
 type BuildResults struct {
 	Out *BuildOut
 
+	Root Builder // the component RunBuild was called with, see RootSwitch
+
+	BuildTime time.Duration // how long RunBuild took
+
+	// ComponentBuildTimes is how long each component took to build, not including the components in its
+	// output.  It is nil unless BuildEnv.SetTimeComponents was called.
+	ComponentBuildTimes map[Builder]time.Duration
+
+	buildParents map[Builder]Builder
+
 	allOut map[buildCacheKey]*BuildOut
+
+	nodeHashes map[*VGNode]uint64 // cache for NodeHash
 }
 
 // ResultFor is alias for indexing into AllOut.
@@ -60,12 +105,20 @@ func (r *BuildResults) ResultFor(component interface{}) *BuildOut {
 	return r.allOut[makeBuildCacheKey(component)]
 }
 
+// ComponentParent returns the component whose Build output c, or nil for the root component.  It is only
+// known when BuildEnv.SetTimeComponents was called.
+func (r *BuildResults) ComponentParent(c Builder) Builder {
+	return r.buildParents[c]
+}
+
 // RunBuild performs a bulid on a component, managing the lifecycles of nested components and related concerned.
 // In the map that is output, m[builder] will give the BuildOut for the component in question.  Child components
 // can likewise be indexed using the component (which should be a struct pointer) as the key.
 // Callers should not modify the return value as it is reused by subsequent calls.
 func (e *BuildEnv) RunBuild(builder Builder) *BuildResults {
 
+	start := time.Now()
+
 	if e.compCache == nil {
 		e.compCache = make(map[CompKey]Builder)
 	}
@@ -102,6 +155,26 @@ func (e *BuildEnv) RunBuild(builder Builder) *BuildResults {
 		e.compStateMap = make(map[Builder]compState)
 	}
 
+	if e.usedKeys == nil {
+		e.usedKeys = make(map[Builder]CompKey)
+	}
+	for k := range e.usedKeys {
+		delete(e.usedKeys, k)
+	}
+
+	if e.timeComponents {
+		if e.buildTimes == nil {
+			e.buildTimes = make(map[Builder]time.Duration)
+			e.buildParents = make(map[Builder]Builder)
+		}
+		for k := range e.buildTimes {
+			delete(e.buildTimes, k)
+		}
+		for k := range e.buildParents {
+			delete(e.buildParents, k)
+		}
+	}
+
 	var buildIn BuildIn
 	buildIn.BuildEnv = e
 	// buildIn.PositionHashList starts empty
@@ -114,6 +187,8 @@ func (e *BuildEnv) RunBuild(builder Builder) *BuildResults {
 		panic(fmt.Errorf("unexpected PositionHashList len = %d", len(buildIn.PositionHashList)))
 	}
 
+	e.retainKeepAlive()
+
 	// remove and invoke destroy on anything where passNum doesn't match
 	for k, st := range e.compStateMap {
 		if st.passNum != e.passNum {
@@ -122,7 +197,11 @@ func (e *BuildEnv) RunBuild(builder Builder) *BuildResults {
 		}
 	}
 
-	return &BuildResults{allOut: e.buildResults, Out: e.buildResults[makeBuildCacheKey(builder)]}
+	ret := &BuildResults{allOut: e.buildResults, Out: e.buildResults[makeBuildCacheKey(builder)], Root: builder, BuildTime: time.Since(start)}
+	if e.timeComponents {
+		ret.ComponentBuildTimes, ret.buildParents = e.buildTimes, e.buildParents
+	}
+	return ret
 }
 
 func (e *BuildEnv) buildOne(buildIn *BuildIn, thisb Builder) {
@@ -134,18 +213,45 @@ func (e *BuildEnv) buildOne(buildIn *BuildIn, thisb Builder) {
 	st.passNum = e.passNum
 	e.compStateMap[thisb] = st
 
+	for _, kst := range e.keepAliveStack {
+		kst.comps[thisb] = struct{}{}
+	}
+	if kst := e.keepAlive[thisb]; kst != nil && kst.passNum == e.passNum {
+		e.keepAliveStack = append(e.keepAliveStack, kst)
+		defer func() { e.keepAliveStack = e.keepAliveStack[:len(e.keepAliveStack)-1] }()
+	}
+
+	prevBuilding := e.building
+	e.building = thisb
+	defer func() { e.building = prevBuilding }()
+
+	var buildStart time.Time
+	if e.timeComponents {
+		buildStart = time.Now()
+		if prevBuilding != nil {
+			e.buildParents[thisb] = prevBuilding
+		}
+	}
+
 	beforeBuilder, ok := thisb.(BeforeBuilder)
 	if ok {
 		beforeBuilder.BeforeBuild()
 	} else {
-		invokeCompute(thisb, e.eventEnv)
+		invokeCompute(thisb, e)
 	}
 
 	buildOut := thisb.Build(buildIn)
+	applyContainment(thisb, buildOut)
+
+	if e.timeComponents {
+		e.buildTimes[thisb] = time.Since(buildStart)
+	}
 
 	// store in buildResults
 	e.buildResults[makeBuildCacheKey(thisb)] = buildOut
 
+	defer invokeProvide(thisb, e)()
+
 	if len(buildOut.Components) == 0 {
 		return
 	}
@@ -166,6 +272,12 @@ func (e *BuildEnv) buildOne(buildIn *BuildIn, thisb Builder) {
 	}
 }
 
+// SetTimeComponents sets whether RunBuild measures the time each component takes to build, see
+// BuildResults.ComponentBuildTimes.  This is meant for development, measuring has a cost of its own.
+func (e *BuildEnv) SetTimeComponents(on bool) {
+	e.timeComponents = on
+}
+
 // CachedComponent will return the component that corresponds to a given CompKey.
 // The CompKey must contain a unique ID for the instance in question, and an optional
 // IterKey if applicable in the caller.
@@ -190,6 +302,117 @@ func (e *BuildEnv) CachedComponent(compKey CompKey) Builder {
 func (e *BuildEnv) UseComponent(compKey CompKey, component Builder) {
 	delete(e.compCache, compKey)    // make sure it's not in the cache
 	e.compUsed[compKey] = component // make sure it is in the used
+	e.usedKeys[component] = compKey
+	for _, kst := range e.keepAliveStack {
+		kst.keys[compKey] = component
+	}
+}
+
+// KeepAlive marks a component used during this build pass to be kept when it is no longer rendered,
+// e.g. because it is toggled out by vg-if, instead of being destroyed.  It and the components
+// in its subtree stay in the cache, so when it is rendered again in the same position with the same
+// key it is returned by CachedComponent with its state intact.  Kept components are destroyed along
+// with the component that output them.  It is called by code generated for components inside a
+// vg-keep-alive tag and must be called each build pass, after UseComponent.
+func (e *BuildEnv) KeepAlive(component Builder) {
+	if e.keepAlive == nil {
+		e.keepAlive = make(map[Builder]*keepAliveState)
+	}
+	kst := e.keepAlive[component]
+	if kst == nil {
+		kst = &keepAliveState{comp: component}
+		e.keepAlive[component] = kst
+	}
+	kst.key, kst.hasKey = e.usedKeys[component]
+	kst.parent = e.building
+	kst.passNum = e.passNum
+	// start over recording the subtree
+	kst.keys = make(map[CompKey]Builder)
+	kst.comps = make(map[Builder]struct{})
+}
+
+// retainKeepAlive puts keep-alive components not rendered in this pass, and their subtrees, back in the cache
+// and marks them as seen so they are not destroyed.  Those whose parent is gone are forgotten.
+func (e *BuildEnv) retainKeepAlive() {
+
+	// a parent may itself be kept by another keep-alive component, so repeat until nothing changes
+	for changed := true; changed; {
+		changed = false
+		for comp, kst := range e.keepAlive {
+			if kst.passNum == e.passNum {
+				continue
+			}
+			if kst.parent != nil && e.compStateMap[kst.parent].passNum != e.passNum {
+				continue
+			}
+			kst.passNum = e.passNum
+			changed = true
+
+			kept := func(c Builder) {
+				st := e.compStateMap[c]
+				st.passNum = e.passNum
+				e.compStateMap[c] = st
+			}
+			kept(comp)
+			for c := range kst.comps {
+				kept(c)
+			}
+			if kst.hasKey {
+				if _, ok := e.compUsed[kst.key]; !ok {
+					e.compUsed[kst.key] = comp
+				}
+			}
+			for k, c := range kst.keys {
+				if _, ok := e.compUsed[k]; !ok {
+					e.compUsed[k] = c
+				}
+			}
+		}
+	}
+
+	for comp, kst := range e.keepAlive {
+		if kst.passNum != e.passNum {
+			delete(e.keepAlive, comp)
+		}
+	}
+}
+
+// DynamicComponent returns the component to use for v in a vg-comp tag.  If v is a Builder it is wired and returned.
+// If v is a ComponentFunc (or func() Builder) the instance it created for the same id and iterKey in the prior
+// build pass is reused, and a new one is created (and the prior one destroyed) when a different
+// function is provided, so assigning a new constructor to a field swaps the component.
+// Returns nil if v is nil.
+func (e *BuildEnv) DynamicComponent(id uint64, iterKey interface{}, v interface{}) Builder {
+
+	var f ComponentFunc
+	switch vt := v.(type) {
+	case nil:
+		return nil
+	case Builder:
+		e.WireComponent(vt)
+		return vt
+	case ComponentFunc:
+		f = vt
+	case func() Builder:
+		f = vt
+	default:
+		panic(fmt.Errorf("vg-comp expr must be a Builder or ComponentFunc, not %T", v))
+	}
+	if f == nil {
+		return nil
+	}
+
+	compKey := MakeCompKey(hashVals(id, funcID(f)), iterKey)
+	comp := e.CachedComponent(compKey)
+	if comp == nil {
+		comp = f()
+		if comp == nil {
+			return nil
+		}
+		e.WireComponent(comp)
+	}
+	e.UseComponent(compKey, comp)
+	return comp
 }
 
 // SetWireFunc assigns the function to be called by WireComponent.
@@ -67,3 +67,167 @@ func (b *testb1) Build(in *BuildIn) (out *BuildOut) {
 		Out: []*VGNode{},
 	}
 }
+
+func TestBuildEnvDynamicComponent(t *testing.T) {
+
+	assert := assert.New(t)
+
+	be, err := NewBuildEnv()
+	assert.NoError(err)
+
+	newB1 := func() Builder { return &testb1{} }
+	newB2 := ComponentFunc(func() Builder { return &testb2{} })
+
+	root := &dynroot{}
+	root.c = newB1
+	be.RunBuild(root)
+	c1 := root.comp
+	assert.IsType(&testb1{}, c1)
+
+	// same func, same instance
+	be.RunBuild(root)
+	assert.True(c1 == root.comp)
+
+	// different func, new instance and the old one is destroyed
+	root.c = newB2
+	be.RunBuild(root)
+	assert.IsType(&testb2{}, root.comp)
+	_, ok := be.compStateMap[c1]
+	assert.False(ok)
+
+	// instances are used as is
+	b1 := &testb1{}
+	root.c = b1
+	be.RunBuild(root)
+	assert.True(b1 == root.comp)
+
+	root.c = nil
+	be.RunBuild(root)
+	assert.Nil(root.comp)
+}
+
+type dynroot struct {
+	c    interface{}
+	comp Builder
+}
+
+func (b *dynroot) Build(in *BuildIn) (out *BuildOut) {
+	out = &BuildOut{}
+	b.comp = in.BuildEnv.DynamicComponent(1, nil, b.c)
+	if b.comp != nil {
+		out.Components = append(out.Components, b.comp)
+		out.Out = append(out.Out, &VGNode{Component: b.comp})
+	}
+	return out
+}
+
+type testb2 struct{}
+
+func (b *testb2) Build(in *BuildIn) (out *BuildOut) {
+	return &BuildOut{
+		Out: []*VGNode{},
+	}
+}
+
+func TestBuildEnvKeepAlive(t *testing.T) {
+
+	assert := assert.New(t)
+
+	be, err := NewBuildEnv()
+	assert.NoError(err)
+
+	root := &karoot{show: true, showWrapper: true}
+	be.RunBuild(root)
+	wrapper := root.wrapper
+	child := wrapper.child
+	grandchild := child.child
+	assert.NotNil(grandchild)
+
+	// toggled out, nothing is destroyed
+	wrapper.show = false
+	be.RunBuild(root)
+	be.RunBuild(root)
+	assert.Equal(0, child.destroyed)
+	assert.Equal(0, grandchild.destroyed)
+
+	// and the same instances are used when toggled back in
+	wrapper.show = true
+	be.RunBuild(root)
+	assert.True(child == wrapper.child)
+	assert.True(grandchild == child.child)
+	assert.Equal(1, child.inited)
+
+	// kept components go with their parent
+	wrapper.show = false
+	be.RunBuild(root)
+	root.showWrapper = false
+	be.RunBuild(root)
+	assert.Equal(1, child.destroyed)
+	assert.Equal(1, grandchild.destroyed)
+	assert.Empty(be.keepAlive)
+}
+
+type karoot struct {
+	showWrapper bool
+	show        bool
+	wrapper     *kawrapper
+}
+
+func (b *karoot) Build(in *BuildIn) (out *BuildOut) {
+	out = &BuildOut{}
+	if b.showWrapper {
+		key := MakeCompKey(1^in.CurrentPositionHash(), nil)
+		b.wrapper, _ = in.BuildEnv.CachedComponent(key).(*kawrapper)
+		if b.wrapper == nil {
+			b.wrapper = &kawrapper{show: b.show}
+		}
+		in.BuildEnv.UseComponent(key, b.wrapper)
+		out.Components = append(out.Components, b.wrapper)
+	}
+	return out
+}
+
+// kawrapper keeps alive its child
+type kawrapper struct {
+	show  bool
+	child *kachild
+}
+
+func (b *kawrapper) Build(in *BuildIn) (out *BuildOut) {
+	out = &BuildOut{}
+	if b.show {
+		key := MakeCompKey(2^in.CurrentPositionHash(), nil)
+		b.child, _ = in.BuildEnv.CachedComponent(key).(*kachild)
+		if b.child == nil {
+			b.child = &kachild{depth: 1}
+		}
+		in.BuildEnv.UseComponent(key, b.child)
+		in.BuildEnv.KeepAlive(b.child)
+		out.Components = append(out.Components, b.child)
+	}
+	return out
+}
+
+type kachild struct {
+	depth     int
+	child     *kachild
+	inited    int
+	destroyed int
+}
+
+func (b *kachild) Init()    { b.inited++ }
+func (b *kachild) Destroy() { b.destroyed++ }
+
+func (b *kachild) Build(in *BuildIn) (out *BuildOut) {
+	out = &BuildOut{}
+	if b.depth < 2 {
+		key := MakeCompKey(3^in.CurrentPositionHash(), nil)
+		b.child, _ = in.BuildEnv.CachedComponent(key).(*kachild)
+		if b.child == nil {
+			b.child = &kachild{depth: b.depth + 1}
+		}
+		in.BuildEnv.UseComponent(key, b.child)
+		out.Components = append(out.Components, b.child)
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package vugu
+
+import (
+	"sort"
+	"strings"
+)
+
+// ClassMap is a set of CSS class names, each of which is applied to an element if its value is true.
+// Use it with :class, e.g. <div class="item" :class='vugu.ClassMap{"active": c.Active}'>.
+// The classes are added to any in the class attribute and the renderer updates only the classes that changed.
+type ClassMap map[string]bool
+
+// String returns the class names whose value is true, sorted and separated by spaces.
+func (m ClassMap) String() string {
+	names := make([]string, 0, len(m))
+	for k, v := range m {
+		if v {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+// StyleMap is a set of inline CSS properties, mapping the property name (e.g. "background-color") to its value.
+// Use it with :style, e.g. <div style="padding:4px" :style='vugu.StyleMap{"width": w}'>.
+// The properties are applied over any in the style attribute and the renderer updates only the properties that changed.
+// Entries with an empty value are ignored.
+type StyleMap map[string]string
+
+// String returns the properties in the form used in a style attribute, sorted by name.
+func (m StyleMap) String() string {
+	var sb strings.Builder
+	for _, k := range m.keys() {
+		if sb.Len() > 0 {
+			sb.WriteString(";")
+		}
+		sb.WriteString(k)
+		sb.WriteString(":")
+		sb.WriteString(m[k])
+	}
+	return sb.String()
+}
+
+// keys returns the names of the properties with a value, sorted.
+func (m StyleMap) keys() []string {
+	names := make([]string, 0, len(m))
+	for k, v := range m {
+		if v != "" {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ClassList returns the classes from the class attribute of n followed by those in n.ClassMap,
+// without duplicates.  This is the full list of classes the element should have.
+func (n *VGNode) ClassList() []string {
+	var ret []string
+	seen := make(map[string]bool)
+	add := func(c string) {
+		if c != "" && !seen[c] {
+			seen[c] = true
+			ret = append(ret, c)
+		}
+	}
+	for _, a := range n.Attr {
+		if a.Key == "class" {
+			for _, c := range strings.Fields(a.Val) {
+				add(c)
+			}
+		}
+	}
+	for _, c := range strings.Fields(n.ClassMap.String()) {
+		add(c)
+	}
+	return ret
+}
+
+// StyleList returns the names and values of the properties in n.StyleMap, sorted by name.
+func (n *VGNode) StyleList() (names, values []string) {
+	for _, k := range n.StyleMap.keys() {
+		names = append(names, k)
+		values = append(values, n.StyleMap[k])
+	}
+	return names, values
+}
@@ -0,0 +1,35 @@
+package vugu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassStyleMap(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("a c", ClassMap{"c": true, "b": false, "a": true}.String())
+	assert.Equal("color:red;width:10px", StyleMap{"width": "10px", "color": "red", "height": ""}.String())
+
+	var n VGNode
+	n.Attr = []VGAttribute{{Key: "class", Val: "x  a"}}
+	n.AddAttrInterface("class", ClassMap{"a": true, "b": true})
+	n.AddAttrInterface("class", ClassMap{"b": false, "c": true})
+	n.AddAttrInterface("style", StyleMap{"width": "1px"})
+	assert.Len(n.Attr, 1) // maps are not added as attributes
+	assert.Equal([]string{"x", "a", "c"}, n.ClassList())
+
+	names, values := n.StyleList()
+	assert.Equal([]string{"width"}, names)
+	assert.Equal([]string{"1px"}, values)
+
+	// with other keys they are attributes like any other value
+	n.AddAttrInterface("data-class", ClassMap{"d": true})
+	n.AddAttrInterface("data-style", StyleMap{"height": "2px"})
+	assert.Equal([]VGAttribute{{Key: "class", Val: "x  a"}, {Key: "data-class", Val: "d"}, {Key: "data-style", Val: "height:2px"}}, n.Attr)
+	assert.Equal([]string{"x", "a", "c"}, n.ClassList())
+	names, values = n.StyleList()
+	assert.Equal([]string{"width"}, names)
+	assert.Equal([]string{"1px"}, values)
+}
@@ -0,0 +1,125 @@
+// vugureplay is a command line tool which shows the instructions in a recording made with a domrender.Recorder,
+// or writes an HTML page which replays them in the browser one buffer at a time.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/vugu/vugu/domrender"
+)
+
+func main() {
+
+	// vugureplay [-html out.html] [-body '<div id="vugu_mount_point"></div>'] recording.bin
+
+	htmlOut := flag.String("html", "", "Write a page which replays the recording to this file instead of printing the instructions")
+	body := flag.String("body", `<div id="vugu_mount_point"></div>`, "HTML for the body of the replay page, it must contain the mount point")
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) != 1 {
+		log.Fatal("usage: vugureplay [-html out.html] [-body html] recording.bin")
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	bufs, err := domrender.ReadRecording(f)
+	f.Close()
+	if err != nil && len(bufs) == 0 {
+		log.Fatal(err)
+	}
+	if err != nil {
+		log.Printf("recording is incomplete: %v", err)
+	}
+
+	if *htmlOut != "" {
+		page, err := replayPage(bufs, *body)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := ioutil.WriteFile(*htmlOut, []byte(page), 0644); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var dec domrender.Decoder
+	for i, buf := range bufs {
+		fmt.Printf("== buffer %d (%d bytes)\n", i, len(buf))
+		dec.Format(os.Stdout, buf)
+	}
+}
+
+// replayPage returns an HTML page which applies bufs to body with the JS helper script, with controls
+// to step through them.  Event listeners are set up as usual but events go nowhere.
+func replayPage(bufs [][]byte, body string) (string, error) {
+
+	script := domrender.HelperScript()
+	if strings.Contains(strings.ToLower(script), "</script") {
+		return "", fmt.Errorf("helper script cannot be embedded in a page")
+	}
+	data, err := json.Marshal(bufs) // base64 strings, and < is escaped
+	if err != nil {
+		return "", err
+	}
+
+	return `<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>vugureplay</title>
+<style>
+#vugureplay { position: fixed; right: 0; bottom: 0; z-index: 2147483647; padding: 4px 8px; background: #333; color: #fff; font: 12px monospace; }
+</style>
+</head>
+<body>
+` + body + `
+<div id="vugureplay">
+<span id="vugureplay-status"></span>
+<button id="vugureplay-next">Next</button>
+<button id="vugureplay-all">All</button>
+<button onclick="location.reload()">Restart</button>
+</div>
+<script>` + script + `</script>
+<script>
+(function() {
+	var bufs = ` + string(data) + `;
+	var next = 0;
+	window.vuguSetEventHandler(function() {});
+	window.vuguSetCallbackHandler(function() {});
+	function status() {
+		document.getElementById("vugureplay-status").textContent = "buffer " + next + " of " + bufs.length;
+	}
+	function step() {
+		if (next >= bufs.length) return false;
+		var s = atob(bufs[next]);
+		var a = window.vuguGetRenderArray();
+		a.fill(0);
+		for (var i = 0; i < s.length && i < a.length; i++) a[i] = s.charCodeAt(i);
+		try {
+			window.vuguRender();
+		} catch (e) {
+			console.error("vugureplay: error in buffer " + next, e);
+		}
+		console.log("vugureplay: applied buffer " + next);
+		next++;
+		status();
+		return true;
+	}
+	document.getElementById("vugureplay-next").addEventListener("click", step);
+	document.getElementById("vugureplay-all").addEventListener("click", function() { while (step()); });
+	status();
+})();
+</script>
+</body>
+</html>
+`, nil
+}
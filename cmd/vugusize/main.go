@@ -0,0 +1,44 @@
+// vugusize is a command line tool which reports how the size of a Wasm executable breaks down by Go package and component.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/vugu/vugu/distutil"
+)
+
+func main() {
+
+	// vugusize path/to/main.wasm
+
+	heavyFraction := flag.Float64("heavy", distutil.HeavyPackageFraction, "Flag packages above this fraction of total code size as heavyweight")
+	flag.Parse()
+
+	distutil.HeavyPackageFraction = *heavyFraction
+
+	args := flag.Args()
+	if len(args) == 0 {
+		log.Fatal("usage: vugusize [-heavy fraction] path/to/main.wasm")
+	}
+
+	for _, arg := range args {
+
+		rep, err := distutil.AnalyzeWasmSizeFile(arg)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if len(args) > 1 {
+			os.Stdout.WriteString("== " + arg + "\n")
+		}
+
+		_, err = rep.WriteTo(os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+	}
+
+}
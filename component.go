@@ -168,6 +168,10 @@ func NewBuilderFunc(f func(in *BuildIn) (out *BuildOut)) Builder {
 	}
 }
 
+// ComponentFunc creates a new component.  Used as the expr of a vg-comp tag it lets the type
+// of component be chosen at runtime, see BuildEnv.DynamicComponent.
+type ComponentFunc func() Builder
+
 // BeforeBuilder is deprecated.  It is replaced by the Compute lifecycle callback.
 type BeforeBuilder interface {
 	BeforeBuild()
@@ -0,0 +1,48 @@
+package vugu
+
+// Containment is the CSS containment of a component's root elements, see Contained.  Empty fields
+// are not set.
+type Containment struct {
+	Contain              string // the contain property, e.g. "content" so its layout and paint do not affect the rest of the page
+	ContentVisibility    string // the content-visibility property, e.g. "auto" so the browser skips rendering it while it is offscreen
+	ContainIntrinsicSize string // the contain-intrinsic-size property, the size it is taken to be while skipped, e.g. "auto 200px"
+}
+
+// ContainOffscreen is containment for components of which many are offscreen at a time, like the items of a
+// long list or the sections of a long page: the browser skips their layout and paint until they are scrolled
+// near the viewport.  The intrinsic size is remembered once each has been rendered, until then it is taken
+// to be 200px high, which can be changed by setting ContainIntrinsicSize.
+var ContainOffscreen = Containment{Contain: "content", ContentVisibility: "auto", ContainIntrinsicSize: "auto 200px"}
+
+// Contained is implemented by components which declare CSS containment for their root elements.  The
+// properties are set on each root element (as with :style, over its style attribute) after Build, so they
+// apply with any renderer and need not be repeated in the component's markup.
+type Contained interface {
+	Containment() Containment
+}
+
+// applyContainment sets the containment of c, if it is Contained, on the root elements of out.
+func applyContainment(c Builder, out *BuildOut) {
+	cc, ok := c.(Contained)
+	if !ok || out == nil {
+		return
+	}
+	ct := cc.Containment()
+	for _, n := range out.Out {
+		if n.Type != ElementNode || n.Data == "" || n.Component != nil {
+			continue
+		}
+		set := func(k, v string) {
+			if v == "" {
+				return
+			}
+			if n.StyleMap == nil {
+				n.StyleMap = make(StyleMap, 3)
+			}
+			n.StyleMap[k] = v
+		}
+		set("contain", ct.Contain)
+		set("content-visibility", ct.ContentVisibility)
+		set("contain-intrinsic-size", ct.ContainIntrinsicSize)
+	}
+}
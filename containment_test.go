@@ -0,0 +1,42 @@
+package vugu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type containedComp struct{ ct Containment }
+
+func (c *containedComp) Containment() Containment { return c.ct }
+
+func (c *containedComp) Build(in *BuildIn) (out *BuildOut) {
+	return &BuildOut{
+		Out: []*VGNode{
+			{Type: ElementNode, Data: "div", StyleMap: StyleMap{"color": "red"}},
+			{Type: TextNode, Data: "\n"},
+			{Type: ElementNode, Data: "section"},
+		},
+	}
+}
+
+func TestContainment(t *testing.T) {
+
+	assert := assert.New(t)
+
+	be, err := NewBuildEnv()
+	assert.NoError(err)
+
+	c := &containedComp{ct: ContainOffscreen}
+	res := be.RunBuild(c)
+	out := res.Out.Out
+
+	assert.Equal(StyleMap{"color": "red", "contain": "content", "content-visibility": "auto", "contain-intrinsic-size": "auto 200px"}, out[0].StyleMap)
+	assert.Nil(out[1].StyleMap)
+	assert.Equal("auto", out[2].StyleMap["content-visibility"])
+
+	// empty fields are left out
+	c.ct = Containment{Contain: "layout paint"}
+	out = be.RunBuild(c).Out.Out
+	assert.Equal(StyleMap{"contain": "layout paint"}, out[2].StyleMap)
+}
@@ -12,11 +12,14 @@ import (
 // are meant to be replaced as needed if you quickly need to hack in CSS or
 // JS references for a development Vugu application.  If you need more control
 // than that, just copy it into your application.
+// wasm_exec.js and main.wasm are loaded relative to the base href, so to serve
+// the app under a path prefix replace `<base href="/"/>`, e.g. with vgbase.Tags.
 var DefaultIndex = StaticContent(`<!doctype html>
 <html>
 <head>
 <title>Vugu App</title>
 <meta charset="utf-8"/>
+<base href="/"/>
 <!-- styles -->
 </head>
 <body>
@@ -24,7 +27,7 @@ var DefaultIndex = StaticContent(`<!doctype html>
 <img style="position: absolute; top: 50%; left: 50%;" src="https://cdnjs.cloudflare.com/ajax/libs/galleriffic/2.0.1/css/loader.gif">
 </div>
 <script src="https://cdn.jsdelivr.net/npm/text-encoding@0.7.0/lib/encoding.min.js"></script> <!-- MS Edge polyfill -->
-<script src="/wasm_exec.js"></script>
+<script src="wasm_exec.js"></script>
 <!-- scripts -->
 <script>
 var wasmSupported = (typeof WebAssembly === "object");
@@ -35,7 +38,8 @@ if (wasmSupported) {
 			return await WebAssembly.instantiate(source, importObject);
 		};
 	}
-	var mainWasmReq = fetch("/main.wasm").then(function(res) {
+	var assetBase = document.querySelector('meta[name="vugu-asset-base"]');
+	var mainWasmReq = fetch(new URL("main.wasm", assetBase ? new URL(assetBase.content, document.baseURI) : document.baseURI)).then(function(res) {
 		if (res.ok) {
 			const go = new Go();
 			WebAssembly.instantiateStreaming(res, go.importObject).then((result) => {
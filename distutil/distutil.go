@@ -31,5 +31,15 @@ Executing a command while overriding certain environment variables is also easy:
 		[]string{"GOOS=js", "GOARCH=wasm"},
 		"go", "build", "-o", filepath.Join(outDir, "main.wasm"), "."))
 
+Binary size can be checked by attributing the compiled code to Go packages and components.
+Packages that are known to be heavyweight (or are just large) are flagged in the report.
+(The vugusize command does the same thing from the command line.)
+
+	rep, err := distutil.AnalyzeWasmSizeFile(filepath.Join(outDir, "main.wasm"))
+	if err != nil {
+		panic(err)
+	}
+	rep.WriteTo(os.Stdout)
+
 */
 package distutil
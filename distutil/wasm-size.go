@@ -0,0 +1,472 @@
+package distutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// HeavyPackages lists packages which are known to add significantly to the size of a Wasm
+// executable, along with a suggestion of what to do about it.  WasmSizeReport flags any of these
+// that are found.  It can be modified to add or remove entries before calling AnalyzeWasmSize.
+var HeavyPackages = map[string]string{
+	"fmt":           "formatting pulls in reflection, strconv or vjson are often enough (watch for fmt.Sprintf in templates)",
+	"encoding/json": "use github.com/vugu/vjson instead",
+	"net/http":      "use the browser's fetch API via js instead",
+	"regexp":        "use strings functions where possible",
+	"text/template": "use .vugu templates instead",
+	"html/template": "use .vugu templates instead",
+	"math/big":      "avoid arbitrary precision math in the client if possible",
+	"crypto/tls":    "not useful in the browser, usually pulled in by net/http",
+	"time/tzdata":   "embedded time zone database",
+}
+
+// HeavyPackageFraction is the fraction of total code size above which any package is flagged as heavyweight.
+var HeavyPackageFraction = 0.05
+
+// WasmSizeEntry is the size of the compiled code attributed to a package or component.
+type WasmSizeEntry struct {
+	Name      string // package path or component type name
+	Size      int64  // bytes of function bodies
+	FuncCount int    // number of functions
+	Note      string // reason it was flagged, only set in WasmSizeReport.Heavy
+}
+
+// WasmSizeReport describes how the size of a Wasm executable breaks down.
+type WasmSizeReport struct {
+	Total      int64           // total file size
+	CodeSize   int64           // size of all function bodies
+	DataSize   int64           // size of the data section (static data, strings, type info, etc.)
+	Packages   []WasmSizeEntry // code size per package, largest first
+	Components []WasmSizeEntry // code size per component (types with a Build method), largest first
+	Heavy      []WasmSizeEntry // packages which are known to be or appear to be heavyweight, largest first
+}
+
+// AnalyzeWasmSizeFile is like AnalyzeWasmSize but reads from a file.
+func AnalyzeWasmSizeFile(path string) (*WasmSizeReport, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return AnalyzeWasmSize(b)
+}
+
+// AnalyzeWasmSize reads a Wasm executable and attributes the size of each function to
+// the package it belongs to, using the function names in the "name" custom section
+// (which Go includes by default; building with -ldflags=-s removes it).
+// Methods on types which have a Build method are also totaled as components.
+func AnalyzeWasmSize(b []byte) (*WasmSizeReport, error) {
+
+	if len(b) < 8 || !bytes.Equal(b[:4], []byte("\x00asm")) {
+		return nil, errors.New("not a wasm file")
+	}
+
+	ret := &WasmSizeReport{Total: int64(len(b))}
+
+	var importFuncCount uint64
+	var codeSizes []int64
+	funcNames := make(map[uint64]string)
+
+	r := &wasmReader{b: b, pos: 8}
+	for r.pos < len(r.b) {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.uleb()
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(r.b)-r.pos) < size {
+			return nil, fmt.Errorf("section %d at offset %d exceeds file length", id, r.pos)
+		}
+		sr := &wasmReader{b: r.b[r.pos : r.pos+int(size)]}
+		r.pos += int(size)
+
+		switch id {
+		case 0: // custom
+			name, err := sr.name()
+			if err != nil {
+				return nil, err
+			}
+			if name == "name" {
+				err = readWasmFuncNames(sr, funcNames)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case 2: // import
+			importFuncCount, err = countWasmFuncImports(sr)
+			if err != nil {
+				return nil, err
+			}
+		case 10: // code
+			count, err := sr.uleb()
+			if err != nil {
+				return nil, err
+			}
+			// each body takes at least a byte, so the section size bounds the (untrusted) count
+			n := count
+			if n > size {
+				n = size
+			}
+			codeSizes = make([]int64, 0, n)
+			for i := uint64(0); i < count; i++ {
+				bodySize, err := sr.uleb()
+				if err != nil {
+					return nil, err
+				}
+				if err := sr.skip(bodySize); err != nil {
+					return nil, err
+				}
+				codeSizes = append(codeSizes, int64(bodySize))
+			}
+		case 11: // data
+			ret.DataSize += int64(size)
+		}
+	}
+
+	pkgNames := wasmPackageNames(b)
+
+	pkgMap := make(map[string]*WasmSizeEntry)
+	typeMap := make(map[string]*WasmSizeEntry)
+	builders := make(map[string]bool)
+
+	for i, sz := range codeSizes {
+		ret.CodeSize += sz
+
+		name := funcNames[importFuncCount+uint64(i)]
+		pkg, recv, method := splitWasmFuncName(name, pkgNames)
+
+		e := pkgMap[pkg]
+		if e == nil {
+			e = &WasmSizeEntry{Name: pkg}
+			pkgMap[pkg] = e
+		}
+		e.Size += sz
+		e.FuncCount++
+
+		if recv != "" {
+			typeName := pkg + "." + recv
+			e := typeMap[typeName]
+			if e == nil {
+				e = &WasmSizeEntry{Name: typeName}
+				typeMap[typeName] = e
+			}
+			e.Size += sz
+			e.FuncCount++
+			if method == "Build" {
+				builders[typeName] = true
+			}
+		}
+	}
+
+	for _, e := range pkgMap {
+		ret.Packages = append(ret.Packages, *e)
+		note, ok := HeavyPackages[e.Name]
+		if !ok && ret.CodeSize > 0 && float64(e.Size)/float64(ret.CodeSize) > HeavyPackageFraction {
+			ok, note = true, fmt.Sprintf("more than %.0f%% of code size", HeavyPackageFraction*100)
+		}
+		if ok {
+			he := *e
+			he.Note = note
+			ret.Heavy = append(ret.Heavy, he)
+		}
+	}
+	for typeName := range builders {
+		ret.Components = append(ret.Components, *typeMap[typeName])
+	}
+
+	sortWasmSizeEntries(ret.Packages)
+	sortWasmSizeEntries(ret.Components)
+	sortWasmSizeEntries(ret.Heavy)
+
+	return ret, nil
+}
+
+// WriteTo writes a human-readable version of the report to w.
+func (rep *WasmSizeReport) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "total: %d bytes (code: %d, data: %d)\n", rep.Total, rep.CodeSize, rep.DataSize)
+	writeList := func(title string, list []WasmSizeEntry) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Fprintf(&buf, "\n%s:\n", title)
+		for _, e := range list {
+			fmt.Fprintf(&buf, "%10d %5.1f%% %6d funcs  %s", e.Size, rep.percent(e.Size), e.FuncCount, e.Name)
+			if e.Note != "" {
+				fmt.Fprintf(&buf, " (%s)", e.Note)
+			}
+			buf.WriteString("\n")
+		}
+	}
+	writeList("packages", rep.Packages)
+	writeList("components", rep.Components)
+	writeList("heavyweight", rep.Heavy)
+	return buf.WriteTo(w)
+}
+
+func (rep *WasmSizeReport) percent(sz int64) float64 {
+	if rep.CodeSize == 0 {
+		return 0
+	}
+	return float64(sz) * 100 / float64(rep.CodeSize)
+}
+
+func sortWasmSizeEntries(l []WasmSizeEntry) {
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Size != l[j].Size {
+			return l[i].Size > l[j].Size
+		}
+		return l[i].Name < l[j].Name
+	})
+}
+
+// wasmPackageNames scans b for Go symbol names (the runtime function name table keeps them unmangled)
+// and returns a map of each package path in the mangled form used in the name section to its actual path.
+// The Go linker replaces all characters other than letters, digits, '_' and '.' with '_' in the
+// name section, which makes it impossible to tell "a/b_c" from "a_b/c" without this.
+func wasmPackageNames(b []byte) map[string]string {
+	ret := make(map[string]string)
+	start := -1
+	for i := 0; i <= len(b); i++ {
+		if i < len(b) && b[i] > ' ' && b[i] < 0x7f {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start > 2 && bytes.IndexByte(b[start:i], '.') > 0 {
+			name := string(b[start:i])
+			pkg, _, _ := splitWasmFuncName(name, nil)
+			// the name section itself contains the mangled names, which must not overwrite the real ones
+			if m := mangleWasmName(pkg); pkg != name && m != pkg {
+				ret[m] = pkg
+			}
+		}
+		start = -1
+	}
+	return ret
+}
+
+func mangleWasmName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// splitWasmFuncName breaks a Go symbol name like "github.com/a/b.(*T).M" into
+// package ("github.com/a/b"), receiver type ("T") and method ("M").  Receiver and
+// method are empty for plain functions.  Names mangled by the linker
+// (e.g. "github.com_a_b.__T_.M") are resolved using pkgs (see wasmPackageNames).
+func splitWasmFuncName(name string, pkgs map[string]string) (pkg, recv, method string) {
+	if name == "" {
+		return "(unknown)", "", ""
+	}
+
+	// type parameters can contain other package paths, ignore them
+	base := name
+	if i := strings.IndexByte(base, '['); i >= 0 {
+		base = base[:i]
+	}
+
+	var rest string
+
+	// find the longest known mangled package name
+	for i := len(base) - 1; i > 0; i-- {
+		if base[i] != '.' {
+			continue
+		}
+		if p, ok := pkgs[base[:i]]; ok {
+			pkg, rest = p, name[i+1:]
+			break
+		}
+	}
+
+	if pkg == "" {
+		slash := strings.LastIndexByte(base, '/')
+		dot := strings.IndexByte(base[slash+1:], '.')
+		if dot < 0 {
+			return name, "", ""
+		}
+		pkg = base[:slash+1+dot]
+		rest = name[slash+1+dot+1:]
+	}
+
+	if strings.HasPrefix(rest, "(*") {
+		if i := strings.IndexByte(rest, ')'); i > 0 {
+			recv, rest = rest[2:i], strings.TrimPrefix(rest[i+1:], ".")
+		}
+	} else if strings.HasPrefix(rest, "__") && strings.Contains(rest, "_.") {
+		// mangled form of a pointer receiver
+		i := strings.Index(rest, "_.")
+		recv, rest = rest[2:i], rest[i+2:]
+	} else if parts := strings.SplitN(rest, ".", 3); len(parts) > 1 && parts[0] != "" && isUpperASCII(parts[0][0]) {
+		// value receiver, or an exported type's closure/method
+		recv, rest = parts[0], strings.Join(parts[1:], ".")
+	}
+	if recv != "" {
+		if i := strings.IndexByte(recv, '['); i >= 0 {
+			recv = recv[:i]
+		}
+		method = strings.SplitN(rest, ".", 2)[0]
+	}
+
+	return pkg, recv, method
+}
+
+func isUpperASCII(c byte) bool { return c >= 'A' && c <= 'Z' }
+
+func countWasmFuncImports(r *wasmReader) (uint64, error) {
+	count, err := r.uleb()
+	if err != nil {
+		return 0, err
+	}
+	var funcCount uint64
+	for i := uint64(0); i < count; i++ {
+		if _, err := r.name(); err != nil {
+			return 0, err
+		}
+		if _, err := r.name(); err != nil {
+			return 0, err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		switch kind {
+		case 0: // func: type index
+			funcCount++
+			_, err = r.uleb()
+		case 1: // table: reftype, limits
+			if _, err = r.byte(); err == nil {
+				err = r.limits()
+			}
+		case 2: // memory: limits
+			err = r.limits()
+		case 3: // global: valtype, mutability
+			if _, err = r.byte(); err == nil {
+				_, err = r.byte()
+			}
+		default:
+			err = fmt.Errorf("unknown import kind %d", kind)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return funcCount, nil
+}
+
+func readWasmFuncNames(r *wasmReader, funcNames map[uint64]string) error {
+	for r.pos < len(r.b) {
+		id, err := r.byte()
+		if err != nil {
+			return err
+		}
+		size, err := r.uleb()
+		if err != nil {
+			return err
+		}
+		if id != 1 { // only function names are of interest
+			if err := r.skip(size); err != nil {
+				return err
+			}
+			continue
+		}
+		count, err := r.uleb()
+		if err != nil {
+			return err
+		}
+		for i := uint64(0); i < count; i++ {
+			idx, err := r.uleb()
+			if err != nil {
+				return err
+			}
+			name, err := r.name()
+			if err != nil {
+				return err
+			}
+			funcNames[idx] = name
+		}
+	}
+	return nil
+}
+
+// wasmReader decodes the primitive types in the Wasm binary format.
+type wasmReader struct {
+	b   []byte
+	pos int
+}
+
+var errWasmTruncated = errors.New("unexpected end of wasm data")
+
+func (r *wasmReader) byte() (byte, error) {
+	if r.pos >= len(r.b) {
+		return 0, errWasmTruncated
+	}
+	c := r.b[r.pos]
+	r.pos++
+	return c, nil
+}
+
+func (r *wasmReader) uleb() (uint64, error) {
+	var ret uint64
+	var shift uint
+	for {
+		c, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		ret |= uint64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			return ret, nil
+		}
+		shift += 7
+		if shift > 63 {
+			return 0, errors.New("invalid LEB128 value in wasm data")
+		}
+	}
+}
+
+func (r *wasmReader) skip(n uint64) error {
+	if uint64(len(r.b)-r.pos) < n {
+		return errWasmTruncated
+	}
+	r.pos += int(n)
+	return nil
+}
+
+func (r *wasmReader) name() (string, error) {
+	l, err := r.uleb()
+	if err != nil {
+		return "", err
+	}
+	start := r.pos
+	if err := r.skip(l); err != nil {
+		return "", err
+	}
+	return string(r.b[start:r.pos]), nil
+}
+
+func (r *wasmReader) limits() error {
+	flags, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if _, err := r.uleb(); err != nil {
+		return err
+	}
+	if flags&1 != 0 {
+		_, err = r.uleb()
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package distutil
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeWasmSize(t *testing.T) {
+
+	uleb := func(v int) []byte {
+		var ret []byte
+		for {
+			c := byte(v & 0x7f)
+			v >>= 7
+			if v != 0 {
+				c |= 0x80
+			}
+			ret = append(ret, c)
+			if v == 0 {
+				return ret
+			}
+		}
+	}
+	name := func(s string) []byte { return append(uleb(len(s)), s...) }
+	section := func(id byte, content []byte) []byte {
+		return append(append([]byte{id}, uleb(len(content))...), content...)
+	}
+
+	funcs := []struct {
+		name string
+		size int
+	}{
+		{"runtime.mallocgc", 100},
+		{"example.com/app.(*Root).Build", 40},
+		{"example.com/app.(*Root).Build.func1", 10},
+		{"example.com/app.(*Root).handleClick", 20},
+		{"fmt.Sprintf", 30},
+	}
+
+	var b bytes.Buffer
+	b.WriteString("\x00asm\x01\x00\x00\x00")
+
+	// one imported function, which shifts the function index space by one
+	var imp []byte
+	imp = append(imp, uleb(1)...)
+	imp = append(imp, name("go")...)
+	imp = append(imp, name("debug")...)
+	imp = append(imp, 0, 0)
+	b.Write(section(2, imp))
+
+	code := uleb(len(funcs))
+	for _, f := range funcs {
+		code = append(code, uleb(f.size)...)
+		code = append(code, make([]byte, f.size)...)
+	}
+	b.Write(section(10, code))
+
+	b.Write(section(11, make([]byte, 50)))
+
+	names := uleb(len(funcs))
+	for i, f := range funcs {
+		names = append(names, uleb(i+1)...)
+		names = append(names, name(f.name)...)
+	}
+	custom := name("name")
+	custom = append(custom, 1)
+	custom = append(custom, uleb(len(names))...)
+	custom = append(custom, names...)
+	b.Write(section(0, custom))
+
+	rep, err := AnalyzeWasmSize(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rep.CodeSize != 200 {
+		t.Errorf("unexpected CodeSize %d", rep.CodeSize)
+	}
+	if rep.DataSize != 50 {
+		t.Errorf("unexpected DataSize %d", rep.DataSize)
+	}
+	if len(rep.Packages) != 3 || rep.Packages[0].Name != "runtime" || rep.Packages[1].Name != "example.com/app" || rep.Packages[1].Size != 70 {
+		t.Errorf("unexpected Packages: %#v", rep.Packages)
+	}
+	if len(rep.Components) != 1 || rep.Components[0].Name != "example.com/app.Root" || rep.Components[0].Size != 70 {
+		t.Errorf("unexpected Components: %#v", rep.Components)
+	}
+
+	foundFmt := false
+	for _, e := range rep.Heavy {
+		if e.Name == "fmt" && e.Note != "" {
+			foundFmt = true
+		}
+	}
+	if !foundFmt {
+		t.Errorf("expected fmt to be flagged as heavy: %#v", rep.Heavy)
+	}
+
+	var out bytes.Buffer
+	rep.WriteTo(&out)
+	if !strings.Contains(out.String(), "example.com/app.Root") {
+		t.Errorf("unexpected report output: %s", out.String())
+	}
+
+	if _, err := AnalyzeWasmSize([]byte("not wasm")); err == nil {
+		t.Errorf("expected error for non-wasm input")
+	}
+
+	// a code section with a count far beyond what it holds is an error, not a huge allocation
+	truncated := append([]byte("\x00asm\x01\x00\x00\x00"), section(10, uleb(1<<40))...)
+	if _, err := AnalyzeWasmSize(truncated); err == nil {
+		t.Errorf("expected error for truncated code section")
+	}
+}
+
+func TestSplitWasmFuncName(t *testing.T) {
+	tests := []struct{ in, pkg, recv, method string }{
+		{"runtime.mallocgc", "runtime", "", ""},
+		{"github.com/vugu/vugu.(*VGNode).Walk", "github.com/vugu/vugu", "VGNode", "Walk"},
+		{"github.com/vugu/vugu.HTML.HTML", "github.com/vugu/vugu", "HTML", "HTML"},
+		{"main.main", "main", "", ""},
+		{"wasm_pc_f_loop", "wasm_pc_f_loop", "", ""},
+		{"github.com_vugu_vugu_internal_htmlx_atom.Atom.String", "github.com/vugu/vugu/internal/htmlx/atom", "Atom", "String"},
+		{"sync_atomic.__Uint64_.Load_fm", "sync/atomic", "Uint64", "Load_fm"},
+	}
+	pkgs := wasmPackageNames([]byte("\x00github.com/vugu/vugu/internal/htmlx/atom.Lookup\x00sync/atomic.(*Uint64).Load\x00"))
+	for _, tc := range tests {
+		pkg, recv, method := splitWasmFuncName(tc.in, pkgs)
+		if pkg != tc.pkg || recv != tc.recv || method != tc.method {
+			t.Errorf("splitWasmFuncName(%q) = %q, %q, %q", tc.in, pkg, recv, method)
+		}
+	}
+}
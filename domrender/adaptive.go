@@ -0,0 +1,206 @@
+package domrender
+
+import (
+	"strings"
+	"time"
+
+	"github.com/vugu/vugu"
+)
+
+// DefaultFrameBudget is used by an AdaptiveQuality with a zero FrameBudget, about a frame at 60Hz.
+const DefaultFrameBudget = 16 * time.Millisecond
+
+// AdaptiveQuality lowers the rendering quality of a JSRenderer on devices where its renders take longer
+// than a frame, and raises it again once they are fast, set with JSRenderer.AdaptiveQuality.  The time of
+// a render is that spent building, comparing and applying the output (RenderStats Build, Diff and Flush),
+// averaged over the recent renders.
+//
+// At vugu.QualityReduced the renderer waits for every other animation frame, so more events and
+// animation-driven changes are handled by each render, and the output of components implementing
+// vugu.Deferrable may be left as it was for a render.  At vugu.QualityMinimal it waits for every fourth
+// frame.  Components can check the level with vugu.RenderQuality and do less themselves.  Waiting for
+// frames does not apply with JSRenderer.DisableAnimationFrame.
+type AdaptiveQuality struct {
+	// FrameBudget is how long a render should take, DefaultFrameBudget if zero.  Renders averaging more
+	// lower the level to QualityReduced and more than twice as much to QualityMinimal.  It is raised to
+	// QualityReduced when they average less, and to QualityFull when they average less than half.
+	FrameBudget time.Duration
+
+	// Renders is how many renders are averaged, 10 if zero.  After the level changes it does not
+	// change again until this many renders have been measured at the new level.
+	Renders int
+
+	// OnChange, if set, is called from Render when the level changes.  It must not lock the EventEnv.
+	OnChange func(q vugu.Quality)
+
+	quality vugu.Quality
+	times   []time.Duration // of the renders since the last change, the oldest is replaced once there are Renders
+	next    int
+}
+
+// Quality returns the current level, it must be called from the goroutine that renders.
+func (a *AdaptiveQuality) Quality() vugu.Quality { return a.quality }
+
+// record adds the time a render took and returns true if the level changed.
+func (a *AdaptiveQuality) record(d time.Duration) bool {
+
+	n := a.Renders
+	if n <= 0 {
+		n = 10
+	}
+	if len(a.times) < n {
+		a.times = append(a.times, d)
+	} else {
+		a.times[a.next%len(a.times)] = d
+		a.next++
+	}
+	if len(a.times) < n {
+		return false
+	}
+
+	var sum time.Duration
+	for _, t := range a.times {
+		sum += t
+	}
+	avg := sum / time.Duration(len(a.times))
+
+	budget := a.FrameBudget
+	if budget <= 0 {
+		budget = DefaultFrameBudget
+	}
+
+	q := a.quality
+	switch {
+	case avg > 2*budget:
+		q = vugu.QualityMinimal
+	case avg > budget:
+		if q < vugu.QualityReduced {
+			q = vugu.QualityReduced
+		}
+	case avg < budget/2:
+		q = vugu.QualityFull
+	default:
+		if q > vugu.QualityReduced {
+			q = vugu.QualityReduced
+		}
+	}
+	if q == a.quality {
+		return false
+	}
+	a.quality, a.times, a.next = q, a.times[:0], 0
+	return true
+}
+
+// framesPerRender returns how many animation frames EventWait waits for at the current level.
+func (a *AdaptiveQuality) framesPerRender() int {
+	switch a.quality {
+	case vugu.QualityReduced:
+		return 2
+	case vugu.QualityMinimal:
+		return 4
+	}
+	return 1
+}
+
+// adaptQuality records the time taken by the render just done with AdaptiveQuality, and when the level
+// changes tells the components with another render.
+func (r *JSRenderer) adaptQuality() {
+	a := r.AdaptiveQuality
+	if !a.record(r.renderStats.Build + r.renderStats.Diff + r.renderStats.Flush) {
+		return
+	}
+	r.eventEnv.SetRenderQuality(a.quality)
+	if a.OnChange != nil {
+		a.OnChange(a.quality)
+	}
+	r.sendEventWaitCh()
+}
+
+// deferSubtree leaves the output of a vugu.Deferrable component at positionID as it was on the last render,
+// when AdaptiveQuality is below QualityFull, keeping what was recorded for the positions in it.  Output is
+// not deferred twice in a row, so it is at most one render behind, and another render is requested to
+// catch up.  It returns false if the output must be rendered, including if it has anything which must be
+// synced every time (see refreshSkipped).
+func (r *JSRenderer) deferSubtree(state *jsRenderState, br *vugu.BuildResults, comp interface{}, n *vugu.VGNode, positionID []byte) bool {
+
+	if r.AdaptiveQuality == nil || r.AdaptiveQuality.quality == vugu.QualityFull || n.IsTemplate() || r.creating {
+		return false
+	}
+	d, ok := comp.(vugu.Deferrable)
+	if !ok {
+		return false
+	}
+	key := string(positionID)
+	if state.prevDeferred[key] {
+		return false
+	}
+	if _, ok := state.prevHashMap[key]; !ok {
+		return false // nothing was recorded for it last time to keep
+	}
+	if !d.DeferRender() || mustSync(br, n) {
+		return false
+	}
+
+	for k, v := range state.prevHashMap {
+		if inSubtree(k, key) {
+			state.hashMap[k] = v
+		}
+	}
+	for k, v := range state.prevDomHandlerMap {
+		if inSubtree(k, key) {
+			state.domHandlerMap[k] = v
+		}
+	}
+	r.keepTriggers(state, positionID)
+	for k, v := range state.prevChildIDMap {
+		if inSubtree(k, key) {
+			if state.childIDMap == nil {
+				state.childIDMap = make(map[string]childIDs)
+			}
+			state.childIDMap[k] = v
+		}
+	}
+
+	if state.deferred == nil {
+		state.deferred = make(map[string]bool)
+	}
+	state.deferred[key] = true
+	return true
+}
+
+// mustSync returns true if the output under n has anything which refreshSkipped would not skip, or is
+// rendered elsewhere with vg-portal.
+func mustSync(br *vugu.BuildResults, n *vugu.VGNode) bool {
+	for n.Component != nil {
+		if n.Portal != "" {
+			return true
+		}
+		bo := br.ResultFor(n.Component)
+		if bo == nil || len(bo.Out) != 1 {
+			return true
+		}
+		n = bo.Out[0]
+	}
+	if n.Portal != "" || len(n.Prop) > 0 || n.JSCreateHandler != nil || n.JSPopulateHandler != nil || hasFormProperties(n) {
+		return true
+	}
+	for _, hs := range n.DOMEventHandlerSpecList {
+		if hs.Global != "" {
+			return true
+		}
+	}
+	if n.InnerHTML != nil {
+		return false
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if mustSync(br, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// inSubtree returns true if positionID k is parent or under it.
+func inSubtree(k, parent string) bool {
+	return strings.HasPrefix(k, parent) && (len(k) == len(parent) || k[len(parent)] == '_')
+}
@@ -0,0 +1,103 @@
+package domrender
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+func TestAdaptiveQualityRecord(t *testing.T) {
+
+	assert := assert.New(t)
+
+	a := &AdaptiveQuality{FrameBudget: 10 * time.Millisecond, Renders: 3}
+	run := func(d time.Duration, n int) (changes int) {
+		for i := 0; i < n; i++ {
+			if a.record(d) {
+				changes++
+			}
+		}
+		return
+	}
+
+	// not decided until there are enough renders
+	assert.Equal(0, run(15*time.Millisecond, 2))
+	assert.Equal(1, run(15*time.Millisecond, 1))
+	assert.Equal(vugu.QualityReduced, a.Quality())
+	assert.Equal(2, a.framesPerRender())
+
+	// measured again at the new level before changing
+	assert.Equal(1, run(25*time.Millisecond, 3))
+	assert.Equal(vugu.QualityMinimal, a.Quality())
+	assert.Equal(4, a.framesPerRender())
+
+	// back up one step when within budget, and all the way when well within it
+	assert.Equal(1, run(8*time.Millisecond, 5))
+	assert.Equal(vugu.QualityReduced, a.Quality())
+	assert.Equal(1, run(2*time.Millisecond, 5))
+	assert.Equal(vugu.QualityFull, a.Quality())
+}
+
+type deferRoot struct {
+	child *deferChild
+}
+
+func (c *deferRoot) Build(in *vugu.BuildIn) *vugu.BuildOut {
+	n := &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+	n.AppendChild(&vugu.VGNode{Component: c.child})
+	return &vugu.BuildOut{Out: []*vugu.VGNode{n}, Components: []vugu.Builder{c.child}}
+}
+
+type deferChild struct {
+	text string
+}
+
+func (c *deferChild) DeferRender() bool { return true }
+
+func (c *deferChild) Build(in *vugu.BuildIn) *vugu.BuildOut {
+	n := &vugu.VGNode{Type: vugu.ElementNode, Data: "span"}
+	n.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: c.text})
+	return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+}
+
+func TestAdaptiveQualityDefer(t *testing.T) {
+
+	assert := assert.New(t)
+
+	r, tr, buildEnv := newTestRenderer(t)
+	r.AdaptiveQuality = &AdaptiveQuality{FrameBudget: time.Hour}
+	r.AdaptiveQuality.quality = vugu.QualityReduced
+	r.FastFirstRender = true
+
+	root := &deferRoot{child: &deferChild{}}
+	for _, text := range []string{"a", "b", "c", "c", "d", "d"} {
+		root.child.text = text
+		assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	}
+
+	var texts []string
+	for _, b := range tr.Renders {
+		instructions, err := DecodeInstructions(b)
+		assert.NoError(err)
+		text := ""
+		for _, in := range instructions {
+			if in.Name == "setText" {
+				text = in.Args[0].(string)
+			}
+		}
+		texts = append(texts, text)
+	}
+	// the first render creates everything and the second records it, after that each change is a
+	// render late
+	assert.Equal([]string{"a", "b", "", "c", "", "d"}, texts)
+
+	// a render is requested to catch up
+	select {
+	case <-r.eventWaitCh:
+	default:
+		t.Errorf("no render requested")
+	}
+}
@@ -0,0 +1,128 @@
+package domrender
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vugu/vjson"
+)
+
+// AuditTrail keeps a record of the most recent events handled by a JSRenderer and errors reported by it,
+// set with JSRenderer.AuditTrail, so a bug report can say what the user did before something went wrong.
+// With it set the default error overlay has a link to download Report as a JSON file.
+type AuditTrail struct {
+	// Size is how many entries are kept, the oldest are dropped first.  100 if zero.
+	Size int
+
+	// Snapshot, if set, is called after the handlers for each event have run, with the EventEnv locked,
+	// and what it returns is stored with the event as JSON, e.g. the state of a vgstore.Store.
+	Snapshot func() interface{}
+
+	mu      sync.Mutex
+	entries []AuditEntry // ring buffer, next is the oldest once it is full
+	next    int
+}
+
+// AuditEntry is an event or error recorded by AuditTrail.
+type AuditEntry struct {
+	Time       time.Time        `json:"time"`
+	Kind       string           `json:"kind"`                  // "event" or "error"
+	EventType  string           `json:"event_type,omitempty"`  // e.g. "click"
+	PositionID string           `json:"position_id,omitempty"` // of the element the listener is on
+	Global     string           `json:"global,omitempty"`      // "window" or "document" for global listeners
+	Target     string           `json:"target,omitempty"`      // the element the event happened on, e.g. "BUTTON#save"
+	Component  string           `json:"component,omitempty"`   // the Go type of the component with the handler
+	State      vjson.RawMessage `json:"state,omitempty"`       // from Snapshot
+	Error      string           `json:"error,omitempty"`       // for errors, or if Snapshot could not be stored
+}
+
+// Entries returns the recorded entries, oldest first.
+func (a *AuditTrail) Entries() []AuditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ret := make([]AuditEntry, 0, len(a.entries))
+	ret = append(ret, a.entries[a.next:]...)
+	return append(ret, a.entries[:a.next]...)
+}
+
+// Clear removes all entries.
+func (a *AuditTrail) Clear() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries, a.next = nil, 0
+}
+
+// Report returns a JSON report of err, which may be nil, and the entries, for attaching to a bug report.
+func (a *AuditTrail) Report(err error) ([]byte, error) {
+	var report struct {
+		Time    time.Time    `json:"time"`
+		Error   string       `json:"error,omitempty"`
+		Stack   string       `json:"stack,omitempty"`
+		Entries []AuditEntry `json:"entries"`
+	}
+	report.Time = time.Now()
+	if err != nil {
+		report.Error = err.Error()
+		if pe, ok := err.(*PanicError); ok {
+			report.Stack = string(pe.Stack)
+		}
+	}
+	report.Entries = a.Entries()
+	return vjson.Marshal(report)
+}
+
+func (a *AuditTrail) record(e AuditEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	size := a.Size
+	if size <= 0 {
+		size = 100
+	}
+	if len(a.entries) < size {
+		a.entries = append(a.entries, e)
+		return
+	}
+	// Size may have been made smaller
+	if len(a.entries) > size {
+		all := append(append([]AuditEntry(nil), a.entries[a.next:]...), a.entries[:a.next]...)
+		a.entries, a.next = all[len(all)-size:], 0
+	}
+	a.entries[a.next] = e
+	a.next = (a.next + 1) % size
+}
+
+// recordEvent records an event after its handlers have run, it is called with the EventEnv locked.
+func (a *AuditTrail) recordEvent(eventType, positionID, global string, summary map[string]interface{}, comp interface{}) {
+	e := AuditEntry{Time: time.Now(), Kind: "event", EventType: eventType, PositionID: positionID, Global: global}
+	if target, ok := summary["target"].(map[string]interface{}); ok {
+		tag, _ := target["tagName"].(string)
+		id, _ := target["id"].(string)
+		e.Target = tag
+		if id != "" {
+			e.Target += "#" + id
+		}
+	}
+	if comp != nil {
+		e.Component = fmt.Sprintf("%T", comp)
+	}
+	if a.Snapshot != nil {
+		b, err := vjson.Marshal(a.Snapshot())
+		if err != nil {
+			e.Error = "snapshot: " + err.Error()
+		} else {
+			e.State = b
+		}
+	}
+	a.record(e)
+}
+
+// recordError records an error reported by the renderer, the first line of it if it has several.
+func (a *AuditTrail) recordError(err error) {
+	msg := err.Error()
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		msg = msg[:i]
+	}
+	a.record(AuditEntry{Time: time.Now(), Kind: "error", Error: msg})
+}
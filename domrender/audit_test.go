@@ -0,0 +1,86 @@
+package domrender
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vjson"
+	"github.com/vugu/vugu"
+)
+
+func TestAuditTrailRing(t *testing.T) {
+
+	assert := assert.New(t)
+
+	a := &AuditTrail{Size: 3}
+	for _, s := range []string{"a", "b", "c", "d"} {
+		a.record(AuditEntry{EventType: s})
+	}
+	types := func() (ret []string) {
+		for _, e := range a.Entries() {
+			ret = append(ret, e.EventType)
+		}
+		return ret
+	}
+	assert.Equal([]string{"b", "c", "d"}, types())
+
+	a.Size = 2
+	a.record(AuditEntry{EventType: "e"})
+	assert.Equal([]string{"d", "e"}, types())
+
+	a.Clear()
+	assert.Empty(a.Entries())
+}
+
+func TestAuditTrail(t *testing.T) {
+
+	assert := assert.New(t)
+
+	count := 0
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "button"}
+		n.DOMEventHandlerSpecList = []vugu.DOMEventHandlerSpec{
+			{EventType: "click", Func: func(vugu.DOMEvent) {
+				count++
+				if count == 2 {
+					panic("oops")
+				}
+			}},
+		}
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	r.AuditTrail = &AuditTrail{Snapshot: func() interface{} { return map[string]int{"count": count} }}
+	r.OnError = func(err error) {}
+	r.DisableErrorOverlay = true
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+
+	click := map[string]interface{}{"event_summary": map[string]interface{}{"target": map[string]interface{}{"tagName": "BUTTON", "id": "save"}}}
+	assert.NoError(tr.SendEvent(click))
+	assert.NoError(tr.SendEvent(click))
+
+	entries := r.AuditTrail.Entries()
+	assert.Len(entries, 3)
+	assert.Equal("event", entries[0].Kind)
+	assert.Equal("click", entries[0].EventType)
+	assert.Equal("BUTTON#save", entries[0].Target)
+	assert.Equal(`{"count":1}`, string(entries[0].State))
+	// the panic is reported once the EventEnv is unlocked, after the event is recorded
+	assert.Equal("event", entries[1].Kind)
+	assert.Equal(`{"count":2}`, string(entries[1].State))
+	assert.Equal("error", entries[2].Kind)
+	assert.Equal("panic during event: oops", entries[2].Error)
+
+	b, err := r.AuditTrail.Report(errors.New("something broke"))
+	assert.NoError(err)
+	var report map[string]interface{}
+	assert.NoError(vjson.Unmarshal(b, &report))
+	assert.Equal("something broke", report["error"])
+	assert.Len(report["entries"], 3)
+
+	assert.True(strings.Contains(auditReportLinkHTML(r.AuditTrail, nil), `download="vugu-report.json"`))
+}
@@ -0,0 +1,112 @@
+package domrender
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+// BudgetWarning is reported when a component takes longer than JSRenderer.RenderBudget in a render.
+type BudgetWarning struct {
+	Component interface{}   // the component
+	Path      []string      // the types of the components from the root to this one, e.g. "*main.Root"
+	Budget    time.Duration // JSRenderer.RenderBudget
+	Build     time.Duration // building the component, zero unless the BuildEnv measures it
+	Emit      time.Duration // comparing its output with the prior render and writing instructions
+}
+
+// String returns the warning as a message, e.g. for logging.
+func (w BudgetWarning) String() string {
+	return fmt.Sprintf("vugu: %s took %v to render (build=%v emit=%v), more than the budget of %v; consider vugu.Memo or splitting it into smaller components",
+		strings.Join(w.Path, " > "), w.Build+w.Emit, w.Build, w.Emit, w.Budget)
+}
+
+// budgetState is what JSRenderer.RenderBudget needs to time each component.
+type budgetState struct {
+	emit  map[interface{}]time.Duration // emit time of each component in the render in progress
+	stack []budgetFrame                 // components being emitted, innermost last
+}
+
+type budgetFrame struct {
+	comp   interface{}
+	start  time.Time
+	nested time.Duration // time spent emitting the components in its output
+}
+
+// budgetStart starts timing a render.
+func (r *JSRenderer) budgetStart() {
+	b := &r.budget
+	if b.emit == nil {
+		b.emit = make(map[interface{}]time.Duration)
+	}
+	for k := range b.emit {
+		delete(b.emit, k)
+	}
+	b.stack = b.stack[:0]
+}
+
+// budgetEnter starts timing the emit of comp's output, which lasts until the matching budgetLeave.
+func (r *JSRenderer) budgetEnter(comp interface{}) {
+	r.budget.stack = append(r.budget.stack, budgetFrame{comp: comp, start: time.Now()})
+}
+
+// budgetLeave records the emit time of the innermost component, not including the components in its output.
+func (r *JSRenderer) budgetLeave() {
+	b := &r.budget
+	f := b.stack[len(b.stack)-1]
+	b.stack = b.stack[:len(b.stack)-1]
+	d := time.Since(f.start)
+	b.emit[f.comp] += d - f.nested
+	if len(b.stack) > 0 {
+		b.stack[len(b.stack)-1].nested += d
+	}
+}
+
+// budgetDone reports each component in br whose build and emit time exceeds RenderBudget, slowest first.
+func (r *JSRenderer) budgetDone(br *vugu.BuildResults) {
+
+	totals := make(map[interface{}]BudgetWarning)
+	for c, d := range br.ComponentBuildTimes {
+		w := totals[c]
+		w.Build = d
+		totals[c] = w
+	}
+	for c, d := range r.budget.emit {
+		w := totals[c]
+		w.Emit = d
+		totals[c] = w
+	}
+
+	var list []BudgetWarning
+	for c, w := range totals {
+		if w.Build+w.Emit <= r.RenderBudget {
+			continue
+		}
+		w.Component, w.Budget = c, r.RenderBudget
+		for p := c; p != nil; {
+			w.Path = append([]string{fmt.Sprintf("%T", p)}, w.Path...)
+			b, ok := p.(vugu.Builder)
+			if !ok {
+				break
+			}
+			p = br.ComponentParent(b)
+		}
+		list = append(list, w)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Build+list[i].Emit > list[j].Build+list[j].Emit })
+
+	for _, w := range list {
+		if r.OnBudgetExceeded != nil {
+			r.OnBudgetExceeded(w)
+		} else if console := js.Global().Get("console"); console.Truthy() {
+			console.Call("warn", w.String())
+		} else {
+			log.Print(w.String())
+		}
+	}
+}
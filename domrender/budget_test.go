@@ -0,0 +1,58 @@
+package domrender
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+// budgetRoot outputs a slow component and a fast one.
+type budgetRoot struct {
+	slow, fast *budgetChild
+}
+
+func (c *budgetRoot) Build(in *vugu.BuildIn) *vugu.BuildOut {
+	n := &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+	n.AppendChild(&vugu.VGNode{Component: c.slow})
+	n.AppendChild(&vugu.VGNode{Component: c.fast})
+	return &vugu.BuildOut{Out: []*vugu.VGNode{n}, Components: []vugu.Builder{c.slow, c.fast}}
+}
+
+type budgetChild struct {
+	delay time.Duration
+}
+
+func (c *budgetChild) Build(in *vugu.BuildIn) *vugu.BuildOut {
+	time.Sleep(c.delay)
+	return &vugu.BuildOut{Out: []*vugu.VGNode{{Type: vugu.ElementNode, Data: "span"}}}
+}
+
+func TestRenderBudget(t *testing.T) {
+
+	assert := assert.New(t)
+
+	r, _, buildEnv := newTestRenderer(t)
+	var warnings []BudgetWarning
+	r.RenderBudget = 20 * time.Millisecond
+	r.OnBudgetExceeded = func(w BudgetWarning) { warnings = append(warnings, w) }
+
+	root := &budgetRoot{slow: &budgetChild{delay: 30 * time.Millisecond}, fast: &budgetChild{}}
+
+	// without build times nothing is slow to emit
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	assert.Empty(warnings)
+
+	buildEnv.SetTimeComponents(true)
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	if assert.Len(warnings, 1) {
+		w := warnings[0]
+		assert.Equal(root.slow, w.Component)
+		assert.Equal([]string{"*domrender.budgetRoot", "*domrender.budgetChild"}, w.Path)
+		assert.True(w.Build >= 30*time.Millisecond)
+		assert.Equal(r.RenderBudget, w.Budget)
+		assert.Contains(w.String(), "*domrender.budgetRoot > *domrender.budgetChild took")
+	}
+}
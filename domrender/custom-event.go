@@ -0,0 +1,23 @@
+package domrender
+
+import (
+	"github.com/vugu/vjson"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// DispatchCustomEvent dispatches a CustomEvent named eventType on target, which may be an element,
+// document or window, for scripts on the page (or Vugu components with e.g. @my-event) to listen for.
+// The event bubbles and its detail is detail encoded as JSON.  Vugu listeners receive the detail
+// in the event summary, e.g. e.Prop("detail", "id").
+//
+// The event is dispatched once the current call into Go returns, so it is safe to call this
+// from an event handler.  Requires the JS helper script, i.e. a JSRenderer must have been created.
+func DispatchCustomEvent(target js.Value, eventType string, detail interface{}) error {
+	b, err := vjson.Marshal(detail)
+	if err != nil {
+		return err
+	}
+	js.Global().Get("window").Call("vuguDispatchCustomEvent", target, eventType, string(b))
+	return nil
+}
@@ -0,0 +1,59 @@
+package domrender
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+
+	"github.com/vugu/vugu"
+)
+
+// debugOverlayState keeps the figures from the prior render so the overlay can show what changed.
+type debugOverlayState struct {
+	renderCount uint64
+	totalAlloc  uint64
+	mallocs     uint64
+	numGC       uint32
+}
+
+// updateDebugOverlay reads the Go runtime memory stats and sends them to the JS helper,
+// which shows them along with the JS heap size (where the browser provides it) in an
+// overlay in the corner of the page.  Allocation figures are deltas since the prior render,
+// so they cover the events, builds and rendering in between.  A steadily increasing heap,
+// or number of components or handler positions, usually points to a leak.
+func (r *JSRenderer) updateDebugOverlay(bo *vugu.BuildOut) {
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	st := &r.debugOverlayState
+	st.renderCount++
+
+	var buf bytes.Buffer
+	line := func(label string, v uint64) {
+		buf.WriteString(label)
+		buf.WriteString(": ")
+		buf.WriteString(strconv.FormatUint(v, 10))
+		buf.WriteString("\n")
+	}
+
+	line("render", st.renderCount)
+	line("go heap alloc", ms.HeapAlloc)
+	line("go heap sys", ms.HeapSys)
+	if st.renderCount > 1 {
+		line("go bytes allocated since last render", ms.TotalAlloc-st.totalAlloc)
+		line("go mallocs since last render", ms.Mallocs-st.mallocs)
+		line("go gc cycles since last render", uint64(ms.NumGC-st.numGC))
+	}
+	line("components", uint64(len(bo.Components)))
+	if r.jsRenderState != nil {
+		line("handler positions", uint64(len(r.jsRenderState.domHandlerMap)))
+		line("handlers", uint64(len(r.Handlers())))
+	}
+
+	st.totalAlloc = ms.TotalAlloc
+	st.mallocs = ms.Mallocs
+	st.numGC = ms.NumGC
+
+	r.transport.Call("vuguDebugOverlay", buf.String())
+}
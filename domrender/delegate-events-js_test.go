@@ -0,0 +1,99 @@
+// +build js
+
+package domrender
+
+// See mount-point-js_test.go for how to run these.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+func TestDelegateEventsDOM(t *testing.T) {
+
+	assert := assert.New(t)
+
+	defer withFakeDOM()()
+	doc := js.Global().Get("document")
+
+	// each renderer has a ul with two li, and for #app a div for another renderer to mount in
+	var clicks []string
+	click := func(name string) []vugu.DOMEventHandlerSpec {
+		return []vugu.DOMEventHandlerSpec{{EventType: "click", Func: func(vugu.DOMEvent) { clicks = append(clicks, name) }}}
+	}
+	render := func(selector string) *JSRenderer {
+		r, err := New(selector)
+		assert.NoError(err)
+		r.DelegateEvents = true
+		buildEnv, err := vugu.NewBuildEnv(r.EventEnv())
+		assert.NoError(err)
+		assert.NoError(r.Render(buildEnv.RunBuild(vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+			ul := &vugu.VGNode{Type: vugu.ElementNode, Data: "ul"}
+			ul.DOMEventHandlerSpecList = click(selector + " ul")
+			for _, name := range []string{"li0", "li1"} {
+				li := &vugu.VGNode{Type: vugu.ElementNode, Data: "li"}
+				li.DOMEventHandlerSpecList = click(selector + " " + name)
+				ul.AppendChild(li)
+			}
+			if selector == "#app" {
+				ul.AppendChild(&vugu.VGNode{Type: vugu.ElementNode, Data: "div", Attr: []vugu.VGAttribute{{Key: "id", Val: "inner"}}})
+			}
+			return &vugu.BuildOut{Out: []*vugu.VGNode{ul}}
+		}))))
+		return r
+	}
+	app, other := render("#app"), render("#other")
+	defer app.Release()
+	inner := render("#inner")
+	defer inner.Release()
+
+	// the root elements replace the mount points
+	appUl := doc.Get("body").Get("childNodes").Index(0)
+	otherUl := doc.Get("body").Get("childNodes").Index(1)
+	innerUl := appUl.Get("lastChild")
+
+	// the click listeners are on each mount point, none on the document or the elements
+	clickListeners := func(el js.Value) (n int) {
+		ls := el.Get("listeners")
+		for i := 0; i < ls.Length(); i++ {
+			if ls.Index(i).Get("type").String() == "click" {
+				n++
+			}
+		}
+		return n
+	}
+	assert.Equal(0, clickListeners(doc))
+	for _, ul := range []js.Value{appUl, otherUl, innerUl} {
+		assert.Equal(1, clickListeners(ul))
+		assert.Equal(0, clickListeners(ul.Get("firstChild")))
+	}
+
+	dispatch := func(el js.Value) []string {
+		clicks = nil
+		el.Call("dispatch", "click")
+		return clicks
+	}
+	assert.Equal([]string{"#app li1", "#app ul"}, dispatch(appUl.Get("childNodes").Index(1)))
+	assert.Equal([]string{"#other li0", "#other ul"}, dispatch(otherUl.Get("firstChild")))
+
+	// the renderer mounted inside another handles its own elements, the outer one only its own as the
+	// event bubbles on
+	assert.Equal([]string{"#inner li0", "#inner ul", "#app ul"}, dispatch(innerUl.Get("firstChild")))
+
+	// a listener of the element's own which stops propagation keeps the handlers from being called
+	stop := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		args[0].Call("stopPropagation")
+		return nil
+	})
+	defer stop.Release()
+	otherUl.Get("firstChild").Call("addEventListener", "click", stop)
+	assert.Empty(dispatch(otherUl.Get("firstChild")))
+
+	// the listeners go with the renderer
+	other.Release()
+	assert.Equal(0, clickListeners(otherUl))
+}
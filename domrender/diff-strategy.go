@@ -0,0 +1,141 @@
+package domrender
+
+import "github.com/vugu/vugu"
+
+// DiffStrategy decides which parts of the output are compared with the DOM on each render and which
+// are left as they were, trading CPU spent in Go against the number of instructions sent to the browser.
+// It is set per renderer with JSRenderer.DiffStrategy, so different strategies can be measured on the
+// same app (see JSRenderer.OnRenderStats).  A strategy can also implement ChildMover to move the DOM
+// nodes of reordered children, otherwise children are synced in place by position.
+type DiffStrategy interface {
+	// SkipNode reports whether the node can be left untouched because it is the same as the last render.
+	// It is not called for new nodes or templates.  Returning true is only a request, the renderer still
+	// syncs subtrees with things which must be synced every time (e.g. form element values).
+	SkipNode(d *DiffNode) bool
+}
+
+// DiffNode is a node being rendered, passed to DiffStrategy.SkipNode.
+type DiffNode struct {
+	state      *jsRenderState
+	br         *vugu.BuildResults
+	n          *vugu.VGNode
+	positionID []byte
+}
+
+// Node returns the node, with any components already resolved to the node they output.
+func (d *DiffNode) Node() *vugu.VGNode { return d.n }
+
+// PositionID returns the position of the node in the output, which stays the same from one render to
+// the next for the same part of the DOM.
+func (d *DiffNode) PositionID() []byte { return d.positionID }
+
+// Hash returns the hash of the node and everything under it (see vugu.BuildResults.NodeHash), and
+// records it for comparison by PrevHash on the next render.  It is only computed if called, so
+// PrevHash returns false next time for positions where it was not.
+func (d *DiffNode) Hash() uint64 {
+	h := d.br.NodeHash(d.n)
+	d.state.hashMap[string(d.positionID)] = h
+	return h
+}
+
+// PrevHash returns the hash recorded for this position on the last render, if any.
+func (d *DiffNode) PrevHash() (uint64, bool) {
+	h, ok := d.state.prevHashMap[string(d.positionID)]
+	return h, ok
+}
+
+// ChildMover is implemented by a DiffStrategy which keeps the DOM nodes of children with keys (see
+// vugu.VGNode.Key, set with vg-key) when they are reordered, moving them to their new positions rather
+// than syncing each position with whatever node was there.  This keeps the state the browser holds
+// for them, such as focus, text selection and CSS transitions, and there is less to sync.  Children
+// without a key keep their position.  The children of an element are only moved if none of them are
+// templates and no two have the same key.
+type ChildMover interface {
+	// KeepChildren is passed the position each child had on the last render, in their new order, or -1
+	// for new children, and returns which stay where they are, the others are moved.  The positions of
+	// those kept must increase, any which do not are moved as well.
+	KeepChildren(prevIndex []int) []bool
+}
+
+var (
+	// FullDiff compares every node with the DOM on every render.  It spends no time hashing but
+	// sends the instructions for the whole output each time.
+	FullDiff DiffStrategy = fullDiff{}
+
+	// HashDiff skips subtrees whose hash is the same as on the last render, sending a single
+	// instruction for each.  This is the default.
+	HashDiff DiffStrategy = hashDiff{}
+
+	// KeyedDiff is HashDiff which also moves the DOM nodes of keyed children when they are reordered
+	// (see ChildMover).  It keeps the longest run of children which are still in the same order and
+	// moves the rest, which is the fewest moves possible.  The children which end up in a different
+	// position are synced in full, as their hashes were recorded for their old one.
+	KeyedDiff DiffStrategy = keyedDiff{}
+)
+
+type fullDiff struct{}
+
+func (fullDiff) SkipNode(d *DiffNode) bool { return false }
+
+type hashDiff struct{}
+
+func (hashDiff) SkipNode(d *DiffNode) bool {
+	h := d.Hash()
+	prevh, ok := d.PrevHash()
+	return ok && prevh == h
+}
+
+type keyedDiff struct{ hashDiff }
+
+func (keyedDiff) KeepChildren(prevIndex []int) []bool {
+	return longestIncreasing(prevIndex)
+}
+
+// longestIncreasing returns which elements of l are in its longest strictly increasing subsequence,
+// ignoring negative ones.  It takes O(n log n) time.
+func longestIncreasing(l []int) []bool {
+
+	// tails[k] is the index in l of the smallest last element of an increasing subsequence of length k+1
+	// found so far, and prev links each element to the one before it in its subsequence
+	tails := make([]int, 0, len(l))
+	prev := make([]int, len(l))
+	for i, v := range l {
+		if v < 0 {
+			continue
+		}
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if l[tails[mid]] < v {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		prev[i] = -1
+		if lo > 0 {
+			prev[i] = tails[lo-1]
+		}
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+
+	ret := make([]bool, len(l))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			ret[i] = true
+		}
+	}
+	return ret
+}
+
+// diffStrategy returns the DiffStrategy to use.
+func (r *JSRenderer) diffStrategy() DiffStrategy {
+	if r.DiffStrategy == nil {
+		return HashDiff
+	}
+	return r.DiffStrategy
+}
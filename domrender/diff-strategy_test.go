@@ -0,0 +1,192 @@
+package domrender
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+func TestDiffStrategy(t *testing.T) {
+
+	assert := assert.New(t)
+
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "ul"}
+		for _, s := range []string{"a", "b", "c"} {
+			li := &vugu.VGNode{Type: vugu.ElementNode, Data: "li"}
+			li.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: s})
+			n.AppendChild(li)
+		}
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	})
+
+	// counts returns the number of instructions in each of three renders of the same output
+	counts := func(ds DiffStrategy) []int {
+		r, tr, buildEnv := newTestRenderer(t)
+		r.DiffStrategy = ds
+		r.FastFirstRender = true
+		for i := 0; i < 3; i++ {
+			assert.NoError(r.Render(buildEnv.RunBuild(root)))
+		}
+		var ret []int
+		for _, b := range tr.Renders {
+			instructions, err := DecodeInstructions(b)
+			assert.NoError(err)
+			ret = append(ret, len(instructions))
+		}
+		return ret
+	}
+
+	full, hash := counts(FullDiff), counts(HashDiff)
+	assert.Len(full, 3)
+	assert.Equal(full[1], full[2])
+	// the first render creates the output without hashing it, so skipping starts with the third
+	assert.Less(hash[2], full[2])
+	assert.Equal(hash, counts(nil))
+
+	// a custom strategy sees each node below the mount point and can use the hashes itself
+	var seen []string
+	counts(skipFunc(func(d *DiffNode) bool {
+		seen = append(seen, d.Node().Data)
+		h := d.Hash()
+		prevh, ok := d.PrevHash()
+		return ok && prevh == h
+	}))
+	assert.Equal([]string{"li", "a", "li", "b", "li", "c", "li", "li", "li"}, seen)
+}
+
+type skipFunc func(d *DiffNode) bool
+
+func (f skipFunc) SkipNode(d *DiffNode) bool { return f(d) }
+
+func TestLongestIncreasing(t *testing.T) {
+
+	assert := assert.New(t)
+
+	assert.Equal([]bool{}, longestIncreasing([]int{}))
+	assert.Equal([]bool{true, true, true}, longestIncreasing([]int{0, 1, 2}))
+	assert.Equal([]bool{false, true, true}, longestIncreasing([]int{2, 0, 1}))
+	assert.Equal([]bool{false, true, false, true}, longestIncreasing([]int{-1, 0, -1, 1}))
+
+	kept := longestIncreasing([]int{5, 1, 6, 2, 3, 0, 4})
+	var seq []int
+	for i, k := range kept {
+		if k {
+			seq = append(seq, []int{5, 1, 6, 2, 3, 0, 4}[i])
+		}
+	}
+	assert.Equal([]int{1, 2, 3, 4}, seq)
+}
+
+func TestKeyedDiff(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var keys []string
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "ul"}
+		n.AppendChild(&vugu.VGNode{Type: vugu.ElementNode, Data: "li", Attr: []vugu.VGAttribute{{Key: "class", Val: "header"}}})
+		for _, k := range keys {
+			li := &vugu.VGNode{Type: vugu.ElementNode, Data: "li", Key: k}
+			li.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: k})
+			n.AppendChild(li)
+		}
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	r.DiffStrategy = KeyedDiff
+
+	// dom is the order of the children of the ul, as moved by the instructions of each render, with
+	// "" for placeholders
+	dom := []string{"header"}
+	var d Decoder
+	var skips int
+	render := func(next ...string) (moves int, forgot []string) {
+		keys = next
+		assert.NoError(r.Render(buildEnv.RunBuild(root)))
+		instructions, err := d.Decode(tr.Renders[len(tr.Renders)-1])
+		assert.NoError(err)
+		skips = 0
+		for _, in := range instructions {
+			switch in.Name {
+			case "skipNode":
+				skips++
+			case "removeChild":
+				i := int(in.Args[0].(uint32))
+				dom = append(dom[:i], dom[i+1:]...)
+			case "moveChild":
+				moves++
+				from, before := in.Args[0].(uint32), int(in.Args[1].(uint32))
+				node := ""
+				if from != newChild {
+					node = dom[from]
+					dom = append(dom[:from], dom[from+1:]...)
+					if int(from) < before {
+						before--
+					}
+				}
+				dom = append(dom[:before], append([]string{node}, dom[before:]...)...)
+			case "forgetSubtree":
+				forgot = append(forgot, in.Args[0].(string))
+			}
+		}
+		// what syncing by position does with placeholders and the ones at the end
+		for i := range dom {
+			if i > 0 && i <= len(next) && dom[i] == "" {
+				dom[i] = next[i-1]
+			}
+		}
+		for len(dom) < len(next)+1 {
+			dom = append(dom, next[len(dom)-1])
+		}
+		return moves, forgot
+	}
+
+	render("a", "b", "c", "d")
+	assert.Equal([]string{"header", "a", "b", "c", "d"}, dom)
+
+	// moving the last to the front is a single move, the others keep their nodes but are synced again
+	// in their new position, with their old one's listeners dropped
+	moves, forgot := render("d", "a", "b", "c")
+	assert.Equal([]string{"header", "d", "a", "b", "c"}, dom)
+	assert.Equal(1, moves)
+	assert.Equal([]string{"0_2", "0_3", "0_4", "0_5"}, forgot)
+	assert.Equal(1, skips, "only the header is skipped")
+
+	// unchanged, nothing moves
+	moves, forgot = render("d", "a", "b", "c")
+	assert.Equal(0, moves)
+	assert.Empty(forgot)
+	assert.Equal(5, skips, "all the items are skipped")
+
+	// removed, added and swapped at once
+	moves, _ = render("c", "e", "a", "d")
+	assert.Equal([]string{"header", "c", "e", "a", "d"}, dom)
+	assert.Equal(3, moves)
+
+	// reversed
+	render("d", "a", "e", "c")
+	assert.Equal([]string{"header", "d", "a", "e", "c"}, dom)
+
+	// any change ends up with the nodes in the right order
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		var next []string
+		for _, k := range rnd.Perm(12)[:rnd.Intn(12)] {
+			next = append(next, strconv.Itoa(k))
+		}
+		render(next...)
+		assert.Equal(append([]string{"header"}, next...), dom[:len(next)+1])
+		dom = dom[:len(next)+1] // syncing by position removes the rest
+	}
+
+	// without keys nothing is moved
+	moves, forgot = render()
+	assert.Equal(0, moves)
+	assert.Empty(forgot)
+}
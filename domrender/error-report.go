@@ -0,0 +1,80 @@
+package domrender
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// PanicError is reported when a panic is recovered from an event handler or during Render.
+type PanicError struct {
+	Value interface{} // value passed to panic
+	Stack []byte      // stack trace of the panic, empty if not available (e.g. in TinyGo)
+	Phase string      // "event" or "render"
+}
+
+// Error implements error.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic during %s: %v", e.Phase, e.Value)
+}
+
+// reportError passes err to OnError, or logs it to the console if OnError is nil, and shows the error overlay.
+func (r *JSRenderer) reportError(err error) {
+
+	if r.AuditTrail != nil {
+		r.AuditTrail.recordError(err)
+	}
+
+	if r.OnError != nil {
+		r.OnError(err)
+	} else {
+		msg := err.Error()
+		if pe, ok := err.(*PanicError); ok && len(pe.Stack) > 0 {
+			msg += "\n" + string(pe.Stack)
+		}
+		if console := js.Global().Get("console"); console.Truthy() {
+			console.Call("error", msg)
+		} else {
+			// not in a browser, e.g. rendering from a server
+			log.Print(msg)
+		}
+	}
+
+	if r.DisableErrorOverlay {
+		return
+	}
+
+	var h string
+	if r.ErrorOverlay != nil {
+		h = r.ErrorOverlay(err)
+	} else {
+		h = defaultErrorOverlayHTML(err)
+		if r.AuditTrail != nil {
+			h += auditReportLinkHTML(r.AuditTrail, err)
+		}
+	}
+	r.transport.Call("vuguErrorOverlay", h)
+}
+
+func defaultErrorOverlayHTML(err error) string {
+	h := "<strong>Error:</strong> " + html.EscapeString(err.Error())
+	if pe, ok := err.(*PanicError); ok && len(pe.Stack) > 0 {
+		h += "<pre style=\"white-space:pre-wrap;margin:8px 0 0 0\">" + html.EscapeString(string(pe.Stack)) + "</pre>"
+	}
+	h += "<div style=\"margin-top:8px;font-size:11px\">(click to dismiss)</div>"
+	return h
+}
+
+// auditReportLinkHTML returns a link to download the AuditTrail report for err.
+func auditReportLinkHTML(a *AuditTrail, err error) string {
+	b, rerr := a.Report(err)
+	if rerr != nil {
+		return ""
+	}
+	href := "data:application/json;charset=utf-8," + url.PathEscape(string(b))
+	return "<div style=\"margin-top:8px;font-size:11px\"><a download=\"vugu-report.json\" style=\"color:#fff\" href=\"" +
+		html.EscapeString(href) + "\" onclick=\"event.stopPropagation()\">Download report</a></div>"
+}
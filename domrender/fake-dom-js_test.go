@@ -0,0 +1,116 @@
+// +build js
+
+package domrender
+
+// See mount-point-js_test.go for how to run these.
+
+import (
+	js "github.com/vugu/vugu/js"
+)
+
+// fakeDOMScript sets up a document with just enough of the DOM for the helper script to render into
+// #app and #other (selectors are only ever an id), and for its listeners to be called with dispatch,
+// which bubbles the event from the element up to the document.  The value property of an input works as in a browser.
+const fakeDOMScript = `(function () {
+	class Node {
+		constructor(nodeType, nodeName) {
+			this.nodeType = nodeType;
+			this.nodeName = nodeName;
+			this.namespaceURI = nodeType === 1 ? "http://www.w3.org/1999/xhtml" : null;
+			this.childNodes = [];
+			this.parentNode = null;
+			this.attrs = {};
+			this.listeners = [];
+			this.style = {};
+			this.nodeValue = "";
+		}
+		get tagName() { return this.nodeName; }
+		get firstChild() { return this.childNodes[0] || null; }
+		get lastChild() { return this.childNodes[this.childNodes.length - 1] || null; }
+		get nextSibling() { return this.sibling(1); }
+		get previousSibling() { return this.sibling(-1); }
+		sibling(d) { return this.parentNode ? this.parentNode.childNodes[this.parentNode.childNodes.indexOf(this) + d] || null : null; }
+		get isConnected() { return this === document || (!!this.parentNode && this.parentNode.isConnected); }
+		get data() { return this.nodeValue; }
+		set data(v) { this.nodeValue = v; }
+		get textContent() { return this.nodeType === 1 ? this.childNodes.map(c => c.textContent).join("") : this.nodeValue; }
+		set textContent(v) { this.childNodes = []; this.appendChild(document.createTextNode(v)); }
+		get attributes() {
+			let attrs = Object.keys(this.attrs).map(k => ({ name: k, value: this.attrs[k] }));
+			attrs.removeNamedItem = k => this.removeAttribute(k);
+			return attrs;
+		}
+		get type() { return (this.attrs.type || "text").toLowerCase(); }
+		// the value of a checkbox (or hidden, button...) input is its attribute, any other's is its own once set
+		get valueIsAttr() { return /^(checkbox|radio|hidden|submit|reset|button|image)$/.test(this.type); }
+		get value() { return !this.valueIsAttr && "ownValue" in this ? this.ownValue : this.attrs.value || ""; }
+		set value(v) { if (this.valueIsAttr) { this.attrs.value = String(v); } else { this.ownValue = String(v); } }
+		hasChildNodes() { return this.childNodes.length > 0; }
+		getRootNode() { return document; }
+		contains(n) { for (; n; n = n.parentNode) { if (n === this) { return true; } } return false; }
+		insertBefore(n, ref) {
+			if (n.parentNode) { n.parentNode.removeChild(n); }
+			let i = ref ? this.childNodes.indexOf(ref) : this.childNodes.length;
+			this.childNodes.splice(i, 0, n);
+			n.parentNode = this;
+			return n;
+		}
+		appendChild(n) { return this.insertBefore(n, null); }
+		removeChild(n) { this.childNodes.splice(this.childNodes.indexOf(n), 1); n.parentNode = null; return n; }
+		replaceChild(n, old) { this.insertBefore(n, old); return this.removeChild(old); }
+		remove() { if (this.parentNode) { this.parentNode.removeChild(this); } }
+		setAttribute(k, v) { this.attrs[k] = String(v); }
+		getAttribute(k) { return k in this.attrs ? this.attrs[k] : null; }
+		hasAttribute(k) { return k in this.attrs; }
+		removeAttribute(k) { delete this.attrs[k]; }
+		addEventListener(type, f) { if (!this.listeners.some(l => l.type === type && l.f === f)) { this.listeners.push({ type: type, f: f }); } }
+		removeEventListener(type, f) { this.listeners = this.listeners.filter(l => !(l.type === type && l.f === f)); }
+		dispatch(type) {
+			let path = [];
+			for (let n = this; n; n = n.parentNode) { path.push(n); }
+			let stopped = false;
+			let event = { type: type, target: this, currentTarget: null, bubbles: true,
+				preventDefault() {}, stopPropagation() { stopped = true; }, composedPath: () => path };
+			for (let n of path) {
+				event.currentTarget = n;
+				for (let l of n.listeners.slice()) { if (l.type === type) { l.f(event); } }
+				if (stopped) { break; }
+			}
+		}
+		find(id) {
+			if (this.attrs.id === id) { return this; }
+			for (let c of this.childNodes) { let n = c.find(id); if (n) { return n; } }
+			return null;
+		}
+	}
+	let document = new Node(9, "#document");
+	document.documentElement = document.appendChild(new Node(1, "HTML"));
+	document.head = document.documentElement.appendChild(new Node(1, "HEAD"));
+	document.body = document.documentElement.appendChild(new Node(1, "BODY"));
+	for (let id of ["app", "other"]) {
+		document.body.appendChild(new Node(1, "DIV")).attrs.id = id;
+	}
+	document.readyState = "complete";
+	document.activeElement = document.body;
+	document.createElement = function (name) { return new Node(1, name.toUpperCase()); };
+	document.createElementNS = function (ns, name) { let n = new Node(1, name.toUpperCase()); n.namespaceURI = ns; return n; };
+	document.createTextNode = function (v) { let n = new Node(3, "#text"); n.nodeValue = v; return n; };
+	document.createComment = function (v) { let n = new Node(8, "#comment"); n.nodeValue = v; return n; };
+	document.getElementById = function (id) { return document.find(id); };
+	document.querySelector = function (selector) { return document.find(selector.slice(1)); };
+	document.querySelectorAll = function (selector) { let n = document.querySelector(selector); return n ? [n] : []; };
+	globalThis.document = document;
+	globalThis.window = globalThis;
+})()`
+
+// withFakeDOM puts a document made with fakeDOMScript on the global object and returns a function
+// which removes it.
+func withFakeDOM() func() {
+	g := js.Global()
+	g.Call("eval", fakeDOMScript)
+	return func() {
+		for _, name := range []string{"document", "window"} {
+			g.Get("Reflect").Call("deleteProperty", g, name)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// +build js
+
+package domrender
+
+// See mount-point-js_test.go for how to run these.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+func TestRemoveValueDOM(t *testing.T) {
+
+	assert := assert.New(t)
+
+	defer withFakeDOM()()
+
+	r, err := New("#app")
+	if !assert.NoError(err) {
+		return
+	}
+	defer r.Release()
+	buildEnv, err := vugu.NewBuildEnv(r.EventEnv())
+	assert.NoError(err)
+
+	// a text input and a checkbox, with a value attribute on each if withValue
+	withValue := true
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+		for _, typ := range []string{"text", "checkbox"} {
+			input := &vugu.VGNode{Type: vugu.ElementNode, Data: "input", Attr: []vugu.VGAttribute{{Key: "type", Val: typ}}}
+			if withValue {
+				input.Attr = append(input.Attr, vugu.VGAttribute{Key: "value", Val: "v"})
+			}
+			n.AppendChild(input)
+		}
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	})
+	input := func(i int) js.Value {
+		return js.Global().Get("document").Get("body").Get("firstChild").Get("childNodes").Index(i) // the root element replaces #app
+	}
+
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	text, checkbox := input(0), input(1)
+	assert.Equal("v", text.Get("value").String())
+	assert.Equal("v", checkbox.Call("getAttribute", "value").String())
+	text.Set("value", "typed")
+
+	// the text input is cleared, what the user typed would otherwise remain; the checkbox's value is
+	// its attribute, which stays removed
+	withValue = false
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	assert.Equal("", text.Get("value").String())
+	assert.False(text.Call("hasAttribute", "value").Bool())
+	assert.False(checkbox.Call("hasAttribute", "value").Bool())
+}
@@ -0,0 +1,57 @@
+package domrender
+
+import (
+	"sort"
+	"time"
+
+	"github.com/vugu/vugu"
+)
+
+// HandlerInfo describes an event handler registered by the renderer, see JSRenderer.Handlers.
+type HandlerInfo struct {
+	PositionID string // the position of the element, as in the instructions sent to the helper script
+	EventType  string
+	Capture    bool
+	Passive    bool
+	Modifiers  vugu.DOMEventModifiers
+	Global     string // "window" or "document" if the listener is on that instead of the element
+	Debounce   time.Duration
+	Throttle   time.Duration
+	Component  interface{} // the component whose output the element is in
+}
+
+// Handlers returns the event handlers registered as of the last render, ordered by position ID, e.g. to
+// check in tests that handlers are removed along with their elements.  It must not be called at the
+// same time as Render or event handling, e.g. from another goroutine without the EventEnv locked.
+func (r *JSRenderer) Handlers() []HandlerInfo {
+
+	if r.jsRenderState == nil {
+		return nil
+	}
+
+	m := r.jsRenderState.domHandlerMap
+	positionIDs := make([]string, 0, len(m))
+	for k := range m {
+		positionIDs = append(positionIDs, k)
+	}
+	sort.Strings(positionIDs)
+
+	var ret []HandlerInfo
+	for _, k := range positionIDs {
+		hs := m[k]
+		for _, spec := range hs.specs {
+			ret = append(ret, HandlerInfo{
+				PositionID: k,
+				EventType:  spec.EventType,
+				Capture:    spec.Capture,
+				Passive:    spec.Passive,
+				Modifiers:  spec.Modifiers,
+				Global:     spec.Global,
+				Debounce:   spec.Debounce,
+				Throttle:   spec.Throttle,
+				Component:  hs.comp,
+			})
+		}
+	}
+	return ret
+}
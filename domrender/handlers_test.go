@@ -0,0 +1,91 @@
+package domrender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+// handlersRoot has a click handler and optionally a child with one of its own.
+type handlersRoot struct {
+	child *handlersChild
+	show  bool
+}
+
+func (c *handlersRoot) Build(in *vugu.BuildIn) *vugu.BuildOut {
+	n := &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+	n.DOMEventHandlerSpecList = []vugu.DOMEventHandlerSpec{{EventType: "click", Func: func(vugu.DOMEvent) {}}}
+	out := &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	if c.show {
+		n.AppendChild(&vugu.VGNode{Component: c.child})
+		out.Components = append(out.Components, c.child)
+	}
+	return out
+}
+
+type handlersChild struct{}
+
+func (c *handlersChild) Build(in *vugu.BuildIn) *vugu.BuildOut {
+	n := &vugu.VGNode{Type: vugu.ElementNode, Data: "button"}
+	n.DOMEventHandlerSpecList = []vugu.DOMEventHandlerSpec{{EventType: "keydown", Func: func(vugu.DOMEvent) {}, Passive: true}}
+	return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+}
+
+func TestHandlers(t *testing.T) {
+
+	assert := assert.New(t)
+
+	r, tr, buildEnv := newTestRenderer(t)
+	assert.Empty(r.Handlers())
+
+	root := &handlersRoot{child: &handlersChild{}, show: true}
+	want := []HandlerInfo{
+		{PositionID: "0", EventType: "click", Component: root},
+		{PositionID: "0_1", EventType: "keydown", Passive: true, Component: root.child},
+	}
+
+	// the same whether synced or skipped as unchanged
+	for i := 0; i < 3; i++ {
+		assert.NoError(r.Render(buildEnv.RunBuild(root)))
+		assert.Equal(want, r.Handlers())
+	}
+
+	// the child's handler goes with it, and the helper script is told to forget it
+	root.show = false
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	assert.Equal(want[:1], r.Handlers())
+	instructions, err := DecodeInstructions(tr.Renders[len(tr.Renders)-1])
+	assert.NoError(err)
+	forgot := false
+	for _, in := range instructions {
+		if in.Name == "forgetPosition" {
+			forgot = true
+		}
+	}
+	assert.True(forgot)
+}
+
+func TestSweepPositions(t *testing.T) {
+
+	assert := assert.New(t)
+
+	r, tr, buildEnv := newTestRenderer(t)
+
+	// the helper script is told to sweep once every sweepInterval renders, at the end
+	root := &handlersRoot{child: &handlersChild{}, show: true}
+	var swept []int
+	for i := 1; i <= sweepInterval*2; i++ {
+		assert.NoError(r.Render(buildEnv.RunBuild(root)))
+		instructions, err := DecodeInstructions(tr.Renders[len(tr.Renders)-1])
+		assert.NoError(err)
+		for j, in := range instructions {
+			if in.Name == "sweepPositions" {
+				swept = append(swept, i)
+				assert.Equal("end", instructions[j+1].Name)
+			}
+		}
+	}
+	assert.Equal([]int{sweepInterval, sweepInterval * 2}, swept)
+}
@@ -0,0 +1,87 @@
+// +build js
+
+package domrender
+
+// See mount-point-js_test.go for how to run these.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+// keyedList renders a ul with an li with a click handler for each of keys.
+type keyedList struct {
+	keys    []string
+	clicked string
+}
+
+func (c *keyedList) Build(in *vugu.BuildIn) *vugu.BuildOut {
+	n := &vugu.VGNode{Type: vugu.ElementNode, Data: "ul"}
+	for _, k := range c.keys {
+		k := k
+		li := &vugu.VGNode{Type: vugu.ElementNode, Data: "li", Key: k}
+		li.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: k})
+		li.DOMEventHandlerSpecList = []vugu.DOMEventHandlerSpec{{EventType: "click", Func: func(vugu.DOMEvent) { c.clicked = k }}}
+		n.AppendChild(li)
+	}
+	return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+}
+
+func TestKeyedDiffDOM(t *testing.T) {
+
+	assert := assert.New(t)
+
+	defer withFakeDOM()()
+	g := js.Global()
+
+	r, err := New("#app")
+	if !assert.NoError(err) {
+		return
+	}
+	defer r.Release()
+	r.DiffStrategy = KeyedDiff
+	buildEnv, err := vugu.NewBuildEnv(r.EventEnv())
+	assert.NoError(err)
+
+	root := &keyedList{}
+	ul := func() js.Value { return g.Get("document").Get("body").Get("firstChild") } // the root element replaces #app
+	items := func() (texts []string) {
+		kids := ul().Get("childNodes")
+		for i := 0; i < kids.Length(); i++ {
+			texts = append(texts, kids.Index(i).Get("textContent").String())
+		}
+		return texts
+	}
+
+	// each li is marked with its key, the mark stays with the same node as they are reordered
+	root.keys = []string{"a", "b", "c", "d"}
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	assert.Equal(root.keys, items())
+	kids := ul().Get("childNodes")
+	for i := 0; i < kids.Length(); i++ {
+		kids.Index(i).Set("mark", root.keys[i])
+	}
+
+	for _, keys := range [][]string{{"d", "a", "b", "c"}, {"c", "e", "a", "d"}, {"d", "a", "e", "c"}} {
+		root.keys = keys
+		assert.NoError(r.Render(buildEnv.RunBuild(root)))
+		assert.Equal(keys, items())
+		kids := ul().Get("childNodes")
+		for i := 0; i < kids.Length(); i++ {
+			if mark := kids.Index(i).Get("mark"); mark.Truthy() {
+				assert.Equal(keys[i], mark.String(), "the node of %s", keys[i])
+			}
+			kids.Index(i).Set("mark", keys[i])
+
+			// each has one listener, for the handler of its own key
+			assert.Equal(1, kids.Index(i).Get("listeners").Length())
+			kids.Index(i).Call("dispatch", "click")
+			r.EventWait()
+			assert.Equal(keys[i], root.clicked)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package domrender
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/vugu/vugu"
+)
+
+// childIDs are the identities of the children of an element, see childIdentities.
+type childIDs struct {
+	el  string // the element's namespace and name, if it is replaced by another its children are gone
+	ids []string
+}
+
+// childIdentities returns what identifies each child of n from one render to the next: its key, or for
+// children without one its position.  It returns false if no child has a key, or the children cannot be
+// moved as DOM nodes, see ChildMover.
+func childIdentities(br *vugu.BuildResults, n *vugu.VGNode) ([]string, bool) {
+
+	var ids []string
+	keyed := false
+	seen := make(map[string]bool)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+
+		key, out := c.Key, c
+		for out.Component != nil && out.Portal == "" {
+			bo := br.ResultFor(out.Component)
+			if bo == nil || len(bo.Out) != 1 {
+				return nil, false
+			}
+			out = bo.Out[0]
+			if key == "" {
+				key = out.Key
+			}
+		}
+		if out.IsTemplate() {
+			return nil, false
+		}
+
+		if key == "" {
+			ids = append(ids, "#"+strconv.Itoa(len(ids)))
+			continue
+		}
+		id := "=" + key
+		if seen[id] {
+			return nil, false
+		}
+		seen[id] = true
+		keyed = true
+		ids = append(ids, id)
+	}
+
+	return ids, keyed
+}
+
+// reorderChildren moves the DOM nodes of the children of the current element n to the order of its keyed
+// children, if the DiffStrategy is a ChildMover, so syncing the children by position which follows
+// finds the node for each child in its place.  Children which are new get a placeholder and those which
+// went away are removed.
+func (r *JSRenderer) reorderChildren(state *jsRenderState, br *vugu.BuildResults, n *vugu.VGNode, positionID []byte) error {
+
+	mover, ok := r.diffStrategy().(ChildMover)
+	if !ok || r.creating {
+		return nil
+	}
+	ids, ok := childIdentities(br, n)
+	if !ok {
+		return nil
+	}
+	el := n.Namespace + ":" + n.Data
+	if state.childIDMap == nil {
+		state.childIDMap = make(map[string]childIDs)
+	}
+	state.childIDMap[string(positionID)] = childIDs{el: el, ids: ids}
+
+	p, ok := state.prevChildIDMap[string(positionID)]
+	if !ok || p.el != el || equalStrings(p.ids, ids) {
+		return nil
+	}
+	prev := p.ids
+
+	prevIndex := make(map[string]int, len(prev))
+	for i, id := range prev {
+		prevIndex[id] = i
+	}
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	// the children at a position which now holds a different one drop their listeners, which are for
+	// that position, and whatever now holds it is synced in full rather than compared with their hashes
+	childPositionID := func(i int) []byte {
+		return append(positionID, []byte(fmt.Sprintf("_%d", i+1))...)
+	}
+	changed := make(map[string]bool)
+	for i := 0; i < len(prev) || i < len(ids); i++ {
+		if i < len(prev) && i < len(ids) && prev[i] == ids[i] {
+			continue
+		}
+		pid := childPositionID(i)
+		changed[string(pid)] = true
+		if i < len(prev) {
+			err := r.instructionList.writeForgetSubtree(pid)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for k := range state.prevHashMap {
+		if k != string(positionID) && inSubtree(k, string(positionID)) {
+			if changed[childPosition(k, len(positionID))] {
+				delete(state.prevHashMap, k)
+			}
+		}
+	}
+
+	// remove the children which went away, from the end so the indexes of the others stay the same
+	var cur []string
+	for i := len(prev) - 1; i >= 0; i-- {
+		if wanted[prev[i]] {
+			continue
+		}
+		err := r.instructionList.writeRemoveChild(uint32(i))
+		if err != nil {
+			return err
+		}
+	}
+	for _, id := range prev {
+		if wanted[id] {
+			cur = append(cur, id)
+		}
+	}
+
+	prevIdx := make([]int, len(ids))
+	for i, id := range ids {
+		prevIdx[i] = -1
+		if j, ok := prevIndex[id]; ok {
+			prevIdx[i] = j
+		}
+	}
+	keep := mover.KeepChildren(prevIdx)
+	if len(keep) != len(ids) {
+		return fmt.Errorf("DiffStrategy %T KeepChildren returned %d values for %d children", mover, len(keep), len(ids))
+	}
+	last := -1
+	for i, j := range prevIdx {
+		if keep[i] && j > last {
+			last = j
+		} else {
+			keep[i] = false
+		}
+	}
+
+	// from the end, each child which is not kept is put in front of the one after it, which is in
+	// place by then, so the children kept never move
+	indexOf := func(id string) int {
+		for i, v := range cur {
+			if v == id {
+				return i
+			}
+		}
+		return -1
+	}
+	for i := len(ids) - 1; i >= 0; i-- {
+		if keep[i] {
+			continue
+		}
+		before := len(cur)
+		if i+1 < len(ids) {
+			before = indexOf(ids[i+1])
+		}
+		from, at := uint32(newChild), before
+		if prevIdx[i] >= 0 {
+			f := indexOf(ids[i])
+			from = uint32(f)
+			cur = append(cur[:f], cur[f+1:]...)
+			if f < before {
+				at--
+			}
+		}
+		err := r.instructionList.writeMoveChild(from, uint32(before))
+		if err != nil {
+			return err
+		}
+		cur = append(cur[:at], append([]string{ids[i]}, cur[at:]...)...)
+	}
+
+	return nil
+}
+
+// keepChildIDs keeps the identities of the children recorded at positionID last render, for an element
+// which is skipped.
+func (r *JSRenderer) keepChildIDs(state *jsRenderState, positionID []byte) {
+	ids, ok := state.prevChildIDMap[string(positionID)]
+	if !ok {
+		return
+	}
+	if state.childIDMap == nil {
+		state.childIDMap = make(map[string]childIDs)
+	}
+	state.childIDMap[string(positionID)] = ids
+}
+
+// childPosition returns the position of the child of the position with length l which k is in, k must be
+// under it.
+func childPosition(k string, l int) string {
+	for i := l + 1; i < len(k); i++ {
+		if k[i] == '_' {
+			return k[:i]
+		}
+	}
+	return k
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
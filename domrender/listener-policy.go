@@ -0,0 +1,92 @@
+package domrender
+
+import (
+	"fmt"
+
+	"github.com/vugu/vugu"
+)
+
+// ListenerPolicy sets the options of event listeners by event type, so they do not have to be given with
+// each handler, see JSRenderer.ListenerPolicy.
+type ListenerPolicy struct {
+	// Passive event types get passive listeners, which tell the browser it can scroll without waiting for
+	// the event to be handled.  Handlers with the prevent modifier (e.g. @touchmove.prevent) are left as
+	// they are, as calling preventDefault has no effect on a passive listener.
+	Passive []string
+
+	// Capture event types get capturing listeners, so the handlers of an element are called before those
+	// of the elements inside it.
+	Capture []string
+}
+
+// DefaultListenerPolicy returns a ListenerPolicy which makes the listeners for scrolling and touch passive:
+// scroll, wheel, touchstart and touchmove.  Browsers warn about listeners for these which are not,
+// as the page cannot scroll until they have been called.
+func DefaultListenerPolicy() *ListenerPolicy {
+	return &ListenerPolicy{Passive: []string{"scroll", "wheel", "touchstart", "touchmove"}}
+}
+
+// apply returns specs with the options of the policy set, specs itself if none change.
+func (p *ListenerPolicy) apply(specs []vugu.DOMEventHandlerSpec) []vugu.DOMEventHandlerSpec {
+	var ret []vugu.DOMEventHandlerSpec
+	for i, hs := range specs {
+		passive := hs.Passive || (hs.Modifiers&vugu.DOMEventModPrevent == 0 && hasString(p.Passive, hs.EventType))
+		capture := hs.Capture || hasString(p.Capture, hs.EventType)
+		if passive == hs.Passive && capture == hs.Capture {
+			continue
+		}
+		if ret == nil {
+			ret = append(make([]vugu.DOMEventHandlerSpec, 0, len(specs)), specs...)
+		}
+		ret[i].Passive, ret[i].Capture = passive, capture
+	}
+	if ret == nil {
+		return specs
+	}
+	return ret
+}
+
+func hasString(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// listenerSpecs returns the handler specs of n with ListenerPolicy applied, as its listeners are set,
+// or an error if they cannot be (see checkListeners).
+func (r *JSRenderer) listenerSpecs(n *vugu.VGNode) ([]vugu.DOMEventHandlerSpec, error) {
+	specs := n.DOMEventHandlerSpecList
+	if r.ListenerPolicy != nil {
+		specs = r.ListenerPolicy.apply(specs)
+	}
+	if err := checkListeners(n, specs); err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// checkListeners returns an error for a handler in specs which asks for something its listener cannot
+// do, which is to prevent the default action of a passive listener's event.
+func checkListeners(n *vugu.VGNode, specs []vugu.DOMEventHandlerSpec) error {
+	for _, hs := range specs {
+		if hs.Passive && hs.Modifiers&vugu.DOMEventModPrevent != 0 {
+			return fmt.Errorf("the %q handler on <%s> has the prevent modifier but is passive, which the browser ignores preventDefault for", hs.EventType, n.Data)
+		}
+	}
+	return nil
+}
+
+// passiveEvent is the DOMEvent passed to the handlers of a passive listener, calling PreventDefault is
+// reported as an error instead of silently having no effect.
+type passiveEvent struct {
+	vugu.DOMEvent
+	prevented bool
+}
+
+// PreventDefault implements vugu.DOMEvent.
+func (e *passiveEvent) PreventDefault() {
+	e.prevented = true
+}
@@ -0,0 +1,157 @@
+// +build js
+
+package domrender
+
+// These tests need a JS environment, e.g. node with:
+//
+//	GOOS=js GOARCH=wasm go test -exec="$(go env GOROOT)/lib/wasm/go_js_wasm_exec" ./domrender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+// fakeDocumentScript sets up just enough of a page for the mount point checks: querySelectorAll
+// matches the selectors in byId and throws for anything starting with "]", as the browser does for
+// invalid selectors.
+const fakeDocumentScript = `(function () {
+	let el = function (id, inHead, children) {
+		return {
+			nodeType: 1, id: id, inHead: inHead, childNodes: children || [], attrs: {},
+			hasChildNodes: function () { return this.childNodes.length > 0; },
+			setAttribute: function (k, v) { this.attrs[k] = v; },
+		};
+	};
+	let byId = {
+		"#app": [el("app")],
+		"#full": [el("full", false, ["server rendered"])],
+		".item": [el("a"), el("b")],
+		"#title": [el("title", true)],
+		"#shadow-full": [Object.assign(el("shadow-full"), { shadowRoot: el("root", false, ["server rendered"]) })],
+	};
+	let find = function (selector) {
+		if (selector.startsWith("]")) {
+			throw new SyntaxError("'" + selector + "' is not a valid selector");
+		}
+		return byId[selector] || [];
+	};
+	globalThis.document = {
+		readyState: "complete",
+		head: { contains: function (e) { return !!e.inHead; } },
+		querySelectorAll: find,
+		querySelector: function (selector) { return find(selector)[0] || null; },
+		addEventListener: function () {},
+	};
+	globalThis.window = globalThis;
+	globalThis.fakeElement = el;
+})()`
+
+// withFakeDocument runs f with a fake document on the global object, which is removed after.
+func withFakeDocument(t *testing.T, f func()) {
+	g := js.Global()
+	g.Call("eval", fakeDocumentScript)
+	defer func() {
+		for _, name := range []string{"document", "window", "fakeElement", "vuguMountPoints"} {
+			g.Get("Reflect").Call("deleteProperty", g, name)
+		}
+	}()
+	f()
+}
+
+func TestCheckMountPoint(t *testing.T) {
+
+	assert := assert.New(t)
+
+	withFakeDocument(t, func() {
+
+		assert.NoError(checkMountPoint("#app"))
+		assert.NoError(checkMountPoint(""))
+
+		err := checkMountPoint("#missing")
+		if assert.Error(err) {
+			assert.Contains(err.Error(), "does not match any element")
+		}
+
+		err = checkMountPoint(".item")
+		if assert.Error(err) {
+			assert.Contains(err.Error(), "matches 2 elements")
+		}
+
+		err = checkMountPoint("#title")
+		if assert.Error(err) {
+			assert.Contains(err.Error(), "inside <head>")
+		}
+
+		err = checkMountPoint("][not a selector")
+		if assert.Error(err) {
+			assert.Contains(err.Error(), "is not valid")
+		}
+
+		// a missing element may still be added while the page loads
+		js.Global().Get("document").Set("readyState", "loading")
+		assert.NoError(checkMountPoint("#missing"))
+		js.Global().Get("document").Set("readyState", "complete")
+
+		// a registered element is found without a selector matching it
+		el := js.Global().Call("fakeElement", "detached")
+		selector := registerMountPoint(el)
+		assert.Equal(`[data-vugu-mount-point="`+el.Get("attrs").Get(mountPointAttr).String()+`"]`, selector)
+		assert.NoError(checkMountPoint(selector))
+		unregisterMountPoint(selector)
+		assert.False(mountPoints().Get(selector).Truthy())
+		assert.Error(checkMountPoint(selector))
+
+		// NewForElement registers the element until the renderer is released
+		el = js.Global().Call("fakeElement", "shadowed")
+		r, err := NewForElement(el)
+		if assert.NoError(err) {
+			assert.Equal("shadowed", mountPoints().Get(r.MountPointSelector).Get("id").String())
+			r.Release()
+			assert.False(mountPoints().Get(r.MountPointSelector).Truthy())
+		}
+	})
+}
+
+func TestMountPointEmpty(t *testing.T) {
+
+	assert := assert.New(t)
+
+	withFakeDocument(t, func() {
+
+		assert.True(mountPointEmpty("#app", false))
+		assert.False(mountPointEmpty("#full", false))
+		assert.False(mountPointEmpty("#missing", false))
+		assert.False(mountPointEmpty("][not a selector", false))
+		assert.True(mountPointEmpty("#full", true), "the shadow root is not there yet")
+		assert.False(mountPointEmpty("#shadow-full", true))
+
+		// FastFirstRender leaves the content of a mount point which is not empty to be synced
+		root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+			n := &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+			n.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: "text"})
+			return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+		})
+		for selector, want := range map[string]int{"#app": 1, "#full": 0} {
+			tr := &CaptureTransport{}
+			r, err := NewWithTransport(selector, tr)
+			assert.NoError(err)
+			r.FastFirstRender = true
+			buildEnv, err := vugu.NewBuildEnv(r.EventEnv())
+			assert.NoError(err)
+			assert.NoError(r.Render(buildEnv.RunBuild(root)))
+			instructions, err := DecodeInstructions(tr.Renders[0])
+			assert.NoError(err)
+			n := 0
+			for _, in := range instructions {
+				if in.Name == "setInnerHTML" {
+					n++
+				}
+			}
+			assert.Equal(want, n, selector)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package domrender
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// mountPointAttr is set on elements passed to NewForElement, with a unique value which is used
+// as the renderer's MountPointSelector.
+const mountPointAttr = "data-vugu-mount-point"
+
+var lastMountPointID int64
+
+// NewForElement is like New but renders into the element el instead of one found by a selector,
+// which also works for elements that a selector cannot reach, such as those inside a shadow root.
+// The element is given a data-vugu-mount-point attribute which is used as the MountPointSelector.
+func NewForElement(el js.Value) (*JSRenderer, error) {
+
+	if !el.Truthy() || el.Get("nodeType").Int() != 1 {
+		return nil, errors.New("mount point is not an element")
+	}
+
+	selector := registerMountPoint(el)
+	r, err := New(selector)
+	if err != nil {
+		unregisterMountPoint(selector)
+		return nil, err
+	}
+	r.mountPointRegistered = true
+	return r, nil
+}
+
+// registerMountPoint gives el a unique data-vugu-mount-point attribute and adds it to the helper
+// script's map of mount points, returning the selector for it.
+func registerMountPoint(el js.Value) string {
+	id := strconv.FormatInt(atomic.AddInt64(&lastMountPointID, 1), 10)
+	el.Call("setAttribute", mountPointAttr, id)
+	selector := fmt.Sprintf("[%s=%q]", mountPointAttr, id)
+	mountPoints().Set(selector, el)
+	return selector
+}
+
+// unregisterMountPoint removes the element registered for selector, see registerMountPoint.
+func unregisterMountPoint(selector string) {
+	js.Global().Get("Reflect").Call("deleteProperty", mountPoints(), selector)
+}
+
+// mountPoints returns the helper script's map of mount point selectors to elements, see NewForElement.
+func mountPoints() js.Value {
+	m := js.Global().Get("vuguMountPoints")
+	if !m.Truthy() {
+		m = js.Global().Get("Object").New()
+		js.Global().Set("vuguMountPoints", m)
+	}
+	return m
+}
+
+// checkMountPoint returns a descriptive error if selector is not valid, does not match exactly one
+// element or matches one inside the document's head, so mistakes show up when the renderer is created
+// rather than part way through the first render.  An empty selector (the whole page) is fine, and
+// nothing is checked where there is no document, e.g. in a Web Worker.
+func checkMountPoint(selector string) (err error) {
+
+	if selector == "" {
+		return nil
+	}
+
+	doc := js.Global().Get("document")
+	if !doc.Truthy() {
+		return nil
+	}
+
+	el := js.Undefined()
+	if m := js.Global().Get("vuguMountPoints"); m.Truthy() {
+		el = m.Get(selector)
+	}
+
+	if !el.Truthy() {
+
+		var list js.Value
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					err = fmt.Errorf("mount point selector %q is not valid: %v", selector, p)
+				}
+			}()
+			list = doc.Call("querySelectorAll", selector)
+		}()
+		if err != nil {
+			return err
+		}
+
+		switch n := list.Length(); {
+		case n == 0:
+			// the page may still be loading, in which case it can show up before the first render
+			if doc.Get("readyState").String() == "loading" {
+				return nil
+			}
+			return fmt.Errorf("mount point selector %q does not match any element", selector)
+		case n > 1:
+			return fmt.Errorf("mount point selector %q matches %d elements, it must match exactly one", selector, n)
+		}
+		el = list.Index(0)
+	}
+
+	if head := doc.Get("head"); head.Truthy() && head.Call("contains", el).Bool() {
+		return fmt.Errorf("mount point selector %q matches an element inside <head>", selector)
+	}
+
+	return nil
+}
+
+// mountPointEmpty reports whether the mount point matching selector has no child nodes, or with shadow
+// its shadow root, see JSRenderer.FastFirstRender.  Where there is no document to look in, e.g. in a
+// Web Worker, it is true.  It is false if the mount point is not found, so the render finds it anyway
+// and reports the error.
+func mountPointEmpty(selector string, shadow bool) (empty bool) {
+
+	doc := js.Global().Get("document")
+	if !doc.Truthy() {
+		return true
+	}
+
+	el := js.Undefined()
+	if m := js.Global().Get("vuguMountPoints"); m.Truthy() {
+		el = m.Get(selector)
+	}
+	if !el.Truthy() && selector != "" {
+		defer func() {
+			if recover() != nil {
+				empty = false
+			}
+		}()
+		el = doc.Call("querySelector", selector)
+	}
+	if !el.Truthy() {
+		return false
+	}
+
+	if shadow {
+		// the shadow root is attached by the first render if it is not there
+		sr := el.Get("shadowRoot")
+		if !sr.Truthy() {
+			return true
+		}
+		el = sr
+	}
+
+	return !el.Call("hasChildNodes").Bool()
+}
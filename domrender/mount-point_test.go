@@ -0,0 +1,25 @@
+package domrender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	js "github.com/vugu/vugu/js"
+)
+
+func TestMountPoint(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// without a document there is nothing to check against
+	assert.NoError(checkMountPoint(""))
+	assert.NoError(checkMountPoint("#app"))
+	assert.NoError(checkMountPoint("][not a selector"))
+	assert.True(mountPointEmpty("#app", false), "it is up to the program")
+
+	_, err := NewForElement(js.Undefined())
+	assert.Error(err)
+	_, err = NewForElement(js.Null())
+	assert.Error(err)
+}
@@ -0,0 +1,137 @@
+package domrender
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vugu/vugu"
+)
+
+// RenderError is returned by Render when something goes wrong, with where in the output it happened
+// so it can be tracked down.  Use errors.As to get it, and Unwrap (or errors.Is) for the underlying error.
+type RenderError struct {
+	Err        error        // what went wrong
+	Component  string       // type of the component whose output Node is in, e.g. "*main.Root", empty if not known
+	Node       *vugu.VGNode // node being rendered, nil if not known
+	Path       string       // nodes from the mount point to Node, e.g. "div > ul > li > #text"
+	PositionID string       // position ID of Node
+	Opcode     string       // name of the instruction being written (see Instruction.Name), empty if none
+
+	top *vugu.VGNode // last node added to Path
+}
+
+// Error implements error.
+func (e *RenderError) Error() string {
+	var ctx []string
+	if e.Component != "" {
+		ctx = append(ctx, "component "+e.Component)
+	}
+	if e.Path != "" {
+		ctx = append(ctx, "at "+e.Path)
+	}
+	if e.PositionID != "" {
+		ctx = append(ctx, fmt.Sprintf("position %q", e.PositionID))
+	}
+	if e.Opcode != "" {
+		ctx = append(ctx, "instruction "+e.Opcode)
+	}
+	if len(ctx) == 0 {
+		return e.Err.Error()
+	}
+	return e.Err.Error() + " (" + strings.Join(ctx, ", ") + ")"
+}
+
+// Unwrap returns Err.
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+// instructionError is an error writing an instruction, so the opcode can go in the RenderError.
+type instructionError struct {
+	op  uint8
+	err error
+}
+
+func opError(op uint8, err error) error {
+	return &instructionError{op: op, err: err}
+}
+
+func (e *instructionError) Error() string {
+	return opcodeInfoMap[e.op].name + ": " + e.err.Error()
+}
+
+func (e *instructionError) Unwrap() error {
+	return e.err
+}
+
+// newRenderError returns err as a *RenderError, with the opcode if it is from writing an instruction.
+func newRenderError(err error) *RenderError {
+	if re, ok := err.(*RenderError); ok {
+		return re
+	}
+	re := &RenderError{Err: err}
+	if ie, ok := err.(*instructionError); ok {
+		re.Err = ie.err
+		re.Opcode = opcodeInfoMap[ie.op].name
+	}
+	return re
+}
+
+// wrapNodeError adds n, which err happened in or below, to the RenderError for err.  Called for each
+// node on the way back up the tree, so the first call sets the node and each one adds to the path.
+// comp is the component whose output n is, if any.
+func wrapNodeError(err error, comp interface{}, n *vugu.VGNode, positionID []byte) error {
+
+	re := newRenderError(err)
+	if re.top == n {
+		return re
+	}
+	re.top = n
+	if re.Node == nil {
+		re.Node = n
+		re.PositionID = string(positionID)
+	}
+	if re.Component == "" && comp != nil {
+		re.Component = fmt.Sprintf("%T", comp)
+	}
+
+	var name string
+	switch {
+	case n.IsTemplate():
+	case n.Type == vugu.ElementNode:
+		name = n.Data
+	case n.Type == vugu.TextNode:
+		name = "#text"
+	case n.Type == vugu.CommentNode:
+		name = "#comment"
+	default:
+		name = fmt.Sprintf("#%d", n.Type)
+	}
+	if name != "" {
+		if re.Path != "" {
+			re.Path = name + " > " + re.Path
+		} else {
+			re.Path = name
+		}
+	}
+
+	return re
+}
+
+// renderError returns the error from render as a *RenderError, with root as the component if it
+// did not happen inside another one.  A ProtocolVersionError is returned as is.
+func renderError(err error, root vugu.Builder) error {
+	if err == nil {
+		return nil
+	}
+	var pve *ProtocolVersionError
+	if errors.As(err, &pve) {
+		return pve
+	}
+	re := newRenderError(err)
+	if re.Component == "" && root != nil {
+		re.Component = fmt.Sprintf("%T", root)
+	}
+	return re
+}
@@ -0,0 +1,78 @@
+package domrender
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+type errTestList struct {
+	bad bool
+}
+
+func (c *errTestList) Build(in *vugu.BuildIn) *vugu.BuildOut {
+	ul := &vugu.VGNode{Type: vugu.ElementNode, Data: "ul"}
+	li := &vugu.VGNode{Type: vugu.ElementNode, Data: "li"}
+	ul.AppendChild(li)
+	if c.bad {
+		li.AppendChild(&vugu.VGNode{Type: vugu.VGNodeType(99)})
+	} else {
+		li.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: "text"})
+	}
+	return &vugu.BuildOut{Out: []*vugu.VGNode{ul}}
+}
+
+type errTestRoot struct {
+	list errTestList
+}
+
+func (c *errTestRoot) Build(in *vugu.BuildIn) *vugu.BuildOut {
+	div := &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+	div.AppendChild(&vugu.VGNode{Component: &c.list})
+	return &vugu.BuildOut{Out: []*vugu.VGNode{div}, Components: []vugu.Builder{&c.list}}
+}
+
+// failingTransport fails to render
+type failingTransport struct {
+	CaptureTransport
+}
+
+var errTestTransport = errors.New("transport failed")
+
+func (t *failingTransport) Render(buf []byte) error { return errTestTransport }
+
+func TestRenderError(t *testing.T) {
+
+	assert := assert.New(t)
+
+	root := &errTestRoot{list: errTestList{bad: true}}
+
+	r, _, buildEnv := newTestRenderer(t)
+
+	err := r.Render(buildEnv.RunBuild(root))
+	var re *RenderError
+	assert.True(errors.As(err, &re))
+	assert.Equal("unknown node type: 99", re.Err.Error())
+	assert.Equal("*domrender.errTestList", re.Component)
+	assert.Equal("div > ul > li > #99", re.Path)
+	assert.Equal(vugu.VGNodeType(99), re.Node.Type)
+	assert.NotEmpty(re.PositionID)
+	assert.Empty(re.Opcode)
+	assert.Contains(err.Error(), "component *domrender.errTestList, at div > ul > li > #99")
+
+	// errors writing instructions have the opcode
+	r, err = NewWithTransport("#app", &failingTransport{})
+	assert.NoError(err)
+	r.FlushWatermark = 1
+	buildEnv, err = vugu.NewBuildEnv(r.EventEnv())
+	assert.NoError(err)
+	root.list.bad = false
+	err = r.Render(buildEnv.RunBuild(root))
+	assert.True(errors.Is(err, errTestTransport))
+	assert.True(errors.As(err, &re))
+	assert.NotEmpty(re.Opcode)
+	assert.Equal("*domrender.errTestRoot", re.Component)
+}
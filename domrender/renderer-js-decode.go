@@ -0,0 +1,249 @@
+package domrender
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Instruction is a decoded instruction from a buffer sent to the JS helper script, see DecodeInstructions.
+type Instruction struct {
+	Opcode uint8
+	Name   string        // e.g. "setAttrStr"
+	Args   []interface{} // string, uint8 or uint32 values, in the order they are encoded
+}
+
+// String returns the instruction in a human readable form, e.g. setAttrStr("id", "main").
+func (in Instruction) String() string {
+	var sb strings.Builder
+	sb.WriteString(in.Name)
+	sb.WriteString("(")
+	for i, a := range in.Args {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		if s, ok := a.(string); ok {
+			fmt.Fprintf(&sb, "%q", s)
+		} else {
+			fmt.Fprint(&sb, a)
+		}
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// opcodeInfo describes how an instruction is encoded.  Each character of args is an argument:
+// s is a string, b a uint8 and w a uint32.  L is a uint8 count followed by that many strings,
+// and P a uint32 count followed by that many pairs of strings.
+type opcodeInfo struct {
+	name string
+	args string
+}
+
+var opcodeInfoMap = map[uint8]opcodeInfo{
+	opcodeEnd:                             {"end", ""},
+	opcodeClearEl:                         {"clearEl", ""},
+	opcodeRemoveOtherAttrs:                {"removeOtherAttrs", ""},
+	opcodeSetAttrStr:                      {"setAttrStr", "ss"},
+	opcodeSelectMountPoint:                {"selectMountPoint", "ss"},
+	opcodeMoveToFirstChild:                {"moveToFirstChild", ""},
+	opcodeSetElement:                      {"setElement", "s"},
+	opcodeSetText:                         {"setText", "s"},
+	opcodeSetComment:                      {"setComment", "s"},
+	opcodeMoveToParent:                    {"moveToParent", ""},
+	opcodeMoveToNextSibling:               {"moveToNextSibling", ""},
+	opcodeRemoveOtherEventListeners:       {"removeOtherEventListeners", "s"},
+	opcodeSetEventListener:                {"setEventListener", "ssbbw"},
+	opcodeSetInnerHTML:                    {"setInnerHTML", "s"},
+	opcodeSetCSSTag:                       {"setCSSTag", "ssL"},
+	opcodeRemoveOtherCSSTags:              {"removeOtherCSSTags", ""},
+	opcodeSetProperty:                     {"setProperty", "ss"},
+	opcodeSelectQuery:                     {"selectQuery", "s"},
+	opcodeBufferInnerHTML:                 {"bufferInnerHTML", "s"},
+	opcodeSetAttrNSStr:                    {"setAttrNSStr", "sss"},
+	opcodeSetElementNS:                    {"setElementNS", "ss"},
+	opcodeCallback:                        {"callback", "w"},
+	opcodeCallbackLastElement:             {"callbackLastElement", "w"},
+	opcodeSkipNode:                        {"skipNode", ""},
+	opcodeSetGlobalEventListener:          {"setGlobalEventListener", "sssbbw"},
+	opcodeRemoveOtherGlobalEventListeners: {"removeOtherGlobalEventListeners", ""},
+	opcodeForgetPosition:                  {"forgetPosition", "s"},
+	opcodeSelectMountPointContainer:       {"selectMountPointContainer", "s"},
+	opcodeSetPropertyStr:                  {"setPropertyStr", "ss"},
+	opcodeSetPropertyBool:                 {"setPropertyBool", "sb"},
+	opcodeSetHidden:                       {"setHidden", ""},
+	opcodeSetClassList:                    {"setClassList", "s"},
+	opcodeSetStyle:                        {"setStyle", "sP"},
+	opcodeSelectPortal:                    {"selectPortal", "s"},
+	opcodeRemoveOtherPortals:              {"removeOtherPortals", ""},
+	opcodeInternString:                    {"internString", "ws"},
+	opcodeProtocolVersion:                 {"protocolVersion", "w"},
+	opcodeSelectShadowRoot:                {"selectShadowRoot", "ss"},
+	opcodeSetStaticInnerHTML:              {"setStaticInnerHTML", "s"},
+	opcodeSetScrollKey:                    {"setScrollKey", "s"},
+	opcodeFocus:                           {"focus", ""},
+	opcodeScrollIntoView:                  {"scrollIntoView", ""},
+	opcodeWriteClipboard:                  {"writeClipboard", "s"},
+	opcodeFocusRef:                        {"focusRef", "s"},
+	opcodeSetSelectionRef:                 {"setSelectionRef", "sww"},
+	opcodeSetAttrBool:                     {"setAttrBool", "sb"},
+	opcodeSetDelegatedEventListener:       {"setDelegatedEventListener", "ssbw"},
+	opcodeSetEventRate:                    {"setEventRate", "bw"},
+	opcodeSweepPositions:                  {"sweepPositions", ""},
+	opcodeForgetSubtree:                   {"forgetSubtree", "s"},
+	opcodeMoveChild:                       {"moveChild", "ww"},
+	opcodeRemoveChild:                     {"removeChild", "w"},
+}
+
+// Decoder decodes a series of instruction buffers, such as a recording, keeping track of the strings
+// interned by one buffer and referred to by later ones.  References to strings interned before the first
+// buffer decoded (e.g. by buffers a RingRecorder no longer has) are shown as "<interned N>".
+type Decoder struct {
+	strings map[uint32]string
+}
+
+// DecodeInstructions decodes a buffer of instructions as sent to the JS helper script (e.g. recorded with
+// a Recorder), up to and including the end instruction.  If the buffer cannot be decoded the instructions
+// before the problem are returned with the error.  Use a Decoder to decode several buffers in order.
+func DecodeInstructions(buf []byte) ([]Instruction, error) {
+	return (&Decoder{}).Decode(buf)
+}
+
+// Decode decodes the next buffer, see DecodeInstructions.
+func (d *Decoder) Decode(buf []byte) ([]Instruction, error) {
+
+	var ret []Instruction
+	pos := 0
+
+	readUint32 := func() (uint32, error) {
+		if pos+4 > len(buf) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		v := binary.BigEndian.Uint32(buf[pos:])
+		pos += 4
+		return v, nil
+	}
+	readUint8 := func() (uint8, error) {
+		if pos >= len(buf) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		pos++
+		return buf[pos-1], nil
+	}
+	readString := func() (string, error) {
+		l, err := readUint32()
+		if err != nil {
+			return "", err
+		}
+		if l&internFlag != 0 {
+			if s, ok := d.strings[l&^internFlag]; ok {
+				return s, nil
+			}
+			return fmt.Sprintf("<interned %d>", l&^internFlag), nil
+		}
+		if uint64(pos)+uint64(l) > uint64(len(buf)) {
+			return "", io.ErrUnexpectedEOF
+		}
+		pos += int(l)
+		return string(buf[pos-int(l) : pos]), nil
+	}
+
+	for pos < len(buf) {
+
+		start := pos
+		op := buf[pos]
+		pos++
+		info, ok := opcodeInfoMap[op]
+		if !ok {
+			return ret, fmt.Errorf("unknown opcode %d at offset %d", op, start)
+		}
+
+		in := Instruction{Opcode: op, Name: info.name}
+		for _, a := range info.args {
+			var err error
+			switch a {
+			case 's':
+				var s string
+				s, err = readString()
+				in.Args = append(in.Args, s)
+			case 'b':
+				var b uint8
+				b, err = readUint8()
+				in.Args = append(in.Args, b)
+			case 'w':
+				var w uint32
+				w, err = readUint32()
+				in.Args = append(in.Args, w)
+			case 'L':
+				var n uint8
+				n, err = readUint8()
+				for i := 0; err == nil && i < int(n); i++ {
+					var s string
+					s, err = readString()
+					in.Args = append(in.Args, s)
+				}
+			case 'P':
+				var n uint32
+				n, err = readUint32()
+				for i := uint32(0); err == nil && i < n*2; i++ {
+					var s string
+					s, err = readString()
+					in.Args = append(in.Args, s)
+				}
+			}
+			if err != nil {
+				return ret, fmt.Errorf("decoding %s at offset %d: %w", info.name, start, err)
+			}
+		}
+
+		if op == opcodeInternString {
+			if d.strings == nil {
+				d.strings = make(map[uint32]string)
+			}
+			d.strings[in.Args[0].(uint32)] = in.Args[1].(string)
+		}
+
+		ret = append(ret, in)
+		if op == opcodeEnd {
+			break
+		}
+	}
+
+	return ret, nil
+}
+
+// FormatInstructions writes the instructions in buf to w, one per line, indented to show which element
+// is being worked on.  Decoding errors are written to w as well as returned.
+func FormatInstructions(w io.Writer, buf []byte) error {
+	return (&Decoder{}).Format(w, buf)
+}
+
+// Format writes the next buffer like FormatInstructions.
+func (d *Decoder) Format(w io.Writer, buf []byte) error {
+
+	instructions, decodeErr := d.Decode(buf)
+
+	depth := 0
+	for _, in := range instructions {
+		switch in.Opcode {
+		case opcodeMoveToParent:
+			if depth > 0 {
+				depth--
+			}
+		case opcodeSelectMountPoint, opcodeSelectMountPointContainer, opcodeSelectShadowRoot, opcodeSelectPortal, opcodeSelectQuery, opcodeClearEl:
+			depth = 0
+		}
+		if _, err := fmt.Fprintf(w, "%s%s\n", strings.Repeat("  ", depth), in); err != nil {
+			return err
+		}
+		if in.Opcode == opcodeMoveToFirstChild {
+			depth++
+		}
+	}
+
+	if decodeErr != nil {
+		fmt.Fprintf(w, "error: %v\n", decodeErr)
+	}
+	return decodeErr
+}
@@ -3,7 +3,6 @@
 package domrender
 
 import (
-	"fmt"
 	"runtime/debug"
 
 	"github.com/vugu/vugu"
@@ -11,33 +10,46 @@ import (
 
 func (r *JSRenderer) sendEventWaitCh() {
 
-	if panicr := recover(); panicr != nil {
-		fmt.Println("handleRawDOMEvent caught panic", panicr)
-		debug.PrintStack()
-
-		// in error case send false to tell event loop to exit
-		select {
-		case r.eventWaitCh <- false:
-		default:
-		}
-		return
-
-	}
-
-	// in normal case send true to the channel to tell the event loop it should render
+	// send true to the channel to tell the event loop it should render
 	select {
 	case r.eventWaitCh <- true:
 	default:
 	}
 }
 
+// invokeEventHandler calls f, recovering from any panic and returning it as a *PanicError.
+func (r *JSRenderer) invokeEventHandler(f func(vugu.DOMEvent), e vugu.DOMEvent) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack(), Phase: "event"}
+		}
+	}()
+	f(e)
+	return nil
+}
+
 // Render is a render function.
-func (r *JSRenderer) Render(buildResults *vugu.BuildResults) error {
+// A panic during rendering is recovered and reported (see OnError) and Render returns nil,
+// so the render loop keeps running.
+func (r *JSRenderer) Render(buildResults *vugu.BuildResults) (err error) {
+
+	if r.Strict && r.released {
+		panic("domrender strict: Render called after Release")
+	}
 
 	// acquire read lock so events are not changing data while Render is in progress
 	r.eventRWMU.RLock()
 	defer r.eventRWMU.RUnlock()
 
-	err := r.render(buildResults)
-	return err
+	defer func() {
+		if v := recover(); v != nil {
+			// drop whatever was partially written, the next render starts over
+			r.instructionList.discard()
+			r.reportError(&PanicError{Value: v, Stack: debug.Stack(), Phase: "render"})
+			err = nil
+		}
+	}()
+
+	err = r.render(buildResults)
+	return renderError(err, buildResults.Root)
 }
@@ -0,0 +1,31 @@
+// +build !js
+
+package domrender
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HelperScriptHandler serves HelperScript, so pages with a Content-Security-Policy not allowing eval can
+// load it as a static script before the program starts:
+//
+//	mux.Handle("/vugu-helper.js", domrender.HelperScriptHandler())
+//
+//	<script src="/vugu-helper.js"></script>
+//
+// and the program renders with:
+//
+//	renderer, err := domrender.NewWithTransport("#vugu_mount_point", &domrender.DirectTransport{ExternalScript: true})
+//
+// The program and the server must be built from the same version of this package, which the renderer
+// checks when it starts.  The version is the response's ETag.  Only available on the server.
+func HelperScriptHandler() http.Handler {
+	etag := `"` + jsHelperScriptVersion + `"`
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(jsHelperScript))
+	})
+}
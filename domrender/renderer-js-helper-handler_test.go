@@ -0,0 +1,40 @@
+// +build !js
+
+package domrender
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHelperScriptHandler(t *testing.T) {
+
+	if !strings.Contains(HelperScript(), `vuguHelperVersion="`+HelperScriptVersion()+`"`) {
+		t.Fatalf("helper script does not set its version %s", HelperScriptVersion())
+	}
+
+	h := HelperScriptHandler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/vugu-helper.js", nil))
+	if w.Code != 200 || w.Body.String() != HelperScript() {
+		t.Fatalf("unexpected response %d: %.100s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/javascript" {
+		t.Errorf("unexpected Content-Type %q", ct)
+	}
+	etag := w.Header().Get("ETag")
+	if etag != `"`+HelperScriptVersion()+`"` {
+		t.Errorf("unexpected ETag %q", etag)
+	}
+
+	req := httptest.NewRequest("GET", "/vugu-helper.js", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", w.Code)
+	}
+}
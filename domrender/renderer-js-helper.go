@@ -0,0 +1,13 @@
+package domrender
+
+// HelperScript returns the JS which applies instruction buffers to the DOM, as passed to Transport.Init.
+// It is needed to replay a recording in a page, or to load it as a static script, see HelperScriptHandler.
+func HelperScript() string {
+	return jsHelperScript
+}
+
+// HelperScriptVersion returns the version of HelperScript, a hash of its contents.  The script sets
+// window.vuguHelperVersion to this.
+func HelperScriptVersion() string {
+	return jsHelperScriptVersion
+}
@@ -63,8 +63,71 @@ const (
 	opcodeCallback            uint8 = 40 // issue callback, sends just callbackID
 	opcodeCallbackLastElement uint8 = 41 // issue callback with callbackID and most recent element reference
 
+	opcodeSkipNode uint8 = 42 // select the next node (per any pending move) and leave it and its children untouched
+
+	opcodeSetGlobalEventListener          uint8 = 43 // assign event listener to window or document
+	opcodeRemoveOtherGlobalEventListeners uint8 = 44 // remove all window and document event listeners that were not set since the last call
+
+	opcodeForgetPosition uint8 = 45 // drop any references held for a positionID which is no longer in use
+
+	opcodeSelectMountPointContainer uint8 = 46 // selects the mount point element like opcodeSelectMountPoint but leaves the element itself as is, its children are synced to a fragment
+
+	opcodeSetPropertyStr  uint8 = 47 // assign a string DOM property (e.g. value) to the current element, if different
+	opcodeSetPropertyBool uint8 = 48 // assign a bool DOM property (e.g. checked) to the current element, if different
+
+	opcodeSetHidden uint8 = 49 // set style.display to none on the current element (vg-show), must come after its attributes
+
+	opcodeSetClassList uint8 = 50 // update the class list of the current element to match, only adding and removing the classes that differ
+	opcodeSetStyle     uint8 = 51 // update the inline style of the current element to match a style attribute value plus properties, only setting those that differ
+
+	opcodeSelectPortal       uint8 = 52 // select the container for portal content in the element matching a selector, creating it if needed, its children are synced to a fragment
+	opcodeRemoveOtherPortals uint8 = 53 // remove portal containers that were not selected since the last time this was called
+
+	opcodeInternString uint8 = 54 // remember a string under an ID for the rest of the session, so later instructions can refer to it instead of repeating it
+
+	opcodeProtocolVersion uint8 = 55 // the version of this protocol the Go side speaks, sent first in a session; this opcode must never change
+
+	opcodeSelectShadowRoot uint8 = 56 // selects the content container in a shadow root attached to the mount point element, creating them if needed, its children are synced to a fragment
+
+	opcodeSetStaticInnerHTML uint8 = 57 // set the innerHTML for an element unless it was already set to the same by this opcode, for content which never changes
+
+	opcodeSetScrollKey uint8 = 58 // keep the scroll position of the current element under a key, restoring it at the end of the buffer if the element is new (vg-scroll-key)
+
+	opcodeFocus          uint8 = 59 // focus the current element at the end of the buffer (vg-focus)
+	opcodeScrollIntoView uint8 = 60 // scroll the current element into view at the end of the buffer (vg-scroll-into-view)
+
+	opcodeWriteClipboard  uint8 = 61 // copy text to the clipboard at the end of the buffer (vugu.WriteClipboard)
+	opcodeFocusRef        uint8 = 62 // focus the element with this vg-ref at the end of the buffer (vugu.Focus)
+	opcodeSetSelectionRef uint8 = 63 // focus and select a range of the text of the element with this vg-ref at the end of the buffer (vugu.SetSelection)
+	opcodeSetAttrBool     uint8 = 64 // add (with an empty value) or remove a boolean attribute of the current element
+
+	opcodeSetDelegatedEventListener uint8 = 65 // assign event listener to currently selected element, called by a listener on the mount point (JSRenderer.DelegateEvents)
+	opcodeSetEventRate              uint8 = 66 // debounce or throttle the event listener set by the next instruction (e.g. @input.debounce-300ms)
+	opcodeSweepPositions            uint8 = 67 // drop the listeners and references held for positions whose element is no longer in the document
+	opcodeForgetSubtree             uint8 = 68 // drop the listeners of the elements at a position and those under it, which are moving (KeyedDiff)
+	opcodeMoveChild                 uint8 = 69 // move a child of the current element before another, or insert a placeholder for a new one (KeyedDiff)
+	opcodeRemoveChild               uint8 = 70 // remove a child of the current element (KeyedDiff)
+
 )
 
+// protocolVersion is the version of the instruction protocol, incremented when the meaning of any
+// instruction changes.  It must match protocolVersion in renderer-js-script.js.
+const protocolVersion = 1
+
+// ProtocolVersionError is returned by Render when the helper script on the page speaks a different
+// version of the instruction protocol than the program, e.g. because the browser has an old copy of the
+// script cached.  Only DirectTransport reports it, with other transports the helper script logs the
+// mismatch in the browser's console.
+type ProtocolVersionError struct {
+	Program int // the version the program speaks
+	Helper  int // the version the helper script speaks
+}
+
+func (e *ProtocolVersionError) Error() string {
+	return fmt.Sprintf("instruction protocol version %d does not match helper script version %d, the page may have an old copy of the helper script cached",
+		e.Program, e.Helper)
+}
+
 // newInstructionList will create a new instance backed by the specified slice and with a clearBufFunc
 // that is called when the buffer is about to overflow.
 func newInstructionList(buf []byte, flushBufFunc func(il *instructionList) error) *instructionList {
@@ -85,8 +148,25 @@ type instructionList struct {
 	pos          int
 	flushBufFunc func(il *instructionList) error
 	logWriter    io.Writer // set to non-nil to enable debug log output
+	count        int       // number of instructions written, for RenderStats
+	maxLen       int       // buf is grown up to this length rather than flushing, if more than len(buf)
+	growth       float64   // factor buf grows by, 2 if not more than 1
+	flushAt      int       // if not zero, flush once this many bytes are written even if buf has room
+	peak         int       // the most bytes flushed at once since it was last reset, for auto tuning
+
+	// strings sent with opcodeInternString, by ID, and those sent since the last flush
+	interned      map[string]uint32
+	internPending []string
 }
 
+// Strings written with writeValInterned are replaced with a reference to an interned string by writing
+// internFlag plus the ID where a length would be, see intern.
+const (
+	internFlag     = 0x80000000
+	maxInternLen   = 64
+	maxInternCount = 8192
+)
+
 var errDoesNotFit = errors.New("requested instruction does not fit in the buffer")
 
 func (il *instructionList) logf(f string, args ...interface{}) error {
@@ -104,20 +184,37 @@ func (il *instructionList) flush() error {
 	il.logf("flush() calling flushBufFunc")
 	err := il.flushBufFunc(il)
 	if err != nil {
+		// JS may not have the strings interned in this buffer
+		il.forgetPending()
 		return err
 	}
+	if il.pos > il.peak {
+		il.peak = il.pos
+	}
 	il.pos = 0
+	il.internPending = il.internPending[:0]
 	il.logf("flush() completed")
 	return nil
 }
 
 // checkLenAndFlush calls checkLen(), if it fails attempts to flush the buffer and checkLen again, at which point any error is returned.
+// It also flushes if flushAt has been reached.
 func (il *instructionList) checkLenAndFlush(l int) error {
 
+	if il.flushAt > 0 && il.pos >= il.flushAt {
+		err := il.flush()
+		if err != nil {
+			return err
+		}
+	}
+
 	err := il.checkLen(l)
 	if err != nil {
 
 		if err == errDoesNotFit {
+			if il.grow(l) {
+				return nil
+			}
 			err = il.flush()
 			if err != nil {
 				return err
@@ -129,6 +226,90 @@ func (il *instructionList) checkLenAndFlush(l int) error {
 	return err
 }
 
+// discard drops the instructions which have not been flushed.
+func (il *instructionList) discard() {
+	il.pos = 0
+	il.forgetPending()
+}
+
+// forgetPending forgets the strings interned since the last flush.  They are always the most recent,
+// so the IDs of those remaining are still below len(il.interned).
+func (il *instructionList) forgetPending() {
+	for _, s := range il.internPending {
+		delete(il.interned, s)
+	}
+	il.internPending = il.internPending[:0]
+}
+
+// intern sends s with opcodeInternString if it is worth doing and has not been sent already, so
+// writeValInterned can refer to it.  It must be called before starting the instruction which uses s.
+// Short strings which repeat a lot (tag names, attribute names, event types) are interned, the
+// table is limited so it cannot grow without bound.
+func (il *instructionList) intern(s string) error {
+	if len(s) == 0 || len(s) > maxInternLen || len(il.interned) >= maxInternCount {
+		return nil
+	}
+	if _, ok := il.interned[s]; ok {
+		return nil
+	}
+
+	err := il.checkLenAndFlush(len(s) + 9)
+	if err != nil {
+		return err
+	}
+
+	if il.interned == nil {
+		il.interned = make(map[string]uint32, 64)
+	}
+	id := uint32(len(il.interned))
+	il.logf("writeInternString[%d](id=%d, s=%q)", opcodeInternString, id, s)
+	il.interned[s] = id
+	il.internPending = append(il.internPending, s)
+	il.writeOpcode(opcodeInternString)
+	il.writeValUint32(id)
+	il.writeValString(s)
+	return nil
+}
+
+// writeValInterned writes s as a reference if it was interned, or else as a string.
+func (il *instructionList) writeValInterned(s string) {
+	if id, ok := il.interned[s]; ok {
+		il.writeValUint32(internFlag | id)
+		return
+	}
+	il.writeValString(s)
+}
+
+// grow enlarges buf, up to maxLen, so l more bytes and the end instruction fit.  It returns false if they cannot.
+func (il *instructionList) grow(l int) bool {
+	need := il.pos + l + 1
+	if need <= len(il.buf) {
+		return true
+	}
+	if need > il.maxLen {
+		return false
+	}
+	growth := il.growth
+	if growth <= 1 {
+		growth = 2
+	}
+	n := len(il.buf)
+	for n < need {
+		next := int(float64(n) * growth)
+		if next <= n {
+			next = need
+		}
+		n = next
+	}
+	if n > il.maxLen {
+		n = il.maxLen
+	}
+	buf := make([]byte, n)
+	copy(buf, il.buf[:il.pos])
+	il.buf = buf
+	return true
+}
+
 func (il *instructionList) checkLen(l int) error {
 	if il.pos+l > len(il.buf)-1 {
 		return errDoesNotFit
@@ -147,10 +328,10 @@ func (il *instructionList) writeClearEl() error {
 
 	err := il.checkLenAndFlush(1)
 	if err != nil {
-		return err
+		return opError(opcodeClearEl, err)
 	}
 
-	il.writeValUint8(opcodeClearEl)
+	il.writeOpcode(opcodeClearEl)
 
 	return nil
 }
@@ -161,10 +342,10 @@ func (il *instructionList) writeRemoveOtherAttrs() error {
 
 	err := il.checkLenAndFlush(1)
 	if err != nil {
-		return err
+		return opError(opcodeRemoveOtherAttrs, err)
 	}
 
-	il.writeValUint8(opcodeRemoveOtherAttrs)
+	il.writeOpcode(opcodeRemoveOtherAttrs)
 
 	return nil
 }
@@ -175,32 +356,72 @@ func (il *instructionList) writeSetAttrStr(name, value string) error {
 
 	size := len(name) + len(value) + 9
 
-	err := il.checkLenAndFlush(size)
+	err := il.intern(name)
 	if err != nil {
-		return err
+		return opError(opcodeSetAttrStr, err)
 	}
 
-	il.writeValUint8(opcodeSetAttrStr)
-	il.writeValString(name)
+	err = il.checkLenAndFlush(size)
+	if err != nil {
+		return opError(opcodeSetAttrStr, err)
+	}
+
+	il.writeOpcode(opcodeSetAttrStr)
+	il.writeValInterned(name)
 	il.writeValString(value)
 
 	return nil
 }
 
+func (il *instructionList) writeSetAttrBool(name string, present bool) error {
+
+	il.logf("writeSetAttrBool[%d](name=%q, present=%v)", opcodeSetAttrBool, name, present)
+
+	err := il.intern(name)
+	if err != nil {
+		return opError(opcodeSetAttrBool, err)
+	}
+
+	err = il.checkLenAndFlush(len(name) + 6)
+	if err != nil {
+		return opError(opcodeSetAttrBool, err)
+	}
+
+	presentB := uint8(0)
+	if present {
+		presentB = 1
+	}
+
+	il.writeOpcode(opcodeSetAttrBool)
+	il.writeValInterned(name)
+	il.writeValUint8(presentB)
+
+	return nil
+}
+
 func (il *instructionList) writeSetAttrNSStr(namespace, name, value string) error {
 
 	il.logf("writeSetAttrNSStr[%d](ns=%q, name=%q, value=%q)", opcodeSetAttrNSStr, namespace, name, value)
 
 	size := len(namespace) + len(name) + len(value) + 9
 
-	err := il.checkLenAndFlush(size)
+	err := il.intern(namespace)
 	if err != nil {
-		return err
+		return opError(opcodeSetAttrNSStr, err)
+	}
+	err = il.intern(name)
+	if err != nil {
+		return opError(opcodeSetAttrNSStr, err)
 	}
 
-	il.writeValUint8(opcodeSetAttrNSStr)
-	il.writeValString(namespace)
-	il.writeValString(name)
+	err = il.checkLenAndFlush(size)
+	if err != nil {
+		return opError(opcodeSetAttrNSStr, err)
+	}
+
+	il.writeOpcode(opcodeSetAttrNSStr)
+	il.writeValInterned(namespace)
+	il.writeValInterned(name)
 	il.writeValString(value)
 
 	return nil
@@ -212,9 +433,9 @@ func (il *instructionList) writeSelectQuery(selector string) error {
 
 	err := il.checkLenAndFlush(5 + len(selector))
 	if err != nil {
-		return err
+		return opError(opcodeSelectQuery, err)
 	}
-	il.writeValUint8(opcodeSelectQuery)
+	il.writeOpcode(opcodeSelectQuery)
 	il.writeValString(selector)
 	return nil
 }
@@ -225,10 +446,10 @@ func (il *instructionList) writeSelectMountPoint(selector, nodeName string) erro
 
 	err := il.checkLenAndFlush(len(selector) + len(nodeName) + 9)
 	if err != nil {
-		return err
+		return opError(opcodeSelectMountPoint, err)
 	}
 
-	il.writeValUint8(opcodeSelectMountPoint)
+	il.writeOpcode(opcodeSelectMountPoint)
 	il.writeValString(selector)
 	il.writeValString(nodeName)
 
@@ -236,16 +457,49 @@ func (il *instructionList) writeSelectMountPoint(selector, nodeName string) erro
 
 }
 
+func (il *instructionList) writeSelectShadowRoot(selector, mode string) error {
+
+	il.logf("writeSelectShadowRoot[%d](selector=%q, mode=%q)", opcodeSelectShadowRoot, selector, mode)
+
+	err := il.checkLenAndFlush(len(selector) + len(mode) + 9)
+	if err != nil {
+		return opError(opcodeSelectShadowRoot, err)
+	}
+
+	il.writeOpcode(opcodeSelectShadowRoot)
+	il.writeValString(selector)
+	il.writeValString(mode)
+
+	return nil
+
+}
+
+func (il *instructionList) writeSelectMountPointContainer(selector string) error {
+
+	il.logf("writeSelectMountPointContainer[%d](selector=%q)", opcodeSelectMountPointContainer, selector)
+
+	err := il.checkLenAndFlush(len(selector) + 5)
+	if err != nil {
+		return opError(opcodeSelectMountPointContainer, err)
+	}
+
+	il.writeOpcode(opcodeSelectMountPointContainer)
+	il.writeValString(selector)
+
+	return nil
+
+}
+
 func (il *instructionList) writeMoveToFirstChild() error {
 
 	il.logf("writeMoveToFirstChild[%d]()", opcodeMoveToFirstChild)
 
 	err := il.checkLenAndFlush(1)
 	if err != nil {
-		return err
+		return opError(opcodeMoveToFirstChild, err)
 	}
 
-	il.writeValUint8(opcodeMoveToFirstChild)
+	il.writeOpcode(opcodeMoveToFirstChild)
 
 	return nil
 }
@@ -254,13 +508,18 @@ func (il *instructionList) writeSetElement(nodeName string) error {
 
 	il.logf("writeSetElement[%d](nodeName=%q)", opcodeSetElement, nodeName)
 
-	err := il.checkLenAndFlush(len(nodeName) + 5)
+	err := il.intern(nodeName)
 	if err != nil {
-		return err
+		return opError(opcodeSetElement, err)
 	}
 
-	il.writeValUint8(opcodeSetElement)
-	il.writeValString(nodeName)
+	err = il.checkLenAndFlush(len(nodeName) + 5)
+	if err != nil {
+		return opError(opcodeSetElement, err)
+	}
+
+	il.writeOpcode(opcodeSetElement)
+	il.writeValInterned(nodeName)
 
 	return nil
 
@@ -271,15 +530,24 @@ func (il *instructionList) writeSetElementNS(nodeName, namespace string) error {
 	il.logf("writeSetElementNS[%d](nodeName=%q, ns=%q)", opcodeSetElementNS, nodeName, namespace)
 
 	size := len(nodeName) + len(namespace) + 9
-	err := il.checkLenAndFlush(size)
+	err := il.intern(nodeName)
+	if err != nil {
+		return opError(opcodeSetElementNS, err)
+	}
+	err = il.intern(namespace)
+	if err != nil {
+		return opError(opcodeSetElementNS, err)
+	}
+
+	err = il.checkLenAndFlush(size)
 
 	if err != nil {
-		return err
+		return opError(opcodeSetElementNS, err)
 	}
 
-	il.writeValUint8(opcodeSetElementNS)
-	il.writeValString(nodeName)
-	il.writeValString(namespace)
+	il.writeOpcode(opcodeSetElementNS)
+	il.writeValInterned(nodeName)
+	il.writeValInterned(namespace)
 
 	return nil
 
@@ -291,10 +559,10 @@ func (il *instructionList) writeSetText(text string) error {
 
 	err := il.checkLenAndFlush(len(text) + 5)
 	if err != nil {
-		return err
+		return opError(opcodeSetText, err)
 	}
 
-	il.writeValUint8(opcodeSetText)
+	il.writeOpcode(opcodeSetText)
 	il.writeValString(text)
 
 	return nil
@@ -307,10 +575,10 @@ func (il *instructionList) writeSetComment(comment string) error {
 
 	err := il.checkLenAndFlush(len(comment) + 5)
 	if err != nil {
-		return err
+		return opError(opcodeSetComment, err)
 	}
 
-	il.writeValUint8(opcodeSetComment)
+	il.writeOpcode(opcodeSetComment)
 	il.writeValString(comment)
 
 	return nil
@@ -323,10 +591,10 @@ func (il *instructionList) writeMoveToParent() error {
 
 	err := il.checkLenAndFlush(1)
 	if err != nil {
-		return err
+		return opError(opcodeMoveToParent, err)
 	}
 
-	il.writeValUint8(opcodeMoveToParent)
+	il.writeOpcode(opcodeMoveToParent)
 
 	return nil
 }
@@ -337,26 +605,39 @@ func (il *instructionList) writeMoveToNextSibling() error {
 
 	err := il.checkLenAndFlush(1)
 	if err != nil {
-		return err
+		return opError(opcodeMoveToNextSibling, err)
 	}
 
-	il.writeValUint8(opcodeMoveToNextSibling)
+	il.writeOpcode(opcodeMoveToNextSibling)
 
 	return nil
 }
 
 func (il *instructionList) writeSetInnerHTML(html string) error {
-
 	il.logf("writeSetInnerHTML[%d](html=%q)", opcodeSetInnerHTML, html)
+	return il.writeInnerHTML(opcodeSetInnerHTML, html)
+}
+
+func (il *instructionList) writeSetStaticInnerHTML(html string) error {
+	il.logf("writeSetStaticInnerHTML[%d](html=%q)", opcodeSetStaticInnerHTML, html)
+	return il.writeInnerHTML(opcodeSetStaticInnerHTML, html)
+}
+
+// writeInnerHTML writes html with opcode, preceded by as many opcodeBufferInnerHTML as needed
+// if it does not fit in the buffer.
+func (il *instructionList) writeInnerHTML(opcode uint8, html string) error {
 
 	// Make sure there is room to write at least one byte
 	// (1 byte for opcode, 4 bytes for string length, 1 byte of data)
 	// [This further ensures that maxLen - il.pos > 0]
 	err := il.checkLenAndFlush(6)
 	if err != nil {
-		return err
+		return opError(opcode, err)
 	}
 
+	// avoid splitting it if the buffer can grow instead
+	il.grow(len(html) + 5)
+
 	remaining := html
 	maxLen := len(il.buf) - 6
 	for len(remaining) > maxLen-il.pos {
@@ -364,37 +645,93 @@ func (il *instructionList) writeSetInnerHTML(html string) error {
 		remaining = remaining[maxLen-il.pos:]
 		err := il.checkLenAndFlush(len(chunk) + 5)
 		if err != nil {
-			return err
+			return opError(opcode, err)
 		}
 
-		il.writeValUint8(opcodeBufferInnerHTML)
+		il.writeOpcode(opcodeBufferInnerHTML)
 		il.writeValString(chunk)
 		il.flush()
 	}
 
 	err = il.checkLenAndFlush(len(remaining) + 5)
 	if err != nil {
-		return err
+		return opError(opcode, err)
 	}
 
-	il.writeValUint8(opcodeSetInnerHTML)
+	il.writeOpcode(opcode)
 	il.writeValString(remaining)
 
 	return nil
 }
 
-func (il *instructionList) writeSetEventListener(positionID []byte, eventType string, capture, passive bool) error {
+// Modes of opcodeSetEventRate.
+const (
+	eventRateDebounce uint8 = 1
+	eventRateThrottle uint8 = 2
+)
+
+func (il *instructionList) writeSetEventRate(mode uint8, ms uint32) error {
 
-	il.logf("writeSetInnerHTML[%d](positionID=%q, eventType=%q, capture=%v, passive=%v)", opcodeSetEventListener, positionID, eventType, capture, passive)
+	il.logf("writeSetEventRate[%d](mode=%d, ms=%d)", opcodeSetEventRate, mode, ms)
 
-	err := il.checkLenAndFlush(len(positionID) + len(eventType) + 11)
+	err := il.checkLenAndFlush(6)
 	if err != nil {
-		return err
+		return opError(opcodeSetEventRate, err)
+	}
+
+	il.writeOpcode(opcodeSetEventRate)
+	il.writeValUint8(mode)
+	il.writeValUint32(ms)
+
+	return nil
+}
+
+func (il *instructionList) writeSetDelegatedEventListener(positionID []byte, eventType string, passive bool, modifiers uint32) error {
+
+	il.logf("writeSetDelegatedEventListener[%d](positionID=%q, eventType=%q, passive=%v, modifiers=%d)", opcodeSetDelegatedEventListener, positionID, eventType, passive, modifiers)
+
+	err := il.intern(eventType)
+	if err != nil {
+		return opError(opcodeSetDelegatedEventListener, err)
+	}
+
+	err = il.checkLenAndFlush(len(positionID) + len(eventType) + 14)
+	if err != nil {
+		return opError(opcodeSetDelegatedEventListener, err)
+	}
+
+	il.writeOpcode(opcodeSetDelegatedEventListener)
+	il.writeValBytes(positionID)
+	il.writeValInterned(eventType)
+
+	passiveB := uint8(0)
+	if passive {
+		passiveB = 1
 	}
+	il.writeValUint8(passiveB)
+
+	il.writeValUint32(modifiers)
+
+	return nil
+}
+
+func (il *instructionList) writeSetEventListener(positionID []byte, eventType string, capture, passive bool, modifiers uint32) error {
+
+	il.logf("writeSetEventListener[%d](positionID=%q, eventType=%q, capture=%v, passive=%v, modifiers=%d)", opcodeSetEventListener, positionID, eventType, capture, passive, modifiers)
 
-	il.writeValUint8(opcodeSetEventListener)
+	err := il.intern(eventType)
+	if err != nil {
+		return opError(opcodeSetEventListener, err)
+	}
+
+	err = il.checkLenAndFlush(len(positionID) + len(eventType) + 15)
+	if err != nil {
+		return opError(opcodeSetEventListener, err)
+	}
+
+	il.writeOpcode(opcodeSetEventListener)
 	il.writeValBytes(positionID)
-	il.writeValString(eventType)
+	il.writeValInterned(eventType)
 
 	captureB := uint8(0)
 	if capture {
@@ -408,6 +745,8 @@ func (il *instructionList) writeSetEventListener(positionID []byte, eventType st
 	}
 	il.writeValUint8(passiveB)
 
+	il.writeValUint32(modifiers)
+
 	return nil
 
 }
@@ -418,10 +757,10 @@ func (il *instructionList) writeRemoveOtherEventListeners(positionID []byte) err
 
 	err := il.checkLenAndFlush(5 + len(positionID))
 	if err != nil {
-		return err
+		return opError(opcodeRemoveOtherEventListeners, err)
 	}
 
-	il.writeValUint8(opcodeRemoveOtherEventListeners)
+	il.writeOpcode(opcodeRemoveOtherEventListeners)
 	il.writeValBytes(positionID)
 
 	return nil
@@ -450,10 +789,10 @@ func (il *instructionList) writeSetCSSTag(elementName string, textContent []byte
 
 	err := il.checkLenAndFlush(l)
 	if err != nil {
-		return err
+		return opError(opcodeSetCSSTag, err)
 	}
 
-	il.writeValUint8(opcodeSetCSSTag)
+	il.writeOpcode(opcodeSetCSSTag)
 	// il.writeValUint64(hashCode)
 	il.writeValString(elementName)
 	il.writeValBytes(textContent)
@@ -472,10 +811,10 @@ func (il *instructionList) writeRemoveOtherCSSTags() error {
 
 	err := il.checkLenAndFlush(1)
 	if err != nil {
-		return err
+		return opError(opcodeRemoveOtherCSSTags, err)
 	}
 
-	il.writeValUint8(opcodeRemoveOtherCSSTags)
+	il.writeOpcode(opcodeRemoveOtherCSSTags)
 
 	return nil
 }
@@ -486,18 +825,220 @@ func (il *instructionList) writeSetProperty(key string, jsonValue []byte) error
 
 	size := len(key) + len(jsonValue) + 9
 
-	err := il.checkLenAndFlush(size)
+	err := il.intern(key)
 	if err != nil {
-		return err
+		return opError(opcodeSetProperty, err)
 	}
 
-	il.writeValUint8(opcodeSetProperty)
-	il.writeValString(key)
+	err = il.checkLenAndFlush(size)
+	if err != nil {
+		return opError(opcodeSetProperty, err)
+	}
+
+	il.writeOpcode(opcodeSetProperty)
+	il.writeValInterned(key)
 	il.writeValBytes(jsonValue)
 
 	return nil
 }
 
+func (il *instructionList) writeSetPropertyStr(key, val string) error {
+
+	il.logf("writeSetPropertyStr[%d](key=%q, val=%q)", opcodeSetPropertyStr, key, val)
+
+	err := il.intern(key)
+	if err != nil {
+		return opError(opcodeSetPropertyStr, err)
+	}
+
+	err = il.checkLenAndFlush(len(key) + len(val) + 9)
+	if err != nil {
+		return opError(opcodeSetPropertyStr, err)
+	}
+
+	il.writeOpcode(opcodeSetPropertyStr)
+	il.writeValInterned(key)
+	il.writeValString(val)
+
+	return nil
+}
+
+func (il *instructionList) writeSetPropertyBool(key string, val bool) error {
+
+	il.logf("writeSetPropertyBool[%d](key=%q, val=%v)", opcodeSetPropertyBool, key, val)
+
+	err := il.intern(key)
+	if err != nil {
+		return opError(opcodeSetPropertyBool, err)
+	}
+
+	err = il.checkLenAndFlush(len(key) + 6)
+	if err != nil {
+		return opError(opcodeSetPropertyBool, err)
+	}
+
+	valB := uint8(0)
+	if val {
+		valB = 1
+	}
+
+	il.writeOpcode(opcodeSetPropertyBool)
+	il.writeValInterned(key)
+	il.writeValUint8(valB)
+
+	return nil
+}
+
+func (il *instructionList) writeSetScrollKey(key string) error {
+
+	il.logf("writeSetScrollKey[%d](key=%q)", opcodeSetScrollKey, key)
+
+	// the same keys are sent every render
+	err := il.intern(key)
+	if err != nil {
+		return opError(opcodeSetScrollKey, err)
+	}
+
+	err = il.checkLenAndFlush(len(key) + 5)
+	if err != nil {
+		return opError(opcodeSetScrollKey, err)
+	}
+
+	il.writeOpcode(opcodeSetScrollKey)
+	il.writeValInterned(key)
+
+	return nil
+}
+
+func (il *instructionList) writeFocus() error {
+
+	il.logf("writeFocus[%d]()", opcodeFocus)
+
+	err := il.checkLenAndFlush(1)
+	if err != nil {
+		return opError(opcodeFocus, err)
+	}
+
+	il.writeOpcode(opcodeFocus)
+
+	return nil
+}
+
+func (il *instructionList) writeScrollIntoView() error {
+
+	il.logf("writeScrollIntoView[%d]()", opcodeScrollIntoView)
+
+	err := il.checkLenAndFlush(1)
+	if err != nil {
+		return opError(opcodeScrollIntoView, err)
+	}
+
+	il.writeOpcode(opcodeScrollIntoView)
+
+	return nil
+}
+
+func (il *instructionList) writeWriteClipboard(text string) error {
+
+	il.logf("writeWriteClipboard[%d](%q)", opcodeWriteClipboard, text)
+
+	err := il.checkLenAndFlush(len(text) + 5)
+	if err != nil {
+		return opError(opcodeWriteClipboard, err)
+	}
+
+	il.writeOpcode(opcodeWriteClipboard)
+	il.writeValString(text)
+
+	return nil
+}
+
+func (il *instructionList) writeFocusRef(ref string) error {
+
+	il.logf("writeFocusRef[%d](%q)", opcodeFocusRef, ref)
+
+	err := il.checkLenAndFlush(len(ref) + 5)
+	if err != nil {
+		return opError(opcodeFocusRef, err)
+	}
+
+	il.writeOpcode(opcodeFocusRef)
+	il.writeValString(ref)
+
+	return nil
+}
+
+func (il *instructionList) writeSetSelectionRef(ref string, start, end int) error {
+
+	il.logf("writeSetSelectionRef[%d](%q, %d, %d)", opcodeSetSelectionRef, ref, start, end)
+
+	err := il.checkLenAndFlush(len(ref) + 13)
+	if err != nil {
+		return opError(opcodeSetSelectionRef, err)
+	}
+
+	il.writeOpcode(opcodeSetSelectionRef)
+	il.writeValString(ref)
+	il.writeValUint32(uint32(start))
+	il.writeValUint32(uint32(end))
+
+	return nil
+}
+
+func (il *instructionList) writeSetHidden() error {
+
+	il.logf("writeSetHidden[%d]()", opcodeSetHidden)
+
+	err := il.checkLenAndFlush(1)
+	if err != nil {
+		return opError(opcodeSetHidden, err)
+	}
+
+	il.writeOpcode(opcodeSetHidden)
+
+	return nil
+}
+
+func (il *instructionList) writeSetClassList(classes string) error {
+
+	il.logf("writeSetClassList[%d](classes=%q)", opcodeSetClassList, classes)
+
+	err := il.checkLenAndFlush(len(classes) + 5)
+	if err != nil {
+		return opError(opcodeSetClassList, err)
+	}
+
+	il.writeOpcode(opcodeSetClassList)
+	il.writeValString(classes)
+
+	return nil
+}
+
+func (il *instructionList) writeSetStyle(baseStyle string, names, values []string) error {
+
+	il.logf("writeSetStyle[%d](baseStyle=%q, names=%q, values=%q)", opcodeSetStyle, baseStyle, names, values)
+
+	size := len(baseStyle) + 9
+	for i := range names {
+		size += len(names[i]) + len(values[i]) + 8
+	}
+
+	err := il.checkLenAndFlush(size)
+	if err != nil {
+		return opError(opcodeSetStyle, err)
+	}
+
+	il.writeOpcode(opcodeSetStyle)
+	il.writeValString(baseStyle)
+	il.writeValUint32(uint32(len(names)))
+	for i := range names {
+		il.writeValString(names[i])
+		il.writeValString(values[i])
+	}
+
+	return nil
+}
+
 func (il *instructionList) writeCallback(callbackID uint32) error {
 
 	il.logf("writeCallback[%d](callbackID=%v)", opcodeCallback, callbackID)
@@ -506,10 +1047,10 @@ func (il *instructionList) writeCallback(callbackID uint32) error {
 
 	err := il.checkLenAndFlush(size)
 	if err != nil {
-		return err
+		return opError(opcodeCallback, err)
 	}
 
-	il.writeValUint8(opcodeCallback)
+	il.writeOpcode(opcodeCallback)
 	il.writeValUint32(callbackID)
 
 	return nil
@@ -523,15 +1064,214 @@ func (il *instructionList) writeCallbackLastElement(callbackID uint32) error {
 
 	err := il.checkLenAndFlush(size)
 	if err != nil {
-		return err
+		return opError(opcodeCallbackLastElement, err)
 	}
 
-	il.writeValUint8(opcodeCallbackLastElement)
+	il.writeOpcode(opcodeCallbackLastElement)
 	il.writeValUint32(callbackID)
 
 	return nil
 }
 
+func (il *instructionList) writeSkipNode() error {
+
+	il.logf("writeSkipNode[%d]()", opcodeSkipNode)
+
+	err := il.checkLenAndFlush(1)
+	if err != nil {
+		return opError(opcodeSkipNode, err)
+	}
+
+	il.writeOpcode(opcodeSkipNode)
+
+	return nil
+}
+
+func (il *instructionList) writeSetGlobalEventListener(positionID []byte, target, eventType string, capture, passive bool, modifiers uint32) error {
+
+	il.logf("writeSetGlobalEventListener[%d](positionID=%q, target=%q, eventType=%q, capture=%v, passive=%v, modifiers=%d)", opcodeSetGlobalEventListener, positionID, target, eventType, capture, passive, modifiers)
+
+	err := il.intern(target)
+	if err != nil {
+		return opError(opcodeSetGlobalEventListener, err)
+	}
+	err = il.intern(eventType)
+	if err != nil {
+		return opError(opcodeSetGlobalEventListener, err)
+	}
+
+	err = il.checkLenAndFlush(len(positionID) + len(target) + len(eventType) + 19)
+	if err != nil {
+		return opError(opcodeSetGlobalEventListener, err)
+	}
+
+	il.writeOpcode(opcodeSetGlobalEventListener)
+	il.writeValBytes(positionID)
+	il.writeValInterned(target)
+	il.writeValInterned(eventType)
+
+	captureB := uint8(0)
+	if capture {
+		captureB = 1
+	}
+	il.writeValUint8(captureB)
+
+	passiveB := uint8(0)
+	if passive {
+		passiveB = 1
+	}
+	il.writeValUint8(passiveB)
+
+	il.writeValUint32(modifiers)
+
+	return nil
+}
+
+func (il *instructionList) writeRemoveOtherGlobalEventListeners() error {
+
+	il.logf("writeRemoveOtherGlobalEventListeners[%d]()", opcodeRemoveOtherGlobalEventListeners)
+
+	err := il.checkLenAndFlush(1)
+	if err != nil {
+		return opError(opcodeRemoveOtherGlobalEventListeners, err)
+	}
+
+	il.writeOpcode(opcodeRemoveOtherGlobalEventListeners)
+
+	return nil
+}
+
+func (il *instructionList) writeSelectPortal(selector string) error {
+
+	il.logf("writeSelectPortal[%d](selector=%q)", opcodeSelectPortal, selector)
+
+	err := il.checkLenAndFlush(len(selector) + 5)
+	if err != nil {
+		return opError(opcodeSelectPortal, err)
+	}
+
+	il.writeOpcode(opcodeSelectPortal)
+	il.writeValString(selector)
+
+	return nil
+}
+
+func (il *instructionList) writeProtocolVersion(version uint32) error {
+
+	il.logf("writeProtocolVersion[%d](version=%d)", opcodeProtocolVersion, version)
+
+	err := il.checkLenAndFlush(5)
+	if err != nil {
+		return opError(opcodeProtocolVersion, err)
+	}
+
+	il.writeOpcode(opcodeProtocolVersion)
+	il.writeValUint32(version)
+
+	return nil
+}
+
+func (il *instructionList) writeRemoveOtherPortals() error {
+
+	il.logf("writeRemoveOtherPortals[%d]()", opcodeRemoveOtherPortals)
+
+	err := il.checkLenAndFlush(1)
+	if err != nil {
+		return opError(opcodeRemoveOtherPortals, err)
+	}
+
+	il.writeOpcode(opcodeRemoveOtherPortals)
+
+	return nil
+}
+
+func (il *instructionList) writeForgetPosition(positionID []byte) error {
+
+	il.logf("writeForgetPosition[%d](positionID=%q)", opcodeForgetPosition, positionID)
+
+	err := il.checkLenAndFlush(len(positionID) + 5)
+	if err != nil {
+		return opError(opcodeForgetPosition, err)
+	}
+
+	il.writeOpcode(opcodeForgetPosition)
+	il.writeValBytes(positionID)
+
+	return nil
+}
+
+func (il *instructionList) writeSweepPositions() error {
+
+	il.logf("writeSweepPositions[%d]()", opcodeSweepPositions)
+
+	err := il.checkLenAndFlush(1)
+	if err != nil {
+		return opError(opcodeSweepPositions, err)
+	}
+
+	il.writeOpcode(opcodeSweepPositions)
+
+	return nil
+}
+
+func (il *instructionList) writeForgetSubtree(positionID []byte) error {
+
+	il.logf("writeForgetSubtree[%d](positionID=%q)", opcodeForgetSubtree, positionID)
+
+	err := il.checkLenAndFlush(len(positionID) + 5)
+	if err != nil {
+		return opError(opcodeForgetSubtree, err)
+	}
+
+	il.writeOpcode(opcodeForgetSubtree)
+	il.writeValBytes(positionID)
+
+	return nil
+}
+
+// newChild is passed to writeMoveChild as from to insert a placeholder for a new child.
+const newChild = 0xFFFFFFFF
+
+// writeMoveChild moves the child of the current element at index from in front of the one at index
+// before (or to the end if there is none), both as they are before the move.  With from newChild an
+// empty comment is inserted instead, which the element synced at that position replaces.
+func (il *instructionList) writeMoveChild(from, before uint32) error {
+
+	il.logf("writeMoveChild[%d](from=%d, before=%d)", opcodeMoveChild, from, before)
+
+	err := il.checkLenAndFlush(9)
+	if err != nil {
+		return opError(opcodeMoveChild, err)
+	}
+
+	il.writeOpcode(opcodeMoveChild)
+	il.writeValUint32(from)
+	il.writeValUint32(before)
+
+	return nil
+}
+
+func (il *instructionList) writeRemoveChild(index uint32) error {
+
+	il.logf("writeRemoveChild[%d](index=%d)", opcodeRemoveChild, index)
+
+	err := il.checkLenAndFlush(5)
+	if err != nil {
+		return opError(opcodeRemoveChild, err)
+	}
+
+	il.writeOpcode(opcodeRemoveChild)
+	il.writeValUint32(index)
+
+	return nil
+}
+
+// writeOpcode starts an instruction.
+func (il *instructionList) writeOpcode(op uint8) {
+	il.count++
+	il.writeValUint8(op)
+}
+
 func (il *instructionList) writeValUint8(b uint8) {
 	il.buf[il.pos] = b
 	il.pos++
@@ -1,9 +1,13 @@
 package domrender
 
 import (
+	"fmt"
+	"io/ioutil"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
 )
 
 func TestWriteSetInnerHTML(t *testing.T) {
@@ -97,3 +101,85 @@ func TestWriteSetInnerHTML(t *testing.T) {
 		assert.Equal(t, test.outputBuffer, buffer, test.description)
 	}
 }
+
+func TestInternStrings(t *testing.T) {
+
+	assert := assert.New(t)
+
+	class := "item"
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		ul := &vugu.VGNode{Type: vugu.ElementNode, Data: "ul"}
+		for i := 0; i < 20; i++ {
+			li := &vugu.VGNode{Type: vugu.ElementNode, Data: "li", Attr: []vugu.VGAttribute{{Key: "class", Val: class}}}
+			ul.AppendChild(li)
+		}
+		return &vugu.BuildOut{Out: []*vugu.VGNode{ul}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	class = "done"
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	if !assert.Len(tr.Renders, 2) {
+		return
+	}
+
+	count := func(instructions []Instruction, s string) (n int) {
+		for _, in := range instructions {
+			if in.String() == s {
+				n++
+			}
+		}
+		return n
+	}
+
+	// each name is sent once, in the first render
+	var dec Decoder
+	first, err := dec.Decode(tr.Renders[0])
+	assert.NoError(err)
+	assert.Equal(1, count(first, `internString(0, "li")`))
+	assert.Equal(1, count(first, `internString(1, "class")`))
+	assert.Equal(20, count(first, `setAttrStr("class", "item")`))
+
+	// and later renders refer to it
+	second, err := dec.Decode(tr.Renders[1])
+	assert.NoError(err)
+	assert.Equal(0, count(second, `internString(1, "class")`))
+	assert.NotContains(string(tr.Renders[1]), "class")
+	assert.Equal(20, count(second, `setAttrStr("class", "done")`))
+
+	second, err = DecodeInstructions(tr.Renders[1])
+	assert.NoError(err)
+	assert.Equal(20, count(second, `setAttrStr("<interned 1>", "done")`))
+}
+
+func TestProtocolVersion(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// the helper script must speak the same version
+	b, err := ioutil.ReadFile("renderer-js-script.js")
+	assert.NoError(err)
+	assert.Contains(string(b), fmt.Sprintf("const protocolVersion = %d\n", protocolVersion))
+
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		return &vugu.BuildOut{Out: []*vugu.VGNode{{Type: vugu.ElementNode, Data: "div"}}}
+	})
+	r, tr, buildEnv := newTestRenderer(t)
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	if !assert.Len(tr.Renders, 2) {
+		return
+	}
+
+	// sent first, once per session
+	first, err := DecodeInstructions(tr.Renders[0])
+	assert.NoError(err)
+	assert.Equal(fmt.Sprintf("protocolVersion(%d)", protocolVersion), first[0].String())
+	second, err := DecodeInstructions(tr.Renders[1])
+	assert.NoError(err)
+	assert.NotEqual("protocolVersion", second[0].Name)
+
+	err = &ProtocolVersionError{Program: 2, Helper: 1}
+	assert.Contains(err.Error(), "version 2 does not match helper script version 1")
+}
@@ -0,0 +1,177 @@
+package domrender
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Recorder receives a copy of each instruction buffer a JSRenderer sends to the browser, see
+// JSRenderer.Recorder.  This is meant for diagnosing problems where the DOM does not match what
+// was rendered, by showing exactly what was sent.  RecordInstructions must not keep buf.
+type Recorder interface {
+	RecordInstructions(buf []byte)
+}
+
+// recordingMagic starts a recording, followed by each buffer as a uint32 length and the bytes.
+const recordingMagic = "vugu-instructions-1\n"
+
+// RingRecorder is a Recorder which keeps the most recent buffers in memory.  WriteTo writes them in the
+// format read by ReadRecording and the vugureplay command.  Since tag, attribute and event names are sent
+// once and referred to after, once older buffers have been dropped a recording may refer to names it does
+// not contain; these decode as placeholders and cannot be replayed exactly.
+type RingRecorder struct {
+	mu   sync.Mutex
+	bufs [][]byte
+	next int
+	full bool
+}
+
+// NewRingRecorder returns a RingRecorder keeping the last size buffers.
+func NewRingRecorder(size int) *RingRecorder {
+	if size < 1 {
+		size = 1
+	}
+	return &RingRecorder{bufs: make([][]byte, size)}
+}
+
+// RecordInstructions implements Recorder.
+func (r *RingRecorder) RecordInstructions(buf []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bufs[r.next] = append(r.bufs[r.next][:0], buf...)
+	r.next++
+	if r.next == len(r.bufs) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// Buffers returns copies of the recorded buffers, oldest first.
+func (r *RingRecorder) Buffers() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ret [][]byte
+	add := func(bufs [][]byte) {
+		for _, b := range bufs {
+			ret = append(ret, append([]byte(nil), b...))
+		}
+	}
+	if r.full {
+		add(r.bufs[r.next:])
+	}
+	add(r.bufs[:r.next])
+	return ret
+}
+
+// Reset discards the recorded buffers.
+func (r *RingRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.next, r.full = 0, false
+}
+
+// WriteTo writes the recorded buffers to w as a recording.
+func (r *RingRecorder) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	wr := &WriterRecorder{W: cw}
+	for _, b := range r.Buffers() {
+		wr.RecordInstructions(b)
+	}
+	if err := wr.init(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, wr.Err()
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// WriterRecorder is a Recorder which writes each buffer to W as it is rendered, e.g. to a file when
+// rendering on the server with liverender.  The first error from W is kept and stops further writes.
+type WriterRecorder struct {
+	W io.Writer
+
+	mu      sync.Mutex
+	started bool
+	err     error
+}
+
+// RecordInstructions implements Recorder.
+func (r *WriterRecorder) RecordInstructions(buf []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.initLocked() != nil {
+		return
+	}
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(buf)))
+	if _, err := r.W.Write(l[:]); err != nil {
+		r.err = err
+		return
+	}
+	if _, err := r.W.Write(buf); err != nil {
+		r.err = err
+	}
+}
+
+// Err returns the first error from writing to W.
+func (r *WriterRecorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *WriterRecorder) init() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.initLocked()
+}
+
+// initLocked writes the header if it has not been written yet.
+func (r *WriterRecorder) initLocked() error {
+	if r.err != nil || r.started {
+		return r.err
+	}
+	r.started = true
+	_, r.err = io.WriteString(r.W, recordingMagic)
+	return r.err
+}
+
+// ReadRecording reads the buffers from a recording written by RingRecorder or WriterRecorder.
+func ReadRecording(r io.Reader) ([][]byte, error) {
+
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(recordingMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != recordingMagic {
+		return nil, errors.New("not a recording of instructions")
+	}
+
+	var ret [][]byte
+	for {
+		var l [4]byte
+		_, err := io.ReadFull(br, l[:])
+		if err == io.EOF {
+			return ret, nil
+		}
+		if err != nil {
+			return ret, fmt.Errorf("reading buffer %d: %w", len(ret), err)
+		}
+		buf := make([]byte, binary.BigEndian.Uint32(l[:]))
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return ret, fmt.Errorf("reading buffer %d: %w", len(ret), err)
+		}
+		ret = append(ret, buf)
+	}
+}
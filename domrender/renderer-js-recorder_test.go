@@ -0,0 +1,66 @@
+package domrender
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+func TestRecorder(t *testing.T) {
+
+	assert := assert.New(t)
+
+	text := "first"
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "div", Attr: []vugu.VGAttribute{{Key: "id", Val: "main"}}}
+		n.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: text})
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	})
+
+	rec := NewRingRecorder(1)
+	r, _, buildEnv := newTestRenderer(t)
+	r.Recorder = rec
+
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	text = "second"
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+
+	// only the last one is kept
+	bufs := rec.Buffers()
+	if !assert.Len(bufs, 1) {
+		return
+	}
+	instructions, err := DecodeInstructions(bufs[0])
+	assert.NoError(err)
+	var names []string
+	for _, in := range instructions {
+		names = append(names, in.String())
+	}
+	assert.Contains(names, `selectMountPoint("#app", "div")`)
+	// "id" was interned by the dropped buffer
+	assert.Contains(names, `setAttrStr("<interned 0>", "main")`)
+	assert.Contains(names, `setText("second")`)
+	assert.Equal("end()", names[len(names)-1])
+
+	var out bytes.Buffer
+	assert.NoError(FormatInstructions(&out, bufs[0]))
+	assert.Contains(out.String(), "\n  setText(\"second\")\n")
+
+	// round trip through a recording
+	var recording bytes.Buffer
+	_, err = rec.WriteTo(&recording)
+	assert.NoError(err)
+	read, err := ReadRecording(&recording)
+	assert.NoError(err)
+	assert.Equal(bufs, read)
+
+	_, err = ReadRecording(strings.NewReader("something else"))
+	assert.Error(err)
+
+	_, err = DecodeInstructions([]byte{opcodeSetText, 0, 0, 0, 9, 'x'})
+	assert.Error(err)
+}
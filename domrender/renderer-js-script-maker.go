@@ -5,6 +5,8 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"flag"
 	"fmt"
@@ -88,9 +90,17 @@ func main() {
 		panic(err)
 	}
 
+	// the version is a hash of the script, so a page with a stale copy is detected
+	sum := sha256.Sum256(b)
+	version := hex.EncodeToString(sum[:8])
+	if !bytes.Contains(b, []byte("VUGU_HELPER_VERSION")) {
+		panic(errors.New("version placeholder not found in script"))
+	}
+	b = bytes.Replace(b, []byte("VUGU_HELPER_VERSION"), []byte(version), 1)
+
 	buf.Reset()
 
-	fmt.Fprintf(&buf, "package domrender\n\n// GENERATED FILE, DO NOT EDIT!  See renderer-js-script-maker.go\n\nconst jsHelperScript = %q\n", b)
+	fmt.Fprintf(&buf, "package domrender\n\n// GENERATED FILE, DO NOT EDIT!  See renderer-js-script-maker.go\n\nconst jsHelperScript = %q\n\nconst jsHelperScriptVersion = %q\n", b, version)
 
 	err = ioutil.WriteFile("renderer-js-script.go", buf.Bytes(), 0644)
 	if err != nil {
@@ -4,6 +4,9 @@
         return;
     } // only once
 
+    // checked by the Go side when the script is loaded by the page, replaced by renderer-js-script-maker.go
+    window.vuguHelperVersion = "VUGU_HELPER_VERSION";
+
     const opcodeEnd = 0         // no more instructions in this buffer
     // const opcodeClearRefmap = 1 // clear the reference map, all following instructions must not reference prior IDs
     const opcodeClearEl = 1 // clear the currently selected element
@@ -46,15 +49,134 @@
     const opcodeCallback = 40 // issue callback, sends just callbackID
     const opcodeCallbackLastElement = 41 // issue callback with callbackID and most recent element reference
 
+    const opcodeSkipNode = 42 // select the next node (per any pending move) and leave it and its children untouched
+
+    const opcodeSetGlobalEventListener = 43 // assign event listener to window or document
+    const opcodeRemoveOtherGlobalEventListeners = 44 // remove all window and document event listeners that were not set since the last call
+
+    const opcodeForgetPosition = 45 // drop any references held for a positionID which is no longer in use
+
+    const opcodeSelectMountPointContainer = 46 // selects the mount point element like opcodeSelectMountPoint but leaves the element itself as is, its children are synced to a fragment
+
+    const opcodeSetPropertyStr = 47 // assign a string DOM property (e.g. value) to the current element, if different
+    const opcodeSetPropertyBool = 48 // assign a bool DOM property (e.g. checked) to the current element, if different
+
+    const opcodeSetHidden = 49 // set style.display to none on the current element (vg-show), must come after its attributes
+
+    const opcodeSetClassList = 50 // update the class list of the current element to match, only adding and removing the classes that differ
+    const opcodeSetStyle = 51 // update the inline style of the current element to match a style attribute value plus properties, only setting those that differ
+
+    const opcodeSelectPortal = 52 // select the container for portal content in the element matching a selector, creating it if needed, its children are synced to a fragment
+    const opcodeRemoveOtherPortals = 53 // remove portal containers that were not selected since the last time this was called
+
+    const opcodeInternString = 54 // remember a string under an ID for the rest of the session, so later instructions can refer to it instead of repeating it
+    const opcodeProtocolVersion = 55 // the version of this protocol the Go side speaks, sent first in a session; this opcode must never change
+
+    const opcodeSelectShadowRoot = 56 // selects the content container in a shadow root attached to the mount point element, creating them if needed, its children are synced to a fragment
+
+    const opcodeSetStaticInnerHTML = 57 // set the innerHTML for an element unless it was already set to the same by this opcode, for content which never changes
+
+    const opcodeSetScrollKey = 58 // keep the scroll position of the current element under a key, restoring it at the end of the buffer if the element is new (vg-scroll-key)
+
+    const opcodeFocus = 59 // focus the current element at the end of the buffer (vg-focus)
+    const opcodeScrollIntoView = 60 // scroll the current element into view at the end of the buffer (vg-scroll-into-view)
+
+    const opcodeWriteClipboard = 61 // copy text to the clipboard at the end of the buffer (vugu.WriteClipboard)
+    const opcodeFocusRef = 62 // focus the element with this vg-ref at the end of the buffer (vugu.Focus)
+    const opcodeSetSelectionRef = 63 // focus and select a range of the text of the element with this vg-ref at the end of the buffer (vugu.SetSelection)
+    const opcodeSetAttrBool = 64 // add (with an empty value) or remove a boolean attribute of the current element
+    const opcodeSetDelegatedEventListener = 65 // assign event listener to currently selected element, called by a listener on the mount point (JSRenderer.DelegateEvents)
+    const opcodeSetEventRate = 66 // debounce or throttle the event listener set by the next instruction (e.g. @input.debounce-300ms)
+    const opcodeSweepPositions = 67 // drop the listeners and references held for positions whose element is no longer in the document
+    const opcodeForgetSubtree = 68 // drop the listeners of the elements at a position and those under it, which are moving (KeyedDiff)
+    const opcodeMoveChild = 69 // move a child of the current element before another, or insert a placeholder for a new one (KeyedDiff)
+    const opcodeRemoveChild = 70 // remove a child of the current element (KeyedDiff)
+
+    // the from of opcodeMoveChild which inserts a placeholder for a new child
+    const newChild = 0xFFFFFFFF
+
+    // the version of the instruction protocol this script implements, must match protocolVersion in renderer-js-instructions.go
+    const protocolVersion = 1
+
     /*DEBUG OPCODE STRINGS*/
 
+    // event modifiers, must match vugu.DOMEventModifiers
+    const eventModPrevent = 1 << 0 // call preventDefault()
+    const eventModStop = 1 << 1 // call stopPropagation()
+    const eventModSelf = 1 << 2 // only if the event target is the element itself
+    const eventModCtrl = 1 << 3
+    const eventModShift = 1 << 4
+    const eventModAlt = 1 << 5
+    const eventModMeta = 1 << 6
+    const eventModEnter = 1 << 7
+    const eventModTab = 1 << 8
+    const eventModEsc = 1 << 9
+    const eventModSpace = 1 << 10
+    const eventModDelete = 1 << 11
+    const eventModUp = 1 << 12
+    const eventModDown = 1 << 13
+    const eventModLeft = 1 << 14 // ArrowLeft or left mouse button
+    const eventModRight = 1 << 15 // ArrowRight or right mouse button
+    const eventModMiddle = 1 << 16
+    const eventModSelection = 1 << 17 // include selection info in the event summary
+    const eventModDataset = 1 << 18 // include the listener element's dataset in the event summary
+    const eventModForm = 1 << 19 // include the fields of the enclosing form in the event summary
+    const eventModAction = 1 << 20 // include the nearest data-vg-action element's action in the event summary
+
+    // version of the event payload sent to Go, must match eventPayloadVersion in renderer-js.go
+    const eventPayloadVersion = 1
+
+    // eventModifiersMatch returns true if the event satisfies the conditions in modifiers
+    // and should be passed on to Go
+    function eventModifiersMatch(event, modifiers) {
+
+        if ((modifiers & eventModSelf) && event.target !== event.currentTarget) {
+            return false;
+        }
+
+        if (((modifiers & eventModCtrl) && !event.ctrlKey) ||
+            ((modifiers & eventModShift) && !event.shiftKey) ||
+            ((modifiers & eventModAlt) && !event.altKey) ||
+            ((modifiers & eventModMeta) && !event.metaKey)) {
+            return false;
+        }
+
+        if (typeof (event.key) == "string") {
+            let keyMods = modifiers & (eventModEnter | eventModTab | eventModEsc | eventModSpace | eventModDelete |
+                eventModUp | eventModDown | eventModLeft | eventModRight);
+            if (keyMods) {
+                let k = event.key;
+                return !!(((keyMods & eventModEnter) && k == "Enter") ||
+                    ((keyMods & eventModTab) && k == "Tab") ||
+                    ((keyMods & eventModEsc) && (k == "Escape" || k == "Esc")) ||
+                    ((keyMods & eventModSpace) && (k == " " || k == "Spacebar")) ||
+                    ((keyMods & eventModDelete) && (k == "Delete" || k == "Backspace")) ||
+                    ((keyMods & eventModUp) && k == "ArrowUp") ||
+                    ((keyMods & eventModDown) && k == "ArrowDown") ||
+                    ((keyMods & eventModLeft) && k == "ArrowLeft") ||
+                    ((keyMods & eventModRight) && k == "ArrowRight"));
+            }
+        } else if (typeof (event.button) == "number") {
+            let buttonMods = modifiers & (eventModLeft | eventModRight | eventModMiddle);
+            if (buttonMods) {
+                let b = event.button;
+                return !!(((buttonMods & eventModLeft) && b == 0) ||
+                    ((buttonMods & eventModMiddle) && b == 1) ||
+                    ((buttonMods & eventModRight) && b == 2));
+            }
+        }
+
+        return true;
+    }
+
     // Decoder provides our binary decoding.
     // Using a class because that's what all the cool JS kids are doing these days.
     class Decoder {
 
-        constructor(dataView, offset) {
+        constructor(dataView, offset, strings) {
             this.dataView = dataView;
             this.offset = offset || 0;
+            this.strings = strings || []; // interned strings by ID, see opcodeInternString
             return this;
         }
 
@@ -82,9 +204,18 @@
             return ret;
         }
 
-        // readString is 4 bytes length followed by utf-8 chars
+        // readString is 4 bytes length followed by utf-8 chars, or the ID of an interned string
+        // with the high bit set in place of the length
         readString() {
             var len = this.dataView.getUint32(this.offset);
+            if (len >= 0x80000000) {
+                this.offset += 4;
+                var s = this.strings[len - 0x80000000];
+                if (s === undefined) {
+                    throw "unknown interned string " + (len - 0x80000000);
+                }
+                return s;
+            }
             var ret = utf8decoder.decode(new DataView(this.dataView.buffer, this.dataView.byteOffset + this.offset + 4, len));
             this.offset += len + 4;
             return ret;
@@ -94,6 +225,230 @@
 
     let utf8decoder = new TextDecoder();
 
+    // the element with focus, looking into shadow roots, or null if nothing has focus
+    let focusedElement = function () {
+        let el = document.activeElement;
+        while (el && el.shadowRoot && el.shadowRoot.activeElement) {
+            el = el.shadowRoot.activeElement;
+        }
+        return (el && el !== document.body && el !== document.documentElement) ? el : null;
+    }
+
+    let isFocusLost = function () {
+        return focusedElement() === null;
+    }
+
+    // the focused element with the child indexes leading to it from its document or shadow root, which
+    // stay the same when syncing replaces it, like its positionID on the Go side
+    let focusedPath = function () {
+        let el = focusedElement();
+        if (!el) {
+            return null;
+        }
+        let root = el.getRootNode();
+        let path = [];
+        for (let n = el; n && n !== root; n = n.parentNode) {
+            let i = 0;
+            for (let s = n.previousSibling; s; s = s.previousSibling) {
+                i++;
+            }
+            path.unshift(i);
+        }
+        return { el: el, root: root, path: path, selection: selectionOf(el), tries: 0 };
+    }
+
+    // the element with a vg-ref, in the renderer's shadow root if it has one or else the document
+    let elementByRef = function (state, ref) {
+        let sel = "[data-vg-ref=\"" + (window.CSS && CSS.escape ? CSS.escape(ref) : ref) + "\"]";
+        return (state.shadowRoot && state.shadowRoot.querySelector(sel)) || document.querySelector(sel);
+    }
+
+    // the selection of a text input or textarea, null for other elements
+    let selectionOf = function (el) {
+        try {
+            if (typeof el.selectionStart === "number") {
+                return [el.selectionStart, el.selectionEnd];
+            }
+        } catch (err) {
+            // some input types throw
+        }
+        return null;
+    }
+
+    // where a caret at pos goes when the text changes from oldValue to newValue: it stays put if the text
+    // before it is unchanged, keeps its distance from the end if the text after it is, and otherwise goes
+    // to the end of the changed part (or stays put if that is the same length, e.g. for a change of case)
+    let mapCaret = function (pos, oldValue, newValue) {
+        let prefix = 0;
+        while (prefix < oldValue.length && prefix < newValue.length && oldValue[prefix] === newValue[prefix]) {
+            prefix++;
+        }
+        if (pos <= prefix) {
+            return pos;
+        }
+        let suffix = 0;
+        while (suffix < oldValue.length - prefix && suffix < newValue.length - prefix &&
+            oldValue[oldValue.length - 1 - suffix] === newValue[newValue.length - 1 - suffix]) {
+            suffix++;
+        }
+        if (pos >= oldValue.length - suffix) {
+            return newValue.length - (oldValue.length - pos);
+        }
+        if (oldValue.length == newValue.length) {
+            return pos;
+        }
+        return newValue.length - suffix;
+    }
+
+    let elementAtPath = function (root, path) {
+        let n = root;
+        for (let i of path) {
+            n = n && n.childNodes[i];
+        }
+        return n && n.nodeType === 1 ? n : null;
+    }
+
+    // the element with IME composition in progress (e.g. typing Chinese or Japanese), its value is not
+    // set while it lasts as that would cancel the composition; the last value held back is set once it
+    // has ended, unless a render has set the value by then
+    let composition = { el: null, pendingEl: null, pendingValue: "" };
+    document.addEventListener("compositionstart", function (event) {
+        // the target is retargeted to the host for elements in a shadow root (see ShadowRootMode)
+        composition.el = event.composedPath ? event.composedPath()[0] : event.target;
+    }, true);
+    document.addEventListener("compositionend", function (event) {
+        composition.el = null;
+        let el = composition.pendingEl;
+        if (!el) {
+            return;
+        }
+        // give the renders for the last events of the composition a chance to go first
+        window.requestAnimationFrame(function () {
+            window.requestAnimationFrame(function () {
+                if (composition.pendingEl === el) {
+                    composition.pendingEl = null;
+                    if (el.value !== composition.pendingValue) {
+                        el.value = composition.pendingValue;
+                    }
+                }
+            });
+        });
+    }, true);
+
+    // eventProxy returns event with currentTarget as given, onStop (if set) is called when propagation is stopped
+    let eventProxy = function (event, currentTarget, onStop) {
+        return new Proxy(event, {
+            get: function (target, prop) {
+                if (prop == "currentTarget") {
+                    return currentTarget;
+                }
+                if (onStop && (prop == "stopPropagation" || prop == "stopImmediatePropagation")) {
+                    return function () { onStop(); target[prop](); };
+                }
+                let v = target[prop];
+                return typeof v == "function" ? v.bind(target) : v;
+            },
+        });
+    }
+
+    // rateLimiter returns a function which passes the last of the events it is called with on to send, once
+    // none have come for rate.ms milliseconds (debounce, rate.mode "d") or at most once every rate.ms
+    // milliseconds (throttle, "t"), so fast streams of events like keystrokes or scrolling do not each make
+    // a call into Go and a render
+    let rateLimiter = function (rate, send) {
+        let timer = null, pending = null, last = 0;
+        let fire = function () {
+            timer = null;
+            let event = pending;
+            pending = null;
+            last = Date.now();
+            // nothing to handle it any more if the element has been removed since
+            if (event.currentTarget && event.currentTarget.isConnected === false) {
+                return;
+            }
+            send(event);
+        };
+        return function (event) {
+            // the event may be sent after it has been dispatched, when its currentTarget is no longer set
+            pending = eventProxy(event, event.currentTarget);
+            if (rate.mode == "d") {
+                clearTimeout(timer);
+                timer = setTimeout(fire, rate.ms);
+            } else if (!timer) {
+                let wait = last + rate.ms - Date.now();
+                if (wait <= 0) {
+                    fire();
+                } else {
+                    timer = setTimeout(fire, wait);
+                }
+            }
+        };
+    }
+
+    // delegated event listeners (JSRenderer.DelegateEvents): instead of each element having its own
+    // listener, el.vuguDelegated maps the event keys of its listeners to the handler functions and one
+    // listener per event type on the renderer's mount point (or shadow content container) calls them as
+    // the event bubbles up to it
+    let makeDelegatedListener = function (state, passive) {
+        return function (event) {
+            let path = event.composedPath ? event.composedPath() : [];
+            let prefix = event.type + "|0|" + (passive ? "1" : "0") + "|";
+            let stopped = false;
+            for (let i = 0; i < path.length && !stopped; i++) {
+                let el = path[i];
+                let handlers = el.vuguDelegated;
+                // elements of another renderer mounted inside this one are handled by its own listener
+                if (handlers && el.vuguDelegatedState === state) {
+                    // the handlers see the element as the currentTarget, as with a listener of its own
+                    let proxy = eventProxy(event, el, function () { stopped = true; });
+                    for (let k in handlers) {
+                        if (k.startsWith(prefix)) {
+                            handlers[k](proxy);
+                        }
+                    }
+                }
+                if (el === event.currentTarget) {
+                    break;
+                }
+            }
+        };
+    }
+
+    // delegatedRoot returns the element the delegated listeners of state go on
+    let delegatedRoot = function (state) {
+        return state.shadowContentEl || state.mountPointEl;
+    }
+
+    // moveDelegated moves the delegated listeners of state over to its mount point, if the element was replaced
+    let moveDelegated = function (state) {
+        let root = delegatedRoot(state);
+        state.delegatedTypes = state.delegatedTypes || {};
+        state.delegatedListeners = state.delegatedListeners || [makeDelegatedListener(state, false), makeDelegatedListener(state, true)];
+        if (state.delegatedEl === root) {
+            return;
+        }
+        for (let key in state.delegatedTypes) {
+            let kparts = key.split("|");
+            let f = state.delegatedListeners[+kparts[1]];
+            if (state.delegatedEl) {
+                state.delegatedEl.removeEventListener(kparts[0], f, { passive: !!+kparts[1] });
+            }
+            root.addEventListener(kparts[0], f, { passive: !!+kparts[1] });
+        }
+        state.delegatedEl = root;
+    }
+
+    // delegateEvent makes sure the mount point of state has the listener for the event type
+    let delegateEvent = function (state, eventType, passive) {
+        moveDelegated(state);
+        let root = state.delegatedEl;
+        let key = eventType + "|" + (passive ? "1" : "0");
+        if (!state.delegatedTypes[key]) {
+            state.delegatedTypes[key] = true;
+            root.addEventListener(eventType, state.delegatedListeners[passive ? 1 : 0], { passive: !!passive });
+        }
+    }
+
     window.vuguGetActiveEvent = function () {
         let state = window.vuguState || {};
         window.vuguState = state;
@@ -124,6 +479,29 @@
         }
     }
 
+    // start reading the contents of an item on the clipboard of the active paste event,
+    // returns a promise for a Uint8Array, or null if there is no such item
+    window.vuguActiveEventClipboardItem = function (index) {
+        let state = window.vuguState || {};
+        window.vuguState = state;
+        let dt = state.activeEvent && state.activeEvent.clipboardData;
+        let item = dt && dt.items && dt.items[index];
+        if (!item) {
+            return null;
+        }
+        // the browser only allows this during the event, the promise settles after
+        if (item.kind == "file") {
+            let f = item.getAsFile();
+            if (!f) {
+                return null;
+            }
+            return f.arrayBuffer().then(function (b) { return new Uint8Array(b); });
+        }
+        return new Promise(function (resolve) {
+            item.getAsString(function (str) { resolve(new TextEncoder().encode(str)); });
+        });
+    }
+
     // window.vuguSetEventHandlerAndBuffer = function(eventHandlerFunc, eventBuffer) {
     // 	let state = window.vuguState || {};
     //     window.vuguState = state;
@@ -146,18 +524,200 @@
         state.callbackHandlerFunc = callbackHandlerFunc;
     }
 
-    window.vuguGetRenderArray = function () {
-        if (!window.vuguRenderArray) {
-            window.vuguRenderArray = new Uint8Array(16384);
+    // function called on the next animation frame after vuguRequestAnimationFrame
+    window.vuguSetAnimationFrameHandler = function (animationFrameHandlerFunc) {
+        let state = window.vuguState || {};
+        window.vuguState = state;
+        state.animationFrameHandlerFunc = animationFrameHandlerFunc;
+    }
+
+    // schedule a call to the animation frame handler, multiple requests before the frame result in one call
+    window.vuguRequestAnimationFrame = function () {
+        let state = window.vuguState || {};
+        window.vuguState = state;
+        if (state.animationFramePending) {
+            return;
         }
-        return window.vuguRenderArray;
+        state.animationFramePending = true;
+        let f = function (ts) {
+            state.animationFramePending = false;
+            /*DEBUG*/ console.log("vuguRequestAnimationFrame: frame", ts);
+            if (state.animationFrameHandlerFunc) {
+                state.animationFrameHandlerFunc(ts);
+            }
+        };
+        if (window.requestAnimationFrame) {
+            window.requestAnimationFrame(f);
+        } else {
+            window.setTimeout(function () { f(Date.now()); }, 16);
+        }
+    }
+
+    // returns counts of references held by the render state, useful to check for unbounded growth in long-running apps
+    window.vuguDebugCounts = function () {
+        let state = window.vuguState || {};
+        window.vuguState = state;
+        return {
+            eventHandlerPositions: Object.keys(state.eventHandlerMap || {}).length,
+            globalEventListeners: Object.keys(state.globalEventHandlerMap || {}).length,
+            forgottenPositions: state.forgottenPositionCount || 0,
+            sweptPositions: state.sweptPositionCount || 0,
+        };
+    }
+
+    // show text in the debug overlay, along with the JS heap size where available
+    window.vuguDebugOverlay = function (text) {
+        let el = document.getElementById("vugu-debug-overlay");
+        if (!el) {
+            el = document.createElement("pre");
+            el.id = "vugu-debug-overlay";
+            el.style.cssText = "position:fixed;right:0;bottom:0;margin:0;padding:4px 8px;z-index:2147483647;" +
+                "background:rgba(0,0,0,0.75);color:#fff;font:11px monospace;pointer-events:none;";
+            // outside of body so it does not interfere with rendering
+            document.documentElement.appendChild(el);
+        }
+        let mem = window.performance && window.performance.memory;
+        if (mem) {
+            text += "js heap used: " + mem.usedJSHeapSize + "\n";
+            text += "js heap total: " + mem.totalJSHeapSize + "\n";
+        }
+        let counts = window.vuguDebugCounts();
+        text += "js handler positions: " + counts.eventHandlerPositions + "\n";
+        el.textContent = text;
+    }
+
+    // show an error over the page, click to dismiss, empty html removes it
+    window.vuguErrorOverlay = function (html) {
+        let el = document.getElementById("vugu-error-overlay");
+        if (!html) {
+            if (el) {
+                el.parentNode.removeChild(el);
+            }
+            return;
+        }
+        if (!el) {
+            el = document.createElement("div");
+            el.id = "vugu-error-overlay";
+            el.style.cssText = "position:fixed;left:0;right:0;top:0;max-height:50%;overflow:auto;padding:8px 12px;" +
+                "z-index:2147483647;background:#b00020;color:#fff;font:13px sans-serif;cursor:pointer;";
+            el.addEventListener("click", function () { el.parentNode.removeChild(el); });
+            // outside of body so it does not interfere with rendering
+            document.documentElement.appendChild(el);
+        }
+        el.innerHTML = html;
+    }
+
+    // dispatch a CustomEvent after the current call stack (e.g. a Go event handler) finishes
+    window.vuguDispatchCustomEvent = function (target, eventType, detailJSON) {
+        let ev = new CustomEvent(eventType, { detail: JSON.parse(detailJSON), bubbles: true, composed: true });
+        let f = function () { target.dispatchEvent(ev); };
+        if (window.queueMicrotask) {
+            window.queueMicrotask(f);
+        } else {
+            setTimeout(f, 0);
+        }
+    }
+
+    // vuguCall calls the function with the specified name with the state of a renderer instance selected,
+    // so several renderers can share the page, each with its own mount point, handlers and so on.
+    // The functions called directly (rather than through vuguCall) use the state selected last.
+    window.vuguCall = function (instance, name) {
+        window.vuguStates = window.vuguStates || {};
+        let prev = window.vuguState;
+        let state = window.vuguStates[instance];
+        if (!state) {
+            // the first instance takes over any state from before instances were used
+            state = (Object.keys(window.vuguStates).length == 0 && prev) || {};
+            window.vuguStates[instance] = state;
+        }
+        window.vuguState = state;
+        try {
+            return window[name].apply(window, Array.prototype.slice.call(arguments, 2));
+        } finally {
+            if (prev) {
+                window.vuguState = prev;
+            }
+        }
+    }
+
+    // vuguDefineElement defines a custom element (see the vgelement package) which calls the functions
+    // given as it is added to and removed from the page, and as its attributes in attrs change
+    window.vuguDefineElement = function (name, attrs, connected, disconnected, changed) {
+        class VuguElement extends HTMLElement {
+            static get observedAttributes() { return attrs; }
+            connectedCallback() { connected(this); }
+            disconnectedCallback() { disconnected(this); }
+            attributeChangedCallback(attr, oldValue, newValue) { changed(this, attr); }
+        }
+        customElements.define(name, VuguElement);
+    }
+
+    // vuguNewInstance returns a new ID for use with vuguCall, unique on the page even with several programs
+    window.vuguNewInstance = function () {
+        window.vuguInstanceCount = (window.vuguInstanceCount || 0) + 1;
+        return window.vuguInstanceCount;
+    }
+
+    // findMountPoint returns the element matching a mount point selector, or the one registered for it in
+    // window.vuguMountPoints, for mount points which a selector cannot reach such as those in a shadow root
+    function findMountPoint(selector) {
+        return (window.vuguMountPoints && window.vuguMountPoints[selector]) || document.querySelector(selector);
+    }
+
+    // vuguFreeInstance drops the state of a renderer instance which is done, removing what it added outside
+    // of its mount point: window and document event listeners, portal containers and the content of its shadow root
+    window.vuguFreeInstance = function (instance) {
+        let state = window.vuguStates && window.vuguStates[instance];
+        if (!state) {
+            return;
+        }
+        for (let k in state.globalEventHandlerMap || {}) {
+            let h = state.globalEventHandlerMap[k];
+            h.target.removeEventListener(h.eventType, h.f, {capture: h.capture, passive: h.passive});
+        }
+        for (let k in state.portalEls || {}) {
+            let el = state.portalEls[k];
+            if (el.parentNode) {
+                el.parentNode.removeChild(el);
+            }
+        }
+        if (state.shadowRoot) {
+            state.shadowRoot.innerHTML = "";
+        }
+        for (let key in state.delegatedTypes || {}) {
+            let kparts = key.split("|");
+            state.delegatedEl.removeEventListener(kparts[0], state.delegatedListeners[+kparts[1]], { passive: !!+kparts[1] });
+        }
+        if (state.visibleObserver) {
+            state.visibleObserver.disconnect();
+        }
+        if (state.resizeObserver) {
+            state.resizeObserver.disconnect();
+        }
+        delete window.vuguStates[instance];
+        if (window.vuguState === state) {
+            window.vuguState = null;
+        }
+    }
+
+    // vuguGetRenderArray returns the array instructions are copied into, making it at least size bytes
+    window.vuguGetRenderArray = function (size) {
+        let state = window.vuguState || {};
+        window.vuguState = state;
+        size = Math.max(size || 0, 16384);
+        if (!state.renderArray || state.renderArray.length < size) {
+            state.renderArray = new Uint8Array(size);
+        }
+        return state.renderArray;
     }
 
     window.vuguRender = function () {
 
-        let buffer = window.vuguRenderArray;
-        if (!window.vuguRenderArray) {
-            throw "window.vuguRenderArray is not set";
+        let renderStart = window.performance ? window.performance.now() : 0;
+
+        let buffer = (window.vuguState || {}).renderArray;
+        if (!buffer) {
+            throw "render array is not set, call vuguGetRenderArray first";
         }
 
         // NOTE: vuguRender must not automatically reset anything between calls.
@@ -175,7 +735,9 @@
 
         let bufferView = new DataView(buffer.buffer, buffer.byteOffset, buffer.byteLength);
 
-        var decoder = new Decoder(bufferView, 0);
+        state.strings = state.strings || [];
+
+        var decoder = new Decoder(bufferView, 0, state.strings);
 
         // state.refMap = state.refMap || {};
         // state.curRef = state.curRef || ""; // current reference number (as a hex string)
@@ -185,6 +747,11 @@
         // mount point element
         state.mountPointEl = state.mountPointEl || null;
 
+        // where the focused element is, so focus can be restored if syncing replaces it
+        if (!state.focusRestore) {
+            state.focusRestore = focusedPath();
+        }
+
         // currently selected element
         state.el = state.el || null;
 
@@ -206,9 +773,379 @@
         // map of positionID -> array of listener spec and handler function, for all elements
         state.eventHandlerMap = state.eventHandlerMap || {};
 
+        // map of positionID -> the element its listeners were last set on, so opcodeSweepPositions can find
+        // positions whose element is gone
+        state.eventHandlerEls = state.eventHandlerEls || {};
+
         // keeps track of event listeners that are being set on the current element, so we can remvoe any extras
         state.elEventKeys = state.elEventKeys || {};
 
+        // map of scroll key -> [scrollTop, scrollLeft] of the element with that key, see opcodeSetScrollKey
+        state.scrollPositions = state.scrollPositions || {};
+
+        // elements given a scroll key in this buffer which need their scroll position restored at the end of it
+        state.scrollRestore = state.scrollRestore || [];
+
+        // [element, action] pairs for vg-focus and vg-scroll-into-view, done at the end of the buffer
+        state.postRender = state.postRender || [];
+
+        // map of positionID|target|eventKey -> listener spec and handler function, for window and document listeners
+        state.globalEventHandlerMap = state.globalEventHandlerMap || {};
+
+        // keeps track of window and document listeners set since the last opcodeRemoveOtherGlobalEventListeners
+        state.globalEventKeys = state.globalEventKeys || {};
+
+        // dropPosition removes the listeners set for positionID from its element, which may still be
+        // referenced elsewhere, and the references to them
+        let dropPosition = function (positionID) {
+            let el = state.eventHandlerEls[positionID];
+            let emap = state.eventHandlerMap[positionID];
+            if (el) {
+                for (let k in emap) {
+                    if (el.vuguDelegated && el.vuguDelegated[k]) {
+                        delete el.vuguDelegated[k];
+                        continue;
+                    }
+                    let kparts = k.split("|");
+                    el.removeEventListener(kparts[0], emap[k], {capture: +kparts[1], passive: +kparts[2]});
+                }
+                if (state.visibleObserver) {
+                    state.visibleObserver.unobserve(el);
+                }
+                if (state.resizeObserver) {
+                    state.resizeObserver.unobserve(el);
+                }
+            }
+            delete state.eventHandlerMap[positionID];
+            delete state.eventHandlerEls[positionID];
+        };
+
+        // observeVisible starts sending "vgvisible" events (vg-visible) to el when it enters or leaves the
+        // viewport, with whether it is visible and how much of it is in the detail
+        let observeVisible = function (el) {
+            if (!window.IntersectionObserver) {
+                return;
+            }
+            if (!state.visibleObserver) {
+                state.visibleObserver = new IntersectionObserver(function (entries) {
+                    for (let entry of entries) {
+                        // removed elements are reported as not intersecting, they are replaced by now
+                        if (!entry.target.isConnected) {
+                            state.visibleObserver.unobserve(entry.target);
+                            continue;
+                        }
+                        entry.target.dispatchEvent(new CustomEvent("vgvisible", {
+                            detail: { visible: entry.isIntersecting, ratio: entry.intersectionRatio },
+                        }));
+                    }
+                });
+            }
+            state.visibleObserver.observe(el);
+        }
+
+        // observeResize starts sending "vgresize" events (vg-resize) to el when its size changes,
+        // with the width and height of its content box in the detail
+        let observeResize = function (el) {
+            if (!window.ResizeObserver) {
+                return;
+            }
+            if (!state.resizeObserver) {
+                state.resizeObserver = new ResizeObserver(function (entries) {
+                    for (let entry of entries) {
+                        if (!entry.target.isConnected) {
+                            state.resizeObserver.unobserve(entry.target);
+                            continue;
+                        }
+                        entry.target.dispatchEvent(new CustomEvent("vgresize", {
+                            detail: { width: entry.contentRect.width, height: entry.contentRect.height },
+                        }));
+                    }
+                });
+            }
+            state.resizeObserver.observe(el);
+        }
+
+        // makeEventListener returns a function to be passed to addEventListener which forwards events to Go,
+        // globalTarget is "window" or "document" for global listeners and empty for elements, rate is set
+        // for debounced or throttled listeners (see opcodeSetEventRate)
+        let makeEventListener = function (positionID, eventType, capture, passive, modifiers, globalTarget, rate) {
+
+            // send passes the event on to Go
+            let send = function (event) {
+
+                // set the active event, so the Go code and call back in and examine it if needed
+                state.activeEvent = event;
+
+                let eventObj = {};
+                // console.log(event);
+                for (let i in event) {
+                    let itype = typeof (event[i]);
+                    // copy primitive values directly
+                    if ((itype == "boolean" || itype == "number" || itype == "string") && true/*event.hasOwnProperty(i)*/) {
+                        eventObj[i] = event[i];
+                    }
+                }
+
+                // also do the same for anything in "target"
+                if (event.target) {
+                    eventObj.target = {};
+                    let et = event.target;
+                    for (let i in et) {
+                        let itype = typeof (et[i]);
+                        if ((itype == "boolean" || itype == "number" || itype == "string") && true/*et.hasOwnProperty(i)*/) {
+                            eventObj.target[i] = et[i];
+                        }
+                    }
+                }
+
+                // the selected files of a file input, and the files dropped by a drop event (contents are read with vgfile)
+                let fileList = function (files) {
+                    let ret = [];
+                    for (let i = 0; i < files.length; i++) {
+                        let f = files[i];
+                        ret.push({ name: f.name, type: f.type, size: f.size, lastModified: f.lastModified });
+                    }
+                    return ret;
+                }
+                // the values of the selected options of a select, for multi-selects (see EventSelectedValues in Go)
+                if (event.target && event.target.selectedOptions) {
+                    let vals = [];
+                    for (let i = 0; i < event.target.selectedOptions.length; i++) {
+                        vals.push(event.target.selectedOptions[i].value);
+                    }
+                    eventObj.target.selectedValues = vals;
+                }
+                if (event.target && event.target.files) {
+                    eventObj.target.files = fileList(event.target.files);
+                }
+                if (event.dataTransfer && event.dataTransfer.files && event.dataTransfer.files.length) {
+                    eventObj.files = fileList(event.dataTransfer.files);
+                }
+
+                // the points of contact of touch events, which are lists so are not copied above
+                let touchList = function (touches) {
+                    let ret = [];
+                    for (let i = 0; i < touches.length; i++) {
+                        let t = touches[i];
+                        ret.push({
+                            identifier: t.identifier, clientX: t.clientX, clientY: t.clientY, pageX: t.pageX, pageY: t.pageY,
+                            screenX: t.screenX, screenY: t.screenY, radiusX: t.radiusX || 0, radiusY: t.radiusY || 0,
+                            rotationAngle: t.rotationAngle || 0, force: t.force || 0,
+                        });
+                    }
+                    return ret;
+                }
+                if (event.touches) {
+                    eventObj.touches = touchList(event.touches);
+                    eventObj.changedTouches = touchList(event.changedTouches || []);
+                    eventObj.targetTouches = touchList(event.targetTouches || []);
+                }
+
+                // pointer moves the browser coalesced into this one, so drawing surfaces get every point
+                if (event.getCoalescedEvents) {
+                    let ce = event.getCoalescedEvents();
+                    if (ce && ce.length > 1) {
+                        eventObj.coalesced = [];
+                        for (let i = 0; i < ce.length; i++) {
+                            let c = ce[i];
+                            eventObj.coalesced.push({ clientX: c.clientX, clientY: c.clientY, offsetX: c.offsetX, offsetY: c.offsetY, pressure: c.pressure, timeStamp: c.timeStamp });
+                        }
+                    }
+                }
+
+                // structured detail from a CustomEvent, e.g. dispatched by other scripts on the page
+                if (window.CustomEvent && event instanceof CustomEvent && event.detail !== undefined && event.detail !== null) {
+                    try {
+                        eventObj.detail = JSON.parse(JSON.stringify(event.detail));
+                    } catch (err) {
+                        console.warn("vugu: CustomEvent detail could not be encoded as JSON", err);
+                    }
+                }
+
+                // what is on the clipboard for paste (and copy and cut) events, the contents are read on request
+                if (event.clipboardData) {
+                    let dt = event.clipboardData;
+                    eventObj.clipboard = { types: Array.prototype.slice.call(dt.types || []), items: [], text: dt.getData("text/plain") || "" };
+                    for (let i = 0; i < (dt.items ? dt.items.length : 0); i++) {
+                        let item = dt.items[i];
+                        let info = { kind: item.kind, type: item.type };
+                        if (item.kind == "file") {
+                            let f = item.getAsFile();
+                            if (f) {
+                                info.name = f.name || "";
+                                info.size = f.size;
+                            }
+                        }
+                        eventObj.clipboard.items.push(info);
+                    }
+                }
+
+                // extra data requested by modifiers
+                if (modifiers & eventModSelection) {
+                    let et = event.target;
+                    if (et && typeof (et.selectionStart) == "number" && typeof (et.value) == "string") {
+                        eventObj.selection = {
+                            text: et.value.substring(et.selectionStart, et.selectionEnd),
+                            start: et.selectionStart,
+                            end: et.selectionEnd,
+                            collapsed: et.selectionStart == et.selectionEnd,
+                        };
+                    } else if (window.getSelection) {
+                        let sel = window.getSelection();
+                        eventObj.selection = {
+                            text: sel.toString(),
+                            start: sel.anchorOffset,
+                            end: sel.focusOffset,
+                            collapsed: sel.isCollapsed,
+                        };
+                    }
+                }
+                if (modifiers & eventModDataset) {
+                    eventObj.dataset = {};
+                    let ds = event.currentTarget && event.currentTarget.dataset;
+                    for (let k in ds || {}) {
+                        eventObj.dataset[k] = ds[k];
+                    }
+                }
+
+                if (modifiers & eventModAction) {
+                    let ct = event.currentTarget, et = event.target;
+                    if (et && et.nodeType != 1) {
+                        et = et.parentElement;
+                    }
+                    let el = et && et.closest && et.closest("[data-vg-action]");
+                    if (el && ct && (el === ct || (ct.contains && ct.contains(el)))) {
+                        eventObj.action = { name: el.getAttribute("data-vg-action"), value: el.getAttribute("data-vg-value") || "" };
+                    }
+                }
+                if (modifiers & eventModForm) {
+                    let ct = event.currentTarget, et = event.target;
+                    let form = (ct && ct.tagName == "FORM") ? ct : ((et && et.form) || (et && et.closest && et.closest("form")));
+                    if (form && window.FormData) {
+                        eventObj.form = {};
+                        new FormData(form).forEach(function (v, k) {
+                            // files are represented by their name
+                            if (typeof (v) != "string") {
+                                v = v.name || "";
+                            }
+                            (eventObj.form[k] = eventObj.form[k] || []).push(v);
+                        });
+                    }
+                }
+
+                // console.log(eventObj);
+                // console.log(JSON.stringify(eventObj));
+
+                let fullJSON = JSON.stringify({
+
+                    // payload version, so Go can tell if it's talking to a different helper script
+                    v: eventPayloadVersion,
+
+                    // include properties from event registration
+                    position_id: positionID,
+                    event_type: eventType,
+                    capture: !!capture,
+                    passive: !!passive,
+                    modifiers: modifiers,
+                    global_target: globalTarget,
+                    rate: rate ? rate.key : "",
+
+                    // the event object data as extracted above
+                    event_summary: eventObj,
+
+                });
+
+                // console.log(state.eventBuffer);
+
+                // write JSON to state.eventBuffer with uint32 length prefix
+
+                let encodeResultBuffer = textEncoder.encode(fullJSON);
+
+                const dataSize = encodeResultBuffer.byteLength - encodeResultBuffer.byteOffset
+                // we need to allocate more bytes for storing data size in the beginning of the buffer
+                const requiredBufferSize = dataSize + 4
+
+                const computeEventBufferSize = (requiredBufferSize) => {
+                    const sixteen_kb = 16384
+                    const actualRequired = requiredBufferSize + 1
+                    const remainder = actualRequired % sixteen_kb
+
+                    // but for now this needs to be at least one byte shorter
+                    // than Go's buffer
+                    if (remainder === 0) {
+                        return actualRequired - 1
+                    }
+
+                    return actualRequired + (sixteen_kb - remainder) - 1
+                }
+
+                // before eventHandlerFunc is called make sure eventBuffer and eventBufferView are setup,
+                // and allocateEventBuffer is called
+                let eventBuffer = state.eventBuffer;
+                if (!eventBuffer || eventBuffer.length < requiredBufferSize) {
+                    const eventBufferSize = computeEventBufferSize(requiredBufferSize)
+                    eventBuffer = new Uint8Array(eventBufferSize);
+                    state.eventBuffer = eventBuffer;
+                    state.eventBufferView = new DataView(eventBuffer.buffer, eventBuffer.byteOffset, eventBuffer.byteLength);
+                }
+                //console.log("encodeResult", encodeResult);
+                state.eventBuffer.set(encodeResultBuffer, 4); // copy encoded string to event buffer
+                // now write length using DataView as uint32
+                state.eventBufferView.setUint32(0, dataSize);
+
+                // let result = textEncoder.encodeInto(fullJSON, state.eventBuffer);
+                // let eventBufferDataView = new DataView(state.eventBuffer.buffer, state.eventBuffer.byteOffset, state.eventBuffer.byteLength);
+                // eventBufferDataView.setUint8(result.written, 0);
+
+                // write length after, since only now do we know the final length
+                // state.eventBufferView.setUint32(0, result.written);
+
+                // serialize event into the event buffer, somehow,
+                // and keep track of the target element, also consider grabbing
+                // the value or relevant properties as appropriate for form things
+
+                /*DEBUG*/ console.log("event handler calling state.eventHandlerFunc", eventBuffer);
+                let prevState = window.vuguState;
+                window.vuguState = state; // so the handler gets this renderer's active event
+                try {
+                    state.eventHandlerFunc.call(null, eventBuffer); // call with null this avoids unnecessary js.Value reference
+                } finally {
+                    window.vuguState = prevState;
+                }
+
+                // unset the active event
+                state.activeEvent = null;
+            };
+            let limited = rate ? rateLimiter(rate, send) : send;
+
+            return function (event) {
+
+                /*DEBUG*/ console.log("event listener called with event", event);
+
+                // apply modifiers, filtered events never make it to Go
+                if (modifiers) {
+                    if (!eventModifiersMatch(event, modifiers)) {
+                        return;
+                    }
+                    if ((modifiers & eventModPrevent) && event.preventDefault) {
+                        event.preventDefault();
+                    }
+                    if ((modifiers & eventModStop) && event.stopPropagation) {
+                        event.stopPropagation();
+                    }
+                }
+
+                limited(event);
+            };
+        };
+
+        // takeEventRate returns the rate set by opcodeSetEventRate for the listener being set, if any
+        let takeEventRate = function () {
+            let rate = state.nextEventRate;
+            state.nextEventRate = null;
+            return rate;
+        };
+
         instructionLoop: while (true) {
 
             let opcode = decoder.readUint8();
@@ -237,7 +1174,151 @@
                         let propName = decoder.readString();
                         let propValueJSON = decoder.readString();
                         /*DEBUG*/ console.log("opcodeSetProperty", propName, propValueJSON);
-                        el[propName] = JSON.parse(propValueJSON);
+                        let propValue = JSON.parse(propValueJSON);
+                        el[propName] = propValue;
+                        // the value of a select can only select an option which exists, so it is set again
+                        // once its options are synced (see opcodeMoveToParent)
+                        if (propName == "value" && el.tagName == "SELECT") {
+                            el.vuguSelectValue = propValue;
+                        }
+                        break;
+                    }
+
+                    // only assigned if different, setting value on an input moves the cursor
+                    case opcodeSetPropertyStr: {
+                        let el = state.el;
+                        if (!el) {
+                            throw "opcodeSetPropertyStr: no current reference";
+                        }
+                        let propName = decoder.readString();
+                        let propValue = decoder.readString();
+                        /*DEBUG*/ console.log("opcodeSetPropertyStr", propName, propValue);
+                        if (propName == "value") {
+                            if (el === composition.el) {
+                                composition.pendingEl = el;
+                                composition.pendingValue = propValue;
+                                break;
+                            }
+                            if (el === composition.pendingEl) {
+                                composition.pendingEl = null;
+                            }
+                        }
+                        if (el[propName] !== propValue) {
+                            // setting the value moves the caret to the end, put it back where the user was typing
+                            let sel = propName == "value" && el === focusedElement() ? selectionOf(el) : null;
+                            let oldValue = sel ? el.value : "";
+                            el[propName] = propValue;
+                            if (sel) {
+                                try {
+                                    el.setSelectionRange(mapCaret(sel[0], oldValue, propValue), mapCaret(sel[1], oldValue, propValue));
+                                } catch (err) {
+                                    // the element does not support a selection
+                                }
+                            }
+                        }
+                        break;
+                    }
+
+                    case opcodeSetPropertyBool: {
+                        let el = state.el;
+                        if (!el) {
+                            throw "opcodeSetPropertyBool: no current reference";
+                        }
+                        let propName = decoder.readString();
+                        let propValue = decoder.readUint8() != 0;
+                        /*DEBUG*/ console.log("opcodeSetPropertyBool", propName, propValue);
+                        if (el[propName] !== propValue) {
+                            el[propName] = propValue;
+                        }
+                        break;
+                    }
+
+                    // no instruction is needed to show the element again, syncing the
+                    // style attribute (or removing it) clears the display set here
+                    case opcodeSetHidden: {
+                        let el = state.el;
+                        if (!el) {
+                            throw "opcodeSetHidden: no current reference";
+                        }
+                        /*DEBUG*/ console.log("opcodeSetHidden");
+                        el.style.display = "none";
+                        break;
+                    }
+
+                    case opcodeSetClassList: {
+                        let el = state.el;
+                        if (!el) {
+                            throw "opcodeSetClassList: no current reference";
+                        }
+                        let classes = decoder.readString();
+                        /*DEBUG*/ console.log("opcodeSetClassList", classes);
+                        let want = {};
+                        for (let c of classes.split(" ")) {
+                            if (c) {
+                                want[c] = true;
+                            }
+                        }
+                        let rm = [];
+                        for (let i = 0; i < el.classList.length; i++) {
+                            if (!want[el.classList[i]]) {
+                                rm.push(el.classList[i]);
+                            }
+                        }
+                        for (let c of rm) {
+                            el.classList.remove(c);
+                        }
+                        for (let c in want) {
+                            if (!el.classList.contains(c)) {
+                                el.classList.add(c);
+                            }
+                        }
+                        state.elAttrNames["class"] = true;
+                        break;
+                    }
+
+                    case opcodeSetStyle: {
+                        let el = state.el;
+                        if (!el) {
+                            throw "opcodeSetStyle: no current reference";
+                        }
+                        let baseStyle = decoder.readString();
+                        let count = decoder.readUint32();
+                        /*DEBUG*/ console.log("opcodeSetStyle", baseStyle, count);
+
+                        // let the browser parse the style attribute value and apply the properties over it,
+                        // so shorthands are expanded and values normalized the same as they are on el
+                        if (!state.styleTmpEl) {
+                            state.styleTmpEl = document.createElement("div");
+                        }
+                        let tmp = state.styleTmpEl;
+                        tmp.setAttribute("style", baseStyle);
+                        for (let i = 0; i < count; i++) {
+                            let name = decoder.readString();
+                            let value = decoder.readString();
+                            tmp.style.setProperty(name, value);
+                        }
+                        let want = {};
+                        for (let i = 0; i < tmp.style.length; i++) {
+                            let name = tmp.style[i];
+                            want[name] = [tmp.style.getPropertyValue(name), tmp.style.getPropertyPriority(name)];
+                        }
+
+                        // remove what's no longer wanted, then set anything that's different
+                        let rm = [];
+                        for (let i = 0; i < el.style.length; i++) {
+                            if (!want[el.style[i]]) {
+                                rm.push(el.style[i]);
+                            }
+                        }
+                        for (let name of rm) {
+                            el.style.removeProperty(name);
+                        }
+                        for (let name in want) {
+                            if (el.style.getPropertyValue(name) !== want[name][0] || el.style.getPropertyPriority(name) !== want[name][1]) {
+                                el.style.setProperty(name, want[name][0], want[name][1]);
+                            }
+                        }
+                        state.elAttrNames["style"] = true;
                         break;
                     }
 
@@ -263,6 +1344,24 @@
                         break;
                     }
 
+                    // boolean attributes are present or absent, e.g. disabled="false" in Go removes disabled
+                    case opcodeSetAttrBool: {
+                        let el = state.el;
+                        if (!el) {
+                            throw "opcodeSetAttrBool: no current reference";
+                        }
+                        let attrName = decoder.readString();
+                        let present = decoder.readUint8() != 0;
+                        /*DEBUG*/ console.log("opcodeSetAttrBool", attrName, present);
+                        if (present) {
+                            el.setAttribute(attrName, "");
+                            state.elAttrNames[attrName] = true;
+                        } else if (el.hasAttribute(attrName)) {
+                            el.removeAttribute(attrName);
+                        }
+                        break;
+                    }
+
                     case opcodeSetAttrNSStr: {
                         let el = state.el;
                         if (!el) {
@@ -300,7 +1399,7 @@
                             // state.elStack.push(state.mountPointEl);
                         } else {
                             // console.log("opcodeSelectMountPoint: state.mountPointEl does not exist, using selector to find it", selector);
-                            let el = document.querySelector(selector);
+                            let el = findMountPoint(selector);
                             if (!el) {
                                 throw "mount point selector not found: " + selector;
                             }
@@ -320,6 +1419,10 @@
                             state.mountPointEl = newEl;
                             el = newEl;
 
+                            if (state.delegatedEl) {
+                                moveDelegated(state);
+                            }
+
                         }
 
                         state.el = el;
@@ -329,6 +1432,67 @@
                         break;
                     }
 
+                    case opcodeSelectMountPointContainer: {
+
+                        state.elAttrNames = {}; // reset attribute list
+                        state.elEventKeys = {};
+
+                        // same as opcodeSelectMountPoint except the element is never replaced,
+                        // a component with multiple root nodes renders them as its children
+                        let selector = decoder.readString();
+
+                        /*DEBUG*/ console.log("opcodeSelectMountPointContainer", selector);
+
+                        if (!state.mountPointEl) {
+                            let el = findMountPoint(selector);
+                            if (!el) {
+                                throw "mount point selector not found: " + selector;
+                            }
+                            state.mountPointEl = el;
+                        }
+
+                        state.el = state.mountPointEl;
+
+                        state.nextElMove = null;
+
+                        break;
+                    }
+
+                    case opcodeSelectShadowRoot: {
+
+                        state.elAttrNames = {}; // reset attribute list
+                        state.elEventKeys = {};
+
+                        let selector = decoder.readString();
+                        let mode = decoder.readString();
+
+                        /*DEBUG*/ console.log("opcodeSelectShadowRoot", selector, mode);
+
+                        // the content goes in a container of its own so the CSS tags, which are put in the shadow root, are left alone
+                        if (!state.shadowContentEl) {
+                            if (!state.mountPointEl) {
+                                let el = findMountPoint(selector);
+                                if (!el) {
+                                    throw "mount point selector not found: " + selector;
+                                }
+                                state.mountPointEl = el;
+                            }
+                            let root = state.mountPointEl.shadowRoot || state.mountPointEl.attachShadow({mode: mode});
+                            let el = document.createElement("div");
+                            el.setAttribute("data-vugu-shadow-content", "");
+                            el.style.display = "contents";
+                            root.appendChild(el);
+                            state.shadowRoot = root;
+                            state.shadowContentEl = el;
+                        }
+
+                        state.el = state.shadowContentEl;
+
+                        state.nextElMove = null;
+
+                        break;
+                    }
+
                     // remove any elements for the current element that we didn't just set
                     case opcodeRemoveOtherAttrs: {
 
@@ -353,6 +1517,20 @@
                             state.el.attributes.removeNamedItem(rmAttrNames[i]);
                         }
 
+                        // removing these attributes does not change what a form element shows once the user has
+                        // interacted with it, the corresponding property must be reset as well (see formProperties in Go);
+                        // except the value of inputs like checkboxes, where the property is the attribute itself
+                        // (setting it would add the attribute back) and of file inputs (it would clear the selection)
+                        for (let i = 0; i < rmAttrNames.length; i++) {
+                            let n = rmAttrNames[i], tag = state.el.tagName;
+                            if ((n == "checked" && tag == "INPUT") || (n == "selected" && tag == "OPTION")) {
+                                state.el[n] = false;
+                            } else if (n == "value" && (tag == "TEXTAREA" ||
+                                (tag == "INPUT" && !/^(checkbox|radio|hidden|submit|reset|button|image|file)$/.test(state.el.type)))) {
+                                state.el.value = "";
+                            }
+                        }
+
                         break;
                     }
 
@@ -364,6 +1542,7 @@
                         // if first_child is next move then we just unset this
                         if (state.nextElMove == "first_child") {
                             state.nextElMove = null;
+                            state.el.vuguSelectValue = undefined;
                         } else {
                             // otherwise we move all silbings after current one, move to parent and reset nextElMove
                             let p = state.el.parentNode;
@@ -375,6 +1554,11 @@
                             state.el = p;
                             state.nextElMove = null;
 
+                            if (p.vuguSelectValue !== undefined) {
+                                p.value = p.vuguSelectValue;
+                                p.vuguSelectValue = undefined;
+                            }
+
                             // // otherwise we actually move and also reset nextElMove
                             // state.el = state.el.parentNode;
                             // state.nextElMove = null;
@@ -405,6 +1589,7 @@
                         if (!state.el) {
                             throw "must have current selection to use opcodeMoveToFirstChild";
                         }
+                        state.el.vuguStaticHTML = null; // its children are being synced, so it no longer has the static HTML
                         state.nextElMove = "first_child";
 
                         break;
@@ -482,7 +1667,8 @@
                         // if we get here we need to verify that state.el is in fact an element of the right type
                         // and replace if not
 
-                        if (state.el.nodeType != 1 || state.el.nodeName.toUpperCase() != nodeName.toUpperCase()) {
+                        // (the namespace is checked too, an SVG element with the same name as an HTML one will not render)
+                        if (state.el.nodeType != 1 || state.el.nodeName.toUpperCase() != nodeName.toUpperCase() || state.el.namespaceURI != "http://www.w3.org/1999/xhtml") {
 
                             let newEl = document.createElement(nodeName);
                             // throw "stopping here";
@@ -539,7 +1725,7 @@
                         // if we get here we need to verify that state.el is in fact an element of the right type
                         // and replace if not
 
-                        if (state.el.nodeType != 1 || state.el.nodeName.toUpperCase() != nodeName.toUpperCase()) {
+                        if (state.el.nodeType != 1 || state.el.nodeName.toUpperCase() != nodeName.toUpperCase() || state.el.namespaceURI != namespace) {
 
                             let newEl = document.createElementNS(namespace, nodeName);
                             // throw "stopping here";
@@ -694,8 +1880,92 @@
                         }
 
                         state.el.innerHTML = (state.bufferedInnerHTML || "") + html;
+                        state.el.vuguStaticHTML = null;
+                        state.bufferedInnerHTML = null
+
+                        break;
+                    }
+
+                    case opcodeSetStaticInnerHTML: {
+
+                        let html = (state.bufferedInnerHTML || "") + decoder.readString();
                         state.bufferedInnerHTML = null
 
+                        /*DEBUG*/ console.log("opcodeSetStaticInnerHTML", html);
+
+                        if (!state.el) {
+                            throw "opcodeSetStaticInnerHTML must have currently selected element";
+                        }
+                        if (state.nextElMove) {
+                            throw "opcodeSetStaticInnerHTML nextElMove must not be set";
+                        }
+                        if (state.el.nodeType != 1) {
+                            throw "opcodeSetStaticInnerHTML currently selected element expected nodeType 1 but has: " + state.el.nodeType;
+                        }
+
+                        // only set when the element is new or had something else in it, so the content is left alone otherwise
+                        if (state.el.vuguStaticHTML !== html) {
+                            state.el.innerHTML = html;
+                            state.el.vuguStaticHTML = html;
+                        }
+
+                        break;
+                    }
+
+                    // the position is recorded as the element is scrolled, so it is known if the element
+                    // is later recreated (restored once the buffer is done and its content is there)
+                    case opcodeSetScrollKey: {
+                        let key = decoder.readString();
+                        let el = state.el;
+                        if (!el) {
+                            throw "opcodeSetScrollKey: no current reference";
+                        }
+                        /*DEBUG*/ console.log("opcodeSetScrollKey", key);
+                        if (el.vuguScrollKey === undefined) {
+                            el.addEventListener("scroll", function () {
+                                state.scrollPositions[el.vuguScrollKey] = [el.scrollTop, el.scrollLeft];
+                            }, {passive: true});
+                        }
+                        if (el.vuguScrollKey !== key) {
+                            el.vuguScrollKey = key;
+                            state.scrollRestore.push(el);
+                        }
+                        break;
+                    }
+
+                    // copy text to the clipboard once the buffer is done
+                    case opcodeWriteClipboard: {
+                        let text = decoder.readString();
+                        /*DEBUG*/ console.log("opcodeWriteClipboard", text);
+                        state.clipboardText = text;
+                        break;
+                    }
+
+                    case opcodeFocusRef: {
+                        let ref = decoder.readString();
+                        /*DEBUG*/ console.log("opcodeFocusRef", ref);
+                        state.focusRef = ref;
+                        break;
+                    }
+
+                    case opcodeSetSelectionRef: {
+                        let ref = decoder.readString();
+                        let start = decoder.readUint32();
+                        let end = decoder.readUint32();
+                        /*DEBUG*/ console.log("opcodeSetSelectionRef", ref, start, end);
+                        state.selectionRef = [ref, start, end];
+                        break;
+                    }
+
+                    // focus or scroll to the current element, once the buffer is done and it is in the document
+                    case opcodeFocus:
+                    case opcodeScrollIntoView: {
+                        let el = state.el;
+                        if (!el) {
+                            throw "opcodeFocus/opcodeScrollIntoView: no current reference";
+                        }
+                        /*DEBUG*/ console.log(opcode == opcodeFocus ? "opcodeFocus" : "opcodeScrollIntoView");
+                        state.postRender.push([el, opcode]);
                         break;
                     }
 
@@ -722,12 +1992,27 @@
                             let f = emap[k];
                             let kparts = k.split("|");
                             state.el.removeEventListener(kparts[0], f, {capture: +kparts[1], passive: +kparts[2]});
+                            if (state.el.vuguDelegated) {
+                                delete state.el.vuguDelegated[k];
+                            }
                             delete emap[k];
                         }
 
+                        // stop observing for vg-visible once there are no listeners left for it
+                        if (state.visibleObserver && toBeRemoved.some(k => k.startsWith("vgvisible|")) &&
+                                !Object.keys(emap).some(k => k.startsWith("vgvisible|"))) {
+                            state.visibleObserver.unobserve(state.el);
+                        }
+                        // and for vg-resize
+                        if (state.resizeObserver && toBeRemoved.some(k => k.startsWith("vgresize|")) &&
+                                !Object.keys(emap).some(k => k.startsWith("vgresize|"))) {
+                            state.resizeObserver.unobserve(state.el);
+                        }
+
                         // if emap is empty now, remove the entry from eventHandlerMap altogether
                         if (Object.keys(emap).length == 0) {
                             delete state.eventHandlerMap[positionID];
+                            delete state.eventHandlerEls[positionID];
                         } else {
                             state.eventHandlerMap[positionID] = emap;
                         }
@@ -741,14 +2026,16 @@
                         let eventType = decoder.readString();
                         let capture = decoder.readUint8();
                         let passive = decoder.readUint8();
+                        let modifiers = decoder.readUint32();
 
-                        /*DEBUG*/ console.log("opcodeSetEventListener", positionID, eventType, capture, passive);
+                        /*DEBUG*/ console.log("opcodeSetEventListener", positionID, eventType, capture, passive, modifiers);
 
                         if (!state.el) {
                             throw "must have state.el set in order to call opcodeSetEventListener";
                         }
 
-                        var eventKey = eventType + "|" + (capture ? "1" : "0") + "|" + (passive ? "1" : "0");
+                        let rate = takeEventRate();
+                        var eventKey = eventType + "|" + (capture ? "1" : "0") + "|" + (passive ? "1" : "0") + "|" + modifiers + (rate ? "|" + rate.key : "");
                         state.elEventKeys[eventKey] = true;
 
                         // map of positionID -> map of listener spec and handler function, for all elements
@@ -758,122 +2045,290 @@
                         // register function if not done already
                         let f = emap[eventKey];
                         if (!f) {
-                            f = function (event) {
+                            f = makeEventListener(positionID, eventType, capture, passive, modifiers, "", rate);
+                            emap[eventKey] = f;
 
-                                /*DEBUG*/ console.log("event listener called with event", event);
+                            // remove here if we noted it as added before
+                            // NOTE: there are cases where this may have no effect, since it is possible for the
+                            // element to have be removed and recreated.
+                            state.el.removeEventListener(eventType, f, {capture: capture, passive: passive});
 
-                                // set the active event, so the Go code and call back in and examine it if needed
-                                state.activeEvent = event;
+                        }
 
-                                let eventObj = {};
-                                // console.log(event);
-                                for (let i in event) {
-                                    let itype = typeof (event[i]);
-                                    // copy primitive values directly
-                                    if ((itype == "boolean" || itype == "number" || itype == "string") && true/*event.hasOwnProperty(i)*/) {
-                                        eventObj[i] = event[i];
-                                    }
-                                }
+                        // we always re-add the event listener, see note above
+                        //this.console.log("addEventListener", eventType);
+                        state.el.addEventListener(eventType, f, {capture: capture, passive: passive});
+                        if (state.el.vuguDelegated) {
+                            delete state.el.vuguDelegated[eventKey]; // in case it was delegated before
+                        }
 
-                                // also do the same for anything in "target"
-                                if (event.target) {
-                                    eventObj.target = {};
-                                    let et = event.target;
-                                    for (let i in et) {
-                                        let itype = typeof (et[i]);
-                                        if ((itype == "boolean" || itype == "number" || itype == "string") && true/*et.hasOwnProperty(i)*/) {
-                                            eventObj.target[i] = et[i];
-                                        }
-                                    }
-                                }
+                        // vg-visible and vg-resize, observing an element again does nothing
+                        if (eventType == "vgvisible") {
+                            observeVisible(state.el);
+                        } else if (eventType == "vgresize") {
+                            observeResize(state.el);
+                        }
 
-                                // console.log(eventObj);
-                                // console.log(JSON.stringify(eventObj));
+                        state.eventHandlerMap[positionID] = emap;
+                        state.eventHandlerEls[positionID] = state.el;
 
-                                let fullJSON = JSON.stringify({
+                        // this.console.log("opcodeSetEventListener", positionID, eventType, capture, passive);
+                        break;
+                    }
 
-                                    // include properties from event registration
-                                    position_id: positionID,
-                                    event_type: eventType,
-                                    capture: !!capture,
-                                    passive: !!passive,
+                    // applies to the listener set by the next instruction
+                    case opcodeSetEventRate: {
+                        let mode = decoder.readUint8();
+                        let ms = decoder.readUint32();
+                        /*DEBUG*/ console.log("opcodeSetEventRate", mode, ms);
+                        let m = mode == 2 ? "t" : "d";
+                        state.nextEventRate = { mode: m, ms: ms, key: m + ms };
+                        break;
+                    }
 
-                                    // the event object data as extracted above
-                                    event_summary: eventObj,
+                    // like opcodeSetEventListener, but the event is passed on by the listener for its type on the
+                    // mount point, see delegateEvent
+                    case opcodeSetDelegatedEventListener: {
+                        let positionID = decoder.readString();
+                        let eventType = decoder.readString();
+                        let passive = decoder.readUint8();
+                        let modifiers = decoder.readUint32();
 
-                                });
+                        /*DEBUG*/ console.log("opcodeSetDelegatedEventListener", positionID, eventType, passive, modifiers);
 
-                                // console.log(state.eventBuffer);
+                        if (!state.el) {
+                            throw "must have state.el set in order to call opcodeSetDelegatedEventListener";
+                        }
 
-                                // write JSON to state.eventBuffer with uint32 length prefix
+                        let rate = takeEventRate();
+                        var eventKey = eventType + "|0|" + (passive ? "1" : "0") + "|" + modifiers + (rate ? "|" + rate.key : "");
+                        state.elEventKeys[eventKey] = true;
 
-                                let encodeResultBuffer = textEncoder.encode(fullJSON);
+                        let emap = state.eventHandlerMap[positionID] || {};
+                        let f = emap[eventKey];
+                        if (!f) {
+                            f = makeEventListener(positionID, eventType, 0, passive, modifiers, "", rate);
+                            emap[eventKey] = f;
+                        }
+                        state.eventHandlerMap[positionID] = emap;
+                        state.eventHandlerEls[positionID] = state.el;
 
-                                const dataSize = encodeResultBuffer.byteLength - encodeResultBuffer.byteOffset
-                                // we need to allocate more bytes for storing data size in the beginning of the buffer
-                                const requiredBufferSize = dataSize + 4
+                        // the element may be new, so this is always set
+                        state.el.vuguDelegated = state.el.vuguDelegated || {};
+                        state.el.vuguDelegated[eventKey] = f;
+                        state.el.vuguDelegatedState = state;
+                        delegateEvent(state, eventType, passive);
 
-                                const computeEventBufferSize = (requiredBufferSize) => {
-                                    const sixteen_kb = 16384
-                                    const actualRequired = requiredBufferSize + 1
-                                    const remainder = actualRequired % sixteen_kb
+                        break;
+                    }
 
-                                    // but for now this needs to be at least one byte shorter
-                                    // than Go's buffer
-                                    if (remainder === 0) {
-                                        return actualRequired - 1
-                                    }
+                    // assign event listener to window or document, independent of the current element
+                    case opcodeSetGlobalEventListener: {
+                        let positionID = decoder.readString();
+                        let globalTarget = decoder.readString();
+                        let eventType = decoder.readString();
+                        let capture = decoder.readUint8();
+                        let passive = decoder.readUint8();
+                        let modifiers = decoder.readUint32();
 
-                                    return actualRequired + (sixteen_kb - remainder) - 1
-                                }
+                        /*DEBUG*/ console.log("opcodeSetGlobalEventListener", positionID, globalTarget, eventType, capture, passive, modifiers);
 
-                                // before eventHandlerFunc is called make sure eventBuffer and eventBufferView are setup,
-                                // and allocateEventBuffer is called
-                                let eventBuffer = state.eventBuffer;
-                                if (!eventBuffer || eventBuffer.length < requiredBufferSize) {
-                                    const eventBufferSize = computeEventBufferSize(requiredBufferSize)
-                                    eventBuffer = new Uint8Array(eventBufferSize);
-                                    state.eventBuffer = eventBuffer;
-                                    state.eventBufferView = new DataView(eventBuffer.buffer, eventBuffer.byteOffset, eventBuffer.byteLength);
+                        let target = globalTarget == "document" ? document : window;
+
+                        let rate = takeEventRate();
+                        let key = positionID + "|" + globalTarget + "|" + eventType + "|" + (capture ? "1" : "0") + "|" + (passive ? "1" : "0") + "|" + modifiers + (rate ? "|" + rate.key : "");
+                        state.globalEventKeys[key] = true;
+
+                        // unlike element listeners these are never lost by elements being recreated, so only add once
+                        if (!state.globalEventHandlerMap[key]) {
+                            let f = makeEventListener(positionID, eventType, capture, passive, modifiers, globalTarget, rate);
+                            state.globalEventHandlerMap[key] = {target: target, eventType: eventType, capture: capture, passive: passive, f: f};
+                            target.addEventListener(eventType, f, {capture: capture, passive: passive});
+                        }
+
+                        break;
+                    }
+
+                    // remove window and document listeners that were not set since the last time this was called
+                    case opcodeRemoveOtherGlobalEventListeners: {
+
+                        /*DEBUG*/ console.log("opcodeRemoveOtherGlobalEventListeners");
+
+                        for (let k in state.globalEventHandlerMap) {
+                            if (!state.globalEventKeys[k]) {
+                                let h = state.globalEventHandlerMap[k];
+                                h.target.removeEventListener(h.eventType, h.f, {capture: h.capture, passive: h.passive});
+                                delete state.globalEventHandlerMap[k];
+                            }
+                        }
+                        state.globalEventKeys = {};
+
+                        break;
+                    }
+
+                    case opcodeSelectPortal: {
+
+                        state.elAttrNames = {}; // reset attribute list
+                        state.elEventKeys = {};
+
+                        let selector = decoder.readString();
+
+                        /*DEBUG*/ console.log("opcodeSelectPortal", selector);
+
+                        state.portalEls = state.portalEls || {};
+                        state.portalKeys = state.portalKeys || {};
+
+                        // portal content goes in a container of its own so anything else in the target is left alone
+                        let el = state.portalEls[selector];
+                        if (el && !el.isConnected) {
+                            delete state.portalEls[selector];
+                            throw "portal container for " + selector + " was removed, portal targets must not be inside content rendered by vugu";
+                        }
+                        if (!el) {
+                            let target = document.querySelector(selector);
+                            if (!target) {
+                                throw "portal target selector not found: " + selector;
+                            }
+                            el = document.createElement("div");
+                            el.setAttribute("data-vugu-portal", selector);
+                            el.style.display = "contents";
+                            target.appendChild(el);
+                            state.portalEls[selector] = el;
+                        }
+                        state.portalKeys[selector] = true;
+
+                        state.el = el;
+                        state.nextElMove = null;
+
+                        break;
+                    }
+
+                    case opcodeProtocolVersion: {
+                        let version = decoder.readUint32();
+                        if (version !== protocolVersion) {
+                            // nothing after this can be trusted to mean the same, the Go side reports the error
+                            console.error("vugu: instruction protocol version " + version + " does not match helper script version " + protocolVersion);
+                            return {protocolVersion: protocolVersion};
+                        }
+                        break;
+                    }
+
+                    case opcodeInternString: {
+                        let id = decoder.readUint32();
+                        state.strings[id] = decoder.readString();
+                        break;
+                    }
+
+                    case opcodeRemoveOtherPortals: {
+
+                        /*DEBUG*/ console.log("opcodeRemoveOtherPortals");
+
+                        state.portalEls = state.portalEls || {};
+                        state.portalKeys = state.portalKeys || {};
+                        for (let selector in state.portalEls) {
+                            if (!state.portalKeys[selector]) {
+                                let el = state.portalEls[selector];
+                                if (el.parentNode) {
+                                    el.parentNode.removeChild(el);
                                 }
-                                //console.log("encodeResult", encodeResult);
-                                state.eventBuffer.set(encodeResultBuffer, 4); // copy encoded string to event buffer
-                                // now write length using DataView as uint32
-                                state.eventBufferView.setUint32(0, dataSize);
+                                delete state.portalEls[selector];
+                            }
+                        }
+                        state.portalKeys = {};
+
+                        break;
+                    }
 
-                                // let result = textEncoder.encodeInto(fullJSON, state.eventBuffer);
-                                // let eventBufferDataView = new DataView(state.eventBuffer.buffer, state.eventBuffer.byteOffset, state.eventBuffer.byteLength);
-                                // eventBufferDataView.setUint8(result.written, 0);
+                    // drop references for a position that no longer has any listeners
+                    case opcodeForgetPosition: {
+                        let positionID = decoder.readString();
 
-                                // write length after, since only now do we know the final length
-                                // state.eventBufferView.setUint32(0, result.written);
+                        /*DEBUG*/ console.log("opcodeForgetPosition", positionID);
 
-                                // serialize event into the event buffer, somehow,
-                                // and keep track of the target element, also consider grabbing
-                                // the value or relevant properties as appropriate for form things
+                        if (state.eventHandlerMap[positionID]) {
+                            delete state.eventHandlerMap[positionID];
+                            state.forgottenPositionCount = (state.forgottenPositionCount || 0) + 1;
+                        }
+                        delete state.eventHandlerEls[positionID];
 
-                                /*DEBUG*/ console.log("event handler calling state.eventHandlerFunc", eventBuffer);
-                                state.eventHandlerFunc.call(null, eventBuffer); // call with null this avoids unnecessary js.Value reference
+                        break;
+                    }
 
-                                // unset the active event
-                                state.activeEvent = null;
-                            };
-                            emap[eventKey] = f;
+                    // drop the listeners of positions whose element has been removed from the document, which
+                    // opcodeForgetPosition missed; this comes at the end of a render, when all elements which
+                    // are still in use have been put in place
+                    case opcodeSweepPositions: {
 
-                            // remove here if we noted it as added before
-                            // NOTE: there are cases where this may have no effect, since it is possible for the
-                            // element to have be removed and recreated.
-                            state.el.removeEventListener(eventType, f, {capture: capture, passive: passive});
+                        /*DEBUG*/ console.log("opcodeSweepPositions");
 
+                        for (let positionID of Object.keys(state.eventHandlerMap)) {
+                            let el = state.eventHandlerEls[positionID];
+                            if (el && el.isConnected) {
+                                continue;
+                            }
+                            dropPosition(positionID);
+                            state.sweptPositionCount = (state.sweptPositionCount || 0) + 1;
+                        }
+                        // and any elements left from positions which were forgotten some other way
+                        for (let positionID of Object.keys(state.eventHandlerEls)) {
+                            if (!state.eventHandlerMap[positionID]) {
+                                delete state.eventHandlerEls[positionID];
+                            }
                         }
 
-                        // we always re-add the event listener, see note above
-                        //this.console.log("addEventListener", eventType);
-                        state.el.addEventListener(eventType, f, {capture: capture, passive: passive});
+                        break;
+                    }
 
-                        state.eventHandlerMap[positionID] = emap;
+                    // the element at a position is moving to another, so its listeners and those of the
+                    // elements under it, which are for their positions, are dropped and set again as
+                    // they are synced in their new ones
+                    case opcodeForgetSubtree: {
+                        let positionID = decoder.readString();
+
+                        /*DEBUG*/ console.log("opcodeForgetSubtree", positionID);
+
+                        let prefix = positionID + "_";
+                        for (let pid of Object.keys(state.eventHandlerMap)) {
+                            if (pid === positionID || pid.startsWith(prefix)) {
+                                dropPosition(pid);
+                            }
+                        }
+
+                        break;
+                    }
+
+                    // move a child of the current element in front of another, the indexes are as they are
+                    // before the move; a child which is not there (the DOM was changed by something else)
+                    // gets a placeholder, which syncing by position then replaces
+                    case opcodeMoveChild: {
+                        let from = decoder.readUint32();
+                        let before = decoder.readUint32();
+
+                        /*DEBUG*/ console.log("opcodeMoveChild", from, before);
+
+                        let kids = state.el.childNodes;
+                        let ref = kids[before] || null;
+                        let node = from === newChild ? null : kids[from];
+                        if (!node) {
+                            node = document.createComment("");
+                        }
+                        if (node !== ref) {
+                            state.el.insertBefore(node, ref);
+                        }
+
+                        break;
+                    }
+
+                    case opcodeRemoveChild: {
+                        let index = decoder.readUint32();
+
+                        /*DEBUG*/ console.log("opcodeRemoveChild", index);
+
+                        let node = state.el.childNodes[index];
+                        if (node) {
+                            state.el.removeChild(node);
+                        }
 
-                        // this.console.log("opcodeSetEventListener", positionID, eventType, capture, passive);
                         break;
                     }
 
@@ -920,8 +2375,11 @@
                         // * if it has vuguCreated==true on it, then add to map of css tags set, else ignore
                         // * if no matching tag then create and set vuguCreated=true, add to map of css tags set
 
+                        // in a shadow root the CSS goes there instead of the head
+                        let cssRoot = state.shadowRoot || this.document;
+
                         let foundTag = null;
-                        this.document.querySelectorAll(elementName).forEach(cssEl => {
+                        cssRoot.querySelectorAll(elementName).forEach(cssEl => {
                             let cssElKey;
                             if (elementName == "style") {
                                 cssElKey = cssEl.textContent;
@@ -946,7 +2404,7 @@
                                 cTag.appendChild(document.createTextNode(textContent)) // set textContent if provided
                                 // cTag.innerText = textContent; // set textContent if provided
                             }
-                            this.document.head.appendChild(cTag); // add to end of head
+                            (state.shadowRoot || this.document.head).appendChild(cTag); // add to end of head, or the shadow root
                             // this.console.log("CREATED ctag: ", cTag);
                             state.elCSSTagsSet.push(cTag); // add to elCSSTagsSet for use in opcodeRemoveOtherCSSTags
                         } else {
@@ -964,7 +2422,7 @@
 
                         state.elCSSTagsSet = state.elCSSTagsSet || [];
 
-                        this.document.querySelectorAll('style,link').forEach(cssEl => {
+                        (state.shadowRoot || this.document).querySelectorAll('style,link').forEach(cssEl => {
 
                             // ignore any not created by vugu
                             if (!cssEl.vuguCreated) {
@@ -999,6 +2457,27 @@
                         break;
                     }
 
+                    // select the next node and leave it as-is, used for unchanged subtrees
+                    case opcodeSkipNode: {
+
+                        /*DEBUG*/ console.log("opcodeSkipNode");
+
+                        if (state.nextElMove == "first_child") {
+                            state.el = state.el.firstChild;
+                        } else if (state.nextElMove == "next_sibling") {
+                            state.el = state.el.nextSibling;
+                        } else if (state.nextElMove) {
+                            throw "bad state.nextElMove value: " + state.nextElMove;
+                        }
+                        state.nextElMove = null;
+
+                        if (!state.el) {
+                            throw "opcodeSkipNode: node to skip does not exist";
+                        }
+
+                        break;
+                    }
+
                     case opcodeCallback: {
                         let callbackID = decoder.readUint32();
 
@@ -1025,6 +2504,99 @@
 
         }
 
+        for (let el of state.scrollRestore) {
+            let pos = state.scrollPositions[el.vuguScrollKey];
+            if (pos && el.isConnected) {
+                el.scrollTop = pos[0];
+                el.scrollLeft = pos[1];
+            }
+        }
+        state.scrollRestore = [];
+
+        // the focused element was replaced, focus the one in its place (unless something else has focus now)
+        if (state.focusRestore) {
+            let fr = state.focusRestore;
+            if (fr.el.isConnected || !isFocusLost()) {
+                state.focusRestore = null;
+            } else {
+                let el = elementAtPath(fr.root, fr.path);
+                if (el && el.tagName === fr.el.tagName) {
+                    state.focusRestore = null;
+                    el.focus({preventScroll: true});
+                    if (fr.selection && el.value === fr.el.value) {
+                        try {
+                            el.setSelectionRange(fr.selection[0], fr.selection[1]);
+                        } catch (err) {
+                            // not a text input
+                        }
+                    }
+                } else if (fr.tries++ > 0) {
+                    // the element at its position may be created by the next buffer of the same render, but not later
+                    state.focusRestore = null;
+                }
+            }
+        }
+
+        for (let [el, action] of state.postRender) {
+            if (!el.isConnected) {
+                continue;
+            }
+            if (action == opcodeFocus) {
+                el.focus();
+            } else {
+                el.scrollIntoView({block: "nearest", inline: "nearest"});
+            }
+        }
+        state.postRender = [];
+
+        if (state.focusRef !== undefined) {
+            let el = elementByRef(state, state.focusRef);
+            delete state.focusRef;
+            if (el) {
+                el.focus();
+            }
+        }
+
+        if (state.selectionRef !== undefined) {
+            let [ref, start, end] = state.selectionRef;
+            delete state.selectionRef;
+            let el = elementByRef(state, ref);
+            if (el) {
+                el.focus();
+                try {
+                    el.setSelectionRange(start, end);
+                } catch (err) {
+                    console.warn("vugu: could not set the selection of", ref, err);
+                }
+            }
+        }
+
+        if (state.clipboardText !== undefined) {
+            let text = state.clipboardText;
+            delete state.clipboardText;
+            if (navigator.clipboard && navigator.clipboard.writeText) {
+                navigator.clipboard.writeText(text).catch(function (err) {
+                    console.warn("vugu: could not write to the clipboard", err);
+                });
+            } else {
+                // older browsers, copy from a hidden textarea
+                let ta = document.createElement("textarea");
+                ta.value = text;
+                ta.style.cssText = "position:fixed;left:-9999px;top:0;";
+                document.body.appendChild(ta);
+                ta.select();
+                try {
+                    document.execCommand("copy");
+                } catch (err) {
+                    console.warn("vugu: could not write to the clipboard", err);
+                }
+                document.body.removeChild(ta);
+            }
+        }
+
+        // how long this took, for RenderStats
+        return window.performance ? window.performance.now() - renderStart : 0;
+
     }
 
 })()
@@ -0,0 +1,26 @@
+package domrender
+
+import (
+	"fmt"
+	"time"
+)
+
+// RenderStats are measurements of one render by a JSRenderer, see JSRenderer.OnRenderStats.
+// Flush includes JS, as the helper script runs during the flush with DirectTransport.
+type RenderStats struct {
+	Build        time.Duration // how long BuildEnv.RunBuild took, from BuildResults.BuildTime
+	Diff         time.Duration // comparing the build output with the prior render and writing instructions
+	Flush        time.Duration // sending instructions with the Transport
+	Wait         time.Duration // waiting for other renderers to take their turn, see Scheduler
+	JS           time.Duration // the helper script applying instructions to the DOM, measured with performance.now (only with DirectTransport)
+	Total        time.Duration // the whole of Render, including Rendered callbacks
+	Instructions int           // instructions sent
+	Bytes        int           // bytes of instructions sent
+	Flushes      int           // buffers sent, more than one if the instructions did not fit in one
+}
+
+// String returns the stats on one line, e.g. for logging.
+func (s RenderStats) String() string {
+	return fmt.Sprintf("build=%v diff=%v flush=%v wait=%v js=%v total=%v instructions=%d bytes=%d flushes=%d",
+		s.Build, s.Diff, s.Flush, s.Wait, s.JS, s.Total, s.Instructions, s.Bytes, s.Flushes)
+}
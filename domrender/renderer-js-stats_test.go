@@ -0,0 +1,151 @@
+package domrender
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+func TestRenderStats(t *testing.T) {
+
+	assert := assert.New(t)
+
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "div", Attr: []vugu.VGAttribute{{Key: "id", Val: "main"}}}
+		n.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: "hello"})
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	var stats []RenderStats
+	r.OnRenderStats = func(s RenderStats) { stats = append(stats, s) }
+
+	br := buildEnv.RunBuild(root)
+	assert.NoError(r.Render(br))
+
+	if !assert.Len(stats, 1) || !assert.Len(tr.Renders, 1) {
+		return
+	}
+	s := stats[0]
+	instructions, err := DecodeInstructions(tr.Renders[0])
+	assert.NoError(err)
+	assert.Equal(len(instructions)-1, s.Instructions) // not counting end
+	assert.Equal(len(tr.Renders[0]), s.Bytes)
+	assert.Equal(1, s.Flushes)
+	assert.Equal(br.BuildTime, s.Build)
+	assert.Zero(s.JS)
+	assert.True(s.Total >= s.Diff+s.Flush)
+	assert.Contains(s.String(), "flushes=1")
+
+	// each render is measured from scratch
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	if assert.Len(stats, 2) {
+		assert.Equal(1, stats[1].Flushes)
+		assert.Equal(len(tr.Renders[1]), stats[1].Bytes)
+	}
+}
+
+func TestInstructionBufferGrows(t *testing.T) {
+
+	assert := assert.New(t)
+
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		ul := &vugu.VGNode{Type: vugu.ElementNode, Data: "ul"}
+		for i := 0; i < 2000; i++ {
+			li := &vugu.VGNode{Type: vugu.ElementNode, Data: "li", Attr: []vugu.VGAttribute{{Key: "class", Val: "item"}}}
+			li.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: fmt.Sprintf("item number %d", i)})
+			ul.AppendChild(li)
+		}
+		return &vugu.BuildOut{Out: []*vugu.VGNode{ul}}
+	})
+
+	render := func(size int) (RenderStats, *CaptureTransport) {
+		r, tr, buildEnv := newTestRenderer(t)
+		r.InstructionBufferSize = size
+		var stats RenderStats
+		r.OnRenderStats = func(s RenderStats) { stats = s }
+		assert.NoError(r.Render(buildEnv.RunBuild(root)))
+		return stats, tr
+	}
+
+	// more than the initial 16k, sent in one go
+	stats, tr := render(0)
+	assert.True(stats.Bytes > 16384, "expected more than 16k of instructions, got %d", stats.Bytes)
+	assert.Equal(1, stats.Flushes)
+	assert.Len(tr.Renders, 1)
+
+	// the same instructions split up when the buffer cannot grow
+	small, tr2 := render(16384)
+	assert.True(small.Flushes > 1)
+	assert.Len(tr2.Renders, small.Flushes)
+	assert.Equal(stats.Instructions, small.Instructions)
+	var count int
+	for _, b := range tr2.Renders {
+		in, err := DecodeInstructions(b)
+		assert.NoError(err)
+		count += len(in) - 1
+	}
+	assert.Equal(stats.Instructions, count)
+}
+
+func TestInstructionBufferTuning(t *testing.T) {
+
+	assert := assert.New(t)
+
+	items := 2000
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		ul := &vugu.VGNode{Type: vugu.ElementNode, Data: "ul"}
+		for i := 0; i < items; i++ {
+			li := &vugu.VGNode{Type: vugu.ElementNode, Data: "li"}
+			li.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: fmt.Sprintf("item number %d", i)})
+			ul.AppendChild(li)
+		}
+		return &vugu.BuildOut{Out: []*vugu.VGNode{ul}}
+	})
+
+	newRenderer := func(setup func(r *JSRenderer)) (*JSRenderer, func() RenderStats) {
+		r, _, buildEnv := newTestRenderer(t)
+		setup(r)
+		var stats RenderStats
+		r.OnRenderStats = func(s RenderStats) { stats = s }
+		return r, func() RenderStats {
+			assert.NoError(r.Render(buildEnv.RunBuild(root)))
+			return stats
+		}
+	}
+
+	// a small initial size grows by the factor given
+	r, render := newRenderer(func(r *JSRenderer) {
+		r.InstructionBufferInitialSize = 1024
+		r.InstructionBufferGrowth = 4
+	})
+	stats := render()
+	assert.Equal(1, stats.Flushes)
+	n := 1024
+	for n <= stats.Bytes {
+		n *= 4
+	}
+	assert.Equal(n, len(r.instructionList.buf))
+
+	// with a watermark the buffer is sent before it has to grow
+	r, render = newRenderer(func(r *JSRenderer) { r.FlushWatermark = 4096 })
+	stats = render()
+	assert.True(stats.Flushes > stats.Bytes/8192, "expected flushes about every 4k, got %d for %d bytes", stats.Flushes, stats.Bytes)
+	assert.Equal(DefaultInstructionBufferInitialSize, len(r.instructionList.buf))
+
+	// after a large render the buffer is shrunk again once the renders are small
+	r, render = newRenderer(func(r *JSRenderer) { r.AutoTuneInstructionBuffer = true })
+	render()
+	large := len(r.instructionList.buf)
+	assert.True(large > DefaultInstructionBufferInitialSize)
+	items = 10
+	for i := 0; i < autoTuneRenders-1; i++ {
+		render()
+		assert.Equal(large, len(r.instructionList.buf), "render %d", i)
+	}
+	render()
+	assert.Equal(DefaultInstructionBufferInitialSize, len(r.instructionList.buf))
+}
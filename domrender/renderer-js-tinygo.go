@@ -15,13 +15,24 @@ func (r *JSRenderer) sendEventWaitCh() {
 	}
 }
 
+// invokeEventHandler calls f.
+// NOTE: tinygo version does not recover from panics for now
+func (r *JSRenderer) invokeEventHandler(f func(vugu.DOMEvent), e vugu.DOMEvent) error {
+	f(e)
+	return nil
+}
+
 // Render is a render function.
 func (r *JSRenderer) Render(buildResults *vugu.BuildResults) error {
 
+	if r.Strict && r.released {
+		panic("domrender strict: Render called after Release")
+	}
+
 	// acquire read lock so events are not changing data while Render is in progress
 	r.eventRWMU.RLock()
 
-	err := r.render(buildResults)
+	err := renderError(r.render(buildResults), buildResults.Root)
 
 	// for now, not using defer in Tinygo
 	r.eventRWMU.RUnlock()
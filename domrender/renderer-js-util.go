@@ -22,6 +22,45 @@ func namespaceToURI(namespace string) string {
 	}
 }
 
+// formProperties lists the attributes of form elements which only give the initial state, once the user
+// has interacted with the element it shows the corresponding DOM property instead, so it is set as well.
+// Each is a string property unless it's a boolean attribute (true if present).
+var formProperties = map[string][]struct {
+	key    string
+	isBool bool
+}{
+	"input":    {{"value", false}, {"checked", true}},
+	"textarea": {{"value", false}},
+	"option":   {{"selected", true}},
+}
+
+// delegatedEventTypes are the event types which bubble, and so can be handled by a listener on the
+// mount point when JSRenderer.DelegateEvents is set.
+var delegatedEventTypes = map[string]bool{
+	"click": true, "dblclick": true, "auxclick": true, "contextmenu": true,
+	"mousedown": true, "mouseup": true, "mousemove": true, "mouseover": true, "mouseout": true,
+	"pointerdown": true, "pointerup": true, "pointermove": true, "pointerover": true, "pointerout": true, "pointercancel": true,
+	"touchstart": true, "touchend": true, "touchmove": true, "touchcancel": true, "wheel": true,
+	"keydown": true, "keyup": true, "keypress": true,
+	"beforeinput": true, "input": true, "change": true, "submit": true, "reset": true,
+	"focusin": true, "focusout": true,
+	"compositionstart": true, "compositionupdate": true, "compositionend": true,
+	"copy": true, "cut": true, "paste": true,
+	"dragstart": true, "drag": true, "dragend": true, "dragenter": true, "dragover": true, "dragleave": true, "drop": true,
+}
+
+// hasFormProperties returns true if n has any of the attributes in formProperties.
+func hasFormProperties(n *vugu.VGNode) bool {
+	for _, fp := range formProperties[n.Data] {
+		for _, a := range n.Attr {
+			if a.Key == fp.key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type renderedCtx struct {
 	eventEnv vugu.EventEnv
 	first    bool
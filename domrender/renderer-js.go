@@ -5,9 +5,11 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/vugu/vjson"
 
@@ -18,6 +20,20 @@ import (
 
 //go:generate go run renderer-js-script-maker.go
 
+// DefaultInstructionBufferSize is the largest the instruction buffer grows to unless
+// JSRenderer.InstructionBufferSize is set.
+const DefaultInstructionBufferSize = 1 << 20
+
+// DefaultInstructionBufferInitialSize is the size of the instruction buffer before it grows unless
+// JSRenderer.InstructionBufferInitialSize is set.
+const DefaultInstructionBufferInitialSize = 16384
+
+// minInstructionBufferSize is the smallest instruction buffer used, whatever the settings.
+const minInstructionBufferSize = 256
+
+// autoTuneRenders is how many recent renders JSRenderer.AutoTuneInstructionBuffer sizes the buffer for.
+const autoTuneRenders = 16
+
 // NewJSRenderer is an alias for New.
 //
 // Deprecated: Use New instead.
@@ -27,74 +43,91 @@ func NewJSRenderer(mountPointSelector string) (*JSRenderer, error) {
 
 // New will create a new JSRenderer with the speicifc mount point selector.
 // If an empty string is passed then the root component should include a top level <html> tag
-// and the entire page will be rendered.
+// and the entire page will be rendered.  An error is returned if the selector is not valid,
+// does not match exactly one element or matches one inside <head>.  See also NewForElement.
 func New(mountPointSelector string) (*JSRenderer, error) {
+	return NewWithTransport(mountPointSelector, &DirectTransport{})
+}
+
+// NewWithTransport is like New but communicates with the page using the specified Transport,
+// e.g. a WorkerTransport when running in a Web Worker.
+func NewWithTransport(mountPointSelector string, transport Transport) (*JSRenderer, error) {
+
+	err := checkMountPoint(mountPointSelector)
+	if err != nil {
+		return nil, err
+	}
 
 	ret := &JSRenderer{
 		MountPointSelector: mountPointSelector,
+		transport:          transport,
+	}
+	if _, ok := transport.(*DirectTransport); ok {
+		ret.Scheduler = DefaultScheduler
 	}
 
-	ret.instructionBuffer = make([]byte, 16384)
-	// ret.instructionTypedArray = js.TypedArrayOf(ret.instructionBuffer)
-
-	ret.window = js.Global().Get("window")
-
-	ret.window.Call("eval", jsHelperScript)
-
-	ret.instructionBufferJS = ret.window.Call("vuguGetRenderArray")
-
-	ret.instructionList = newInstructionList(ret.instructionBuffer, func(il *instructionList) error {
-
-		// call vuguRender to have the instructions processed in JS
-		ret.instructionBuffer[il.pos] = 0 // ensure zero terminator
-
-		// copy the data over
-		js.CopyBytesToJS(ret.instructionBufferJS, ret.instructionBuffer)
-
-		// then call vuguRender
-		ret.window.Call("vuguRender" /*, ret.instructionBufferJS*/)
-
-		return nil
-	})
-
-	// enable debug logging
-	// ret.instructionList.logWriter = os.Stdout
-
-	ret.eventHandlerBuffer = make([]byte, 16384)
-	// ret.eventHandlerTypedArray = js.TypedArrayOf(ret.eventHandlerBuffer)
+	ret.instructionList = newInstructionList(make([]byte, DefaultInstructionBufferInitialSize), func(il *instructionList) error {
 
-	ret.eventHandlerFunc = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		if len(args) != 1 {
-			panic(fmt.Errorf("eventHandlerFunc got arg slice not exactly 1 element in length: %#v", args))
+		// have the instructions processed in JS
+		il.buf[il.pos] = 0 // ensure zero terminator
+		buf := il.buf[:il.pos+1]
+		if ret.Recorder != nil {
+			ret.Recorder.RecordInstructions(buf)
 		}
-		bufferLength := args[0].Length()
-		if cap(ret.eventHandlerBuffer) < bufferLength+1 {
-			ret.eventHandlerBuffer = make([]byte, bufferLength+1)
+		st := &ret.renderStats
+		sched := ret.Scheduler
+		start := time.Now()
+		if sched != nil {
+			sched.acquire(ret)
+			sched.sending(ret)
 		}
-		//log.Println(cap(ret.eventHandlerBuffer))
-		n := js.CopyBytesToGo(ret.eventHandlerBuffer, args[0])
-		if n >= len(ret.eventHandlerBuffer) {
-			panic(errors.New("event data is too large, cannot continue, len: " + strconv.Itoa(n)))
+		st.Wait += time.Since(start)
+		start = time.Now()
+		err := ret.transport.Render(buf)
+		if sched != nil {
+			sched.sent(ret)
 		}
-		ret.handleDOMEvent() // discard this and args, all data should be in eventHandlerBuffer; avoid using js.Value
-		return nil
-		// return jsEnv.handleRawDOMEvent(this, args)
+		st.Flush += time.Since(start)
+		st.Bytes += len(buf)
+		st.Flushes++
+		if rt, ok := ret.transport.(renderTimer); ok {
+			st.JS += rt.lastRenderTime()
+		}
+		return err
 	})
 
-	// wire up the event handler func and the array that we used to communicate with instead of js.Value
-	// ret.window.Call("vuguSetEventHandlerAndBuffer", ret.eventHandlerFunc, ret.eventHandlerTypedArray)
-
-	// wire up callback handler
-	ret.window.Call("vuguSetCallbackHandler", js.FuncOf(ret.handleCallback))
-
-	// wire up the event handler func
-	ret.window.Call("vuguSetEventHandler", ret.eventHandlerFunc)
+	// enable debug logging
+	// ret.instructionList.logWriter = os.Stdout
 
-	// log.Printf("ret.window: %#v", ret.window)
-	// log.Printf("eval: %#v", ret.window.Get("eval"))
+	// animation frames requested by EventWait to schedule renders
+	ret.animationFrameCh = make(chan struct{}, 1)
 
 	ret.eventWaitCh = make(chan bool, 64)
 
+	err = ret.transport.Init(jsHelperScript, TransportHandlers{
+		Event: ret.handleDOMEvent,
+		Callback: func(args []js.Value) {
+			ret.handleCallback(js.Undefined(), args)
+		},
+		Frame: func() {
+			select {
+			case ret.animationFrameCh <- struct{}{}:
+			default:
+			}
+		},
+		Close: func() {
+			// stop EventWait, including if it's waiting for a frame which will not come now
+			go func() { ret.eventWaitCh <- false }()
+			select {
+			case ret.animationFrameCh <- struct{}{}:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	ret.eventEnv = vugu.NewEventEnvImpl(
 		&ret.eventRWMU,
 		ret.eventWaitCh,
@@ -103,17 +136,75 @@ func New(mountPointSelector string) (*JSRenderer, error) {
 	return ret, nil
 }
 
+// sweepInterval is the number of renders between sweeps of the positions held by the helper script,
+// which drop the listeners of elements no longer in the document.
+const sweepInterval = 64
+
 type jsRenderState struct {
-	// stores positionID to slice of DOMEventHandlerSpec
-	domHandlerMap map[string][]vugu.DOMEventHandlerSpec
+	// true once the helper script has accepted our protocol version
+	protocolVersionSent bool
+
+	// true once a render has completed, before then the mount point may be filled using the fast path
+	// (see JSRenderer.FastFirstRender)
+	mounted bool
+
+	// the root component of the last render, when it changes the mount point is filled again as on the first render
+	root vugu.Builder
+
+	// true until the render after the root component changed is done, the mount point holds the
+	// previous root's output which can be replaced whatever JSRenderer.FastFirstRender says
+	rootSwitched bool
+
+	// stores positionID to the event handlers there, rebuilt each render with the
+	// prior one kept so positions which went away can be pruned on the JS side as well
+	domHandlerMap     map[string]domHandlers
+	prevDomHandlerMap map[string]domHandlers
+
+	// successful renders since the helper script was last told to sweep its positions, see sweepInterval
+	rendersSinceSweep int
+
+	// stores positionID to what identifies each of the children there for this render and the prior
+	// one, for elements with keyed children when the DiffStrategy is a ChildMover
+	childIDMap     map[string]childIDs
+	prevChildIDMap map[string]childIDs
 
 	// callback stuff is handled by callbackManager
 	callbackManager callbackManager
+
+	// stores positionID to NodeHash for this render and the prior one,
+	// used to skip syncing subtrees which have not changed
+	hashMap     map[string]uint64
+	prevHashMap map[string]uint64
+
+	// stores positionID to the vg-focus and vg-scroll-into-view conditions which were true, for this
+	// render and the prior one, so they only act when they become true
+	triggerMap     map[string]uint8
+	prevTriggerMap map[string]uint8
+
+	// positionIDs of the output left as it was by AdaptiveQuality in this render and the prior one
+	deferred     map[string]bool
+	prevDeferred map[string]bool
+
+	// nodes with Portal set found during this render, synced after the main output
+	portalList []portalItem
+}
+
+// portalItem is a node to be rendered into a portal target.
+type portalItem struct {
+	bo   *vugu.BuildOut
+	n    *vugu.VGNode
+	comp interface{} // the component whose output it is in
+}
+
+// domHandlers are the event handlers of the element at a position, and the component whose output it is in.
+type domHandlers struct {
+	specs []vugu.DOMEventHandlerSpec
+	comp  interface{}
 }
 
 func newJsRenderState() *jsRenderState {
 	return &jsRenderState{
-		domHandlerMap: make(map[string][]vugu.DOMEventHandlerSpec, 8),
+		domHandlerMap: make(map[string]domHandlers, 8),
 	}
 }
 
@@ -121,51 +212,225 @@ func newJsRenderState() *jsRenderState {
 type JSRenderer struct {
 	MountPointSelector string
 
+	// DisableAnimationFrame causes EventWait to return as soon as an event occurs, instead
+	// of waiting for the browser's next animation frame.
+	DisableAnimationFrame bool
+
+	// DebugOverlay shows Go and JS memory usage and per-render allocations in the corner of the page.
+	DebugOverlay bool
+
+	// Strict enables checks for mistakes which otherwise show up as odd rendering bugs, for use in
+	// development.  Each render checks that position IDs are unique, every event listener has a handler,
+	// and the output of the previous render was not modified after it was rendered (Build must return
+	// new VGNodes), and Render panics if the renderer has been released.  Check failures are reported
+	// like other panics during Render, see OnError.
+	Strict bool
+
+	// RenderBudget, if not zero, is how long a component may take to render before a warning is reported,
+	// for finding components to memoize or split in development.  The time is that taken to emit its
+	// output, i.e. compare it with the prior render and write instructions (not including the components
+	// in it), plus the time to build it if the BuildEnv measures it (see vugu.BuildEnv.SetTimeComponents,
+	// which is also needed for the component paths to be complete).
+	RenderBudget time.Duration
+
+	// OnBudgetExceeded is called with each component that exceeded RenderBudget at the end of a render.
+	// If nil the warning is logged to the console.
+	OnBudgetExceeded func(w BudgetWarning)
+
+	// OnError is called with a *PanicError when a panic is recovered from an event handler or
+	// Render, after which the program keeps running.  If nil the error is logged to the console.
+	OnError func(err error)
+
+	// ErrorOverlay returns the HTML shown over the page when an error is reported.
+	// If nil a default showing the error message and stack trace is used.
+	ErrorOverlay func(err error) string
+
+	// DisableErrorOverlay prevents any error overlay from being shown.
+	DisableErrorOverlay bool
+
+	// AuditTrail, if set, records the events handled and errors reported, see AuditTrail.
+	AuditTrail *AuditTrail
+
+	// Recorder, if set, is given every instruction buffer sent to the browser, e.g. a RingRecorder to
+	// keep the last few for inspecting with FormatInstructions or the vugureplay command.
+	Recorder Recorder
+
+	// InstructionBufferSize is the size the instruction buffer may grow to, so that a render is sent to
+	// the browser in one call where possible (see RenderStats.Flushes).  Renders needing more are sent
+	// in several calls.  If zero DefaultInstructionBufferSize is used.
+	InstructionBufferSize int
+
+	// InstructionBufferInitialSize is the size of the instruction buffer before it grows, set when the
+	// first render starts.  If zero DefaultInstructionBufferInitialSize is used.  A small widget can use
+	// a small buffer, an app with a very large tree can avoid growing it in several steps.
+	InstructionBufferInitialSize int
+
+	// InstructionBufferGrowth is the factor the instruction buffer grows by when it is full, up to
+	// InstructionBufferSize.  If not more than 1, the buffer doubles.
+	InstructionBufferGrowth float64
+
+	// FlushWatermark, if not zero, sends the instructions to the browser once this many bytes are buffered,
+	// even if the buffer has room for more.  A large render is then applied in several smaller batches,
+	// which other renderers sharing the Scheduler can take turns between.
+	FlushWatermark int
+
+	// AutoTuneInstructionBuffer sizes the instruction buffer for the recent renders: when it is much larger
+	// than the most sent in one flush by any of them, e.g. after one unusually large render, it is shrunk
+	// again (to no less than InstructionBufferInitialSize).  It grows as needed whether or not this is set.
+	AutoTuneInstructionBuffer bool
+	recentPeaks               [autoTuneRenders]int // the most bytes flushed at once by the recent renders
+	recentPeakIndex           int
+
+	// OnRenderStats, if set, is called at the end of each render with measurements of it, for profiling
+	// large component trees.  It is called from Render and must not lock the EventEnv.
+	OnRenderStats func(stats RenderStats)
+
+	// Scheduler coordinates sending instructions with the other renderers on the page.  It is
+	// DefaultScheduler for renderers with a DirectTransport, and nil (not coordinated) for other
+	// transports, e.g. one per connection on a server.  It must not be changed once rendering has started.
+	Scheduler *Scheduler
+
+	// FastFirstRender makes the first render create the content of an empty mount point from scratch,
+	// which takes fewer instructions and skips the bookkeeping needed to compare with the next render
+	// (see visitMount).  It is for a mount point which starts out empty: where the program can see
+	// the document, one with child nodes (e.g. HTML rendered on the server) is synced as usual instead,
+	// but elsewhere (e.g. in a Web Worker) whatever is in the mount point is replaced.
+	FastFirstRender bool
+
+	// DiffStrategy decides which unchanged parts of the output are skipped rather than compared with
+	// the DOM.  If nil HashDiff is used.
+	DiffStrategy DiffStrategy
+
+	// HTMLSanitizer, if set, cleans the HTML of vg-html before it is written to the page, e.g. a
+	// vgsanitize.Policy for apps showing HTML from users.  Static HTML from component markup is left as is.
+	HTMLSanitizer vugu.HTMLSanitizer
+
+	// AdaptiveQuality, if set, lowers the rendering quality when renders take longer than a frame,
+	// see AdaptiveQuality.
+	AdaptiveQuality *AdaptiveQuality
+
+	// ListenerPolicy, if set, makes event listeners passive or capturing by event type, e.g.
+	// DefaultListenerPolicy() for passive scroll and touch listeners.  Handlers which set these
+	// options themselves keep them.  A handler with the prevent modifier on a passive listener is an
+	// error when rendered, and calling PreventDefault from one is reported (see OnError).
+	ListenerPolicy *ListenerPolicy
+
+	// DelegateEvents makes the renderer listen for events of the types which bubble, such as click, input
+	// and keydown, with one listener per type on the mount point element (or the shadow content container
+	// with ShadowRootMode), which passes them on to the handlers of the elements they bubble through.
+	// Otherwise each element has its own listeners, which are added again every render the element is
+	// synced, and that adds up for big lists.  Handlers still get the element as the currentTarget, but
+	// they are called once the event reaches the mount point, so the order changes: the listeners of
+	// the elements in between which are not delegated (such as those added outside of the renderer) are
+	// called first, stopping propagation in one of those keeps the handlers from being called, and
+	// stopping propagation in a handler no longer keeps those from being called.  Capturing listeners, the
+	// content of portals and event types which do not bubble, such as focus or mouseenter, are not
+	// delegated.  It must not be changed once rendering has started.
+	DelegateEvents bool
+
+	// ShadowRootMode, if set to "open" or "closed", makes the renderer attach a shadow root with that mode
+	// to the mount point element and render inside it, along with the CSS of its components, so the
+	// output is not affected by the page's styles and does not affect the page.  This is useful for
+	// widgets embedded in pages you do not control.  The mount point element itself is left as is, like
+	// when the root component has multiple root nodes, and a mount point selector is required.
+	ShadowRootMode string
+
+	createChildren bool // the next visitSyncElementEtc creates its children rather than syncing them
+	creating       bool // creating new elements, so there is nothing on them to remove
+
 	eventWaitCh chan bool          // events send to this and EventWait receives from it
 	eventRWMU   sync.RWMutex       // make sure Render and event handling are not attempted at the same time (not totally sure if this is necessary in terms of the wasm threading model but enforce it with a rwmutex all the same)
 	eventEnv    *vugu.EventEnvImpl // our EventEnv implementation that exposes eventRWMU and eventWaitCh to events in a clean way
 
-	eventHandlerFunc   js.Func // the callback function for DOM events
-	eventHandlerBuffer []byte
-	// eventHandlerTypedArray js.TypedArray
+	animationFrameCh chan struct{} // receives when an animation frame requested by EventWait occurs
 
-	instructionBuffer   []byte   // our local instruction buffer
-	instructionBufferJS js.Value // a Uint8Array on the JS side that we copy into
-	instructionList     *instructionList
+	instructionList *instructionList
 
-	window js.Value
+	transport Transport // how we talk to the JS helper script
+
+	mountPointRegistered bool // MountPointSelector is in window.vuguMountPoints, see NewForElement
+
+	released bool        // Release has been called
+	strict   strictState // see Strict
+	budget   budgetState // see RenderBudget
+
+	visitComp interface{} // the component whose output is being synced, recorded with its event handlers
 
 	jsRenderState *jsRenderState
 
 	// manages the Rendered lifecycle callback stuff
 	lifecycleStateMap map[interface{}]lifecycleState
 	lifecyclePassNum  uint8
+
+	debugOverlayState debugOverlayState
+
+	renderStats RenderStats // for the render in progress
 }
 
 type lifecycleState struct {
 	passNum uint8
 }
 
+// initialBufferSize returns the size the instruction buffer starts at.
+func (r *JSRenderer) initialBufferSize() int {
+	n := r.InstructionBufferInitialSize
+	if n == 0 {
+		n = DefaultInstructionBufferInitialSize
+	}
+	if r.InstructionBufferSize > 0 && n > r.InstructionBufferSize {
+		n = r.InstructionBufferSize
+	}
+	if n < minInstructionBufferSize {
+		n = minInstructionBufferSize
+	}
+	return n
+}
+
+// autoTuneInstructionBuffer records the most sent in one flush by the render just done, and shrinks
+// the instruction buffer if it is more than four times what the recent renders needed.
+func (r *JSRenderer) autoTuneInstructionBuffer() {
+
+	r.recentPeaks[r.recentPeakIndex] = r.instructionList.peak
+	r.recentPeakIndex = (r.recentPeakIndex + 1) % len(r.recentPeaks)
+
+	most := 0
+	for _, n := range r.recentPeaks {
+		if n > most {
+			most = n
+		}
+	}
+
+	size := most * 2
+	if size < r.initialBufferSize() {
+		size = r.initialBufferSize()
+	}
+	if il := r.instructionList; len(il.buf) > most*4 && len(il.buf) > size && il.pos == 0 {
+		il.buf = make([]byte, size)
+	}
+}
+
 // EventEnv returns an EventEnv that can be used for synchronizing updates.
 func (r *JSRenderer) EventEnv() vugu.EventEnv {
 	return r.eventEnv
 }
 
-// Release calls release on any resources that this renderer allocated.
+// Release calls release on any resources that this renderer allocated.  With a DirectTransport this
+// removes its window and document event listeners and portal content from the page, and empties its
+// shadow root, if any, so another renderer can be started there.  The renderer must not be used after.
 func (r *JSRenderer) Release() {
-	// NOTE: seems sensible to leave this here in case we do need something to be released, better than
-	// omitting it and people getting used to no release being needed and then requiring it later.
-	// r.instructionTypedArray.Release()
+	r.released = true
+	if rt, ok := r.transport.(releaser); ok {
+		rt.release()
+	}
+	if r.mountPointRegistered {
+		unregisterMountPoint(r.MountPointSelector)
+	}
 }
 
 func (r *JSRenderer) render(buildResults *vugu.BuildResults) error {
 
 	bo := buildResults.Out
 
-	if !js.Global().Truthy() {
-		return errors.New("js environment not available")
-	}
-
 	if bo == nil {
 		return errors.New("BuildOut is nil")
 	}
@@ -185,8 +450,73 @@ func (r *JSRenderer) render(buildResults *vugu.BuildResults) error {
 
 	state := r.jsRenderState
 
+	if r.Strict {
+		r.strictStart(buildResults)
+	}
+	if r.RenderBudget > 0 {
+		r.budgetStart()
+	}
+
+	// a new root component (see vugu.RootSwitch) has nothing in common with what is on the page
+	if state.root != buildResults.Root {
+		state.mounted = false
+		state.rootSwitched = state.root != nil
+		state.root = buildResults.Root
+	}
+
+	start := time.Now()
+	r.renderStats = RenderStats{Build: buildResults.BuildTime}
+	r.instructionList.count = 0
+	r.instructionList.maxLen = r.InstructionBufferSize
+	if r.instructionList.maxLen == 0 {
+		r.instructionList.maxLen = DefaultInstructionBufferSize
+	}
+	r.instructionList.growth = r.InstructionBufferGrowth
+	r.instructionList.flushAt = r.FlushWatermark
+	r.instructionList.peak = 0
+	if !state.mounted && r.instructionList.pos == 0 && len(r.instructionList.buf) != r.initialBufferSize() {
+		r.instructionList.buf = make([]byte, r.initialBufferSize())
+	}
+
 	state.callbackManager.startRender()
 	defer state.callbackManager.doneRender()
+	if r.Scheduler != nil {
+		defer r.Scheduler.done(r)
+	}
+
+	// start a new set of hashes, the prior set is what we compare against to skip unchanged subtrees
+	state.prevHashMap, state.hashMap = state.hashMap, make(map[string]uint64, len(state.hashMap))
+	state.prevChildIDMap, state.childIDMap = state.childIDMap, nil
+	state.prevTriggerMap, state.triggerMap = state.triggerMap, make(map[string]uint8, len(state.triggerMap))
+	state.prevDomHandlerMap, state.domHandlerMap = state.domHandlerMap, make(map[string]domHandlers, len(state.domHandlerMap))
+	state.prevDeferred, state.deferred = state.deferred, nil
+	state.portalList = state.portalList[:0]
+	renderOK := false
+	defer func() {
+		// if anything went wrong we can't trust the DOM to match the hashes, so don't skip anything next time
+		if !renderOK {
+			state.hashMap = nil
+			state.childIDMap = nil
+			// don't act on conditions again which were true before
+			for k, v := range state.prevTriggerMap {
+				state.triggerMap[k] |= v
+			}
+			// and keep handlers for anything we didn't get to, their listeners are still in the DOM
+			for k, v := range state.prevDomHandlerMap {
+				if _, ok := state.domHandlerMap[k]; !ok {
+					state.domHandlerMap[k] = v
+				}
+			}
+		}
+	}()
+
+	// the helper script checks it speaks the same protocol before anything else
+	if !state.protocolVersionSent {
+		err := r.instructionList.writeProtocolVersion(protocolVersion)
+		if err != nil {
+			return err
+		}
+	}
 
 	// TODO: move this next chunk out to it's own func at least
 
@@ -243,6 +573,17 @@ func (r *JSRenderer) render(buildResults *vugu.BuildResults) error {
 		}
 		return nil
 	}
+	// the CSS goes in the shadow root, so it has to exist first
+	if r.ShadowRootMode != "" {
+		if r.MountPointSelector == "" {
+			return errors.New("ShadowRootMode requires a mount point selector")
+		}
+		err := r.instructionList.writeSelectShadowRoot(r.MountPointSelector, r.ShadowRootMode)
+		if err != nil {
+			return err
+		}
+	}
+
 	err := walkCSSBuildOut(bo)
 	if err != nil {
 		return err
@@ -254,7 +595,21 @@ func (r *JSRenderer) render(buildResults *vugu.BuildResults) error {
 	}
 
 	// main output
+	if r.RenderBudget > 0 {
+		r.budgetEnter(buildResults.Root)
+	}
+	r.visitComp = buildResults.Root
+	defer func() { r.visitComp = nil }()
 	err = r.visitFirst(state, bo, buildResults, bo.Out[0], []byte("0"))
+	if r.RenderBudget > 0 {
+		r.budgetLeave()
+	}
+	if err != nil {
+		return err
+	}
+
+	// then anything rendered elsewhere
+	err = r.visitPortals(state, buildResults)
 	if err != nil {
 		return err
 	}
@@ -262,10 +617,83 @@ func (r *JSRenderer) render(buildResults *vugu.BuildResults) error {
 	// // JS stuff last
 	// // log.Printf("TODO: handle JS")
 
+	// any window or document listeners not set during this render belong to elements that are gone
+	err = r.instructionList.writeRemoveOtherGlobalEventListeners()
+	if err != nil {
+		return err
+	}
+
+	// see vugu.WriteClipboard
+	if text, ok := r.eventEnv.TakeClipboardWrite(); ok {
+		err = r.instructionList.writeWriteClipboard(text)
+		if err != nil {
+			return err
+		}
+	}
+
+	// see vugu.Focus
+	if ref, ok := r.eventEnv.TakeFocus(); ok {
+		err = r.instructionList.writeFocusRef(ref)
+		if err != nil {
+			return err
+		}
+	}
+	if ref, start, end, ok := r.eventEnv.TakeSelection(); ok {
+		err = r.instructionList.writeSetSelectionRef(ref, start, end)
+		if err != nil {
+			return err
+		}
+	}
+
+	// have JS drop its references for positions that no longer have handlers,
+	// either the elements were removed or their listeners were already removed above
+	for positionID := range state.prevDomHandlerMap {
+		if _, ok := state.domHandlerMap[positionID]; !ok {
+			err = r.instructionList.writeForgetPosition([]byte(positionID))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	state.prevDomHandlerMap = nil
+
+	// and every so often to check all it holds, which catches anything the above missed, e.g. after
+	// a render which failed part way or an element the browser or other code removed
+	state.rendersSinceSweep++
+	if state.rendersSinceSweep >= sweepInterval {
+		err = r.instructionList.writeSweepPositions()
+		if err != nil {
+			return err
+		}
+		state.rendersSinceSweep = 0
+	}
+
 	err = r.instructionList.flush()
 	if err != nil {
 		return err
 	}
+	renderOK = true
+	if r.Strict {
+		r.strictDone(buildResults)
+	}
+	state.protocolVersionSent = true
+	state.mounted = true
+	state.rootSwitched = false
+	if r.AutoTuneInstructionBuffer {
+		r.autoTuneInstructionBuffer()
+	}
+	r.renderStats.Diff = time.Since(start) - r.renderStats.Flush - r.renderStats.Wait
+	r.renderStats.Instructions = r.instructionList.count
+	if r.RenderBudget > 0 {
+		r.budgetDone(buildResults)
+	}
+	if r.AdaptiveQuality != nil {
+		r.adaptQuality()
+	}
+	// catch up on the output left as it was
+	if len(state.deferred) > 0 {
+		r.sendEventWaitCh()
+	}
 
 	// handle Rendered lifecycle callback
 	if r.lifecycleStateMap == nil {
@@ -296,26 +724,58 @@ func (r *JSRenderer) render(buildResults *vugu.BuildResults) error {
 		}
 	}
 
+	if r.DebugOverlay {
+		r.updateDebugOverlay(bo)
+	}
+
+	if r.OnRenderStats != nil {
+		r.renderStats.Total = time.Since(start)
+		r.OnRenderStats(r.renderStats)
+	}
+
 	return nil
 
 }
 
 // EventWait blocks until an event has occurred which causes a re-render.
 // It returns true if the render loop should continue or false if it should exit.
+// Unless DisableAnimationFrame is set, it then waits for the browser's next animation frame
+// before returning, so any other events which occur before then are handled by the same render
+// and rendering stays in sync with the display refresh (or for several frames when AdaptiveQuality
+// has lowered the quality).  Otherwise it lets other goroutines run
+// first, so changes made by those the event started (e.g. store subscribers) are rendered together.
+// See also vugu.Batch.
 func (r *JSRenderer) EventWait() (ok bool) {
 
-	// make sure the JS environment is still available, returning false otherwise
-	if !js.Global().Truthy() {
-		return false
+	ok = <-r.eventWaitCh
+	if !ok {
+		return
 	}
 
-	// FIXME: this should probably have some sort of "debouncing" on it to handle the case of
-	// several events in rapid succession causing multiple renders - maybe we read from eventWaitCH
-	// continuously until it's empty, with a max of like 20ms pause between each or something, and then
-	// only return after we don't see anything for that time frame.
+	if r.DisableAnimationFrame {
+		runtime.Gosched()
+	} else {
+		frames := 1
+		if r.AdaptiveQuality != nil {
+			frames = r.AdaptiveQuality.framesPerRender()
+		}
+		for i := 0; i < frames; i++ {
+			r.transport.Call("vuguRequestAnimationFrame")
+			<-r.animationFrameCh
+		}
+	}
 
-	ok = <-r.eventWaitCh
-	return
+	// drain anything that came in while we were waiting, it's covered by this render
+	for {
+		select {
+		case ok = <-r.eventWaitCh:
+			if !ok {
+				return
+			}
+		default:
+			return true
+		}
+	}
 
 }
 
@@ -330,8 +790,6 @@ func (r *JSRenderer) EventWait() (ok bool) {
 
 func (r *JSRenderer) visitFirst(state *jsRenderState, bo *vugu.BuildOut, br *vugu.BuildResults, n *vugu.VGNode, positionID []byte) error {
 
-	// log.Printf("TODO: We need to go through and optimize away unneeded calls to create elements, set attributes, set event handlers, etc. for cases where they are the same per hash")
-
 	// log.Printf("JSRenderer.visitFirst")
 
 	if n.Type != vugu.ElementNode {
@@ -425,15 +883,188 @@ func (r *JSRenderer) visitMount(state *jsRenderState, bo *vugu.BuildOut, br *vug
 
 	// log.Printf("visitMount got here")
 
+	// on the first render into an empty mount point, or after the root component changed, its children
+	// are created from scratch, which is quicker than syncing as there are fewer instructions and no
+	// hashes to record for skipping subtrees next time (the next render syncs everything, and records them)
+	r.createChildren = !state.mounted &&
+		(state.rootSwitched || r.FastFirstRender && mountPointEmpty(r.MountPointSelector, r.ShadowRootMode != ""))
+	defer func() { r.createChildren, r.creating = false, false }()
+
+	err := r.visitMountNode(state, bo, br, n, positionID)
+	if err != nil {
+		return wrapNodeError(err, nil, n, positionID)
+	}
+	return nil
+}
+
+// visitMountNode syncs n, the root of the output, to the mount point.
+func (r *JSRenderer) visitMountNode(state *jsRenderState, bo *vugu.BuildOut, br *vugu.BuildResults, n *vugu.VGNode, positionID []byte) error {
+
+	// multiple root nodes (a template) are synced as the children of the mount point,
+	// as is everything in a shadow root
+	if n.IsTemplate() || r.ShadowRootMode != "" {
+		return r.visitMountFragment(state, bo, br, n, positionID)
+	}
+
 	err := r.instructionList.writeSelectMountPoint(r.MountPointSelector, n.Data)
 	if err != nil {
 		return err
 	}
 
+	if r.Strict {
+		r.strictNode(n, positionID)
+	}
+
 	return r.visitSyncElementEtc(state, bo, br, n, positionID)
 
 }
 
+// visitMountFragment syncs the children of template node n as the children of the mount point,
+// leaving the mount point element itself in place.  With a ShadowRootMode they are synced in the
+// shadow root instead, and n itself is synced there if it is not a template.
+func (r *JSRenderer) visitMountFragment(state *jsRenderState, bo *vugu.BuildOut, br *vugu.BuildResults, n *vugu.VGNode, positionID []byte) error {
+
+	var err error
+	if r.ShadowRootMode != "" {
+		err = r.instructionList.writeSelectShadowRoot(r.MountPointSelector, r.ShadowRootMode)
+	} else {
+		err = r.instructionList.writeSelectMountPointContainer(r.MountPointSelector)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !n.IsTemplate() {
+		err = r.startCreating()
+		if err != nil {
+			return err
+		}
+		err = r.instructionList.writeMoveToFirstChild()
+		if err != nil {
+			return err
+		}
+		err = r.visitSyncNodeOrSkip(state, bo, br, n, positionID)
+		if err != nil {
+			return err
+		}
+		err = r.instructionList.writeMoveToNextSibling()
+		if err != nil {
+			return err
+		}
+		return r.instructionList.writeMoveToParent()
+	}
+
+	if n.FirstChild == nil {
+		return r.instructionList.writeSetInnerHTML("")
+	}
+
+	err = r.startCreating()
+	if err != nil {
+		return err
+	}
+
+	err = r.instructionList.writeMoveToFirstChild()
+	if err != nil {
+		return err
+	}
+
+	childIndex := 1
+	for nchild := n.FirstChild; nchild != nil; nchild = nchild.NextSibling {
+
+		childPositionID := append(positionID, []byte(fmt.Sprintf("_t_%d", childIndex))...)
+
+		err = r.visitSyncNodeOrSkip(state, bo, br, nchild, childPositionID)
+		if err != nil {
+			return err
+		}
+		err = r.instructionList.writeMoveToNextSibling()
+		if err != nil {
+			return err
+		}
+		childIndex++
+	}
+
+	return r.instructionList.writeMoveToParent()
+}
+
+// visitPortals syncs the nodes collected in state.portalList as the children of a container in their
+// portal target.  Each target has its own namespace of position IDs, so the content of one portal
+// does not affect how another, or the main output, is synced.
+func (r *JSRenderer) visitPortals(state *jsRenderState, br *vugu.BuildResults) error {
+
+	// portals inside portal content are appended to state.portalList as it is visited,
+	// these are synced in another round
+	done := make(map[string]bool)
+	for start := 0; start < len(state.portalList); {
+
+		// group by target, in the order first seen
+		var selectors []string
+		bySelector := make(map[string][]portalItem)
+		for _, item := range state.portalList[start:] {
+			if _, ok := bySelector[item.n.Portal]; !ok {
+				selectors = append(selectors, item.n.Portal)
+			}
+			bySelector[item.n.Portal] = append(bySelector[item.n.Portal], item)
+		}
+		start = len(state.portalList)
+
+		for _, sel := range selectors {
+
+			if done[sel] {
+				return fmt.Errorf("vg-portal %q is inside the content of another portal with the same target", sel)
+			}
+			done[sel] = true
+
+			err := r.instructionList.writeSelectPortal(sel)
+			if err != nil {
+				return err
+			}
+			err = r.instructionList.writeMoveToFirstChild()
+			if err != nil {
+				return err
+			}
+
+			for i, item := range bySelector[sel] {
+
+				childPositionID := []byte(fmt.Sprintf("p(%s)_%d", sel, i+1))
+
+				// render the node itself here instead of collecting it again
+				n := *item.n
+				n.Portal = ""
+				r.visitComp = item.comp
+
+				err = r.visitSyncNodeOrSkip(state, item.bo, br, &n, childPositionID)
+				if err != nil {
+					return err
+				}
+				err = r.instructionList.writeMoveToNextSibling()
+				if err != nil {
+					return err
+				}
+			}
+
+			err = r.instructionList.writeMoveToParent()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return r.instructionList.writeRemoveOtherPortals()
+}
+
+// inPortal returns true if positionID is in the content of a portal, which is outside the mount point
+// and so out of reach of delegated listeners.
+func inPortal(positionID []byte) bool {
+	return bytes.HasPrefix(positionID, []byte("p("))
+}
+
+// visitPortalPlaceholder records n to be rendered into its portal target and syncs a comment in its place.
+func (r *JSRenderer) visitPortalPlaceholder(state *jsRenderState, bo *vugu.BuildOut, n *vugu.VGNode) error {
+	state.portalList = append(state.portalList, portalItem{bo: bo, n: n, comp: r.visitComp})
+	return r.instructionList.writeSetComment("vg-portal " + n.Portal)
+}
+
 func (r *JSRenderer) visitSyncNode(state *jsRenderState, bo *vugu.BuildOut, br *vugu.BuildResults, n *vugu.VGNode, positionID []byte) error {
 
 	// log.Printf("visitSyncNode")
@@ -447,6 +1078,9 @@ func (r *JSRenderer) visitSyncNode(state *jsRenderState, bo *vugu.BuildOut, br *
 			return fmt.Errorf("component %#v expected exactly one Out element but got %d instead",
 				n.Component, len(compBuildOut.Out))
 		}
+		prevComp := r.visitComp
+		r.visitComp = n.Component
+		defer func() { r.visitComp = prevComp }()
 		return r.visitSyncNode(state, compBuildOut, br, compBuildOut.Out[0], positionID)
 	}
 
@@ -459,7 +1093,7 @@ func (r *JSRenderer) visitSyncNode(state *jsRenderState, bo *vugu.BuildOut, br *
 			// use a different character here for the position to ensure it's unique
 			childPositionID := append(positionID, []byte(fmt.Sprintf("_t_%d", childIndex))...)
 
-			err = r.visitSyncNode(state, bo, br, nchild, childPositionID)
+			err = r.visitSyncNodeOrSkip(state, bo, br, nchild, childPositionID)
 			if err != nil {
 				return err
 			}
@@ -504,6 +1138,146 @@ func (r *JSRenderer) visitSyncNode(state *jsRenderState, bo *vugu.BuildOut, br *
 
 }
 
+// visitSyncNodeOrSkip compares the hash of n with the one rendered at the same position last time
+// and if they match emits a skip instead of syncing the subtree, otherwise it calls visitSyncNode.
+func (r *JSRenderer) visitSyncNodeOrSkip(state *jsRenderState, bo *vugu.BuildOut, br *vugu.BuildResults, n *vugu.VGNode, positionID []byte) (err error) {
+
+	var comp interface{}
+	defer func() {
+		if err != nil {
+			err = wrapNodeError(err, comp, n, positionID)
+		}
+	}()
+
+	// resolve components to the node they output, this is what actually ends up in the DOM
+	for n.Component != nil {
+		comp = n.Component
+		if n.Portal != "" {
+			return r.visitPortalPlaceholder(state, bo, n)
+		}
+		compBuildOut := br.ResultFor(n.Component)
+		if len(compBuildOut.Out) != 1 {
+			return fmt.Errorf("component %#v expected exactly one Out element but got %d instead",
+				n.Component, len(compBuildOut.Out))
+		}
+		bo, n = compBuildOut, compBuildOut.Out[0]
+	}
+
+	if r.Strict {
+		r.strictNode(n, positionID)
+	}
+
+	if n.Portal != "" {
+		return r.visitPortalPlaceholder(state, bo, n)
+	}
+
+	if comp != nil {
+		prevComp := r.visitComp
+		r.visitComp = comp
+		defer func() { r.visitComp = prevComp }()
+		if r.RenderBudget > 0 {
+			r.budgetEnter(comp)
+			defer r.budgetLeave()
+		}
+	}
+
+	if comp != nil && r.deferSubtree(state, br, comp, n, positionID) {
+		return r.instructionList.writeSkipNode()
+	}
+
+	// templates flatten into multiple DOM nodes and so cannot be skipped as one,
+	// and new nodes are not compared with anything
+	if n.IsTemplate() || r.creating {
+		return r.visitSyncNode(state, bo, br, n, positionID)
+	}
+
+	d := DiffNode{state: state, br: br, n: n, positionID: positionID}
+	if r.diffStrategy().SkipNode(&d) && r.refreshSkipped(state, br, n, positionID) {
+		return r.instructionList.writeSkipNode()
+	}
+
+	return r.visitSyncNode(state, bo, br, n, positionID)
+}
+
+// refreshSkipped walks a subtree that is unchanged since the last render, updating the handler
+// functions in domHandlerMap (they are new closures each build) and recording the hash of each
+// position in it.  It returns false if the subtree contains something that must be synced
+// every time (JS properties or form element values, which can diverge from the DOM, or vg-js-* callbacks).
+func (r *JSRenderer) refreshSkipped(state *jsRenderState, br *vugu.BuildResults, n *vugu.VGNode, positionID []byte) bool {
+
+	var bo *vugu.BuildOut
+	if n.Component != nil && n.Portal == "" {
+		prevComp := r.visitComp
+		defer func() { r.visitComp = prevComp }()
+	}
+	for n.Component != nil && n.Portal == "" {
+		r.visitComp = n.Component
+		compBuildOut := br.ResultFor(n.Component)
+		if len(compBuildOut.Out) != 1 {
+			return false
+		}
+		bo, n = compBuildOut, compBuildOut.Out[0]
+	}
+
+	// the placeholder comment is unchanged, the portal content is synced separately
+	if n.Portal != "" {
+		state.portalList = append(state.portalList, portalItem{bo: bo, n: n, comp: r.visitComp})
+		return true
+	}
+
+	if n.IsTemplate() {
+		childIndex := 1
+		for nchild := n.FirstChild; nchild != nil; nchild = nchild.NextSibling {
+			childPositionID := append(positionID, []byte(fmt.Sprintf("_t_%d", childIndex))...)
+			if !r.refreshSkipped(state, br, nchild, childPositionID) {
+				return false
+			}
+			childIndex++
+		}
+		return true
+	}
+
+	if len(n.Prop) > 0 || n.JSCreateHandler != nil || n.JSPopulateHandler != nil || hasFormProperties(n) {
+		return false
+	}
+
+	// window and document listeners are removed unless set again each render
+	for _, hs := range n.DOMEventHandlerSpecList {
+		if hs.Global != "" {
+			return false
+		}
+	}
+
+	state.hashMap[string(positionID)] = br.NodeHash(n)
+
+	r.keepTriggers(state, positionID)
+	r.keepChildIDs(state, positionID)
+
+	if len(n.DOMEventHandlerSpecList) > 0 {
+		// the same specs as when it was synced, so events from its listeners still match
+		specs, err := r.listenerSpecs(n)
+		if err != nil {
+			return false
+		}
+		state.domHandlerMap[string(positionID)] = domHandlers{specs: specs, comp: r.visitComp}
+	}
+
+	if n.InnerHTML != nil {
+		return true
+	}
+
+	childIndex := 1
+	for nchild := n.FirstChild; nchild != nil; nchild = nchild.NextSibling {
+		childPositionID := append(positionID, []byte(fmt.Sprintf("_%d", childIndex))...)
+		if !r.refreshSkipped(state, br, nchild, childPositionID) {
+			return false
+		}
+		childIndex++
+	}
+
+	return true
+}
+
 // visitSyncElementEtc syncs the rest of the stuff that only applies to elements
 func (r *JSRenderer) visitSyncElementEtc(state *jsRenderState, bo *vugu.BuildOut, br *vugu.BuildResults, n *vugu.VGNode, positionID []byte) error {
 
@@ -513,6 +1287,12 @@ func (r *JSRenderer) visitSyncElementEtc(state *jsRenderState, bo *vugu.BuildOut
 	}
 
 	if n.InnerHTML != nil {
+		if n.InnerHTMLStatic {
+			return r.instructionList.writeSetStaticInnerHTML(*n.InnerHTML)
+		}
+		if r.HTMLSanitizer != nil {
+			return r.instructionList.writeSetInnerHTML(r.HTMLSanitizer.SanitizeHTML(*n.InnerHTML))
+		}
 		return r.instructionList.writeSetInnerHTML(*n.InnerHTML)
 	}
 
@@ -531,6 +1311,16 @@ func (r *JSRenderer) visitSyncElementEtc(state *jsRenderState, bo *vugu.BuildOut
 
 	if n.FirstChild != nil {
 
+		err = r.startCreating()
+		if err != nil {
+			return err
+		}
+
+		err = r.reorderChildren(state, br, n, positionID)
+		if err != nil {
+			return err
+		}
+
 		err = r.instructionList.writeMoveToFirstChild()
 		if err != nil {
 			return err
@@ -541,7 +1331,7 @@ func (r *JSRenderer) visitSyncElementEtc(state *jsRenderState, bo *vugu.BuildOut
 
 			childPositionID := append(positionID, []byte(fmt.Sprintf("_%d", childIndex))...)
 
-			err = r.visitSyncNode(state, bo, br, nchild, childPositionID)
+			err = r.visitSyncNodeOrSkip(state, bo, br, nchild, childPositionID)
 			if err != nil {
 				return err
 			}
@@ -572,8 +1362,25 @@ func (r *JSRenderer) visitSyncElementEtc(state *jsRenderState, bo *vugu.BuildOut
 }
 
 func (r *JSRenderer) syncElement(state *jsRenderState, n *vugu.VGNode, positionID []byte) error {
+
+	// with a ClassMap or StyleMap the class and style attributes are applied by a diffing instruction below
+	var baseStyle string
+	skipAttr := func(a vugu.VGAttribute) bool {
+		if a.Key == "class" && n.ClassMap != nil {
+			return true
+		}
+		if a.Key == "style" && n.StyleMap != nil {
+			baseStyle = a.Val
+			return true
+		}
+		return false
+	}
+
 	if namespaceToURI(n.Namespace) != "" {
 		for _, a := range n.Attr {
+			if skipAttr(a) {
+				continue
+			}
 			ns := namespaceToURI(a.Namespace)
 			// FIXME: we skip Namespace="" && Key = "xmlns" here, because this WILL cause an js exception
 			// the correct way would be, to parse the xmlns attribute in the generator, set the namespace of the holding element
@@ -581,25 +1388,97 @@ func (r *JSRenderer) syncElement(state *jsRenderState, n *vugu.VGNode, positionI
 			if ns == "" && a.Key == "xmlns" {
 				continue
 			}
-			err := r.instructionList.writeSetAttrNSStr(ns, a.Key, a.Val)
+			var err error
+			if a.IsBool() {
+				err = r.instructionList.writeSetAttrBool(a.Key, a.Present())
+			} else {
+				err = r.instructionList.writeSetAttrNSStr(ns, a.Key, a.Val)
+			}
 			if err != nil {
 				return err
 			}
 		}
 	} else {
 		for _, a := range n.Attr {
-			err := r.instructionList.writeSetAttrStr(a.Key, a.Val)
+			if skipAttr(a) {
+				continue
+			}
+			var err error
+			if a.IsBool() {
+				err = r.instructionList.writeSetAttrBool(a.Key, a.Present())
+			} else {
+				err = r.instructionList.writeSetAttrStr(a.Key, a.Val)
+			}
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	err := r.instructionList.writeRemoveOtherAttrs()
+	if n.ClassMap != nil {
+		err := r.instructionList.writeSetClassList(strings.Join(n.ClassList(), " "))
+		if err != nil {
+			return err
+		}
+	}
+
+	if n.StyleMap != nil {
+		names, values := n.StyleList()
+		err := r.instructionList.writeSetStyle(baseStyle, names, values)
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if !r.creating {
+		err = r.instructionList.writeRemoveOtherAttrs()
+		if err != nil {
+			return err
+		}
+	}
+
+	// vg-show
+	if n.Hidden {
+		err = r.instructionList.writeSetHidden()
+		if err != nil {
+			return err
+		}
+	}
+
+	// vg-focus and vg-scroll-into-view
+	err = r.writeTriggers(state, n, positionID)
 	if err != nil {
 		return err
 	}
 
+	// vg-scroll-key
+	if n.ScrollKey != "" {
+		err = r.instructionList.writeSetScrollKey(n.ScrollKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	// set the properties corresponding to form element attributes, see formProperties
+	if namespaceToURI(n.Namespace) == "" {
+		for _, fp := range formProperties[n.Data] {
+			for _, a := range n.Attr {
+				if a.Key != fp.key {
+					continue
+				}
+				if fp.isBool {
+					err = r.instructionList.writeSetPropertyBool(a.Key, a.Present())
+				} else {
+					err = r.instructionList.writeSetPropertyStr(a.Key, a.Val)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
 	// do any JS properties
 	for _, p := range n.Prop {
 		err := r.instructionList.writeSetProperty(p.Key, []byte(p.JSONVal))
@@ -610,20 +1489,98 @@ func (r *JSRenderer) syncElement(state *jsRenderState, n *vugu.VGNode, positionI
 
 	if len(n.DOMEventHandlerSpecList) > 0 {
 
+		specs, err := r.listenerSpecs(n)
+		if err != nil {
+			return err
+		}
+
 		// store in domHandlerMap
-		state.domHandlerMap[string(positionID)] = n.DOMEventHandlerSpecList
+		state.domHandlerMap[string(positionID)] = domHandlers{specs: specs, comp: r.visitComp}
 
-		for _, hs := range n.DOMEventHandlerSpecList {
-			err := r.instructionList.writeSetEventListener(positionID, hs.EventType, hs.Capture, hs.Passive)
+		for i, hs := range specs {
+			if hasListenerBefore(specs, i) {
+				continue
+			}
+			if mode, ms := eventRate(hs); mode != 0 {
+				err := r.instructionList.writeSetEventRate(mode, ms)
+				if err != nil {
+					return err
+				}
+			}
+			if hs.Global != "" {
+				err := r.instructionList.writeSetGlobalEventListener(positionID, hs.Global, hs.EventType, hs.Capture, hs.Passive, uint32(hs.Modifiers))
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if r.DelegateEvents && !hs.Capture && delegatedEventTypes[hs.EventType] && !inPortal(positionID) {
+				err := r.instructionList.writeSetDelegatedEventListener(positionID, hs.EventType, hs.Passive, uint32(hs.Modifiers))
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			err := r.instructionList.writeSetEventListener(positionID, hs.EventType, hs.Capture, hs.Passive, uint32(hs.Modifiers))
 			if err != nil {
 				return err
 			}
 		}
 	}
 	// always write the remove for event listeners so any previous ones are taken away
+	if r.creating {
+		return nil
+	}
 	return r.instructionList.writeRemoveOtherEventListeners(positionID)
 }
 
+// hasListenerBefore returns true if a spec before list[i] has the same options, so they share a listener.
+func hasListenerBefore(list []vugu.DOMEventHandlerSpec, i int) bool {
+	hs := list[i]
+	for _, prev := range list[:i] {
+		if prev.EventType == hs.EventType && prev.Capture == hs.Capture && prev.Passive == hs.Passive &&
+			prev.Modifiers == hs.Modifiers && prev.Global == hs.Global &&
+			prev.Debounce == hs.Debounce && prev.Throttle == hs.Throttle {
+			return true
+		}
+	}
+	return false
+}
+
+// eventRate returns the mode and interval in milliseconds of opcodeSetEventRate for hs, mode is 0 if its
+// events are not debounced or throttled.
+func eventRate(hs vugu.DOMEventHandlerSpec) (mode uint8, ms uint32) {
+	switch {
+	case hs.Debounce > 0:
+		return eventRateDebounce, uint32(hs.Debounce / time.Millisecond)
+	case hs.Throttle > 0:
+		return eventRateThrottle, uint32(hs.Throttle / time.Millisecond)
+	}
+	return 0, 0
+}
+
+// eventRateKey returns how the helper script identifies the debounce or throttle of hs in event payloads,
+// e.g. "d300", empty if there is none.
+func eventRateKey(hs vugu.DOMEventHandlerSpec) string {
+	switch mode, ms := eventRate(hs); mode {
+	case eventRateDebounce:
+		return "d" + strconv.FormatUint(uint64(ms), 10)
+	case eventRateThrottle:
+		return "t" + strconv.FormatUint(uint64(ms), 10)
+	}
+	return ""
+}
+
+// startCreating clears the current element if createChildren is set, so the children synced
+// next are created rather than compared with what was there, see visitMount.
+func (r *JSRenderer) startCreating() error {
+	if !r.createChildren {
+		return nil
+	}
+	r.createChildren, r.creating = false, true
+	return r.instructionList.writeSetInnerHTML("")
+}
+
 // // writeAllStaticAttrs is a helper to write all the static attrs from a VGNode
 // func (r *JSRenderer) writeAllStaticAttrs(n *vugu.VGNode) error {
 // 	for _, a := range n.Attr {
@@ -639,10 +1596,31 @@ func (r *JSRenderer) handleCallback(this js.Value, args []js.Value) interface{}
 	return r.jsRenderState.callbackManager.callback(this, args)
 }
 
-func (r *JSRenderer) handleDOMEvent() {
-
-	strlen := binary.BigEndian.Uint32(r.eventHandlerBuffer[:4])
-	b := r.eventHandlerBuffer[4 : strlen+4]
+// eventPayloadVersion is the version of the event payload format sent by the JS helper script,
+// incremented when the fields change incompatibly.  Version 1 payloads are JSON objects with:
+//
+//	v             - the version, 1
+//	position_id   - positionID of the element the listener is on
+//	event_type    - event type the listener was registered with
+//	capture       - capture flag the listener was registered with
+//	passive       - passive flag the listener was registered with
+//	modifiers     - vugu.DOMEventModifiers the listener was registered with
+//	global_target - "window" or "document" for global listeners, otherwise empty
+//	rate          - e.g. "d300" or "t100" for listeners debounced or throttled by that many milliseconds, empty or missing otherwise
+//	event_summary - the primitive values of the event and its target, plus any extra data requested by modifiers
+const eventPayloadVersion = 1
+
+// handleDOMEvent handles an event from the transport, data is the uint32 length of the payload
+// followed by the payload.
+func (r *JSRenderer) handleDOMEvent(data []byte) {
+
+	// the data may come from over a network, report anything invalid rather than panicing
+	if len(data) < 4 || uint64(binary.BigEndian.Uint32(data[:4]))+4 > uint64(len(data)) {
+		r.reportError(fmt.Errorf("invalid event data of length %d", len(data)))
+		return
+	}
+	strlen := binary.BigEndian.Uint32(data[:4])
+	b := data[4 : strlen+4]
 	// log.Printf("handleDOMEvent JSON from event buffer: %q", b)
 
 	// var ee eventEnv
@@ -654,6 +1632,9 @@ func (r *JSRenderer) handleDOMEvent() {
 		EventType  string // `json:"event_type"`
 		Capture    bool   // `json:"capture"`
 		Passive    bool   // `json:"passive"`
+		Modifiers  uint32 // `json:"modifiers"`
+		Global     string // `json:"global_target"`
+		Rate       string // `json:"rate"`, see eventRateKey
 
 		// the event object data as extracted above
 		EventSummary map[string]interface{} // `json:"event_summary"`
@@ -663,7 +1644,14 @@ func (r *JSRenderer) handleDOMEvent() {
 	// err := json.Unmarshal(b, &eventDetail)
 	err := vjson.Unmarshal(b, &edm)
 	if err != nil {
-		panic(err)
+		r.reportError(fmt.Errorf("invalid event data: %w", err))
+		return
+	}
+
+	// check the version before looking at anything else
+	if v, _ := edm["v"].(float64); v != eventPayloadVersion {
+		r.reportError(fmt.Errorf("event payload version %v does not match expected version %d", edm["v"], eventPayloadVersion))
+		return
 	}
 
 	// manually extract fields
@@ -671,6 +1659,10 @@ func (r *JSRenderer) handleDOMEvent() {
 	eventDetail.EventType, _ = edm["event_type"].(string)
 	eventDetail.Capture, _ = edm["capture"].(bool)
 	eventDetail.Passive, _ = edm["passive"].(bool)
+	modifiers, _ := edm["modifiers"].(float64)
+	eventDetail.Modifiers = uint32(modifiers)
+	eventDetail.Global, _ = edm["global_target"].(string)
+	eventDetail.Rate, _ = edm["rate"].(string)
 	eventDetail.EventSummary, _ = edm["event_summary"].(map[string]interface{})
 
 	domEvent := vugu.NewDOMEvent(r.eventEnv, eventDetail.EventSummary)
@@ -681,33 +1673,53 @@ func (r *JSRenderer) handleDOMEvent() {
 	// and around the invokation of the handler call itself
 
 	r.eventRWMU.Lock()
+	// every handler with the same options shares the one listener, they are called in the order
+	// they are in the list, see vugu.VGNode.DOMEventHandlerSpecList
 	handlers := r.jsRenderState.domHandlerMap[eventDetail.PositionID]
-	var f func(vugu.DOMEvent)
-	for _, h := range handlers {
-		if h.EventType == eventDetail.EventType && h.Capture == eventDetail.Capture {
-			f = h.Func
-			break
+	var fs []func(vugu.DOMEvent)
+	for _, h := range handlers.specs {
+		if h.EventType == eventDetail.EventType && h.Capture == eventDetail.Capture && h.Passive == eventDetail.Passive && uint32(h.Modifiers) == eventDetail.Modifiers && h.Global == eventDetail.Global &&
+			eventRateKey(h) == eventDetail.Rate && h.Func != nil {
+			fs = append(fs, h.Func)
 		}
 	}
 
-	// make sure we found something, panic if not
-	if f == nil {
+	// make sure we found something, report if not
+	if len(fs) == 0 {
 		r.eventRWMU.Unlock()
-		panic(fmt.Errorf("Unable to find event handler for positionID=%q, eventType=%q, capture=%v",
-			eventDetail.PositionID, eventDetail.EventType, eventDetail.Capture))
+		r.reportError(fmt.Errorf("Unable to find event handler for positionID=%q, eventType=%q, capture=%v, modifiers=%d",
+			eventDetail.PositionID, eventDetail.EventType, eventDetail.Capture, eventDetail.Modifiers))
+		return
 	}
 
-	// NOTE: For tinygo support we are not using defer here for now - it would probably be better to do so since
-	// the handler can panic.  However, Vugu program behavior after panicing from an event is currently
-	// undefined so whatever for now.  We'll have to make a decision later about whether or not Vugu
-	// programs should keep running after an event handler panics.  They do in JS after an exception,
-	// but... this is not JS.  Needs more thought.
+	// invoke handlers, a panic is recovered (except in tinygo) and reported and the program keeps
+	// running, much like an exception in a JS event handler, which does not stop the others either
+	var errs []error
+	var pe *passiveEvent
+	if eventDetail.Passive {
+		pe = &passiveEvent{DOMEvent: domEvent}
+		domEvent = pe
+	}
+	for _, f := range fs {
+		if err := r.invokeEventHandler(f, domEvent); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if pe != nil && pe.prevented {
+		errs = append(errs, fmt.Errorf("PreventDefault was called for a %q event from a passive listener, which the browser ignores; use the prevent modifier (e.g. @%s.prevent) so the listener is not passive",
+			eventDetail.EventType, eventDetail.EventType))
+	}
 
-	// invoke handler
-	f(domEvent)
+	if r.AuditTrail != nil {
+		r.AuditTrail.recordEvent(eventDetail.EventType, eventDetail.PositionID, eventDetail.Global, eventDetail.EventSummary, handlers.comp)
+	}
 
 	r.eventRWMU.Unlock()
 
+	for _, err := range errs {
+		r.reportError(err)
+	}
+
 	// TODO: Also give this more thought: For now we just do a non-blocking push to the
 	// eventWaitCh, telling the render loop that a render is required, but if a bunch
 	// of them stack up we don't wait
@@ -0,0 +1,615 @@
+package domrender
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+// newTestRenderer returns a JSRenderer mounted at "#app" which renders to a CaptureTransport, and a
+// BuildEnv for it.
+func newTestRenderer(t *testing.T) (*JSRenderer, *CaptureTransport, *vugu.BuildEnv) {
+	t.Helper()
+	tr := &CaptureTransport{}
+	r, err := NewWithTransport("#app", tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buildEnv, err := vugu.NewBuildEnv(r.EventEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, tr, buildEnv
+}
+
+func TestFastFirstRender(t *testing.T) {
+
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		ul := &vugu.VGNode{Type: vugu.ElementNode, Data: "ul"}
+		for i := 0; i < 3; i++ {
+			li := &vugu.VGNode{Type: vugu.ElementNode, Data: "li", Attr: []vugu.VGAttribute{{Key: "class", Val: "item"}}}
+			li.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: "text"})
+			ul.AppendChild(li)
+		}
+		return &vugu.BuildOut{Out: []*vugu.VGNode{ul}}
+	})
+
+	// count returns how many of the instructions in each render have the name
+	count := func(t *testing.T, fast bool, name string) []int {
+		r, tr, buildEnv := newTestRenderer(t)
+		r.FastFirstRender = fast
+		assert.NoError(t, r.Render(buildEnv.RunBuild(root)))
+		assert.NoError(t, r.Render(buildEnv.RunBuild(root)))
+		var ret []int
+		for _, b := range tr.Renders {
+			instructions, err := DecodeInstructions(b)
+			assert.NoError(t, err)
+			n := 0
+			for _, in := range instructions {
+				if in.Name == name {
+					n++
+				}
+			}
+			ret = append(ret, n)
+		}
+		return ret
+	}
+
+	// the first render empties the mount point and only the mount point's own attributes are synced,
+	// the second syncs everything as there are no hashes recorded to skip the unchanged items with
+	assert.Equal(t, []int{1, 0}, count(t, true, "setInnerHTML"))
+	assert.Equal(t, []int{1, 4}, count(t, true, "removeOtherAttrs"))
+
+	// by default everything is synced first, so the unchanged items are skipped the second time
+	assert.Equal(t, []int{0, 0}, count(t, false, "setInnerHTML"))
+	assert.Equal(t, []int{4, 1}, count(t, false, "removeOtherAttrs"))
+}
+
+func TestShadowRootMode(t *testing.T) {
+
+	assert := assert.New(t)
+
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		div := &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+		div.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: "hello"})
+		css := &vugu.VGNode{Type: vugu.ElementNode, Data: "style"}
+		css.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: "div { color: red; }"})
+		return &vugu.BuildOut{Out: []*vugu.VGNode{div}, CSS: []*vugu.VGNode{css}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	r.ShadowRootMode = "open"
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	if !assert.Len(tr.Renders, 1) {
+		return
+	}
+
+	instructions, err := DecodeInstructions(tr.Renders[0])
+	assert.NoError(err)
+	var names []string
+	for _, in := range instructions {
+		names = append(names, in.Name)
+	}
+
+	// the shadow root is selected before the CSS so the CSS goes in it,
+	// and the root element is synced inside it rather than replacing the mount point
+	if assert.Contains(names, "selectShadowRoot") && assert.Contains(names, "setCSSTag") {
+		assert.Equal(`selectShadowRoot("#app", "open")`, instructions[indexOf(names, "selectShadowRoot")].String())
+		assert.Less(indexOf(names, "selectShadowRoot"), indexOf(names, "setCSSTag"))
+	}
+	assert.NotContains(names, "selectMountPoint")
+	assert.Contains(names, "setElement")
+
+	// which needs a mount point
+	r, err = NewWithTransport("", &CaptureTransport{})
+	assert.NoError(err)
+	r.ShadowRootMode = "open"
+	buildEnv, err = vugu.NewBuildEnv(r.EventEnv())
+	assert.NoError(err)
+	assert.Error(r.Render(buildEnv.RunBuild(root)))
+}
+
+func indexOf(list []string, s string) int {
+	for i := range list {
+		if list[i] == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestStaticInnerHTML(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var content interface{}
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		div := &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+		div.SetInnerHTML(content)
+		return &vugu.BuildOut{Out: []*vugu.VGNode{div}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	content = vugu.StaticHTML("<p>static</p>")
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	content = vugu.HTML("<p>dynamic</p>")
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	if !assert.Len(tr.Renders, 2) {
+		return
+	}
+
+	render := func(i int) string {
+		var buf bytes.Buffer
+		assert.NoError(FormatInstructions(&buf, tr.Renders[i]))
+		return buf.String()
+	}
+	assert.Contains(render(0), `setStaticInnerHTML("<p>static</p>")`)
+	assert.Contains(render(1), `setInnerHTML("<p>dynamic</p>")`)
+}
+
+func TestRootSwitch(t *testing.T) {
+
+	assert := assert.New(t)
+
+	newRoot := func(tag string) vugu.Builder {
+		return vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+			n := &vugu.VGNode{Type: vugu.ElementNode, Data: tag}
+			n.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: tag})
+			return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+		})
+	}
+	login, app := newRoot("form"), newRoot("main")
+
+	r, tr, buildEnv := newTestRenderer(t)
+
+	roots := vugu.NewRootSwitch(login)
+	for i := 0; i < 4; i++ {
+		if i == 2 {
+			roots.Set(app)
+		}
+		assert.NoError(r.Render(buildEnv.RunBuild(roots.Root())))
+	}
+
+	// the mount point is emptied and filled again for the new root, the first render syncs as
+	// FastFirstRender is not set
+	var got []int
+	for _, b := range tr.Renders {
+		instructions, err := DecodeInstructions(b)
+		assert.NoError(err)
+		n := 0
+		for _, in := range instructions {
+			if in.Name == "setInnerHTML" {
+				n++
+			}
+		}
+		got = append(got, n)
+	}
+	assert.Equal([]int{0, 0, 1, 0}, got)
+}
+
+func TestMultipleHandlers(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// two click handlers with the same options, one which panics, and one with a modifier
+	var calls []string
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "button"}
+		n.DOMEventHandlerSpecList = []vugu.DOMEventHandlerSpec{
+			{EventType: "click", Func: func(vugu.DOMEvent) { calls = append(calls, "first") }},
+			{EventType: "click", Func: func(vugu.DOMEvent) { panic("oops") }},
+			{EventType: "click", Func: func(vugu.DOMEvent) { calls = append(calls, "second") }},
+			{EventType: "click", Func: func(vugu.DOMEvent) { calls = append(calls, "prevent") }, Modifiers: vugu.DOMEventModPrevent},
+		}
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	var reported []error
+	r.OnError = func(err error) { reported = append(reported, err) }
+	r.DisableErrorOverlay = true
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+
+	// one listener for each set of options
+	instructions, err := DecodeInstructions(tr.Renders[0])
+	assert.NoError(err)
+	listeners := 0
+	for _, in := range instructions {
+		if in.Name == "setEventListener" {
+			listeners++
+		}
+	}
+	assert.Equal(2, listeners)
+
+	event := func(modifiers int) {
+		assert.NoError(tr.SendEvent(map[string]interface{}{"modifiers": modifiers}))
+	}
+
+	// all of those sharing a listener are called in order, a panic does not stop the others
+	event(0)
+	assert.Equal([]string{"first", "second"}, calls)
+	assert.Len(reported, 1)
+
+	calls = nil
+	event(int(vugu.DOMEventModPrevent))
+	assert.Equal([]string{"prevent"}, calls)
+}
+
+func TestScrollKey(t *testing.T) {
+
+	assert := assert.New(t)
+
+	key := "list"
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "ul", ScrollKey: key}
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	for _, k := range []string{"list", "other"} {
+		key = k
+		assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	}
+
+	var keys []string
+	for _, b := range tr.Renders {
+		instructions, err := DecodeInstructions(b)
+		assert.NoError(err)
+		for _, in := range instructions {
+			if in.Name == "setScrollKey" {
+				keys = append(keys, in.Args[0].(string))
+			}
+		}
+	}
+	assert.Equal([]string{"list", "other"}, keys)
+}
+
+func TestFocusTrigger(t *testing.T) {
+
+	assert := assert.New(t)
+
+	focus := false
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+		n.AppendChild(&vugu.VGNode{Type: vugu.ElementNode, Data: "input", Focus: focus})
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	// the focus instruction is only written when the condition becomes true, including after renders
+	// where the element was skipped as unchanged
+	for _, f := range []bool{false, true, true, true, true, false, true} {
+		focus = f
+		assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	}
+
+	var counts []int
+	for _, b := range tr.Renders {
+		instructions, err := DecodeInstructions(b)
+		assert.NoError(err)
+		count := 0
+		for _, in := range instructions {
+			if in.Name == "focus" {
+				count++
+			}
+		}
+		counts = append(counts, count)
+	}
+	assert.Equal([]int{0, 1, 0, 0, 0, 0, 1}, counts)
+}
+
+func TestHTMLSanitizer(t *testing.T) {
+
+	assert := assert.New(t)
+
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		div := &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+		user := &vugu.VGNode{Type: vugu.ElementNode, Data: "p"}
+		user.SetInnerHTML(vugu.HTML(`<b onclick="x()">hi</b>`))
+		div.AppendChild(user)
+		static := &vugu.VGNode{Type: vugu.ElementNode, Data: "p"}
+		static.SetInnerHTML(vugu.StaticHTML(`<i onclick="y()">static</i>`))
+		div.AppendChild(static)
+		return &vugu.BuildOut{Out: []*vugu.VGNode{div}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	r.HTMLSanitizer = vugu.HTMLSanitizerFunc(func(s string) string { return strings.Replace(s, ` onclick="x()"`, "", -1) })
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+
+	var html []string
+	for _, b := range tr.Renders {
+		instructions, err := DecodeInstructions(b)
+		assert.NoError(err)
+		for _, in := range instructions {
+			// not the empty one clearing the mount point
+			if (in.Name == "setInnerHTML" || in.Name == "setStaticInnerHTML") && in.Args[0] != "" {
+				html = append(html, in.Args[0].(string))
+			}
+		}
+	}
+	assert.Equal([]string{`<b>hi</b>`, `<i onclick="y()">static</i>`}, html)
+}
+
+func TestFocusRef(t *testing.T) {
+
+	assert := assert.New(t)
+
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		return &vugu.BuildOut{Out: []*vugu.VGNode{{Type: vugu.ElementNode, Data: "input", Attr: []vugu.VGAttribute{{Key: "data-vg-ref", Val: "name"}}}}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+
+	vugu.Focus(r.EventEnv(), "name")
+	vugu.SetSelection(r.EventEnv(), "name", 1, 4)
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	// only written once
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+
+	var refs []string
+	for _, b := range tr.Renders {
+		instructions, err := DecodeInstructions(b)
+		assert.NoError(err)
+		for _, in := range instructions {
+			if in.Name == "focusRef" || in.Name == "setSelectionRef" {
+				refs = append(refs, in.String())
+			}
+		}
+	}
+	assert.Equal([]string{`focusRef("name")`, `setSelectionRef("name", 1, 4)`}, refs)
+}
+
+func TestWriteClipboard(t *testing.T) {
+
+	assert := assert.New(t)
+
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		return &vugu.BuildOut{Out: []*vugu.VGNode{{Type: vugu.ElementNode, Data: "div"}}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+
+	vugu.WriteClipboard(r.EventEnv(), "copied")
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	// only written once
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+
+	var texts []string
+	for _, b := range tr.Renders {
+		instructions, err := DecodeInstructions(b)
+		assert.NoError(err)
+		for _, in := range instructions {
+			if in.Name == "writeClipboard" {
+				texts = append(texts, in.Args[0].(string))
+			}
+		}
+	}
+	assert.Equal([]string{"copied"}, texts)
+}
+
+func TestBoolAttr(t *testing.T) {
+
+	assert := assert.New(t)
+
+	disabled := "false"
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "input", Attr: []vugu.VGAttribute{
+			{Key: "type", Val: "checkbox"},
+			{Key: "disabled", Val: disabled},
+			{Key: "checked", Val: "false"},
+		}}
+		n.AddAttrInterface("data-on", true)
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	for _, d := range []string{"false", "true"} {
+		disabled = d
+		assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	}
+
+	var attrs [][]string
+	var d Decoder // the second render refers to the names interned by the first
+	for _, b := range tr.Renders {
+		instructions, err := d.Decode(b)
+		assert.NoError(err)
+		var l []string
+		for _, in := range instructions {
+			switch in.Name {
+			case "setAttrStr", "setAttrBool", "setPropertyBool":
+				l = append(l, in.String())
+			}
+		}
+		attrs = append(attrs, l)
+	}
+	assert.Equal([][]string{
+		{`setAttrStr("type", "checkbox")`, `setAttrBool("disabled", 0)`, `setAttrBool("checked", 0)`, `setAttrBool("data-on", 1)`, `setPropertyBool("checked", 0)`},
+		{`setAttrStr("type", "checkbox")`, `setAttrBool("disabled", 1)`, `setAttrBool("checked", 0)`, `setAttrBool("data-on", 1)`, `setPropertyBool("checked", 0)`},
+	}, attrs)
+}
+
+func TestDelegateEvents(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var clicked []int
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		ul := &vugu.VGNode{Type: vugu.ElementNode, Data: "ul"}
+		ul.DOMEventHandlerSpecList = []vugu.DOMEventHandlerSpec{
+			{EventType: "click", Capture: true, Func: func(vugu.DOMEvent) {}},
+		}
+		for i := 0; i < 3; i++ {
+			i := i
+			li := &vugu.VGNode{Type: vugu.ElementNode, Data: "li"}
+			li.DOMEventHandlerSpecList = []vugu.DOMEventHandlerSpec{
+				{EventType: "click", Func: func(vugu.DOMEvent) { clicked = append(clicked, i) }},
+				{EventType: "mouseenter", Func: func(vugu.DOMEvent) {}},
+			}
+			ul.AppendChild(li)
+		}
+		modal := &vugu.VGNode{Type: vugu.ElementNode, Data: "div", Portal: "#modal"}
+		modal.DOMEventHandlerSpecList = []vugu.DOMEventHandlerSpec{
+			{EventType: "click", Func: func(vugu.DOMEvent) {}},
+		}
+		ul.AppendChild(modal)
+		return &vugu.BuildOut{Out: []*vugu.VGNode{ul}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	r.DelegateEvents = true
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+
+	// capturing listeners, events which do not bubble and portal content, which is outside the mount
+	// point, still get a listener on the element
+	instructions, err := DecodeInstructions(tr.Renders[0])
+	assert.NoError(err)
+	counts := map[string]int{}
+	var positionIDs []string
+	for _, in := range instructions {
+		switch in.Name {
+		case "setEventListener":
+			counts[in.Name+" "+in.Args[1].(string)]++
+		case "setDelegatedEventListener":
+			counts[in.Name+" "+in.Args[1].(string)]++
+			positionIDs = append(positionIDs, in.Args[0].(string))
+		}
+	}
+	assert.Equal(map[string]int{
+		"setEventListener click":          2,
+		"setEventListener mouseenter":     3,
+		"setDelegatedEventListener click": 3,
+	}, counts)
+
+	// events passed on by the delegated listener are handled as usual
+	assert.NoError(tr.SendEvent(map[string]interface{}{"position_id": positionIDs[1]}))
+	assert.Equal([]int{1}, clicked)
+}
+
+func TestEventRate(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var calls []string
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "input"}
+		n.DOMEventHandlerSpecList = []vugu.DOMEventHandlerSpec{
+			{EventType: "input", Func: func(vugu.DOMEvent) { calls = append(calls, "every") }},
+			{EventType: "input", Debounce: 300 * time.Millisecond, Func: func(vugu.DOMEvent) { calls = append(calls, "debounced") }},
+			{EventType: "scroll", Global: "window", Throttle: 100 * time.Millisecond, Func: func(vugu.DOMEvent) { calls = append(calls, "throttled") }},
+		}
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+
+	// the rate is set just before the listener it applies to, which is separate from the one without
+	instructions, err := DecodeInstructions(tr.Renders[0])
+	assert.NoError(err)
+	var seq []string
+	for _, in := range instructions {
+		switch in.Name {
+		case "setEventRate", "setEventListener", "setGlobalEventListener":
+			seq = append(seq, in.Name)
+		}
+	}
+	assert.Equal([]string{"setEventListener", "setEventRate", "setEventListener", "setEventRate", "setGlobalEventListener"}, seq)
+
+	event := func(eventType, global, rate string) {
+		assert.NoError(tr.SendEvent(map[string]interface{}{"event_type": eventType, "global_target": global, "rate": rate}))
+	}
+	event("input", "", "")
+	event("input", "", "d300")
+	event("scroll", "window", "t100")
+	assert.Equal([]string{"every", "debounced", "throttled"}, calls)
+}
+
+func TestListenerPolicy(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var specs []vugu.DOMEventHandlerSpec
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+		n.DOMEventHandlerSpecList = specs
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	var reported []error
+	r.OnError = func(err error) { reported = append(reported, err) }
+	r.DisableErrorOverlay = true
+	r.ListenerPolicy = DefaultListenerPolicy()
+	r.ListenerPolicy.Capture = []string{"focus"}
+
+	// the policy sets the options, except passive for a handler with the prevent modifier
+	specs = []vugu.DOMEventHandlerSpec{
+		{EventType: "touchmove", Func: func(e vugu.DOMEvent) { e.PreventDefault() }},
+		{EventType: "touchstart", Modifiers: vugu.DOMEventModPrevent, Func: func(vugu.DOMEvent) {}},
+		{EventType: "focus", Func: func(vugu.DOMEvent) {}},
+		{EventType: "click", Func: func(vugu.DOMEvent) {}},
+	}
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+	instructions, err := DecodeInstructions(tr.Renders[0])
+	assert.NoError(err)
+	var listeners []string
+	for _, in := range instructions {
+		if in.Name == "setEventListener" {
+			listeners = append(listeners, fmt.Sprintf("%v capture=%v passive=%v", in.Args[1], in.Args[2], in.Args[3]))
+		}
+	}
+	assert.Equal([]string{
+		"touchmove capture=0 passive=1",
+		"touchstart capture=0 passive=0",
+		"focus capture=1 passive=0",
+		"click capture=0 passive=0",
+	}, listeners)
+	assert.False(specs[0].Passive, "the built specs are not changed")
+
+	// calling PreventDefault from a passive listener is reported
+	assert.NoError(tr.SendEvent(map[string]interface{}{"event_type": "touchmove", "passive": true}))
+	if assert.Len(reported, 1) {
+		assert.Contains(reported[0].Error(), "@touchmove.prevent")
+	}
+
+	// the policy still applies once the element is skipped as unchanged
+	called := 0
+	skipRoot := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+		span := &vugu.VGNode{Type: vugu.ElementNode, Data: "span"}
+		span.DOMEventHandlerSpecList = []vugu.DOMEventHandlerSpec{{EventType: "scroll", Func: func(vugu.DOMEvent) { called++ }}}
+		n.AppendChild(span)
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	})
+	reported = nil
+	var d Decoder
+	for i := 0; i < 3; i++ {
+		assert.NoError(r.Render(buildEnv.RunBuild(skipRoot)))
+		instructions, err := d.Decode(tr.Renders[len(tr.Renders)-1])
+		assert.NoError(err)
+		skipped := false
+		for _, in := range instructions {
+			skipped = skipped || in.Name == "skipNode"
+		}
+		assert.Equal(i == 2, skipped, "render %d", i+1)
+	}
+	assert.NoError(tr.SendEvent(map[string]interface{}{"position_id": "0_1", "event_type": "scroll", "passive": true}))
+	assert.Equal(1, called)
+	assert.Empty(reported)
+
+	// the prevent modifier on a passive handler is an error
+	specs = []vugu.DOMEventHandlerSpec{
+		{EventType: "wheel", Passive: true, Modifiers: vugu.DOMEventModPrevent, Func: func(vugu.DOMEvent) {}},
+	}
+	err = r.Render(buildEnv.RunBuild(root))
+	if assert.Error(err) {
+		assert.Contains(err.Error(), `"wheel" handler on <div>`)
+	}
+}
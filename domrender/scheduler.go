@@ -0,0 +1,83 @@
+package domrender
+
+import (
+	"sync"
+)
+
+// DefaultScheduler is used by the JSRenderers made with a DirectTransport (see New), so all the renderers
+// a program has on its page take turns unless told otherwise.
+var DefaultScheduler = &Scheduler{}
+
+// Scheduler makes several JSRenderers on a page, e.g. separate apps in their own mount points, take turns
+// sending instructions to the browser, so one large app does not hold up the others.  A renderer keeps
+// its turn while it renders, flushing as often as it needs to, except that each time it flushes the turn
+// passes to the renderer waiting longest, if any, and it continues when it is its turn again.  The turn
+// is let go of while the instructions are sent, which may block for some transports, so one renderer
+// which cannot send does not hold up the others.  Renderers in different programs are not coordinated.
+type Scheduler struct {
+	mu      sync.Mutex
+	owner   *JSRenderer // whose turn it is, nil if nobody's
+	waiting []schedulerWaiter
+}
+
+type schedulerWaiter struct {
+	r  *JSRenderer
+	ch chan struct{}
+}
+
+// acquire waits until it is r's turn.
+func (s *Scheduler) acquire(r *JSRenderer) {
+
+	s.mu.Lock()
+	if s.owner == nil || s.owner == r {
+		s.owner = r
+		s.mu.Unlock()
+		return
+	}
+	ch := make(chan struct{})
+	s.waiting = append(s.waiting, schedulerWaiter{r: r, ch: ch})
+	s.mu.Unlock()
+
+	<-ch // next has made us the owner
+}
+
+// sending is called by r before it sends instructions, and gives its turn to the first waiting renderer.
+func (s *Scheduler) sending(r *JSRenderer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.owner == r {
+		s.next()
+	}
+}
+
+// sent is called by r after it sends instructions, it has its turn back unless another renderer took it.
+func (s *Scheduler) sent(r *JSRenderer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.owner == nil {
+		s.owner = r
+	}
+}
+
+// done ends r's turn at the end of a render.
+func (s *Scheduler) done(r *JSRenderer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.owner == r {
+		s.next()
+	}
+}
+
+// next gives the turn to the first waiting renderer.  s.mu must be held.
+func (s *Scheduler) next() {
+	s.owner = nil
+	if len(s.waiting) == 0 {
+		return
+	}
+	w := s.waiting[0]
+	copy(s.waiting, s.waiting[1:])
+	s.waiting[len(s.waiting)-1] = schedulerWaiter{}
+	s.waiting = s.waiting[:len(s.waiting)-1]
+	s.owner = w.r
+	close(w.ch)
+}
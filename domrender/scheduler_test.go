@@ -0,0 +1,148 @@
+package domrender
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+// logTransport logs each Render under a name, taking a while about it.
+type logTransport struct {
+	CaptureTransport
+	name    string
+	mu      *sync.Mutex
+	log     *[]string
+	flushed chan struct{}
+}
+
+func (t *logTransport) Render(buf []byte) error {
+	time.Sleep(2 * time.Millisecond)
+	t.mu.Lock()
+	*t.log = append(*t.log, t.name)
+	t.mu.Unlock()
+	select {
+	case t.flushed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func TestScheduler(t *testing.T) {
+
+	assert := assert.New(t)
+
+	sched := &Scheduler{}
+	var mu sync.Mutex
+	var log []string
+
+	newRenderer := func(name string, items int) (*JSRenderer, *vugu.BuildResults, *logTransport) {
+		tr := &logTransport{name: name, mu: &mu, log: &log, flushed: make(chan struct{}, 1)}
+		r, err := NewWithTransport("#"+name, tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Scheduler = sched
+		r.InstructionBufferSize = 16384 // so the big one needs several flushes
+		root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+			ul := &vugu.VGNode{Type: vugu.ElementNode, Data: "ul"}
+			for i := 0; i < items; i++ {
+				li := &vugu.VGNode{Type: vugu.ElementNode, Data: "li"}
+				li.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: strings.Repeat("x", 100)})
+				ul.AppendChild(li)
+			}
+			return &vugu.BuildOut{Out: []*vugu.VGNode{ul}}
+		})
+		buildEnv, err := vugu.NewBuildEnv(r.EventEnv())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r, buildEnv.RunBuild(root), tr
+	}
+
+	big, bigResults, bigTr := newRenderer("big", 2000)
+	small, smallResults, _ := newRenderer("small", 1)
+
+	done := make(chan error)
+	go func() { done <- big.Render(bigResults) }()
+	<-bigTr.flushed // big has started
+
+	assert.NoError(small.Render(smallResults))
+	assert.NoError(<-done)
+
+	// small did not have to wait for all of big
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.True(len(log) > 3, "expected several flushes from big, got %v", log) {
+		assert.Equal("big", log[len(log)-1], "small should have gone before big finished: %v", log)
+	}
+	assert.Contains(log, "small")
+
+	// and the turn is free again
+	assert.Nil(sched.owner)
+	assert.Len(sched.waiting, 0)
+}
+
+// blockTransport blocks in Render until unblocked, like a connection which is not being read from.
+type blockTransport struct {
+	CaptureTransport
+	sending chan struct{}
+	unblock chan struct{}
+}
+
+func (t *blockTransport) Render(buf []byte) error {
+	t.sending <- struct{}{}
+	<-t.unblock
+	return nil
+}
+
+func TestSchedulerBlockedTransport(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// only renderers with a DirectTransport share the DefaultScheduler
+	r, _, _ := newTestRenderer(t)
+	assert.Nil(r.Scheduler)
+
+	sched := &Scheduler{}
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		return &vugu.BuildOut{Out: []*vugu.VGNode{{Type: vugu.ElementNode, Data: "div"}}}
+	})
+	render := func(r *JSRenderer) error {
+		buildEnv, err := vugu.NewBuildEnv(r.EventEnv())
+		if err != nil {
+			return err
+		}
+		return r.Render(buildEnv.RunBuild(root))
+	}
+
+	blocked := &blockTransport{sending: make(chan struct{}), unblock: make(chan struct{})}
+	stuck, err := NewWithTransport("#stuck", blocked)
+	assert.NoError(err)
+	stuck.Scheduler = sched
+	stuckDone := make(chan error)
+	go func() { stuckDone <- render(stuck) }()
+	<-blocked.sending
+
+	// the other renderer does not wait for the stuck one to finish sending
+	other, tr, _ := newTestRenderer(t)
+	other.Scheduler = sched
+	otherDone := make(chan error)
+	go func() { otherDone <- render(other) }()
+	select {
+	case err := <-otherDone:
+		assert.NoError(err)
+		assert.Len(tr.Renders, 1)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the render waited for the blocked transport")
+	}
+
+	close(blocked.unblock)
+	assert.NoError(<-stuckDone)
+	assert.Nil(sched.owner)
+	assert.Len(sched.waiting, 0)
+}
@@ -0,0 +1,56 @@
+package domrender
+
+import (
+	"fmt"
+
+	"github.com/vugu/vugu"
+)
+
+// strictState is what JSRenderer.Strict needs to check its invariants.
+type strictState struct {
+	positions map[string]bool    // position IDs synced in the render in progress
+	prev      *vugu.BuildResults // the last rendered output
+	prevHash  uint64             // and its hash when it was rendered
+}
+
+// strictStart checks the output of the previous render has not been changed since, and starts
+// checking the render of br.
+func (r *JSRenderer) strictStart(br *vugu.BuildResults) {
+	s := &r.strict
+	if s.positions == nil {
+		s.positions = make(map[string]bool)
+	}
+	for k := range s.positions {
+		delete(s.positions, k)
+	}
+	prev := s.prev
+	s.prev = nil
+	if prev != nil {
+		prev.ForgetNodeHashes()
+		if prev.NodeHash(prev.Out.Out[0]) != s.prevHash {
+			panic(fmt.Errorf("domrender strict: the output of the previous render was modified after it was rendered, Build must return new VGNodes each time"))
+		}
+	}
+}
+
+// strictDone records the hash of br, which has been rendered.
+func (r *JSRenderer) strictDone(br *vugu.BuildResults) {
+	r.strict.prev = br
+	r.strict.prevHash = br.NodeHash(br.Out.Out[0])
+}
+
+// strictNode checks n is at a position not used already in this render, and that its event handlers are complete.
+func (r *JSRenderer) strictNode(n *vugu.VGNode, positionID []byte) {
+	if r.strict.positions[string(positionID)] {
+		panic(fmt.Errorf("domrender strict: position ID %q is used more than once (node %q)", positionID, n.Data))
+	}
+	r.strict.positions[string(positionID)] = true
+	for _, hs := range n.DOMEventHandlerSpecList {
+		if hs.EventType == "" {
+			panic(fmt.Errorf("domrender strict: event listener with no event type on <%s> at position %q", n.Data, positionID))
+		}
+		if hs.Func == nil {
+			panic(fmt.Errorf("domrender strict: no handler func for %q event on <%s> at position %q", hs.EventType, n.Data, positionID))
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package domrender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+func TestStrict(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// render returns the error reported for the render of root's output, if any
+	var reported error
+	newRenderer := func() (*JSRenderer, *vugu.BuildEnv) {
+		r, _, buildEnv := newTestRenderer(t)
+		r.Strict = true
+		r.DisableErrorOverlay = true
+		r.OnError = func(err error) { reported = err }
+		return r, buildEnv
+	}
+	render := func(r *JSRenderer, buildEnv *vugu.BuildEnv, root vugu.Builder) error {
+		reported = nil
+		assert.NoError(r.Render(buildEnv.RunBuild(root)))
+		return reported
+	}
+
+	var kept *vugu.VGNode
+	var handler func(vugu.DOMEvent)
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		if kept == nil {
+			kept = &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+			kept.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: "text"})
+		}
+		kept.DOMEventHandlerSpecList = []vugu.DOMEventHandlerSpec{{EventType: "click", Func: handler}}
+		return &vugu.BuildOut{Out: []*vugu.VGNode{kept}}
+	})
+
+	r, buildEnv := newRenderer()
+	handler = func(vugu.DOMEvent) {}
+	assert.NoError(render(r, buildEnv, root))
+
+	// modifying the output after it was rendered
+	kept.FirstChild.Data = "changed"
+	assert.Contains(render(r, buildEnv, root).Error(), "modified after it was rendered")
+
+	// no handler func
+	kept = nil
+	handler = nil
+	assert.Contains(render(r, buildEnv, root).Error(), `no handler func for "click" event on <div>`)
+
+	// rendering after release
+	r.Release()
+	assert.Panics(func() { r.Render(buildEnv.RunBuild(root)) })
+
+	// duplicate position IDs can only come from a broken renderer, but check them anyway
+	r, buildEnv = newRenderer()
+	n := &vugu.VGNode{Type: vugu.ElementNode, Data: "p"}
+	r.strictStart(buildEnv.RunBuild(root))
+	r.strictNode(n, []byte("0_1"))
+	assert.Panics(func() { r.strictNode(n, []byte("0_1")) })
+}
@@ -0,0 +1,354 @@
+package domrender
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vugu/vjson"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// Transport is how a JSRenderer communicates with the JS helper script which updates the DOM.
+// DirectTransport calls the script directly and is what New uses.  WorkerTransport is for programs
+// running in a Web Worker, it sends everything with postMessage to the page, which runs the script
+// on the program's behalf (see WorkerBridgeScript).  CaptureTransport is for tests.  Other channels
+// can be used by implementing Transport, see the liverender package for an example.
+type Transport interface {
+	// Init loads the helper script and arranges for the handlers to be called.
+	Init(script string, h TransportHandlers) error
+	// Render has the instructions in buf processed, buf ends with a zero byte.
+	// Render must not keep buf after returning.
+	Render(buf []byte) error
+	// Call calls the helper script function with the specified name, args must be strings, numbers or bools.
+	Call(name string, args ...interface{})
+}
+
+// TransportHandlers are the functions a Transport calls as things happen on the page.
+type TransportHandlers struct {
+	Event    func(data []byte)     // a DOM event occurred, data is the event payload and is only valid during the call
+	Callback func(args []js.Value) // a vg-js-create or vg-js-populate callback instruction was processed
+	Frame    func()                // the animation frame after a call to vuguRequestAnimationFrame occurred
+	Close    func()                // the page went away, e.g. a network connection was lost, EventWait returns false after this
+}
+
+// releaser is implemented by Transports which hold resources on the page for the renderer, see
+// JSRenderer.Release.
+type releaser interface {
+	release()
+}
+
+// renderTimer is implemented by Transports which know how long the helper script took to process
+// the last buffer passed to Render, for RenderStats.
+type renderTimer interface {
+	lastRenderTime() time.Duration
+}
+
+// DirectTransport calls the helper script on window, for programs running on the page's main thread.
+//
+// By default the helper script is run with eval, which a Content-Security-Policy without 'unsafe-eval'
+// does not allow.  Instead the page can load it as a static script, see HelperScriptHandler, before the
+// program starts, and set ExternalScript so it is an error if it has not.  Either way a helper script
+// already on the page is used as is, if it is the same version as the program's.
+//
+// Each DirectTransport has its own state in the helper script, so several renderers can share the page.
+type DirectTransport struct {
+	ExternalScript bool // the page loads the helper script, do not eval it
+
+	window         js.Value
+	instance       int      // our state in the helper script, see vuguCall
+	renderArray    js.Value // Uint8Array on the JS side that instructions are copied into
+	renderArrayLen int
+	eventBuffer    []byte        // event data is copied here from JS
+	renderTime     time.Duration // how long the helper script took to process the last buffer
+}
+
+// Init implements Transport.
+func (t *DirectTransport) Init(script string, h TransportHandlers) error {
+
+	t.window = js.Global().Get("window")
+	err := loadHelperScript(t.window, script, t.ExternalScript)
+	if err != nil {
+		return err
+	}
+	t.instance = t.window.Call("vuguNewInstance").Int()
+	t.renderArray = t.call("vuguGetRenderArray")
+	t.renderArrayLen = t.renderArray.Length()
+
+	t.call("vuguSetEventHandler", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		t.eventBuffer = copyEventData(t.eventBuffer, args[0])
+		h.Event(t.eventBuffer)
+		return nil
+	}))
+	t.call("vuguSetCallbackHandler", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		h.Callback(args)
+		return nil
+	}))
+	t.call("vuguSetAnimationFrameHandler", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		h.Frame()
+		return nil
+	}))
+
+	return nil
+}
+
+// LoadHelperScript makes sure the helper script is on the page, for using the functions it has other
+// than through a renderer, e.g. to define custom elements.  As with a DirectTransport it is evaluated
+// unless the page has loaded it already, see HelperScriptHandler.
+func LoadHelperScript() error {
+	return loadHelperScript(js.Global().Get("window"), jsHelperScript, false)
+}
+
+// loadHelperScript evaluates script in window unless the helper script is there already, or returns an
+// error if external is set, and checks the version of the one there.
+func loadHelperScript(window js.Value, script string, external bool) error {
+	if !window.Truthy() {
+		return errors.New("js environment not available")
+	}
+	if !window.Get("vuguRender").Truthy() {
+		if external {
+			return errors.New("helper script not loaded by the page, vuguRender is not defined")
+		}
+		window.Call("eval", script)
+	}
+	if v := window.Get("vuguHelperVersion"); v.Type() != js.TypeString || v.String() != jsHelperScriptVersion {
+		return fmt.Errorf("helper script on the page is version %s, program expects %s", v, jsHelperScriptVersion)
+	}
+	return nil
+}
+
+// Render implements Transport.
+func (t *DirectTransport) Render(buf []byte) error {
+	if len(buf) > t.renderArrayLen {
+		t.renderArray = t.call("vuguGetRenderArray", len(buf))
+		t.renderArrayLen = t.renderArray.Length()
+	}
+	js.CopyBytesToJS(t.renderArray, buf)
+	ret := t.call("vuguRender")
+	t.renderTime = 0
+	switch ret.Type() {
+	case js.TypeNumber:
+		t.renderTime = time.Duration(ret.Float() * float64(time.Millisecond))
+	case js.TypeObject:
+		return &ProtocolVersionError{Program: protocolVersion, Helper: ret.Get("protocolVersion").Int()}
+	}
+	return nil
+}
+
+func (t *DirectTransport) lastRenderTime() time.Duration {
+	return t.renderTime
+}
+
+func (t *DirectTransport) release() {
+	if t.instance != 0 {
+		t.window.Call("vuguFreeInstance", t.instance)
+		t.instance = 0
+	}
+}
+
+// Call implements Transport.
+func (t *DirectTransport) Call(name string, args ...interface{}) {
+	t.call(name, args...)
+}
+
+// call calls the helper script function with our state selected, so several renderers can share the page.
+func (t *DirectTransport) call(name string, args ...interface{}) js.Value {
+	return t.window.Call("vuguCall", append([]interface{}{t.instance, name}, args...)...)
+}
+
+// WorkerTransport is used to run a program in a Web Worker, so heavy computation in Go does not make the
+// page unresponsive.  Instructions are sent to the page with postMessage (transferring the buffer rather
+// than copying it), and events and animation frames are sent back the same way.  The page must pass the
+// Worker to vuguWorkerBridge, from WorkerBridgeScript, before the program starts:
+//
+//	<script>/* WorkerBridgeScript */</script>
+//	<script>vuguWorkerBridge(new Worker("worker.js"));</script>
+//
+// where worker.js loads wasm_exec.js and runs the program as usual, and the program renders with:
+//
+//	renderer, err := domrender.NewWithTransport("#vugu_mount_point", &domrender.WorkerTransport{})
+//
+// Everything that needs the page's JS objects from Go does not work in a worker: vg-js-create and vg-js-populate
+// (a warning is logged on the page), the JSEvent, JSEventTarget, JSEventCurrentTarget, PreventDefault and
+// StopPropagation methods of DOMEvent (use the event modifiers instead, e.g. @click.prevent), and DispatchCustomEvent.
+// Rendering is asynchronous, the DOM is updated shortly after Render returns.
+type WorkerTransport struct {
+	global      js.Value
+	eventBuffer []byte
+}
+
+// Init implements Transport.
+func (t *WorkerTransport) Init(script string, h TransportHandlers) error {
+
+	t.global = js.Global()
+
+	t.global.Call("addEventListener", "message", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		data := args[0].Get("data")
+		if !data.Truthy() {
+			return nil
+		}
+		switch data.Get("vugu").String() {
+		case "event":
+			t.eventBuffer = copyEventData(t.eventBuffer, data.Get("buf"))
+			h.Event(t.eventBuffer)
+		case "frame":
+			h.Frame()
+		}
+		return nil
+	}))
+
+	msg := js.Global().Get("Object").New()
+	msg.Set("vugu", "init")
+	msg.Set("script", script)
+	msg.Set("version", jsHelperScriptVersion)
+	t.global.Call("postMessage", msg)
+
+	return nil
+}
+
+// Render implements Transport.
+func (t *WorkerTransport) Render(buf []byte) error {
+	arr := js.Global().Get("Uint8Array").New(len(buf))
+	js.CopyBytesToJS(arr, buf)
+	msg := js.Global().Get("Object").New()
+	msg.Set("vugu", "render")
+	msg.Set("buf", arr)
+	transfer := js.Global().Get("Array").New()
+	transfer.Call("push", arr.Get("buffer"))
+	t.global.Call("postMessage", msg, transfer)
+	return nil
+}
+
+// Call implements Transport.
+func (t *WorkerTransport) Call(name string, args ...interface{}) {
+	jsArgs := js.Global().Get("Array").New()
+	for _, a := range args {
+		jsArgs.Call("push", a)
+	}
+	msg := js.Global().Get("Object").New()
+	msg.Set("vugu", "call")
+	msg.Set("name", name)
+	msg.Set("args", jsArgs)
+	t.global.Call("postMessage", msg)
+}
+
+// CaptureTransport records what a JSRenderer sends instead of sending it anywhere, for testing
+// components and the renderer outside of the browser.  Events can be sent to the renderer with
+// SendEvent, or Handlers.Event for raw payloads.  It is not safe for concurrent use.
+type CaptureTransport struct {
+	Script   string            // the helper script passed to Init
+	Handlers TransportHandlers // the handlers passed to Init
+	Renders  [][]byte          // a copy of each buffer passed to Render
+	Calls    []CapturedCall    // each call to Call
+}
+
+// CapturedCall is a call to CaptureTransport.Call.
+type CapturedCall struct {
+	Name string
+	Args []interface{}
+}
+
+// Init implements Transport.
+func (t *CaptureTransport) Init(script string, h TransportHandlers) error {
+	t.Script, t.Handlers = script, h
+	return nil
+}
+
+// Render implements Transport.
+func (t *CaptureTransport) Render(buf []byte) error {
+	t.Renders = append(t.Renders, append([]byte(nil), buf...))
+	return nil
+}
+
+// Call implements Transport.
+func (t *CaptureTransport) Call(name string, args ...interface{}) {
+	t.Calls = append(t.Calls, CapturedCall{Name: name, Args: args})
+}
+
+// SendEvent sends an event to the renderer as the helper script would, with the payload fields given
+// (position_id, event_type, capture, passive, modifiers, global_target, rate and event_summary) and
+// the rest as for a click on the element at position "0" with a listener of no options.
+func (t *CaptureTransport) SendEvent(fields map[string]interface{}) error {
+	payload := map[string]interface{}{
+		"v":             eventPayloadVersion,
+		"position_id":   "0",
+		"event_type":    "click",
+		"capture":       false,
+		"passive":       false,
+		"modifiers":     0,
+		"global_target": "",
+		"rate":          "",
+		"event_summary": map[string]interface{}{},
+	}
+	for k, v := range fields {
+		payload[k] = v
+	}
+	b, err := vjson.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshaling event payload: %w", err)
+	}
+	data := make([]byte, 4, 4+len(b))
+	binary.BigEndian.PutUint32(data, uint32(len(b)))
+	t.Handlers.Event(append(data, b...))
+	return nil
+}
+
+// copyEventData copies the Uint8Array data into buf, growing it if needed, and returns the result.
+func copyEventData(buf []byte, data js.Value) []byte {
+	n := data.Length()
+	if cap(buf) < n {
+		buf = make([]byte, n)
+	}
+	buf = buf[:n]
+	js.CopyBytesToGo(buf, data)
+	return buf
+}
+
+// WorkerBridgeScript defines vuguWorkerBridge(worker), which runs the helper script on the page for a
+// program using WorkerTransport in the specified Worker.  Include it in the page, e.g. in a script tag.
+// If the page has loaded the helper script already (see HelperScriptHandler) it is not run with eval.
+const WorkerBridgeScript = `function vuguWorkerBridge(worker) {
+	var warned = false;
+	worker.addEventListener("message", function (e) {
+		var m = e.data;
+		if (!m || !m.vugu) {
+			return;
+		}
+		switch (m.vugu) {
+		case "init":
+			if (!window.vuguRender) {
+				(0, eval)(m.script);
+			} else if (window.vuguHelperVersion !== m.version) {
+				console.error("vugu: helper script on the page is version " + window.vuguHelperVersion + ", program expects " + m.version);
+			}
+			window.vuguSetEventHandler(function (buf) {
+				// only the payload, the buffer is reused by the helper script
+				var n = new DataView(buf.buffer, buf.byteOffset, buf.byteLength).getUint32(0) + 4;
+				var b = buf.slice(0, n);
+				worker.postMessage({vugu: "event", buf: b}, [b.buffer]);
+			});
+			window.vuguSetCallbackHandler(function () {
+				if (!warned) {
+					warned = true;
+					console.warn("vugu: vg-js-create and vg-js-populate are not supported when rendering from a worker");
+				}
+			});
+			window.vuguSetAnimationFrameHandler(function () {
+				worker.postMessage({vugu: "frame"});
+			});
+			break;
+		case "render":
+			window.vuguGetRenderArray(m.buf.length).set(m.buf);
+			window.vuguRender();
+			break;
+		case "call":
+			if (/^vugu[A-Z]/.test(m.name) && typeof window[m.name] === "function") {
+				window[m.name].apply(window, m.args);
+			}
+			break;
+		}
+	});
+}
+`
@@ -0,0 +1,44 @@
+package domrender
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+func TestCaptureTransport(t *testing.T) {
+
+	assert := assert.New(t)
+
+	clicks := 0
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "button"}
+		n.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: "click me"})
+		n.DOMEventHandlerSpecList = []vugu.DOMEventHandlerSpec{{EventType: "click", Func: func(vugu.DOMEvent) { clicks++ }}}
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+	})
+
+	r, tr, buildEnv := newTestRenderer(t)
+	assert.Equal(jsHelperScript, tr.Script)
+
+	assert.NoError(r.Render(buildEnv.RunBuild(root)))
+
+	if assert.Len(tr.Renders, 1) {
+		b := tr.Renders[0]
+		assert.True(bytes.Contains(b, []byte("#app")))
+		assert.True(bytes.Contains(b, []byte("click me")))
+		assert.Equal(byte(0), b[len(b)-1])
+	}
+
+	assert.NoError(tr.SendEvent(nil))
+	assert.Equal(1, clicks)
+
+	// bad data is reported rather than panicing, with the error overlay shown
+	tr.Handlers.Event([]byte{0, 0, 1, 0, '{'})
+	if assert.Len(tr.Calls, 1) {
+		assert.Equal("vuguErrorOverlay", tr.Calls[0].Name)
+	}
+}
@@ -0,0 +1,63 @@
+package domrender
+
+import (
+	"strings"
+
+	"github.com/vugu/vugu"
+)
+
+// Triggers are the vg-focus and vg-scroll-into-view conditions of an element.  They act only on the
+// render in which they become true, so e.g. an element with vg-focus="c.Editing" is focused once when
+// editing starts instead of taking focus back on every render.
+const (
+	triggerFocus uint8 = 1 << iota
+	triggerScrollIntoView
+)
+
+// nodeTriggers returns the triggers which are true for n.
+func nodeTriggers(n *vugu.VGNode) (t uint8) {
+	if n.Focus {
+		t |= triggerFocus
+	}
+	if n.ScrollIntoView {
+		t |= triggerScrollIntoView
+	}
+	return t
+}
+
+// writeTriggers writes the instructions for the triggers of n which were not true at positionID on the
+// last render, and records them for the next.
+func (r *JSRenderer) writeTriggers(state *jsRenderState, n *vugu.VGNode, positionID []byte) error {
+
+	t := nodeTriggers(n)
+	if t == 0 {
+		delete(state.triggerMap, string(positionID))
+		return nil
+	}
+	state.triggerMap[string(positionID)] = t
+
+	t &^= state.prevTriggerMap[string(positionID)]
+	if t&triggerFocus != 0 {
+		err := r.instructionList.writeFocus()
+		if err != nil {
+			return err
+		}
+	}
+	if t&triggerScrollIntoView != 0 {
+		err := r.instructionList.writeScrollIntoView()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keepTriggers carries the triggers at and under positionID over from the last render, for a skipped
+// subtree which is the same as it was.
+func (r *JSRenderer) keepTriggers(state *jsRenderState, positionID []byte) {
+	for k, v := range state.prevTriggerMap {
+		if strings.HasPrefix(k, string(positionID)) {
+			state.triggerMap[k] = v
+		}
+	}
+}
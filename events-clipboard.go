@@ -0,0 +1,150 @@
+package vugu
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/vugu/vugu/js"
+)
+
+// ClipboardItem describes an item on the clipboard of a paste event (copy and cut events
+// have the same information, usually empty).  Several items with different types are common,
+// e.g. "text/plain" and "text/html" for copied rich text, or a "file" item for a copied image.
+type ClipboardItem struct {
+	Kind string // "string" or "file"
+	Type string // MIME type, e.g. "text/html" or "image/png"
+	Name string // file name, for files
+	Size int64  // size in bytes, for files
+}
+
+// ClipboardItems returns the items on the clipboard from the event summary of a paste event,
+// or nil if it has none.
+func ClipboardItems(e DOMEvent) []ClipboardItem {
+	il, ok := e.Prop("clipboard", "items").([]interface{})
+	if !ok {
+		return nil
+	}
+	ret := make([]ClipboardItem, 0, len(il))
+	for _, i := range il {
+		m, _ := i.(map[string]interface{})
+		var item ClipboardItem
+		item.Kind, _ = m["kind"].(string)
+		item.Type, _ = m["type"].(string)
+		item.Name, _ = m["name"].(string)
+		size, _ := m["size"].(float64)
+		item.Size = int64(size)
+		ret = append(ret, item)
+	}
+	return ret
+}
+
+// ClipboardText returns the plain text on the clipboard from the event summary of a paste event, or
+// an empty string if there is none.
+func ClipboardText(e DOMEvent) string {
+	return e.PropString("clipboard", "text")
+}
+
+// WriteClipboard copies text to the clipboard.  It is done by the renderer at the end of the next render,
+// so call it from an event handler, e.g. for the click of a "Copy" button, as browsers only allow writing to
+// the clipboard shortly after a user action.  ee is the renderer's EventEnv, e.g. event.EventEnv().  If it is
+// called more than once before the render the last text is copied.
+func WriteClipboard(ee EventEnv, text string) {
+	impl, ok := ee.(*EventEnvImpl)
+	if !ok {
+		return
+	}
+	impl.clipboardMu.Lock()
+	impl.clipboard = &text
+	impl.clipboardMu.Unlock()
+}
+
+// TakeClipboardWrite returns the text passed to WriteClipboard since it was last called, for use by renderers.
+func (ee *EventEnvImpl) TakeClipboardWrite() (string, bool) {
+	ee.clipboardMu.Lock()
+	defer ee.clipboardMu.Unlock()
+	if ee.clipboard == nil {
+		return "", false
+	}
+	text := *ee.clipboard
+	ee.clipboard = nil
+	return text, true
+}
+
+// ReadClipboardItem starts reading the contents of the clipboard item at index i (as returned by ClipboardItems)
+// of the paste event e, e.g. the bytes of a pasted image to upload or the HTML of pasted rich text to sanitize.
+// It must be called from the event handler, as the browser only allows access to the clipboard during the event,
+// but the contents are read after the handler returns, so call Wait from a goroutine:
+//
+//	func (c *Editor) HandlePaste(event vugu.DOMEvent) { // with @paste.prevent
+//		for i, item := range vugu.ClipboardItems(event) {
+//			if item.Type == "text/html" {
+//				content, ee := vugu.ReadClipboardItem(event, i), event.EventEnv()
+//				go func() {
+//					b, err := content.Wait()
+//					ee.Lock()
+//					defer ee.UnlockRender()
+//					...
+//				}()
+//			}
+//		}
+//	}
+func ReadClipboardItem(e DOMEvent, i int) *ClipboardContent {
+
+	c := &ClipboardContent{done: make(chan struct{})}
+
+	window := js.Global().Get("window")
+	if !window.Truthy() {
+		c.finish(nil, errors.New("clipboard not available in this environment"))
+		return c
+	}
+	p := window.Call("vuguActiveEventClipboardItem", i)
+	if !p.Truthy() {
+		c.finish(nil, errors.New("no clipboard item at this index in the active event"))
+		return c
+	}
+
+	var then, catch js.Func
+	then = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		b := make([]byte, args[0].Length())
+		js.CopyBytesToGo(b, args[0])
+		c.finish(b, nil)
+		then.Release()
+		catch.Release()
+		return nil
+	})
+	catch = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		msg := "reading clipboard item failed"
+		if m := args[0].Get("message"); m.Type() == js.TypeString {
+			msg += ": " + m.String()
+		}
+		c.finish(nil, errors.New(msg))
+		then.Release()
+		catch.Release()
+		return nil
+	})
+	p.Call("then", then, catch)
+
+	return c
+}
+
+// ClipboardContent is the contents of a clipboard item being read, see ReadClipboardItem.
+type ClipboardContent struct {
+	once sync.Once
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+func (c *ClipboardContent) finish(data []byte, err error) {
+	c.once.Do(func() {
+		c.data, c.err = data, err
+		close(c.done)
+	})
+}
+
+// Wait blocks until the contents have been read and returns them, strings are UTF-8.
+// Do not call it from the event handler itself, the read cannot complete until the handler returns.
+func (c *ClipboardContent) Wait() ([]byte, error) {
+	<-c.done
+	return c.data, c.err
+}
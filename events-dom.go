@@ -2,6 +2,7 @@ package vugu
 
 import (
 	"sync"
+	"time"
 
 	"github.com/vugu/vugu/js"
 )
@@ -190,6 +191,306 @@ type DOMEventHandlerSpec struct {
 	Func      func(DOMEvent)
 	Capture   bool
 	Passive   bool
+	Modifiers DOMEventModifiers // checked in the browser before Func is called
+	Global    string            // "window" or "document" to listen on that instead of the element, the listener is still removed along with the element
+
+	// Debounce and Throttle, if set, limit how often the browser passes events on to Func, e.g. with
+	// @input.debounce-300ms or @scroll.throttle-100ms, so fast streams of events do not each make a call
+	// into Go and a render.  Debounce passes on the last event once none have come for that long, Throttle
+	// passes on at most one event (the last) that often.  Modifiers are still applied to every event, but
+	// calling PreventDefault or StopPropagation from Func has no effect on events held back.  At most
+	// one of them should be set.
+	Debounce time.Duration
+	Throttle time.Duration
+}
+
+// DOMEventModifiers is a set of conditions and actions which are evaluated by the renderer
+// before an event is passed on to a handler, so trivial filtering does not require a call into
+// Go.  They correspond to the modifiers in the @event.modifier syntax, e.g. @keydown.enter or @submit.prevent.
+// If any key modifiers are set the event's key must match one of them, likewise for mouse buttons.
+// Modifiers can also request extra data which is not part of the event itself, this is added to the
+// event summary under the modifier name, e.g. event.Prop("dataset", "id") with @click.dataset.
+type DOMEventModifiers uint32
+
+// Available DOMEventModifiers.
+const (
+	DOMEventModPrevent   DOMEventModifiers = 1 << iota // call preventDefault()
+	DOMEventModStop                                    // call stopPropagation()
+	DOMEventModSelf                                    // only if the event target is the element itself
+	DOMEventModCtrl                                    // only if the ctrl key is pressed
+	DOMEventModShift                                   // only if the shift key is pressed
+	DOMEventModAlt                                     // only if the alt key is pressed
+	DOMEventModMeta                                    // only if the meta key is pressed
+	DOMEventModEnter                                   // only if key is Enter
+	DOMEventModTab                                     // only if key is Tab
+	DOMEventModEsc                                     // only if key is Escape
+	DOMEventModSpace                                   // only if key is a space
+	DOMEventModDelete                                  // only if key is Delete or Backspace
+	DOMEventModUp                                      // only if key is ArrowUp
+	DOMEventModDown                                    // only if key is ArrowDown
+	DOMEventModLeft                                    // only if key is ArrowLeft, or for mouse events the left button
+	DOMEventModRight                                   // only if key is ArrowRight, or for mouse events the right button
+	DOMEventModMiddle                                  // only if the middle mouse button
+	DOMEventModSelection                               // include "selection" with the text, start, end and collapsed state of the current selection
+	DOMEventModDataset                                 // include "dataset" with the data-* attributes of the element the listener is on
+	DOMEventModForm                                    // include "form" with the fields of the enclosing form, see FormValues
+	DOMEventModAction                                  // include "action" from the nearest data-vg-action element within the element the listener is on, see EventAction
+)
+
+// FormValues returns the fields of the form included in the event summary by the "form"
+// modifier (e.g. @submit.prevent.form) as a map of field name to values, in the same way the browser
+// would submit them: unchecked checkboxes are omitted, and multi-selects and fields sharing a
+// name have one value for each.  File inputs give the file name.  Returns nil if there is no form data.
+func FormValues(e DOMEvent) map[string][]string {
+	fm, ok := e.Prop("form").(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	ret := make(map[string][]string, len(fm))
+	for k, v := range fm {
+		vl, _ := v.([]interface{})
+		sl := make([]string, 0, len(vl))
+		for _, vv := range vl {
+			s, _ := vv.(string)
+			sl = append(sl, s)
+		}
+		ret[k] = sl
+	}
+	return ret
+}
+
+// EventAction returns the data-vg-action and data-vg-value attributes of the element included in the
+// event summary by the "action" modifier (e.g. @click.action), which is the nearest one to the event target
+// with a data-vg-action attribute, up to and including the element the listener is on.  This lets a single
+// listener handle events from markup which is not built by Vugu, such as HTML rendered by a server.
+// Returns an empty name if there is no such element.
+func EventAction(e DOMEvent) (name, value string) {
+	return e.PropString("action", "name"), e.PropString("action", "value")
+}
+
+// IsComposing returns true for input and keyboard events which are part of an IME composition, e.g. while
+// typing Chinese or Japanese, when the text is not final yet.  The renderer does not set the value of an
+// element while it is being composed, as that would cancel the composition, so binding the value as usual
+// works.  But a handler which changes the text, e.g. to filter or reformat it, should leave it alone until
+// the composition ends:
+//
+//	<input :value="c.Code" @input="c.CodeInput(event)" @compositionend="c.CodeInput(event)">
+//
+// with
+//
+//	func (c *Form) CodeInput(event vugu.DOMEvent) {
+//		if !vugu.IsComposing(event) {
+//			c.Code = strings.ToUpper(event.PropString("target", "value"))
+//		}
+//	}
+func IsComposing(e DOMEvent) bool {
+	return e.PropBool("isComposing")
+}
+
+// CompositionData returns the text being composed of a compositionstart, compositionupdate or
+// compositionend event.
+func CompositionData(e DOMEvent) string {
+	return e.PropString("data")
+}
+
+// EventSelection returns the selection of the text input or textarea of an event, included in the event
+// summary by the "selection" modifier (e.g. @keyup.selection), as offsets into its value.  The caret is
+// where start and end are the same.  ok is false if there is no selection in the summary.
+func EventSelection(e DOMEvent) (start, end int, ok bool) {
+	if _, ok := e.Prop("selection", "start").(float64); !ok {
+		return 0, 0, false
+	}
+	return int(e.PropFloat64("selection", "start")), int(e.PropFloat64("selection", "end")), true
+}
+
+// EventFile describes a file selected with a file input or dropped on an element, see EventFiles.
+type EventFile struct {
+	Name         string
+	Type         string // MIME type, empty if the browser cannot tell
+	Size         int64  // size in bytes
+	LastModified time.Time
+}
+
+// EventFiles returns the files in the event summary: those selected with the file input of
+// a change or input event (e.g. <input type="file" @change="c.Selected(event)">), or those dropped
+// by a drop event.  Package vgfile reads their contents.  Returns nil if there are none.
+func EventFiles(e DOMEvent) []EventFile {
+	fl, ok := e.Prop("target", "files").([]interface{})
+	if !ok {
+		fl, ok = e.Prop("files").([]interface{})
+	}
+	if !ok || len(fl) == 0 {
+		return nil
+	}
+	ret := make([]EventFile, 0, len(fl))
+	for _, f := range fl {
+		m, _ := f.(map[string]interface{})
+		var ef EventFile
+		ef.Name, _ = m["name"].(string)
+		ef.Type, _ = m["type"].(string)
+		size, _ := m["size"].(float64)
+		ef.Size = int64(size)
+		if ms, ok := m["lastModified"].(float64); ok {
+			ef.LastModified = time.Unix(0, int64(ms)*int64(time.Millisecond))
+		}
+		ret = append(ret, ef)
+	}
+	return ret
+}
+
+// TouchPoint is a point of contact with a touch surface, see EventTouches.  Coordinates are in CSS pixels.
+type TouchPoint struct {
+	ID               int // the same for the same finger from touchstart to touchend
+	ClientX, ClientY float64
+	PageX, PageY     float64
+	ScreenX, ScreenY float64
+	RadiusX, RadiusY float64 // of the ellipse of the contact area, zero if not supported
+	RotationAngle    float64 // of the ellipse, in degrees
+	Force            float64 // from 0 to 1, zero if not supported
+}
+
+// TouchInfo is the touch points of a touch event, see EventTouches.
+type TouchInfo struct {
+	Touches        []TouchPoint // all the points now touching the surface
+	ChangedTouches []TouchPoint // those which changed with this event, e.g. for touchend the ones lifted
+	TargetTouches  []TouchPoint // those which started on the event target and are still touching
+}
+
+// EventTouches returns the touch points of a touchstart, touchmove, touchend or touchcancel event,
+// e.g. to tell a pinch from a swipe.  The lists are empty for other events.
+func EventTouches(e DOMEvent) TouchInfo {
+	return TouchInfo{
+		Touches:        touchPoints(e.Prop("touches")),
+		ChangedTouches: touchPoints(e.Prop("changedTouches")),
+		TargetTouches:  touchPoints(e.Prop("targetTouches")),
+	}
+}
+
+func touchPoints(v interface{}) []TouchPoint {
+	tl, _ := v.([]interface{})
+	if len(tl) == 0 {
+		return nil
+	}
+	ret := make([]TouchPoint, 0, len(tl))
+	for _, t := range tl {
+		m, _ := t.(map[string]interface{})
+		f := func(k string) float64 { v, _ := m[k].(float64); return v }
+		ret = append(ret, TouchPoint{
+			ID:            int(f("identifier")),
+			ClientX:       f("clientX"),
+			ClientY:       f("clientY"),
+			PageX:         f("pageX"),
+			PageY:         f("pageY"),
+			ScreenX:       f("screenX"),
+			ScreenY:       f("screenY"),
+			RadiusX:       f("radiusX"),
+			RadiusY:       f("radiusY"),
+			RotationAngle: f("rotationAngle"),
+			Force:         f("force"),
+		})
+	}
+	return ret
+}
+
+// PointerInfo describes the pointer of a pointer event, see EventPointer.  Coordinates are in CSS pixels.
+type PointerInfo struct {
+	ID                 int    // the same for the same pointer while it is down, e.g. one for each finger
+	Type               string // "mouse", "pen" or "touch", empty if the event is not a pointer event
+	IsPrimary          bool   // the first finger down, or the mouse or pen
+	Buttons            int    // bitmask of the buttons pressed, 1 for the primary button or contact with the surface
+	ClientX, ClientY   float64
+	OffsetX, OffsetY   float64 // relative to the event target
+	Width, Height      float64 // of the contact area, 1 for a mouse
+	Pressure           float64 // from 0 to 1, 0.5 for a pressed mouse button
+	TangentialPressure float64 // barrel pressure of a pen, from -1 to 1
+	TiltX, TiltY       float64 // angles of a pen from the surface, in degrees
+	Twist              float64 // rotation of a pen around its axis, in degrees
+
+	// Coalesced is the positions the browser coalesced into this event, oldest first, ending with
+	// the event's own.  For a pointermove there can be several since the last event was delivered.
+	Coalesced []PointerPoint
+}
+
+// PointerPoint is the position of a pointer at one of the moves coalesced into a pointermove event.
+type PointerPoint struct {
+	ClientX, ClientY float64
+	OffsetX, OffsetY float64
+	Pressure         float64
+	TimeStamp        float64 // in milliseconds, as event.timeStamp
+}
+
+// EventPointer returns the pointer of a pointerdown, pointermove, pointerup or other pointer event, e.g.
+// for a drawing surface:
+//
+//	<canvas @pointermove="c.Draw(event)"></canvas>
+//
+// with
+//
+//	func (c *Sketch) Draw(event vugu.DOMEvent) {
+//		p := vugu.EventPointer(event)
+//		for _, pt := range p.Coalesced { // every point the pen passed through, not only the last one
+//			c.lineTo(pt.OffsetX, pt.OffsetY, pt.Pressure)
+//		}
+//	}
+func EventPointer(e DOMEvent) PointerInfo {
+	ret := PointerInfo{
+		ID:                 int(e.PropFloat64("pointerId")),
+		Type:               e.PropString("pointerType"),
+		IsPrimary:          e.PropBool("isPrimary"),
+		Buttons:            int(e.PropFloat64("buttons")),
+		ClientX:            e.PropFloat64("clientX"),
+		ClientY:            e.PropFloat64("clientY"),
+		OffsetX:            e.PropFloat64("offsetX"),
+		OffsetY:            e.PropFloat64("offsetY"),
+		Width:              e.PropFloat64("width"),
+		Height:             e.PropFloat64("height"),
+		Pressure:           e.PropFloat64("pressure"),
+		TangentialPressure: e.PropFloat64("tangentialPressure"),
+		TiltX:              e.PropFloat64("tiltX"),
+		TiltY:              e.PropFloat64("tiltY"),
+		Twist:              e.PropFloat64("twist"),
+	}
+	cl, _ := e.Prop("coalesced").([]interface{})
+	if len(cl) == 0 {
+		ret.Coalesced = []PointerPoint{{ClientX: ret.ClientX, ClientY: ret.ClientY, OffsetX: ret.OffsetX, OffsetY: ret.OffsetY,
+			Pressure: ret.Pressure, TimeStamp: e.PropFloat64("timeStamp")}}
+		return ret
+	}
+	for _, c := range cl {
+		m, _ := c.(map[string]interface{})
+		f := func(k string) float64 { v, _ := m[k].(float64); return v }
+		ret.Coalesced = append(ret.Coalesced, PointerPoint{
+			ClientX:   f("clientX"),
+			ClientY:   f("clientY"),
+			OffsetX:   f("offsetX"),
+			OffsetY:   f("offsetY"),
+			Pressure:  f("pressure"),
+			TimeStamp: f("timeStamp"),
+		})
+	}
+	return ret
+}
+
+// VisibleEventType is the event type of the vg-visible directive, which is the same as @vgvisible.
+// The event is sent when an element starts observing, and each time it enters or leaves the viewport
+// after that, as seen by an IntersectionObserver.  See Visibility.
+const VisibleEventType = "vgvisible"
+
+// Visibility returns whether the element of a vg-visible event is in the viewport, and the fraction
+// of it which is, from 0 to 1.
+func Visibility(e DOMEvent) (visible bool, ratio float64) {
+	return e.PropBool("detail", "visible"), e.PropFloat64("detail", "ratio")
+}
+
+// ResizeEventType is the event type of the vg-resize directive, which is the same as @vgresize.
+// The event is sent when an element starts observing, and each time its size changes after that,
+// as seen by a ResizeObserver.  See ElementSize.
+const ResizeEventType = "vgresize"
+
+// ElementSize returns the width and height in CSS pixels of the content box of the element of a
+// vg-resize event.
+func ElementSize(e DOMEvent) (width, height float64) {
+	return e.PropFloat64("detail", "width"), e.PropFloat64("detail", "height")
 }
 
 // // DOMEventHandler is created in BuildVDOM to represent a method call that is performed to handle an event.
@@ -256,6 +557,15 @@ func NewEventEnvImpl(rwmu *sync.RWMutex, requestRenderCH chan bool) *EventEnvImp
 type EventEnvImpl struct {
 	rwmu            *sync.RWMutex
 	requestRenderCH chan bool
+
+	clipboardMu sync.Mutex
+	clipboard   *string // text passed to WriteClipboard, until the renderer takes it
+
+	focusMu  sync.Mutex
+	focusRef *string           // ref passed to Focus, until the renderer takes it
+	selRef   *selectionRequest // passed to SetSelection, until the renderer takes it
+
+	quality int32 // see RenderQuality, accessed atomically
 }
 
 // Lock will acquire write lock
@@ -274,11 +584,17 @@ func (ee *EventEnvImpl) UnlockOnly() {
 	ee.rwmu.Unlock()
 }
 
-// UnlockRender will release write lock and request re-render
+// UnlockRender will release write lock and request re-render, or if a Batch is in progress when it ends
 func (ee *EventEnvImpl) UnlockRender() {
 	// if ee.rwmu != nil {
 	ee.rwmu.Unlock()
 	// }
+	if !holdRender(ee) {
+		ee.requestRender()
+	}
+}
+
+func (ee *EventEnvImpl) requestRender() {
 	if ee.requestRenderCH != nil {
 		// send non-blocking
 		select {
@@ -0,0 +1,226 @@
+package vugu
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormValues(t *testing.T) {
+
+	assert := assert.New(t)
+
+	e := NewDOMEvent(nil, map[string]interface{}{
+		"type": "submit",
+		"form": map[string]interface{}{
+			"name":   []interface{}{"Joe"},
+			"colors": []interface{}{"red", "blue"},
+		},
+	})
+	assert.Equal(map[string][]string{"name": {"Joe"}, "colors": {"red", "blue"}}, FormValues(e))
+
+	e = NewDOMEvent(nil, map[string]interface{}{"type": "submit"})
+	assert.Nil(FormValues(e))
+}
+
+func TestVisibility(t *testing.T) {
+
+	assert := assert.New(t)
+
+	e := NewDOMEvent(nil, map[string]interface{}{
+		"type":   VisibleEventType,
+		"detail": map[string]interface{}{"visible": true, "ratio": 0.5},
+	})
+	visible, ratio := Visibility(e)
+	assert.True(visible)
+	assert.Equal(0.5, ratio)
+}
+
+func TestEventFiles(t *testing.T) {
+
+	assert := assert.New(t)
+
+	e := NewDOMEvent(nil, map[string]interface{}{
+		"type": "change",
+		"target": map[string]interface{}{
+			"files": []interface{}{
+				map[string]interface{}{"name": "a.png", "type": "image/png", "size": float64(1234), "lastModified": float64(1600000000000)},
+			},
+		},
+	})
+	assert.Equal([]EventFile{
+		{Name: "a.png", Type: "image/png", Size: 1234, LastModified: time.Unix(1600000000, 0)},
+	}, EventFiles(e))
+
+	e = NewDOMEvent(nil, map[string]interface{}{
+		"type":  "drop",
+		"files": []interface{}{map[string]interface{}{"name": "b.txt", "size": float64(3)}},
+	})
+	assert.Equal([]EventFile{{Name: "b.txt", Size: 3}}, EventFiles(e))
+
+	e = NewDOMEvent(nil, map[string]interface{}{"type": "change", "target": map[string]interface{}{"files": []interface{}{}}})
+	assert.Nil(EventFiles(e))
+}
+
+func TestElementSize(t *testing.T) {
+
+	e := NewDOMEvent(nil, map[string]interface{}{
+		"type":   ResizeEventType,
+		"detail": map[string]interface{}{"width": 640.0, "height": 480.5},
+	})
+	width, height := ElementSize(e)
+	assert.Equal(t, 640.0, width)
+	assert.Equal(t, 480.5, height)
+}
+
+func TestClipboardText(t *testing.T) {
+
+	assert := assert.New(t)
+
+	e := NewDOMEvent(nil, map[string]interface{}{"type": "paste", "clipboard": map[string]interface{}{"text": "hello"}})
+	assert.Equal("hello", ClipboardText(e))
+
+	var mu sync.RWMutex
+	ee := NewEventEnvImpl(&mu, nil)
+	_, ok := ee.TakeClipboardWrite()
+	assert.False(ok)
+	WriteClipboard(ee, "first")
+	WriteClipboard(ee, "second")
+	text, ok := ee.TakeClipboardWrite()
+	assert.True(ok)
+	assert.Equal("second", text)
+	_, ok = ee.TakeClipboardWrite()
+	assert.False(ok)
+}
+
+func TestClipboardItems(t *testing.T) {
+
+	assert := assert.New(t)
+
+	e := NewDOMEvent(nil, map[string]interface{}{
+		"type": "paste",
+		"clipboard": map[string]interface{}{
+			"types": []interface{}{"text/plain", "text/html", "Files"},
+			"items": []interface{}{
+				map[string]interface{}{"kind": "string", "type": "text/plain"},
+				map[string]interface{}{"kind": "string", "type": "text/html"},
+				map[string]interface{}{"kind": "file", "type": "image/png", "name": "image.png", "size": float64(1234)},
+			},
+		},
+	})
+	assert.Equal([]ClipboardItem{
+		{Kind: "string", Type: "text/plain"},
+		{Kind: "string", Type: "text/html"},
+		{Kind: "file", Type: "image/png", Name: "image.png", Size: 1234},
+	}, ClipboardItems(e))
+
+	// outside the browser the read fails straight away rather than blocking
+	_, err := ReadClipboardItem(e, 2).Wait()
+	assert.Error(err)
+
+	e = NewDOMEvent(nil, map[string]interface{}{"type": "click"})
+	assert.Nil(ClipboardItems(e))
+}
+
+func TestEventTouches(t *testing.T) {
+
+	assert := assert.New(t)
+
+	pt := func(id, x, y float64) map[string]interface{} {
+		return map[string]interface{}{"identifier": id, "clientX": x, "clientY": y, "force": 0.5}
+	}
+	e := NewDOMEvent(nil, map[string]interface{}{
+		"type":           "touchend",
+		"touches":        []interface{}{pt(0, 10, 20)},
+		"changedTouches": []interface{}{pt(1, 30, 40)},
+		"targetTouches":  []interface{}{},
+	})
+	ti := EventTouches(e)
+	assert.Equal([]TouchPoint{{ID: 0, ClientX: 10, ClientY: 20, Force: 0.5}}, ti.Touches)
+	assert.Equal([]TouchPoint{{ID: 1, ClientX: 30, ClientY: 40, Force: 0.5}}, ti.ChangedTouches)
+	assert.Nil(ti.TargetTouches)
+
+	assert.Equal(TouchInfo{}, EventTouches(NewDOMEvent(nil, map[string]interface{}{"type": "click"})))
+}
+
+func TestEventPointer(t *testing.T) {
+
+	assert := assert.New(t)
+
+	e := NewDOMEvent(nil, map[string]interface{}{
+		"type": "pointerdown", "pointerId": 3.0, "pointerType": "pen", "isPrimary": true, "buttons": 1.0,
+		"offsetX": 5.0, "offsetY": 6.0, "pressure": 0.7, "tiltX": 30.0, "timeStamp": 100.0,
+	})
+	p := EventPointer(e)
+	assert.Equal(3, p.ID)
+	assert.Equal("pen", p.Type)
+	assert.True(p.IsPrimary)
+	assert.Equal(1, p.Buttons)
+	assert.Equal(30.0, p.TiltX)
+	assert.Equal([]PointerPoint{{OffsetX: 5, OffsetY: 6, Pressure: 0.7, TimeStamp: 100}}, p.Coalesced)
+
+	e = NewDOMEvent(nil, map[string]interface{}{
+		"type": "pointermove", "pointerType": "touch", "offsetX": 9.0,
+		"coalesced": []interface{}{
+			map[string]interface{}{"offsetX": 8.0, "pressure": 0.5},
+			map[string]interface{}{"offsetX": 9.0, "pressure": 0.5},
+		},
+	})
+	p = EventPointer(e)
+	assert.Len(p.Coalesced, 2)
+	assert.Equal(8.0, p.Coalesced[0].OffsetX)
+
+	assert.Equal("", EventPointer(NewDOMEvent(nil, map[string]interface{}{"type": "click"})).Type)
+}
+
+func TestComposition(t *testing.T) {
+
+	assert := assert.New(t)
+
+	e := NewDOMEvent(nil, map[string]interface{}{"type": "input", "isComposing": true, "target": map[string]interface{}{"value": "ni"}})
+	assert.True(IsComposing(e))
+
+	e = NewDOMEvent(nil, map[string]interface{}{"type": "compositionend", "data": "你"})
+	assert.False(IsComposing(e))
+	assert.Equal("你", CompositionData(e))
+}
+
+func TestFocus(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var mu sync.RWMutex
+	ee := NewEventEnvImpl(&mu, nil)
+	_, ok := ee.TakeFocus()
+	assert.False(ok)
+	Focus(ee, "name")
+	Focus(ee, "email")
+	ref, ok := ee.TakeFocus()
+	assert.True(ok)
+	assert.Equal("email", ref)
+	_, ok = ee.TakeFocus()
+	assert.False(ok)
+
+	SetSelection(ee, "name", 2, 5)
+	ref, start, end, ok := ee.TakeSelection()
+	assert.True(ok)
+	assert.Equal([]interface{}{"name", 2, 5}, []interface{}{ref, start, end})
+	_, _, _, ok = ee.TakeSelection()
+	assert.False(ok)
+}
+
+func TestEventSelection(t *testing.T) {
+
+	assert := assert.New(t)
+
+	e := NewDOMEvent(nil, map[string]interface{}{"type": "keyup", "selection": map[string]interface{}{"start": 3.0, "end": 3.0}})
+	start, end, ok := EventSelection(e)
+	assert.True(ok)
+	assert.Equal(3, start)
+	assert.Equal(3, end)
+
+	_, _, ok = EventSelection(NewDOMEvent(nil, map[string]interface{}{"type": "keyup"}))
+	assert.False(ok)
+}
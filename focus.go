@@ -0,0 +1,59 @@
+package vugu
+
+// Focus moves the keyboard focus to the element named ref with vg-ref, e.g. <input vg-ref="search">, or
+// the first one in the document if there are several.  Like WriteClipboard it is done by the renderer at
+// the end of the next render, so the element can be one that render creates, e.g. the first field of a
+// form being shown.  ee is the renderer's EventEnv, e.g. event.EventEnv().  If it is called more than once
+// before the render the last ref is focused.  For focus that follows state, see vg-focus.
+func Focus(ee EventEnv, ref string) {
+	impl, ok := ee.(*EventEnvImpl)
+	if !ok {
+		return
+	}
+	impl.focusMu.Lock()
+	impl.focusRef = &ref
+	impl.focusMu.Unlock()
+}
+
+// TakeFocus returns the ref passed to Focus since it was last called, for use by renderers.
+func (ee *EventEnvImpl) TakeFocus() (string, bool) {
+	ee.focusMu.Lock()
+	defer ee.focusMu.Unlock()
+	if ee.focusRef == nil {
+		return "", false
+	}
+	ref := *ee.focusRef
+	ee.focusRef = nil
+	return ref, true
+}
+
+type selectionRequest struct {
+	ref        string
+	start, end int
+}
+
+// SetSelection focuses the text input or textarea named ref with vg-ref and selects its text from start
+// to end, offsets into its value, or puts the caret there if they are the same.  As with Focus it is done
+// at the end of the next render, after the value is set.  The caret of a focused input is otherwise kept
+// where it was when a render changes its value.
+func SetSelection(ee EventEnv, ref string, start, end int) {
+	impl, ok := ee.(*EventEnvImpl)
+	if !ok {
+		return
+	}
+	impl.focusMu.Lock()
+	impl.selRef = &selectionRequest{ref: ref, start: start, end: end}
+	impl.focusMu.Unlock()
+}
+
+// TakeSelection returns what was passed to SetSelection since it was last called, for use by renderers.
+func (ee *EventEnvImpl) TakeSelection() (ref string, start, end int, ok bool) {
+	ee.focusMu.Lock()
+	defer ee.focusMu.Unlock()
+	if ee.selRef == nil {
+		return "", 0, 0, false
+	}
+	s := *ee.selRef
+	ee.selRef = nil
+	return s.ref, s.start, s.end, true
+}
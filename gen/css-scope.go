@@ -0,0 +1,264 @@
+package gen
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/vugu/html"
+)
+
+// scopeComponentStyles handles <style scoped> in the top level nodes of a component.
+// The CSS is rewritten so each selector only matches elements with a scope attribute specific to
+// this component, and every element in the component's markup is given that attribute.
+// Must be called before compactNodeTree so static HTML includes the attribute.
+func (p *ParserGo) scopeComponentStyles(nodes []*html.Node) {
+
+	var styles []*html.Node
+	var cssBuf strings.Builder
+	for _, n := range nodes {
+		if n.Type == html.ElementNode && strings.ToLower(n.Data) == "style" && hasAttr(n, "scoped") {
+			styles = append(styles, n)
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				cssBuf.WriteString(c.Data)
+			}
+		}
+	}
+	if len(styles) == 0 {
+		return
+	}
+
+	// the same component always gets the same attribute, which must differ from any other component
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s.%s\n%s", p.PackageName, p.StructType, cssBuf.String())
+	scopeAttr := fmt.Sprintf("data-vgs-%08x", h.Sum32())
+
+	for _, n := range styles {
+		removeAttr(n, "scoped")
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				c.Data = scopeCSS(c.Data, scopeAttr)
+			}
+		}
+	}
+
+	var tag func(n *html.Node)
+	tag = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			name := strings.ToLower(n.Data)
+			// components and vg- tags do not end up in the DOM themselves
+			if !strings.Contains(n.Data, ":") && !strings.HasPrefix(name, "vg-") {
+				n.Attr = append(n.Attr, html.Attribute{Key: scopeAttr, OrigKey: scopeAttr})
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			tag(c)
+		}
+	}
+	for _, n := range nodes {
+		if n.Type == html.ElementNode {
+			switch strings.ToLower(n.Data) {
+			case "style", "script", "link":
+				continue
+			}
+		}
+		tag(n)
+	}
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func removeAttr(n *html.Node, key string) {
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		if a.Key != key {
+			attrs = append(attrs, a)
+		}
+	}
+	n.Attr = attrs
+}
+
+// scopeCSS adds [scopeAttr] to the selectors of each rule in css, including those in @media,
+// @supports and similar blocks.  The contents of other at-rules (e.g. @keyframes, @font-face) are
+// left as is.  A selector wrapped in :global(...) is not scoped.
+func scopeCSS(css, scopeAttr string) string {
+	var out strings.Builder
+	i := 0
+	for i < len(css) {
+		// prelude is everything up to a block or the end of a statement
+		start := i
+		end := scanCSS(css, i, "{;}")
+		prelude := css[start:end]
+		if end >= len(css) {
+			out.WriteString(prelude)
+			break
+		}
+		switch css[end] {
+		case ';', '}':
+			// statement at-rule like @import (or a stray brace), copied as is
+			out.WriteString(css[start : end+1])
+			i = end + 1
+			continue
+		}
+
+		// css[end] == '{', find the matching close
+		blockEnd := matchCSSBrace(css, end)
+		block := css[end+1 : blockEnd]
+
+		trimmed := strings.TrimSpace(stripCSSComments(prelude))
+		if strings.HasPrefix(trimmed, "@") {
+			out.WriteString(prelude)
+			out.WriteString("{")
+			switch strings.ToLower(strings.Fields(trimmed[1:] + " ")[0]) {
+			case "media", "supports", "container", "layer", "document":
+				out.WriteString(scopeCSS(block, scopeAttr))
+			default:
+				out.WriteString(block)
+			}
+		} else {
+			out.WriteString(scopeSelectorList(prelude, scopeAttr))
+			out.WriteString("{")
+			out.WriteString(block)
+		}
+		if blockEnd < len(css) && css[blockEnd] == '}' {
+			out.WriteString("}")
+		}
+		i = blockEnd + 1
+	}
+	return out.String()
+}
+
+// scanCSS returns the index of the first character in stop at or after i that is not in a comment,
+// string, or parentheses, or len(s) if there is none.
+func scanCSS(s string, i int, stop string) int {
+	depth := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == '/' && i+1 < len(s) && s[i+1] == '*':
+			e := strings.Index(s[i+2:], "*/")
+			if e < 0 {
+				return len(s)
+			}
+			i += e + 4
+			continue
+		case c == '"' || c == '\'':
+			i++
+			for i < len(s) && s[i] != c {
+				if s[i] == '\\' {
+					i++
+				}
+				i++
+			}
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0 && strings.IndexByte(stop, c) >= 0:
+			return i
+		}
+		i++
+	}
+	return len(s)
+}
+
+func stripCSSComments(s string) string {
+	for {
+		b := strings.Index(s, "/*")
+		if b < 0 {
+			return s
+		}
+		e := strings.Index(s[b+2:], "*/")
+		if e < 0 {
+			return s[:b]
+		}
+		s = s[:b] + s[b+2+e+2:]
+	}
+}
+
+// matchCSSBrace returns the index of the '}' matching the '{' at open, or len(s) if it is not closed.
+func matchCSSBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		i = scanCSS(s, i, "{}")
+		if i >= len(s) {
+			break
+		}
+		if s[i] == '{' {
+			depth++
+		} else {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+// scopeSelectorList scopes each selector in a comma separated list.
+func scopeSelectorList(list, scopeAttr string) string {
+	var parts []string
+	for i := 0; ; {
+		e := scanCSS(list, i, ",")
+		parts = append(parts, scopeSelector(list[i:e], scopeAttr))
+		if e >= len(list) {
+			break
+		}
+		i = e + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// scopeSelector adds [scopeAttr] to the last compound selector in sel, before any pseudo-classes
+// or pseudo-elements, e.g. "ul li:hover" becomes "ul li[scopeAttr]:hover".
+func scopeSelector(sel, scopeAttr string) string {
+
+	if g := strings.Index(sel, ":global("); g >= 0 {
+		depth := 1
+		for e := g + len(":global("); e < len(sel); e++ {
+			switch sel[e] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 {
+				return sel[:g] + sel[g+len(":global("):e] + sel[e+1:]
+			}
+		}
+	}
+
+	// keep surrounding whitespace as is
+	lead := len(sel) - len(strings.TrimLeft(sel, " \t\r\n"))
+	core := strings.TrimSpace(sel)
+	trail := sel[lead+len(core):]
+	if core == "" {
+		return sel
+	}
+
+	// find the start of the last compound selector
+	compoundStart := 0
+	for i := 0; i < len(core); {
+		e := scanCSS(core, i, " \t\r\n>+~")
+		if e >= len(core) {
+			break
+		}
+		compoundStart = e + 1
+		i = e + 1
+	}
+
+	// and where its pseudo-classes and pseudo-elements start
+	insert := scanCSS(core, compoundStart, ":")
+
+	return sel[:lead] + core[:insert] + "[" + scopeAttr + "]" + core[insert:] + trail
+}
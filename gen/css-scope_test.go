@@ -0,0 +1,22 @@
+package gen
+
+import "testing"
+
+func TestScopeCSS(t *testing.T) {
+	tests := []struct{ in, out string }{
+		{`.a{color:red}`, `.a[s]{color:red}`},
+		{`ul li:hover, p > a::before {x:y}`, `ul li[s]:hover, p > a[s]::before {x:y}`},
+		{`a:not(.b, .c) {}`, `a[s]:not(.b, .c) {}`},
+		{`input[type="a b"]{}`, `input[type="a b"][s]{}`},
+		{`:hover{}`, `[s]:hover{}`},
+		{`:global(body) .x{} .y{}`, `body .x{} .y[s]{}`},
+		{`@media (max-width: 10px) { .a{b:c} .d{e:f} } .g{}`, `@media (max-width: 10px) { .a[s]{b:c} .d[s]{e:f} } .g[s]{}`},
+		{`@keyframes k { from {a:b} to {a:c} } .x{}`, `@keyframes k { from {a:b} to {a:c} } .x[s]{}`},
+		{`@import "x.css"; /* c{} */ .a{content:"}"}`, `@import "x.css"; /* c{} */ .a[s]{content:"}"}`},
+	}
+	for _, tc := range tests {
+		if got := scopeCSS(tc.in, "s"); got != tc.out {
+			t.Errorf("scopeCSS(%q)\n got: %q\nwant: %q", tc.in, got, tc.out)
+		}
+	}
+}
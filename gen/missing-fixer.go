@@ -170,8 +170,12 @@ type %s struct {}
 				/*if len(opts) == 1 && opts[0] == "interface" {
 					isInterface = true
 				} else */
+				// the only option is the type of the event's value, e.g. `//vugugen:event Select *Item`
+				valueType := ""
 				if len(opts) == 0 {
 					// no opts is fine
+				} else if len(opts) == 1 {
+					valueType = opts[0]
 				} else {
 					return fmt.Errorf("error parsing %s vugugen event comment unexpected options %q", fname, c)
 				}
@@ -179,8 +183,16 @@ type %s struct {}
 				// check for NameEvent
 				decl := findTypeDecl(&fset, pkg, eventName+"Event")
 
-				// emit type if missing as a struct wrapper around a DOMEvent
-				if decl == nil {
+				// emit type if missing as a struct wrapper around a DOMEvent, plus the value if it has one
+				if decl == nil && valueType != "" {
+					fmt.Fprintf(fout, `// %sEvent is a component event.
+type %sEvent struct {
+	vugu.DOMEvent
+	Value %s
+}
+
+`, eventName, eventName, valueType)
+				} else if decl == nil {
 					fmt.Fprintf(fout, `// %sEvent is a component event.
 type %sEvent struct {
 	vugu.DOMEvent
@@ -215,6 +227,29 @@ var _ %sHandler = %sFunc(nil)
 `, eventName, eventName, eventName, eventName, eventName, eventName, eventName, eventName, eventName, eventName, eventName, eventName, eventName)
 				}
 
+				// check for EmitName, emit if missing, for the component to emit the event with
+				if findFuncDecl(pkg, "Emit"+eventName) == nil {
+					if valueType != "" {
+						fmt.Fprintf(fout, `// Emit%s calls h with a %sEvent for value, unless h is nil (no handler is bound).
+func Emit%s(h %sHandler, value %s) {
+	if h != nil {
+		h.%sHandle(%sEvent{Value: value})
+	}
+}
+
+`, eventName, eventName, eventName, eventName, valueType, eventName, eventName)
+					} else {
+						fmt.Fprintf(fout, `// Emit%s calls h with event, unless h is nil (no handler is bound).
+func Emit%s(h %sHandler, event %sEvent) {
+	if h != nil {
+		h.%sHandle(event)
+	}
+}
+
+`, eventName, eventName, eventName, eventName, eventName)
+					}
+				}
+
 			default:
 				return fmt.Errorf("error parsing %s vugugen comment with unknown type %q", fname, c)
 			}
@@ -366,6 +401,20 @@ func findTypeDecl(fset *token.FileSet, pkg *ast.Package, typeName string) ast.De
 	return nil
 }
 
+// findFuncDecl looks through the package for a function (not a method) with the given name and
+// returns the declaration or nil if not found
+func findFuncDecl(pkg *ast.Package, funcName string) ast.Decl {
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if ok && funcDecl.Recv == nil && funcDecl.Name.Name == funcName {
+				return funcDecl
+			}
+		}
+	}
+	return nil
+}
+
 // findFileBuildMethodType will return "Comp" given `func (c *Root) Comp` exists in the file.
 func findFileBuildMethodType(file *ast.File) string {
 
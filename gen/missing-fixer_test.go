@@ -37,6 +37,8 @@ func TestMissingFixer(t *testing.T) {
 	must(ioutil.WriteFile(filepath.Join(tmpDir, "comp1_vgen.go"), []byte("package main\n\nimport \"github.com/vugu/vugu\"\n\ntype Comp1 struct{}\n\nfunc (c *Comp1)Build(vgin *vugu.BuildIn) (vgout *vugu.BuildOut) {return nil}"), 0644))
 	// a file with an event where the event type is declared but not the handler interface or func
 	must(ioutil.WriteFile(filepath.Join(tmpDir, "epart.go"), []byte("package main\n\n//vugugen:event Part\ntype PartEvent struct { A string }\n"), 0644))
+	// an event with a value, and one whose emit function is declared
+	must(ioutil.WriteFile(filepath.Join(tmpDir, "eselect.go"), []byte("package main\n\n//vugugen:event Select *Item\ntype Item struct{}\n\n//vugugen:event Done\nfunc EmitDone() {}\n"), 0644))
 
 	// 	// TEMP
 	// 	must(ioutil.WriteFile(filepath.Join(tmpDir, "root_vgen.go"), []byte(`
@@ -73,6 +75,14 @@ func TestMissingFixer(t *testing.T) {
 		"type SomethingFunc func",
 		"func (f SomethingFunc) SomethingHandle(",
 		"var _ SomethingHandler =",
+		"func EmitSomething(h SomethingHandler, event SomethingEvent)",
+
+		"Value *Item",
+		"func EmitSelect(h SelectHandler, value *Item)",
+		"h.SelectHandle(SelectEvent{Value: value})",
+
+		"type DoneEvent struct",
+		"!func EmitDone(",
 
 		// "type PartEvent struct", // should exist only in epart.go
 
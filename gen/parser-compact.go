@@ -9,6 +9,7 @@ import (
 	// "github.com/vugu/vugu/internal/html"
 	// "golang.org/x/net/html"
 	"github.com/vugu/html"
+	"github.com/vugu/vugu"
 )
 
 // compactNodeTree operates on a Node tree in-place and find elements with static
@@ -17,6 +18,9 @@ import (
 // it is much faster for large blocks of HTML than individual syncing DOM nodes.
 // Any modern browser's native HTML parser is always going to be a lot faster than
 // we can achieve calling back and forth from wasm for each element.
+// The output is a vugu.StaticHTML, so the renderer only sets it when the element is
+// created, and elements with dynamic attributes or events but static contents have
+// their contents compacted the same way, with the element itself still synced.
 func compactNodeTree(rootN *html.Node) error {
 
 	// do not collapse html, body or head, and nothing inside head
@@ -67,24 +71,11 @@ func compactNodeTree(rootN *html.Node) error {
 					continue
 				}
 
-				var htmlBuf bytes.Buffer
-				// walk each immediate child of cn
-				for cnChild := cn.FirstChild; cnChild != nil; cnChild = cnChild.NextSibling {
-					// render directly into htmlBuf
-					err := html.Render(&htmlBuf, cnChild)
-					if err != nil {
-						return false, err
-					}
+				err := compactChildren(cn)
+				if err != nil {
+					return false, err
 				}
 
-				// add a vg-html with the static Go string expression of the contents casted to a vugu.HTML
-				cn.Attr = append(cn.Attr, html.Attribute{Key: "vg-html", Val: "vugu.HTML(" + htmlGoQuoteString(htmlBuf.String()) + ")"})
-				// cn.Attr = append(cn.Attr, html.Attribute{Key: "vg-html", Val: htmlGoQuoteString(htmlBuf.String())})
-
-				// remove children, since vg-html supplants them
-				cn.FirstChild = nil
-				cn.LastChild = nil
-
 			}
 
 			return false, nil
@@ -92,7 +83,14 @@ func compactNodeTree(rootN *html.Node) error {
 
 		// if all of the children are compactable, we need to check if this is an element that contains no dynamic attributes
 		if allCompactable {
-			return isStaticEl(n), nil
+			if isStaticEl(n) {
+				return true, nil
+			}
+			// otherwise the element stays as it is but its contents can still be compacted
+			if n.FirstChild != nil && isStaticContentEl(n) {
+				return false, compactChildren(n)
+			}
+			return false, nil
 		}
 
 		// default is not compactable
@@ -103,6 +101,51 @@ func compactNodeTree(rootN *html.Node) error {
 	return err
 }
 
+// compactChildren replaces the children of cn with a vg-html of their static output.
+func compactChildren(cn *html.Node) error {
+
+	var htmlBuf bytes.Buffer
+	// walk each immediate child of cn
+	for cnChild := cn.FirstChild; cnChild != nil; cnChild = cnChild.NextSibling {
+		// render directly into htmlBuf
+		err := html.Render(&htmlBuf, cnChild)
+		if err != nil {
+			return err
+		}
+	}
+
+	// add a vg-html with the static Go string expression of the contents casted to a vugu.StaticHTML
+	cn.Attr = append(cn.Attr, html.Attribute{Key: "vg-html", Val: "vugu.StaticHTML(" + htmlGoQuoteString(htmlBuf.String()) + ")"})
+
+	// remove children, since vg-html supplants them
+	cn.FirstChild = nil
+	cn.LastChild = nil
+
+	return nil
+}
+
+// isStaticContentEl returns true if n is an element whose contents, if static, can be replaced with a
+// vg-html even though it has dynamic attributes, i.e. it is not a component and nothing else sets or
+// uses its contents (vg-model selects its options).
+func isStaticContentEl(n *html.Node) bool {
+
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if strings.Contains(n.Data, ":") || strings.HasPrefix(n.Data, "vg-") {
+		return false
+	}
+
+	for _, attr := range n.Attr {
+		switch attr.Key {
+		case "vg-html", "vg-content", "vg-js-create", "vg-js-populate", "vg-model":
+			return false
+		}
+	}
+
+	return true
+}
+
 func isStaticEl(n *html.Node) bool {
 
 	if n.Type != html.ElementNode { // must be element
@@ -113,7 +156,7 @@ func isStaticEl(n *html.Node) bool {
 	if strings.Contains(n.Data, ":") {
 		return false
 	}
-	if n.Data == "vg-comp" {
+	if n.Data == "vg-comp" || n.Data == "vg-keep-alive" {
 		return false
 	}
 
@@ -127,6 +170,9 @@ func isStaticEl(n *html.Node) bool {
 		if !unicode.IsLetter(rune(attr.Key[0])) { // anything except a letter as an attr we assume to be dynamic
 			return false
 		}
+		if !(vugu.VGAttribute{Namespace: attr.Namespace, Key: attr.Key, Val: attr.Val}).Present() { // e.g. disabled="false" is left off by the renderer
+			return false
+		}
 	}
 
 	// if it passes above, should be fine to compact
@@ -25,6 +25,12 @@ func TestCompactNodeTree(t *testing.T) {
 		<p>
 			This is some static text here, <strong>blah</strong> bleh <em>blee</em>.
 		</p>
+		<section :class="c.Class" @click="c.HandleClick(event)">
+			<h2>Static heading</h2>
+		</section>
+		<section vg-js-create="c.Create(value)">
+			<h2>Static heading</h2>
+		</section>
 	</div>
 </body>
 </html>`
@@ -41,7 +47,13 @@ func TestCompactNodeTree(t *testing.T) {
 
 	log.Printf("OUT:\n%s", buf.String())
 
-	assert.Contains(buf.String(), "<p vg-html=\"vugu.HTML(&#34;")
+	assert.Contains(buf.String(), "<p vg-html=\"vugu.StaticHTML(&#34;")
+
+	// dynamic attributes and events stay on the element with its contents compacted
+	assert.Contains(buf.String(), `<section :class="c.Class" @click="c.HandleClick(event)" vg-html="vugu.StaticHTML(&#34;\n\t\t\t\x3Ch2\x3EStatic heading`)
+	// unless something uses the children
+	assert.Contains(buf.String(), `<section vg-js-create="c.Create(value)">
+			<h2>Static heading</h2>`)
 
 	// log.Printf("HERE: %#v", n.FirstChild.FirstChild.NextSibling.FirstChild.FirstChild)
 	// log.Printf("HERE: %#v", n.FirstChild.FirstChild.NextSibling.NextSibling.FirstChild.FirstChild.NextSibling.NextSibling)
@@ -104,6 +104,21 @@ func TestRun(t *testing.T) {
 			},
 			build: "wasm",
 		},
+		{
+			name:      "svg-fragment",
+			opts:      ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu": `<div><svg><main:Bar/></svg></div>`,
+				"bar.vugu":  `<g xmlns="http://www.w3.org/2000/svg"><rect vg-if="true" width="10"></rect></g>`,
+				"go.mod":    "module testcase\nreplace github.com/vugu/vugu => " + pwd + "\n",
+				"main.go":   "package main\nfunc main(){}",
+			},
+			out: map[string][]string{
+				"bar_vgen.go": {`Namespace: "svg", Data: "g"`, `Namespace: "svg", Data: "rect"`},
+			},
+			build: "default",
+		},
 		{
 			name:      "recursive",
 			opts:      ParserGoPkgOpts{},
@@ -157,6 +172,29 @@ func TestRun(t *testing.T) {
 			},
 			build: "default",
 		},
+		{
+			name:      "events-typed",
+			opts:      ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu": `<div><main:Picker @Select="c.HandleSelect"></main:Picker><main:Picker @Select="c.Selected = event.Value"></main:Picker></div>
+<script type="application/x-go">
+type Root struct { Selected string }
+func (c *Root) HandleSelect(event SelectEvent) { c.Selected = event.Value }
+</script>`,
+				"picker.vugu": `<button @click="EmitSelect(c.Select, &#34;a&#34;)">a</button>
+<script type="application/x-go">
+type Picker struct { Select SelectHandler }
+</script>`,
+				"go.mod":  "module testcase\nreplace github.com/vugu/vugu => " + pwd + "\n",
+				"main.go": "package main\nfunc main(){}\n\n//vugugen:event Select string\n",
+			},
+			out: map[string][]string{
+				"root_vgen.go":      {`vgcomp.Select = SelectFunc\(c.HandleSelect\)`, `vgcomp.Select = SelectFunc\(func\(event SelectEvent\) \{`},
+				"0_missing_vgen.go": {`Value string`, `func EmitSelect\(h SelectHandler, value string\)`},
+			},
+			build: "default",
+		},
 	}
 
 	for _, tc := range tcList {
@@ -267,3 +305,35 @@ func tstWriteFiles(dir string, m map[string]string) {
 	}
 
 }
+
+func TestVGKeyParseGoPkgRun(t *testing.T) {
+
+	assert := assert.New(t)
+
+	tmpDir, err := ioutil.TempDir("", "TestVGKeyParseGoPkgRun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	assert.NoError(ioutil.WriteFile(filepath.Join(tmpDir, "root.vugu"), []byte(`
+<ul>
+	<li vg-for="_, item := range c.Items" vg-key="item.ID" vg-content="item.Name"></li>
+	<li vg-for="_, item := range c.Items" vg-content="item.Name"></li>
+</ul>
+<script type="application/x-go">
+type Item struct { ID int; Name string }
+type Root struct { Items []Item }
+</script>
+`), 0644))
+
+	p := NewParserGoPkg(tmpDir, nil)
+	assert.NoError(p.Run())
+
+	b, err := ioutil.ReadFile(filepath.Join(tmpDir, "root_vgen.go"))
+	assert.NoError(err)
+
+	// only an explicit vg-key identifies the element, the iteration key does not
+	assert.Equal(1, bytes.Count(b, []byte(`vgn.SetKey(item.ID)`)), "%s", b)
+	assert.Equal(1, bytes.Count(b, []byte(`SetKey(`)))
+}
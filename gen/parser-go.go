@@ -76,6 +76,9 @@ func gofmt(pgm string) (string, error) {
 	return string(fmtOutput.Bytes()), nil
 }
 
+// svgNamespaceURI is the xmlns attribute value that marks a component's root element as SVG.
+const svgNamespaceURI = "http://www.w3.org/2000/svg"
+
 // Parse is an experiment...
 // r is the actual input, fname is only used to emit line directives
 func (p *ParserGo) Parse(r io.Reader, fname string) error {
@@ -89,6 +92,7 @@ func (p *ParserGo) Parse(r io.Reader, fname string) error {
 
 	// use a tokenizer to peek at the first element and see if it's an HTML tag
 	state.isFullHTML = false
+	isSVGFragment := false
 	tmpZ := html.NewTokenizer(bytes.NewReader(inRaw))
 	for {
 		tt := tmpZ.Next()
@@ -103,6 +107,15 @@ func (p *ParserGo) Parse(r io.Reader, fname string) error {
 			state.isFullHTML = true
 			break
 		}
+		// an SVG element other than <svg> itself (e.g. <g>) can only be parsed correctly
+		// in an SVG context, which a component indicates with the xmlns attribute
+		if t.Data != "svg" {
+			for _, a := range t.Attr {
+				if a.Namespace == "" && a.Key == "xmlns" && a.Val == svgNamespaceURI {
+					isSVGFragment = true
+				}
+			}
+		}
 		break
 	}
 
@@ -118,7 +131,12 @@ func (p *ParserGo) Parse(r io.Reader, fname string) error {
 
 	} else {
 
-		nlist, err := html.ParseFragment(bytes.NewReader(inRaw), &html.Node{
+		in := inRaw
+		if isSVGFragment {
+			in = append(append([]byte("<svg>"), inRaw...), "</svg>"...)
+		}
+
+		nlist, err := html.ParseFragment(bytes.NewReader(in), &html.Node{
 			Type:     html.ElementNode,
 			DataAtom: atom.Div,
 			Data:     "div",
@@ -127,6 +145,18 @@ func (p *ParserGo) Parse(r io.Reader, fname string) error {
 			return err
 		}
 
+		// unwrap the <svg> added above, its children are in the SVG namespace
+		if isSVGFragment && len(nlist) == 1 && nlist[0].Type == html.ElementNode && nlist[0].Data == "svg" {
+			svgN := nlist[0]
+			nlist = nil
+			for n := svgN.FirstChild; n != nil; n = n.NextSibling {
+				nlist = append(nlist, n)
+			}
+			for _, n := range nlist {
+				svgN.RemoveChild(n)
+			}
+		}
+
 		// only add elements
 		for _, n := range nlist {
 			if n.Type != html.ElementNode {
@@ -138,6 +168,11 @@ func (p *ParserGo) Parse(r io.Reader, fname string) error {
 
 	}
 
+	// <style scoped>, this changes the markup so must be done before optimizing it
+	if !state.isFullHTML {
+		p.scopeComponentStyles(state.docNodeList)
+	}
+
 	// run n through the optimizer and convert large chunks of static elements into
 	// vg-html attributes, this should provide a significiant performance boost for static HTML
 	if !p.NoOptimizeStatic {
@@ -212,6 +247,8 @@ type parseGoState struct {
 	// cssChunkList []codeChunk
 	// jsChunkList  []codeChunk
 	outIsSet bool // set to true when vgout.Out has been set for to the level node
+
+	keepAlive int // > 0 when inside a vg-keep-alive tag
 }
 
 func (p *ParserGo) visitOverall(state *parseGoState) error {
@@ -238,10 +275,11 @@ func (p *ParserGo) visitOverall(state *parseGoState) error {
 	// NOTE: Use things that are lightweight here - e.g. don't do var _ = fmt.Sprintf because that brings in all of the
 	// (possibly quite large) formatting code, which might otherwise be avoided.
 	fmt.Fprintf(&state.goBufBottom, "// 'fix' unused imports\n")
-	fmt.Fprintf(&state.goBufBottom, "var _ fmt.Stringer\n")
-	fmt.Fprintf(&state.goBufBottom, "var _ reflect.Type\n")
 	fmt.Fprintf(&state.goBufBottom, "var _ vjson.RawMessage\n")
 	fmt.Fprintf(&state.goBufBottom, "var _ js.Value\n")
+	// fmt and reflect are removed again by pruneAstFileImports if nothing else uses them
+	fmt.Fprintf(&state.goBufBottom, "var _ fmt.Stringer\n")
+	fmt.Fprintf(&state.goBufBottom, "var _ reflect.Type\n")
 	fmt.Fprintf(&state.goBufBottom, "\n")
 
 	// remove document node if present
@@ -261,7 +299,7 @@ func (p *ParserGo) visitOverall(state *parseGoState) error {
 
 	} else {
 
-		gotTopNode := false
+		var topNodes []*html.Node
 
 		for _, n := range state.docNodeList {
 
@@ -296,13 +334,6 @@ func (p *ParserGo) visitOverall(state *parseGoState) error {
 				continue
 			}
 
-			if gotTopNode {
-				return fmt.Errorf("Found more than one top level element: %s", n.Data)
-			}
-			gotTopNode = true
-
-			// handle top node
-
 			// check for forbidden top level tags
 			nodeName := strings.ToLower(n.Data)
 			if nodeName == "head" ||
@@ -310,12 +341,20 @@ func (p *ParserGo) visitOverall(state *parseGoState) error {
 				return fmt.Errorf("component cannot use %q as top level tag", nodeName)
 			}
 
-			err := p.visitTopNode(state, n)
+			topNodes = append(topNodes, n)
+		}
+
+		// handle top node(s)
+		if len(topNodes) == 1 {
+			err := p.visitTopNode(state, topNodes[0])
+			if err != nil {
+				return err
+			}
+		} else if len(topNodes) > 1 {
+			err := p.visitTopFragment(state, topNodes)
 			if err != nil {
 				return err
 			}
-			continue
-
 		}
 
 	}
@@ -702,6 +741,29 @@ func (p *ParserGo) visitTopNode(state *parseGoState, n *html.Node) error {
 	return nil
 }
 
+// visitTopFragment handles multiple top level nodes, which are output as children of a
+// template node so they appear in the DOM without a wrapper element.
+func (p *ParserGo) visitTopFragment(state *parseGoState, nodes []*html.Node) error {
+
+	fmt.Fprintf(&state.buildBuf, "vgn = &vugu.VGNode{Type:vugu.VGNodeType(%d)} // fragment\n", vugu.ElementNode)
+	fmt.Fprintf(&state.buildBuf, "vgout.Out = append(vgout.Out, vgn) // root for output\n")
+	state.outIsSet = true
+
+	fmt.Fprintf(&state.buildBuf, "{\n")
+	fmt.Fprintf(&state.buildBuf, "vgparent := vgn; _ = vgparent\n") // vgparent set for this block to vgn
+
+	for _, n := range nodes {
+		err := p.visitDefaultByType(state, n)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(&state.buildBuf, "}\n")
+
+	return nil
+}
+
 // visitNodeElementAndCtrl handles an element that supports vg-if, vg-for etc
 func (p *ParserGo) visitNodeElementAndCtrl(state *parseGoState, n *html.Node) error {
 
@@ -765,15 +827,84 @@ func (p *ParserGo) visitNodeJustElement(state *parseGoState, n *html.Node) error
 		fmt.Fprintf(&state.buildBuf, "vgn.SetInnerHTML(%s)\n", htmlExpr)
 	}
 
+	// vg-show
+	showExpr := vgShowExpr(n)
+	if showExpr != "" {
+		fmt.Fprintf(&state.buildBuf, "vgn.Hidden = !(%s)\n", showExpr)
+	}
+
+	// vg-can.disable
+	if perm := vgCanDisablePermission(n); perm != "" {
+		fmt.Fprintf(&state.buildBuf, "if !vgin.Can(%q) {\n", perm)
+		fmt.Fprintf(&state.buildBuf, "vgn.Attr = append(vgn.Attr, vugu.VGAttribute{Key: \"disabled\"}, vugu.VGAttribute{Key: \"aria-disabled\", Val: \"true\"})\n")
+		fmt.Fprintf(&state.buildBuf, "}\n")
+	}
+
+	// vg-focus and vg-scroll-into-view
+	if focusExpr := vgFocusExpr(n); focusExpr != "" {
+		fmt.Fprintf(&state.buildBuf, "vgn.Focus = %s\n", focusExpr)
+	}
+	if scrollExpr := vgScrollIntoViewExpr(n); scrollExpr != "" {
+		fmt.Fprintf(&state.buildBuf, "vgn.ScrollIntoView = %s\n", scrollExpr)
+	}
+
+	// vg-scroll-key
+	if scrollKeyExpr := vgScrollKeyExpr(n); scrollKeyExpr != "" {
+		fmt.Fprintf(&state.buildBuf, "vgn.ScrollKey = %s\n", scrollKeyExpr)
+	}
+
+	// vg-key
+	if keyExpr := vgKeyExpr(n); keyExpr != "" {
+		fmt.Fprintf(&state.buildBuf, "vgn.SetKey(%s)\n", keyExpr)
+	}
+
+	// vg-portal
+	if sel := vgPortalSelector(n); sel != "" {
+		fmt.Fprintf(&state.buildBuf, "vgn.Portal = %q\n", sel)
+	}
+
 	// DOM events
-	eventMap, eventKeys := vgDOMEventExprs(n)
-	for _, k := range eventKeys {
-		expr := eventMap[k]
+	eventKeys, eventExprs := vgDOMEventExprs(n)
+	for i, k := range eventKeys {
+		expr := eventExprs[i]
+		ea, err := parseDOMEventKey(k)
+		if err != nil {
+			return err
+		}
 		fmt.Fprintf(&state.buildBuf, "vgn.DOMEventHandlerSpecList = append(vgn.DOMEventHandlerSpecList, vugu.DOMEventHandlerSpec{\n")
-		fmt.Fprintf(&state.buildBuf, "EventType: %q,\n", k)
+		fmt.Fprintf(&state.buildBuf, "EventType: %q,\n", ea.eventType)
 		fmt.Fprintf(&state.buildBuf, "Func: func(event vugu.DOMEvent) { %s },\n", expr)
-		fmt.Fprintf(&state.buildBuf, "// TODO: implement capture, etc. mostly need to decide syntax\n")
+		if ea.capture {
+			fmt.Fprintf(&state.buildBuf, "Capture: true,\n")
+		}
+		if ea.passive {
+			fmt.Fprintf(&state.buildBuf, "Passive: true,\n")
+		}
+		if ea.global != "" {
+			fmt.Fprintf(&state.buildBuf, "Global: %q,\n", ea.global)
+		}
+		if len(ea.modifiers) > 0 {
+			fmt.Fprintf(&state.buildBuf, "Modifiers: %s,\n", strings.Join(ea.modifiers, "|"))
+		}
+		if ea.debounce > 0 {
+			fmt.Fprintf(&state.buildBuf, "Debounce: %d, // %v\n", int64(ea.debounce), ea.debounce)
+		}
+		if ea.throttle > 0 {
+			fmt.Fprintf(&state.buildBuf, "Throttle: %d, // %v\n", int64(ea.throttle), ea.throttle)
+		}
+		fmt.Fprintf(&state.buildBuf, "})\n")
+	}
+
+	// vg-model, bound once the children (e.g. options) are built
+	modelExpr, modelEvent := vgModelExpr(n)
+	if modelExpr != "" {
+		fmt.Fprintf(&state.buildBuf, "vgn.DOMEventHandlerSpecList = append(vgn.DOMEventHandlerSpecList, vugu.DOMEventHandlerSpec{\n")
+		fmt.Fprintf(&state.buildBuf, "EventType: %q,\n", modelEvent)
+		fmt.Fprintf(&state.buildBuf, "Func: func(event vugu.DOMEvent) { vugu.ModelChanged(event, &(%s)) },\n", modelExpr)
 		fmt.Fprintf(&state.buildBuf, "})\n")
+		fmt.Fprintf(&state.buildBuf, "{\n")
+		fmt.Fprintf(&state.buildBuf, "vgmodeln := vgn\n")
+		defer fmt.Fprintf(&state.buildBuf, "vugu.BindModel(vgmodeln, &(%s))\n}\n", modelExpr)
 	}
 
 	if n.FirstChild != nil {
@@ -815,6 +946,10 @@ func (p *ParserGo) visitDefaultByType(state *parseGoState, n *html.Node) error {
 			err = p.visitVGCompTag(state, n)
 		} else if n.Data == "vg-template" {
 			err = p.visitVGTemplateTag(state, n)
+		} else if n.Data == "vg-slot" {
+			err = p.visitVGSlotTag(state, n)
+		} else if n.Data == "vg-keep-alive" {
+			err = p.visitVGKeepAliveTag(state, n)
 		} else {
 			err = p.visitNodeElementAndCtrl(state, n)
 		}
@@ -867,17 +1002,114 @@ func (p *ParserGo) visitVGCompTag(state *parseGoState, n *html.Node) error {
 	fmt.Fprintf(&state.buildBuf, "{\n")
 	defer fmt.Fprintf(&state.buildBuf, "}\n")
 
-	fmt.Fprintf(&state.buildBuf, "var vgcomp vugu.Builder = %s\n", expr)
+	// expr can be a component instance or a vugu.ComponentFunc which creates one, in which case
+	// the instance is cached by position (and vg-key) like regular component tags
+	compKeyID := compHashCounted(p.StructType + "." + n.OrigData)
+	keyExpr := vgKeyExpr(n)
+	if keyExpr == "" {
+		keyExpr = "vgiterkey"
+	}
+	fmt.Fprintf(&state.buildBuf, "var vgcomp vugu.Builder = vgin.BuildEnv.DynamicComponent(0x%X^vgin.CurrentPositionHash(), %s, %s)\n", compKeyID, keyExpr, expr)
+	fmt.Fprintf(&state.buildBuf, "if vgcomp != nil {\n")
+	if state.keepAlive > 0 {
+		fmt.Fprintf(&state.buildBuf, "    vgin.BuildEnv.KeepAlive(vgcomp)\n")
+	}
+	fmt.Fprintf(&state.buildBuf, "    vgout.Components = append(vgout.Components, vgcomp)\n")
+	fmt.Fprintf(&state.buildBuf, "    vgn = &vugu.VGNode{Component:vgcomp}\n")
+	if explicitKeyExpr := vgKeyExpr(n); explicitKeyExpr != "" {
+		fmt.Fprintf(&state.buildBuf, "    vgn.SetKey(%s)\n", explicitKeyExpr)
+	}
+	if sel := vgPortalSelector(n); sel != "" {
+		fmt.Fprintf(&state.buildBuf, "    vgn.Portal = %q\n", sel)
+	}
+	fmt.Fprintf(&state.buildBuf, "    vgparent.AppendChild(vgn)\n")
+	fmt.Fprintf(&state.buildBuf, "}\n")
+
+	return nil
+}
+
+// emitSlotBuilder assigns a Builder which outputs nodes to the slot field target.
+func (p *ParserGo) emitSlotBuilder(state *parseGoState, target string, nodes []*html.Node) error {
+
+	fmt.Fprintf(&state.buildBuf, "%s = vugu.NewBuilderFunc(func(vgin *vugu.BuildIn) (vgout *vugu.BuildOut) {\n", target)
+	// vgn is the equivalent of a vg-template tag and becomes the contents of vgout.Out and the vgparent
+	fmt.Fprintf(&state.buildBuf, "vgn := &vugu.VGNode{Type:vugu.VGNodeType(%d)}\n", vugu.ElementNode)
+	fmt.Fprintf(&state.buildBuf, "vgout = &vugu.BuildOut{}\n")
+	fmt.Fprintf(&state.buildBuf, "vgout.Out = append(vgout.Out, vgn)\n")
+	fmt.Fprintf(&state.buildBuf, "vgparent := vgn; _ = vgparent\n")
+	fmt.Fprintf(&state.buildBuf, "\n")
+
+	// iterate over nodes and do the usual with each one
+	for _, childN := range nodes {
+		err := p.visitDefaultByType(state, childN)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(&state.buildBuf, "return\n")
+	fmt.Fprintf(&state.buildBuf, "})\n")
+
+	return nil
+}
+
+// visitVGSlotTag handles vg-slot used in a component's own markup (outside of a component tag),
+// it outputs the Builder the parent assigned to the slot field named by the name attribute
+// (DefaultSlot if not specified), or the contents of the vg-slot tag if the field is nil.
+func (p *ParserGo) visitVGSlotTag(state *parseGoState, n *html.Node) error {
+
+	// vg-if
+	ife := vgIfExpr(n)
+	if ife != "" {
+		fmt.Fprintf(&state.buildBuf, "if %s {\n", ife)
+		defer fmt.Fprintf(&state.buildBuf, "}\n")
+	}
+
+	slotName := strings.TrimSpace(vgSlotName(n))
+	if slotName == "" {
+		slotName = "DefaultSlot"
+	}
+
+	fmt.Fprintf(&state.buildBuf, "{\n")
+	fmt.Fprintf(&state.buildBuf, "var vgcomp vugu.Builder = c.%s\n", slotName)
 	fmt.Fprintf(&state.buildBuf, "if vgcomp != nil {\n")
 	fmt.Fprintf(&state.buildBuf, "    vgin.BuildEnv.WireComponent(vgcomp)\n")
 	fmt.Fprintf(&state.buildBuf, "    vgout.Components = append(vgout.Components, vgcomp)\n")
 	fmt.Fprintf(&state.buildBuf, "    vgn = &vugu.VGNode{Component:vgcomp}\n")
 	fmt.Fprintf(&state.buildBuf, "    vgparent.AppendChild(vgn)\n")
+	fmt.Fprintf(&state.buildBuf, "} else {\n")
+
+	// fallback content
+	for childN := n.FirstChild; childN != nil; childN = childN.NextSibling {
+		err := p.visitDefaultByType(state, childN)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(&state.buildBuf, "}\n")
 	fmt.Fprintf(&state.buildBuf, "}\n")
 
 	return nil
 }
 
+// visitVGKeepAliveTag handles vg-keep-alive, which outputs its children as is but components
+// inside it are kept (see BuildEnv.KeepAlive) instead of destroyed when they are not rendered.
+func (p *ParserGo) visitVGKeepAliveTag(state *parseGoState, n *html.Node) error {
+
+	state.keepAlive++
+	defer func() { state.keepAlive-- }()
+
+	for childN := n.FirstChild; childN != nil; childN = childN.NextSibling {
+		err := p.visitDefaultByType(state, childN)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // visitVGTemplateTag handles vg-template
 func (p *ParserGo) visitVGTemplateTag(state *parseGoState, n *html.Node) error {
 
@@ -898,6 +1130,9 @@ func (p *ParserGo) visitVGTemplateTag(state *parseGoState, n *html.Node) error {
 
 	// output a node with type Element but empty data
 	fmt.Fprintf(&state.buildBuf, "vgn = &vugu.VGNode{Type:vugu.VGNodeType(%d)} // <vg-template>\n", vugu.ElementNode)
+	if sel := vgPortalSelector(n); sel != "" {
+		fmt.Fprintf(&state.buildBuf, "vgn.Portal = %q\n", sel)
+	}
 	fmt.Fprintf(&state.buildBuf, "vgparent.AppendChild(vgn)\n")
 
 	// and then only process children
@@ -975,6 +1210,9 @@ func (p *ParserGo) visitNodeComponentElement(state *parseGoState, n *html.Node)
 	fmt.Fprintf(&state.buildBuf, "vgin.BuildEnv.WireComponent(vgcomp)\n")
 	fmt.Fprintf(&state.buildBuf, "}\n")
 	fmt.Fprintf(&state.buildBuf, "vgin.BuildEnv.UseComponent(vgcompKey, vgcomp) // ensure we can use this in the cache next time around\n")
+	if state.keepAlive > 0 {
+		fmt.Fprintf(&state.buildBuf, "vgin.BuildEnv.KeepAlive(vgcomp) // inside vg-keep-alive\n")
+	}
 
 	// now that we have vgcomp with the right type and a correct value, we can declare the vg-var if specified
 	if vgv := vgVarExpr(n); vgv != "" {
@@ -1046,9 +1284,16 @@ func (p *ParserGo) visitNodeComponentElement(state *parseGoState, n *html.Node)
 	// your component as Something func(SomethingEvent) - still type-safe but very straightforward.
 	// So far it seems like the best approach.
 
-	eventMap, eventKeys := vgEventExprs(n)
-	for _, k := range eventKeys {
-		expr := eventMap[k]
+	// A method or func value can be bound directly, so @Something="c.HandleSomething" is the same as
+	// @Something="c.HandleSomething(event)".
+
+	eventKeys, eventExprs := vgEventExprs(n)
+	for i, k := range eventKeys {
+		expr := eventExprs[i]
+		if isFuncValueExpr(expr) {
+			fmt.Fprintf(&state.buildBuf, "vgcomp.%s = %s%sFunc(%s)\n", k, pkgPrefix, k, expr)
+			continue
+		}
 		// fmt.Fprintf(&state.buildBuf, "vgcomp.%s = func(event %s%sEvent){%s}\n", k, pkgPrefix, k, expr)
 		// switched to using interfaces
 		fmt.Fprintf(&state.buildBuf, "vgcomp.%s = %s%sFunc(func(event %s%sEvent){%s})\n", k, pkgPrefix, k, pkgPrefix, k, expr)
@@ -1058,9 +1303,11 @@ func (p *ParserGo) visitNodeComponentElement(state *parseGoState, n *html.Node)
 
 	// slots:
 
-	// scan children and see if it's default slot mode or vg-slot tags
+	// scan children and see if it's default slot mode or vg-slot tags, markup outside of
+	// vg-slot tags goes in the default slot
 	foundTagSlot, foundDefSlot := false, false
 	var foundTagSlotNames []string
+	var defSlotNodes []*html.Node
 	for childN := n.FirstChild; childN != nil; childN = childN.NextSibling {
 
 		// non-ws text means default slot
@@ -1068,11 +1315,13 @@ func (p *ParserGo) visitNodeComponentElement(state *parseGoState, n *html.Node)
 			if strings.TrimSpace(childN.Data) != "" {
 				foundDefSlot = true
 			}
+			defSlotNodes = append(defSlotNodes, childN)
 			continue
 		}
 
 		// ignore comments
 		if childN.Type == html.CommentNode {
+			defSlotNodes = append(defSlotNodes, childN)
 			continue
 		}
 
@@ -1087,18 +1336,17 @@ func (p *ParserGo) visitNodeComponentElement(state *parseGoState, n *html.Node)
 			if name != "" {
 				foundTagSlotNames = append(foundTagSlotNames, name)
 			}
+			if name == "DefaultSlot" {
+				return fmt.Errorf("in tag %q vg-slot name %q is used for markup outside of vg-slot tags, remove the vg-slot tag instead", n.Data, name)
+			}
 		} else {
 			foundDefSlot = true
+			defSlotNodes = append(defSlotNodes, childN)
 		}
 	}
 
 	// now process slot(s) appropriately according to format
-	switch {
-
-	case foundTagSlot && foundDefSlot:
-		return fmt.Errorf("in tag %q found both vg-slot and other markup, only one or the other is allowed", n.Data)
-
-	case foundTagSlot:
+	if foundTagSlot {
 
 		// NOTE:
 		// <vg-slot name="X"> will assign to vgcomp.X
@@ -1133,12 +1381,8 @@ func (p *ParserGo) visitNodeComponentElement(state *parseGoState, n *html.Node)
 				continue
 			}
 
-			if childN.Type != html.ElementNode { // should be impossible from foundTagSlot check above, just making sure
-				panic(fmt.Errorf("unexpected non-element found where vg-slot should be: %#v", childN))
-			}
-
-			if childN.Data != "vg-slot" { // should also be imposible
-				panic(fmt.Errorf("unexpected element found where vg-slot should be: %#v", childN))
+			if childN.Type != html.ElementNode || childN.Data != "vg-slot" { // default slot content, done below
+				continue
 			}
 
 			slotName := strings.TrimSpace(vgSlotName(childN))
@@ -1146,48 +1390,23 @@ func (p *ParserGo) visitNodeComponentElement(state *parseGoState, n *html.Node)
 				return fmt.Errorf("found vg-slot tag without a 'name' attribute, the name is required")
 			}
 
-			fmt.Fprintf(&state.buildBuf, "vgcomp.%s = vugu.NewBuilderFunc(func(vgin *vugu.BuildIn) (vgout *vugu.BuildOut) {\n", slotName)
-			fmt.Fprintf(&state.buildBuf, "vgn := &vugu.VGNode{Type:vugu.VGNodeType(%d)}\n", vugu.ElementNode)
-			fmt.Fprintf(&state.buildBuf, "vgout = &vugu.BuildOut{}\n")
-			fmt.Fprintf(&state.buildBuf, "vgout.Out = append(vgout.Out, vgn)\n")
-			fmt.Fprintf(&state.buildBuf, "vgparent := vgn; _ = vgparent\n")
-			fmt.Fprintf(&state.buildBuf, "\n")
-
-			// iterate over children and do the usual with each one
+			var slotNodes []*html.Node
 			for innerChildN := childN.FirstChild; innerChildN != nil; innerChildN = innerChildN.NextSibling {
-				err := p.visitDefaultByType(state, innerChildN)
-				if err != nil {
-					return err
-				}
+				slotNodes = append(slotNodes, innerChildN)
 			}
-
-			fmt.Fprintf(&state.buildBuf, "return\n")
-			fmt.Fprintf(&state.buildBuf, "})\n")
-
-		}
-
-	case foundDefSlot:
-		fmt.Fprintf(&state.buildBuf, "vgcomp.DefaultSlot = vugu.NewBuilderFunc(func(vgin *vugu.BuildIn) (vgout *vugu.BuildOut) {\n")
-		// vgn is the equivalent of a vg-template tag and becomes the contents of vgout.Out and the vgparent
-		fmt.Fprintf(&state.buildBuf, "vgn := &vugu.VGNode{Type:vugu.VGNodeType(%d)}\n", vugu.ElementNode)
-		fmt.Fprintf(&state.buildBuf, "vgout = &vugu.BuildOut{}\n")
-		fmt.Fprintf(&state.buildBuf, "vgout.Out = append(vgout.Out, vgn)\n")
-		fmt.Fprintf(&state.buildBuf, "vgparent := vgn; _ = vgparent\n")
-		fmt.Fprintf(&state.buildBuf, "\n")
-
-		// iterate over children and do the usual with each one
-		for childN := n.FirstChild; childN != nil; childN = childN.NextSibling {
-			err := p.visitDefaultByType(state, childN)
+			err := p.emitSlotBuilder(state, "vgcomp."+slotName, slotNodes)
 			if err != nil {
 				return err
 			}
-		}
 
-		fmt.Fprintf(&state.buildBuf, "return\n")
-		fmt.Fprintf(&state.buildBuf, "})\n")
+		}
+	}
 
-	default:
-		// nothing meaningful inside this component tag
+	if foundDefSlot {
+		err := p.emitSlotBuilder(state, "vgcomp.DefaultSlot", defSlotNodes)
+		if err != nil {
+			return err
+		}
 	}
 
 	// // keep track of contents for default slot
@@ -1224,6 +1443,12 @@ func (p *ParserGo) visitNodeComponentElement(state *parseGoState, n *html.Node)
 
 	fmt.Fprintf(&state.buildBuf, "vgout.Components = append(vgout.Components, vgcomp)\n")
 	fmt.Fprintf(&state.buildBuf, "vgn = &vugu.VGNode{Component:vgcomp}\n")
+	if keyExpr != "" {
+		fmt.Fprintf(&state.buildBuf, "vgn.SetKey(%s)\n", keyExpr)
+	}
+	if sel := vgPortalSelector(n); sel != "" {
+		fmt.Fprintf(&state.buildBuf, "vgn.Portal = %q\n", sel)
+	}
 	fmt.Fprintf(&state.buildBuf, "vgparent.AppendChild(vgn)\n")
 
 	return nil
@@ -8,7 +8,9 @@ import (
 	"go/token"
 	"io"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	// "github.com/vugu/vugu/internal/htmlx"
 
@@ -44,6 +46,8 @@ func staticVGAttr(inAttr []html.Attribute) (ret []vugu.VGAttribute) {
 		// case a.Key == "vg-for":
 		// case a.Key == "vg-key":
 		// case a.Key == "vg-html":
+		case a.Key == "vg-ref": // names the element for vugu.Focus
+			ret = append(ret, vugu.VGAttribute{Key: "data-vg-ref", Val: a.Val})
 		case strings.HasPrefix(a.Key, "vg-"):
 		case strings.HasPrefix(a.Key, "."):
 		case strings.HasPrefix(a.Key, ":"):
@@ -74,9 +78,98 @@ func vgVarExpr(n *html.Node) string {
 	return ""
 }
 
+// vgIfExpr returns the condition for outputting n, from vg-if and vg-can
 func vgIfExpr(n *html.Node) string {
+	var ife, perm string
 	for _, a := range n.Attr {
 		if a.Key == "vg-if" {
+			ife = a.Val
+		}
+		if a.Key == "vg-can" {
+			perm = a.Val
+		}
+	}
+	if perm == "" {
+		return ife
+	}
+	if ife == "" {
+		return fmt.Sprintf("vgin.Can(%q)", perm)
+	}
+	return fmt.Sprintf("(%s) && vgin.Can(%q)", ife, perm)
+}
+
+// vgCanDisablePermission returns the permission of vg-can.disable, without which n is disabled
+func vgCanDisablePermission(n *html.Node) string {
+	for _, a := range n.Attr {
+		if a.Key == "vg-can.disable" {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func vgShowExpr(n *html.Node) string {
+	for _, a := range n.Attr {
+		if a.Key == "vg-show" {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func vgFocusExpr(n *html.Node) string {
+	for _, a := range n.Attr {
+		if a.Key == "vg-focus" {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// vgModelExpr returns the expression of vg-model and the event which updates it: change for selects,
+// checkboxes and radio buttons and input for text.
+func vgModelExpr(n *html.Node) (expr, eventType string) {
+	for _, a := range n.Attr {
+		if a.Key == "vg-model" {
+			expr = a.Val
+		}
+	}
+	if expr == "" {
+		return "", ""
+	}
+	eventType = "input"
+	if n.Data == "select" {
+		eventType = "change"
+	}
+	for _, a := range n.Attr {
+		if a.Key == "type" && (a.Val == "checkbox" || a.Val == "radio") {
+			eventType = "change"
+		}
+	}
+	return expr, eventType
+}
+
+func vgScrollIntoViewExpr(n *html.Node) string {
+	for _, a := range n.Attr {
+		if a.Key == "vg-scroll-into-view" {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func vgScrollKeyExpr(n *html.Node) string {
+	for _, a := range n.Attr {
+		if a.Key == "vg-scroll-key" {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func vgPortalSelector(n *html.Node) string {
+	for _, a := range n.Attr {
+		if a.Key == "vg-portal" {
 			return a.Val
 		}
 	}
@@ -214,30 +307,149 @@ func jsCallbackVGAttrExpr(n *html.Node) (ret map[string]string) {
 	return ret
 }
 
-func vgDOMEventExprs(n *html.Node) (ret map[string]string, retKeys []string) {
+func vgDOMEventExprs(n *html.Node) (retKeys, retExprs []string) {
 	return vgEventExprs(n)
 }
 
-// extract "@event" stuff from a node
-func vgEventExprs(n *html.Node) (ret map[string]string, retKeys []string) {
-	var da []html.Attribute
-	// get attrs first
+// extract "@event" stuff from a node, in order and including any given more than once
+// (an element can have several handlers for the same event); vg-visible and vg-resize are
+// the same as @vgvisible and @vgresize
+func vgEventExprs(n *html.Node) (retKeys, retExprs []string) {
 	for _, a := range n.Attr {
 		if strings.HasPrefix(a.OrigKey, "@") {
-			da = append(da, a)
+			retKeys = append(retKeys, strings.TrimPrefix(a.OrigKey, "@"))
+			retExprs = append(retExprs, a.Val)
+		} else if a.Key == "vg-visible" || a.Key == "vg-resize" {
+			retKeys = append(retKeys, "vg"+strings.TrimPrefix(a.Key, "vg-"))
+			retExprs = append(retExprs, a.Val)
 		}
 	}
-	if len(da) == 0 { // don't allocate map if we don't have to
-		return
+	return
+}
+
+// domEventModifierNames maps the modifiers available in the @event.modifier syntax to the corresponding vugu.DOMEventModifiers.
+// The "capture" and "passive" modifiers are handled separately since they set fields of their own.
+var domEventModifierNames = map[string]string{
+	"prevent": "vugu.DOMEventModPrevent",
+	"stop":    "vugu.DOMEventModStop",
+	"self":    "vugu.DOMEventModSelf",
+	"ctrl":    "vugu.DOMEventModCtrl",
+	"shift":   "vugu.DOMEventModShift",
+	"alt":     "vugu.DOMEventModAlt",
+	"meta":    "vugu.DOMEventModMeta",
+	"enter":   "vugu.DOMEventModEnter",
+	"tab":     "vugu.DOMEventModTab",
+	"esc":     "vugu.DOMEventModEsc",
+	"space":   "vugu.DOMEventModSpace",
+	"delete":  "vugu.DOMEventModDelete",
+	"up":      "vugu.DOMEventModUp",
+	"down":    "vugu.DOMEventModDown",
+	"left":    "vugu.DOMEventModLeft",
+	"right":   "vugu.DOMEventModRight",
+	"middle":  "vugu.DOMEventModMiddle",
+
+	"selection": "vugu.DOMEventModSelection",
+	"dataset":   "vugu.DOMEventModDataset",
+	"form":      "vugu.DOMEventModForm",
+	"action":    "vugu.DOMEventModAction",
+}
+
+type domEventAttr struct {
+	global    string // "window" or "document" if specified
+	eventType string
+	capture   bool
+	passive   bool
+	modifiers []string // Go expressions for each vugu.DOMEventModifiers value, in the order given
+	debounce  time.Duration
+	throttle  time.Duration
+}
+
+// parseDOMEventKey parses the part of a DOM event attribute after the "@", e.g. "keydown.enter.prevent".
+// The event type may be prefixed with "window:" or "document:" to listen for events there instead of on the element.
+func parseDOMEventKey(k string) (ret domEventAttr, err error) {
+	parts := strings.Split(k, ".")
+	ret.eventType = parts[0]
+	if i := strings.IndexByte(ret.eventType, ':'); i >= 0 {
+		ret.global, ret.eventType = strings.ToLower(ret.eventType[:i]), ret.eventType[i+1:]
+		if ret.global != "window" && ret.global != "document" {
+			return ret, fmt.Errorf("event %q has unknown target %q, must be window or document", k, ret.global)
+		}
 	}
-	// make map as small as possible
-	ret = make(map[string]string, len(da))
-	for _, a := range da {
-		k := strings.TrimPrefix(a.OrigKey, "@")
-		retKeys = append(retKeys, k)
-		ret[k] = a.Val
+	if ret.eventType == "" {
+		return ret, fmt.Errorf("event %q is missing the event type", k)
+	}
+	for _, mod := range parts[1:] {
+		switch mod = strings.ToLower(mod); mod {
+		case "capture":
+			ret.capture = true
+		case "passive":
+			ret.passive = true
+		default:
+			if name, d, ok := parseEventRate(mod); ok {
+				if ret.debounce != 0 || ret.throttle != 0 {
+					return ret, fmt.Errorf("event %q can only be debounced or throttled once", k)
+				}
+				if d <= 0 {
+					return ret, fmt.Errorf("event %q has invalid %s interval in %q", k, name, mod)
+				}
+				if name == "debounce" {
+					ret.debounce = d
+				} else {
+					ret.throttle = d
+				}
+				continue
+			}
+			expr, ok := domEventModifierNames[mod]
+			if !ok {
+				return ret, fmt.Errorf("event %q has unknown modifier %q", k, mod)
+			}
+			ret.modifiers = append(ret.modifiers, expr)
+		}
+	}
+	return ret, nil
+}
+
+// Default intervals of the debounce and throttle event modifiers without one.
+const (
+	defaultDebounce = 300 * time.Millisecond
+	defaultThrottle = 100 * time.Millisecond
+)
+
+// parseEventRate parses the debounce and throttle event modifiers, e.g. "debounce" or "throttle-100ms",
+// ok is false for other modifiers.  An invalid interval returns a zero d.
+func parseEventRate(mod string) (name string, d time.Duration, ok bool) {
+	for _, name := range []string{"debounce", "throttle"} {
+		if mod == name {
+			if name == "debounce" {
+				return name, defaultDebounce, true
+			}
+			return name, defaultThrottle, true
+		}
+		if strings.HasPrefix(mod, name+"-") {
+			d, _ := time.ParseDuration(mod[len(name)+1:])
+			return name, d, true
+		}
+	}
+	return "", 0, false
+}
+
+// isFuncValueExpr returns true if expr is just a name or selector, e.g. "c.HandleSelect", which for a
+// component event is a func value rather than a statement.
+func isFuncValueExpr(expr string) bool {
+	e, err := parser.ParseExpr(strings.TrimSpace(expr))
+	if err != nil {
+		return false
+	}
+	for {
+		switch x := e.(type) {
+		case *ast.Ident:
+			return true
+		case *ast.SelectorExpr:
+			e = x.X
+		default:
+			return false
+		}
 	}
-	return
 }
 
 // var vgDOMParseExprRE = regexp.MustCompile(`^([a-zA-Z0-9_.]+)\((.*)\)$`)
@@ -280,6 +492,7 @@ func dedupImports(r io.Reader, w io.Writer, fname string) error {
 	// ast.Print(fset, f.Decls)
 	// f.Decls = f.Decls[1:]
 	dedupAstFileImports(f)
+	pruneAstFileImports(f, prunableImports)
 	ast.SortImports(fset, f)
 
 	err = printer.Fprint(w, fset, f)
@@ -337,3 +550,103 @@ func dedupAstFileImports(f *ast.File) {
 	f.Decls = outdecls
 
 }
+
+// prunableImports are the imports emitted into every generated file which are removed again if
+// nothing in the file uses them.  Just importing a package means its package-level variables and
+// init code are linked in, which for these adds size that can't otherwise be eliminated.
+var prunableImports = []string{"fmt", "reflect"}
+
+// pruneAstFileImports removes imports of the given paths which are not referenced anywhere in f, along with
+// the "var _ pkg.Type" declarations used to keep them from being reported as unused.
+func pruneAstFileImports(f *ast.File, paths []string) {
+
+	for _, path := range paths {
+
+		// find the name the package is imported as
+		name := path[strings.LastIndex(path, "/")+1:]
+		found := false
+		for _, ispec := range f.Imports {
+			if ispec.Path.Value != strconv.Quote(path) {
+				continue
+			}
+			if ispec.Name != nil {
+				name = ispec.Name.Name
+			}
+			found = true
+		}
+		if !found || name == "." || name == "_" {
+			continue
+		}
+
+		// isPlaceholder returns true for var _ name.Something
+		isPlaceholder := func(decl ast.Decl) bool {
+			genDecl, _ := decl.(*ast.GenDecl)
+			if genDecl == nil || genDecl.Tok != token.VAR || len(genDecl.Specs) != 1 {
+				return false
+			}
+			vspec := genDecl.Specs[0].(*ast.ValueSpec)
+			if len(vspec.Names) != 1 || vspec.Names[0].Name != "_" || len(vspec.Values) != 0 {
+				return false
+			}
+			sel, _ := vspec.Type.(*ast.SelectorExpr)
+			if sel == nil {
+				return false
+			}
+			x, _ := sel.X.(*ast.Ident)
+			return x != nil && x.Name == name
+		}
+
+		// look for any use other than the placeholder
+		used := false
+		for _, decl := range f.Decls {
+			if isPlaceholder(decl) {
+				continue
+			}
+			ast.Inspect(decl, func(n ast.Node) bool {
+				if sel, ok := n.(*ast.SelectorExpr); ok {
+					if x, ok := sel.X.(*ast.Ident); ok && x.Name == name && x.Obj == nil {
+						used = true
+					}
+				}
+				return !used
+			})
+			if used {
+				break
+			}
+		}
+		if used {
+			continue
+		}
+
+		// remove the import and placeholder
+		outdecls := make([]ast.Decl, 0, len(f.Decls))
+		for _, decl := range f.Decls {
+			if isPlaceholder(decl) {
+				continue
+			}
+			if genDecl, _ := decl.(*ast.GenDecl); genDecl != nil && genDecl.Tok == token.IMPORT {
+				outspecs := make([]ast.Spec, 0, len(genDecl.Specs))
+				for _, spec := range genDecl.Specs {
+					if spec.(*ast.ImportSpec).Path.Value != strconv.Quote(path) {
+						outspecs = append(outspecs, spec)
+					}
+				}
+				if len(outspecs) == 0 {
+					continue
+				}
+				genDecl.Specs = outspecs
+			}
+			outdecls = append(outdecls, decl)
+		}
+		f.Decls = outdecls
+
+		outimports := make([]*ast.ImportSpec, 0, len(f.Imports))
+		for _, ispec := range f.Imports {
+			if ispec.Path.Value != strconv.Quote(path) {
+				outimports = append(outimports, ispec)
+			}
+		}
+		f.Imports = outimports
+	}
+
+}
@@ -1,10 +1,14 @@
 package gen
 
 import (
+	"bytes"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vugu/html"
+	"github.com/vugu/html/atom"
 )
 
 func TestVgForExpr(t *testing.T) {
@@ -80,3 +84,102 @@ func TestVgForExpr(t *testing.T) {
 		})
 	}
 }
+
+func TestPruneAstFileImports(t *testing.T) {
+
+	assert := assert.New(t)
+
+	src := `package x
+
+import "fmt"
+import "reflect"
+import "strings"
+
+func f() string { return fmt.Sprint(strings.ToUpper("a")) }
+
+var _ fmt.Stringer
+var _ reflect.Type
+`
+
+	var buf bytes.Buffer
+	err := dedupImports(strings.NewReader(src), &buf, "x.go")
+	assert.NoError(err)
+	out := buf.String()
+
+	assert.Contains(out, `"fmt"`)
+	assert.Contains(out, "var _ fmt.Stringer")
+	assert.NotContains(out, `"reflect"`)
+	assert.NotContains(out, "reflect.Type")
+	assert.Contains(out, `"strings"`)
+}
+
+func TestParseDOMEventKey(t *testing.T) {
+	tests := []struct {
+		in            string
+		expectedRet   domEventAttr
+		expectedError string
+	}{
+		{in: "click", expectedRet: domEventAttr{eventType: "click"}},
+		{in: "keydown.enter", expectedRet: domEventAttr{eventType: "keydown", modifiers: []string{"vugu.DOMEventModEnter"}}},
+		{in: "click.Ctrl.stop", expectedRet: domEventAttr{eventType: "click", modifiers: []string{"vugu.DOMEventModCtrl", "vugu.DOMEventModStop"}}},
+		{in: "submit.prevent.capture", expectedRet: domEventAttr{eventType: "submit", capture: true, modifiers: []string{"vugu.DOMEventModPrevent"}}},
+		{in: "touchstart.passive", expectedRet: domEventAttr{eventType: "touchstart", passive: true}},
+		{in: "click.dataset.selection", expectedRet: domEventAttr{eventType: "click", modifiers: []string{"vugu.DOMEventModDataset", "vugu.DOMEventModSelection"}}},
+		{in: "submit.prevent.form", expectedRet: domEventAttr{eventType: "submit", modifiers: []string{"vugu.DOMEventModPrevent", "vugu.DOMEventModForm"}}},
+		{in: "window:resize", expectedRet: domEventAttr{global: "window", eventType: "resize"}},
+		{in: "document:keydown.esc", expectedRet: domEventAttr{global: "document", eventType: "keydown", modifiers: []string{"vugu.DOMEventModEsc"}}},
+		{in: "input.debounce-500ms", expectedRet: domEventAttr{eventType: "input", debounce: 500 * time.Millisecond}},
+		{in: "input.debounce", expectedRet: domEventAttr{eventType: "input", debounce: 300 * time.Millisecond}},
+		{in: "window:scroll.passive.throttle-1s", expectedRet: domEventAttr{global: "window", eventType: "scroll", passive: true, throttle: time.Second}},
+		{in: "input.debounce-soon", expectedError: `event "input.debounce-soon" has invalid debounce interval in "debounce-soon"`},
+		{in: "input.debounce.throttle", expectedError: `event "input.debounce.throttle" can only be debounced or throttled once`},
+		{in: "body:click", expectedError: `event "body:click" has unknown target "body", must be window or document`},
+		{in: "click.bogus", expectedError: `event "click.bogus" has unknown modifier "bogus"`},
+		{in: ".enter", expectedError: `event ".enter" is missing the event type`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			ret, err := parseDOMEventKey(tt.in)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedRet, ret)
+		})
+	}
+}
+
+func TestIsFuncValueExpr(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected bool
+	}{
+		{in: "c.HandleSelect", expected: true},
+		{in: " handleSelect ", expected: true},
+		{in: "c.list.HandleSelect", expected: true},
+		{in: "c.HandleSelect(event)", expected: false},
+		{in: "c.Selected = event.Value", expected: false},
+		{in: "c.items[0].Handle", expected: false},
+		{in: "log.Println(event); c.Count++", expected: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, isFuncValueExpr(tt.in), tt.in)
+	}
+}
+
+func TestVgEventExprs(t *testing.T) {
+	nodes, err := html.ParseFragment(strings.NewReader(`<div @click="c.A()" id="x" @click="c.B()" @keydown.enter="c.C()"></div>`),
+		&html.Node{Type: html.ElementNode, DataAtom: atom.Div, Data: "div"})
+	assert.NoError(t, err)
+	keys, exprs := vgEventExprs(nodes[0])
+	assert.Equal(t, []string{"click", "click", "keydown.enter"}, keys)
+	assert.Equal(t, []string{"c.A()", "c.B()", "c.C()"}, exprs)
+
+	nodes, err = html.ParseFragment(strings.NewReader(`<img vg-visible="c.Load(event)" vg-resize="c.Resized(event)">`),
+		&html.Node{Type: html.ElementNode, DataAtom: atom.Div, Data: "div"})
+	assert.NoError(t, err)
+	keys, exprs = vgEventExprs(nodes[0])
+	assert.Equal(t, []string{"vgvisible", "vgresize"}, keys)
+	assert.Equal(t, []string{"c.Load(event)", "c.Resized(event)"}, exprs)
+}
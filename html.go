@@ -14,6 +14,29 @@ func (h HTML) HTML() string {
 	return string(h)
 }
 
+// StaticHTML is like HTML but promises the content never changes for the element it is
+// set on, so the renderer only needs to set it when the element is created.  The code
+// generator uses it for static blocks of HTML.
+type StaticHTML string
+
+// HTML implements the HTMLer interface.
+func (h StaticHTML) HTML() string {
+	return string(h)
+}
+
+// HTMLSanitizer cleans HTML set with vg-html (from an HTMLer such as HTML) before a renderer outputs it,
+// so HTML from users cannot run scripts on the page.  See domrender.JSRenderer.HTMLSanitizer and package
+// vgsanitize.  StaticHTML is not sanitized as it comes from the component's own markup.
+type HTMLSanitizer interface {
+	SanitizeHTML(s string) string
+}
+
+// HTMLSanitizerFunc implements HTMLSanitizer as a function.
+type HTMLSanitizerFunc func(s string) string
+
+// SanitizeHTML implements the HTMLSanitizer interface.
+func (f HTMLSanitizerFunc) SanitizeHTML(s string) string { return f(s) }
+
 // NOTE: I'm bailing on this OptionalHTMLer thing because you can get the same
 // functionality with an explicit vg-if.  It's unclear how much benefit
 // it is to hide an element when you pass it a nil and if it's worth the effort
@@ -0,0 +1,41 @@
+// +build js
+
+package js
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAwait(t *testing.T) {
+
+	assert := assert.New(t)
+
+	promise := Global().Get("Promise")
+
+	v, err := Await(promise.Call("resolve", 42), nil)
+	assert.NoError(err)
+	assert.Equal(42, v.Int())
+
+	rejected := func() Value { return promise.Call("reject", Global().Get("Error").New("boom")) }
+
+	// the reason is the Error's Value by default
+	_, err = Await(rejected(), nil)
+	var jsErr Error
+	if assert.True(errors.As(err, &jsErr)) {
+		assert.Equal("boom", jsErr.Get("message").String())
+	}
+
+	_, err = Await(rejected(), func(reason Value) error { return errors.New("mapped: " + reason.Get("message").String()) })
+	assert.EqualError(err, "mapped: boom")
+
+	// NewDOMError takes the name and message of the reason, a reason which is not an object is the message
+	_, err = Await(rejected(), NewDOMError)
+	assert.Equal(&DOMError{Name: "Error", Message: "boom"}, err)
+	_, err = Await(promise.Call("reject", "gone"), NewDOMError)
+	assert.Equal(&DOMError{Message: "gone"}, err)
+	_, err = Await(promise.Call("reject"), NewDOMError)
+	assert.Equal(&DOMError{}, err)
+}
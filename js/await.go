@@ -0,0 +1,61 @@
+package js
+
+// Await waits for the promise p to settle and returns the value it was fulfilled with.  If it was
+// rejected the error is mapErr's for the reason, or an Error holding the reason if mapErr is nil (see
+// NewDOMError for the usual mapErr).
+// Like Value.Call, it is only functional in a JS environment.
+func Await(p Value, mapErr func(reason Value) error) (Value, error) {
+
+	type result struct {
+		v   Value
+		err error
+	}
+	ch := make(chan result, 1)
+
+	then := FuncOf(func(this Value, args []Value) interface{} {
+		ch <- result{v: args[0]}
+		return nil
+	})
+	defer then.Release()
+	catch := FuncOf(func(this Value, args []Value) interface{} {
+		var err error = Error{Value: args[0]}
+		if mapErr != nil {
+			err = mapErr(args[0])
+		}
+		ch <- result{err: err}
+		return nil
+	})
+	defer catch.Release()
+
+	p.Call("then", then, catch)
+	r := <-ch
+	return r.v, r.err
+}
+
+// DOMError is an error a promise was rejected with, usually a DOMException, by its name and message,
+// e.g. Name is "NotAllowedError" when the user denied permission.
+type DOMError struct {
+	Name    string
+	Message string
+}
+
+// Error implements error.
+func (e *DOMError) Error() string {
+	return e.Name + ": " + e.Message
+}
+
+// NewDOMError returns the *DOMError for the reason a promise was rejected with, for use as Await's mapErr.
+func NewDOMError(reason Value) error {
+	if reason.Type() != TypeObject {
+		return &DOMError{Message: OptString(reason)}
+	}
+	return &DOMError{Name: OptString(reason.Get("name")), Message: OptString(reason.Get("message"))}
+}
+
+// OptString returns the string v holds, or "" if it is not a string (e.g. undefined or null).
+func OptString(v Value) string {
+	if v.Type() != TypeString {
+		return ""
+	}
+	return v.String()
+}
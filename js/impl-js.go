@@ -172,7 +172,7 @@ func (v Value) Get(p string) Value {
 }
 
 func (v Value) Set(p string, x interface{}) {
-	sjs.Value(v).Set(p, x)
+	sjs.Value(v).Set(p, fixArgsToSjs([]interface{}{x})[0])
 }
 
 func (v Value) Index(i int) Value {
@@ -180,7 +180,7 @@ func (v Value) Index(i int) Value {
 }
 
 func (v Value) SetIndex(i int, x interface{}) {
-	sjs.Value(v).SetIndex(i, x)
+	sjs.Value(v).SetIndex(i, fixArgsToSjs([]interface{}{x})[0])
 }
 
 func (v Value) Length() int {
@@ -0,0 +1,33 @@
+// +build js
+
+package js
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSetWrapped checks that a Value or Func is set as the JS value it wraps, as it is when passed to Call.
+func TestSetWrapped(t *testing.T) {
+
+	assert := assert.New(t)
+
+	is := func(a, b Value) bool { return Global().Get("Object").Call("is", a, b).Bool() }
+
+	inner := Global().Get("Object").New()
+	f := FuncOf(func(this Value, args []Value) interface{} { return 7 })
+	defer f.Release()
+
+	obj := Global().Get("Object").New()
+	obj.Set("inner", inner)
+	obj.Set("f", f)
+	assert.True(is(inner, obj.Get("inner")))
+	assert.Equal(7, obj.Call("f").Int())
+
+	arr := Global().Get("Array").New()
+	arr.SetIndex(0, inner)
+	arr.SetIndex(1, f)
+	assert.True(is(inner, arr.Index(0)))
+	assert.Equal(7, arr.Index(1).Invoke().Int())
+}
@@ -40,10 +40,12 @@ func invokeInit(c interface{}, eventEnv EventEnv) {
 // ComputeCtx is the context passed to a Compute callback.
 type ComputeCtx interface {
 	EventEnv() EventEnv
+	Inject(key interface{}) (value interface{}, ok bool) // see BuildIn.Inject
 }
 
 type computeCtx struct {
 	eventEnv EventEnv
+	env      *BuildEnv
 }
 
 // EventEnv implements ComputeCtx
@@ -51,6 +53,11 @@ func (c *computeCtx) EventEnv() EventEnv {
 	return c.eventEnv
 }
 
+// Inject implements ComputeCtx
+func (c *computeCtx) Inject(key interface{}) (value interface{}, ok bool) {
+	return c.env.inject(key)
+}
+
 type computer0 interface {
 	Compute()
 }
@@ -58,11 +65,11 @@ type computer1 interface {
 	Compute(ctx ComputeCtx)
 }
 
-func invokeCompute(c interface{}, eventEnv EventEnv) {
+func invokeCompute(c interface{}, e *BuildEnv) {
 	if i, ok := c.(computer0); ok {
 		i.Compute()
 	} else if i, ok := c.(computer1); ok {
-		i.Compute(&computeCtx{eventEnv: eventEnv})
+		i.Compute(&computeCtx{eventEnv: e.eventEnv, env: e})
 	}
 }
 
@@ -0,0 +1,52 @@
+package liverender
+
+// ClientScript is served by Handler for requests other than WebSocket connections.  It connects
+// to the URL it was loaded from, runs the helper script the server sends (unless the page has loaded
+// it already, see domrender.HelperScriptHandler) and relays events back.
+const ClientScript = `(function () {
+	var url = document.currentScript.src.replace(/^http/, "ws");
+	var ws = new WebSocket(url);
+	ws.binaryType = "arraybuffer";
+	var warned = false;
+	ws.onmessage = function (e) {
+		if (typeof e.data !== "string") {
+			var buf = new Uint8Array(e.data);
+			window.vuguGetRenderArray(buf.length).set(buf);
+			window.vuguRender();
+			return;
+		}
+		var m = JSON.parse(e.data);
+		switch (m.vugu) {
+		case "init":
+			if (!window.vuguRender) {
+				(0, eval)(m.script);
+			} else if (window.vuguHelperVersion !== m.version) {
+				console.error("vugu: helper script on the page is version " + window.vuguHelperVersion + ", server expects " + m.version);
+			}
+			window.vuguSetEventHandler(function (buf) {
+				// only the payload, the buffer is reused by the helper script
+				var n = new DataView(buf.buffer, buf.byteOffset, buf.byteLength).getUint32(0) + 4;
+				ws.send(buf.slice(0, n));
+			});
+			window.vuguSetCallbackHandler(function () {
+				if (!warned) {
+					warned = true;
+					console.warn("vugu: vg-js-create and vg-js-populate are not supported when rendering from the server");
+				}
+			});
+			window.vuguSetAnimationFrameHandler(function () {
+				ws.send('{"vugu":"frame"}');
+			});
+			break;
+		case "call":
+			if (/^vugu[A-Z]/.test(m.name) && typeof window[m.name] === "function") {
+				window[m.name].apply(window, m.args || []);
+			}
+			break;
+		}
+	};
+	ws.onclose = function () {
+		console.warn("vugu: connection to the server closed, reload the page to reconnect");
+	};
+})();
+`
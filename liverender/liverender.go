@@ -0,0 +1,183 @@
+/*
+Package liverender runs components on the server and renders them in the browser over a WebSocket,
+so a Vugu app can be used without shipping WebAssembly to the browser at all.
+
+For each page a thin client script opens a WebSocket connection.  The components for that page are built
+and rendered on the server by a domrender.JSRenderer, the same as in the browser, except the instructions
+it produces are sent over the connection to the client, which applies them to the DOM with the usual helper
+script.  DOM events are sent back and handled on the server.
+
+	http.Handle("/live", &liverender.Handler{
+		MountPointSelector: "#app",
+		Setup: func(r *http.Request, buildEnv *vugu.BuildEnv, eventEnv vugu.EventEnv) vugu.Builder {
+			return &Root{}
+		},
+	})
+
+and in the page:
+
+	<div id="app"></div>
+	<script src="/live"></script>
+
+Each connection has its own components, which are discarded when the connection closes (the client does
+not reconnect by itself, and state is not kept across connections).  Every event involves a round trip
+to the server, so this works best for apps with modest interactivity on a good connection.
+
+As with domrender.WorkerTransport, features which need the page's JS objects from Go do not work:
+vg-js-create and vg-js-populate, the JSEvent and similar methods of DOMEvent (use event modifiers
+such as @click.prevent instead of PreventDefault) and DispatchCustomEvent.
+*/
+package liverender
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/vugu/vugu"
+	"github.com/vugu/vugu/domrender"
+)
+
+// Handler serves the client script and its WebSocket connections, both at the same path.
+type Handler struct {
+	// MountPointSelector is the element the root component is rendered into, as for domrender.New.
+	MountPointSelector string
+
+	// Setup is called for each connection and returns the root component, in the same way as vuguSetup
+	// in a wasm program.  The request is the one for the WebSocket connection, with the page's cookies.
+	Setup func(r *http.Request, buildEnv *vugu.BuildEnv, eventEnv vugu.EventEnv) vugu.Builder
+
+	// CheckOrigin returns true if a connection should be accepted.  If nil connections are
+	// only accepted from pages on the same host, so other sites cannot connect on a user's behalf.
+	CheckOrigin func(r *http.Request) bool
+
+	// OnError is set as the renderer's OnError, see domrender.JSRenderer.  If nil errors are logged.
+	OnError func(err error)
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if !isUpgrade(r) {
+		w.Header().Set("Content-Type", "application/javascript")
+		w.Write([]byte(ClientScript))
+		return
+	}
+
+	checkOrigin := h.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	conn, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	if err := h.run(r, conn); err != nil {
+		log.Printf("liverender: %v", err)
+	}
+}
+
+// run builds and renders until the connection is closed.
+func (h *Handler) run(r *http.Request, conn *wsConn) error {
+
+	renderer, err := domrender.NewWithTransport(h.MountPointSelector, &transport{conn: conn})
+	if err != nil {
+		return err
+	}
+	defer renderer.Release()
+	renderer.OnError = h.OnError
+
+	eventEnv := renderer.EventEnv()
+	buildEnv, err := vugu.NewBuildEnv(eventEnv)
+	if err != nil {
+		return err
+	}
+	root := h.Setup(r, buildEnv, eventEnv)
+
+	for ok := true; ok; ok = renderer.EventWait() {
+
+		// unlike in the browser events are handled concurrently, so hold the lock while building
+		eventEnv.RLock()
+		buildResults := buildEnv.RunBuild(root)
+		eventEnv.RUnlock()
+
+		err = renderer.Render(buildResults)
+		if err != nil {
+			// most likely the connection went away
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// transport implements domrender.Transport over a websocket.  Instructions are sent as binary
+// messages, and events come back the same way.  Everything else is JSON in text messages.
+type transport struct {
+	conn *wsConn
+}
+
+type message struct {
+	Vugu    string        `json:"vugu"`
+	Script  string        `json:"script,omitempty"`
+	Version string        `json:"version,omitempty"`
+	Name    string        `json:"name,omitempty"`
+	Args    []interface{} `json:"args,omitempty"`
+}
+
+// Init implements domrender.Transport.
+func (t *transport) Init(script string, h domrender.TransportHandlers) error {
+
+	if err := t.writeJSON(message{Vugu: "init", Script: script, Version: domrender.HelperScriptVersion()}); err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			op, data, err := t.conn.ReadMessage()
+			if err != nil {
+				t.conn.Close()
+				h.Close()
+				return
+			}
+			if op == opBinary {
+				h.Event(data)
+				continue
+			}
+			var m message
+			if json.Unmarshal(data, &m) == nil && m.Vugu == "frame" {
+				h.Frame()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Render implements domrender.Transport.
+func (t *transport) Render(buf []byte) error {
+	return t.conn.WriteMessage(opBinary, buf)
+}
+
+// Call implements domrender.Transport.
+func (t *transport) Call(name string, args ...interface{}) {
+	// a failure shows up as an error from the next Render
+	t.writeJSON(message{Vugu: "call", Name: name, Args: args})
+}
+
+func (t *transport) writeJSON(m message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return t.conn.WriteMessage(opText, b)
+}
+
+var _ domrender.Transport = &transport{}
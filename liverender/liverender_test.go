@@ -0,0 +1,193 @@
+package liverender
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vugu/vugu"
+)
+
+type testRoot struct {
+	count int
+}
+
+func (c *testRoot) Build(vgin *vugu.BuildIn) *vugu.BuildOut {
+	n := &vugu.VGNode{Type: vugu.ElementNode, Data: "button"}
+	n.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: fmt.Sprintf("clicked %d times", c.count)})
+	n.DOMEventHandlerSpecList = append(n.DOMEventHandlerSpecList, vugu.DOMEventHandlerSpec{
+		EventType: "click",
+		Func:      func(event vugu.DOMEvent) { c.count++ },
+	})
+	return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+}
+
+// testClient is just enough of a websocket client to talk to Handler
+type testClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dial(t *testing.T, srv *httptest.Server) *testClient {
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	fmt.Fprintf(conn, "GET /live HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\nOrigin: %s\r\n\r\n", strings.TrimPrefix(srv.URL, "http://"), key, srv.URL)
+
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("unexpected status %s", res.Status)
+	}
+	if got := res.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %q", got)
+	}
+	return &testClient{conn: conn, br: br}
+}
+
+func (c *testClient) read(t *testing.T) (byte, []byte) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(c.br, hdr[:]); err != nil {
+		t.Fatal(err)
+	}
+	l := int(hdr[1] & 0x7F)
+	switch l {
+	case 126:
+		var b [2]byte
+		io.ReadFull(c.br, b[:])
+		l = int(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		io.ReadFull(c.br, b[:])
+		l = int(binary.BigEndian.Uint64(b[:]))
+	}
+	data := make([]byte, l)
+	if _, err := io.ReadFull(c.br, data); err != nil {
+		t.Fatal(err)
+	}
+	return hdr[0] & 0x0F, data
+}
+
+func (c *testClient) write(t *testing.T, op byte, data []byte) {
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{0x80 | op, 0x80 | 126, byte(len(data) >> 8), byte(len(data))}
+	frame = append(frame, mask...)
+	for i, b := range data {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readRender reads messages, answering animation frame requests, until a render containing want.
+func (c *testClient) readRender(t *testing.T, want string) {
+	for {
+		op, data := c.read(t)
+		if op == opText {
+			var m message
+			json.Unmarshal(data, &m)
+			if m.Vugu == "call" && m.Name == "vuguRequestAnimationFrame" {
+				c.write(t, opText, []byte(`{"vugu":"frame"}`))
+			}
+			continue
+		}
+		if bytes.Contains(data, []byte(want)) {
+			return
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+
+	srv := httptest.NewServer(&Handler{
+		MountPointSelector: "#app",
+		Setup: func(r *http.Request, buildEnv *vugu.BuildEnv, eventEnv vugu.EventEnv) vugu.Builder {
+			return &testRoot{}
+		},
+	})
+	defer srv.Close()
+
+	// a normal request gets the client script
+	res, err := http.Get(srv.URL + "/live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if string(b) != ClientScript {
+		t.Errorf("unexpected script: %s", b)
+	}
+
+	c := dial(t, srv)
+	defer c.conn.Close()
+
+	op, data := c.read(t)
+	var m message
+	if op != opText || json.Unmarshal(data, &m) != nil || m.Vugu != "init" || !strings.Contains(m.Script, "vuguRender") {
+		t.Fatalf("expected init message, got %d %.100q", op, data)
+	}
+
+	c.readRender(t, "clicked 0 times")
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"v":             1,
+		"position_id":   "0",
+		"event_type":    "click",
+		"capture":       false,
+		"passive":       false,
+		"modifiers":     0,
+		"global_target": "",
+		"event_summary": map[string]interface{}{"type": "click"},
+	})
+	event := make([]byte, 4, 4+len(payload))
+	binary.BigEndian.PutUint32(event, uint32(len(payload)))
+	c.write(t, opBinary, append(event, payload...))
+
+	c.readRender(t, "clicked 1 times")
+
+	// close from the client is echoed
+	c.write(t, opClose, []byte{0x03, 0xE8})
+	if op, _ := c.read(t); op != opClose {
+		t.Errorf("expected close, got %d", op)
+	}
+}
+
+func TestHandlerOrigin(t *testing.T) {
+
+	srv := httptest.NewServer(&Handler{Setup: func(r *http.Request, buildEnv *vugu.BuildEnv, eventEnv vugu.EventEnv) vugu.Builder {
+		return &testRoot{}
+	}})
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL+"/live", nil)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Origin", "https://example.com")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("expected forbidden, got %s", res.Status)
+	}
+}
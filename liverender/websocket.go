@@ -0,0 +1,241 @@
+package liverender
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// A minimal server side WebSocket (RFC 6455) implementation, just what is needed to talk to the client script.
+
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// websocket opcodes
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// maxMessageSize limits messages from clients, event payloads are much smaller than this
+const maxMessageSize = 1 << 20
+
+var errBadFrame = errors.New("liverender: invalid websocket frame")
+
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	wmu  sync.Mutex // writes come from the render loop and the read loop
+}
+
+// isUpgrade returns true if r asks for a websocket connection.
+func isUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") && headerHasToken(r.Header, "Connection", "upgrade")
+}
+
+// sameOrigin returns true if r has no Origin header (not from a browser) or it matches the host requested.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// upgrade performs the websocket handshake, responding with an error if it can't.
+func upgrade(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+
+	if r.Method != "GET" || !isUpgrade(r) {
+		http.Error(w, "websocket connection expected", http.StatusBadRequest)
+		return nil, errors.New("liverender: not a websocket request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("liverender: unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("liverender: missing Sec-WebSocket-Key")
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, errors.New("liverender: ResponseWriter does not implement http.Hijacker")
+	}
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	brw.WriteString(acceptKey(key))
+	brw.WriteString("\r\n\r\n")
+	if err := brw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &wsConn{conn: conn, br: brw.Reader}, nil
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	io.WriteString(h, key+wsGUID)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadMessage returns the next text or binary message, answering pings along the way.
+// A close from the client returns io.EOF.
+func (c *wsConn) ReadMessage() (op byte, data []byte, err error) {
+
+	inMessage := false
+	for {
+		fop, fin, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch fop {
+		case opPing:
+			if err := c.WriteMessage(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			// echo the status code back, then we're done
+			if len(payload) > 2 {
+				payload = payload[:2]
+			}
+			c.WriteMessage(opClose, payload)
+			return 0, nil, io.EOF
+		case opText, opBinary:
+			if inMessage {
+				return 0, nil, errBadFrame
+			}
+			inMessage = true
+			op = fop
+		case opContinuation:
+			if !inMessage {
+				return 0, nil, errBadFrame
+			}
+		default:
+			return 0, nil, errBadFrame
+		}
+
+		if len(data)+len(payload) > maxMessageSize {
+			return 0, nil, errors.New("liverender: websocket message too large")
+		}
+		data = append(data, payload...)
+		if fin {
+			return op, data, nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (op byte, fin bool, payload []byte, err error) {
+
+	var hdr [2]byte
+	if _, err := io.ReadFull(c.br, hdr[:]); err != nil {
+		return 0, false, nil, err
+	}
+	fin = hdr[0]&0x80 != 0
+	op = hdr[0] & 0x0F
+	if hdr[0]&0x70 != 0 || hdr[1]&0x80 == 0 { // no extensions, and clients must mask
+		return 0, false, nil, errBadFrame
+	}
+
+	length := uint64(hdr[1] & 0x7F)
+	switch length {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return 0, false, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return 0, false, nil, err
+		}
+		length = binary.BigEndian.Uint64(b[:])
+	}
+	if op >= opClose && (!fin || length > 125) {
+		return 0, false, nil, errBadFrame
+	}
+	if length > maxMessageSize {
+		return 0, false, nil, errors.New("liverender: websocket message too large")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return 0, false, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return 0, false, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return op, fin, payload, nil
+}
+
+// WriteMessage writes data as a single frame.
+func (c *wsConn) WriteMessage(op byte, data []byte) error {
+
+	hdr := make([]byte, 2, 10+len(data))
+	hdr[0] = 0x80 | op
+	switch l := len(data); {
+	case l < 126:
+		hdr[1] = byte(l)
+	case l <= 0xFFFF:
+		hdr[1] = 126
+		hdr = append(hdr, byte(l>>8), byte(l))
+	default:
+		hdr[1] = 127
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(l))
+		hdr = append(hdr, b[:]...)
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err := c.conn.Write(append(hdr, data...))
+	return err
+}
+
+// Close closes the underlying connection.
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
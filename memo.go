@@ -0,0 +1,50 @@
+package vugu
+
+// Memo caches a value derived from other values, such as a filtered and sorted list, so it is only
+// computed again when they change rather than on every build.  The zero value is ready to use, typically
+// as a field of the component:
+//
+//	type ItemList struct {
+//		Items  []Item
+//		Filter string
+//		shown  vugu.Memo
+//	}
+//
+//	func (c *ItemList) Shown() []Item {
+//		return c.shown.Get(func() interface{} {
+//			return filterAndSort(c.Items, c.Filter)
+//		}, &c.Items, &c.Filter).([]Item)
+//	}
+//
+// Dependencies are checked for changes using a ModTracker, see ModTracker.ModCheckAll for the types supported.
+type Memo struct {
+	mt    ModTracker
+	value interface{}
+	valid bool
+}
+
+// Get returns the value returned by fn, calling it on the first call and after that only if any of deps
+// (pointers, as for ModTracker.ModCheckAll) have changed since the previous call or Invalidate was called.
+func (m *Memo) Get(fn func() interface{}, deps ...interface{}) interface{} {
+	m.mt.TrackNext()
+	if m.mt.ModCheckAll(deps...) || !m.valid {
+		m.value = fn()
+		m.valid = true
+	}
+	return m.value
+}
+
+// Invalidate makes the next Get call fn whether or not its dependencies have changed, for when the
+// value depends on something which is not one of them.  Like other changes to be rendered it should
+// be made with the EventEnv write lock held, e.g. from an event handler, see also InvalidateRender.
+func (m *Memo) Invalidate() {
+	m.valid = false
+}
+
+// InvalidateRender calls Invalidate with the eventEnv write lock held and requests a render,
+// for use from other goroutines.
+func (m *Memo) InvalidateRender(eventEnv EventEnv) {
+	eventEnv.Lock()
+	defer eventEnv.UnlockRender()
+	m.Invalidate()
+}
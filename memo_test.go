@@ -0,0 +1,59 @@
+package vugu
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemo(t *testing.T) {
+
+	assert := assert.New(t)
+
+	items := []string{"pear", "apple", "plum"}
+	filter := "p"
+
+	var m Memo
+	calls := 0
+	shown := func() []string {
+		return m.Get(func() interface{} {
+			calls++
+			var ret []string
+			for _, it := range items {
+				if strings.HasPrefix(it, filter) {
+					ret = append(ret, it)
+				}
+			}
+			sort.Strings(ret)
+			return ret
+		}, &items, &filter).([]string)
+	}
+
+	assert.Equal([]string{"pear", "plum"}, shown())
+	assert.Equal([]string{"pear", "plum"}, shown())
+	assert.Equal(1, calls)
+
+	filter = "a"
+	assert.Equal([]string{"apple"}, shown())
+	assert.Equal(2, calls)
+
+	items = append(items, "apricot")
+	assert.Equal([]string{"apple", "apricot"}, shown())
+	assert.Equal(3, calls)
+	shown()
+	assert.Equal(3, calls)
+
+	m.Invalidate()
+	shown()
+	assert.Equal(4, calls)
+
+	var mu sync.RWMutex
+	renderCh := make(chan bool, 1)
+	m.InvalidateRender(NewEventEnvImpl(&mu, renderCh))
+	assert.True(<-renderCh)
+	shown()
+	assert.Equal(5, calls)
+}
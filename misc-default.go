@@ -7,3 +7,8 @@ import "reflect"
 func rvIsZero(rv reflect.Value) bool {
 	return rv.IsZero()
 }
+
+// funcID returns a value identifying the code of function f
+func funcID(f ComponentFunc) uint64 {
+	return uint64(reflect.ValueOf(f).Pointer())
+}
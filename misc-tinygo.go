@@ -8,3 +8,9 @@ func rvIsZero(rv reflect.Value) bool {
 	// TODO: figure out how to do this in Tinygo
 	return false
 }
+
+func funcID(f ComponentFunc) uint64 {
+	// TODO: figure out how to do this in Tinygo, for now components made by
+	// different functions in the same position are not told apart
+	return 0
+}
@@ -0,0 +1,224 @@
+package vugu
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/vugu/vjson"
+)
+
+// BindModel sets the state of form element n from the Go value ptr points to, for vg-model, e.g.
+// <select vg-model="c.Color">.  Code generated for vg-model calls it once the children of n are built.
+// The supported elements and types are:
+//
+//	<select>                   *string, the value of the selected option
+//	<select multiple>          *[]string, the values of the selected options
+//	<input type="checkbox">    *bool, or *[]string which has its value when checked
+//	<input type="radio">       *string, checked when it equals its value
+//	<input>, <textarea>        *string
+//
+// Other combinations panic, as for other errors in generated code.  The value of an option is
+// its value attribute, or else its text, as in the browser.
+func BindModel(n *VGNode, ptr interface{}) {
+
+	switch n.Data {
+
+	case "select":
+		switch v := ptr.(type) {
+		case *string:
+			// the select's value property selects the option with that value or none, it is set
+			// again by the renderer once the options are synced; the selected attribute is for
+			// static rendering
+			n.Prop = append(n.Prop, VGProperty{Key: "value", JSONVal: modelJSON(*v)})
+			found := false
+			walkOptions(n, func(opt *VGNode) {
+				opt.setModelAttr("selected", !found && optionValue(opt) == *v)
+				found = found || optionValue(opt) == *v
+			})
+		case *[]string:
+			sel := make(map[string]bool, len(*v))
+			for _, s := range *v {
+				sel[s] = true
+			}
+			walkOptions(n, func(opt *VGNode) {
+				on := sel[optionValue(opt)]
+				opt.setModelAttr("selected", on)
+				opt.Prop = append(opt.Prop, VGProperty{Key: "selected", JSONVal: modelJSON(on)})
+			})
+		default:
+			panic(fmt.Errorf("vg-model on <select> must be a *string or *[]string, not %T", ptr))
+		}
+
+	case "input", "textarea":
+		switch modelAttr(n, "type") {
+		case "checkbox":
+			switch v := ptr.(type) {
+			case *bool:
+				n.setModelAttr("checked", *v)
+			case *[]string:
+				val := modelAttr(n, "value")
+				checked := false
+				for _, s := range *v {
+					if s == val {
+						checked = true
+						break
+					}
+				}
+				n.setModelAttr("checked", checked)
+			default:
+				panic(fmt.Errorf("vg-model on a checkbox must be a *bool or *[]string, not %T", ptr))
+			}
+		case "radio":
+			v, ok := ptr.(*string)
+			if !ok {
+				panic(fmt.Errorf("vg-model on a radio button must be a *string, not %T", ptr))
+			}
+			n.setModelAttr("checked", *v == modelAttr(n, "value"))
+		default:
+			v, ok := ptr.(*string)
+			if !ok {
+				panic(fmt.Errorf("vg-model on <%s> must be a *string, not %T", n.Data, ptr))
+			}
+			// the value attribute is set as a property by the renderer, which keeps the caret and does not
+			// interrupt IME composition
+			n.removeAttr("value")
+			n.Attr = append(n.Attr, VGAttribute{Key: "value", Val: *v})
+		}
+
+	default:
+		panic(fmt.Errorf("vg-model is not supported on <%s>", n.Data))
+	}
+}
+
+// ModelChanged updates the Go value ptr points to from the form element of the event, for vg-model.
+// It is the handler of the change event (input event for text) which vg-model adds, see BindModel.
+func ModelChanged(e DOMEvent, ptr interface{}) {
+
+	tag := strings.ToLower(e.PropString("target", "tagName"))
+	typ := strings.ToLower(e.PropString("target", "type"))
+	val := e.PropString("target", "value")
+
+	switch {
+
+	case tag == "select":
+		switch v := ptr.(type) {
+		case *string:
+			*v = val
+		case *[]string:
+			*v = EventSelectedValues(e)
+		}
+
+	case tag == "input" && typ == "checkbox":
+		checked := e.PropBool("target", "checked")
+		switch v := ptr.(type) {
+		case *bool:
+			*v = checked
+		case *[]string:
+			l := (*v)[:0:0]
+			for _, s := range *v {
+				if s != val {
+					l = append(l, s)
+				}
+			}
+			if checked {
+				l = append(l, val)
+			}
+			*v = l
+		}
+
+	case tag == "input" && typ == "radio":
+		if v, ok := ptr.(*string); ok && e.PropBool("target", "checked") {
+			*v = val
+		}
+
+	default:
+		if v, ok := ptr.(*string); ok {
+			*v = val
+		}
+	}
+}
+
+// EventSelectedValues returns the values of the selected options of the select element of an event,
+// e.g. the change event of a <select multiple>, in document order.
+func EventSelectedValues(e DOMEvent) []string {
+	l, ok := e.Prop("target", "selectedValues").([]interface{})
+	if !ok {
+		return nil
+	}
+	ret := make([]string, 0, len(l))
+	for _, v := range l {
+		s, _ := v.(string)
+		ret = append(ret, s)
+	}
+	return ret
+}
+
+// setModelAttr adds or removes the boolean attribute key, which the renderer also sets as a property.
+func (n *VGNode) setModelAttr(key string, on bool) {
+	n.removeAttr(key)
+	if on {
+		n.Attr = append(n.Attr, VGAttribute{Key: key})
+	}
+}
+
+func (n *VGNode) removeAttr(key string) {
+	for i := 0; i < len(n.Attr); i++ {
+		if n.Attr[i].Key == key {
+			n.Attr = append(n.Attr[:i:i], n.Attr[i+1:]...)
+			i--
+		}
+	}
+}
+
+// walkOptions calls fn for each option within n, including those in optgroups and templates.
+func walkOptions(n *VGNode, fn func(opt *VGNode)) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != ElementNode {
+			continue
+		}
+		if c.Data == "option" {
+			fn(c)
+		} else if c.Data == "optgroup" || c.IsTemplate() {
+			walkOptions(c, fn)
+		}
+	}
+}
+
+// optionValue returns the value of an option: its value attribute or else its text with the white space
+// collapsed.
+func optionValue(opt *VGNode) string {
+	for _, a := range opt.Attr {
+		if a.Key == "value" {
+			return a.Val
+		}
+	}
+	if opt.InnerHTML != nil {
+		return strings.Join(strings.Fields(html.UnescapeString(*opt.InnerHTML)), " ")
+	}
+	var sb strings.Builder
+	for c := opt.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == TextNode {
+			sb.WriteString(c.Data)
+		}
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// modelAttr returns the value of attribute key of n, empty if it is not set.
+func modelAttr(n *VGNode, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func modelJSON(v interface{}) []byte {
+	b, err := vjson.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
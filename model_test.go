@@ -0,0 +1,100 @@
+package vugu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindModel(t *testing.T) {
+
+	assert := assert.New(t)
+
+	option := func(val, text string) *VGNode {
+		opt := &VGNode{Type: ElementNode, Data: "option"}
+		if val != "" {
+			opt.Attr = append(opt.Attr, VGAttribute{Key: "value", Val: val})
+		}
+		opt.AppendChild(&VGNode{Type: TextNode, Data: text})
+		return opt
+	}
+	selectNode := func() *VGNode {
+		n := &VGNode{Type: ElementNode, Data: "select"}
+		n.AppendChild(option("r", "Red"))
+		g := &VGNode{Type: ElementNode, Data: "optgroup"}
+		g.AppendChild(option("", " Light  blue "))
+		n.AppendChild(g)
+		return n
+	}
+
+	// single select
+	color := "Light blue"
+	n := selectNode()
+	BindModel(n, &color)
+	assert.Equal([]VGProperty{{Key: "value", JSONVal: []byte(`"Light blue"`)}}, n.Prop)
+	assert.Empty(n.FirstChild.Attr[1:])
+	assert.Equal([]VGAttribute{{Key: "selected"}}, n.LastChild.FirstChild.Attr)
+
+	// multiple
+	tags := []string{"r"}
+	n = selectNode()
+	BindModel(n, &tags)
+	assert.Equal([]VGProperty{{Key: "selected", JSONVal: []byte(`true`)}}, n.FirstChild.Prop)
+	assert.Equal([]VGProperty{{Key: "selected", JSONVal: []byte(`false`)}}, n.LastChild.FirstChild.Prop)
+
+	// checkboxes and text
+	done := true
+	n = &VGNode{Type: ElementNode, Data: "input", Attr: []VGAttribute{{Key: "type", Val: "checkbox"}, {Key: "checked"}}}
+	BindModel(n, &done)
+	assert.Equal([]VGAttribute{{Key: "type", Val: "checkbox"}, {Key: "checked"}}, n.Attr)
+	done = false
+	BindModel(n, &done)
+	assert.Equal([]VGAttribute{{Key: "type", Val: "checkbox"}}, n.Attr)
+
+	name := "Joe"
+	n = &VGNode{Type: ElementNode, Data: "textarea"}
+	BindModel(n, &name)
+	assert.Equal([]VGAttribute{{Key: "value", Val: "Joe"}}, n.Attr)
+
+	assert.Panics(func() { BindModel(n, &done) })
+	assert.Panics(func() { BindModel(&VGNode{Type: ElementNode, Data: "div"}, &name) })
+}
+
+func TestModelChanged(t *testing.T) {
+
+	assert := assert.New(t)
+
+	event := func(target map[string]interface{}) DOMEvent {
+		return NewDOMEvent(nil, map[string]interface{}{"type": "change", "target": target})
+	}
+
+	var color string
+	ModelChanged(event(map[string]interface{}{"tagName": "SELECT", "value": "r"}), &color)
+	assert.Equal("r", color)
+
+	var tags []string
+	e := event(map[string]interface{}{"tagName": "SELECT", "value": "a", "selectedValues": []interface{}{"a", "c"}})
+	ModelChanged(e, &tags)
+	assert.Equal([]string{"a", "c"}, tags)
+	assert.Equal([]string{"a", "c"}, EventSelectedValues(e))
+
+	// checkboxes sharing a slice
+	ModelChanged(event(map[string]interface{}{"tagName": "INPUT", "type": "checkbox", "value": "b", "checked": true}), &tags)
+	assert.Equal([]string{"a", "c", "b"}, tags)
+	ModelChanged(event(map[string]interface{}{"tagName": "INPUT", "type": "checkbox", "value": "a", "checked": false}), &tags)
+	assert.Equal([]string{"c", "b"}, tags)
+
+	var done bool
+	ModelChanged(event(map[string]interface{}{"tagName": "INPUT", "type": "checkbox", "value": "on", "checked": true}), &done)
+	assert.True(done)
+
+	var size string
+	ModelChanged(event(map[string]interface{}{"tagName": "INPUT", "type": "radio", "value": "m", "checked": false}), &size)
+	assert.Equal("", size)
+	ModelChanged(event(map[string]interface{}{"tagName": "INPUT", "type": "radio", "value": "m", "checked": true}), &size)
+	assert.Equal("m", size)
+
+	var name string
+	ModelChanged(event(map[string]interface{}{"tagName": "INPUT", "type": "text", "value": "Joe"}), &name)
+	assert.Equal("Joe", name)
+}
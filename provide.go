@@ -0,0 +1,74 @@
+package vugu
+
+// Provider is implemented by components which provide values to the components below them in the tree,
+// such as a theme, the current session or services, so they do not have to be passed down through
+// every component in between.  Provide is called on each build, after the component's Build, and the
+// values are available to its descendants with Inject (BuildIn.Inject or ComputeCtx.Inject) until
+// the build of its subtree is done.  As the whole tree is built on each render, descendants always see
+// the values provided most recently; change them with the EventEnv locked, as for any other change
+// which should be rendered.
+type Provider interface {
+	Provide(ctx ProvideCtx)
+}
+
+// ProvideCtx is the context passed to a Provide callback.
+type ProvideCtx interface {
+	EventEnv() EventEnv
+
+	// Provide makes value available to descendants with Inject(key).  Like with context.Context,
+	// key should be of an unexported type so it does not collide with keys from other packages.
+	// A value provided for the same key further down the tree takes its place below that point.
+	Provide(key, value interface{})
+}
+
+type provision struct {
+	key, value interface{}
+}
+
+type provideCtx struct {
+	eventEnv EventEnv
+	env      *BuildEnv
+}
+
+// EventEnv implements ProvideCtx
+func (c *provideCtx) EventEnv() EventEnv {
+	return c.eventEnv
+}
+
+// Provide implements ProvideCtx
+func (c *provideCtx) Provide(key, value interface{}) {
+	c.env.provided = append(c.env.provided, provision{key: key, value: value})
+}
+
+// invokeProvide calls Provide on c if it is a Provider and returns a func which removes what it
+// provided, to be called once its subtree is built.
+func invokeProvide(c interface{}, e *BuildEnv) (done func()) {
+	p, ok := c.(Provider)
+	if !ok {
+		return func() {}
+	}
+	n := len(e.provided)
+	p.Provide(&provideCtx{eventEnv: e.eventEnv, env: e})
+	return func() {
+		for i := n; i < len(e.provided); i++ {
+			e.provided[i] = provision{}
+		}
+		e.provided = e.provided[:n]
+	}
+}
+
+// inject returns the value provided for key by the nearest ancestor of the component being built.
+func (e *BuildEnv) inject(key interface{}) (value interface{}, ok bool) {
+	for i := len(e.provided) - 1; i >= 0; i-- {
+		if e.provided[i].key == key {
+			return e.provided[i].value, true
+		}
+	}
+	return nil, false
+}
+
+// Inject returns the value provided for key by the nearest ancestor of the component being built which
+// is a Provider, or false if none has provided it.
+func (bi *BuildIn) Inject(key interface{}) (value interface{}, ok bool) {
+	return bi.BuildEnv.inject(key)
+}
@@ -0,0 +1,130 @@
+package vugu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type provideTestKey string
+
+// provideb provides Values and builds Children
+type provideb struct {
+	Values   map[interface{}]interface{}
+	Children []Builder
+}
+
+func (b *provideb) Provide(ctx ProvideCtx) {
+	for k, v := range b.Values {
+		ctx.Provide(k, v)
+	}
+}
+
+func (b *provideb) Build(in *BuildIn) (out *BuildOut) {
+	return &BuildOut{Components: b.Children}
+}
+
+// injectb records what it injects for Key in Build and Compute
+type injectb struct {
+	Key             interface{}
+	Built, Computed interface{}
+	BuiltOK         bool
+}
+
+func (b *injectb) Compute(ctx ComputeCtx) {
+	b.Computed, _ = ctx.Inject(b.Key)
+}
+
+func (b *injectb) Build(in *BuildIn) (out *BuildOut) {
+	b.Built, b.BuiltOK = in.Inject(b.Key)
+	return &BuildOut{}
+}
+
+func TestProvideInject(t *testing.T) {
+
+	assert := assert.New(t)
+
+	be, err := NewBuildEnv()
+	assert.NoError(err)
+
+	theme := provideTestKey("theme")
+	session := provideTestKey("session")
+
+	deep := &injectb{Key: theme}
+	deepSession := &injectb{Key: session}
+	inner := &injectb{Key: theme}
+	sibling := &injectb{Key: theme}
+	other := &injectb{Key: provideTestKey("other")}
+
+	root := &provideb{
+		Values: map[interface{}]interface{}{theme: "dark", session: 1},
+		Children: []Builder{
+			&provideb{Children: []Builder{deep, deepSession}},
+			&provideb{
+				Values:   map[interface{}]interface{}{theme: "light"},
+				Children: []Builder{inner},
+			},
+			sibling,
+			other,
+		},
+	}
+
+	be.RunBuild(root)
+
+	// through a component in between which provides nothing
+	assert.Equal("dark", deep.Built)
+	assert.Equal("dark", deep.Computed)
+	assert.Equal(1, deepSession.Built)
+	// the nearest ancestor wins
+	assert.Equal("light", inner.Built)
+	assert.Equal("light", inner.Computed)
+	// and does not leak to its siblings
+	assert.Equal("dark", sibling.Built)
+	assert.Nil(other.Built)
+	assert.False(other.BuiltOK)
+	assert.Len(be.provided, 0)
+
+	// a changed value is seen on the next build
+	root.Values[theme] = "blue"
+	be.RunBuild(root)
+	assert.Equal("blue", deep.Built)
+	assert.Equal("blue", sibling.Built)
+	assert.Equal("light", inner.Built)
+
+	// outside of any provider
+	lone := &injectb{Key: theme}
+	be.RunBuild(lone)
+	assert.False(lone.BuiltOK)
+}
+
+// canb records BuildIn.Can for Permission
+type canb struct {
+	Permission string
+	Allowed    bool
+}
+
+func (b *canb) Build(in *BuildIn) (out *BuildOut) {
+	b.Allowed = in.Can(b.Permission)
+	return &BuildOut{}
+}
+
+func TestBuildInCan(t *testing.T) {
+
+	assert := assert.New(t)
+
+	be, err := NewBuildEnv()
+	assert.NoError(err)
+
+	view, edit, outside := &canb{Permission: "view"}, &canb{Permission: "edit"}, &canb{Permission: "view"}
+	auth := AuthorizerFunc(func(permission string) bool { return permission == "view" })
+	root := &provideb{Children: []Builder{
+		&provideb{Values: map[interface{}]interface{}{authorizerKey{}: auth}, Children: []Builder{view, edit}},
+		outside,
+	}}
+	be.RunBuild(root)
+
+	assert.True(view.Allowed)
+	assert.False(edit.Allowed)
+	// no Authorizer provided denies everything
+	assert.False(outside.Allowed)
+}
@@ -0,0 +1,57 @@
+package vugu
+
+import "sync/atomic"
+
+// Quality is how much rendering work the device keeps up with, as measured by a renderer (see
+// domrender.AdaptiveQuality), so components can degrade gracefully on slow devices, e.g. by showing fewer
+// items or leaving out decorative animation.  See RenderQuality.
+type Quality int32
+
+// Quality levels, from best to worst.
+const (
+	QualityFull    Quality = iota // renders fit in a frame
+	QualityReduced                // renders often take longer than a frame
+	QualityMinimal                // renders take much longer than a frame
+)
+
+// String returns the name of the level, e.g. "reduced".
+func (q Quality) String() string {
+	switch q {
+	case QualityFull:
+		return "full"
+	case QualityReduced:
+		return "reduced"
+	case QualityMinimal:
+		return "minimal"
+	}
+	return "unknown"
+}
+
+// RenderQuality returns the Quality of the renderer of ee, e.g. ComputeCtx.EventEnv, or QualityFull if
+// the renderer does not measure it:
+//
+//	func (c *Feed) Compute(ctx vugu.ComputeCtx) {
+//		c.animate = vugu.RenderQuality(ctx.EventEnv()) == vugu.QualityFull
+//	}
+//
+// The renderer renders again when it changes.
+func RenderQuality(ee EventEnv) Quality {
+	impl, ok := ee.(*EventEnvImpl)
+	if !ok {
+		return QualityFull
+	}
+	return Quality(atomic.LoadInt32(&impl.quality))
+}
+
+// SetRenderQuality sets what RenderQuality returns, for use by renderers.
+func (ee *EventEnvImpl) SetRenderQuality(q Quality) {
+	atomic.StoreInt32(&ee.quality, int32(q))
+}
+
+// Deferrable is implemented by components whose output may be left as it was for a render when the
+// renderer is short of time, e.g. a sidebar of related items, so the rest of the page updates sooner.
+// The renderer catches up on the next render.  DeferRender is called for each render below QualityFull,
+// returning false renders the component as usual.
+type Deferrable interface {
+	DeferRender() bool
+}
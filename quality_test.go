@@ -0,0 +1,23 @@
+package vugu
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderQuality(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var mu sync.RWMutex
+	ee := NewEventEnvImpl(&mu, nil)
+	assert.Equal(QualityFull, RenderQuality(ee))
+	ee.SetRenderQuality(QualityMinimal)
+	assert.Equal(QualityMinimal, RenderQuality(ee))
+	assert.Equal("minimal", RenderQuality(ee).String())
+
+	// other EventEnvs do not measure it
+	assert.Equal(QualityFull, RenderQuality(nil))
+}
@@ -0,0 +1,42 @@
+package vugu
+
+import "sync"
+
+// RootSwitch holds the root component of a running program so it can be replaced by another, e.g. going
+// from a login screen to the application once signed in, without reloading the page.  The loop which
+// builds and renders gets the current root from it each time:
+//
+//	roots := vugu.NewRootSwitch(&Login{})
+//	for ok := true; ok; ok = renderer.EventWait() {
+//		err = renderer.Render(buildEnv.RunBuild(roots.Root()))
+//		...
+//	}
+//
+// and components which were given the RootSwitch call Set, e.g. from an event handler.  The components of
+// the old root are destroyed on the next build, and the renderer empties the mount point and fills it
+// with the new root as on the first render, so nothing (including event listeners) is left over from
+// the old one.
+type RootSwitch struct {
+	mu   sync.Mutex
+	root Builder
+}
+
+// NewRootSwitch returns a RootSwitch with root as the current root component.
+func NewRootSwitch(root Builder) *RootSwitch {
+	return &RootSwitch{root: root}
+}
+
+// Root returns the current root component.
+func (s *RootSwitch) Root() Builder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.root
+}
+
+// Set replaces the root component, which takes effect on the next build.  Call it with the EventEnv
+// write lock held, as for other changes to be rendered, e.g. from an event handler.
+func (s *RootSwitch) Set(root Builder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.root = root
+}
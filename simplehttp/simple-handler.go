@@ -17,7 +17,6 @@ After creation, some flags are available for tuning, e.g.:
 
 	h.EnableGenerate = true // upon page reload run "go generate ."
 	h.DisableBuildCache = true // do not try to cache build results during development, just rebuild every time
-	h.ParserGoPkgOpts.SkipRegisterComponentTypes = true // do not generate component registration init() stuff
 
 Since it's just a regular http.Handler, starting a webserver is as simple as:
 
@@ -378,6 +377,8 @@ var DefaultIsPageFunc = func(r *http.Request) bool {
 }
 
 // DefaultPageTemplateSource a useful default HTML template for serving pages.
+// The base href is "/" unless BaseHref is in the template data, and main.wasm is
+// loaded from AssetBaseHref if given (e.g. a CDN), see package vgbase.
 var DefaultPageTemplateSource = `<!doctype html>
 <html>
 <head>
@@ -387,6 +388,8 @@ var DefaultPageTemplateSource = `<!doctype html>
 <title>Vugu Dev - {{.Request.URL.Path}}</title>
 {{end}}
 <meta charset="utf-8"/>
+<base href="{{if .BaseHref}}{{.BaseHref}}{{else}}/{{end}}"/>
+{{if .AssetBaseHref}}<meta name="vugu-asset-base" content="{{.AssetBaseHref}}"/>{{end}}
 {{if .MetaTags}}{{range $k, $v := .MetaTags}}
 <meta name="{{$k}}" content="{{$v}}"/>
 {{end}}{{end}}
@@ -394,7 +397,7 @@ var DefaultPageTemplateSource = `<!doctype html>
 <link rel="stylesheet" href="{{$f}}" />
 {{end}}{{end}}
 <script src="https://cdn.jsdelivr.net/npm/text-encoding@0.7.0/lib/encoding.min.js"></script> <!-- MS Edge polyfill -->
-<script src="/wasm_exec.js"></script>
+<script src="wasm_exec.js"></script>
 </head>
 <body>
 <div id="vugu_mount_point">
@@ -412,7 +415,8 @@ if (wasmSupported) {
 		};
 	}
 	const go = new Go();
-	WebAssembly.instantiateStreaming(fetch("/main.wasm"), go.importObject).then((result) => {
+	var assetBase = document.querySelector('meta[name="vugu-asset-base"]');
+	WebAssembly.instantiateStreaming(fetch(new URL("main.wasm", assetBase ? new URL(assetBase.content, document.baseURI) : document.baseURI)), go.importObject).then((result) => {
 		go.run(result.instance);
 	});
 } else {
@@ -11,6 +11,7 @@ import (
 	"github.com/vugu/html"
 	"github.com/vugu/html/atom"
 	"github.com/vugu/vugu"
+	"github.com/vugu/vugu/vgbase"
 )
 
 // caller should be able to just specify a directory,
@@ -27,6 +28,11 @@ func New(w io.Writer) *StaticRenderer {
 // StaticRenderer provides rendering as static HTML to an io.Writer.
 type StaticRenderer struct {
 	w io.Writer
+
+	baseHref  string
+	assetHref string
+
+	sanitizer vugu.HTMLSanitizer
 }
 
 // SetWriter assigns the Writer to be used for subsequent calls to Render.
@@ -35,24 +41,48 @@ func (r *StaticRenderer) SetWriter(w io.Writer) {
 	r.w = w
 }
 
+// SetHTMLSanitizer makes subsequent calls to Render clean the HTML of vg-html with s, e.g. a
+// vgsanitize.Policy, as domrender.JSRenderer.HTMLSanitizer does.  Static HTML from component markup is
+// left as is.  nil disables this.
+func (r *StaticRenderer) SetHTMLSanitizer(s vugu.HTMLSanitizer) {
+	r.sanitizer = s
+}
+
+// SetBase makes subsequent calls to Render put a base element with href, and if not empty a meta
+// element with the asset base, in the <head> of the output if it has none already, the same as
+// vgbase.Tags.  As a renderer has its own, one can be used per tenant when several are served
+// under different prefixes.  An empty href disables this.
+func (r *StaticRenderer) SetBase(href, assets string) {
+	r.baseHref, r.assetHref = "", ""
+	if href != "" {
+		r.baseHref = vgbase.Clean(href)
+		if assets != "" {
+			r.assetHref = vgbase.Clean(assets)
+		}
+	}
+}
+
 // Render will perform a static render of the given BuildResults and write it to the writer assigned.
 func (r *StaticRenderer) Render(buildResults *vugu.BuildResults) error {
 
-	n, err := r.renderOne(buildResults, buildResults.Out)
+	nodes, err := r.renderOne(buildResults, buildResults.Out)
 	if err != nil {
 		return err
 	}
 
-	err = html.Render(r.w, n)
-	if err != nil {
-		return err
+	// usually one node, more if the root component is a fragment
+	for _, n := range nodes {
+		err = html.Render(r.w, n)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 
 }
 
-func (r *StaticRenderer) renderOne(br *vugu.BuildResults, bo *vugu.BuildOut) (*html.Node, error) {
+func (r *StaticRenderer) renderOne(br *vugu.BuildResults, bo *vugu.BuildOut) ([]*html.Node, error) {
 
 	if len(bo.Out) != 1 {
 		return nil, fmt.Errorf("BuildOut must contain exactly one element in Out")
@@ -68,11 +98,7 @@ func (r *StaticRenderer) renderOne(br *vugu.BuildResults, bo *vugu.BuildOut) (*h
 		// if component then look up BuildOut for it and call renderOne again and return
 		if vgn.Component != nil {
 			cbo := br.ResultFor(vgn.Component)
-			retn, err := r.renderOne(br, cbo)
-			if err != nil {
-				return nil, err
-			}
-			return []*html.Node{retn}, nil
+			return r.renderOne(br, cbo)
 			// if len(retn) != 1 {
 			// 	return nil, fmt.Errorf("StaticRenderer.renderOne component renderOne returned unexpected %d nodes", len(retn))
 			// }
@@ -98,14 +124,39 @@ func (r *StaticRenderer) renderOne(br *vugu.BuildResults, bo *vugu.BuildOut) (*h
 		n.Data = vgn.Data                          // copy Data over
 		n.DataAtom = atom.Lookup([]byte(vgn.Data)) // lookup atom
 		for _, vgattr := range vgn.Attr {
+			if vgattr.Key == "class" && vgn.ClassMap != nil {
+				continue // included in ClassList below
+			}
+			if !vgattr.Present() {
+				continue // e.g. disabled="false"
+			}
 			n.Attr = append(n.Attr, html.Attribute{Key: vgattr.Key, Val: vgattr.Val})
 		}
 
+		// :class and :style maps
+		if vgn.ClassMap != nil {
+			if cl := vgn.ClassList(); len(cl) > 0 {
+				n.Attr = append(n.Attr, html.Attribute{Key: "class", Val: strings.Join(cl, " ")})
+			}
+		}
+		if s := vgn.StyleMap.String(); s != "" {
+			n.Attr = appendStyleAttr(n.Attr, s)
+		}
+
+		// vg-show
+		if vgn.Hidden {
+			n.Attr = appendStyleAttr(n.Attr, "display:none")
+		}
+
 		// handle InnerHTML
 
 		if vgn.InnerHTML != nil {
 
-			nparts, err := html.ParseFragment(strings.NewReader(*vgn.InnerHTML), n)
+			innerHTML := *vgn.InnerHTML
+			if r.sanitizer != nil && !vgn.InnerHTMLStatic {
+				innerHTML = r.sanitizer.SanitizeHTML(innerHTML)
+			}
+			nparts, err := html.ParseFragment(strings.NewReader(innerHTML), n)
 			if err != nil {
 				return nil, err
 			}
@@ -133,6 +184,7 @@ func (r *StaticRenderer) renderOne(br *vugu.BuildResults, bo *vugu.BuildOut) (*h
 		// (Vugu build output does not always have a head tag and multiple components
 		// can each emit it, so we have to keep things like CSS separate)
 		if n.Type == html.ElementNode && n.Data == "head" {
+			r.insertBase(n)
 			for _, css := range bo.CSS {
 
 				// convert each one
@@ -162,14 +214,27 @@ func (r *StaticRenderer) renderOne(br *vugu.BuildResults, bo *vugu.BuildOut) (*h
 		return []*html.Node{n}, nil
 	}
 
-	nret, err := visit(vgn)
-	if err != nil {
-		return nil, err
+	return visit(vgn)
+}
+
+// insertBase puts the elements for SetBase first in head, so they apply to the URLs after them.
+func (r *StaticRenderer) insertBase(head *html.Node) {
+	if r.baseHref == "" {
+		return
+	}
+	for c := head.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "base" {
+			return
+		}
 	}
-	if len(nret) != 1 {
-		return nil, fmt.Errorf("StaticRenderer.renderOne visit returned unexpected %d nodes", len(nret))
+	if r.assetHref != "" {
+		head.InsertBefore(&html.Node{Type: html.ElementNode, Data: "meta", DataAtom: atom.Meta, Attr: []html.Attribute{
+			{Key: "name", Val: vgbase.AssetBaseMetaName}, {Key: "content", Val: r.assetHref},
+		}}, head.FirstChild)
 	}
-	return nret[0], nil
+	head.InsertBefore(&html.Node{Type: html.ElementNode, Data: "base", DataAtom: atom.Base, Attr: []html.Attribute{
+		{Key: "href", Val: r.baseHref},
+	}}, head.FirstChild)
 }
 
 func appendChildren(parent *html.Node, children []*html.Node) {
@@ -178,6 +243,21 @@ func appendChildren(parent *html.Node, children []*html.Node) {
 	}
 }
 
+// appendStyleAttr adds css to the style attribute in attrs, creating it if needed.
+func appendStyleAttr(attrs []html.Attribute, css string) []html.Attribute {
+	for i := range attrs {
+		if attrs[i].Key == "style" {
+			v := strings.TrimSpace(attrs[i].Val)
+			if v != "" && !strings.HasSuffix(v, ";") {
+				v += ";"
+			}
+			attrs[i].Val = v + css
+			return attrs
+		}
+	}
+	return append(attrs, html.Attribute{Key: "style", Val: css})
+}
+
 // EventEnv returns a simple EventEnv implementation suitable for use with the static renderer.
 func (r *StaticRenderer) EventEnv() *RWMutexEventEnv {
 	return &RWMutexEventEnv{}
@@ -1,13 +1,16 @@
 package staticrender
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/vugu/vugu"
 	"github.com/vugu/vugu/gen"
 )
 
@@ -101,6 +104,185 @@ comp1 in the house
 			},
 			outReNotMatch: []string{`vg-template`},
 		},
+		{
+			name:      "vg-show",
+			opts:      gen.ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu": `<div><span vg-show="false">a</span><span vg-show="false" style="color:red">b</span><span vg-show="true">c</span></div>`,
+			},
+			outReMatch: []string{
+				`<span style="display:none">a</span>`,
+				`<span style="color:red;display:none">b</span>`,
+				`<span>c</span>`,
+			},
+			outReNotMatch: []string{`vg-show`},
+		},
+		{
+			name:      "class-style-map",
+			opts:      gen.ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu": `<div><span class="a" :class='vugu.ClassMap{"b":true,"c":false}' style="color:red" :style='vugu.StyleMap{"width":"10px"}'>x</span></div>`,
+			},
+			outReMatch: []string{
+				`<span style="color:red;width:10px" class="a b">x</span>`,
+			},
+			outReNotMatch: []string{`class="c"`, ` c"`},
+		},
+		{
+			name:      "scoped-style",
+			opts:      gen.ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu": `<html><head></head><body><div><main:Comp1/></div></body></html>`,
+				"comp1.vugu": `<div class="box"><p>static</p></div>
+<style scoped>.box p { color: red; }</style>`,
+			},
+			outReMatch: []string{
+				`<div class="box" data-vgs-[0-9a-f]{8}=""><p data-vgs-[0-9a-f]{8}="">static</p></div>`,
+			},
+			outReNotMatch: []string{`scoped`},
+		},
+		{
+			name:      "slots",
+			opts:      gen.ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu": `<div><main:Card><vg-slot name="Title">A Title</vg-slot><p>body</p></main:Card><main:Card/></div>`,
+				"card.vugu": `<div class="card"><h1><vg-slot name="Title">No Title</vg-slot></h1><vg-slot></vg-slot></div>`,
+				"card.go":   "package main\n\nimport \"github.com/vugu/vugu\"\n\ntype Card struct {\n\tTitle vugu.Builder\n\tDefaultSlot vugu.Builder\n}\n",
+			},
+			outReMatch: []string{
+				`<div class="card"><h1>A Title</h1><p>body</p></div>`,
+				`<div class="card"><h1>No Title</h1></div>`,
+			},
+			outReNotMatch: []string{`vg-slot`},
+		},
+		{
+			name:      "dynamic-component",
+			opts:      gen.ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu":  `<div><vg-comp expr="c.Which"></vg-comp></div>`,
+				"root.go":    "package main\n\nimport \"github.com/vugu/vugu\"\n\ntype Root struct {\n\tWhich vugu.ComponentFunc\n}\n\nfunc (c *Root) Init() {\n\tc.Which = func() vugu.Builder { return &Comp1{} }\n}\n",
+				"comp1.vugu": `<span>comp1</span>`,
+			},
+			outReMatch: []string{
+				`<div><span>comp1</span></div>`,
+			},
+			outReNotMatch: []string{`vg-comp`},
+		},
+		{
+			name:      "keep-alive",
+			opts:      gen.ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu":  `<div><vg-keep-alive><main:Comp1 vg-if="true"></main:Comp1><p>static</p></vg-keep-alive></div>`,
+				"comp1.vugu": `<span>comp1</span>`,
+			},
+			outReMatch: []string{
+				`<div><span>comp1</span><p>static</p></div>`,
+			},
+			outReNotMatch: []string{`vg-keep-alive`},
+		},
+		{
+			name:      "portal",
+			opts:      gen.ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu":  `<div><p vg-portal="#modals">modal</p><main:Comp1 vg-portal="#toasts"></main:Comp1></div>`,
+				"comp1.vugu": `<span>toast</span>`,
+			},
+			outReMatch: []string{
+				`<div><p>modal</p><span>toast</span></div>`,
+			},
+			outReNotMatch: []string{`vg-portal`},
+		},
+		{
+			name:      "vg-can",
+			opts:      gen.ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu":  `<div><main:Comp1></main:Comp1></div>`,
+				"root.go":    "package main\n\nimport \"github.com/vugu/vugu\"\n\ntype Root struct{}\n\nfunc (c *Root) Provide(ctx vugu.ProvideCtx) {\n\tvugu.ProvideAuthorizer(ctx, vugu.AuthorizerFunc(func(p string) bool { return p == \"view\" }))\n}\n",
+				"comp1.vugu": `<p><span vg-can="view">shown</span><span vg-can="admin">secret</span><button vg-can.disable="admin">delete</button><main:Comp2 vg-can="admin"></main:Comp2></p>`,
+				"comp2.vugu": `<b>admin panel</b>`,
+			},
+			outReMatch: []string{
+				`<span>shown</span>`,
+				`<button disabled="" aria-disabled="true">delete</button>`,
+			},
+			outReNotMatch: []string{`secret`, `admin panel`, `vg-can`},
+		},
+		{
+			name:      "vg-ref",
+			opts:      gen.ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu": `<div><input vg-ref="search" :value='"x"'></div>`,
+			},
+			outReMatch: []string{
+				`<input data-vg-ref="search" value="x"/>`,
+			},
+			outReNotMatch: []string{` vg-ref`},
+		},
+		{
+			name:      "vg-model",
+			opts:      gen.ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu": `<div><select vg-model="c.Color"><option value="red">Red</option><option>blue</option></select>` +
+					`<select multiple vg-model="c.Tags"><option vg-for='_, t := range []string{"a", "b", "c"}' :value="t" vg-content="t"></option></select>` +
+					`<input type="checkbox" vg-model="c.Done"><input vg-model="c.Name"></div>`,
+				"root.go": "package main\n\ntype Root struct {\n\tColor string\n\tTags []string\n\tDone bool\n\tName string\n}\n\n" +
+					"func (c *Root) Init() {\n\tc.Color = \"blue\"\n\tc.Tags = []string{\"c\"}\n\tc.Done = true\n\tc.Name = \"Joe\"\n}\n",
+			},
+			outReMatch: []string{
+				`<option value="red">Red</option><option selected="">blue</option>`,
+				`<option value="b">b</option><option value="c" selected="">c</option>`,
+				`<input type="checkbox" checked=""/><input value="Joe"/>`,
+			},
+			outReNotMatch: []string{`vg-model`},
+		},
+		{
+			name:      "bool-attr",
+			opts:      gen.ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu": `<div><button disabled="false" :hidden='"false"'>a</button><input readonly :required="true"><p><input checked="false"></p></div>`,
+			},
+			outReMatch: []string{
+				`<button>a</button>`,
+				`<input readonly="" required=""/>`,
+			},
+			outReNotMatch: []string{`disabled`, `hidden`, `checked`},
+		},
+		{
+			name:      "fragment",
+			opts:      gen.ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu":  `<div><main:Comp1/></div>`,
+				"comp1.vugu": `<span>one</span> <span vg-if='true'>two</span>`,
+			},
+			outReMatch: []string{
+				`<div><span>one</span><span>two</span></div>`,
+			},
+			outReNotMatch: []string{`should not match`},
+		},
+		{
+			name:      "fragment-root",
+			opts:      gen.ParserGoPkgOpts{},
+			recursive: false,
+			infiles: map[string]string{
+				"root.vugu": `<p>first</p><p>second</p>`,
+			},
+			outReMatch: []string{
+				`<p>first</p><p>second</p>`,
+			},
+			outReNotMatch: []string{`should not match`},
+		},
 	}
 
 	for _, tc := range tcList {
@@ -370,3 +552,70 @@ func tstWriteFiles(dir string, m map[string]string) {
 // 	}
 
 // }
+
+func TestSetBase(t *testing.T) {
+
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		h := &vugu.VGNode{Type: vugu.ElementNode, Data: "html"}
+		head := &vugu.VGNode{Type: vugu.ElementNode, Data: "head"}
+		head.AppendChild(&vugu.VGNode{Type: vugu.ElementNode, Data: "title"})
+		h.AppendChild(head)
+		h.AppendChild(&vugu.VGNode{Type: vugu.ElementNode, Data: "body"})
+		return &vugu.BuildOut{Out: []*vugu.VGNode{h}}
+	})
+	buildEnv, err := vugu.NewBuildEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	r := New(&buf)
+	r.SetBase("tenant1", "https://cdn.example.com/app")
+	if err := r.Render(buildEnv.RunBuild(root)); err != nil {
+		t.Fatal(err)
+	}
+	want := `<head><base href="/tenant1/"/><meta name="vugu-asset-base" content="https://cdn.example.com/app/"/><title>`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %s in output: %s", want, buf.String())
+	}
+
+	buf.Reset()
+	r.SetBase("", "")
+	if err := r.Render(buildEnv.RunBuild(root)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "<base") {
+		t.Errorf("unexpected base in output: %s", buf.String())
+	}
+}
+
+func TestSetHTMLSanitizer(t *testing.T) {
+
+	root := vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		div := &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+		user := &vugu.VGNode{Type: vugu.ElementNode, Data: "p"}
+		user.SetInnerHTML(vugu.HTML(`<b>hi</b><script>alert(1)</script>`))
+		div.AppendChild(user)
+		static := &vugu.VGNode{Type: vugu.ElementNode, Data: "p"}
+		static.SetInnerHTML(vugu.StaticHTML(`<i>static</i>`))
+		div.AppendChild(static)
+		return &vugu.BuildOut{Out: []*vugu.VGNode{div}}
+	})
+	buildEnv, err := vugu.NewBuildEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	r := New(&buf)
+	r.SetHTMLSanitizer(vugu.HTMLSanitizerFunc(func(s string) string {
+		return strings.Replace(s, "<script>alert(1)</script>", "", -1) + "<!--sanitized-->"
+	}))
+	if err := r.Render(buildEnv.RunBuild(root)); err != nil {
+		t.Fatal(err)
+	}
+	want := `<div><p><b>hi</b><!--sanitized--></p><p><i>static</i></p></div>`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %s in output: %s", want, buf.String())
+	}
+}
@@ -0,0 +1,283 @@
+/*
+Package vganim sequences element animations with the Web Animations API, for things like onboarding tours
+and micro-interactions where several elements move in a coordinated way.
+
+A timeline is built from Animations, each animating one element through a list of keyframes, combined with
+Sequence, Parallel and Stagger:
+
+	fadeIn := func(el js.Value) vganim.Step {
+		return vganim.Animation{Target: el, Duration: 300 * time.Millisecond, Easing: "ease-out",
+			Keyframes: []vganim.Keyframe{{"opacity": 0, "transform": "translateY(20px)"}, {"opacity": 1, "transform": "none"}}}
+	}
+	var items []vganim.Step
+	for _, el := range c.itemEls {
+		items = append(items, fadeIn(el))
+	}
+	tl := vganim.Sequence(
+		fadeIn(c.panel),
+		vganim.Stagger(80*time.Millisecond, items...),
+		vganim.Wait(time.Second),
+		vganim.Parallel(fadeIn(c.nextButton), fadeIn(c.skipButton)),
+	)
+	c.playback = vganim.Play(event.EventEnv(), tl, func() { c.tourStep++ })
+
+The elements are usually captured with vg-js-create.  Play works out when each animation starts and calls
+element.animate for all of them at once, delaying each by its start time, so the browser runs the whole
+timeline without going back to Go.  Outside of the browser, or where the API is not supported, Play
+finishes straight away.
+*/
+package vganim
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+// Keyframe is one keyframe of an animation: CSS properties in their JS form (e.g. "backgroundColor")
+// with their values, plus optionally "offset" and "easing".
+type Keyframe map[string]interface{}
+
+// Step is part of a timeline: an Animation, or a group of steps made with Sequence, Parallel, Stagger or Wait.
+type Step interface {
+	// schedule appends the animations of the step to list, starting at start, and returns when it ends.
+	schedule(start time.Duration, list []Scheduled) ([]Scheduled, time.Duration)
+}
+
+// Animation animates one element.
+type Animation struct {
+	Target     js.Value      // the element
+	Keyframes  []Keyframe    // at least one, with two or more it goes from the first to the last
+	Duration   time.Duration // of one iteration
+	Delay      time.Duration // before it starts, after the point in the timeline where it is
+	Easing     string        // CSS timing function, "linear" if empty
+	Fill       string        // "none", "forwards", "backwards" or "both"; if empty "backwards", so it holds the first keyframe until it starts
+	Iterations float64       // how many times it runs, once if zero
+}
+
+// Length returns how long the animation takes, including its delay.
+func (a Animation) Length() time.Duration {
+	it := a.Iterations
+	if it <= 0 {
+		it = 1
+	}
+	return a.Delay + time.Duration(float64(a.Duration)*it)
+}
+
+func (a Animation) schedule(start time.Duration, list []Scheduled) ([]Scheduled, time.Duration) {
+	return append(list, Scheduled{Animation: a, Start: start}), start + a.Length()
+}
+
+// Sequence runs steps one after the other.
+func Sequence(steps ...Step) Step { return sequence(steps) }
+
+type sequence []Step
+
+func (s sequence) schedule(start time.Duration, list []Scheduled) ([]Scheduled, time.Duration) {
+	end := start
+	for _, step := range s {
+		list, end = step.schedule(end, list)
+	}
+	return list, end
+}
+
+// Parallel runs steps at the same time, it ends when the longest does.
+func Parallel(steps ...Step) Step { return Stagger(0, steps...) }
+
+// Stagger starts each step interval after the previous one started, e.g. for a list of items appearing
+// one by one.  It ends when the last to finish does.
+func Stagger(interval time.Duration, steps ...Step) Step {
+	return stagger{interval: interval, steps: steps}
+}
+
+type stagger struct {
+	interval time.Duration
+	steps    []Step
+}
+
+func (s stagger) schedule(start time.Duration, list []Scheduled) ([]Scheduled, time.Duration) {
+	end := start
+	for i, step := range s.steps {
+		var stepEnd time.Duration
+		list, stepEnd = step.schedule(start+time.Duration(i)*s.interval, list)
+		if stepEnd > end {
+			end = stepEnd
+		}
+	}
+	return list, end
+}
+
+// Wait is a pause, e.g. in a Sequence.
+func Wait(d time.Duration) Step { return wait(d) }
+
+type wait time.Duration
+
+func (w wait) schedule(start time.Duration, list []Scheduled) ([]Scheduled, time.Duration) {
+	return list, start + time.Duration(w)
+}
+
+// Scheduled is an Animation with when it starts in a timeline.
+type Scheduled struct {
+	Animation
+	Start time.Duration // from the start of the timeline, not including Delay
+}
+
+// Schedule returns the animations of step in order with when each starts, and how long the whole
+// timeline takes.
+func Schedule(step Step) ([]Scheduled, time.Duration) {
+	return step.schedule(0, nil)
+}
+
+// Playback controls a timeline started with Play.
+type Playback struct {
+	eventEnv vugu.EventEnv
+	onFinish func()
+	length   time.Duration
+
+	mu         sync.Mutex
+	animations []js.Value
+	funcs      []js.Func
+	running    int  // animations not finished yet
+	done       bool // finished or cancelled
+}
+
+// Play starts the animations of step and returns a Playback to control them.  When they have all
+// finished onFinish, if not nil, is called with eventEnv locked and a render is requested after (or
+// without locking if eventEnv is nil).  It is not called if the Playback is cancelled.
+func Play(eventEnv vugu.EventEnv, step Step, onFinish func()) *Playback {
+
+	list, length := Schedule(step)
+	p := &Playback{eventEnv: eventEnv, onFinish: onFinish, length: length}
+
+	p.mu.Lock()
+	for _, s := range list {
+		if !s.Target.Truthy() || !s.Target.Get("animate").Truthy() {
+			continue
+		}
+		a := s.Target.Call("animate", keyframesJS(s.Keyframes), timingJS(s))
+		var f js.Func
+		f = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			// don't block the JS side, the finish is delivered under the lock
+			go p.finished()
+			return nil
+		})
+		a.Set("onfinish", f)
+		p.animations = append(p.animations, a)
+		p.funcs = append(p.funcs, f)
+		p.running++
+	}
+	if p.running == 0 {
+		p.running = 1
+		go p.finished()
+	}
+	p.mu.Unlock()
+
+	return p
+}
+
+// Length returns how long the timeline takes when it is not paused.
+func (p *Playback) Length() time.Duration { return p.length }
+
+// Pause pauses all the animations.
+func (p *Playback) Pause() { p.each("pause") }
+
+// Resume continues after Pause.
+func (p *Playback) Resume() { p.each("play") }
+
+// Finish jumps to the end of all the animations, and onFinish is called as if they had run.
+func (p *Playback) Finish() { p.each("finish") }
+
+// Cancel stops the animations and removes their effects, onFinish is not called.
+func (p *Playback) Cancel() {
+	p.mu.Lock()
+	if p.done {
+		p.mu.Unlock()
+		return
+	}
+	p.done = true
+	p.mu.Unlock()
+	p.each("cancel")
+	p.release()
+}
+
+// Done returns true once the timeline has finished or been cancelled.
+func (p *Playback) Done() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.done
+}
+
+func (p *Playback) each(method string) {
+	p.mu.Lock()
+	animations := p.animations
+	p.mu.Unlock()
+	for _, a := range animations {
+		a.Call(method)
+	}
+}
+
+// finished is called as each animation finishes, after the last it calls onFinish.
+func (p *Playback) finished() {
+
+	p.mu.Lock()
+	p.running--
+	if p.running > 0 || p.done {
+		p.mu.Unlock()
+		return
+	}
+	p.done = true
+	p.mu.Unlock()
+	p.release()
+
+	if p.onFinish == nil {
+		return
+	}
+	if p.eventEnv != nil {
+		p.eventEnv.Lock()
+		defer p.eventEnv.UnlockRender()
+	}
+	p.onFinish()
+}
+
+func (p *Playback) release() {
+	p.mu.Lock()
+	funcs := p.funcs
+	p.funcs = nil
+	p.mu.Unlock()
+	for _, f := range funcs {
+		f.Release()
+	}
+}
+
+// keyframesJS converts keyframes to the form js.ValueOf accepts.
+func keyframesJS(keyframes []Keyframe) []interface{} {
+	ret := make([]interface{}, 0, len(keyframes))
+	for _, k := range keyframes {
+		ret = append(ret, map[string]interface{}(k))
+	}
+	return ret
+}
+
+// timingJS returns the options for element.animate, with the start time added to the delay.
+func timingJS(s Scheduled) map[string]interface{} {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	easing, fill, iterations := s.Easing, s.Fill, s.Iterations
+	if easing == "" {
+		easing = "linear"
+	}
+	if fill == "" {
+		fill = "backwards"
+	}
+	if iterations <= 0 {
+		iterations = 1
+	}
+	return map[string]interface{}{
+		"duration":   ms(s.Duration),
+		"delay":      ms(s.Start + s.Delay),
+		"easing":     easing,
+		"fill":       fill,
+		"iterations": iterations,
+	}
+}
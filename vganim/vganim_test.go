@@ -0,0 +1,55 @@
+package vganim
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+func TestSchedule(t *testing.T) {
+
+	assert := assert.New(t)
+
+	ms := time.Millisecond
+	a := func(d time.Duration) Animation { return Animation{Duration: d} }
+
+	list, length := Schedule(Sequence(
+		a(100*ms),
+		Stagger(50*ms, a(100*ms), a(100*ms), a(20*ms)),
+		Wait(30*ms),
+		Parallel(Animation{Duration: 100 * ms, Delay: 10 * ms, Iterations: 2}, a(50*ms)),
+	))
+
+	var starts []time.Duration
+	for _, s := range list {
+		starts = append(starts, s.Start)
+	}
+	// the stagger ends with its second animation (at 100+50+100), not its last
+	assert.Equal([]time.Duration{0, 100 * ms, 150 * ms, 200 * ms, 280 * ms, 280 * ms}, starts)
+	assert.Equal(490*ms, length)
+
+	assert.Equal(map[string]interface{}{
+		"duration": 100.0, "delay": 290.0, "easing": "linear", "fill": "backwards", "iterations": 2.0,
+	}, timingJS(list[4]))
+}
+
+func TestPlayWithoutBrowser(t *testing.T) {
+
+	var mu sync.RWMutex
+	ee := vugu.NewEventEnvImpl(&mu, make(chan bool, 1))
+
+	done := make(chan struct{})
+	p := Play(ee, Sequence(Animation{Duration: time.Second}), func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("onFinish was not called")
+	}
+	assert.True(t, p.Done())
+	assert.Equal(t, time.Second, p.Length())
+}
@@ -0,0 +1,404 @@
+package vgbarcode
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+// LinearDecoder is a pure Go Decoder for the common 1D formats: EAN-13, EAN-8, UPC-A and Code 128.
+// It reads a number of rows across the image, so the barcode should be roughly horizontal
+// (upside down is fine).  Each row is binarized separately, which copes with uneven lighting
+// across the image but not with heavy blur.
+type LinearDecoder struct {
+	Rows int // number of rows to read, spread evenly over the image, defaults to 16
+}
+
+// Decode implements Decoder.  Each value is returned once no matter how many rows it was read from.
+func (d LinearDecoder) Decode(img *image.Gray) ([]Result, error) {
+
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return nil, nil
+	}
+
+	rows := d.Rows
+	if rows <= 0 {
+		rows = 16
+	}
+
+	var ret []Result
+	seen := make(map[Result]bool)
+	row := make([]uint8, b.Dx())
+	for i := 0; i < rows; i++ {
+		y := b.Min.Y + (2*i+1)*b.Dy()/(2*rows)
+		copy(row, img.Pix[img.PixOffset(b.Min.X, y):])
+		runs, dark := rowRuns(row)
+		if runs == nil {
+			continue
+		}
+		res, ok := decodeRuns(runs, dark)
+		if !ok {
+			reverseInts(runs)
+			res, ok = decodeRuns(runs, dark != (len(runs)%2 == 0))
+		}
+		if ok && !seen[res] {
+			seen[res] = true
+			ret = append(ret, res)
+		}
+	}
+	return ret, nil
+}
+
+// rowRuns binarizes a row of pixels and returns the lengths of each run of the same color, and whether
+// the first run is dark.  Returns nil if the row has too little contrast to contain a barcode.
+func rowRuns(row []uint8) (runs []int, firstDark bool) {
+
+	lo, hi := uint8(255), uint8(0)
+	for _, v := range row {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	if int(hi)-int(lo) < 48 {
+		return nil, false
+	}
+	threshold := (int(lo) + int(hi)) / 2
+
+	firstDark = int(row[0]) < threshold
+	dark := firstDark
+	n := 0
+	for _, v := range row {
+		if (int(v) < threshold) == dark {
+			n++
+			continue
+		}
+		runs = append(runs, n)
+		dark = !dark
+		n = 1
+	}
+	runs = append(runs, n)
+	return runs, firstDark
+}
+
+func reverseInts(s []int) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// decodeRuns tries each dark run as the start of a barcode.
+func decodeRuns(runs []int, firstDark bool) (Result, bool) {
+	start := 1
+	if firstDark {
+		start = 0
+	}
+	for i := start; i < len(runs); i += 2 {
+		// a barcode must be preceded by a light quiet zone, or start at the edge of the image
+		quiet := 0
+		if i > 0 {
+			quiet = runs[i-1]
+		}
+		if s, ok := decodeEAN(runs, i, quiet, 13); ok {
+			if s[0] == '0' {
+				return Result{Value: s[1:], Format: UPCA}, true
+			}
+			return Result{Value: s, Format: EAN13}, true
+		}
+		if s, ok := decodeEAN(runs, i, quiet, 8); ok {
+			return Result{Value: s, Format: EAN8}, true
+		}
+		if s, ok := decodeCode128(runs, i, quiet); ok {
+			return Result{Value: s, Format: Code128}, true
+		}
+	}
+	return Result{}, false
+}
+
+// matchPattern returns the index of the entry in patterns that best matches runs, each pattern being
+// the widths in modules of the same number of runs, and how well it matched (lower is better).
+func matchPattern(runs []int, patterns [][]int) (best int, bestErr float64) {
+	total := 0
+	for _, r := range runs {
+		total += r
+	}
+	modules := 0
+	for _, m := range patterns[0] {
+		modules += m
+	}
+	unit := float64(total) / float64(modules)
+
+	best, bestErr = -1, math.MaxFloat64
+	for pi, p := range patterns {
+		e := 0.0
+		for i, r := range runs {
+			e += math.Abs(float64(r)/unit - float64(p[i]))
+		}
+		if e < bestErr {
+			best, bestErr = pi, e
+		}
+	}
+	return best, bestErr
+}
+
+// maxPatternErr is the total difference in modules allowed when matching a symbol
+const maxPatternErr = 1.5
+
+// widths of the light/dark runs of the EAN L code digits, the R codes have the same widths in the
+// opposite colors and the G codes are the L codes reversed
+var eanLPatterns = [][]int{
+	{3, 2, 1, 1}, {2, 2, 2, 1}, {2, 1, 2, 2}, {1, 4, 1, 1}, {1, 1, 3, 2},
+	{1, 2, 3, 1}, {1, 1, 1, 4}, {1, 3, 1, 2}, {1, 2, 1, 3}, {3, 1, 1, 2},
+}
+
+// eanLGPatterns is eanLPatterns followed by the G codes
+var eanLGPatterns = func() [][]int {
+	ret := append([][]int{}, eanLPatterns...)
+	for _, p := range eanLPatterns {
+		ret = append(ret, []int{p[3], p[2], p[1], p[0]})
+	}
+	return ret
+}()
+
+// L/G parity of the first six EAN-13 digits (1 for G) which encodes the leading digit
+var ean13Parity = []string{
+	"000000", "001011", "001101", "001110", "010011",
+	"011001", "011100", "010100", "010110", "011010",
+}
+
+// decodeEAN decodes an EAN-13 or EAN-8 (according to digits) starting at runs[i].
+func decodeEAN(runs []int, i, quiet, digits int) (string, bool) {
+
+	half := digits / 2
+	if digits == 13 {
+		half = 6
+	}
+	nruns := 3 + half*4 + 5 + half*4 + 3
+	if i+nruns > len(runs) {
+		return "", false
+	}
+	total := 0
+	for _, r := range runs[i : i+nruns] {
+		total += r
+	}
+	unit := float64(total) / float64(3+half*7+5+half*7+3)
+	if quiet > 0 && float64(quiet) < unit*3 {
+		return "", false
+	}
+
+	// guards are all one module wide
+	guard := func(g []int) bool {
+		for _, r := range g {
+			if math.Abs(float64(r)/unit-1) > 0.6 {
+				return false
+			}
+		}
+		return true
+	}
+	mid := i + 3 + half*4
+	if !guard(runs[i:i+3]) || !guard(runs[mid:mid+5]) || !guard(runs[i+nruns-3:i+nruns]) {
+		return "", false
+	}
+
+	var sb strings.Builder
+	parity := make([]byte, 0, half)
+	for d := 0; d < half; d++ {
+		o := i + 3 + d*4
+		patterns := eanLPatterns
+		if digits == 13 {
+			patterns = eanLGPatterns
+		}
+		p, e := matchPattern(runs[o:o+4], patterns)
+		if e > maxPatternErr {
+			return "", false
+		}
+		sb.WriteByte(byte('0' + p%10))
+		parity = append(parity, byte('0'+p/10))
+	}
+	for d := 0; d < half; d++ {
+		o := mid + 5 + d*4
+		p, e := matchPattern(runs[o:o+4], eanLPatterns)
+		if e > maxPatternErr {
+			return "", false
+		}
+		sb.WriteByte(byte('0' + p))
+	}
+	s := sb.String()
+
+	if digits == 13 {
+		first := -1
+		for n, p := range ean13Parity {
+			if p == string(parity) {
+				first = n
+			}
+		}
+		if first < 0 {
+			return "", false
+		}
+		s = string(rune('0'+first)) + s
+	} else if strings.ContainsRune(string(parity), '1') {
+		return "", false
+	}
+
+	if !eanChecksumOK(s) {
+		return "", false
+	}
+	return s, true
+}
+
+// eanChecksumOK reports if the last digit of s is the correct check digit, weights alternate 3 and 1
+// starting from the digit before it.
+func eanChecksumOK(s string) bool {
+	sum := 0
+	w := 3
+	for i := len(s) - 2; i >= 0; i-- {
+		sum += int(s[i]-'0') * w
+		w = 4 - w
+	}
+	return (10-sum%10)%10 == int(s[len(s)-1]-'0')
+}
+
+// widths of the dark/light runs of each Code 128 symbol value, excluding stop
+var code128Patterns = [][]int{
+	{2, 1, 2, 2, 2, 2}, {2, 2, 2, 1, 2, 2}, {2, 2, 2, 2, 2, 1}, {1, 2, 1, 2, 2, 3}, {1, 2, 1, 3, 2, 2},
+	{1, 3, 1, 2, 2, 2}, {1, 2, 2, 2, 1, 3}, {1, 2, 2, 3, 1, 2}, {1, 3, 2, 2, 1, 2}, {2, 2, 1, 2, 1, 3},
+	{2, 2, 1, 3, 1, 2}, {2, 3, 1, 2, 1, 2}, {1, 1, 2, 2, 3, 2}, {1, 2, 2, 1, 3, 2}, {1, 2, 2, 2, 3, 1},
+	{1, 1, 3, 2, 2, 2}, {1, 2, 3, 1, 2, 2}, {1, 2, 3, 2, 2, 1}, {2, 2, 3, 2, 1, 1}, {2, 2, 1, 1, 3, 2},
+	{2, 2, 1, 2, 3, 1}, {2, 1, 3, 2, 1, 2}, {2, 2, 3, 1, 1, 2}, {3, 1, 2, 1, 3, 1}, {3, 1, 1, 2, 2, 2},
+	{3, 2, 1, 1, 2, 2}, {3, 2, 1, 2, 2, 1}, {3, 1, 2, 2, 1, 2}, {3, 2, 2, 1, 1, 2}, {3, 2, 2, 2, 1, 1},
+	{2, 1, 2, 1, 2, 3}, {2, 1, 2, 3, 2, 1}, {2, 3, 2, 1, 2, 1}, {1, 1, 1, 3, 2, 3}, {1, 3, 1, 1, 2, 3},
+	{1, 3, 1, 3, 2, 1}, {1, 1, 2, 3, 1, 3}, {1, 3, 2, 1, 1, 3}, {1, 3, 2, 3, 1, 1}, {2, 1, 1, 3, 1, 3},
+	{2, 3, 1, 1, 1, 3}, {2, 3, 1, 3, 1, 1}, {1, 1, 2, 1, 3, 3}, {1, 1, 2, 3, 3, 1}, {1, 3, 2, 1, 3, 1},
+	{1, 1, 3, 1, 2, 3}, {1, 1, 3, 3, 2, 1}, {1, 3, 3, 1, 2, 1}, {3, 1, 3, 1, 2, 1}, {2, 1, 1, 3, 3, 1},
+	{2, 3, 1, 1, 3, 1}, {2, 1, 3, 1, 1, 3}, {2, 1, 3, 3, 1, 1}, {2, 1, 3, 1, 3, 1}, {3, 1, 1, 1, 2, 3},
+	{3, 1, 1, 3, 2, 1}, {3, 3, 1, 1, 2, 1}, {3, 1, 2, 1, 1, 3}, {3, 1, 2, 3, 1, 1}, {3, 3, 2, 1, 1, 1},
+	{3, 1, 4, 1, 1, 1}, {2, 2, 1, 4, 1, 1}, {4, 3, 1, 1, 1, 1}, {1, 1, 1, 2, 2, 4}, {1, 1, 1, 4, 2, 2},
+	{1, 2, 1, 1, 2, 4}, {1, 2, 1, 4, 2, 1}, {1, 4, 1, 1, 2, 2}, {1, 4, 1, 2, 2, 1}, {1, 1, 2, 2, 1, 4},
+	{1, 1, 2, 4, 1, 2}, {1, 2, 2, 1, 1, 4}, {1, 2, 2, 4, 1, 1}, {1, 4, 2, 1, 1, 2}, {1, 4, 2, 2, 1, 1},
+	{2, 4, 1, 2, 1, 1}, {2, 2, 1, 1, 1, 4}, {4, 1, 3, 1, 1, 1}, {2, 4, 1, 1, 1, 2}, {1, 3, 4, 1, 1, 1},
+	{1, 1, 1, 2, 4, 2}, {1, 2, 1, 1, 4, 2}, {1, 2, 1, 2, 4, 1}, {1, 1, 4, 2, 1, 2}, {1, 2, 4, 1, 1, 2},
+	{1, 2, 4, 2, 1, 1}, {4, 1, 1, 2, 1, 2}, {4, 2, 1, 1, 1, 2}, {4, 2, 1, 2, 1, 1}, {2, 1, 2, 1, 4, 1},
+	{2, 1, 4, 1, 2, 1}, {4, 1, 2, 1, 2, 1}, {1, 1, 1, 1, 4, 3}, {1, 1, 1, 3, 4, 1}, {1, 3, 1, 1, 4, 1},
+	{1, 1, 4, 1, 1, 3}, {1, 1, 4, 3, 1, 1}, {4, 1, 1, 1, 1, 3}, {4, 1, 1, 3, 1, 1}, {1, 1, 3, 1, 4, 1},
+	{1, 1, 4, 1, 3, 1}, {3, 1, 1, 1, 4, 1}, {4, 1, 1, 1, 3, 1}, {2, 1, 1, 4, 1, 2}, {2, 1, 1, 2, 1, 4},
+	{2, 1, 1, 2, 3, 2},
+}
+
+var code128Stop = []int{2, 3, 3, 1, 1, 1, 2}
+
+// Code 128 symbol values with special meaning
+const (
+	code128StartA = 103
+	code128StartB = 104
+	code128StartC = 105
+	code128ShiftV = 98
+	code128CodeC  = 99
+	code128CodeB  = 100 // in code set A, FNC4 in B
+	code128CodeA  = 101 // in code set B, FNC4 in A
+	code128FNC1   = 102
+)
+
+// decodeCode128 decodes a Code 128 barcode starting at runs[i].
+func decodeCode128(runs []int, i, quiet int) (string, bool) {
+
+	if i+6 > len(runs) {
+		return "", false
+	}
+	start, e := matchPattern(runs[i:i+6], code128Patterns)
+	if e > maxPatternErr || start < code128StartA || start > code128StartC {
+		return "", false
+	}
+	if quiet > 0 && quiet < sum(runs[i:i+6])*5/11 {
+		return "", false
+	}
+
+	var vals []int
+	o := i + 6
+	for {
+		// stop is the only symbol with 7 runs, check for it first
+		if o+7 <= len(runs) {
+			if _, e := matchPattern(runs[o:o+7], [][]int{code128Stop}); e <= maxPatternErr {
+				break
+			}
+		}
+		if o+6 > len(runs) {
+			return "", false
+		}
+		v, e := matchPattern(runs[o:o+6], code128Patterns)
+		if e > maxPatternErr || v >= code128StartA {
+			return "", false
+		}
+		vals = append(vals, v)
+		o += 6
+	}
+	if len(vals) < 2 {
+		return "", false
+	}
+
+	check := vals[len(vals)-1]
+	vals = vals[:len(vals)-1]
+	total := start
+	for n, v := range vals {
+		total += (n + 1) * v
+	}
+	if total%103 != check {
+		return "", false
+	}
+
+	var sb strings.Builder
+	set := start
+	for n := 0; n < len(vals); n++ {
+		v := vals[n]
+		switch set {
+		case code128StartC:
+			switch {
+			case v < 100:
+				sb.WriteByte(byte('0' + v/10))
+				sb.WriteByte(byte('0' + v%10))
+			case v == code128CodeB:
+				set = code128StartB
+			case v == code128CodeA:
+				set = code128StartA
+			}
+		default:
+			switch {
+			case v < 96:
+				sb.WriteByte(code128Char(set, v))
+			case v == code128ShiftV && n+1 < len(vals):
+				n++
+				other := code128StartA
+				if set == code128StartA {
+					other = code128StartB
+				}
+				sb.WriteByte(code128Char(other, vals[n]))
+			case v == code128CodeC:
+				set = code128StartC
+			case v == code128CodeB && set == code128StartA:
+				set = code128StartB
+			case v == code128CodeA && set == code128StartB:
+				set = code128StartA
+			}
+			// FNC1-4 are not part of the value
+		}
+	}
+	return sb.String(), true
+}
+
+// code128Char returns the character for value v (< 96) in code set A or B.
+func code128Char(set, v int) byte {
+	if set == code128StartA && v >= 64 {
+		return byte(v - 64)
+	}
+	return byte(v + 32)
+}
+
+func sum(s []int) int {
+	t := 0
+	for _, v := range s {
+		t += v
+	}
+	return t
+}
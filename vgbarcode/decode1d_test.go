@@ -0,0 +1,126 @@
+package vgbarcode
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+// drawModules draws widths (alternating dark and light, starting with dark) as a barcode image
+// with a quiet zone either side, and light/dark levels that are not pure white/black.
+func drawModules(widths []int, scale int, flip bool) *image.Gray {
+	modules := 20
+	for _, w := range widths {
+		modules += w
+	}
+	img := image.NewGray(image.Rect(0, 0, modules*scale, 30))
+	for i := range img.Pix {
+		img.Pix[i] = 200
+	}
+	x := 10 * scale
+	for i, w := range widths {
+		if i%2 == 0 {
+			for y := 0; y < 30; y++ {
+				for dx := 0; dx < w*scale; dx++ {
+					px := x + dx
+					if flip {
+						px = img.Bounds().Dx() - 1 - px
+					}
+					img.Pix[y*img.Stride+px] = 40
+				}
+			}
+		}
+		x += w * scale
+	}
+	return img
+}
+
+func eanWidths(s string) []int {
+	half := len(s) / 2
+	var parity string
+	digits := s
+	if len(s) == 13 {
+		parity = ean13Parity[s[0]-'0']
+		digits = s[1:]
+	}
+	w := []int{1, 1, 1}
+	for i := 0; i < half; i++ {
+		p := eanLPatterns[digits[i]-'0']
+		if parity != "" && parity[i] == '1' {
+			p = []int{p[3], p[2], p[1], p[0]}
+		}
+		w = append(w, p...)
+	}
+	w = append(w, 1, 1, 1, 1, 1)
+	for i := half; i < len(digits); i++ {
+		w = append(w, eanLPatterns[digits[i]-'0']...)
+	}
+	// the left digits start with light, so the runs above alternate correctly from the first dark guard bar
+	return append(w, 1, 1, 1)
+}
+
+func code128Widths(vals []int) []int {
+	var w []int
+	check := vals[0]
+	for i, v := range vals {
+		w = append(w, code128Patterns[v]...)
+		check += i * v
+	}
+	w = append(w, code128Patterns[check%103]...)
+	return append(w, code128Stop...)
+}
+
+func TestLinearDecoder(t *testing.T) {
+
+	tcList := []struct {
+		name   string
+		widths []int
+		flip   bool
+		expect []Result
+	}{
+		{name: "ean13", widths: eanWidths("4006381333931"), expect: []Result{{"4006381333931", EAN13}}},
+		{name: "ean13-flip", widths: eanWidths("4006381333931"), flip: true, expect: []Result{{"4006381333931", EAN13}}},
+		{name: "upca", widths: eanWidths("0036000291452"), expect: []Result{{"036000291452", UPCA}}},
+		{name: "ean8", widths: eanWidths("96385074"), expect: []Result{{"96385074", EAN8}}},
+		{name: "ean13-bad-check", widths: eanWidths("4006381333932"), expect: nil},
+		// start B "Hi", code C "1234"
+		{name: "code128", widths: code128Widths([]int{code128StartB, 'H' - 32, 'i' - 32, code128CodeC, 12, 34}), expect: []Result{{"Hi1234", Code128}}},
+		{name: "code128-flip", widths: code128Widths([]int{code128StartB, 'H' - 32, 'i' - 32, code128CodeC, 12, 34}), flip: true, expect: []Result{{"Hi1234", Code128}}},
+		// start A, shift to B for a lower case letter
+		{name: "code128-shift", widths: code128Widths([]int{code128StartA, 'A' - 32, code128ShiftV, 'b' - 32, 'C' - 32}), expect: []Result{{"AbC", Code128}}},
+		{name: "blank", widths: nil, expect: nil},
+	}
+
+	for _, tc := range tcList {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for _, scale := range []int{2, 3} {
+				res, err := LinearDecoder{}.Decode(drawModules(tc.widths, scale, tc.flip))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(res, tc.expect) {
+					t.Errorf("scale %d: expected %v, got %v", scale, tc.expect, res)
+				}
+			}
+		})
+	}
+}
+
+func TestCode128Patterns(t *testing.T) {
+	if len(code128Patterns) != 106 {
+		t.Fatalf("expected 106 patterns, got %d", len(code128Patterns))
+	}
+	seen := make(map[[6]int]int)
+	for i, p := range code128Patterns {
+		if sum(p) != 11 {
+			t.Errorf("pattern %d has %d modules", i, sum(p))
+		}
+		var k [6]int
+		copy(k[:], p)
+		if j, ok := seen[k]; ok {
+			t.Errorf("pattern %d is the same as %d", i, j)
+		}
+		seen[k] = i
+	}
+}
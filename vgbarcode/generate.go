@@ -0,0 +1,3 @@
+package vgbarcode
+
+//go:generate vugugen
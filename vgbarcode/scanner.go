@@ -0,0 +1,300 @@
+package vgbarcode
+
+import (
+	"image"
+	"time"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+// Scanner shows the camera in a video element and emits Scan for each barcode it reads.
+// The camera is started when the Scanner is first rendered and stopped when it is destroyed.
+type Scanner struct {
+	Formats  []Format      // formats to read, all formats supported by the browser if empty
+	Facing   string        // camera to use, "environment" (the default) or "user"
+	Interval time.Duration // time between reads, defaults to 250ms
+	Repeat   time.Duration // how long before the same value is emitted again, defaults to 2s
+	Decoder  Decoder       // used if the browser has no BarcodeDetector, defaults to LinearDecoder{}
+
+	Scan  ScanHandler  // called with each value read
+	Error ErrorHandler // called if the camera cannot be started
+
+	AttrMap vugu.AttrMap // regular HTML attributes for the video element like id and class
+
+	eventEnv vugu.EventEnv
+	started  bool
+	done     chan struct{}
+	stream   js.Value
+}
+
+// Init implements vugu.Initer.
+func (c *Scanner) Init(ctx vugu.InitCtx) {
+	c.eventEnv = ctx.EventEnv()
+}
+
+// Destroy implements vugu.Destroyer, it stops the camera.
+func (c *Scanner) Destroy() {
+	if c.done != nil {
+		close(c.done)
+		c.done = nil
+	}
+	if c.stream.Truthy() {
+		tracks := c.stream.Call("getTracks")
+		for i := 0; i < tracks.Length(); i++ {
+			tracks.Index(i).Call("stop")
+		}
+		c.stream = js.Null()
+	}
+}
+
+func (c *Scanner) handleVideo(video js.Value) {
+	if c.started || !video.Truthy() {
+		return
+	}
+	c.started = true
+	c.done = make(chan struct{})
+
+	s := scan{
+		video:    video,
+		formats:  c.Formats,
+		facing:   c.Facing,
+		interval: c.Interval,
+		repeat:   c.Repeat,
+		decoder:  c.Decoder,
+		done:     c.done,
+		c:        c,
+	}
+	if s.facing == "" {
+		s.facing = "environment"
+	}
+	if s.interval <= 0 {
+		s.interval = 250 * time.Millisecond
+	}
+	if s.repeat <= 0 {
+		s.repeat = 2 * time.Second
+	}
+	if s.decoder == nil {
+		s.decoder = LinearDecoder{}
+	}
+	go s.run()
+}
+
+// scan holds the state of the goroutine reading from the camera, the Scanner's fields are only
+// touched with the EventEnv locked.
+type scan struct {
+	video    js.Value
+	formats  []Format
+	facing   string
+	interval time.Duration
+	repeat   time.Duration
+	decoder  Decoder
+	done     chan struct{}
+	c        *Scanner
+
+	detector js.Value
+	canvas   js.Value
+	last     map[string]time.Time
+}
+
+func (s *scan) run() {
+
+	stream, err := s.start()
+	if !s.lock() {
+		if stream.Truthy() { // destroyed while waiting for the camera
+			tracks := stream.Call("getTracks")
+			for i := 0; i < tracks.Length(); i++ {
+				tracks.Index(i).Call("stop")
+			}
+		}
+		return
+	}
+	if err != nil {
+		if s.c.Error != nil {
+			s.c.Error.ErrorHandle(ErrorEvent{Err: err})
+		}
+		s.unlock()
+		return
+	}
+	s.c.stream = stream
+	s.unlock()
+
+	s.last = make(map[string]time.Time)
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-t.C:
+		}
+
+		// HAVE_CURRENT_DATA or better
+		if s.video.Get("readyState").Int() < 2 {
+			continue
+		}
+		results, err := s.detect()
+		if err != nil || len(results) == 0 {
+			continue
+		}
+		s.emit(results)
+	}
+}
+
+// start asks for the camera and starts playing it in the video element.
+func (s *scan) start() (js.Value, error) {
+
+	md := js.Global().Get("navigator").Get("mediaDevices")
+	if !md.Truthy() || !md.Get("getUserMedia").Truthy() {
+		return js.Null(), ErrNotAvailable
+	}
+
+	video := js.Global().Get("Object").New()
+	video.Set("facingMode", s.facing)
+	constraints := js.Global().Get("Object").New()
+	constraints.Set("video", video)
+	constraints.Set("audio", false)
+	stream, err := js.Await(md.Call("getUserMedia", constraints), js.NewDOMError)
+	if err != nil {
+		return js.Null(), err
+	}
+
+	// muted and playsinline are needed for autoplay on mobile browsers
+	s.video.Set("muted", true)
+	s.video.Call("setAttribute", "playsinline", "")
+	s.video.Set("srcObject", stream)
+	s.video.Call("play")
+
+	if DetectorAvailable() {
+		opts := js.Global().Get("Object").New()
+		if len(s.formats) > 0 {
+			formats := js.Global().Get("Array").New()
+			for _, f := range s.formats {
+				formats.Call("push", string(f))
+			}
+			opts.Set("formats", formats)
+		}
+		s.detector = js.Global().Get("BarcodeDetector").New(opts)
+	}
+
+	return stream, nil
+}
+
+// detect reads barcodes from the current video frame.
+func (s *scan) detect() ([]Result, error) {
+
+	if s.detector.Truthy() {
+		v, err := js.Await(s.detector.Call("detect", s.video), js.NewDOMError)
+		if err != nil {
+			return nil, err
+		}
+		ret := make([]Result, 0, v.Length())
+		for i := 0; i < v.Length(); i++ {
+			ret = append(ret, Result{
+				Value:  v.Index(i).Get("rawValue").String(),
+				Format: Format(v.Index(i).Get("format").String()),
+			})
+		}
+		return ret, nil
+	}
+
+	img := s.frame()
+	if img == nil {
+		return nil, nil
+	}
+	results, err := s.decoder.Decode(img)
+	if err != nil || len(s.formats) == 0 {
+		return results, err
+	}
+	ret := results[:0]
+	for _, r := range results {
+		for _, f := range s.formats {
+			if r.Format == f {
+				ret = append(ret, r)
+				break
+			}
+		}
+	}
+	return ret, nil
+}
+
+// maxFrameWidth limits the size of frames copied to Go for decoding
+const maxFrameWidth = 640
+
+// frame copies the current video frame into a grayscale image.
+func (s *scan) frame() *image.Gray {
+
+	w, h := s.video.Get("videoWidth").Int(), s.video.Get("videoHeight").Int()
+	if w == 0 || h == 0 {
+		return nil
+	}
+	if w > maxFrameWidth {
+		h = h * maxFrameWidth / w
+		w = maxFrameWidth
+	}
+
+	if !s.canvas.Truthy() {
+		s.canvas = js.Global().Get("document").Call("createElement", "canvas")
+	}
+	if s.canvas.Get("width").Int() != w || s.canvas.Get("height").Int() != h {
+		s.canvas.Set("width", w)
+		s.canvas.Set("height", h)
+	}
+	ctx := s.canvas.Call("getContext", "2d")
+	ctx.Call("drawImage", s.video, 0, 0, w, h)
+	data := ctx.Call("getImageData", 0, 0, w, h).Get("data")
+
+	rgba := make([]byte, w*h*4)
+	js.CopyBytesToGo(rgba, js.Global().Get("Uint8Array").New(data.Get("buffer")))
+
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		p := rgba[i*4:]
+		img.Pix[i] = uint8((299*int(p[0]) + 587*int(p[1]) + 114*int(p[2])) / 1000)
+	}
+	return img
+}
+
+// emit calls the Scan handler for each result not already emitted within the repeat time.
+func (s *scan) emit(results []Result) {
+	now := time.Now()
+	if !s.lock() {
+		return
+	}
+	defer s.unlock()
+	for _, r := range results {
+		if t, ok := s.last[r.Value]; ok && now.Sub(t) < s.repeat {
+			continue
+		}
+		s.last[r.Value] = now
+		if s.c.Scan != nil {
+			s.c.Scan.ScanHandle(ScanEvent{Result: r})
+		}
+	}
+}
+
+// lock locks the EventEnv, returning false (and leaving it unlocked) if the Scanner has been destroyed.
+func (s *scan) lock() bool {
+	if s.c.eventEnv != nil {
+		s.c.eventEnv.Lock()
+	}
+	select {
+	case <-s.done:
+		s.unlockNoRender()
+		return false
+	default:
+	}
+	return true
+}
+
+func (s *scan) unlock() {
+	if s.c.eventEnv != nil {
+		s.c.eventEnv.UnlockRender()
+	}
+}
+
+func (s *scan) unlockNoRender() {
+	if s.c.eventEnv != nil {
+		s.c.eventEnv.UnlockOnly()
+	}
+}
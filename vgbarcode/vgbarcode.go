@@ -0,0 +1,105 @@
+/*
+Package vgbarcode reads barcodes and QR codes with the device camera.
+
+The Scanner component shows the camera in a video element and emits a Scan event for each value
+it reads:
+
+	<vgbarcode:Scanner :Formats='[]vgbarcode.Format{vgbarcode.QRCode, vgbarcode.EAN13}'
+		@Scan='c.code = event.Value'
+		@Error='c.err = event.Err'></vgbarcode:Scanner>
+
+Where the browser has BarcodeDetector it is used for detection, which supports all Formats.  Otherwise
+frames are decoded in Go by the Scanner's Decoder, by default a LinearDecoder which reads the common 1D
+formats (EAN-13, EAN-8, UPC-A and Code 128).  Set Decoder to one of your own to read other formats
+in browsers without BarcodeDetector.
+*/
+package vgbarcode
+
+import (
+	"errors"
+	"image"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// ErrNotAvailable is returned when the camera is not available (or outside of the browser).
+var ErrNotAvailable = errors.New("vgbarcode: camera is not available in this environment")
+
+// Format is a barcode format, named as for BarcodeDetector.
+type Format string
+
+// Available Formats.
+const (
+	Aztec      Format = "aztec"
+	Code128    Format = "code_128"
+	Code39     Format = "code_39"
+	Code93     Format = "code_93"
+	Codabar    Format = "codabar"
+	DataMatrix Format = "data_matrix"
+	EAN13      Format = "ean_13"
+	EAN8       Format = "ean_8"
+	ITF        Format = "itf"
+	PDF417     Format = "pdf417"
+	QRCode     Format = "qr_code"
+	UPCA       Format = "upc_a"
+	UPCE       Format = "upc_e"
+)
+
+// Result is a decoded barcode.
+type Result struct {
+	Value  string
+	Format Format
+}
+
+// Decoder decodes barcodes in an image.  It is used by Scanner for browsers without BarcodeDetector.
+// Decode should return no results and a nil error if the image has no barcode.
+type Decoder interface {
+	Decode(img *image.Gray) ([]Result, error)
+}
+
+// DetectorAvailable returns true if the browser has BarcodeDetector.
+func DetectorAvailable() bool {
+	return js.Global().Get("BarcodeDetector").Truthy()
+}
+
+// ScanEvent is emitted by Scanner for each value read.
+type ScanEvent struct {
+	Result
+}
+
+// ScanHandler is the interface for things that can handle ScanEvent.
+type ScanHandler interface {
+	ScanHandle(event ScanEvent)
+}
+
+// ScanFunc implements ScanHandler as a function.
+type ScanFunc func(event ScanEvent)
+
+// ScanHandle implements the ScanHandler interface.
+func (f ScanFunc) ScanHandle(event ScanEvent) { f(event) }
+
+// assert ScanFunc implements ScanHandler
+var _ ScanHandler = ScanFunc(nil)
+
+// ErrorEvent is emitted by Scanner when the camera cannot be used, e.g. because the user did not allow it.
+type ErrorEvent struct {
+	Err error
+}
+
+// ErrorHandler is the interface for things that can handle ErrorEvent.
+type ErrorHandler interface {
+	ErrorHandle(event ErrorEvent)
+}
+
+// ErrorFunc implements ErrorHandler as a function.
+type ErrorFunc func(event ErrorEvent)
+
+// ErrorHandle implements the ErrorHandler interface.
+func (f ErrorFunc) ErrorHandle(event ErrorEvent) { f(event) }
+
+// assert ErrorFunc implements ErrorHandler
+var _ ErrorHandler = ErrorFunc(nil)
+
+// Error is a failure reported by the browser, e.g. Name is "NotAllowedError" if the user did not
+// allow the camera to be used.
+type Error = js.DOMError
@@ -0,0 +1,165 @@
+/*
+Package vgbase lets an app be served under a path prefix, e.g. one per tenant, and load its assets from
+another prefix such as a CDN, instead of assuming it is at the root of the site.
+
+The server puts the prefixes in the page with Tags, which writes a base element and, if the assets are
+elsewhere, a meta element:
+
+	<base href="/tenant1/app/"/>
+	<meta name="vugu-asset-base" content="https://cdn.example.com/app/v3/"/>
+
+Relative URLs in the page and in components ("img/logo.png", not "/img/logo.png") then resolve under
+the base href in the browser and in server rendered output alike.  The default index pages in devutil
+and simplehttp load wasm_exec.js and main.wasm this way.
+
+In the app, the prefixes are read from the page once, the first time they are needed.  Use URL and Path
+to convert between the paths a router matches and the URLs in the address bar, and AssetURL for assets
+which must come from the asset base, e.g. ones referred to from JS:
+
+	route, ok := vgbase.Path(js.Global().Get("location").Get("pathname").String()) // "/tenant1/app/users" -> "/users"
+	href := vgbase.URL("/users/42")                                              // "/tenant1/app/users/42"
+	src := vgbase.AssetURL("img/logo.png")                                       // "https://cdn.example.com/app/v3/img/logo.png"
+
+Outside of the browser the base href is "/" unless Set has been called, as it may be for server-side
+rendering or tests.
+*/
+package vgbase
+
+import (
+	"html"
+	"strings"
+	"sync"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// AssetBaseMetaName is the name of the meta element with the asset base.
+const AssetBaseMetaName = "vugu-asset-base"
+
+var (
+	mu                  sync.Mutex
+	loaded              bool
+	baseHref, assetHref string
+)
+
+// load reads the prefixes from the page, if it has not been done already.  mu must be held.
+func load() {
+
+	if loaded {
+		return
+	}
+	loaded = true
+	baseHref, assetHref = "/", ""
+
+	doc := js.Global().Get("document")
+	if !doc.Truthy() {
+		return
+	}
+	url := js.Global().Get("URL")
+	if el := doc.Call("querySelector", "base[href]"); el.Truthy() {
+		// el.href is the attribute resolved against the page
+		baseHref = Clean(url.New(el.Get("href")).Get("pathname").String())
+	}
+	if el := doc.Call("querySelector", `meta[name="`+AssetBaseMetaName+`"]`); el.Truthy() {
+		assetHref = Clean(url.New(el.Get("content"), doc.Get("baseURI")).Get("href").String())
+	}
+}
+
+// Href returns the base href of the app, a path starting and ending with "/".
+func Href() string {
+	mu.Lock()
+	defer mu.Unlock()
+	load()
+	return baseHref
+}
+
+// AssetHref returns the prefix assets are loaded from, ending with "/".  It is the base href unless the
+// page has an asset base.
+func AssetHref() string {
+	mu.Lock()
+	defer mu.Unlock()
+	load()
+	if assetHref == "" {
+		return baseHref
+	}
+	return assetHref
+}
+
+// Set makes Href and AssetHref return href and assets instead of reading them from the page.  An empty
+// assets means assets are under href.
+func Set(href, assets string) {
+	mu.Lock()
+	defer mu.Unlock()
+	loaded = true
+	baseHref, assetHref = Clean(href), cleanAsset(assets)
+}
+
+// URL returns the address of the app's route path p (e.g. "/users/42") under the base href.  Absolute
+// URLs are returned unchanged.
+func URL(p string) string {
+	return join(Href(), p)
+}
+
+// AssetURL returns the address of the asset at p (e.g. "img/logo.png") under the asset base.  Absolute
+// URLs are returned unchanged.
+func AssetURL(p string) string {
+	return join(AssetHref(), p)
+}
+
+// Path returns the app's route path for the path p of a URL under the base href, the inverse of URL.
+// It returns false if p is not under the base href.
+func Path(p string) (string, bool) {
+	return strip(Href(), p)
+}
+
+// Tags returns the elements to put in the head of the page for the base href and, if not empty, the
+// asset base.
+func Tags(href, assets string) string {
+	ret := `<base href="` + html.EscapeString(Clean(href)) + `"/>`
+	if assets = cleanAsset(assets); assets != "" {
+		ret += "\n" + `<meta name="` + AssetBaseMetaName + `" content="` + html.EscapeString(assets) + `"/>`
+	}
+	return ret
+}
+
+// Clean returns href as a base href: with a trailing "/" and, unless it is an absolute URL, a leading one.
+// An empty href is "/".
+func Clean(href string) string {
+	if !isAbs(href) && !strings.HasPrefix(href, "/") {
+		href = "/" + href
+	}
+	if !strings.HasSuffix(href, "/") {
+		href += "/"
+	}
+	return href
+}
+
+func cleanAsset(href string) string {
+	if href == "" {
+		return ""
+	}
+	return Clean(href)
+}
+
+// isAbs returns true if u has a scheme or is protocol relative.
+func isAbs(u string) bool {
+	return strings.HasPrefix(u, "//") || strings.Contains(u, "://") ||
+		strings.HasPrefix(u, "data:") || strings.HasPrefix(u, "blob:")
+}
+
+func join(base, p string) string {
+	if isAbs(p) {
+		return p
+	}
+	return base + strings.TrimPrefix(p, "/")
+}
+
+func strip(base, p string) (string, bool) {
+	if p+"/" == base {
+		return "/", true
+	}
+	if !strings.HasPrefix(p, base) {
+		return "", false
+	}
+	return "/" + strings.TrimPrefix(p, base), true
+}
@@ -0,0 +1,45 @@
+package vgbase
+
+import "testing"
+
+func TestBase(t *testing.T) {
+
+	if Href() != "/" || AssetHref() != "/" {
+		t.Errorf("expected root base outside the browser, got %q, %q", Href(), AssetHref())
+	}
+
+	Set("tenant1/app", "")
+	defer Set("/", "")
+	if got := URL("/users/42"); got != "/tenant1/app/users/42" {
+		t.Errorf("URL: got %q", got)
+	}
+	if got := AssetURL("img/logo.png"); got != "/tenant1/app/img/logo.png" {
+		t.Errorf("AssetURL: got %q", got)
+	}
+	if got := URL("https://example.com/x"); got != "https://example.com/x" {
+		t.Errorf("URL of absolute URL: got %q", got)
+	}
+	for p, want := range map[string]string{
+		"/tenant1/app":          "/",
+		"/tenant1/app/":         "/",
+		"/tenant1/app/users/42": "/users/42",
+		"/tenant2/app/users":    "",
+	} {
+		if got, ok := Path(p); got != want || ok != (want != "") {
+			t.Errorf("Path(%q) = %q, %v; want %q", p, got, ok, want)
+		}
+	}
+
+	Set("/tenant1/app/", "https://cdn.example.com/v3")
+	if got := AssetURL("/main.wasm"); got != "https://cdn.example.com/v3/main.wasm" {
+		t.Errorf("AssetURL with asset base: got %q", got)
+	}
+
+	want := `<base href="/a&amp;b/"/>` + "\n" + `<meta name="vugu-asset-base" content="//cdn.example.com/"/>`
+	if got := Tags("a&b", "//cdn.example.com"); got != want {
+		t.Errorf("Tags: got %s", got)
+	}
+	if got := Tags("", ""); got != `<base href="/"/>` {
+		t.Errorf("Tags: got %s", got)
+	}
+}
@@ -0,0 +1,184 @@
+package vgchart
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+// Chart is a chart drawn by Chart.js, see the package documentation.
+type Chart struct {
+	Config Config
+
+	Click ClickHandler // called when an element of the chart is clicked
+	Error ErrorHandler // called if the chart cannot be created or updated
+
+	AttrMap vugu.AttrMap // regular HTML attributes for the element around the canvas like id and class
+
+	eventEnv  vugu.EventEnv
+	canvas    js.Value
+	chart     js.Value
+	shown     interface{} // Config as sent to the chart, decoded by encoding/json
+	shownType Type
+	clickFunc js.Func
+}
+
+// Init implements vugu.Initer.
+func (c *Chart) Init(ctx vugu.InitCtx) {
+	c.eventEnv = ctx.EventEnv()
+}
+
+// Compute implements vugu.Computer, it sends the changes to Config to the chart.
+func (c *Chart) Compute(ctx vugu.ComputeCtx) {
+	if !c.canvas.Truthy() {
+		return
+	}
+	if err := c.update(); err != nil && c.Error != nil {
+		c.Error.ErrorHandle(ErrorEvent{Err: err})
+	}
+}
+
+// Destroy implements vugu.Destroyer, it destroys the chart.
+func (c *Chart) Destroy() {
+	c.destroyChart()
+	if c.clickFunc.Truthy() {
+		c.clickFunc.Release()
+		c.clickFunc = js.Func{}
+	}
+	c.canvas = js.Null()
+}
+
+func (c *Chart) destroyChart() {
+	if c.chart.Truthy() {
+		c.chart.Call("destroy")
+		c.chart = js.Null()
+	}
+	c.shown = nil
+}
+
+func (c *Chart) handleCreate(canvas js.Value) {
+	c.canvas = canvas
+	if c.chart.Truthy() {
+		return
+	}
+	if err := c.update(); err != nil && c.Error != nil {
+		c.Error.ErrorHandle(ErrorEvent{Err: err})
+	}
+}
+
+// update creates the chart, or else applies the differences between Config and what was sent before.
+func (c *Chart) update() error {
+
+	next, err := toJSONTree(c.Config)
+	if err != nil {
+		return err
+	}
+
+	if c.chart.Truthy() && c.Config.Type == c.shownType {
+		patches, err := diffJSON(nil, c.shown, next)
+		if err != nil || len(patches) == 0 {
+			return err
+		}
+		if err := applyPatches(c.chart, patches); err != nil {
+			return err
+		}
+		c.chart.Get("options").Set("onClick", c.clickFunc)
+		c.chart.Call("update")
+		c.shown = next
+		return nil
+	}
+
+	c.destroyChart()
+	chartClass := js.Global().Get("Chart")
+	if !chartClass.Truthy() || !c.canvas.Truthy() {
+		return ErrNotAvailable
+	}
+	b, err := json.Marshal(next)
+	if err != nil {
+		return err
+	}
+	config := js.Global().Get("JSON").Call("parse", string(b))
+	if !config.Get("options").Truthy() {
+		config.Set("options", js.Global().Get("Object").New())
+	}
+	if !c.clickFunc.Truthy() {
+		c.clickFunc = js.FuncOf(c.handleClick)
+	}
+	config.Get("options").Set("onClick", c.clickFunc)
+	c.chart = chartClass.New(c.canvas, config)
+	c.shown, c.shownType = next, c.Config.Type
+	return nil
+}
+
+// applyPatches makes the changes in patches to the chart's config.
+func applyPatches(chart js.Value, patches []patch) error {
+	for _, p := range patches {
+		if len(p.Path) == 0 {
+			return fmt.Errorf("vgchart: cannot replace the whole config")
+		}
+		obj := chart
+		for _, key := range p.Path[:len(p.Path)-1] {
+			obj = getKey(obj, key)
+			if !obj.Truthy() {
+				return fmt.Errorf("vgchart: no object at %v", p.Path)
+			}
+		}
+		last := p.Path[len(p.Path)-1]
+		if p.Delete {
+			js.Global().Get("Reflect").Call("deleteProperty", obj, fmt.Sprint(last))
+			continue
+		}
+		value := js.Global().Get("JSON").Call("parse", p.Value)
+		if i, ok := last.(int); ok {
+			obj.SetIndex(i, value)
+		} else {
+			obj.Set(last.(string), value)
+		}
+	}
+	return nil
+}
+
+func getKey(v js.Value, key interface{}) js.Value {
+	if i, ok := key.(int); ok {
+		return v.Index(i)
+	}
+	return v.Get(key.(string))
+}
+
+// handleClick is called by Chart.js with the event, the active elements and the chart.
+func (c *Chart) handleClick(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 || args[1].Length() == 0 {
+		return nil
+	}
+	el := args[1].Index(0)
+	datasetIndex, index := el.Get("datasetIndex").Int(), el.Get("index").Int()
+	go func() {
+		c.eventEnv.Lock()
+		if !c.chart.Truthy() {
+			c.eventEnv.UnlockOnly()
+			return
+		}
+		event := clickEvent(c.Config, datasetIndex, index)
+		if c.Click != nil {
+			c.Click.ClickHandle(event)
+		}
+		c.eventEnv.UnlockRender()
+	}()
+	return nil
+}
+
+// clickEvent returns the ClickEvent for the value at index in the dataset at datasetIndex.
+func clickEvent(config Config, datasetIndex, index int) ClickEvent {
+	event := ClickEvent{DatasetIndex: datasetIndex, Index: index}
+	if index >= 0 && index < len(config.Data.Labels) {
+		event.Label = config.Data.Labels[index]
+	}
+	if datasetIndex >= 0 && datasetIndex < len(config.Data.Datasets) {
+		if data := config.Data.Datasets[datasetIndex].Data; index >= 0 && index < len(data) {
+			event.Value = data[index]
+		}
+	}
+	return event
+}
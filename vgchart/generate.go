@@ -0,0 +1,3 @@
+package vgchart
+
+//go:generate vugugen
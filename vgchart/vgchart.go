@@ -0,0 +1,223 @@
+/*
+Package vgchart provides a Chart component which draws charts with Chart.js from Go data.
+
+Chart.js (version 3 or later) must be loaded by the page so window.Chart exists.  The chart is described
+by a Config, much like the object passed to new Chart() in JS:
+
+	<vgchart:Chart :Config='c.chartConfig()' style="height:300px"
+		@Click='c.selected = event.Label'></vgchart:Chart>
+
+with
+
+	func (c *Root) chartConfig() vgchart.Config {
+		return vgchart.Config{
+			Type: vgchart.Line,
+			Data: vgchart.Data{
+				Labels:   c.days,
+				Datasets: []vgchart.Dataset{{Label: "Visits", Data: c.visits, BorderColor: "#36a2eb"}},
+			},
+			Options: map[string]interface{}{"maintainAspectRatio": false},
+		}
+	}
+
+Each time the Chart is rendered its Config is compared with the previous one and only the parts which
+changed are sent to the chart, which then animates the change.  Changing Type creates a new chart.
+Clicks on the chart's elements come back as ClickEvent.  Options are as documented for Chart.js, except
+that JS functions (such as callbacks for tick labels) cannot be given from Go.
+*/
+package vgchart
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+)
+
+// ErrNotAvailable is returned when Chart.js has not been loaded (or outside of the browser).
+var ErrNotAvailable = errors.New("vgchart: Chart.js is not available in this environment")
+
+// Type is a kind of chart.
+type Type string
+
+// The chart types built into Chart.js.
+const (
+	Line      Type = "line"
+	Bar       Type = "bar"
+	Pie       Type = "pie"
+	Doughnut  Type = "doughnut"
+	PolarArea Type = "polarArea"
+	Radar     Type = "radar"
+	Scatter   Type = "scatter"
+	Bubble    Type = "bubble"
+)
+
+// Config describes a chart.
+type Config struct {
+	Type    Type                   `json:"type"`
+	Data    Data                   `json:"data"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// Data is the data shown in a chart.
+type Data struct {
+	Labels   []string  `json:"labels,omitempty"`
+	Datasets []Dataset `json:"datasets"`
+}
+
+// Dataset is a series of values.  The common styling properties have fields, others can be set
+// in Options, e.g. {"pointRadius": 0}.
+type Dataset struct {
+	Type            Type        `json:"type,omitempty"` // for mixed charts
+	Label           string      `json:"label,omitempty"`
+	Data            []float64   `json:"data"`
+	BackgroundColor interface{} `json:"backgroundColor,omitempty"` // a CSS color, or a []string with one for each value
+	BorderColor     interface{} `json:"borderColor,omitempty"`     // likewise
+	BorderWidth     int         `json:"borderWidth,omitempty"`
+	Fill            interface{} `json:"fill,omitempty"` // e.g. true or "origin"
+	Tension         float64     `json:"tension,omitempty"`
+	Hidden          bool        `json:"hidden,omitempty"`
+
+	Options map[string]interface{} `json:"-"` // other properties, merged into the dataset
+}
+
+// MarshalJSON implements json.Marshaler, merging Options into the dataset.
+func (d Dataset) MarshalJSON() ([]byte, error) {
+	type dataset Dataset // without the MarshalJSON method
+	b, err := json.Marshal(dataset(d))
+	if err != nil || len(d.Options) == 0 {
+		return b, err
+	}
+	m := make(map[string]interface{}, len(d.Options)+8)
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	for k, v := range d.Options {
+		if _, ok := m[k]; !ok {
+			m[k] = v
+		}
+	}
+	return json.Marshal(m)
+}
+
+// patch is a change to the chart's config, Value is JSON, Delete removes the property instead.
+type patch struct {
+	Path   []interface{} `json:"path"` // property names and array indexes from the config
+	Value  string        `json:"value,omitempty"`
+	Delete bool          `json:"delete,omitempty"`
+}
+
+// diffJSON returns the patches which turn prev into next, both as decoded by encoding/json.  Objects are
+// compared property by property and arrays of the same length element by element, anything else is replaced.
+func diffJSON(path []interface{}, prev, next interface{}) ([]patch, error) {
+
+	if reflect.DeepEqual(prev, next) {
+		return nil, nil
+	}
+
+	switch n := next.(type) {
+	case map[string]interface{}:
+		if o, ok := prev.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(n))
+			for k := range n {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys) // so the patches are always in the same order
+			var ret []patch
+			for _, k := range keys {
+				pl, err := diffJSON(childPath(path, k), o[k], n[k])
+				if err != nil {
+					return nil, err
+				}
+				ret = append(ret, pl...)
+			}
+			keys = keys[:0]
+			for k := range o {
+				if _, ok := n[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				ret = append(ret, patch{Path: childPath(path, k), Delete: true})
+			}
+			return ret, nil
+		}
+	case []interface{}:
+		if o, ok := prev.([]interface{}); ok && len(o) == len(n) {
+			var ret []patch
+			for i := range n {
+				pl, err := diffJSON(childPath(path, i), o[i], n[i])
+				if err != nil {
+					return nil, err
+				}
+				ret = append(ret, pl...)
+			}
+			return ret, nil
+		}
+	}
+
+	b, err := json.Marshal(next)
+	if err != nil {
+		return nil, err
+	}
+	return []patch{{Path: path, Value: string(b)}}, nil
+}
+
+// childPath returns a copy of path with key appended.
+func childPath(path []interface{}, key interface{}) []interface{} {
+	ret := make([]interface{}, len(path), len(path)+1)
+	copy(ret, path)
+	return append(ret, key)
+}
+
+// toJSONTree returns v as decoded by encoding/json.
+func toJSONTree(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var ret interface{}
+	return ret, json.Unmarshal(b, &ret)
+}
+
+// ClickEvent is emitted by Chart when one of the chart's elements (a bar, point, slice, etc.) is clicked.
+type ClickEvent struct {
+	DatasetIndex int
+	Index        int     // the index of the value in the dataset
+	Label        string  // the label for Index, if any
+	Value        float64 // the value clicked
+}
+
+// ClickHandler is the interface for things that can handle ClickEvent.
+type ClickHandler interface {
+	ClickHandle(event ClickEvent)
+}
+
+// ClickFunc implements ClickHandler as a function.
+type ClickFunc func(event ClickEvent)
+
+// ClickHandle implements the ClickHandler interface.
+func (f ClickFunc) ClickHandle(event ClickEvent) { f(event) }
+
+// assert ClickFunc implements ClickHandler
+var _ ClickHandler = ClickFunc(nil)
+
+// ErrorEvent is emitted by Chart when the chart cannot be created or updated.
+type ErrorEvent struct {
+	Err error
+}
+
+// ErrorHandler is the interface for things that can handle ErrorEvent.
+type ErrorHandler interface {
+	ErrorHandle(event ErrorEvent)
+}
+
+// ErrorFunc implements ErrorHandler as a function.
+type ErrorFunc func(event ErrorEvent)
+
+// ErrorHandle implements the ErrorHandler interface.
+func (f ErrorFunc) ErrorHandle(event ErrorEvent) { f(event) }
+
+// assert ErrorFunc implements ErrorHandler
+var _ ErrorHandler = ErrorFunc(nil)
@@ -0,0 +1,85 @@
+package vgchart
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	js "github.com/vugu/vugu/js"
+)
+
+func TestDatasetJSON(t *testing.T) {
+	b, err := json.Marshal(Dataset{Label: "a", Data: []float64{1, 2}, Options: map[string]interface{}{"pointRadius": 0, "label": "ignored"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"data":[1,2],"label":"a","pointRadius":0}` {
+		t.Errorf("unexpected JSON %s", b)
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+
+	prev, _ := toJSONTree(Config{
+		Type: Bar,
+		Data: Data{
+			Labels:   []string{"a", "b"},
+			Datasets: []Dataset{{Label: "x", Data: []float64{1, 2}}, {Label: "y", Data: []float64{3, 4}}},
+		},
+		Options: map[string]interface{}{"responsive": true, "indexAxis": "y"},
+	})
+	next, _ := toJSONTree(Config{
+		Type: Bar,
+		Data: Data{
+			Labels:   []string{"a", "b", "c"},
+			Datasets: []Dataset{{Label: "x", Data: []float64{1, 2}}, {Label: "y", Data: []float64{3, 5}}},
+		},
+		Options: map[string]interface{}{"responsive": false},
+	})
+
+	patches, err := diffJSON(nil, prev, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range patches {
+		if p.Delete {
+			got = append(got, fmt.Sprintf("delete %v", p.Path))
+		} else {
+			got = append(got, fmt.Sprintf("%v=%s", p.Path, p.Value))
+		}
+	}
+	want := []string{
+		`[data datasets 1 data 1]=5`,
+		`[data labels]=["a","b","c"]`,
+		`[options responsive]=false`,
+		`delete [options indexAxis]`,
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("unexpected patches:\n%q\nwant:\n%q", got, want)
+	}
+
+	if patches, _ := diffJSON(nil, next, next); len(patches) != 0 {
+		t.Errorf("expected no patches, got %v", patches)
+	}
+}
+
+func TestClickEvent(t *testing.T) {
+	config := Config{Data: Data{Labels: []string{"a", "b"}, Datasets: []Dataset{{Data: []float64{1, 2}}}}}
+	if e := clickEvent(config, 0, 1); e.Label != "b" || e.Value != 2 {
+		t.Errorf("unexpected event %#v", e)
+	}
+	if e := clickEvent(config, 3, 5); e.Label != "" || e.Value != 0 {
+		t.Errorf("unexpected event %#v", e)
+	}
+}
+
+func TestNotAvailable(t *testing.T) {
+	var err error
+	c := &Chart{Error: ErrorFunc(func(event ErrorEvent) { err = event.Err })}
+	c.handleCreate(js.Undefined())
+	if err != ErrNotAvailable {
+		t.Errorf("expected ErrNotAvailable, got %v", err)
+	}
+	c.Destroy()
+}
@@ -0,0 +1,78 @@
+package vgcode
+
+import (
+	js "github.com/vugu/vugu/js"
+)
+
+// CodeMirror is the Adapter for CodeMirror 5, which must be loaded so window.CodeMirror exists, along with
+// the modes for the languages used.  Language is the CodeMirror mode, e.g. "go" or "text/x-go".
+type CodeMirror struct{}
+
+// Mount implements Adapter.
+func (CodeMirror) Mount(el js.Value, config Config, change func()) (Instance, error) {
+
+	codeMirror := js.Global().Get("CodeMirror")
+	if !codeMirror.Truthy() || !el.Truthy() {
+		return nil, ErrNotAvailable
+	}
+
+	opts := map[string]interface{}{
+		"value":    config.Value,
+		"mode":     config.Language,
+		"readOnly": config.ReadOnly,
+	}
+	if config.Theme != "" {
+		opts["theme"] = config.Theme
+	}
+	for k, v := range config.Options {
+		opts[k] = v
+	}
+
+	cm := &codeMirrorInstance{}
+	cm.editor = codeMirror.Invoke(el, jsObject(opts))
+	cm.changeFunc = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		// changes made by SetValue are reported with the origin "setValue"
+		if len(args) > 1 && args[1].Get("origin").Type() == js.TypeString && args[1].Get("origin").String() == "setValue" {
+			return nil
+		}
+		change()
+		return nil
+	})
+	cm.editor.Call("on", "change", cm.changeFunc)
+	return cm, nil
+}
+
+type codeMirrorInstance struct {
+	editor     js.Value
+	changeFunc js.Func
+}
+
+func (cm *codeMirrorInstance) Value() string { return cm.editor.Call("getValue").String() }
+
+func (cm *codeMirrorInstance) SetValue(value string) { cm.editor.Call("setValue", value) }
+
+func (cm *codeMirrorInstance) SetLanguage(language string) {
+	cm.editor.Call("setOption", "mode", language)
+}
+
+func (cm *codeMirrorInstance) SetTheme(theme string) {
+	if theme == "" {
+		theme = "default"
+	}
+	cm.editor.Call("setOption", "theme", theme)
+}
+
+func (cm *codeMirrorInstance) SetReadOnly(readOnly bool) {
+	cm.editor.Call("setOption", "readOnly", readOnly)
+}
+
+func (cm *codeMirrorInstance) Focus() { cm.editor.Call("focus") }
+
+func (cm *codeMirrorInstance) Dispose() {
+	cm.editor.Call("off", "change", cm.changeFunc)
+	wrapper := cm.editor.Call("getWrapperElement")
+	if parent := wrapper.Get("parentNode"); parent.Truthy() {
+		parent.Call("removeChild", wrapper)
+	}
+	cm.changeFunc.Release()
+}
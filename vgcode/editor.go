@@ -0,0 +1,178 @@
+package vgcode
+
+import (
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+// Editor is a code editor, see the package documentation.
+type Editor struct {
+	Adapter   Adapter // the kind of editor, e.g. Monaco{} or CodeMirror{}
+	Value     string  // the content, set when the user edits it before Change is called
+	Language  string
+	Theme     string
+	DarkTheme string // if set, used instead of Theme when the system prefers a dark color scheme
+	ReadOnly  bool
+
+	// Options are passed to the editor when it is created, changes afterwards have no effect.
+	Options map[string]interface{}
+
+	Change ChangeHandler // called when the user edits the value
+	Error  ErrorHandler  // called if the editor cannot be created
+
+	AttrMap vugu.AttrMap // regular HTML attributes for the element like id and class, give it a height
+
+	eventEnv vugu.EventEnv
+	inst     Instance
+	shown    editorState // what the editor has
+
+	dark      bool
+	darkQuery js.Value
+	darkFunc  js.Func
+}
+
+// editorState is what Compute keeps in sync with the editor.
+type editorState struct {
+	value    string
+	language string
+	theme    string
+	readOnly bool
+}
+
+// Init implements vugu.Initer.
+func (c *Editor) Init(ctx vugu.InitCtx) {
+	c.eventEnv = ctx.EventEnv()
+}
+
+// Compute implements vugu.Computer, it updates the editor with changes to the fields.
+func (c *Editor) Compute(ctx vugu.ComputeCtx) {
+	if c.inst == nil {
+		return
+	}
+	want := c.state()
+	if want.value != c.shown.value {
+		c.inst.SetValue(want.value)
+	}
+	if want.language != c.shown.language {
+		c.inst.SetLanguage(want.language)
+	}
+	if want.theme != c.shown.theme {
+		c.inst.SetTheme(want.theme)
+	}
+	if want.readOnly != c.shown.readOnly {
+		c.inst.SetReadOnly(want.readOnly)
+	}
+	c.shown = want
+}
+
+// Destroy implements vugu.Destroyer, it disposes of the editor.
+func (c *Editor) Destroy() {
+	c.dispose()
+	if c.darkQuery.Truthy() {
+		c.darkQuery.Call("removeEventListener", "change", c.darkFunc)
+		c.darkFunc.Release()
+		c.darkQuery = js.Null()
+	}
+}
+
+// Focus moves the keyboard focus to the editor.
+func (c *Editor) Focus() {
+	if c.inst != nil {
+		c.inst.Focus()
+	}
+}
+
+func (c *Editor) state() editorState {
+	s := editorState{value: c.Value, language: c.Language, theme: c.Theme, readOnly: c.ReadOnly}
+	if c.dark && c.DarkTheme != "" {
+		s.theme = c.DarkTheme
+	}
+	return s
+}
+
+func (c *Editor) dispose() {
+	if c.inst != nil {
+		c.inst.Dispose()
+		c.inst = nil
+	}
+}
+
+func (c *Editor) handleCreate(el js.Value) {
+
+	// the callback can come again for the same element, which keeps its editor
+	if c.inst != nil && el.Get("vgcodeInit").Truthy() {
+		return
+	}
+	c.dispose()
+
+	if c.DarkTheme != "" && !c.darkQuery.Truthy() {
+		c.watchColorScheme()
+	}
+
+	adapter := c.Adapter
+	if adapter == nil {
+		adapter = Monaco{}
+	}
+	s := c.state()
+	inst, err := adapter.Mount(el, Config{
+		Value:    s.value,
+		Language: s.language,
+		Theme:    s.theme,
+		ReadOnly: s.readOnly,
+		Options:  c.Options,
+	}, c.handleChange)
+	if err != nil {
+		if c.Error != nil {
+			c.Error.ErrorHandle(ErrorEvent{Err: err})
+		}
+		return
+	}
+	c.inst = inst
+	c.shown = s
+	if el.Truthy() {
+		el.Set("vgcodeInit", true)
+	}
+}
+
+// handleChange is called by the editor from JS, which must not block, so the EventEnv is
+// locked in another goroutine.
+func (c *Editor) handleChange() {
+	inst := c.inst
+	if inst == nil {
+		return
+	}
+	value := inst.Value()
+	go func() {
+		c.eventEnv.Lock()
+		if c.inst != inst || value == c.Value {
+			c.eventEnv.UnlockOnly()
+			return
+		}
+		c.Value = value
+		c.shown.value = value
+		if c.Change != nil {
+			c.Change.ChangeHandle(ChangeEvent{Value: value})
+		}
+		c.eventEnv.UnlockRender()
+	}()
+}
+
+// watchColorScheme follows the system's preferred color scheme for DarkTheme.
+func (c *Editor) watchColorScheme() {
+	matchMedia := js.Global().Get("matchMedia")
+	if !matchMedia.Truthy() {
+		return
+	}
+	c.darkQuery = js.Global().Call("matchMedia", "(prefers-color-scheme: dark)")
+	c.dark = c.darkQuery.Get("matches").Truthy()
+	c.darkFunc = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		dark := len(args) > 0 && args[0].Get("matches").Truthy()
+		go func() {
+			c.eventEnv.Lock()
+			c.dark = dark
+			c.eventEnv.UnlockRender()
+		}()
+		return nil
+	})
+	c.darkQuery.Call("addEventListener", "change", c.darkFunc)
+}
@@ -0,0 +1,110 @@
+package vgcode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+	"github.com/vugu/vugu/staticrender"
+)
+
+type testInstance struct {
+	value    string
+	calls    []string
+	disposed bool
+	change   func()
+}
+
+func (i *testInstance) Value() string           { return i.value }
+func (i *testInstance) SetValue(value string)   { i.value = value; i.calls = append(i.calls, "value") }
+func (i *testInstance) SetLanguage(lang string) { i.calls = append(i.calls, "language "+lang) }
+func (i *testInstance) SetTheme(theme string)   { i.calls = append(i.calls, "theme "+theme) }
+func (i *testInstance) SetReadOnly(ro bool)     { i.calls = append(i.calls, "readonly") }
+func (i *testInstance) Focus()                  {}
+func (i *testInstance) Dispose()                { i.disposed = true }
+
+type testAdapter struct {
+	inst   *testInstance
+	config Config
+}
+
+func (a *testAdapter) Mount(el js.Value, config Config, change func()) (Instance, error) {
+	a.config = config
+	a.inst = &testInstance{value: config.Value, change: change}
+	return a.inst, nil
+}
+
+type testInitCtx struct{ eventEnv vugu.EventEnv }
+
+func (c testInitCtx) EventEnv() vugu.EventEnv { return c.eventEnv }
+
+func TestEditor(t *testing.T) {
+
+	eventEnv := &staticrender.RWMutexEventEnv{}
+	adapter := &testAdapter{}
+	changed := make(chan string, 1)
+	c := &Editor{
+		Adapter:  adapter,
+		Value:    "package main",
+		Language: "go",
+		Change:   ChangeFunc(func(event ChangeEvent) { changed <- event.Value }),
+	}
+	c.Init(testInitCtx{eventEnv})
+	c.handleCreate(js.Undefined())
+
+	if adapter.config.Value != "package main" || adapter.config.Language != "go" {
+		t.Fatalf("unexpected config %#v", adapter.config)
+	}
+
+	// unchanged fields are not pushed to the editor
+	c.Compute(nil)
+	if len(adapter.inst.calls) != 0 {
+		t.Errorf("unexpected calls %v", adapter.inst.calls)
+	}
+
+	c.Value = "package foo"
+	c.Language = "javascript"
+	c.Compute(nil)
+	if adapter.inst.value != "package foo" || len(adapter.inst.calls) != 2 || adapter.inst.calls[1] != "language javascript" {
+		t.Errorf("unexpected calls %v", adapter.inst.calls)
+	}
+
+	// an edit by the user
+	adapter.inst.value = "package bar"
+	adapter.inst.change()
+	select {
+	case v := <-changed:
+		if v != "package bar" {
+			t.Errorf("unexpected change %q", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no change event")
+	}
+	eventEnv.Lock()
+	if c.Value != "package bar" {
+		t.Errorf("unexpected value %q", c.Value)
+	}
+	eventEnv.Unlock()
+
+	// which is not pushed back
+	adapter.inst.calls = nil
+	c.Compute(nil)
+	if len(adapter.inst.calls) != 0 {
+		t.Errorf("unexpected calls %v", adapter.inst.calls)
+	}
+
+	inst := adapter.inst
+	c.Destroy()
+	if !inst.disposed {
+		t.Errorf("expected editor to be disposed")
+	}
+}
+
+func TestAdaptersNotAvailable(t *testing.T) {
+	for _, a := range []Adapter{Monaco{}, CodeMirror{}} {
+		if _, err := a.Mount(js.Undefined(), Config{}, func() {}); err != ErrNotAvailable {
+			t.Errorf("%T: expected ErrNotAvailable, got %v", a, err)
+		}
+	}
+}
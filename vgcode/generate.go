@@ -0,0 +1,3 @@
+package vgcode
+
+//go:generate vugugen
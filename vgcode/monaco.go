@@ -0,0 +1,78 @@
+package vgcode
+
+import (
+	js "github.com/vugu/vugu/js"
+)
+
+// Monaco is the Adapter for the Monaco editor, which must be loaded so window.monaco exists.
+// Monaco's themes are global, so all editors on the page share the theme set last.
+type Monaco struct{}
+
+// Mount implements Adapter.
+func (Monaco) Mount(el js.Value, config Config, change func()) (Instance, error) {
+
+	monaco := js.Global().Get("monaco")
+	if !monaco.Truthy() || !el.Truthy() {
+		return nil, ErrNotAvailable
+	}
+
+	opts := map[string]interface{}{
+		"value":           config.Value,
+		"language":        config.Language,
+		"readOnly":        config.ReadOnly,
+		"automaticLayout": true,
+	}
+	if config.Theme != "" {
+		opts["theme"] = config.Theme
+	}
+	for k, v := range config.Options {
+		opts[k] = v
+	}
+
+	m := &monacoInstance{monaco: monaco}
+	m.editor = monaco.Get("editor").Call("create", el, jsObject(opts))
+	m.changeFunc = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		change()
+		return nil
+	})
+	m.listener = m.editor.Call("onDidChangeModelContent", m.changeFunc)
+	return m, nil
+}
+
+type monacoInstance struct {
+	monaco     js.Value
+	editor     js.Value
+	listener   js.Value
+	changeFunc js.Func
+}
+
+func (m *monacoInstance) Value() string { return m.editor.Call("getValue").String() }
+
+func (m *monacoInstance) SetValue(value string) {
+	// an edit instead of setValue keeps the undo history
+	model := m.editor.Call("getModel")
+	edit := jsObject(map[string]interface{}{"range": model.Call("getFullModelRange"), "text": value})
+	m.editor.Call("pushUndoStop")
+	m.editor.Call("executeEdits", "vgcode", js.Global().Get("Array").New(edit))
+	m.editor.Call("pushUndoStop")
+}
+
+func (m *monacoInstance) SetLanguage(language string) {
+	m.monaco.Get("editor").Call("setModelLanguage", m.editor.Call("getModel"), language)
+}
+
+func (m *monacoInstance) SetTheme(theme string) {
+	m.monaco.Get("editor").Call("setTheme", theme)
+}
+
+func (m *monacoInstance) SetReadOnly(readOnly bool) {
+	m.editor.Call("updateOptions", jsObject(map[string]interface{}{"readOnly": readOnly}))
+}
+
+func (m *monacoInstance) Focus() { m.editor.Call("focus") }
+
+func (m *monacoInstance) Dispose() {
+	m.listener.Call("dispose")
+	m.editor.Call("dispose")
+	m.changeFunc.Release()
+}
@@ -0,0 +1,105 @@
+/*
+Package vgcode wraps JS code editors such as Monaco and CodeMirror in an Editor component.
+
+The editor's script must be loaded by the page (e.g. with a script tag in the head) before the Editor is
+rendered.  The Editor gives it an element of its own, which Vugu does not render into, and keeps the
+value, language, theme and read only state in sync with its fields:
+
+	<vgcode:Editor :Adapter='vgcode.Monaco{}' :Value='c.source' Language="go"
+		Theme="vs" DarkTheme="vs-dark"
+		@Change='c.source = event.Value'></vgcode:Editor>
+
+The editor is created when the Editor is first rendered and disposed of when it is destroyed.  If
+DarkTheme is set it is used instead of Theme while the user's system prefers a dark color scheme, and
+switched as that changes.
+
+This is also meant as an example of how to wrap other heavy JS widgets: mount the widget from a
+vg-js-create callback on an element without children, push changes of the component's fields into it
+from Compute, bring its events back into Go with the EventEnv locked, and release everything in Destroy.
+Other editors can be used by implementing Adapter.
+*/
+package vgcode
+
+import (
+	"errors"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// ErrNotAvailable is returned when the editor's script has not been loaded (or outside of the browser).
+var ErrNotAvailable = errors.New("vgcode: editor is not available in this environment")
+
+// Config is the initial state of an editor passed to Adapter.Mount.
+type Config struct {
+	Value    string
+	Language string // e.g. "go" or "javascript", the names are those of the editor
+	Theme    string // the names are those of the editor
+	ReadOnly bool
+
+	// Options are passed on to the editor as they are, e.g. {"lineNumbers": false}
+	Options map[string]interface{}
+}
+
+// Adapter creates editors of one kind in an element.  change must be called after each edit made by the user,
+// from the JS callback (it does not block).
+type Adapter interface {
+	Mount(el js.Value, config Config, change func()) (Instance, error)
+}
+
+// Instance is an editor created by an Adapter.
+type Instance interface {
+	Value() string
+	SetValue(value string)
+	SetLanguage(language string)
+	SetTheme(theme string)
+	SetReadOnly(readOnly bool)
+	Focus()
+	Dispose() // removes the editor and releases its resources
+}
+
+// ChangeEvent is emitted by Editor when the user edits the value.
+type ChangeEvent struct {
+	Value string
+}
+
+// ChangeHandler is the interface for things that can handle ChangeEvent.
+type ChangeHandler interface {
+	ChangeHandle(event ChangeEvent)
+}
+
+// ChangeFunc implements ChangeHandler as a function.
+type ChangeFunc func(event ChangeEvent)
+
+// ChangeHandle implements the ChangeHandler interface.
+func (f ChangeFunc) ChangeHandle(event ChangeEvent) { f(event) }
+
+// assert ChangeFunc implements ChangeHandler
+var _ ChangeHandler = ChangeFunc(nil)
+
+// ErrorEvent is emitted by Editor when the editor cannot be created, e.g. because its script was not loaded.
+type ErrorEvent struct {
+	Err error
+}
+
+// ErrorHandler is the interface for things that can handle ErrorEvent.
+type ErrorHandler interface {
+	ErrorHandle(event ErrorEvent)
+}
+
+// ErrorFunc implements ErrorHandler as a function.
+type ErrorFunc func(event ErrorEvent)
+
+// ErrorHandle implements the ErrorHandler interface.
+func (f ErrorFunc) ErrorHandle(event ErrorEvent) { f(event) }
+
+// assert ErrorFunc implements ErrorHandler
+var _ ErrorHandler = ErrorFunc(nil)
+
+// jsObject returns m as a JS object.
+func jsObject(m map[string]interface{}) js.Value {
+	o := js.Global().Get("Object").New()
+	for k, v := range m {
+		o.Set(k, v)
+	}
+	return o
+}
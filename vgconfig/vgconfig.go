@@ -0,0 +1,134 @@
+/*
+Package vgconfig passes configuration from the server to the app when it starts, such as the base URL
+of the API, feature flags and the locale, as JSON in the page:
+
+	<script type="application/json" id="vugu-config">{"apiBaseURL":"https://api.example.com","features":{"beta":true}}</script>
+
+The server writes the element with ScriptTag, e.g. into the page from devutil:
+
+	tag, err := vgconfig.ScriptTag(vgconfig.Config{APIBaseURL: os.Getenv("API_URL"), Locale: "de"})
+	index := devutil.DefaultIndex.Replace("<!-- styles -->", tag)
+
+and the app reads it with Load:
+
+	func (c *Root) Init(ctx vugu.InitCtx) {
+		c.config, c.err = vgconfig.Load()
+		if c.config.Enabled("beta") {
+			// ...
+		}
+	}
+
+Other settings can be added by passing a struct of your own to ScriptTag and reading them with
+Config.Decode.  The config is read from the page once and cached.  Outside of the browser Load returns
+ErrNotAvailable, unless Set has been called, as it may be for server-side rendering or tests.
+*/
+package vgconfig
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/vugu/vjson"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// ErrNotAvailable is returned by Load outside of the browser.
+var ErrNotAvailable = errors.New("vgconfig: not available in this environment")
+
+// ErrNotFound is returned by Load when the page has no config element.
+var ErrNotFound = errors.New("vgconfig: no config in the page")
+
+// ElementID is the id of the script element holding the config.
+const ElementID = "vugu-config"
+
+// Config is the configuration passed to the app.  The methods may be called on a nil *Config,
+// so the result of Load can be used without checking for an error if defaults are acceptable.
+type Config struct {
+	APIBaseURL string          `json:"apiBaseURL,omitempty"`
+	Locale     string          `json:"locale,omitempty"` // a BCP 47 language tag, e.g. "en-US"
+	Features   map[string]bool `json:"features,omitempty"`
+
+	raw []byte
+}
+
+// Enabled returns true if the feature flag is set.
+func (c *Config) Enabled(feature string) bool {
+	return c != nil && c.Features[feature]
+}
+
+// Decode unmarshals the whole config, including any settings beyond the fields of Config, into v.
+func (c *Config) Decode(v interface{}) error {
+	if c == nil || len(c.raw) == 0 {
+		return ErrNotFound
+	}
+	return vjson.Unmarshal(c.raw, v)
+}
+
+// Parse returns the Config in the JSON data.
+func Parse(data []byte) (*Config, error) {
+	c := &Config{}
+	if err := vjson.Unmarshal(data, c); err != nil {
+		return nil, errors.New("vgconfig: " + err.Error())
+	}
+	c.raw = append([]byte(nil), data...)
+	return c, nil
+}
+
+var (
+	mu     sync.Mutex
+	loaded *Config
+)
+
+// Load returns the config from the page, see the package documentation.
+func Load() (*Config, error) {
+
+	mu.Lock()
+	defer mu.Unlock()
+	if loaded != nil {
+		return loaded, nil
+	}
+
+	doc := js.Global().Get("document")
+	if !doc.Truthy() {
+		return nil, ErrNotAvailable
+	}
+	el := doc.Call("getElementById", ElementID)
+	if !el.Truthy() {
+		return nil, ErrNotFound
+	}
+	c, err := Parse([]byte(el.Get("textContent").String()))
+	if err != nil {
+		return nil, err
+	}
+	loaded = c
+	return c, nil
+}
+
+// Set makes Load return the config in the JSON data instead of reading it from the page.
+func Set(data []byte) error {
+	c, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	loaded = c
+	return nil
+}
+
+// ScriptTag returns the script element to put in the page to pass v, usually a Config, to the app.
+func ScriptTag(v interface{}) (string, error) {
+	b, err := vjson.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return `<script type="application/json" id="` + ElementID + `">` + escapeJSON(string(b)) + `</script>`, nil
+}
+
+// escapeJSON makes JSON safe to put in a script element, by escaping the characters which could end it.
+// These can only occur in strings, where the escapes mean the same.
+func escapeJSON(s string) string {
+	return strings.NewReplacer("<", `\u003c`, ">", `\u003e`, "&", `\u0026`).Replace(s)
+}
@@ -0,0 +1,49 @@
+package vgconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+
+	if _, err := Load(); err != ErrNotAvailable {
+		t.Errorf("Load: expected ErrNotAvailable, got %v", err)
+	}
+
+	var c *Config
+	if c.Enabled("beta") {
+		t.Errorf("nil config should have no features")
+	}
+
+	tag, err := ScriptTag(struct {
+		Config
+		Title string `json:"title"`
+	}{Config{APIBaseURL: "https://api.example.com", Features: map[string]bool{"beta": true}}, "</script><b>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(tag, "</script>") != 1 || !strings.HasPrefix(tag, `<script type="application/json" id="vugu-config">`) {
+		t.Fatalf("unexpected tag %s", tag)
+	}
+
+	data := strings.TrimSuffix(strings.TrimPrefix(tag, `<script type="application/json" id="vugu-config">`), "</script>")
+	if err := Set([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	c, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.APIBaseURL != "https://api.example.com" || !c.Enabled("beta") || c.Enabled("other") {
+		t.Errorf("unexpected config %#v", c)
+	}
+	var extra struct{ Title string }
+	if err := c.Decode(&extra); err != nil || extra.Title != "</script><b>" {
+		t.Errorf("unexpected decode %#v, %v", extra, err)
+	}
+
+	if _, err := Parse([]byte("{")); err == nil {
+		t.Errorf("expected error for bad JSON")
+	}
+}
@@ -0,0 +1,408 @@
+/*
+Package vgelement registers Vugu components as custom elements (Web Components), so they can be used on
+pages which are not written with Vugu, like any other HTML element:
+
+	<my-counter start="5"></my-counter>
+	<script>
+	document.querySelector("my-counter").addEventListener("change", e => console.log(e.detail));
+	</script>
+
+The program defines the element and then keeps running:
+
+	type Counter struct {
+		Start    int         // from the start attribute
+		OnChange func(n int) // dispatches the change event
+		n        int
+	}
+
+	func main() {
+		err := vgelement.Define("my-counter", vgelement.Definition{
+			New:    func() vugu.Builder { return &Counter{} },
+			Attrs:  map[string]string{"start": "Start"},
+			Events: map[string]string{"change": "OnChange"},
+		})
+		if err != nil {
+			panic(err)
+		}
+		select {}
+	}
+
+Each element on the page gets a new component, rendered by its own renderer in the element's shadow root
+(see domrender.JSRenderer.ShadowRootMode), so the page's styles do not leak in and the component's CSS does
+not leak out.  Observed attributes are copied to fields of the component when the element is added to the
+page and whenever they change, and the component is rendered again.  Fields for events are set to functions
+which dispatch a CustomEvent on the element with the argument, converted to JSON, as its detail.  The events
+bubble and cross the shadow root boundary.
+
+When the element is removed from the page its renderer is stopped and released, and the component is
+discarded; adding the element back starts a new one.  An element whose component fails to render is
+left as it is, with the error reported (see Definition.OnError).  The element class is created by the
+helper script, which a page whose Content-Security-Policy does not allow 'unsafe-eval' can load as a
+static script, see domrender.HelperScriptHandler.
+*/
+package vgelement
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/vugu/vjson"
+
+	"github.com/vugu/vugu"
+	"github.com/vugu/vugu/domrender"
+	js "github.com/vugu/vugu/js"
+)
+
+// ErrNotAvailable is returned by Define when custom elements are not supported, e.g. outside of a browser.
+var ErrNotAvailable = errors.New("vgelement: custom elements not available")
+
+// Definition describes a custom element.
+type Definition struct {
+	// New returns the component for a new element, it must be a pointer to a struct if Attrs or Events are set.
+	New func() vugu.Builder
+
+	// Attrs maps the names of observed attributes to the fields of the component they are copied to.
+	// Fields may be strings, numbers, or bools, which are true if the attribute is present.  A missing
+	// attribute or one which cannot be parsed as the field's type sets the zero value.
+	Attrs map[string]string
+
+	// Events maps event names to the fields of the component which dispatch them.  The fields must be
+	// funcs with no results and at most one parameter, which becomes the event's detail.
+	Events map[string]string
+
+	// OnError, if set, is called with the error when an element cannot be rendered, otherwise it is
+	// logged with console.error.  The element is not rendered again until it is added back to the page.
+	OnError func(err error)
+}
+
+// Define registers a custom element with the name, which must contain a hyphen, see the package
+// documentation.  It returns an error if the definition does not match the component or the browser
+// rejects the name, e.g. because it is already defined.
+func Define(name string, def Definition) (err error) {
+
+	if def.New == nil {
+		return errors.New("vgelement: Definition.New is nil")
+	}
+	if err := def.check(); err != nil {
+		return err
+	}
+
+	registry := js.Global().Get("customElements")
+	if !registry.Truthy() {
+		return ErrNotAvailable
+	}
+	if err := domrender.LoadHelperScript(); err != nil {
+		return fmt.Errorf("vgelement: %w", err)
+	}
+
+	attrs := make([]string, 0, len(def.Attrs))
+	for k := range def.Attrs {
+		attrs = append(attrs, k)
+	}
+	sort.Strings(attrs)
+	attrList := js.Global().Get("Array").New()
+	for i, a := range attrs {
+		attrList.SetIndex(i, a)
+	}
+
+	d := &definition{Definition: def, attrs: attrs, elements: make(map[int]*element)}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("vgelement: defining %q: %v", name, r)
+		}
+	}()
+	js.Global().Call("vuguDefineElement", name, attrList,
+		js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			d.connected(args[0])
+			return nil
+		}),
+		js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			d.disconnected(args[0])
+			return nil
+		}),
+		js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			d.attributeChanged(args[0], args[1].String())
+			return nil
+		}),
+	)
+
+	return nil
+}
+
+// check returns an error if a field named in Attrs or Events is missing or of the wrong type.
+func (def *Definition) check() error {
+
+	if len(def.Attrs) == 0 && len(def.Events) == 0 {
+		return nil
+	}
+
+	v := reflect.ValueOf(def.New())
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("vgelement: component %T is not a pointer to a struct", v.Interface())
+	}
+	t := v.Elem().Type()
+
+	for attr, name := range def.Attrs {
+		f, ok := t.FieldByName(name)
+		if !ok {
+			return fmt.Errorf("vgelement: %v has no field %s for attribute %q", t, name, attr)
+		}
+		switch f.Type.Kind() {
+		case reflect.String, reflect.Bool,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+		default:
+			return fmt.Errorf("vgelement: field %s for attribute %q is a %v, not a string, number or bool", name, attr, f.Type)
+		}
+	}
+
+	for event, name := range def.Events {
+		f, ok := t.FieldByName(name)
+		if !ok {
+			return fmt.Errorf("vgelement: %v has no field %s for event %q", t, name, event)
+		}
+		if f.Type.Kind() != reflect.Func || f.Type.NumIn() > 1 || f.Type.NumOut() > 0 || f.Type.IsVariadic() {
+			return fmt.Errorf("vgelement: field %s for event %q is a %v, not a func with at most one parameter and no results", name, event, f.Type)
+		}
+	}
+
+	return nil
+}
+
+// setAttr sets the field of comp for attr to the value of the attribute, present is false if it is missing.
+func (def *Definition) setAttr(comp vugu.Builder, attr string, value string, present bool) {
+
+	name, ok := def.Attrs[attr]
+	if !ok {
+		return
+	}
+	f := reflect.ValueOf(comp).Elem().FieldByName(name)
+
+	f.Set(reflect.Zero(f.Type()))
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Bool:
+		f.SetBool(present)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(value, 10, f.Type().Bits()); err == nil {
+			f.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(value, 10, f.Type().Bits()); err == nil {
+			f.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(value, f.Type().Bits()); err == nil {
+			f.SetFloat(n)
+		}
+	}
+}
+
+// setEvents sets the event fields of comp to call dispatch with the event name and detail.
+func (def *Definition) setEvents(comp vugu.Builder, dispatch func(event string, detail interface{})) {
+
+	for event, name := range def.Events {
+		event := event
+		f := reflect.ValueOf(comp).Elem().FieldByName(name)
+		f.Set(reflect.MakeFunc(f.Type(), func(args []reflect.Value) []reflect.Value {
+			var detail interface{}
+			if len(args) > 0 {
+				detail = args[0].Interface()
+			}
+			dispatch(event, detail)
+			return nil
+		}))
+	}
+}
+
+// definition keeps track of the elements of a Definition on the page.
+type definition struct {
+	Definition
+	attrs []string
+
+	mu       sync.Mutex
+	lastID   int
+	elements map[int]*element
+}
+
+// element is an instance of a custom element on the page.
+type element struct {
+	el        js.Value
+	selector  string
+	comp      vugu.Builder
+	renderer  *domrender.JSRenderer
+	transport *transport
+	onError   func(err error)
+}
+
+// elementIDProp is the property of the element with its ID, which is also the value of elementIDAttr,
+// used as the renderer's mount point selector.
+const (
+	elementIDProp = "vgelementID"
+	elementIDAttr = "data-vgelement-id"
+)
+
+// mountPoints returns the helper script's map of mount point selectors to elements, which finds
+// elements inside shadow roots that document.querySelector does not.
+func mountPoints() js.Value {
+	m := js.Global().Get("vuguMountPoints")
+	if !m.Truthy() {
+		m = js.Global().Get("Object").New()
+		js.Global().Set("vuguMountPoints", m)
+	}
+	return m
+}
+
+func (d *definition) connected(el js.Value) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if v := el.Get(elementIDProp); v.Truthy() && d.elements[v.Int()] != nil {
+		return
+	}
+	d.lastID++
+	id := d.lastID
+	el.Set(elementIDProp, id)
+	el.Call("setAttribute", elementIDAttr, strconv.Itoa(id))
+
+	e := &element{el: el, comp: d.New(), transport: &transport{}, onError: d.OnError}
+	for _, attr := range d.attrs {
+		v := el.Call("getAttribute", attr)
+		d.setAttr(e.comp, attr, js.OptString(v), !v.IsNull())
+	}
+	d.setEvents(e.comp, e.dispatch)
+
+	e.selector = fmt.Sprintf("[%s=%q]", elementIDAttr, strconv.Itoa(id))
+	mountPoints().Set(e.selector, el)
+
+	r, err := domrender.NewWithTransport(e.selector, e.transport)
+	if err != nil {
+		js.Global().Get("Reflect").Call("deleteProperty", mountPoints(), e.selector)
+		js.Global().Get("console").Call("error", "vgelement: "+err.Error())
+		return
+	}
+	r.ShadowRootMode = "open"
+	r.Scheduler = domrender.DefaultScheduler
+	e.renderer = r
+	d.elements[id] = e
+
+	go e.run()
+}
+
+func (d *definition) disconnected(el js.Value) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	v := el.Get(elementIDProp)
+	if !v.Truthy() {
+		return
+	}
+	id := v.Int()
+	e := d.elements[id]
+	if e == nil {
+		return
+	}
+	delete(d.elements, id)
+	el.Set(elementIDProp, js.Undefined())
+	js.Global().Get("Reflect").Call("deleteProperty", mountPoints(), e.selector)
+
+	e.transport.close()
+}
+
+func (d *definition) attributeChanged(el js.Value, attr string) {
+
+	d.mu.Lock()
+	v := el.Get(elementIDProp)
+	var e *element
+	if v.Truthy() {
+		e = d.elements[v.Int()]
+	}
+	d.mu.Unlock()
+
+	// before the element is on the page, connected reads all of the attributes
+	if e == nil {
+		return
+	}
+
+	value := el.Call("getAttribute", attr)
+	ee := e.renderer.EventEnv()
+	ee.Lock()
+	d.setAttr(e.comp, attr, js.OptString(value), !value.IsNull())
+	ee.UnlockRender()
+}
+
+// run renders the element's component until it is removed from the page.
+func (e *element) run() {
+
+	defer e.renderer.Release()
+
+	buildEnv, err := vugu.NewBuildEnv(e.renderer.EventEnv())
+	if err != nil {
+		e.report(err)
+		return
+	}
+
+	for ok := true; ok; ok = e.renderer.EventWait() {
+		err = e.renderer.Render(buildEnv.RunBuild(e.comp))
+		if err != nil {
+			e.report(err)
+			return
+		}
+	}
+}
+
+// report passes err to the Definition's OnError, or logs it.
+func (e *element) report(err error) {
+	if e.onError != nil {
+		e.onError(err)
+		return
+	}
+	js.Global().Get("console").Call("error", "vgelement: "+err.Error())
+}
+
+// dispatch fires a CustomEvent on the element.
+func (e *element) dispatch(event string, detail interface{}) {
+
+	var jsDetail interface{}
+	if detail != nil {
+		b, err := vjson.Marshal(detail)
+		if err != nil {
+			panic(fmt.Errorf("vgelement: %s event detail: %w", event, err))
+		}
+		jsDetail = js.Global().Get("JSON").Call("parse", string(b))
+	}
+
+	init := js.Global().Get("Object").New()
+	init.Set("detail", jsDetail)
+	init.Set("bubbles", true)
+	init.Set("composed", true)
+	e.el.Call("dispatchEvent", js.Global().Get("CustomEvent").New(event, init))
+}
+
+// transport is a DirectTransport which can be closed, to stop the render loop of an element
+// that has been removed.
+type transport struct {
+	domrender.DirectTransport
+	closeFunc func()
+}
+
+// Init implements domrender.Transport.
+func (t *transport) Init(script string, h domrender.TransportHandlers) error {
+	t.closeFunc = h.Close
+	return t.DirectTransport.Init(script, h)
+}
+
+func (t *transport) close() {
+	if t.closeFunc != nil {
+		t.closeFunc()
+	}
+}
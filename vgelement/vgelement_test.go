@@ -0,0 +1,110 @@
+package vgelement
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+	"github.com/vugu/vugu/domrender"
+)
+
+type testComp struct {
+	Label   string
+	Count   int
+	Ratio   float64
+	Open    bool
+	OnPick  func(id string)
+	OnClose func()
+	Other   []string
+}
+
+func (c *testComp) Build(vgin *vugu.BuildIn) *vugu.BuildOut { return &vugu.BuildOut{} }
+
+func TestCheck(t *testing.T) {
+
+	assert := assert.New(t)
+
+	newComp := func() vugu.Builder { return &testComp{} }
+
+	def := Definition{
+		New:    newComp,
+		Attrs:  map[string]string{"label": "Label", "count": "Count", "ratio": "Ratio", "open": "Open"},
+		Events: map[string]string{"pick": "OnPick", "close": "OnClose"},
+	}
+	assert.NoError(def.check())
+
+	def = Definition{New: newComp, Attrs: map[string]string{"label": "Missing"}}
+	assert.Contains(def.check().Error(), "no field Missing")
+	def = Definition{New: newComp, Attrs: map[string]string{"other": "Other"}}
+	assert.Contains(def.check().Error(), "not a string, number or bool")
+	def = Definition{New: newComp, Events: map[string]string{"label": "Label"}}
+	assert.Contains(def.check().Error(), "not a func")
+
+	// only structs have fields
+	def = Definition{New: func() vugu.Builder { return vugu.NewBuilderFunc(nil) }, Attrs: map[string]string{"label": "Label"}}
+	assert.Error(def.check())
+	def = Definition{New: func() vugu.Builder { return vugu.NewBuilderFunc(nil) }}
+	assert.NoError(def.check())
+
+	// outside of a browser
+	assert.Equal(ErrNotAvailable, Define("my-comp", Definition{New: newComp}))
+	assert.Error(Define("my-comp", Definition{}))
+}
+
+func TestSetAttrAndEvents(t *testing.T) {
+
+	assert := assert.New(t)
+
+	def := Definition{
+		Attrs:  map[string]string{"label": "Label", "count": "Count", "ratio": "Ratio", "open": "Open"},
+		Events: map[string]string{"pick": "OnPick", "close": "OnClose"},
+	}
+	c := &testComp{}
+
+	def.setAttr(c, "label", "hello", true)
+	def.setAttr(c, "count", "42", true)
+	def.setAttr(c, "ratio", "0.5", true)
+	def.setAttr(c, "open", "", true)
+	def.setAttr(c, "unknown", "x", true)
+	assert.Equal(&testComp{Label: "hello", Count: 42, Ratio: 0.5, Open: true}, c)
+
+	// missing or bad values are zero
+	def.setAttr(c, "count", "many", true)
+	def.setAttr(c, "open", "", false)
+	def.setAttr(c, "label", "", false)
+	assert.Equal(&testComp{Ratio: 0.5}, c)
+
+	type dispatched struct {
+		event  string
+		detail interface{}
+	}
+	var got []dispatched
+	def.setEvents(c, func(event string, detail interface{}) {
+		got = append(got, dispatched{event, detail})
+	})
+	c.OnPick("a")
+	c.OnClose()
+	assert.Equal([]dispatched{{"pick", "a"}, {"close", nil}}, got)
+}
+
+func TestRunError(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// a component which cannot be rendered is reported rather than panicing, and its renderer released
+	var reported []error
+	r, err := domrender.NewWithTransport("#bad", &domrender.CaptureTransport{})
+	assert.NoError(err)
+	e := &element{
+		comp: vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+			return &vugu.BuildOut{Out: []*vugu.VGNode{{Type: vugu.VGNodeType(99)}}}
+		}),
+		renderer: r,
+		onError:  func(err error) { reported = append(reported, err) },
+	}
+	e.run()
+	if assert.Len(reported, 1) {
+		assert.Contains(reported[0].Error(), "is not vugu.ElementNode")
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Package vgexport exports tabular data as CSV or XLSX files, e.g. for an "Export" button on a table
+in an admin UI.  Pass the rows as currently shown (after filtering and sorting) and the user gets
+the same thing in their spreadsheet:
+
+	func (c *Orders) HandleExport(event vugu.DOMEvent) {
+		t := vgexport.Table{Header: []string{"ID", "Customer", "Total", "Date"}}
+		for _, o := range c.visibleOrders() {
+			t.Rows = append(t.Rows, []interface{}{o.ID, o.Customer, o.Total, o.Date})
+		}
+		vgexport.DownloadXLSX("orders.xlsx", t)
+	}
+
+Cells may be strings, numbers, bools, time.Time or nil.  In XLSX numbers and times are stored as
+such, so they can be summed and sorted in the spreadsheet.  Anything else is formatted with fmt.
+*/
+package vgexport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/vugu/vugu/vgfile"
+)
+
+// Table is the data to export.
+type Table struct {
+	Name   string          // sheet name in XLSX, defaults to "Sheet1"
+	Header []string        // optional column headings
+	Rows   [][]interface{} // cell values
+}
+
+// CSV returns t as CSV, one line per row with the header first.
+func CSV(t Table) []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if len(t.Header) > 0 {
+		w.Write(t.Header)
+	}
+	var rec []string
+	for _, row := range t.Rows {
+		rec = rec[:0]
+		for _, v := range row {
+			rec = append(rec, formatCSV(v))
+		}
+		w.Write(rec)
+	}
+	w.Flush()
+	return buf.Bytes()
+}
+
+// DownloadCSV downloads t as a CSV file with the specified name.  The file starts with a UTF-8 byte
+// order mark, without which Excel assumes a legacy encoding.
+func DownloadCSV(name string, t Table) error {
+	return vgfile.Download(name, "text/csv;charset=utf-8", append([]byte("\xef\xbb\xbf"), CSV(t)...))
+}
+
+// DownloadXLSX downloads t as an XLSX file with the specified name.
+func DownloadXLSX(name string, t Table) error {
+	b, err := XLSX(t)
+	if err != nil {
+		return err
+	}
+	return vgfile.Download(name, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", b)
+}
+
+func formatCSV(v interface{}) string {
+	switch vt := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return vt
+	case time.Time:
+		return vt.Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(vt, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(vt), 'f', -1, 32)
+	}
+	return fmt.Sprint(v)
+}
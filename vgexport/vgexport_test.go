@@ -0,0 +1,103 @@
+package vgexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testTable() Table {
+	return Table{
+		Name:   "Orders: 2020/Q1",
+		Header: []string{"ID", "Customer", "Total", "Paid", "Date"},
+		Rows: [][]interface{}{
+			{1, "Joe, Inc.", 12.5, true, time.Date(2020, 1, 2, 12, 0, 0, 0, time.UTC)},
+			{2, `say "hi" <b>`, nil, false, time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+}
+
+func TestCSV(t *testing.T) {
+	got := string(CSV(testTable()))
+	want := "ID,Customer,Total,Paid,Date\n" +
+		"1,\"Joe, Inc.\",12.5,true,2020-01-02T12:00:00Z\n" +
+		"2,\"say \"\"hi\"\" <b>\",,false,1900-03-01T00:00:00Z\n"
+	if got != want {
+		t.Errorf("unexpected CSV:\n%s", got)
+	}
+}
+
+func TestXLSX(t *testing.T) {
+
+	b, err := XLSX(testTable())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+
+		// every part must be well formed
+		d := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			_, err := d.Token()
+			if err != nil {
+				if err.Error() != "EOF" {
+					t.Errorf("%s: %v", f.Name, err)
+				}
+				break
+			}
+		}
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing %s", name)
+		}
+	}
+
+	if !strings.Contains(files["xl/workbook.xml"], `name="Orders 2020Q1"`) {
+		t.Errorf("unexpected workbook: %s", files["xl/workbook.xml"])
+	}
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr" s="2"><is><t xml:space="preserve">ID</t></is></c>`,
+		`<c r="A2"><v>1</v></c>`,
+		`<c r="C2"><v>12.5</v></c>`,
+		`<c r="D2" t="b"><v>1</v></c>`,
+		`<c r="E2" s="1"><v>43832.5</v></c>`,
+		`<t xml:space="preserve">say &#34;hi&#34; &lt;b&gt;</t>`,
+		`<c r="E3" s="1"><v>61</v></c>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet does not contain %s:\n%s", want, sheet)
+		}
+	}
+	if strings.Contains(sheet, `r="C3"`) {
+		t.Errorf("nil cell should be omitted")
+	}
+}
+
+func TestCellRef(t *testing.T) {
+	for col, want := range map[int]string{0: "A1", 25: "Z1", 26: "AA1", 51: "AZ1", 52: "BA1", 701: "ZZ1", 702: "AAA1"} {
+		if got := cellRef(col, 1); got != want {
+			t.Errorf("col %d: expected %s, got %s", col, want, got)
+		}
+	}
+}
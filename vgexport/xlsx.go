@@ -0,0 +1,211 @@
+package vgexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XLSX returns t as an XLSX workbook with a single sheet.  The header row is bold.
+// Strings are stored inline, which every spreadsheet application reads but some rewrite on save.
+func XLSX(t Table) ([]byte, error) {
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	files := []struct {
+		name string
+		body func(w io.Writer) error
+	}{
+		{"[Content_Types].xml", writeConst(xlsxContentTypes)},
+		{"_rels/.rels", writeConst(xlsxRels)},
+		{"xl/workbook.xml", func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, xlsxWorkbook, xmlEscape(sheetName(t.Name)))
+			return err
+		}},
+		{"xl/_rels/workbook.xml.rels", writeConst(xlsxWorkbookRels)},
+		{"xl/styles.xml", writeConst(xlsxStyles)},
+		{"xl/worksheets/sheet1.xml", func(w io.Writer) error { return writeSheet(w, t) }},
+	}
+
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if err := f.body(w); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// cell styles, indexes into cellXfs in xlsxStyles
+const (
+	styleDefault = 0
+	styleDate    = 1
+	styleHeader  = 2
+)
+
+func writeSheet(w io.Writer, t Table) error {
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	rowNum := 0
+	writeRow := func(cells []interface{}, style int) {
+		rowNum++
+		fmt.Fprintf(&b, `<row r="%d">`, rowNum)
+		for i, v := range cells {
+			writeCell(&b, cellRef(i, rowNum), v, style)
+		}
+		b.WriteString(`</row>`)
+	}
+
+	if len(t.Header) > 0 {
+		cells := make([]interface{}, len(t.Header))
+		for i, h := range t.Header {
+			cells[i] = h
+		}
+		writeRow(cells, styleHeader)
+	}
+	for _, row := range t.Rows {
+		writeRow(row, styleDefault)
+	}
+
+	b.WriteString(`</sheetData></worksheet>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeCell(b *strings.Builder, ref string, v interface{}, style int) {
+
+	styleAttr := ""
+	if style != styleDefault {
+		styleAttr = fmt.Sprintf(` s="%d"`, style)
+	}
+
+	var num string
+	switch vt := v.(type) {
+	case nil:
+		return
+	case int:
+		num = strconv.Itoa(vt)
+	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		num = fmt.Sprint(vt)
+	case float32:
+		num = strconv.FormatFloat(float64(vt), 'g', -1, 32)
+	case float64:
+		num = strconv.FormatFloat(vt, 'g', -1, 64)
+	case bool:
+		val := "0"
+		if vt {
+			val = "1"
+		}
+		fmt.Fprintf(b, `<c r="%s" t="b"%s><v>%s</v></c>`, ref, styleAttr, val)
+		return
+	case time.Time:
+		if style == styleDefault {
+			styleAttr = fmt.Sprintf(` s="%d"`, styleDate)
+		}
+		num = strconv.FormatFloat(excelTime(vt), 'f', -1, 64)
+	default:
+		s, ok := v.(string)
+		if !ok {
+			s = fmt.Sprint(v)
+		}
+		fmt.Fprintf(b, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">%s</t></is></c>`, ref, styleAttr, xmlEscape(s))
+		return
+	}
+	fmt.Fprintf(b, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr, num)
+}
+
+// excelTime converts t to a spreadsheet serial date, the days since 1899-12-30 in t's location.
+func excelTime(t time.Time) float64 {
+	y, m, d := t.Date()
+	wall := time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	return wall.Sub(epoch).Hours() / 24
+}
+
+// cellRef returns the A1 style reference of a cell, col is 0 based and row is 1 based.
+func cellRef(col, row int) string {
+	var letters []byte
+	for col++; col > 0; col = (col - 1) / 26 {
+		letters = append([]byte{byte('A' + (col-1)%26)}, letters...)
+	}
+	return string(letters) + strconv.Itoa(row)
+}
+
+// sheetName returns name with the characters not allowed in sheet names removed, and shortened to the maximum length.
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, name)
+	if r := []rune(name); len(r) > 31 {
+		name = string(r[:31])
+	}
+	if strings.TrimSpace(name) == "" {
+		name = "Sheet1"
+	}
+	return name
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func writeConst(s string) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	}
+}
+
+const xlsxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const xlsxWorkbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// numFmtId 22 is the built in date and time format
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`</cellXfs></styleSheet>`
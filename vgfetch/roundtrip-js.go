@@ -0,0 +1,68 @@
+// +build js
+
+package vgfetch
+
+import (
+	"context"
+	"errors"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// roundTrip makes the request with fetch.
+func roundTrip(ctx context.Context, req *Request) (*Response, error) {
+
+	fetch := js.Global().Get("fetch")
+	if !fetch.Truthy() {
+		return nil, errors.New("vgfetch: fetch is not available")
+	}
+
+	opts := js.Global().Get("Object").New()
+	opts.Set("method", req.Method)
+	headers := js.Global().Get("Object").New()
+	for k, v := range req.Header {
+		headers.Set(k, v)
+	}
+	opts.Set("headers", headers)
+	if req.Body != nil {
+		body := js.Global().Get("Uint8Array").New(len(req.Body))
+		js.CopyBytesToJS(body, req.Body)
+		opts.Set("body", body)
+	}
+	if ac := js.Global().Get("AbortController"); ac.Truthy() {
+		controller := ac.New()
+		opts.Set("signal", controller.Get("signal"))
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-ctx.Done():
+				controller.Call("abort")
+			case <-stop:
+			}
+		}()
+	}
+
+	res, err := js.Await(fetch.Invoke(req.URL, opts), js.NewDOMError)
+	if err != nil {
+		return nil, err
+	}
+
+	h := make(map[string]string)
+	forEach := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		h[args[1].String()] = args[0].String()
+		return nil
+	})
+	res.Get("headers").Call("forEach", forEach)
+	forEach.Release()
+
+	buf, err := js.Await(res.Call("arrayBuffer"), js.NewDOMError)
+	if err != nil {
+		return nil, err
+	}
+	arr := js.Global().Get("Uint8Array").New(buf)
+	b := make([]byte, arr.Length())
+	js.CopyBytesToGo(b, arr)
+
+	return &Response{StatusCode: res.Get("status").Int(), Header: h, Body: b}, nil
+}
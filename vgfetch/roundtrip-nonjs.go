@@ -0,0 +1,45 @@
+// +build !js
+
+package vgfetch
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// roundTrip makes the request with net/http.
+func roundTrip(ctx context.Context, req *Request) (*Response, error) {
+
+	var body io.Reader
+	if req.Body != nil {
+		body = bytes.NewReader(req.Body)
+	}
+	hreq, err := http.NewRequest(req.Method, req.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	hreq = hreq.WithContext(ctx)
+	for k, v := range req.Header {
+		hreq.Header.Set(k, v)
+	}
+
+	hres, err := http.DefaultClient.Do(hreq)
+	if err != nil {
+		return nil, err
+	}
+	defer hres.Body.Close()
+	b, err := ioutil.ReadAll(hres.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	h := make(map[string]string, len(hres.Header))
+	for k, v := range hres.Header {
+		h[strings.ToLower(k)] = strings.Join(v, ", ")
+	}
+	return &Response{StatusCode: hres.StatusCode, Header: h, Body: b}, nil
+}
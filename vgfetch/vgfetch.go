@@ -0,0 +1,176 @@
+/*
+Package vgfetch makes HTTP requests from components and delivers the responses with the EventEnv write
+lock held, requesting a render afterward, so the results can be stored in the component directly:
+
+	func (c *UserList) Init(ctx vugu.InitCtx) {
+		c.client = &vgfetch.Client{EventEnv: ctx.EventEnv()}
+		c.loading = true
+		c.client.GetJSON("/api/users", &c.users, func(err error) {
+			c.loading, c.err = false, err
+		})
+	}
+
+Requests are made in their own goroutine, so they can be started from event handlers and Init.  In the
+browser they are made with fetch directly, which keeps net/http (and its size) out of the program,
+elsewhere, e.g. when rendering server-side or in tests, with net/http.
+*/
+package vgfetch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/vugu/vjson"
+
+	"github.com/vugu/vugu"
+)
+
+// Request is an HTTP request.
+type Request struct {
+	Method string            // "GET" if empty
+	URL    string            // relative URLs are relative to the page in the browser
+	Header map[string]string // added to Client.Header
+	Body   []byte
+}
+
+// Response is an HTTP response.  The whole body is read before it is delivered.
+type Response struct {
+	StatusCode int
+	Header     map[string]string // keys are lower case
+	Body       []byte
+}
+
+// OK returns true for a 2xx status code.
+func (r *Response) OK() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// StatusError is the error passed by the JSON methods for a response whose status is not 2xx.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+// Error implements error.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("vgfetch: status %d", e.StatusCode)
+}
+
+// Client makes requests and delivers their responses, see the package documentation.
+// It can be shared between components.
+type Client struct {
+	// EventEnv is locked while callbacks are called, and a render requested after.
+	// If nil callbacks are called without locking or rendering.
+	EventEnv vugu.EventEnv
+
+	// Header is sent with every request, e.g. an Authorization header.
+	Header map[string]string
+}
+
+// Do makes the request in a new goroutine, then calls done with the response, or the error if there was
+// no response (a status which is not 2xx is not an error here).  The returned func cancels the request,
+// after which done is not called; calling it after done has been called does nothing.
+func (c *Client) Do(req *Request, done func(res *Response, err error)) (cancel func()) {
+
+	r := *req
+	if r.Method == "" {
+		r.Method = "GET"
+	}
+	h := make(map[string]string, len(c.Header)+len(req.Header))
+	for k, v := range c.Header {
+		h[k] = v
+	}
+	for k, v := range req.Header {
+		h[k] = v
+	}
+	r.Header = h
+
+	return c.start(func(ctx context.Context) (*Response, error) { return roundTrip(ctx, &r) }, done)
+}
+
+// start calls fn in a new goroutine and delivers what it returns to done, see Do.
+func (c *Client) start(fn func(ctx context.Context) (*Response, error), done func(res *Response, err error)) (cancel func()) {
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	cancelled := false
+
+	go func() {
+		res, err := fn(ctx)
+		cancelCtx()
+		if c.EventEnv != nil {
+			c.EventEnv.Lock()
+		}
+		mu.Lock()
+		skip := cancelled
+		mu.Unlock()
+		if skip {
+			if c.EventEnv != nil {
+				c.EventEnv.UnlockOnly()
+			}
+			return
+		}
+		if c.EventEnv != nil {
+			defer c.EventEnv.UnlockRender()
+		}
+		done(res, err)
+	}()
+
+	return func() {
+		mu.Lock()
+		cancelled = true
+		mu.Unlock()
+		cancelCtx()
+	}
+}
+
+// Get is Do with a GET request for url.
+func (c *Client) Get(url string, done func(res *Response, err error)) (cancel func()) {
+	return c.Do(&Request{URL: url}, done)
+}
+
+// GetJSON requests url and unmarshals the response body into v, then calls done, which may be nil,
+// with any error.  v is only changed if there is no error, and while the EventEnv is locked.
+func (c *Client) GetJSON(url string, v interface{}, done func(err error)) (cancel func()) {
+	return c.doJSON(&Request{URL: url, Header: map[string]string{"Accept": "application/json"}}, v, done)
+}
+
+// PostJSON sends body as JSON to url, and unmarshals the response body into v if it is not nil,
+// otherwise it is like GetJSON.
+func (c *Client) PostJSON(url string, body, v interface{}, done func(err error)) (cancel func()) {
+	b, err := vjson.Marshal(body)
+	if err != nil {
+		return c.fail(err, done)
+	}
+	return c.doJSON(&Request{
+		Method: "POST",
+		URL:    url,
+		Header: map[string]string{"Accept": "application/json", "Content-Type": "application/json"},
+		Body:   b,
+	}, v, done)
+}
+
+func (c *Client) doJSON(req *Request, v interface{}, done func(err error)) (cancel func()) {
+	return c.Do(req, func(res *Response, err error) {
+		if err == nil && !res.OK() {
+			err = &StatusError{StatusCode: res.StatusCode, Body: res.Body}
+		}
+		if err == nil && v != nil && len(bytes.TrimSpace(res.Body)) > 0 {
+			err = vjson.Unmarshal(res.Body, v)
+		}
+		if done != nil {
+			done(err)
+		}
+	})
+}
+
+// fail delivers err to done as if it came from a request, so it is called the same way.
+func (c *Client) fail(err error, done func(err error)) (cancel func()) {
+	return c.start(func(context.Context) (*Response, error) { return nil, err }, func(_ *Response, err error) {
+		if done != nil {
+			done(err)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package vgfetch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+func TestClient(t *testing.T) {
+
+	assert := assert.New(t)
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users":
+			w.Header().Set("X-Token", r.Header.Get("Authorization"))
+			w.Write([]byte(`[{"name":"Ann"},{"name":"Bob"}]`))
+		case "/echo":
+			b := make([]byte, r.ContentLength)
+			r.Body.Read(b)
+			w.Write(b)
+		case "/slow":
+			<-release
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	var mu sync.RWMutex
+	renderCh := make(chan bool, 1)
+	ee := vugu.NewEventEnvImpl(&mu, renderCh)
+	c := &Client{EventEnv: ee, Header: map[string]string{"Authorization": "secret"}}
+
+	doneCh := make(chan error, 1)
+
+	var users []struct {
+		Name string `json:"name"`
+	}
+	c.GetJSON(srv.URL+"/users", &users, func(err error) { doneCh <- err })
+	assert.NoError(<-doneCh)
+	assert.True(<-renderCh)
+	assert.Len(users, 2)
+	assert.Equal("Bob", users[1].Name)
+
+	c.Get(srv.URL+"/users", func(res *Response, err error) {
+		assert.True(res.OK())
+		assert.Equal("secret", res.Header["x-token"])
+		doneCh <- err
+	})
+	assert.NoError(<-doneCh)
+
+	var echo map[string]int
+	c.PostJSON(srv.URL+"/echo", map[string]int{"a": 1}, &echo, func(err error) { doneCh <- err })
+	assert.NoError(<-doneCh)
+	assert.Equal(map[string]int{"a": 1}, echo)
+
+	c.GetJSON(srv.URL+"/missing", &users, func(err error) { doneCh <- err })
+	err := <-doneCh
+	if assert.IsType(&StatusError{}, err) {
+		assert.Equal(404, err.(*StatusError).StatusCode)
+	}
+	assert.Len(users, 2)
+
+	c.PostJSON(srv.URL+"/echo", func() {}, nil, func(err error) { doneCh <- err })
+	assert.Error(<-doneCh)
+
+	// cancelled requests are not delivered
+	<-renderCh
+	cancel := c.Get(srv.URL+"/slow", func(res *Response, err error) { doneCh <- err })
+	cancel()
+	select {
+	case <-doneCh:
+		t.Fatal("cancelled request delivered")
+	case <-renderCh:
+		t.Fatal("cancelled request rendered")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,141 @@
+package vgfile
+
+import (
+	"io"
+	"time"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+// DefaultChunkSize is the most a File reads from the browser at once, unless File.ChunkSize is set.
+const DefaultChunkSize = 1 << 20
+
+// File reads the contents of a file the user selected or dropped, in chunks, so large files never
+// have to be copied into Go all at once.  Reads wait for the browser so must be done in a goroutine.
+type File struct {
+	Name         string
+	Type         string // MIME type, empty if the browser cannot tell
+	Size         int64
+	LastModified time.Time
+
+	// ChunkSize is the most each read copies from the browser, DefaultChunkSize if zero.
+	ChunkSize int
+
+	file   js.Value
+	offset int64
+}
+
+// OpenEventFile returns the file at index i of the event's files (see vugu.EventFiles): those of the
+// file input of a change or input event, or those dropped by a drop event.  It must be called from
+// the event handler, the contents can then be read later:
+//
+//	func (c *Upload) HandleChange(event vugu.DOMEvent) { // <input type="file" @change="c.HandleChange(event)">
+//		f, err := vgfile.OpenEventFile(event, 0)
+//		if err != nil {
+//			return
+//		}
+//		ee := event.EventEnv()
+//		go func() {
+//			err := c.upload(f.Name, f) // f is an io.Reader
+//			ee.Lock()
+//			defer ee.UnlockRender()
+//			c.err = err
+//		}()
+//	}
+func OpenEventFile(e vugu.DOMEvent, i int) (*File, error) {
+	if !js.Global().Get("Blob").Truthy() {
+		return nil, ErrNotAvailable
+	}
+	var files js.Value
+	if target := e.JSEventTarget(); target.Truthy() {
+		files = target.Get("files")
+	}
+	if dt := e.JSEvent().Get("dataTransfer"); !files.Truthy() && dt.Truthy() {
+		files = dt.Get("files")
+	}
+	if !files.Truthy() {
+		return nil, ErrNotAvailable
+	}
+	if i < 0 || i >= files.Length() {
+		return nil, &Error{Name: "NotFoundError", Message: "no file at this index"}
+	}
+	return NewFile(files.Index(i)), nil
+}
+
+// NewFile returns a File which reads the JS File or Blob f.
+func NewFile(f js.Value) *File {
+	ret := &File{
+		Name: js.OptString(f.Get("name")),
+		Type: js.OptString(f.Get("type")),
+		Size: int64(f.Get("size").Float()),
+		file: f,
+	}
+	if lm := f.Get("lastModified"); lm.Type() == js.TypeNumber {
+		ret.LastModified = time.Unix(0, int64(lm.Float())*int64(time.Millisecond))
+	}
+	return ret
+}
+
+// JSValue returns the JS File, e.g. to pass to CreateObjectURL for a preview.
+func (f *File) JSValue() js.Value {
+	return f.file
+}
+
+// Read implements io.Reader, reading the next part of the file.
+func (f *File) Read(p []byte) (int, error) {
+	if f.offset >= f.Size {
+		return 0, io.EOF
+	}
+	n := int64(len(p))
+	if chunk := int64(f.chunkSize()); n > chunk {
+		n = chunk
+	}
+	if rest := f.Size - f.offset; n > rest {
+		n = rest
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	err := f.readAt(p[:n], f.offset)
+	if err != nil {
+		return 0, err
+	}
+	f.offset += n
+	return int(n), nil
+}
+
+// ReadAll reads the rest of the file.
+func (f *File) ReadAll() ([]byte, error) {
+	ret := make([]byte, f.Size-f.offset)
+	for off := 0; off < len(ret); {
+		n, err := f.Read(ret[off:])
+		if err != nil {
+			return ret[:off], err
+		}
+		off += n
+	}
+	return ret, nil
+}
+
+// Reset goes back to the start of the file.
+func (f *File) Reset() {
+	f.offset = 0
+}
+
+// readAt fills p with the bytes of the file from off.
+func (f *File) readAt(p []byte, off int64) error {
+	buf, err := js.Await(f.file.Call("slice", float64(off), float64(off+int64(len(p)))).Call("arrayBuffer"), js.NewDOMError)
+	if err != nil {
+		return err
+	}
+	js.CopyBytesToGo(p, js.Global().Get("Uint8Array").New(buf))
+	return nil
+}
+
+func (f *File) chunkSize() int {
+	if f.ChunkSize > 0 {
+		return f.ChunkSize
+	}
+	return DefaultChunkSize
+}
@@ -0,0 +1,291 @@
+/*
+Package vgfile saves files generated in Go to the user's computer, and reads files the user selects.
+
+Download works in all browsers and can be called directly from an event handler:
+
+	func (c *Report) HandleExport(event vugu.DOMEvent) {
+		vgfile.Download("report.csv", "text/csv", c.csvBytes())
+	}
+
+Where the browser has the File System Access API, SaveAs shows a save dialog and PickDirectory lets
+the user choose a directory to read and write files in.  These wait for the user so must be called
+in a goroutine (started from the event handler, as browsers only show the dialogs in response
+to a user action):
+
+	func (c *Report) HandleSaveAs(event vugu.DOMEvent) {
+		ee := event.EventEnv()
+		data := c.csvBytes()
+		go func() {
+			err := vgfile.SaveAs(vgfile.SaveOptions{SuggestedName: "report.csv"}, data)
+			ee.Lock()
+			defer ee.UnlockRender()
+			c.err = err
+		}()
+	}
+
+Files selected with <input type="file"> or dropped on an element are listed in the event by
+vugu.EventFiles, and OpenEventFile returns a File which reads one in chunks as an io.Reader.
+*/
+package vgfile
+
+import (
+	"errors"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// ErrNotAvailable is returned when the browser does not support the API used (or outside of the browser).
+var ErrNotAvailable = errors.New("vgfile: not available in this environment")
+
+// Error is a failure reported by the browser, e.g. Name is "AbortError" when the user cancels a dialog.
+type Error = js.DOMError
+
+// IsAbort returns true if err is the user cancelling a dialog.
+func IsAbort(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Name == "AbortError"
+}
+
+// NewBlob returns a Blob with a copy of data.
+func NewBlob(data []byte, mimeType string) js.Value {
+	arr := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(arr, data)
+	parts := js.Global().Get("Array").New()
+	parts.Call("push", arr)
+	opts := js.Global().Get("Object").New()
+	opts.Set("type", mimeType)
+	return js.Global().Get("Blob").New(parts, opts)
+}
+
+// CreateObjectURL returns a URL for blob, e.g. to show an image or video made in Go.
+// Call RevokeObjectURL when it is no longer needed to release the memory.
+func CreateObjectURL(blob js.Value) string {
+	return js.Global().Get("URL").Call("createObjectURL", blob).String()
+}
+
+// RevokeObjectURL releases a URL returned by CreateObjectURL.
+func RevokeObjectURL(url string) {
+	js.Global().Get("URL").Call("revokeObjectURL", url)
+}
+
+// Download saves data as a file with the specified name, in the same way as when the user clicks a
+// link to a file (usually to the downloads folder, or browsers may ask where to save it).
+func Download(name, mimeType string, data []byte) error {
+	if !js.Global().Get("Blob").Truthy() || !js.Global().Get("document").Truthy() {
+		return ErrNotAvailable
+	}
+	return DownloadBlob(name, NewBlob(data, mimeType))
+}
+
+// DownloadBlob is like Download for an existing Blob.
+func DownloadBlob(name string, blob js.Value) error {
+
+	doc := js.Global().Get("document")
+	if !doc.Truthy() {
+		return ErrNotAvailable
+	}
+
+	url := CreateObjectURL(blob)
+	a := doc.Call("createElement", "a")
+	a.Set("href", url)
+	a.Set("download", name)
+	a.Get("style").Set("display", "none")
+	doc.Get("body").Call("appendChild", a)
+	a.Call("click")
+	a.Call("remove")
+
+	// the download has started by the time timers run, the URL can be released then
+	var revoke js.Func
+	revoke = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		RevokeObjectURL(url)
+		revoke.Release()
+		return nil
+	})
+	js.Global().Call("setTimeout", revoke, 1000)
+
+	return nil
+}
+
+// FileType is a type of file offered in a save dialog.
+type FileType struct {
+	Description string              // e.g. "CSV file"
+	Accept      map[string][]string // mime type to extensions, e.g. {"text/csv": {".csv"}}
+}
+
+// SaveOptions are the options for SaveAs.
+type SaveOptions struct {
+	SuggestedName string
+	Types         []FileType
+}
+
+// SaveAvailable returns true if the browser supports SaveAs.
+func SaveAvailable() bool {
+	return js.Global().Get("showSaveFilePicker").Truthy()
+}
+
+// SaveAs shows a save dialog and writes data to the file the user chooses.
+// Returns ErrNotAvailable if SaveAvailable is false, you might use Download instead in that case.
+func SaveAs(opts SaveOptions, data []byte) error {
+
+	if !SaveAvailable() {
+		return ErrNotAvailable
+	}
+
+	jsOpts := js.Global().Get("Object").New()
+	if opts.SuggestedName != "" {
+		jsOpts.Set("suggestedName", opts.SuggestedName)
+	}
+	if len(opts.Types) > 0 {
+		types := js.Global().Get("Array").New()
+		for _, t := range opts.Types {
+			jt := js.Global().Get("Object").New()
+			if t.Description != "" {
+				jt.Set("description", t.Description)
+			}
+			accept := js.Global().Get("Object").New()
+			for mt, exts := range t.Accept {
+				arr := js.Global().Get("Array").New()
+				for _, ext := range exts {
+					arr.Call("push", ext)
+				}
+				accept.Set(mt, arr)
+			}
+			jt.Set("accept", accept)
+			types.Call("push", jt)
+		}
+		jsOpts.Set("types", types)
+	}
+
+	handle, err := js.Await(js.Global().Call("showSaveFilePicker", jsOpts), js.NewDOMError)
+	if err != nil {
+		return err
+	}
+	return writeHandle(handle, data)
+}
+
+// PickAvailable returns true if the browser supports PickDirectory.
+func PickAvailable() bool {
+	return js.Global().Get("showDirectoryPicker").Truthy()
+}
+
+// PickDirectory shows a dialog for the user to choose a directory.  If write is true read and write
+// access is asked for, otherwise only read access.
+func PickDirectory(write bool) (*Directory, error) {
+
+	if !PickAvailable() {
+		return nil, ErrNotAvailable
+	}
+
+	opts := js.Global().Get("Object").New()
+	if write {
+		opts.Set("mode", "readwrite")
+	}
+	handle, err := js.Await(js.Global().Call("showDirectoryPicker", opts), js.NewDOMError)
+	if err != nil {
+		return nil, err
+	}
+	return &Directory{handle: handle}, nil
+}
+
+// Directory is a directory chosen by the user with PickDirectory.
+type Directory struct {
+	handle js.Value
+}
+
+// Entry is a file or directory in a Directory.
+type Entry struct {
+	Name  string
+	IsDir bool
+}
+
+// Name returns the name of the directory.
+func (d *Directory) Name() string {
+	return d.handle.Get("name").String()
+}
+
+// Handle returns the FileSystemDirectoryHandle, e.g. to store in IndexedDB to use again later.
+func (d *Directory) Handle() js.Value {
+	return d.handle
+}
+
+// List returns the entries in the directory, in no particular order.
+func (d *Directory) List() ([]Entry, error) {
+
+	var ret []Entry
+	iter := d.handle.Call("values")
+	for {
+		next, err := js.Await(iter.Call("next"), js.NewDOMError)
+		if err != nil {
+			return ret, err
+		}
+		if next.Get("done").Bool() {
+			return ret, nil
+		}
+		v := next.Get("value")
+		ret = append(ret, Entry{Name: v.Get("name").String(), IsDir: v.Get("kind").String() == "directory"})
+	}
+}
+
+// Dir returns the subdirectory with the specified name, creating it if create is true and it does not exist.
+func (d *Directory) Dir(name string, create bool) (*Directory, error) {
+	opts := js.Global().Get("Object").New()
+	opts.Set("create", create)
+	handle, err := js.Await(d.handle.Call("getDirectoryHandle", name, opts), js.NewDOMError)
+	if err != nil {
+		return nil, err
+	}
+	return &Directory{handle: handle}, nil
+}
+
+// ReadFile returns the contents of the file with the specified name.
+func (d *Directory) ReadFile(name string) ([]byte, error) {
+	handle, err := js.Await(d.handle.Call("getFileHandle", name), js.NewDOMError)
+	if err != nil {
+		return nil, err
+	}
+	file, err := js.Await(handle.Call("getFile"), js.NewDOMError)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := js.Await(file.Call("arrayBuffer"), js.NewDOMError)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]byte, buf.Get("byteLength").Int())
+	js.CopyBytesToGo(ret, js.Global().Get("Uint8Array").New(buf))
+	return ret, nil
+}
+
+// WriteFile writes data to the file with the specified name, replacing it if it exists.
+// The directory must have been picked with write access.
+func (d *Directory) WriteFile(name string, data []byte) error {
+	opts := js.Global().Get("Object").New()
+	opts.Set("create", true)
+	handle, err := js.Await(d.handle.Call("getFileHandle", name, opts), js.NewDOMError)
+	if err != nil {
+		return err
+	}
+	return writeHandle(handle, data)
+}
+
+// Remove removes the file or (empty) directory with the specified name.
+func (d *Directory) Remove(name string) error {
+	_, err := js.Await(d.handle.Call("removeEntry", name), js.NewDOMError)
+	return err
+}
+
+// writeHandle replaces the contents of a FileSystemFileHandle with data.
+func writeHandle(handle js.Value, data []byte) error {
+	w, err := js.Await(handle.Call("createWritable"), js.NewDOMError)
+	if err != nil {
+		return err
+	}
+	arr := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(arr, data)
+	if _, err := js.Await(w.Call("write", arr), js.NewDOMError); err != nil {
+		w.Call("abort")
+		return err
+	}
+	_, err = js.Await(w.Call("close"), js.NewDOMError)
+	return err
+}
@@ -0,0 +1,37 @@
+package vgfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vugu/vugu"
+)
+
+func TestNotAvailable(t *testing.T) {
+
+	if err := Download("a.txt", "text/plain", []byte("a")); err != ErrNotAvailable {
+		t.Errorf("Download: expected ErrNotAvailable, got %v", err)
+	}
+	if err := SaveAs(SaveOptions{SuggestedName: "a.txt"}, []byte("a")); err != ErrNotAvailable {
+		t.Errorf("SaveAs: expected ErrNotAvailable, got %v", err)
+	}
+	if _, err := PickDirectory(false); err != ErrNotAvailable {
+		t.Errorf("PickDirectory: expected ErrNotAvailable, got %v", err)
+	}
+}
+
+func TestIsAbort(t *testing.T) {
+	if !IsAbort(&Error{Name: "AbortError"}) {
+		t.Errorf("expected AbortError to be an abort")
+	}
+	if IsAbort(&Error{Name: "NotAllowedError"}) || IsAbort(errors.New("AbortError")) {
+		t.Errorf("unexpected abort")
+	}
+}
+
+func TestOpenEventFile(t *testing.T) {
+	e := vugu.NewDOMEvent(nil, map[string]interface{}{"type": "change"})
+	if _, err := OpenEventFile(e, 0); err != ErrNotAvailable {
+		t.Errorf("OpenEventFile: expected ErrNotAvailable, got %v", err)
+	}
+}
@@ -12,12 +12,20 @@ import "github.com/vugu/vugu"
 type Input struct {
 	Value   StringValuer // get/set the currently selected value
 	AttrMap vugu.AttrMap
+	Field   *Field // optional Form field to validate, its Value is used if Value is nil
 }
 
 func (c *Input) handleChange(event vugu.DOMEvent) {
 
 	newVal := event.PropString("target", "value")
 	// c.curVal = newVal // why not
-	c.Value.SetStringValue(newVal)
+	c.value().SetStringValue(newVal)
+	c.Field.Touch()
 
 }
+
+func (c *Input) value() StringValuer { return fieldValue(c.Value, c.Field, c.AttrMap) }
+
+func (c *Input) ariaInvalid() interface{} { return fieldAriaInvalid(c.Field) }
+
+func (c *Input) fieldRef() interface{} { return fieldRef(c.Field) }
@@ -34,6 +34,7 @@ type Select struct {
 	Options Options // provide KeyLister and TextMapper in one
 
 	AttrMap vugu.AttrMap // regular HTML attributes like id and class
+	Field   *Field       // optional Form field to validate, its Value is used if Value is nil
 	// TODO: might make sense to refactor this AttrMap thing to work well
 	// with SetAttributeInterface and AttributeLister/vg-attr - perhaps a slice of attributes
 	// is better and for vg-attr we just append to the slice and for the
@@ -51,15 +52,15 @@ func (c *Select) buildKeys() []string {
 	// if c.el.IsUndefined() {
 	// 	panic(errors.New("Select should have c.el set"))
 	// }
-	if c.Value == nil {
-		panic(errors.New("Select.Value must not be nil"))
+	if c.value() == nil {
+		panic(errors.New("Select.Value or Select.Field must not be nil"))
 	}
 	if c.Options == nil {
 		panic(errors.New("Select.Options must not be nil (TODO: when slots are supported that will be allowed instead of Options)"))
 	}
 
 	c.keys = c.Options.KeyList()
-	c.curVal = c.Value.StringValue()
+	c.curVal = c.value().StringValue()
 
 	return c.keys
 }
@@ -102,6 +103,13 @@ func (c *Select) handleChange(event vugu.DOMEvent) {
 
 	newVal := event.PropString("target", "value")
 	c.curVal = newVal // why not
-	c.Value.SetStringValue(newVal)
+	c.value().SetStringValue(newVal)
+	c.Field.Touch()
 
 }
+
+func (c *Select) value() StringValuer { return fieldValue(c.Value, c.Field, c.AttrMap) }
+
+func (c *Select) ariaInvalid() interface{} { return fieldAriaInvalid(c.Field) }
+
+func (c *Select) fieldRef() interface{} { return fieldRef(c.Field) }
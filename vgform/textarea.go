@@ -6,12 +6,20 @@ import "github.com/vugu/vugu"
 type Textarea struct {
 	Value   StringValuer // get/set the currently selected value
 	AttrMap vugu.AttrMap
+	Field   *Field // optional Form field to validate, its Value is used if Value is nil
 }
 
 func (c *Textarea) handleChange(event vugu.DOMEvent) {
 
 	newVal := event.PropString("target", "value")
 	// c.curVal = newVal // why not
-	c.Value.SetStringValue(newVal)
+	c.value().SetStringValue(newVal)
+	c.Field.Touch()
 
 }
+
+func (c *Textarea) value() StringValuer { return fieldValue(c.Value, c.Field, c.AttrMap) }
+
+func (c *Textarea) ariaInvalid() interface{} { return fieldAriaInvalid(c.Field) }
+
+func (c *Textarea) fieldRef() interface{} { return fieldRef(c.Field) }
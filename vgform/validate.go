@@ -0,0 +1,359 @@
+package vgform
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vugu/vugu"
+)
+
+// Validator checks the value of a field, returning an error with the message to show if it is not valid.
+type Validator interface {
+	Validate(value string) error
+}
+
+// ValidatorFunc implements Validator as a function, e.g. for checks specific to an app.
+type ValidatorFunc func(value string) error
+
+// Validate implements the Validator interface.
+func (f ValidatorFunc) Validate(value string) error { return f(value) }
+
+// validationError is the error of the validators here, its message is shown to the user.
+type validationError string
+
+func (e validationError) Error() string { return string(e) }
+
+// message returns msg if not empty or else def formatted with args.
+func message(msg, def string, args ...interface{}) error {
+	if msg != "" {
+		return validationError(msg)
+	}
+	return validationError(fmt.Sprintf(def, args...))
+}
+
+// Required checks the value is not empty or only spaces.  If msg is empty a default message is used, as
+// with the other validators here.  The other validators accept an empty value, so a field can be optional.
+func Required(msg string) Validator {
+	return ValidatorFunc(func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return message(msg, "This field is required.")
+		}
+		return nil
+	})
+}
+
+// Pattern checks the whole value matches the regular expression pattern, like the pattern attribute of an
+// input.  It panics if pattern does not compile.
+func Pattern(pattern, msg string) Validator {
+	re := regexp.MustCompile("^(?:" + pattern + ")$")
+	return ValidatorFunc(func(value string) error {
+		if value != "" && !re.MatchString(value) {
+			return message(msg, "Enter a value in the requested format.")
+		}
+		return nil
+	})
+}
+
+// MinLength checks the value has at least n characters.
+func MinLength(n int, msg string) Validator {
+	return ValidatorFunc(func(value string) error {
+		if value != "" && utf8.RuneCountInString(value) < n {
+			return message(msg, "Enter at least %d characters.", n)
+		}
+		return nil
+	})
+}
+
+// MaxLength checks the value has at most n characters.
+func MaxLength(n int, msg string) Validator {
+	return ValidatorFunc(func(value string) error {
+		if utf8.RuneCountInString(value) > n {
+			return message(msg, "Enter at most %d characters.", n)
+		}
+		return nil
+	})
+}
+
+// Min checks the value is a number no less than min.
+func Min(min float64, msg string) Validator {
+	return ValidatorFunc(func(value string) error {
+		if value == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return validationError("Enter a number.")
+		}
+		if f < min {
+			return message(msg, "Enter a number no less than %v.", min)
+		}
+		return nil
+	})
+}
+
+// Max checks the value is a number no more than max.
+func Max(max float64, msg string) Validator {
+	return ValidatorFunc(func(value string) error {
+		if value == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return validationError("Enter a number.")
+		}
+		if f > max {
+			return message(msg, "Enter a number no more than %v.", max)
+		}
+		return nil
+	})
+}
+
+// attrValidators returns the validators for the HTML validation attributes in attrs: required, pattern,
+// minlength, maxlength, min and max.
+func attrValidators(attrs vugu.AttrMap) []Validator {
+	var ret []Validator
+	str := func(k string) (string, bool) {
+		v, ok := attrs[k]
+		if !ok || v == nil || v == false {
+			return "", false
+		}
+		return fmt.Sprint(v), true
+	}
+	if _, ok := str("required"); ok {
+		ret = append(ret, Required(""))
+	}
+	if s, ok := str("pattern"); ok {
+		if _, err := regexp.Compile(s); err == nil {
+			ret = append(ret, Pattern(s, ""))
+		}
+	}
+	if s, ok := str("minlength"); ok {
+		if n, err := strconv.Atoi(s); err == nil {
+			ret = append(ret, MinLength(n, ""))
+		}
+	}
+	if s, ok := str("maxlength"); ok {
+		if n, err := strconv.Atoi(s); err == nil {
+			ret = append(ret, MaxLength(n, ""))
+		}
+	}
+	if s, ok := str("min"); ok {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			ret = append(ret, Min(f, ""))
+		}
+	}
+	if s, ok := str("max"); ok {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			ret = append(ret, Max(f, ""))
+		}
+	}
+	return ret
+}
+
+// Field is a value in a Form with the validators it must pass, see Form.Add.  Its methods may be called
+// on a nil Field, which is always valid.
+type Field struct {
+	Name       string
+	Value      StringValuer
+	Validators []Validator
+
+	attrValidators []Validator // from the attributes of the control it is bound to
+	form           *Form
+	touched        bool
+	err            error
+}
+
+// Validate checks the value with the validators of the field, and those from the validation attributes of
+// the control it is bound to, and returns the first error.
+func (f *Field) Validate() error {
+	if f == nil {
+		return nil
+	}
+	var value string
+	if f.Value != nil {
+		value = f.Value.StringValue()
+	}
+	f.err = nil
+	for _, list := range [][]Validator{f.attrValidators, f.Validators} {
+		for _, v := range list {
+			if err := v.Validate(value); err != nil {
+				f.err = err
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Touch marks the field as changed by the user and validates it, so its error is shown from now on.
+// The controls in this package call it on change.
+func (f *Field) Touch() {
+	if f == nil {
+		return
+	}
+	f.touched = true
+	f.Validate()
+}
+
+// Touched returns true once the field has been changed by the user.
+func (f *Field) Touched() bool {
+	return f != nil && f.touched
+}
+
+// Err returns the error from the last time the field was validated.
+func (f *Field) Err() error {
+	if f == nil {
+		return nil
+	}
+	return f.err
+}
+
+// Message returns the error message to show: that of Err once the field is touched or its form was
+// submitted, so a form is not covered in errors before the user has typed anything, otherwise empty.
+func (f *Field) Message() string {
+	if f == nil || f.err == nil || !(f.touched || (f.form != nil && f.form.submitted)) {
+		return ""
+	}
+	return f.err.Error()
+}
+
+// Invalid returns true if Message is not empty, e.g. for :class='{"is-invalid": c.form.Field("email").Invalid()}'.
+func (f *Field) Invalid() bool {
+	return f.Message() != ""
+}
+
+// Form validates a set of fields and blocks submitting it until they are valid.  The fields are added
+// once, e.g. in Init, and bound to the controls in this package with their Field:
+//
+//	func (c *Signup) Init() {
+//		c.form.Add("email", vgform.StringPtr{&c.Email}, vgform.Required(""), vgform.Pattern(`[^@\s]+@[^@\s]+`, "Enter an email address."))
+//		c.form.Add("age", vgform.StringPtr{&c.Age}, vgform.Min(18, "You must be 18 or over."))
+//	}
+//
+// with
+//
+//	<form novalidate @submit='c.form.Submit(event, c.save)'>
+//		<vgform:Input type="email" :Field='c.form.Field("email")'></vgform:Input>
+//		<p class="error" vg-if='c.form.Field("email").Invalid()' vg-content='c.form.Field("email").Message()'></p>
+//		<vgform:Input type="number" max="130" :Field='c.form.Field("age")'></vgform:Input>
+//		...
+//	</form>
+//
+// The HTML validation attributes of a control (required, pattern, minlength, maxlength, min and max) are
+// checked as well.  Set novalidate on the form so the browser leaves the checking to the Form.  As changes
+// and submits are handled by event handlers, errors are rendered as they change.
+type Form struct {
+	fields    []*Field
+	submitted bool
+}
+
+// Add adds a field with its value and validators, replacing any with the same name, and returns it.
+func (f *Form) Add(name string, value StringValuer, validators ...Validator) *Field {
+	fld := &Field{Name: name, Value: value, Validators: validators, form: f}
+	for i, old := range f.fields {
+		if old.Name == name {
+			f.fields[i] = fld
+			return fld
+		}
+	}
+	f.fields = append(f.fields, fld)
+	return fld
+}
+
+// Field returns the field added with name, or nil if there is none.
+func (f *Form) Field(name string) *Field {
+	for _, fld := range f.fields {
+		if fld.Name == name {
+			return fld
+		}
+	}
+	return nil
+}
+
+// Fields returns the fields in the order they were added.
+func (f *Form) Fields() []*Field {
+	return f.fields
+}
+
+// Check validates all the fields and marks the form as submitted, so all their errors are shown.  It
+// returns the first invalid field, or nil if they are all valid.
+func (f *Form) Check() *Field {
+	f.submitted = true
+	var first *Field
+	for _, fld := range f.fields {
+		if fld.Validate() != nil && first == nil {
+			first = fld
+		}
+	}
+	return first
+}
+
+// Submit handles the submit event of the form: it stops the browser submitting it, checks the fields
+// and calls onValid if they are all valid.  Otherwise the control of the first invalid field is focused.
+func (f *Form) Submit(event vugu.DOMEvent, onValid func()) {
+	event.PreventDefault()
+	if first := f.Check(); first != nil {
+		vugu.Focus(event.EventEnv(), first.Name)
+		return
+	}
+	if onValid != nil {
+		onValid()
+	}
+}
+
+// Valid returns true if all the fields are valid, without showing their errors.
+func (f *Form) Valid() bool {
+	valid := true
+	for _, fld := range f.fields {
+		if fld.Validate() != nil {
+			valid = false
+		}
+	}
+	return valid
+}
+
+// Submitted returns true once Submit or Check has been called.
+func (f *Form) Submitted() bool {
+	return f.submitted
+}
+
+// Reset hides the errors again, as before the fields were touched and the form submitted, e.g. after
+// saving it.
+func (f *Form) Reset() {
+	f.submitted = false
+	for _, fld := range f.fields {
+		fld.touched, fld.err = false, nil
+	}
+}
+
+// fieldValue returns the value a control is bound to: v, or the value of f if v is nil.  The validators
+// for the attributes of the control are set on f as it renders.
+func fieldValue(v StringValuer, f *Field, attrs vugu.AttrMap) StringValuer {
+	if f == nil {
+		return v
+	}
+	f.attrValidators = attrValidators(attrs)
+	if v == nil {
+		return f.Value
+	}
+	return v
+}
+
+// fieldAriaInvalid returns the aria-invalid attribute value for f, nil omits it.
+func fieldAriaInvalid(f *Field) interface{} {
+	if !f.Invalid() {
+		return nil
+	}
+	return "true"
+}
+
+// fieldRef returns the data-vg-ref attribute value for f, so Form.Submit can focus its control.
+func fieldRef(f *Field) interface{} {
+	if f == nil || f.Name == "" {
+		return nil
+	}
+	return f.Name
+}
@@ -0,0 +1,127 @@
+package vgform
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vugu/vugu"
+)
+
+func TestValidators(t *testing.T) {
+
+	tcList := []struct {
+		name  string
+		v     Validator
+		value string
+		ok    bool
+	}{
+		{"required-empty", Required(""), " ", false},
+		{"required-ok", Required(""), "a", true},
+		{"pattern-ok", Pattern(`[a-z]+`, ""), "abc", true},
+		{"pattern-partial", Pattern(`[a-z]+`, ""), "abc1", false},
+		{"pattern-empty", Pattern(`[a-z]+`, ""), "", true},
+		{"minlength", MinLength(3, ""), "ab", false},
+		{"minlength-runes", MinLength(3, ""), "äöü", true},
+		{"maxlength", MaxLength(2, ""), "abc", false},
+		{"min", Min(18, ""), "17", false},
+		{"min-ok", Min(18, ""), "18", true},
+		{"min-nan", Min(18, ""), "x", false},
+		{"max", Max(10, ""), "10.5", false},
+		{"max-empty", Max(10, ""), "", true},
+	}
+
+	for _, tc := range tcList {
+		err := tc.v.Validate(tc.value)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: Validate(%q) = %v", tc.name, tc.value, err)
+		}
+	}
+
+	if err := Required("Name please.").Validate(""); err == nil || err.Error() != "Name please." {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
+
+func TestForm(t *testing.T) {
+
+	var name, age string
+	var f Form
+	f.Add("name", StringPtr{&name}, Required(""))
+	f.Add("age", StringPtr{&age}, ValidatorFunc(func(v string) error {
+		if v == "13" {
+			return errors.New("Unlucky.")
+		}
+		return nil
+	}))
+
+	// errors are not shown before the field is touched or the form submitted
+	if f.Valid() {
+		t.Errorf("expected form to be invalid")
+	}
+	if f.Field("name").Invalid() {
+		t.Errorf("expected no message before touch")
+	}
+
+	if first := f.Check(); first == nil || first.Name != "name" {
+		t.Fatalf("unexpected first invalid field: %v", first)
+	}
+	if msg := f.Field("name").Message(); msg != "This field is required." {
+		t.Errorf("unexpected message %q", msg)
+	}
+
+	name, age = "Joe", "13"
+	f.Field("name").Touch()
+	if f.Field("name").Invalid() {
+		t.Errorf("expected name to be valid after touch")
+	}
+	if first := f.Check(); first == nil || first.Name != "age" {
+		t.Fatalf("unexpected first invalid field: %v", first)
+	}
+
+	age = "14"
+	if first := f.Check(); first != nil {
+		t.Errorf("expected valid form, got %s invalid", first.Name)
+	}
+
+	f.Reset()
+	age = "13"
+	f.Valid()
+	if f.Field("age").Invalid() || f.Submitted() {
+		t.Errorf("expected no messages after Reset")
+	}
+
+	// nil fields are valid
+	var nf *Field
+	if nf.Validate() != nil || nf.Invalid() || f.Field("missing") != nil {
+		t.Errorf("expected nil field to be valid")
+	}
+}
+
+func TestFieldAttrs(t *testing.T) {
+
+	var v string
+	var f Form
+	fld := f.Add("code", StringPtr{&v})
+
+	c := &Input{Field: fld, AttrMap: vugu.AttrMap{"required": true, "pattern": `\d+`, "maxlength": 4}}
+	if c.value() != fld.Value {
+		t.Errorf("expected the field value to be used")
+	}
+	if c.fieldRef() != "code" {
+		t.Errorf("unexpected ref %v", c.fieldRef())
+	}
+
+	for value, ok := range map[string]bool{"": false, "12a": false, "12345": false, "123": true} {
+		v = value
+		if (fld.Validate() == nil) != ok {
+			t.Errorf("Validate(%q) = %v", value, fld.Err())
+		}
+	}
+
+	f.Check()
+	v = ""
+	f.Check()
+	if c.ariaInvalid() != "true" {
+		t.Errorf("expected aria-invalid")
+	}
+}
@@ -0,0 +1,87 @@
+// +build js
+
+package vghistory
+
+import (
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+// New returns the History of the page.
+func New(eventEnv vugu.EventEnv) *History {
+	return newHistory(eventEnv, &browserBackend{})
+}
+
+// browserBackend is a backend using window.history and window.location.
+type browserBackend struct {
+	onPopState js.Func
+	listening  bool
+}
+
+func (b *browserBackend) href() string {
+	return js.Global().Get("location").Get("href").String()
+}
+
+// entry returns the ID kept in history.state, which is an object with a vgEntry field.
+func (b *browserBackend) entry(newID int64) int64 {
+	history := js.Global().Get("history")
+	st := history.Get("state")
+	if st.Type() == js.TypeObject {
+		if id := st.Get("vgEntry"); id.Type() == js.TypeNumber {
+			return int64(id.Float())
+		}
+	}
+	history.Call("replaceState", entryState(newID), "")
+	return newID
+}
+
+func (b *browserBackend) push(u string, id int64) {
+	js.Global().Get("history").Call("pushState", entryState(id), "", u)
+}
+
+func (b *browserBackend) replace(u string) {
+	history := js.Global().Get("history")
+	history.Call("replaceState", history.Get("state"), "", u)
+}
+
+func entryState(id int64) js.Value {
+	st := js.Global().Get("Object").New()
+	st.Set("vgEntry", float64(id))
+	return st
+}
+
+func (b *browserBackend) move(delta int) {
+	js.Global().Get("history").Call("go", delta)
+}
+
+func (b *browserBackend) listen(fn func()) {
+	b.onPopState = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		// the handler locks the EventEnv, which must not be done in a JS callback
+		go fn()
+		return nil
+	})
+	js.Global().Call("addEventListener", "popstate", b.onPopState)
+	b.listening = true
+}
+
+func (b *browserBackend) scroll() (x, y float64) {
+	w := js.Global()
+	return w.Get("scrollX").Float(), w.Get("scrollY").Float()
+}
+
+func (b *browserBackend) scrollTo(x, y float64) {
+	js.Global().Call("scrollTo", x, y)
+}
+
+func (b *browserBackend) manualScroll() {
+	js.Global().Get("history").Set("scrollRestoration", "manual")
+}
+
+func (b *browserBackend) stopListening() {
+	if !b.listening {
+		return
+	}
+	b.listening = false
+	js.Global().Call("removeEventListener", "popstate", b.onPopState)
+	b.onPopState.Release()
+}
@@ -0,0 +1,10 @@
+// +build !js
+
+package vghistory
+
+import "github.com/vugu/vugu"
+
+// New returns the History of the page.  Outside the browser it is kept in memory, starting at "http://localhost/".
+func New(eventEnv vugu.EventEnv) *History {
+	return NewMemory(eventEnv, "http://localhost/")
+}
@@ -0,0 +1,95 @@
+package vghistory
+
+import (
+	"sync"
+
+	"github.com/vugu/vugu"
+)
+
+// NewMemory returns a History kept in memory, starting at the absolute URL start, for use outside the
+// browser and in tests.  Back and Forward behave as in the browser, calling the OnChange funcs later
+// with the EventEnv locked.
+func NewMemory(eventEnv vugu.EventEnv, start string) *History {
+	return newHistory(eventEnv, &memoryBackend{entries: []memoryEntry{{u: start}}})
+}
+
+// memoryBackend is a backend which keeps the history in a slice.
+type memoryBackend struct {
+	mu      sync.Mutex
+	entries []memoryEntry
+	index   int
+	fn      func()
+	x, y    float64 // the scroll position
+}
+
+type memoryEntry struct {
+	u  string
+	id int64
+}
+
+func (m *memoryBackend) href() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.entries[m.index].u
+}
+
+func (m *memoryBackend) entry(newID int64) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries[m.index].id == 0 {
+		m.entries[m.index].id = newID
+	}
+	return m.entries[m.index].id
+}
+
+func (m *memoryBackend) push(u string, id int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries[:m.index+1], memoryEntry{u: u, id: id})
+	m.index++
+}
+
+func (m *memoryBackend) replace(u string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[m.index].u = u
+}
+
+func (m *memoryBackend) move(delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.index + delta
+	if i < 0 || i >= len(m.entries) {
+		return
+	}
+	m.index = i
+	if m.fn != nil {
+		go m.fn()
+	}
+}
+
+func (m *memoryBackend) listen(fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fn = fn
+}
+
+func (m *memoryBackend) stopListening() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fn = nil
+}
+
+func (m *memoryBackend) scroll() (x, y float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.x, m.y
+}
+
+func (m *memoryBackend) scrollTo(x, y float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.x, m.y = x, y
+}
+
+func (m *memoryBackend) manualScroll() {}
@@ -0,0 +1,251 @@
+/*
+Package vghistory gives components the page URL and navigation with the browser's History API, without
+using js.Value:
+
+	func (c *Root) Init(ctx vugu.InitCtx) {
+		c.history = vghistory.New(ctx.EventEnv())
+		c.removeListener = c.history.OnChange(func(u *url.URL) {
+			c.page = u.Query().Get("page")
+		})
+	}
+
+	func (c *Root) HandleNext(e vugu.DOMEvent) {
+		c.history.Push("?page=" + c.nextPage)
+	}
+
+Push and Replace change the URL and call the OnChange funcs directly, so they are to be called with the
+EventEnv locked, e.g. from an event handler.  When the URL changes because the user goes back or
+forward, the OnChange funcs are called with the EventEnv write lock held and a render requested after.
+
+Scroll positions are handled like for pages loaded from the server if Rendered is called from the root
+component's Rendered method: the page is scrolled to the top after Push, and back to where it was after
+going back or forward, once the content is there.
+
+	func (c *Root) Rendered(ctx vugu.RenderedCtx) {
+		c.history.Rendered()
+	}
+
+Outside the browser New returns a History kept in memory, as does NewMemory, e.g. for tests.
+*/
+package vghistory
+
+import (
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vugu/vugu"
+)
+
+// backend is where the history is kept, the browser or memory.
+type backend interface {
+	href() string            // the current absolute URL
+	entry(newID int64) int64 // the ID of the current entry, which is given newID if it has none
+	push(u string, id int64) // add a URL to the history with an entry ID and make it current
+	replace(u string)        // replace the current URL, keeping the entry ID
+	move(delta int)          // go back (negative) or forward, calling the listen func once the URL changed
+	listen(fn func())        // fn is called in a new goroutine after the URL changes because of move or the user
+	stopListening()          // stop calling the listen func
+	scroll() (x, y float64)  // the scroll position of the page
+	scrollTo(x, y float64)   // scroll the page
+	manualScroll()           // stop the browser restoring scroll positions itself
+}
+
+// History is the history of the page, see the package documentation.
+type History struct {
+	eventEnv vugu.EventEnv
+	b        backend
+
+	mu        sync.Mutex
+	listeners map[int]func(u *url.URL)
+	nextID    int
+	closed    bool
+
+	// scroll positions by history entry ID, see Rendered
+	entryID     int64 // the current entry
+	lastEntryID int64
+	scrolls     map[int64][2]float64
+	pending     *[2]float64 // where to scroll on the next Rendered
+	manual      bool        // manualScroll was called
+}
+
+func newHistory(eventEnv vugu.EventEnv, b backend) *History {
+	h := &History{eventEnv: eventEnv, b: b, listeners: make(map[int]func(u *url.URL)), scrolls: make(map[int64][2]float64)}
+	h.entryID = b.entry(h.newEntryID())
+	b.listen(h.popped)
+	return h
+}
+
+// newEntryID returns an ID for a history entry, which is unique across page loads in the same tab
+// (it is based on the time in microseconds, so it is exact as a JS number).
+func (h *History) newEntryID() int64 {
+	id := time.Now().UnixNano() / 1000
+	if id <= h.lastEntryID {
+		id = h.lastEntryID + 1
+	}
+	h.lastEntryID = id
+	return id
+}
+
+// URL returns the current URL.
+func (h *History) URL() *url.URL {
+	u, err := url.Parse(h.b.href())
+	if err != nil {
+		return &url.URL{Path: "/"}
+	}
+	return u
+}
+
+// Path returns the path of the current URL.
+func (h *History) Path() string {
+	return h.URL().Path
+}
+
+// Query returns the query parameters of the current URL.
+func (h *History) Query() url.Values {
+	return h.URL().Query()
+}
+
+// Push navigates to u, which may be relative to the current URL (e.g. "?page=2" or "../list"), adding it
+// to the history so the back button returns to the current URL.  Only the path, query and fragment of u
+// are used, the page cannot navigate to another origin this way.  The OnChange funcs are called before it
+// returns.
+func (h *History) Push(u string) {
+	next := h.resolve(u)
+	if next == nil {
+		return
+	}
+	h.mu.Lock()
+	x, y := h.b.scroll()
+	h.scrolls[h.entryID] = [2]float64{x, y}
+	h.entryID = h.newEntryID()
+	h.b.push(next.String(), h.entryID)
+	h.pending = &[2]float64{0, 0}
+	h.mu.Unlock()
+	h.notify()
+}
+
+// Replace is like Push but replaces the current URL in the history instead of adding to it.
+func (h *History) Replace(u string) {
+	next := h.resolve(u)
+	if next == nil {
+		return
+	}
+	h.b.replace(next.String())
+	h.notify()
+}
+
+// SetQuery replaces the current URL with one with the query parameters q, leaving the rest as is, e.g. to
+// reflect the state of a filter in the URL without adding to the history.
+func (h *History) SetQuery(q url.Values) {
+	next := h.URL()
+	next.RawQuery = q.Encode()
+	h.b.replace(next.String())
+	h.notify()
+}
+
+// Back goes back in the history, as the browser's back button does.  The OnChange funcs are called later,
+// like when the user goes back.
+func (h *History) Back() {
+	h.b.move(-1)
+}
+
+// Forward goes forward in the history.  The OnChange funcs are called later, like when the user goes forward.
+func (h *History) Forward() {
+	h.b.move(1)
+}
+
+// OnChange adds fn to the funcs called with the new URL when it changes.  The returned func removes it.
+func (h *History) OnChange(fn func(u *url.URL)) (remove func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	id := h.nextID
+	h.nextID++
+	h.listeners[id] = fn
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.listeners, id)
+	}
+}
+
+// Close stops calling the OnChange funcs, and releases what is used to listen for changes in the browser.
+func (h *History) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	h.b.stopListening()
+}
+
+// resolve returns u resolved against the current URL, keeping the current scheme and host.
+func (h *History) resolve(u string) *url.URL {
+	ref, err := url.Parse(u)
+	if err != nil {
+		return nil
+	}
+	ref.Scheme, ref.Host, ref.User = "", "", nil
+	return h.URL().ResolveReference(ref)
+}
+
+// Rendered scrolls the page for the last navigation, to the top after Push or to where it was after going
+// back or forward, see the package documentation.  It is to be called from the root component's Rendered
+// method, after the content for the new URL has been rendered.
+func (h *History) Rendered() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.manual {
+		h.manual = true
+		h.b.manualScroll()
+	}
+	if h.pending != nil {
+		h.b.scrollTo(h.pending[0], h.pending[1])
+		h.pending = nil
+	}
+}
+
+// popped is called when the user or Back or Forward changed the URL.
+func (h *History) popped() {
+	if h.eventEnv != nil {
+		h.eventEnv.Lock()
+		defer h.eventEnv.UnlockRender()
+	}
+
+	// the page has not been scrolled since (the browser does not once Rendered has been called), so the
+	// position is that of the entry left
+	h.mu.Lock()
+	x, y := h.b.scroll()
+	h.scrolls[h.entryID] = [2]float64{x, y}
+	h.entryID = h.b.entry(h.newEntryID())
+	pos := h.scrolls[h.entryID]
+	h.pending = &pos
+	h.mu.Unlock()
+
+	h.notify()
+}
+
+// notify calls the OnChange funcs with the current URL, in the order they were added.
+func (h *History) notify() {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	ids := make([]int, 0, len(h.listeners))
+	for id := range h.listeners {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	fns := make([]func(u *url.URL), 0, len(ids))
+	for _, id := range ids {
+		fns = append(fns, h.listeners[id])
+	}
+	h.mu.Unlock()
+
+	for _, fn := range fns {
+		fn(h.URL())
+	}
+}
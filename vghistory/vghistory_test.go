@@ -0,0 +1,112 @@
+package vghistory
+
+import (
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+func TestHistory(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var mu sync.RWMutex
+	renderCh := make(chan bool, 1)
+	ee := vugu.NewEventEnvImpl(&mu, renderCh)
+
+	h := NewMemory(ee, "https://example.com/list?page=1")
+	assert.Equal("/list", h.Path())
+	assert.Equal("1", h.Query().Get("page"))
+
+	var got []string
+	remove := h.OnChange(func(u *url.URL) { got = append(got, u.String()) })
+
+	// relative URLs, staying on the same origin
+	h.Push("?page=2")
+	h.Push("items/3#top")
+	h.Push("https://other.example.com/away")
+	assert.Equal([]string{
+		"https://example.com/list?page=2",
+		"https://example.com/items/3#top",
+		"https://example.com/away",
+	}, got)
+
+	h.Replace("/replaced")
+	assert.Equal("https://example.com/replaced", h.URL().String())
+
+	h.SetQuery(url.Values{"q": {"a b"}})
+	assert.Equal("https://example.com/replaced?q=a+b", h.URL().String())
+
+	// back and forward are delivered with the lock held and a render requested
+	got = nil
+	h.Back()
+	assert.True(<-renderCh)
+	mu.Lock()
+	assert.Equal([]string{"https://example.com/items/3#top"}, got)
+	mu.Unlock()
+	h.Forward()
+	<-renderCh
+	assert.Equal("https://example.com/replaced?q=a+b", h.URL().String())
+
+	// pushing after going back drops the entries ahead
+	h.Back()
+	<-renderCh
+	h.Push("/new")
+	h.Forward()
+	assert.Equal("https://example.com/new", h.URL().String())
+
+	// nothing is called once removed or closed
+	got = nil
+	remove()
+	h.Push("/a")
+	assert.Empty(got)
+	h.OnChange(func(u *url.URL) { got = append(got, u.String()) })
+	h.Close()
+	h.Push("/b")
+	assert.Empty(got)
+}
+
+func TestHistoryScroll(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var mu sync.RWMutex
+	renderCh := make(chan bool, 1)
+	ee := vugu.NewEventEnvImpl(&mu, renderCh)
+
+	h := NewMemory(ee, "https://example.com/list")
+	m := h.b.(*memoryBackend)
+
+	// nothing is scrolled until the navigation is rendered
+	m.scrollTo(0, 500)
+	h.Push("/item/1")
+	x, y := m.scroll()
+	assert.Equal([2]float64{0, 500}, [2]float64{x, y})
+	h.Rendered()
+	x, y = m.scroll()
+	assert.Equal([2]float64{0, 0}, [2]float64{x, y})
+
+	// back to where the list was, and forward to where the item was
+	m.scrollTo(10, 200)
+	h.Back()
+	<-renderCh
+	h.Rendered()
+	x, y = m.scroll()
+	assert.Equal([2]float64{0, 500}, [2]float64{x, y})
+	h.Forward()
+	<-renderCh
+	h.Rendered()
+	x, y = m.scroll()
+	assert.Equal([2]float64{10, 200}, [2]float64{x, y})
+
+	// other renders leave the scroll position alone
+	m.scrollTo(0, 50)
+	h.Replace("?tab=2")
+	h.Rendered()
+	x, y = m.scroll()
+	assert.Equal([2]float64{0, 50}, [2]float64{x, y})
+}
@@ -0,0 +1,222 @@
+/*
+Package vgimage loads images into a canvas (an OffscreenCanvas where the browser has it) so they can be
+resized, cropped and rotated before being uploaded, e.g. for avatar and photo upload forms.
+
+Transforms are done by the browser's canvas, which is fast and handles decoding of all the formats the
+browser supports.  For anything else, Pixels copies the image into Go as an *image.RGBA, and FromRGBA
+copies one back so it can be encoded with Blob or Bytes.
+
+Load, Blob and Bytes wait for the browser so must not be called from an event handler directly,
+call them in a goroutine:
+
+	func (c *Avatar) HandleChange(event vugu.DOMEvent) {
+		file := event.JSEventTarget().Get("files").Index(0)
+		ee := event.EventEnv()
+		go func() {
+			img, err := vgimage.Load(file)
+			if err == nil {
+				img = img.Fit(256, 256)
+				c.upload, err = img.Bytes("image/jpeg", 0.9)
+			}
+			ee.Lock()
+			defer ee.UnlockRender()
+			c.err = err
+		}()
+	}
+*/
+package vgimage
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// ErrNotAvailable is returned when the browser has no canvas support (or outside of the browser).
+var ErrNotAvailable = errors.New("vgimage: canvas is not available in this environment")
+
+// Error is a failure reported by the browser, e.g. Name is "InvalidStateError" if the image
+// could not be decoded.
+type Error = js.DOMError
+
+// Image is an image held in a canvas.  Transforms return a new Image and leave the original as is.
+type Image struct {
+	canvas js.Value
+	width  int
+	height int
+}
+
+// Load decodes src, which is a File or Blob (e.g. from an input with type="file"), an img element,
+// or an ImageBitmap, into a new Image.  JPEG orientation is applied by the browser.
+func Load(src js.Value) (*Image, error) {
+
+	cib := js.Global().Get("createImageBitmap")
+	if !cib.Truthy() {
+		return nil, ErrNotAvailable
+	}
+	bitmap, err := js.Await(cib.Invoke(src), js.NewDOMError)
+	if err != nil {
+		return nil, err
+	}
+	defer bitmap.Call("close")
+
+	w, h := bitmap.Get("width").Int(), bitmap.Get("height").Int()
+	ret := newImage(w, h)
+	if ret == nil {
+		return nil, ErrNotAvailable
+	}
+	ret.context().Call("drawImage", bitmap, 0, 0)
+	return ret, nil
+}
+
+// FromRGBA copies img into a new Image.
+func FromRGBA(img *image.RGBA) (*Image, error) {
+
+	b := img.Bounds()
+	ret := newImage(b.Dx(), b.Dy())
+	if ret == nil {
+		return nil, ErrNotAvailable
+	}
+
+	// copy row by row in case img is a sub image
+	pix := make([]byte, b.Dx()*b.Dy()*4)
+	for y := 0; y < b.Dy(); y++ {
+		o := img.PixOffset(b.Min.X, b.Min.Y+y)
+		copy(pix[y*b.Dx()*4:(y+1)*b.Dx()*4], img.Pix[o:o+b.Dx()*4])
+	}
+	arr := js.Global().Get("Uint8ClampedArray").New(len(pix))
+	js.CopyBytesToJS(js.Global().Get("Uint8Array").New(arr.Get("buffer")), pix)
+	data := js.Global().Get("ImageData").New(arr, b.Dx(), b.Dy())
+	ret.context().Call("putImageData", data, 0, 0)
+	return ret, nil
+}
+
+// Width returns the width of the image in pixels.
+func (im *Image) Width() int { return im.width }
+
+// Height returns the height of the image in pixels.
+func (im *Image) Height() int { return im.height }
+
+// Canvas returns the OffscreenCanvas (or canvas element) holding the image, e.g. to draw it elsewhere.
+func (im *Image) Canvas() js.Value { return im.canvas }
+
+// Resize returns the image scaled to width x height.
+func (im *Image) Resize(width, height int) *Image {
+	ret := newImage(width, height)
+	ctx := ret.context()
+	ctx.Set("imageSmoothingQuality", "high")
+	ctx.Call("drawImage", im.canvas, 0, 0, width, height)
+	return ret
+}
+
+// Fit returns the image scaled down, keeping its aspect ratio, so it is no larger than maxWidth x maxHeight.
+// If it already fits it is returned as is.
+func (im *Image) Fit(maxWidth, maxHeight int) *Image {
+	scale := math.Min(float64(maxWidth)/float64(im.width), float64(maxHeight)/float64(im.height))
+	if scale >= 1 {
+		return im
+	}
+	w := int(math.Max(1, math.Round(float64(im.width)*scale)))
+	h := int(math.Max(1, math.Round(float64(im.height)*scale)))
+	return im.Resize(w, h)
+}
+
+// Crop returns the part of the image in r, which is clipped to the image bounds.
+func (im *Image) Crop(r image.Rectangle) *Image {
+	r = r.Intersect(image.Rect(0, 0, im.width, im.height))
+	ret := newImage(r.Dx(), r.Dy())
+	ret.context().Call("drawImage", im.canvas, r.Min.X, r.Min.Y, r.Dx(), r.Dy(), 0, 0, r.Dx(), r.Dy())
+	return ret
+}
+
+// Rotate returns the image rotated clockwise by degrees.  The result is sized to fit the whole
+// rotated image, with transparent corners unless degrees is a multiple of 90.
+func (im *Image) Rotate(degrees float64) *Image {
+	rad := degrees * math.Pi / 180
+	sin, cos := math.Abs(math.Sin(rad)), math.Abs(math.Cos(rad))
+	// avoid an extra pixel from rounding errors for right angles
+	sin, cos = math.Round(sin*1e9)/1e9, math.Round(cos*1e9)/1e9
+	w := int(math.Ceil(float64(im.width)*cos + float64(im.height)*sin))
+	h := int(math.Ceil(float64(im.width)*sin + float64(im.height)*cos))
+
+	ret := newImage(w, h)
+	ctx := ret.context()
+	ctx.Call("translate", float64(w)/2, float64(h)/2)
+	ctx.Call("rotate", rad)
+	ctx.Call("drawImage", im.canvas, -float64(im.width)/2, -float64(im.height)/2)
+	return ret
+}
+
+// Pixels copies the image into Go.
+func (im *Image) Pixels() *image.RGBA {
+	ret := image.NewRGBA(image.Rect(0, 0, im.width, im.height))
+	if im.width == 0 || im.height == 0 {
+		return ret
+	}
+	data := im.context().Call("getImageData", 0, 0, im.width, im.height).Get("data")
+	js.CopyBytesToGo(ret.Pix, js.Global().Get("Uint8Array").New(data.Get("buffer")))
+	return ret
+}
+
+// Blob encodes the image as mimeType, e.g. "image/png", "image/jpeg" or "image/webp", and returns the
+// resulting Blob, which can be sent with fetch or FormData.  Quality is between 0 and 1 and is used
+// for lossy formats.  If the browser does not support mimeType it uses PNG.
+func (im *Image) Blob(mimeType string, quality float64) (js.Value, error) {
+
+	if im.canvas.Get("convertToBlob").Truthy() {
+		opts := js.Global().Get("Object").New()
+		opts.Set("type", mimeType)
+		opts.Set("quality", quality)
+		return js.Await(im.canvas.Call("convertToBlob", opts), js.NewDOMError)
+	}
+
+	// canvas element
+	ch := make(chan js.Value, 1)
+	cb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		ch <- args[0]
+		return nil
+	})
+	defer cb.Release()
+	im.canvas.Call("toBlob", cb, mimeType, quality)
+	blob := <-ch
+	if !blob.Truthy() {
+		return js.Null(), &Error{Name: "EncodingError", Message: "could not encode image"}
+	}
+	return blob, nil
+}
+
+// Bytes is like Blob but returns the encoded image, e.g. to upload using net/http.
+func (im *Image) Bytes(mimeType string, quality float64) ([]byte, error) {
+	blob, err := im.Blob(mimeType, quality)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := js.Await(blob.Call("arrayBuffer"), js.NewDOMError)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]byte, buf.Get("byteLength").Int())
+	js.CopyBytesToGo(ret, js.Global().Get("Uint8Array").New(buf))
+	return ret, nil
+}
+
+// newImage returns a new transparent Image of the specified size, or nil if canvas is not available.
+func newImage(width, height int) *Image {
+	var canvas js.Value
+	if oc := js.Global().Get("OffscreenCanvas"); oc.Truthy() {
+		canvas = oc.New(width, height)
+	} else if doc := js.Global().Get("document"); doc.Truthy() {
+		canvas = doc.Call("createElement", "canvas")
+		canvas.Set("width", width)
+		canvas.Set("height", height)
+	} else {
+		return nil
+	}
+	return &Image{canvas: canvas, width: width, height: height}
+}
+
+func (im *Image) context() js.Value {
+	return im.canvas.Call("getContext", "2d")
+}
@@ -0,0 +1,24 @@
+package vgimage
+
+import (
+	"image"
+	"testing"
+
+	js "github.com/vugu/vugu/js"
+)
+
+func TestNotAvailable(t *testing.T) {
+
+	if _, err := Load(js.Null()); err != ErrNotAvailable {
+		t.Errorf("Load: expected ErrNotAvailable, got %v", err)
+	}
+	if _, err := FromRGBA(image.NewRGBA(image.Rect(0, 0, 2, 2))); err != ErrNotAvailable {
+		t.Errorf("FromRGBA: expected ErrNotAvailable, got %v", err)
+	}
+
+	// an image that already fits is returned as is without touching the canvas
+	im := &Image{width: 100, height: 50}
+	if im.Fit(100, 100) != im {
+		t.Errorf("Fit should return the same Image when it already fits")
+	}
+}
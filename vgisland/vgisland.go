@@ -0,0 +1,145 @@
+/*
+Package vgisland shows HTML rendered by a server inside a Vugu application, as "islands" which the
+server can update as part of its responses, for moving a server rendered application to Vugu a piece
+at a time.
+
+An Island component shows the current HTML for its target, from an Islands which holds what the server
+sent for each target:
+
+	<vgisland:Island :Islands="c.Islands" Target="cart" :OnAction="c.handleCartAction"></vgisland:Island>
+
+Server responses include updates as JSON, in an "islands" member alongside anything else:
+
+	{"islands": [{"target": "cart", "html": "<ul><li>Tea <button data-vg-action=\"remove\" data-vg-value=\"17\">x</button></li></ul>"}]}
+
+and are applied with ApplyJSON, with the EventEnv write lock held so the islands are rendered again:
+
+	ee.Lock()
+	err := c.Islands.ApplyJSON(body)
+	ee.UnlockRender()
+
+As the HTML is not built by Vugu it cannot have Vugu event handlers.  Instead, clicks on elements with a
+data-vg-action attribute, and submits of forms with one, inside an island call its OnAction with the
+action name and the element's data-vg-value attribute (and the fields for a form), the same way again
+each time the HTML is replaced.
+*/
+package vgisland
+
+import (
+	"sync"
+
+	"github.com/vugu/vjson"
+
+	"github.com/vugu/vugu"
+)
+
+// Update is new HTML for the island with the name Target.
+type Update struct {
+	Target string `json:"target"`
+	HTML   string `json:"html"`
+}
+
+// Islands holds the HTML of islands, by target.  The zero value is ready to use.
+type Islands struct {
+	mu   sync.Mutex
+	html map[string]string
+}
+
+// Apply sets the HTML of islands.  An update with empty HTML empties its island.
+func (is *Islands) Apply(updates ...Update) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	if is.html == nil {
+		is.html = make(map[string]string, len(updates))
+	}
+	for _, u := range updates {
+		is.html[u.Target] = u.HTML
+	}
+}
+
+// ApplyJSON applies the updates in the "islands" member of the JSON object b, see the package
+// documentation.  Other members are ignored, and it is not an error for there to be no updates.
+func (is *Islands) ApplyJSON(b []byte) error {
+	var resp struct {
+		Islands []Update `json:"islands"`
+	}
+	err := vjson.Unmarshal(b, &resp)
+	if err != nil {
+		return err
+	}
+	is.Apply(resp.Islands...)
+	return nil
+}
+
+// HTML returns the HTML of the island target.
+func (is *Islands) HTML(target string) string {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	return is.html[target]
+}
+
+// ActionEvent is passed to Island.OnAction.
+type ActionEvent struct {
+	Name     string              // data-vg-action attribute of the element
+	Value    string              // data-vg-value attribute of the element
+	Form     map[string][]string // fields of the form, if it was submitted, see vugu.FormValues
+	DOMEvent vugu.DOMEvent
+}
+
+// Island is a component which shows the HTML of an island, see the package documentation.
+type Island struct {
+	Islands  *Islands
+	Target   string
+	Tag      string              // tag of the element the HTML is put in, "div" if empty
+	AttrMap  vugu.AttrMap        // other attributes of the element
+	OnAction func(e ActionEvent) // called for actions in the island
+}
+
+// Build implements vugu.Builder.
+func (c *Island) Build(vgin *vugu.BuildIn) (vgout *vugu.BuildOut) {
+
+	tag := c.Tag
+	if tag == "" {
+		tag = "div"
+	}
+
+	vgout = &vugu.BuildOut{}
+	vgn := &vugu.VGNode{Type: vugu.ElementNode, Data: tag}
+	vgout.Out = append(vgout.Out, vgn)
+	vgn.AddAttrList(c.AttrMap)
+	vgn.Attr = append(vgn.Attr, vugu.VGAttribute{Key: "data-vg-island", Val: c.Target})
+
+	var html string
+	if c.Islands != nil {
+		html = c.Islands.HTML(c.Target)
+	}
+	vgn.SetInnerHTML(vugu.HTML(html))
+
+	vgn.DOMEventHandlerSpecList = append(vgn.DOMEventHandlerSpecList, vugu.DOMEventHandlerSpec{
+		EventType: "click",
+		Func:      func(event vugu.DOMEvent) { c.handleEvent(event, false) },
+		Modifiers: vugu.DOMEventModAction,
+	}, vugu.DOMEventHandlerSpec{
+		EventType: "submit",
+		Func:      func(event vugu.DOMEvent) { c.handleEvent(event, true) },
+		Modifiers: vugu.DOMEventModAction | vugu.DOMEventModForm,
+	})
+
+	return vgout
+}
+
+func (c *Island) handleEvent(event vugu.DOMEvent, submit bool) {
+	name, value := vugu.EventAction(event)
+	if name == "" {
+		return
+	}
+	e := ActionEvent{Name: name, Value: value, DOMEvent: event}
+	if submit {
+		// the server's form would otherwise be submitted by the browser
+		event.PreventDefault()
+		e.Form = vugu.FormValues(event)
+	}
+	if c.OnAction != nil {
+		c.OnAction(e)
+	}
+}
@@ -0,0 +1,49 @@
+package vgisland
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu/vgtest"
+)
+
+func TestIsland(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var got []ActionEvent
+	islands := &Islands{}
+	c := &Island{Islands: islands, Target: "cart", OnAction: func(e ActionEvent) {
+		e.DOMEvent = nil
+		got = append(got, e)
+	}}
+
+	r, err := vgtest.New(c)
+	assert.NoError(err)
+	assert.Equal("", r.FindByTag("div")[0].Text())
+
+	r.EventEnv().Lock()
+	assert.NoError(islands.ApplyJSON([]byte(`{"total": 3, "islands": [
+		{"target": "cart", "html": "<p>Tea <button data-vg-action=\"remove\" data-vg-value=\"17\"><b>x</b></button></p><form data-vg-action=\"add\"><input name=\"qty\" value=\"2\"></form>"},
+		{"target": "other", "html": "<p>other</p>"}]}`)))
+	r.EventEnv().UnlockRender()
+	assert.NoError(r.Render())
+
+	div := r.FindByTag("div")[0]
+	v, _ := div.AttrValue("data-vg-island")
+	assert.Equal("cart", v)
+	assert.Equal("<p>other</p>", islands.HTML("other"))
+
+	// from an element inside the one with the action
+	assert.NoError(r.Click(r.FindByTag("b")[0]))
+	// not on an action
+	assert.NoError(r.Click(r.FindByTag("p")[0]))
+	assert.NoError(r.Dispatch(r.FindByTag("form")[0], "submit", nil))
+	assert.Equal([]ActionEvent{
+		{Name: "remove", Value: "17"},
+		{Name: "add", Form: map[string][]string{"qty": {"2"}}},
+	}, got)
+
+	assert.Error(islands.ApplyJSON([]byte("not json")))
+}
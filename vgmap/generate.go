@@ -0,0 +1,3 @@
+package vgmap
+
+//go:generate vugugen
@@ -0,0 +1,260 @@
+package vgmap
+
+import (
+	"html"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+// Map is an interactive map, see the package documentation.
+type Map struct {
+	Center    LatLng
+	Zoom      float64
+	Tiles     TileLayer // OpenStreetMap if URL is empty
+	Markers   []Marker
+	Polylines []Polyline
+
+	Click       ClickHandler       // called when the map is clicked
+	MarkerClick MarkerClickHandler // called when a marker is clicked
+	Move        MoveHandler        // called when the user has moved or zoomed the map
+	Error       ErrorHandler       // called if the map cannot be created
+
+	AttrMap vugu.AttrMap // regular HTML attributes for the element like id and class, give it a height
+
+	eventEnv vugu.EventEnv
+
+	lmap       js.Value
+	center     LatLng  // the view last set or reported
+	zoom       float64 // likewise
+	markers    map[string]item
+	markerObjs map[string]js.Value
+	lines      map[string]item
+	lineObjs   map[string]js.Value
+
+	funcs []js.Func
+}
+
+// Init implements vugu.Initer.
+func (c *Map) Init(ctx vugu.InitCtx) {
+	c.eventEnv = ctx.EventEnv()
+}
+
+// Compute implements vugu.Computer, it applies changes to the fields to the map.
+func (c *Map) Compute(ctx vugu.ComputeCtx) {
+	if !c.lmap.Truthy() {
+		return
+	}
+	if c.Center != c.center || c.Zoom != c.zoom {
+		c.lmap.Call("setView", latLng(c.Center), c.Zoom)
+		c.center, c.zoom = c.Center, c.Zoom
+	}
+	c.updateMarkers()
+	c.updatePolylines()
+}
+
+// Destroy implements vugu.Destroyer, it removes the map.
+func (c *Map) Destroy() {
+	if c.lmap.Truthy() {
+		c.lmap.Call("remove")
+		c.lmap = js.Null()
+	}
+	for _, f := range c.funcs {
+		f.Release()
+	}
+	c.funcs = nil
+	c.markers, c.markerObjs, c.lines, c.lineObjs = nil, nil, nil, nil
+}
+
+func (c *Map) handleCreate(el js.Value) {
+
+	if c.lmap.Truthy() {
+		return
+	}
+	l := js.Global().Get("L")
+	if !l.Truthy() || !el.Truthy() {
+		if c.Error != nil {
+			c.Error.ErrorHandle(ErrorEvent{Err: ErrNotAvailable})
+		}
+		return
+	}
+
+	opts := js.Global().Get("Object").New()
+	opts.Set("center", latLng(c.Center))
+	opts.Set("zoom", c.Zoom)
+	c.lmap = l.Call("map", el, opts)
+	c.center, c.zoom = c.Center, c.Zoom
+
+	tiles := c.Tiles
+	if tiles.URL == "" {
+		tiles = OpenStreetMap
+	}
+	topts := js.Global().Get("Object").New()
+	topts.Set("attribution", tiles.Attribution)
+	if tiles.MaxZoom > 0 {
+		topts.Set("maxZoom", tiles.MaxZoom)
+	}
+	l.Call("tileLayer", tiles.URL, topts).Call("addTo", c.lmap)
+
+	c.lmap.Call("on", "click", c.funcOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) == 0 {
+			return nil
+		}
+		pos := toLatLng(args[0].Get("latlng"))
+		c.locked(func() {
+			if c.Click != nil {
+				c.Click.ClickHandle(ClickEvent{Position: pos})
+			}
+		})
+		return nil
+	}))
+
+	c.lmap.Call("on", "moveend", c.funcOf(func(this js.Value, args []js.Value) interface{} {
+		lmap := c.lmap
+		if !lmap.Truthy() {
+			return nil
+		}
+		b := lmap.Call("getBounds")
+		event := MoveEvent{
+			Center: toLatLng(lmap.Call("getCenter")),
+			Zoom:   lmap.Call("getZoom").Float(),
+			Bounds: Bounds{SouthWest: toLatLng(b.Call("getSouthWest")), NorthEast: toLatLng(b.Call("getNorthEast"))},
+		}
+		c.locked(func() {
+			// moves made by Compute are reported too, these do not change anything
+			c.center, c.zoom = event.Center, event.Zoom
+			c.Center, c.Zoom = event.Center, event.Zoom
+			if c.Move != nil {
+				c.Move.MoveHandle(event)
+			}
+		})
+		return nil
+	}))
+
+	c.markers, c.markerObjs = make(map[string]item), make(map[string]js.Value)
+	c.lines, c.lineObjs = make(map[string]item), make(map[string]js.Value)
+	c.updateMarkers()
+	c.updatePolylines()
+}
+
+// locked calls f with the EventEnv locked from another goroutine, as JS callbacks must not block.
+func (c *Map) locked(f func()) {
+	go func() {
+		c.eventEnv.Lock()
+		if !c.lmap.Truthy() { // destroyed in the meantime
+			c.eventEnv.UnlockOnly()
+			return
+		}
+		f()
+		c.eventEnv.UnlockRender()
+	}()
+}
+
+func (c *Map) funcOf(f func(this js.Value, args []js.Value) interface{}) js.Func {
+	fn := js.FuncOf(f)
+	c.funcs = append(c.funcs, fn)
+	return fn
+}
+
+func (c *Map) updateMarkers() {
+
+	next := make([]item, len(c.Markers))
+	for i, m := range c.Markers {
+		next[i] = m
+	}
+	added, changed, removed := diff(c.markers, next)
+
+	for _, id := range removed {
+		c.markerObjs[id].Call("remove")
+		delete(c.markerObjs, id)
+		delete(c.markers, id)
+	}
+	for _, it := range changed {
+		m, old := it.(Marker), c.markers[it.itemID()].(Marker)
+		if m.Title == old.Title && m.Popup == old.Popup {
+			c.markerObjs[m.ID].Call("setLatLng", latLng(m.Position))
+			c.markers[m.ID] = m
+			continue
+		}
+		// the title cannot be changed, so replace the marker
+		c.markerObjs[m.ID].Call("remove")
+		added = append(added, m)
+	}
+	for _, it := range added {
+		m := it.(Marker)
+		opts := js.Global().Get("Object").New()
+		opts.Set("title", m.Title)
+		obj := js.Global().Get("L").Call("marker", latLng(m.Position), opts)
+		if m.Popup != "" {
+			obj.Call("bindPopup", html.EscapeString(m.Popup))
+		}
+		id := m.ID
+		obj.Call("on", "click", c.funcOf(func(this js.Value, args []js.Value) interface{} {
+			c.locked(func() {
+				if c.MarkerClick != nil {
+					c.MarkerClick.MarkerClickHandle(MarkerClickEvent{ID: id})
+				}
+			})
+			return nil
+		}))
+		obj.Call("addTo", c.lmap)
+		c.markerObjs[m.ID] = obj
+		c.markers[m.ID] = m
+	}
+}
+
+func (c *Map) updatePolylines() {
+
+	next := make([]item, len(c.Polylines))
+	for i, p := range c.Polylines {
+		next[i] = p
+	}
+	added, changed, removed := diff(c.lines, next)
+
+	for _, id := range removed {
+		c.lineObjs[id].Call("remove")
+		delete(c.lineObjs, id)
+		delete(c.lines, id)
+	}
+	for _, it := range changed {
+		p := it.(Polyline)
+		obj := c.lineObjs[p.ID]
+		obj.Call("setLatLngs", latLngs(p.Points))
+		obj.Call("setStyle", polylineStyle(p))
+		c.lines[p.ID] = p
+	}
+	for _, it := range added {
+		p := it.(Polyline)
+		obj := js.Global().Get("L").Call("polyline", latLngs(p.Points), polylineStyle(p))
+		obj.Call("addTo", c.lmap)
+		c.lineObjs[p.ID] = obj
+		c.lines[p.ID] = p
+	}
+}
+
+func polylineStyle(p Polyline) js.Value {
+	opts := js.Global().Get("Object").New()
+	if p.Color != "" {
+		opts.Set("color", p.Color)
+	}
+	if p.Weight > 0 {
+		opts.Set("weight", p.Weight)
+	}
+	return opts
+}
+
+func latLng(p LatLng) js.Value {
+	return js.ValueOf([]interface{}{p.Lat, p.Lng})
+}
+
+func latLngs(points []LatLng) js.Value {
+	l := make([]interface{}, len(points))
+	for i, p := range points {
+		l[i] = []interface{}{p.Lat, p.Lng}
+	}
+	return js.ValueOf(l)
+}
+
+func toLatLng(v js.Value) LatLng {
+	return LatLng{Lat: v.Get("lat").Float(), Lng: v.Get("lng").Float()}
+}
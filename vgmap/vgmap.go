@@ -0,0 +1,184 @@
+/*
+Package vgmap provides a Map component showing an interactive map with Leaflet.
+
+Leaflet's script and CSS must be loaded by the page (so window.L exists) before the Map is rendered.
+Markers and polylines are declared as Go data, and each time the Map is rendered the differences from
+the previous render are applied to the map, so they can be kept in a slice and changed like any other
+component state:
+
+	<vgmap:Map :Center='c.center' :Zoom='13' :Markers='c.markers'
+		style="height:400px"
+		@Click='c.markers = append(c.markers, vgmap.Marker{ID: c.nextID(), Position: event.Position})'
+		@MarkerClick='c.selected = event.ID'
+		@Move='c.center = event.Center'></vgmap:Map>
+
+Markers and polylines are identified by their ID, which must be unique among the items of each kind.
+Center and Zoom move the map when they are changed, and are updated when the user moves it.
+
+Like vgcode.Editor, the map is created in an element Vugu does not render into, from a vg-js-create
+callback, and events from Leaflet are handled with the EventEnv locked.
+*/
+package vgmap
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ErrNotAvailable is returned when Leaflet has not been loaded (or outside of the browser).
+var ErrNotAvailable = errors.New("vgmap: Leaflet is not available in this environment")
+
+// LatLng is a geographical position in degrees.
+type LatLng struct {
+	Lat float64
+	Lng float64
+}
+
+// Bounds is a rectangular area of the map.
+type Bounds struct {
+	SouthWest LatLng
+	NorthEast LatLng
+}
+
+// TileLayer is the source of the map images.
+type TileLayer struct {
+	URL         string // e.g. "https://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png"
+	Attribution string // HTML shown in the corner of the map, as required by most tile providers
+	MaxZoom     int
+}
+
+// OpenStreetMap is the default TileLayer.  See the OpenStreetMap tile usage policy before using it
+// for anything with heavy traffic.
+var OpenStreetMap = TileLayer{
+	URL:         "https://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png",
+	Attribution: `&copy; <a href="https://www.openstreetmap.org/copyright">OpenStreetMap</a> contributors`,
+	MaxZoom:     19,
+}
+
+// Marker is a pin on the map.
+type Marker struct {
+	ID       string
+	Position LatLng
+	Title    string // shown when the pointer is over the marker
+	Popup    string // text shown when the marker is clicked, if not empty
+}
+
+func (m Marker) itemID() string { return m.ID }
+
+// Polyline is a line through a number of points.
+type Polyline struct {
+	ID     string
+	Points []LatLng
+	Color  string // CSS color, Leaflet's default if empty
+	Weight int    // in pixels, Leaflet's default if 0
+}
+
+func (p Polyline) itemID() string { return p.ID }
+
+// item is a Marker or Polyline.
+type item interface {
+	itemID() string
+}
+
+// diff compares next with prev, which holds the items of the previous render by ID, and returns
+// the items which are new, those which changed, and the IDs of those which are gone.
+func diff(prev map[string]item, next []item) (added, changed []item, removed []string) {
+	seen := make(map[string]bool, len(next))
+	for _, it := range next {
+		id := it.itemID()
+		if seen[id] {
+			continue // duplicate ID, the first one wins
+		}
+		seen[id] = true
+		old, ok := prev[id]
+		if !ok {
+			added = append(added, it)
+		} else if !reflect.DeepEqual(old, it) {
+			changed = append(changed, it)
+		}
+	}
+	for id := range prev {
+		if !seen[id] {
+			removed = append(removed, id)
+		}
+	}
+	return
+}
+
+// ClickEvent is emitted by Map when the map is clicked somewhere other than a marker.
+type ClickEvent struct {
+	Position LatLng
+}
+
+// ClickHandler is the interface for things that can handle ClickEvent.
+type ClickHandler interface {
+	ClickHandle(event ClickEvent)
+}
+
+// ClickFunc implements ClickHandler as a function.
+type ClickFunc func(event ClickEvent)
+
+// ClickHandle implements the ClickHandler interface.
+func (f ClickFunc) ClickHandle(event ClickEvent) { f(event) }
+
+// assert ClickFunc implements ClickHandler
+var _ ClickHandler = ClickFunc(nil)
+
+// MarkerClickEvent is emitted by Map when a marker is clicked.
+type MarkerClickEvent struct {
+	ID string
+}
+
+// MarkerClickHandler is the interface for things that can handle MarkerClickEvent.
+type MarkerClickHandler interface {
+	MarkerClickHandle(event MarkerClickEvent)
+}
+
+// MarkerClickFunc implements MarkerClickHandler as a function.
+type MarkerClickFunc func(event MarkerClickEvent)
+
+// MarkerClickHandle implements the MarkerClickHandler interface.
+func (f MarkerClickFunc) MarkerClickHandle(event MarkerClickEvent) { f(event) }
+
+// assert MarkerClickFunc implements MarkerClickHandler
+var _ MarkerClickHandler = MarkerClickFunc(nil)
+
+// MoveEvent is emitted by Map when the user has finished moving or zooming the map.
+type MoveEvent struct {
+	Center LatLng
+	Zoom   float64
+	Bounds Bounds
+}
+
+// MoveHandler is the interface for things that can handle MoveEvent.
+type MoveHandler interface {
+	MoveHandle(event MoveEvent)
+}
+
+// MoveFunc implements MoveHandler as a function.
+type MoveFunc func(event MoveEvent)
+
+// MoveHandle implements the MoveHandler interface.
+func (f MoveFunc) MoveHandle(event MoveEvent) { f(event) }
+
+// assert MoveFunc implements MoveHandler
+var _ MoveHandler = MoveFunc(nil)
+
+// ErrorEvent is emitted by Map when the map cannot be created, e.g. because Leaflet was not loaded.
+type ErrorEvent struct {
+	Err error
+}
+
+// ErrorHandler is the interface for things that can handle ErrorEvent.
+type ErrorHandler interface {
+	ErrorHandle(event ErrorEvent)
+}
+
+// ErrorFunc implements ErrorHandler as a function.
+type ErrorFunc func(event ErrorEvent)
+
+// ErrorHandle implements the ErrorHandler interface.
+func (f ErrorFunc) ErrorHandle(event ErrorEvent) { f(event) }
+
+// assert ErrorFunc implements ErrorHandler
+var _ ErrorHandler = ErrorFunc(nil)
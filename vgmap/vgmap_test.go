@@ -0,0 +1,53 @@
+package vgmap
+
+import (
+	"sort"
+	"testing"
+
+	js "github.com/vugu/vugu/js"
+)
+
+func TestDiff(t *testing.T) {
+
+	prev := map[string]item{
+		"a": Marker{ID: "a", Position: LatLng{1, 2}},
+		"b": Marker{ID: "b", Position: LatLng{3, 4}},
+		"c": Polyline{ID: "c", Points: []LatLng{{1, 1}, {2, 2}}},
+	}
+	next := []item{
+		Marker{ID: "a", Position: LatLng{1, 2}},
+		Marker{ID: "b", Position: LatLng{5, 6}},
+		Marker{ID: "d"},
+		Marker{ID: "d", Title: "duplicate"},
+	}
+
+	added, changed, removed := diff(prev, next)
+	if len(added) != 1 || added[0].itemID() != "d" || added[0].(Marker).Title != "" {
+		t.Errorf("unexpected added %v", added)
+	}
+	if len(changed) != 1 || changed[0].(Marker).Position != (LatLng{5, 6}) {
+		t.Errorf("unexpected changed %v", changed)
+	}
+	sort.Strings(removed)
+	if len(removed) != 1 || removed[0] != "c" {
+		t.Errorf("unexpected removed %v", removed)
+	}
+
+	// slices are compared by content
+	prev = map[string]item{"c": Polyline{ID: "c", Points: []LatLng{{1, 1}, {2, 2}}}}
+	_, changed, _ = diff(prev, []item{Polyline{ID: "c", Points: []LatLng{{1, 1}, {2, 2}}}})
+	if len(changed) != 0 {
+		t.Errorf("unexpected changed %v", changed)
+	}
+}
+
+func TestNotAvailable(t *testing.T) {
+	var err error
+	c := &Map{Error: ErrorFunc(func(event ErrorEvent) { err = event.Err })}
+	c.handleCreate(js.Undefined())
+	if err != ErrNotAvailable {
+		t.Errorf("expected ErrNotAvailable, got %v", err)
+	}
+	c.Compute(nil) // does nothing without a map
+	c.Destroy()
+}
@@ -0,0 +1,196 @@
+/*
+Package vgmsg delivers messages from window.postMessage and BroadcastChannel to Go handlers,
+for coordinating between tabs of the same application and with a parent window when running
+embedded in an iframe.
+
+Handlers are called with the EventEnv write lock held and a render is requested when they return,
+the same as for DOM event handlers.  Start listening in Init and stop in Destroy, for example:
+
+	func (c *Root) Init(ctx vugu.InitCtx) {
+		c.ch, _ = vgmsg.NewBroadcastChannel(ctx.EventEnv(), "myapp", c.handleMessage)
+	}
+
+	func (c *Root) Destroy() {
+		c.ch.Close()
+	}
+
+Message data is converted to and from Go values by way of JSON.  Outside of the browser
+(e.g. when rendering server-side) listeners do nothing and posting returns ErrNotAvailable.
+*/
+package vgmsg
+
+import (
+	"errors"
+
+	"github.com/vugu/vjson"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+// ErrNotAvailable is returned when the browser API needed is not present.
+var ErrNotAvailable = errors.New("vgmsg: not available in this environment")
+
+// Message is a message received by a WindowListener or BroadcastChannel.
+type Message struct {
+	Data   js.Value // the message data as received
+	Origin string   // origin of the sender, empty for BroadcastChannel messages
+	Source js.Value // the window that sent the message, undefined for BroadcastChannel messages
+}
+
+// Decode unmarshals the message data, which must be JSON compatible, into v.
+func (m Message) Decode(v interface{}) error {
+	if m.Data.IsUndefined() {
+		return errors.New("vgmsg: message has no data")
+	}
+	s := js.Global().Get("JSON").Call("stringify", m.Data).String()
+	return vjson.Unmarshal([]byte(s), v)
+}
+
+// WindowListener receives messages posted to the current window, e.g. by a parent window or an iframe.
+type WindowListener struct {
+	fn js.Func
+}
+
+// ListenWindow starts calling handler with messages posted to the current window.  Messages whose origin
+// is not in allowedOrigins are ignored.  Origins are compared exactly and look like "https://example.com".
+// An entry of "*" accepts all origins, in which case the handler must not trust the message contents.
+// If eventEnv is nil the handler is called without locking or rendering.
+func ListenWindow(eventEnv vugu.EventEnv, allowedOrigins []string, handler func(Message)) *WindowListener {
+
+	l := &WindowListener{}
+
+	window := js.Global().Get("window")
+	if !window.Truthy() {
+		return l
+	}
+
+	l.fn = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		ev := args[0]
+		origin := ev.Get("origin").String()
+		if !originAllowed(allowedOrigins, origin) {
+			return nil
+		}
+		deliver(eventEnv, handler, Message{
+			Data:   ev.Get("data"),
+			Origin: origin,
+			Source: ev.Get("source"),
+		})
+		return nil
+	})
+	window.Call("addEventListener", "message", l.fn)
+
+	return l
+}
+
+// Close stops the listener.  It is safe to call more than once.
+func (l *WindowListener) Close() {
+	if l.fn.IsUndefined() {
+		return
+	}
+	js.Global().Get("window").Call("removeEventListener", "message", l.fn)
+	l.fn.Release()
+	l.fn = js.Func{}
+}
+
+// PostMessage sends v to the target window (e.g. from window.parent or an iframe's contentWindow).
+// targetOrigin is the origin the target window must have for the message to be delivered,
+// it is required so messages are not sent to an unexpected page by accident.
+func PostMessage(target js.Value, v interface{}, targetOrigin string) error {
+	if targetOrigin == "" {
+		return errors.New("vgmsg: targetOrigin is required")
+	}
+	if !target.Truthy() {
+		return ErrNotAvailable
+	}
+	data, err := toJS(v)
+	if err != nil {
+		return err
+	}
+	target.Call("postMessage", data, targetOrigin)
+	return nil
+}
+
+// PostToParent sends v to the parent window, for an application embedded in an iframe.
+func PostToParent(v interface{}, targetOrigin string) error {
+	return PostMessage(js.Global().Get("window").Get("parent"), v, targetOrigin)
+}
+
+// BroadcastChannel sends and receives messages between windows, tabs and iframes of the same origin.
+type BroadcastChannel struct {
+	ch js.Value
+	fn js.Func
+}
+
+// NewBroadcastChannel joins the channel with the specified name and calls handler with messages
+// posted to it by other windows.  Only same origin pages can join a channel, so no origin check is needed.
+// If eventEnv is nil the handler is called without locking or rendering.
+func NewBroadcastChannel(eventEnv vugu.EventEnv, name string, handler func(Message)) (*BroadcastChannel, error) {
+
+	bcClass := js.Global().Get("BroadcastChannel")
+	if !bcClass.Truthy() {
+		return &BroadcastChannel{}, ErrNotAvailable
+	}
+
+	c := &BroadcastChannel{ch: bcClass.New(name)}
+	c.fn = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		deliver(eventEnv, handler, Message{Data: args[0].Get("data")})
+		return nil
+	})
+	c.ch.Call("addEventListener", "message", c.fn)
+
+	return c, nil
+}
+
+// Post sends v to the other members of the channel (not including c).
+func (c *BroadcastChannel) Post(v interface{}) error {
+	if !c.ch.Truthy() {
+		return ErrNotAvailable
+	}
+	data, err := toJS(v)
+	if err != nil {
+		return err
+	}
+	c.ch.Call("postMessage", data)
+	return nil
+}
+
+// Close leaves the channel.  It is safe to call more than once.
+func (c *BroadcastChannel) Close() {
+	if !c.ch.Truthy() {
+		return
+	}
+	c.ch.Call("removeEventListener", "message", c.fn)
+	c.ch.Call("close")
+	c.fn.Release()
+	c.ch = js.Undefined()
+}
+
+// deliver calls handler with the lock held, and requests a render afterward.
+func deliver(eventEnv vugu.EventEnv, handler func(Message), m Message) {
+	if eventEnv == nil {
+		handler(m)
+		return
+	}
+	eventEnv.Lock()
+	defer eventEnv.UnlockRender()
+	handler(m)
+}
+
+// toJS converts v to a JS value by way of JSON.
+func toJS(v interface{}) (js.Value, error) {
+	b, err := vjson.Marshal(v)
+	if err != nil {
+		return js.Undefined(), err
+	}
+	return js.Global().Get("JSON").Call("parse", string(b)), nil
+}
+
+func originAllowed(allowedOrigins []string, origin string) bool {
+	for _, o := range allowedOrigins {
+		if o == "*" || o == origin {
+			return true
+		}
+	}
+	return false
+}
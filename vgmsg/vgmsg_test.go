@@ -0,0 +1,43 @@
+package vgmsg
+
+import "testing"
+
+func TestOriginAllowed(t *testing.T) {
+	tests := []struct {
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{nil, "https://example.com", false},
+		{[]string{"https://example.com"}, "https://example.com", true},
+		{[]string{"https://example.com"}, "https://example.com.evil.test", false},
+		{[]string{"https://example.com"}, "http://example.com", false},
+		{[]string{"https://a.test", "https://b.test"}, "https://b.test", true},
+		{[]string{"*"}, "null", true},
+	}
+	for _, tc := range tests {
+		if got := originAllowed(tc.allowed, tc.origin); got != tc.want {
+			t.Errorf("originAllowed(%q, %q) = %v, want %v", tc.allowed, tc.origin, got, tc.want)
+		}
+	}
+}
+
+func TestNotAvailable(t *testing.T) {
+
+	// outside the browser listeners do nothing and posting fails
+	l := ListenWindow(nil, []string{"*"}, func(Message) { t.Errorf("unexpected message") })
+	l.Close()
+
+	if err := PostToParent("hello", "https://example.com"); err != ErrNotAvailable {
+		t.Errorf("unexpected error from PostToParent: %v", err)
+	}
+
+	c, err := NewBroadcastChannel(nil, "test", func(Message) {})
+	if err != ErrNotAvailable {
+		t.Errorf("unexpected error from NewBroadcastChannel: %v", err)
+	}
+	if err := c.Post("hello"); err != ErrNotAvailable {
+		t.Errorf("unexpected error from Post: %v", err)
+	}
+	c.Close()
+}
@@ -0,0 +1,130 @@
+package vugu
+
+import (
+	"encoding/binary"
+
+	"github.com/vugu/xxhash"
+)
+
+// ForgetNodeHashes drops the results cached by NodeHash, so they are computed again from the nodes
+// as they are now, e.g. to check they have not been modified since.
+func (r *BuildResults) ForgetNodeHashes() {
+	r.nodeHashes = nil
+}
+
+// NodeHash returns a hash of the content of n and everything underneath it, descending into
+// the output of any components.  The hash covers the type, data, namespace, attributes,
+// JS properties, inner HTML and event listener specs of each node (but not the handler
+// functions themselves, which are usually new closures each build).  Two nodes with the same
+// hash can be assumed to produce the same DOM, which renderers can use to skip unchanged subtrees.
+// Results are cached for the lifetime of this BuildResults.
+func (r *BuildResults) NodeHash(n *VGNode) uint64 {
+	if n == nil {
+		return 0
+	}
+	if h, ok := r.nodeHashes[n]; ok {
+		return h
+	}
+	if r.nodeHashes == nil {
+		r.nodeHashes = make(map[*VGNode]uint64, 64)
+	}
+
+	d := xxhash.New()
+	var b [8]byte
+	writeUint64 := func(v uint64) {
+		binary.BigEndian.PutUint64(b[:], v)
+		d.Write(b[:])
+	}
+	writeString := func(s string) {
+		writeUint64(uint64(len(s)))
+		d.Write([]byte(s))
+	}
+
+	writeString(n.Portal)
+	writeString(n.Key)
+
+	if n.Component != nil {
+		// component output is hashed in place of the node itself
+		writeUint64(1)
+		if cbo := r.ResultFor(n.Component); cbo != nil {
+			for _, cn := range cbo.Out {
+				writeUint64(r.NodeHash(cn))
+			}
+		}
+	} else {
+		writeUint64(uint64(n.Type))
+		writeString(n.Data)
+		writeString(n.Namespace)
+
+		writeUint64(uint64(len(n.Attr)))
+		for _, a := range n.Attr {
+			writeString(a.Namespace)
+			writeString(a.Key)
+			writeString(a.Val)
+			writeUint64(uint64(a.Type))
+		}
+
+		writeUint64(uint64(len(n.Prop)))
+		for _, p := range n.Prop {
+			writeString(p.Key)
+			writeString(string(p.JSONVal))
+		}
+
+		if n.InnerHTML != nil {
+			writeUint64(1)
+			writeString(*n.InnerHTML)
+		} else {
+			writeUint64(0)
+		}
+
+		writeUint64(uint64(len(n.DOMEventHandlerSpecList)))
+		for _, hs := range n.DOMEventHandlerSpecList {
+			writeString(hs.EventType)
+			var flags uint64
+			if hs.Capture {
+				flags |= 1
+			}
+			if hs.Passive {
+				flags |= 2
+			}
+			writeUint64(flags)
+			writeUint64(uint64(hs.Modifiers))
+			writeString(hs.Global)
+		}
+
+		var jsFlags uint64
+		if n.JSCreateHandler != nil {
+			jsFlags |= 1
+		}
+		if n.JSPopulateHandler != nil {
+			jsFlags |= 2
+		}
+		writeUint64(jsFlags)
+
+		if n.Hidden {
+			writeUint64(1)
+		} else {
+			writeUint64(0)
+		}
+
+		writeString(n.ScrollKey)
+		var triggers uint64
+		if n.Focus {
+			triggers |= 1
+		}
+		if n.ScrollIntoView {
+			triggers |= 2
+		}
+		writeUint64(triggers)
+		writeString(n.ClassMap.String())
+		writeString(n.StyleMap.String())
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeUint64(r.NodeHash(c))
+		}
+	}
+
+	h := d.Sum64()
+	r.nodeHashes[n] = h
+	return h
+}
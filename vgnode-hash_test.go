@@ -0,0 +1,45 @@
+package vugu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildResultsNodeHash(t *testing.T) {
+
+	assert := assert.New(t)
+
+	mk := func(text string) *VGNode {
+		n := &VGNode{Type: ElementNode, Data: "div", Attr: []VGAttribute{{Key: "class", Val: "a"}}}
+		n.AppendChild(&VGNode{Type: TextNode, Data: text})
+		return n
+	}
+
+	var br BuildResults
+	n1, n2, n3 := mk("one"), mk("one"), mk("two")
+	assert.Equal(br.NodeHash(n1), br.NodeHash(n2))
+	assert.NotEqual(br.NodeHash(n1), br.NodeHash(n3))
+
+	// event types are part of the hash but the handler funcs are not
+	n4, n5 := mk("one"), mk("one")
+	n4.DOMEventHandlerSpecList = append(n4.DOMEventHandlerSpecList, DOMEventHandlerSpec{EventType: "click", Func: func(DOMEvent) {}})
+	n5.DOMEventHandlerSpecList = append(n5.DOMEventHandlerSpecList, DOMEventHandlerSpec{EventType: "click", Func: func(DOMEvent) {}})
+	assert.Equal(br.NodeHash(n4), br.NodeHash(n5))
+	assert.NotEqual(br.NodeHash(n1), br.NodeHash(n4))
+
+	// components hash as their output
+	comp := &rootb1{}
+	br.allOut = map[buildCacheKey]*BuildOut{makeBuildCacheKey(comp): {Out: []*VGNode{mk("three")}}}
+	c1, c2 := &VGNode{Component: comp}, &VGNode{Component: comp}
+	assert.Equal(br.NodeHash(c1), br.NodeHash(c2))
+	assert.NotEqual(br.NodeHash(c1), br.NodeHash(n1))
+
+	// rendering into a portal changes the DOM
+	n6 := mk("one")
+	n6.Portal = "#modals"
+	assert.NotEqual(br.NodeHash(n1), br.NodeHash(n6))
+	c3 := &VGNode{Component: comp, Portal: "#modals"}
+	assert.NotEqual(br.NodeHash(c1), br.NodeHash(c3))
+
+}
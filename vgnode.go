@@ -5,6 +5,7 @@ import (
 	"html"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/vugu/vugu/js"
 )
@@ -31,6 +32,37 @@ const (
 // VGAttribute is the attribute on an HTML tag.
 type VGAttribute struct {
 	Namespace, Key, Val string
+	Type                VGAttrType
+}
+
+// VGAttrType tells the renderers how to set an attribute.
+type VGAttrType uint8
+
+const (
+	VGAttrString VGAttrType = iota // set to Val, except for the boolean attributes of HTML (see IsBool)
+	VGAttrBool                     // present or absent, see Present
+)
+
+// boolAttrs are the boolean attributes of HTML elements.
+var boolAttrs = map[string]bool{
+	"allowfullscreen": true, "async": true, "autofocus": true, "autoplay": true, "checked": true,
+	"controls": true, "default": true, "defer": true, "disabled": true, "formnovalidate": true,
+	"hidden": true, "inert": true, "ismap": true, "itemscope": true, "loop": true, "multiple": true,
+	"muted": true, "nomodule": true, "novalidate": true, "open": true, "playsinline": true,
+	"readonly": true, "required": true, "reversed": true, "selected": true,
+}
+
+// IsBool returns true if a is a boolean attribute, which is either present or absent rather than having a
+// value: its Type is VGAttrBool or it is one of those of HTML, such as disabled, checked, readonly or hidden.
+func (a VGAttribute) IsBool() bool {
+	return a.Type == VGAttrBool || (a.Namespace == "" && boolAttrs[a.Key])
+}
+
+// Present returns false for a boolean attribute with the value "false", e.g. disabled="false" or
+// :disabled='fmt.Sprint(c.Busy)', which the renderers leave off the element, as the browser would treat it
+// as disabled otherwise.  Other attributes are always present.
+func (a VGAttribute) Present() bool {
+	return !a.IsBool() || !strings.EqualFold(a.Val, "false")
 }
 
 // VGProperty is a JS property to be set on a DOM element.
@@ -53,7 +85,12 @@ type VGProperty struct {
 // and attributes, events, etc. ignored.
 //
 // Prop contains JavaScript property values to be assigned during render. InnerHTML provides alternate
-// HTML content instead of children.  DOMEventHandlerSpecList specifies DOM handlers to register.
+// HTML content instead of children.  Hidden (set by vg-show) keeps the element in the DOM but with display:none.
+// Portal (set by vg-portal) renders the node, which may also be a component or template, into the element
+// matching a CSS selector instead of in place (staticrender outputs it in place).
+// DOMEventHandlerSpecList specifies DOM handlers to register.  There can be several for the same event, e.g. one
+// from the template and one added by a wrapping component, those with the same options (Capture, Passive,
+// Modifiers and Global) share one listener and are called in the order they are in the list.
 // And the JS...Handler fields are used to register callbacks to obtain information at JS render-time.
 //
 // TODO: This and its related parts should probably move into a sub-package (vgnode?) and
@@ -74,6 +111,22 @@ type VGNode struct {
 
 	InnerHTML *string // indicates that children should be ignored and this raw HTML is the children of this tag; nil means not set, empty string means explicitly set to empty string
 
+	InnerHTMLStatic bool // InnerHTML is the same every render (set from a StaticHTML), so it is only set when the element is created
+
+	Hidden bool // element is rendered with display:none, instead of being removed like with vg-if
+
+	Portal string // CSS selector of the element this node is rendered into instead of in place (vg-portal)
+
+	ScrollKey string // the element's scroll position is kept under this key and restored if it is recreated (vg-scroll-key)
+
+	Key string // identifies the node among its siblings from one render to the next (vg-key), so it can be moved when they are reordered (see domrender.KeyedDiff)
+
+	Focus          bool // the element is focused after the render in which this becomes true (vg-focus)
+	ScrollIntoView bool // the element is scrolled into view after the render in which this becomes true (vg-scroll-into-view)
+
+	ClassMap ClassMap // classes applied in addition to the class attribute (:class with a ClassMap)
+	StyleMap StyleMap // inline style properties applied over the style attribute (:style with a StyleMap)
+
 	DOMEventHandlerSpecList []DOMEventHandlerSpec // describes invocations when DOM events happen
 
 	// indicates this node's output should be delegated to the specified component
@@ -85,6 +138,11 @@ type VGNode struct {
 	JSPopulateHandler JSValueHandler
 }
 
+// SetKey sets Key to the value of a vg-key expression.
+func (n *VGNode) SetKey(k interface{}) {
+	n.Key = fmt.Sprint(k)
+}
+
 // IsComponent returns true if this is a component (Component != nil).
 // Components have rendering delegated to them instead of processing this node.
 func (n *VGNode) IsComponent() bool {
@@ -93,6 +151,7 @@ func (n *VGNode) IsComponent() bool {
 
 // IsTemplate returns true if this is a template (Type is ElementNode and Data is an empty string and not a Component).
 // Templates have their children flattened into the output DOM instead of being processed directly.
+// A component with more than one root node outputs them as the children of a template.
 func (n *VGNode) IsTemplate() bool {
 	if n.Type == ElementNode && n.Data == "" && n.Component == nil {
 		return true
@@ -205,7 +264,8 @@ func (n *VGNode) Walk(f func(*VGNode) error) error {
 // AddAttrInterface sets an attribute based on the given interface. The followings types are supported
 // - string - value is used as attr value as it is
 // - int,float,... - the value is converted to string with strconv and used as attr value
-// - bool - treat the attribute as a flag. If false, the attribute will be ignored, if true outputs the attribute without a value
+// - bool - treat the attribute as a flag (VGAttrBool). If false, the attribute will be ignored, if true outputs the attribute without a value
+// - ClassMap, StyleMap - for "class" and "style" respectively, merged into the ClassMap or StyleMap field instead of being added as an attribute, for other keys the attribute value is its String()
 // - fmt.Stringer - if the value implements fmt.Stringer, the returned string of StringVar() is used
 // - ptr - If the ptr is nil, the attribute will be ignored. Else, the rules above apply
 // any other type is handled via fmt.Sprintf()
@@ -246,13 +306,35 @@ func (n *VGNode) AddAttrInterface(key string, val interface{}) {
 		nattr.Val = strconv.FormatFloat(float64(v), 'f', 6, 32)
 	case float64:
 		nattr.Val = strconv.FormatFloat(v, 'f', 6, 64)
+	case ClassMap:
+		if key != "class" {
+			nattr.Val = v.String()
+			break
+		}
+		if n.ClassMap == nil {
+			n.ClassMap = make(ClassMap, len(v))
+		}
+		for k, on := range v {
+			n.ClassMap[k] = on
+		}
+		return
+	case StyleMap:
+		if key != "style" {
+			nattr.Val = v.String()
+			break
+		}
+		if n.StyleMap == nil {
+			n.StyleMap = make(StyleMap, len(v))
+		}
+		for k, sv := range v {
+			n.StyleMap[k] = sv
+		}
+		return
 	case bool:
 		if !v {
 			return
 		}
-		// FIXME: according to the HTML spec this is valid for flags, but not pretty
-		// To change this, we have to adopt the changes inside the domrender and staticrender too.
-		nattr.Val = key
+		nattr.Type = VGAttrBool
 	case fmt.Stringer:
 		// we have to check that the given interface does not hide a nil ptr
 		if p := reflect.ValueOf(val); p.Kind() == reflect.Ptr && p.IsNil() {
@@ -289,7 +371,7 @@ func (n *VGNode) AddAttrList(lister VGAttributeLister) {
 // are followed and the same rules applied.  Values implementing HTMLer will have their
 // HTML() method called and the result put into InnerHTML without escaping.
 // Values implementing fmt.Stringer have thier String() method called and the escaped result
-// used as in string above.
+// used as in string above.  A StaticHTML is used as-is and also sets InnerHTMLStatic.
 //
 // All other values have undefined behavior but are currently handled by setting InnerHTML
 // to the result of: `html.EscapeString(fmt.Sprintf("%v", val))`
@@ -297,12 +379,16 @@ func (n *VGNode) SetInnerHTML(val interface{}) {
 
 	var s string
 
+	n.InnerHTMLStatic = false
 	if val == nil {
 		n.InnerHTML = nil
 		return
 	}
 
 	switch v := val.(type) {
+	case StaticHTML:
+		s = string(v)
+		n.InnerHTMLStatic = true
 	case string:
 		s = html.EscapeString(v)
 	case int:
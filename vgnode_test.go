@@ -9,6 +9,35 @@ import (
 	// atom "golang.org/x/net/html/atom"
 )
 
+func TestVGAttributeBool(t *testing.T) {
+
+	tcList := []struct {
+		attr    VGAttribute
+		isBool  bool
+		present bool
+	}{
+		{VGAttribute{Key: "disabled"}, true, true},
+		{VGAttribute{Key: "disabled", Val: "false"}, true, false},
+		{VGAttribute{Key: "readonly", Val: "FALSE"}, true, false},
+		{VGAttribute{Key: "hidden", Val: "hidden"}, true, true},
+		{VGAttribute{Key: "value", Val: "false"}, false, true},
+		{VGAttribute{Key: "data-open", Val: "false", Type: VGAttrBool}, true, false},
+		{VGAttribute{Namespace: "xlink", Key: "checked", Val: "false"}, false, true},
+	}
+	for _, tc := range tcList {
+		if tc.attr.IsBool() != tc.isBool || tc.attr.Present() != tc.present {
+			t.Errorf("%+v: IsBool() = %v, Present() = %v", tc.attr, tc.attr.IsBool(), tc.attr.Present())
+		}
+	}
+
+	var n VGNode
+	n.AddAttrInterface("open", true)
+	n.AddAttrInterface("closed", false)
+	if len(n.Attr) != 1 || n.Attr[0] != (VGAttribute{Key: "open", Type: VGAttrBool}) {
+		t.Errorf("unexpected attributes %+v", n.Attr)
+	}
+}
+
 //go:noinline
 func allocVGNode() *VGNode {
 	var ret VGNode
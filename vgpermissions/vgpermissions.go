@@ -0,0 +1,163 @@
+/*
+Package vgpermissions wraps navigator.permissions, so components can find out whether the user has
+granted permission for things like the camera, notifications or geolocation and render appropriate
+UI before invoking those APIs.
+
+Watch is usually what you want, it reports the current state and any changes to it (e.g. when the user
+changes the site settings) and can be called from Init:
+
+	func (c *Root) Init(ctx vugu.InitCtx) {
+		c.geo = vgpermissions.Watch(ctx.EventEnv(), "geolocation", func(s vgpermissions.State) {
+			c.geoState = s
+		})
+	}
+
+	func (c *Root) Destroy() {
+		c.geo.Close()
+	}
+*/
+package vgpermissions
+
+import (
+	"errors"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+// ErrNotAvailable is returned when the browser does not support the Permissions API (or outside of the browser).
+var ErrNotAvailable = errors.New("vgpermissions: Permissions API is not available in this environment")
+
+// State is the state of a permission.
+type State string
+
+// Available States.
+const (
+	Unknown State = ""        // the permission name is not supported by this browser, or the query has not completed
+	Granted State = "granted" // the API can be used without prompting
+	Denied  State = "denied"  // the API will fail without prompting
+	Prompt  State = "prompt"  // using the API will ask the user
+)
+
+// Query returns the state of the permission with the specified name, e.g. "camera", "notifications",
+// "geolocation".  It waits for the browser's answer so must not be called from an event handler
+// or lifecycle callback directly, use it in a goroutine or use Watch instead.
+func Query(name string) (State, error) {
+
+	perms := permissions()
+	if !perms.Truthy() {
+		return Unknown, ErrNotAvailable
+	}
+
+	type result struct {
+		s   State
+		err error
+	}
+	ch := make(chan result, 1)
+
+	then := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		ch <- result{s: State(args[0].Get("state").String())}
+		return nil
+	})
+	defer then.Release()
+	catch := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		ch <- result{err: errors.New("vgpermissions: " + args[0].Call("toString").String())}
+		return nil
+	})
+	defer catch.Release()
+
+	query(perms, name).Call("then", then, catch)
+	r := <-ch
+	return r.s, r.err
+}
+
+// Watcher reports the state of a permission as it changes.
+type Watcher struct {
+	state  State
+	status js.Value
+	fn     js.Func
+	closed bool
+}
+
+// Watch queries the permission with the specified name and calls handler with its state once known,
+// and again each time it changes.  The handler is called with the EventEnv write lock held and a render
+// is requested when it returns, the same as for DOM event handlers.  If eventEnv is nil the handler is called
+// without locking or rendering.  If the permission name is not supported the handler is called with Unknown.
+// Outside of the browser Watch does nothing.
+func Watch(eventEnv vugu.EventEnv, name string, handler func(State)) *Watcher {
+
+	w := &Watcher{}
+
+	perms := permissions()
+	if !perms.Truthy() {
+		return w
+	}
+
+	update := func(s State) {
+		if eventEnv != nil {
+			eventEnv.Lock()
+			defer eventEnv.UnlockRender()
+		}
+		if w.closed {
+			return
+		}
+		w.state = s
+		handler(s)
+	}
+
+	var then js.Func
+	then = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer then.Release()
+		if len(args) == 0 || !args[0].Truthy() {
+			update(Unknown)
+			return nil
+		}
+		if w.closed {
+			return nil
+		}
+		w.status = args[0]
+		w.fn = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			update(State(w.status.Get("state").String()))
+			return nil
+		})
+		w.status.Call("addEventListener", "change", w.fn)
+		update(State(w.status.Get("state").String()))
+		return nil
+	})
+
+	// a rejected query (unsupported name) resolves to undefined and is handled above
+	query(perms, name).Call("catch", js.Global().Get("Function").New("")).Call("then", then)
+
+	return w
+}
+
+// State returns the most recent state reported to the handler.
+func (w *Watcher) State() State {
+	return w.state
+}
+
+// Close stops watching.  It is safe to call more than once.
+func (w *Watcher) Close() {
+	if w.closed {
+		return
+	}
+	w.closed = true
+	if w.status.Truthy() {
+		w.status.Call("removeEventListener", "change", w.fn)
+		w.fn.Release()
+	}
+}
+
+func permissions() js.Value {
+	nav := js.Global().Get("navigator")
+	if !nav.Truthy() {
+		return js.Undefined()
+	}
+	return nav.Get("permissions")
+}
+
+func query(perms js.Value, name string) js.Value {
+	desc := js.Global().Get("Object").New()
+	desc.Set("name", name)
+	return perms.Call("query", desc)
+}
@@ -0,0 +1,17 @@
+package vgpermissions
+
+import "testing"
+
+func TestNotAvailable(t *testing.T) {
+
+	if _, err := Query("camera"); err != ErrNotAvailable {
+		t.Errorf("unexpected error from Query: %v", err)
+	}
+
+	w := Watch(nil, "camera", func(State) { t.Errorf("unexpected handler call") })
+	if w.State() != Unknown {
+		t.Errorf("unexpected State %q", w.State())
+	}
+	w.Close()
+	w.Close()
+}
@@ -0,0 +1,118 @@
+package vgprint
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	js "github.com/vugu/vugu/js"
+	"github.com/vugu/vugu/vgfile"
+)
+
+// BrowserPrint is a Backend which opens the browser's print dialog for the document, from which
+// the user can print it or save it as a PDF.  The document is loaded in a hidden iframe, so the
+// rest of the page is not printed and nothing else needs print CSS.
+type BrowserPrint struct{}
+
+// Print implements Backend.  It returns once the document is loading, the dialog shows after that.
+func (BrowserPrint) Print(doc *Document) error {
+
+	document := js.Global().Get("document")
+	if !document.Truthy() {
+		return ErrNotAvailable
+	}
+
+	iframe := document.Call("createElement", "iframe")
+	style := iframe.Get("style")
+	style.Set("position", "fixed")
+	style.Set("width", "0")
+	style.Set("height", "0")
+	style.Set("border", "0")
+	style.Set("visibility", "hidden")
+
+	var onload, onafterprint js.Func
+	onafterprint = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		iframe.Call("remove")
+		onafterprint.Release()
+		return nil
+	})
+	onload = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		onload.Release()
+		w := iframe.Get("contentWindow")
+		w.Call("addEventListener", "afterprint", onafterprint)
+		w.Call("focus")
+		w.Call("print")
+		return nil
+	})
+	iframe.Set("onload", onload)
+	iframe.Set("srcdoc", string(doc.HTML))
+	document.Get("body").Call("appendChild", iframe)
+
+	return nil
+}
+
+// HTTPBackend is a Backend which posts the document HTML to a server endpoint that returns the PDF
+// (e.g. by loading it in a headless browser), and downloads the result.  The request has
+// Content-Type text/html and, if the document has a title, an X-Document-Title header.
+// Print waits for the response so must be called in a goroutine in the browser.
+type HTTPBackend struct {
+	URL      string       // endpoint to post to
+	Filename string       // name of the downloaded file, defaults to the document title plus ".pdf"
+	Client   *http.Client // defaults to http.DefaultClient
+	Header   http.Header  // additional request headers, e.g. for authentication
+}
+
+// Print implements Backend.
+func (b HTTPBackend) Print(doc *Document) error {
+	pdf, err := b.PDF(doc)
+	if err != nil {
+		return err
+	}
+	name := b.Filename
+	if name == "" {
+		name = doc.Title
+		if name == "" {
+			name = "document"
+		}
+		name += ".pdf"
+	}
+	return vgfile.Download(name, "application/pdf", pdf)
+}
+
+// PDF posts doc to the endpoint and returns the PDF, e.g. to upload or attach it elsewhere.
+func (b HTTPBackend) PDF(doc *Document) ([]byte, error) {
+
+	req, err := http.NewRequest("POST", b.URL, bytes.NewReader(doc.HTML))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range b.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "text/html; charset=utf-8")
+	req.Header.Set("Accept", "application/pdf")
+	if doc.Title != "" {
+		req.Header.Set("X-Document-Title", doc.Title)
+	}
+
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vgprint: %s returned %s: %s", b.URL, res.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,167 @@
+/*
+Package vgprint renders components as printable documents, for invoices, reports and the like,
+and hands them to a Backend to be turned into a PDF.
+
+The component is rendered with the static renderer into a standalone HTML document, with the CSS
+of the components it uses and print CSS for the page size and margins.  Two backends are provided:
+BrowserPrint opens the browser's print dialog (where the user can choose to save as PDF), and HTTPBackend
+posts the HTML to a server endpoint which returns the PDF (e.g. using a headless browser) and downloads it.
+
+	func (c *OrderPage) HandlePrint(event vugu.DOMEvent) {
+		opts := vgprint.Options{Title: "Invoice " + c.order.Number, PageSize: "A4", Margin: "15mm"}
+		err := vgprint.Print(&Invoice{Order: c.order}, opts, vgprint.BrowserPrint{})
+		...
+	}
+
+The component is built in a BuildEnv of its own, so pass a new instance rather than one the
+application is already rendering.
+*/
+package vgprint
+
+import (
+	"bytes"
+	"errors"
+	"html"
+	"strings"
+
+	"github.com/vugu/vugu"
+	"github.com/vugu/vugu/staticrender"
+)
+
+// ErrNotAvailable is returned by backends that cannot be used in this environment.
+var ErrNotAvailable = errors.New("vgprint: not available in this environment")
+
+// Options control how a Document is rendered.
+type Options struct {
+	Title    string             // document title, browsers also use it as the default file name when saving a PDF
+	PageSize string             // CSS @page size, e.g. "A4", "letter" or "A4 landscape", the printer default if empty
+	Margin   string             // CSS @page margin, e.g. "15mm", the printer default if empty
+	CSS      string             // additional CSS, e.g. fonts or page breaks
+	BaseURL  string             // base URL for relative links and images, needed when the HTML is rendered elsewhere (e.g. by a server)
+	Wire     func(vugu.Builder) // called on each component created, like BuildEnv.SetWireFunc
+}
+
+// Document is a rendered standalone HTML document.
+type Document struct {
+	Title string
+	HTML  []byte
+}
+
+// Backend turns a Document into a PDF.
+type Backend interface {
+	Print(doc *Document) error
+}
+
+// BackendFunc implements Backend as a function.
+type BackendFunc func(doc *Document) error
+
+// Print implements Backend.
+func (f BackendFunc) Print(doc *Document) error { return f(doc) }
+
+// Print renders c and passes the result to backend.
+func Print(c vugu.Builder, opts Options, backend Backend) error {
+	doc, err := Render(c, opts)
+	if err != nil {
+		return err
+	}
+	return backend.Print(doc)
+}
+
+// Render builds c and renders it as a standalone HTML document.
+func Render(c vugu.Builder, opts Options) (*Document, error) {
+
+	sr := staticrender.New(nil)
+	buildEnv, err := vugu.NewBuildEnv(sr.EventEnv())
+	if err != nil {
+		return nil, err
+	}
+	if opts.Wire != nil {
+		buildEnv.SetWireFunc(opts.Wire)
+		opts.Wire(c)
+	}
+	br := buildEnv.RunBuild(c)
+
+	var body bytes.Buffer
+	sr.SetWriter(&body)
+	if err := sr.Render(br); err != nil {
+		return nil, err
+	}
+
+	// the head content we add: base, title, print CSS and the CSS of each component
+	var head strings.Builder
+	if opts.BaseURL != "" {
+		head.WriteString(`<base href="` + html.EscapeString(opts.BaseURL) + `">`)
+	}
+	if opts.Title != "" {
+		head.WriteString("<title>" + html.EscapeString(opts.Title) + "</title>")
+	}
+	head.WriteString("<style>" + pageCSS(opts) + "</style>")
+	writeCSS(&head, br, br.Out, make(map[string]bool))
+
+	var out bytes.Buffer
+	out.WriteString("<!doctype html>\n")
+	if s := body.String(); strings.HasPrefix(s, "<html") {
+		// a full document already, put ours at the end of its head
+		// (staticrender adds the root component's CSS there already, the duplicate is harmless)
+		if i := strings.Index(s, "</head>"); i >= 0 {
+			out.WriteString(s[:i] + head.String() + s[i:])
+		} else {
+			out.WriteString(s)
+		}
+	} else {
+		out.WriteString(`<html><head><meta charset="utf-8">`)
+		out.WriteString(head.String())
+		out.WriteString("</head><body>")
+		out.WriteString(s)
+		out.WriteString("</body></html>")
+	}
+
+	return &Document{Title: opts.Title, HTML: out.Bytes()}, nil
+}
+
+// pageCSS returns the @page rule for opts plus opts.CSS.
+func pageCSS(opts Options) string {
+	var b strings.Builder
+	if opts.PageSize != "" || opts.Margin != "" {
+		b.WriteString("@page {")
+		if opts.PageSize != "" {
+			b.WriteString(" size: " + opts.PageSize + ";")
+		}
+		if opts.Margin != "" {
+			b.WriteString(" margin: " + opts.Margin + ";")
+		}
+		b.WriteString(" }\n")
+	}
+	// keep backgrounds and colors as designed rather than saving ink
+	b.WriteString("html { -webkit-print-color-adjust: exact; print-color-adjust: exact; }\n")
+	b.WriteString(opts.CSS)
+	return strings.Replace(b.String(), "</", `<\/`, -1)
+}
+
+// writeCSS writes the style and link tags of bo and the components it uses, skipping duplicates.
+func writeCSS(w *strings.Builder, br *vugu.BuildResults, bo *vugu.BuildOut, seen map[string]bool) {
+	if bo == nil {
+		return
+	}
+	for _, n := range bo.CSS {
+		var b strings.Builder
+		b.WriteString("<" + n.Data)
+		for _, a := range n.Attr {
+			b.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+		}
+		b.WriteString(">")
+		if n.Data == "style" {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				b.WriteString(c.Data)
+			}
+			b.WriteString("</style>")
+		}
+		if !seen[b.String()] {
+			seen[b.String()] = true
+			w.WriteString(b.String())
+		}
+	}
+	for _, c := range bo.Components {
+		writeCSS(w, br, br.ResultFor(c), seen)
+	}
+}
@@ -0,0 +1,89 @@
+package vgprint
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vugu/vugu"
+)
+
+func testBuilder() vugu.Builder {
+	return vugu.NewBuilderFunc(func(in *vugu.BuildIn) *vugu.BuildOut {
+		n := &vugu.VGNode{Type: vugu.ElementNode, Data: "div"}
+		n.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: "Invoice #42"})
+		style := &vugu.VGNode{Type: vugu.ElementNode, Data: "style"}
+		style.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: ".total { font-weight: bold; }"})
+		return &vugu.BuildOut{Out: []*vugu.VGNode{n}, CSS: []*vugu.VGNode{style}}
+	})
+}
+
+func TestRender(t *testing.T) {
+
+	doc, err := Render(testBuilder(), Options{
+		Title:    "Invoice <42>",
+		PageSize: "A4",
+		Margin:   "15mm",
+		CSS:      "nav { display: none; }</style>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(doc.HTML)
+
+	for _, want := range []string{
+		"<!doctype html>\n<html><head>",
+		"<title>Invoice &lt;42&gt;</title>",
+		"@page { size: A4; margin: 15mm; }",
+		"nav { display: none; }<\\/style>",
+		"<style>.total { font-weight: bold; }</style>",
+		"<body><div>Invoice #42</div></body></html>",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("document does not contain %q:\n%s", want, s)
+		}
+	}
+	if doc.Title != "Invoice <42>" {
+		t.Errorf("unexpected title %q", doc.Title)
+	}
+}
+
+func TestHTTPBackend(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("X-Document-Title") != "Report" || !strings.Contains(string(body), "Invoice #42") {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.4"))
+	}))
+	defer srv.Close()
+
+	doc, err := Render(testBuilder(), Options{Title: "Report"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pdf, err := HTTPBackend{URL: srv.URL}.PDF(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(pdf) != "%PDF-1.4" {
+		t.Errorf("unexpected response %q", pdf)
+	}
+
+	_, err = HTTPBackend{URL: srv.URL}.PDF(&Document{HTML: []byte("<p>x</p>")})
+	if err == nil || !strings.Contains(err.Error(), "bad request") {
+		t.Errorf("expected error, got %v", err)
+	}
+}
+
+func TestBrowserPrintNotAvailable(t *testing.T) {
+	if err := (BrowserPrint{}).Print(&Document{}); err != ErrNotAvailable {
+		t.Errorf("expected ErrNotAvailable, got %v", err)
+	}
+}
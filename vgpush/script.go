@@ -0,0 +1,56 @@
+package vgpush
+
+// ServiceWorkerScript is the JS to include in the service worker, see the package documentation.
+// It handles the push, notificationclick and periodicsync events.
+const ServiceWorkerScript = `(function () {
+	function windows() {
+		return self.clients.matchAll({type: "window", includeUncontrolled: true});
+	}
+	function postAll(list, msg) {
+		list.forEach(function (c) {
+			c.postMessage(msg);
+		});
+	}
+	self.addEventListener("push", function (e) {
+		var text = e.data ? e.data.text() : "";
+		var n = {};
+		try {
+			var v = JSON.parse(text);
+			if (v && typeof v === "object") {
+				n = v;
+			}
+		} catch (err) {
+		}
+		e.waitUntil(windows().then(function (list) {
+			postAll(list, {vgpush: text});
+			if (list.some(function (c) { return c.focused; })) {
+				return;
+			}
+			return self.registration.showNotification(n.title || self.registration.scope, {
+				body: n.body || (n.title ? "" : text),
+				icon: n.icon,
+				tag: n.tag,
+				data: {url: n.url}
+			});
+		}));
+	});
+	self.addEventListener("notificationclick", function (e) {
+		e.notification.close();
+		var url = new URL((e.notification.data && e.notification.data.url) || "", self.registration.scope).href;
+		e.waitUntil(windows().then(function (list) {
+			for (var i = 0; i < list.length; i++) {
+				if (list[i].url === url && list[i].focus) {
+					return list[i].focus();
+				}
+			}
+			return self.clients.openWindow(url);
+		}));
+	});
+	self.addEventListener("periodicsync", function (e) {
+		var done = typeof self.vgpushPeriodicSync === "function" ? self.vgpushPeriodicSync(e.tag) : null;
+		e.waitUntil(Promise.resolve(done).then(windows).then(function (list) {
+			postAll(list, {vgpushPeriodicSync: e.tag});
+		}));
+	});
+})();
+`
@@ -0,0 +1,335 @@
+/*
+Package vgpush subscribes to push messages with the Push API and registers Periodic Background Sync,
+and delivers push messages to the app while it is open.
+
+The page must have a service worker registered, and the service worker must include ServiceWorkerScript
+(write it to a file and importScripts it, or paste it in).  The script shows a notification for each push
+unless a window of the app is focused, and forwards the payload to any open windows, where Listen picks
+it up:
+
+	func (c *Root) Init(ctx vugu.InitCtx) {
+		c.push = vgpush.Listen(ctx.EventEnv(), func(p vgpush.Push) {
+			var msg chatMessage
+			if p.Decode(&msg) == nil {
+				c.messages = append(c.messages, msg)
+			}
+		})
+	}
+
+	func (c *Root) Destroy() {
+		c.push.Close()
+	}
+
+Subscribe asks the user for permission to show notifications if needed and so must be started from
+a user action, in a goroutine because it waits for the browser:
+
+	func (c *Root) HandleEnable(event vugu.DOMEvent) {
+		ee := event.EventEnv()
+		go func() {
+			sub, err := vgpush.Subscribe(vgpush.SubscribeOptions{ApplicationServerKey: vapidPublicKey})
+			if err == nil {
+				err = sendToServer(sub.JSON)
+			}
+			ee.Lock()
+			defer ee.UnlockRender()
+			c.err = err
+		}()
+	}
+
+Push messages with a JSON payload of the form {"title": "...", "body": "...", "url": "..."} are shown
+as a notification with that title and body, and clicking it opens url (or focuses a window already there).
+Other payloads are shown with a generic title.
+
+Where the browser supports Periodic Background Sync (and the app is installed), RegisterPeriodicSync
+has the service worker woken about every interval.  The script then calls self.vgpushPeriodicSync(tag)
+in the service worker if it is defined (it may return a Promise) and tells open windows, which
+ListenPeriodicSync reports.
+
+Outside of the browser the functions return ErrNotAvailable and listeners do nothing.
+*/
+package vgpush
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/vugu/vjson"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+// ErrNotAvailable is returned when the browser does not support the API used (or outside of the browser).
+var ErrNotAvailable = errors.New("vgpush: not available in this environment")
+
+// ErrNoServiceWorker is returned when no service worker is registered for the page.
+var ErrNoServiceWorker = errors.New("vgpush: no service worker registered")
+
+// Error is a failure reported by the browser, e.g. Name is "NotAllowedError" when the user denies permission.
+type Error = js.DOMError
+
+// IsDenied returns true if err is the user (or browser) refusing permission.
+func IsDenied(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Name == "NotAllowedError"
+}
+
+// SubscribeOptions are the options for Subscribe.
+type SubscribeOptions struct {
+	ApplicationServerKey string // the server's VAPID public key, base64 URL encoded as usual
+}
+
+// Subscription is a push subscription, JSON is what the server needs to send messages to it.
+type Subscription struct {
+	Endpoint       string
+	ExpirationTime time.Time // zero if it does not expire
+	P256dh         string    // the keys, base64 URL encoded
+	Auth           string
+	JSON           []byte // PushSubscription.toJSON() as JSON
+
+	sub js.Value
+}
+
+// Unsubscribe cancels the subscription.
+func (s *Subscription) Unsubscribe() error {
+	if !s.sub.Truthy() {
+		return ErrNotAvailable
+	}
+	_, err := js.Await(s.sub.Call("unsubscribe"), js.NewDOMError)
+	return err
+}
+
+// Subscribe returns the push subscription for the page's service worker, subscribing if there is none.
+func Subscribe(opts SubscribeOptions) (*Subscription, error) {
+
+	pm, err := pushManager()
+	if err != nil {
+		return nil, err
+	}
+	key, err := decodeKey(opts.ApplicationServerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	arr := js.Global().Get("Uint8Array").New(len(key))
+	js.CopyBytesToJS(arr, key)
+	o := js.Global().Get("Object").New()
+	o.Set("userVisibleOnly", true)
+	o.Set("applicationServerKey", arr)
+
+	sub, err := js.Await(pm.Call("subscribe", o), js.NewDOMError)
+	if err != nil {
+		return nil, err
+	}
+	return newSubscription(sub)
+}
+
+// CurrentSubscription returns the push subscription for the page's service worker, or nil if there is none.
+func CurrentSubscription() (*Subscription, error) {
+	pm, err := pushManager()
+	if err != nil {
+		return nil, err
+	}
+	sub, err := js.Await(pm.Call("getSubscription"), js.NewDOMError)
+	if err != nil || !sub.Truthy() {
+		return nil, err
+	}
+	return newSubscription(sub)
+}
+
+func newSubscription(sub js.Value) (*Subscription, error) {
+	s := &Subscription{sub: sub}
+	s.JSON = []byte(js.Global().Get("JSON").Call("stringify", sub).String())
+	var v struct {
+		Endpoint       string   `json:"endpoint"`
+		ExpirationTime *float64 `json:"expirationTime"`
+		Keys           struct {
+			P256dh string `json:"p256dh"`
+			Auth   string `json:"auth"`
+		} `json:"keys"`
+	}
+	if err := vjson.Unmarshal(s.JSON, &v); err != nil {
+		return nil, err
+	}
+	s.Endpoint, s.P256dh, s.Auth = v.Endpoint, v.Keys.P256dh, v.Keys.Auth
+	if v.ExpirationTime != nil {
+		s.ExpirationTime = time.Unix(0, int64(*v.ExpirationTime)*int64(time.Millisecond))
+	}
+	return s, nil
+}
+
+// decodeKey decodes a base64 URL encoded key, with or without padding.
+func decodeKey(key string) ([]byte, error) {
+	if key == "" {
+		return nil, errors.New("vgpush: ApplicationServerKey is required")
+	}
+	key = strings.TrimRight(strings.NewReplacer("+", "-", "/", "_").Replace(key), "=")
+	b, err := base64.RawURLEncoding.DecodeString(key)
+	if err != nil {
+		return nil, errors.New("vgpush: invalid ApplicationServerKey: " + err.Error())
+	}
+	return b, nil
+}
+
+// RegisterPeriodicSync asks for the service worker to be woken with tag about every minInterval, the
+// browser decides how often it actually happens.  The "periodic-background-sync" permission
+// (see vgpermissions) is only granted to installed apps.
+func RegisterPeriodicSync(tag string, minInterval time.Duration) error {
+	ps, err := periodicSync()
+	if err != nil {
+		return err
+	}
+	o := js.Global().Get("Object").New()
+	o.Set("minInterval", minInterval.Milliseconds())
+	_, err = js.Await(ps.Call("register", tag, o), js.NewDOMError)
+	return err
+}
+
+// UnregisterPeriodicSync cancels the periodic sync registered with tag.
+func UnregisterPeriodicSync(tag string) error {
+	ps, err := periodicSync()
+	if err != nil {
+		return err
+	}
+	_, err = js.Await(ps.Call("unregister", tag), js.NewDOMError)
+	return err
+}
+
+// PeriodicSyncTags returns the tags of the registered periodic syncs.
+func PeriodicSyncTags() ([]string, error) {
+	ps, err := periodicSync()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := js.Await(ps.Call("getTags"), js.NewDOMError)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0, tags.Length())
+	for i, n := 0, tags.Length(); i < n; i++ {
+		ret = append(ret, tags.Index(i).String())
+	}
+	return ret, nil
+}
+
+// Push is a push message forwarded by the service worker.
+type Push struct {
+	Text string // the payload as text, empty if there was none
+}
+
+// Decode unmarshals the payload, which must be JSON, into v.
+func (p Push) Decode(v interface{}) error {
+	if p.Text == "" {
+		return errors.New("vgpush: push has no payload")
+	}
+	return vjson.Unmarshal([]byte(p.Text), v)
+}
+
+// Listener receives messages from ServiceWorkerScript, see Listen and ListenPeriodicSync.
+type Listener struct {
+	fn js.Func
+	sw js.Value
+}
+
+// Listen calls handler with each push message the service worker receives while the page is open.
+// The handler is called with the EventEnv write lock held and a render is requested when it returns,
+// the same as for DOM event handlers.  If eventEnv is nil the handler is called without locking or rendering.
+func Listen(eventEnv vugu.EventEnv, handler func(Push)) *Listener {
+	return listen(eventEnv, "vgpush", func(text string) { handler(Push{Text: text}) })
+}
+
+// ListenPeriodicSync calls handler with the tag each time the service worker is woken for a periodic
+// sync while the page is open.  Locking is as for Listen.
+func ListenPeriodicSync(eventEnv vugu.EventEnv, handler func(tag string)) *Listener {
+	return listen(eventEnv, "vgpushPeriodicSync", handler)
+}
+
+// listen calls handler with the key property of messages from the service worker which have it.
+func listen(eventEnv vugu.EventEnv, key string, handler func(string)) *Listener {
+
+	l := &Listener{}
+	sw := serviceWorker()
+	if !sw.Truthy() {
+		return l
+	}
+
+	l.sw = sw
+	l.fn = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		data := args[0].Get("data")
+		if !data.Truthy() || data.Get(key).Type() != js.TypeString {
+			return nil
+		}
+		s := data.Get(key).String()
+		if eventEnv == nil {
+			handler(s)
+			return nil
+		}
+		go func() {
+			eventEnv.Lock()
+			defer eventEnv.UnlockRender()
+			handler(s)
+		}()
+		return nil
+	})
+	sw.Call("addEventListener", "message", l.fn)
+	return l
+}
+
+// Close stops listening.  It is safe to call more than once.
+func (l *Listener) Close() {
+	if !l.sw.Truthy() {
+		return
+	}
+	l.sw.Call("removeEventListener", "message", l.fn)
+	l.fn.Release()
+	l.sw = js.Undefined()
+}
+
+func serviceWorker() js.Value {
+	nav := js.Global().Get("navigator")
+	if !nav.Truthy() {
+		return js.Undefined()
+	}
+	return nav.Get("serviceWorker")
+}
+
+// registration returns the service worker registration for the page.
+func registration() (js.Value, error) {
+	sw := serviceWorker()
+	if !sw.Truthy() {
+		return js.Undefined(), ErrNotAvailable
+	}
+	reg, err := js.Await(sw.Call("getRegistration"), js.NewDOMError)
+	if err != nil {
+		return js.Undefined(), err
+	}
+	if !reg.Truthy() {
+		return js.Undefined(), ErrNoServiceWorker
+	}
+	return reg, nil
+}
+
+func pushManager() (js.Value, error) {
+	if !js.Global().Get("PushManager").Truthy() {
+		return js.Undefined(), ErrNotAvailable
+	}
+	reg, err := registration()
+	if err != nil {
+		return js.Undefined(), err
+	}
+	return reg.Get("pushManager"), nil
+}
+
+func periodicSync() (js.Value, error) {
+	reg, err := registration()
+	if err != nil {
+		return js.Undefined(), err
+	}
+	ps := reg.Get("periodicSync")
+	if !ps.Truthy() {
+		return js.Undefined(), ErrNotAvailable
+	}
+	return ps, nil
+}
@@ -0,0 +1,64 @@
+package vgpush
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNotAvailable(t *testing.T) {
+
+	if _, err := Subscribe(SubscribeOptions{ApplicationServerKey: "AAAA"}); err != ErrNotAvailable {
+		t.Errorf("Subscribe: expected ErrNotAvailable, got %v", err)
+	}
+	if _, err := CurrentSubscription(); err != ErrNotAvailable {
+		t.Errorf("CurrentSubscription: expected ErrNotAvailable, got %v", err)
+	}
+	if err := RegisterPeriodicSync("news", time.Hour); err != ErrNotAvailable {
+		t.Errorf("RegisterPeriodicSync: expected ErrNotAvailable, got %v", err)
+	}
+	if _, err := PeriodicSyncTags(); err != ErrNotAvailable {
+		t.Errorf("PeriodicSyncTags: expected ErrNotAvailable, got %v", err)
+	}
+	if err := (&Subscription{}).Unsubscribe(); err != ErrNotAvailable {
+		t.Errorf("Unsubscribe: expected ErrNotAvailable, got %v", err)
+	}
+
+	l := Listen(nil, func(Push) { t.Errorf("unexpected handler call") })
+	l.Close()
+	l.Close()
+}
+
+func TestDecodeKey(t *testing.T) {
+	for _, key := range []string{"-_8", "+/8=", "-_8="} {
+		b, err := decodeKey(key)
+		if err != nil || len(b) != 2 || b[0] != 0xfb || b[1] != 0xff {
+			t.Errorf("decodeKey(%q) = %x, %v", key, b, err)
+		}
+	}
+	if _, err := decodeKey(""); err == nil {
+		t.Errorf("expected error for empty key")
+	}
+	if _, err := decodeKey("!!"); err == nil {
+		t.Errorf("expected error for invalid key")
+	}
+}
+
+func TestPush(t *testing.T) {
+	var v struct{ Title string }
+	if err := (Push{Text: `{"title":"hi"}`}).Decode(&v); err != nil || v.Title != "hi" {
+		t.Errorf("unexpected decode %#v, %v", v, err)
+	}
+	if err := (Push{}).Decode(&v); err == nil {
+		t.Errorf("expected error for empty payload")
+	}
+}
+
+func TestIsDenied(t *testing.T) {
+	if !IsDenied(&Error{Name: "NotAllowedError"}) {
+		t.Errorf("expected NotAllowedError to be denied")
+	}
+	if IsDenied(&Error{Name: "AbortError"}) || IsDenied(errors.New("NotAllowedError")) {
+		t.Errorf("unexpected denied")
+	}
+}
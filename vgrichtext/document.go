@@ -0,0 +1,225 @@
+package vgrichtext
+
+import (
+	"bytes"
+	"html"
+	"net/url"
+	"strings"
+
+	vhtml "github.com/vugu/html"
+	"github.com/vugu/html/atom"
+)
+
+// NodeType is the type of a Node.
+type NodeType int
+
+// Available NodeTypes.
+const (
+	TextNode NodeType = iota + 1
+	ElementNode
+)
+
+// Node is a text or element node in a Document, much like a vugu.VGNode but only with what rich text needs.
+type Node struct {
+	Type     NodeType
+	Data     string  // the text for TextNode, the tag name for ElementNode
+	Href     string  // the link for "a" elements
+	Children []*Node // for ElementNode
+}
+
+// Document is rich text as a list of nodes, which only contains the elements and attributes
+// listed in ParseHTML.
+type Document struct {
+	Nodes []*Node
+}
+
+// allowed lists the elements a Document may contain
+var allowed = map[string]bool{
+	"p": true, "br": true, "strong": true, "em": true, "u": true, "s": true, "a": true,
+	"ul": true, "ol": true, "li": true, "h1": true, "h2": true, "h3": true,
+	"blockquote": true, "code": true, "pre": true,
+}
+
+// renamed maps elements to their equivalent in allowed
+var renamed = map[string]string{
+	"b": "strong", "i": "em", "strike": "s", "del": "s", "h4": "h3", "h5": "h3", "h6": "h3",
+}
+
+// dropped lists the elements which are removed along with their contents, other elements not in allowed are
+// replaced by their contents
+var dropped = map[string]bool{
+	"script": true, "style": true, "title": true, "meta": true, "link": true, "head": true, "template": true,
+	"iframe": true, "object": true, "embed": true, "noscript": true, "svg": true, "math": true,
+	"textarea": true, "select": true, "button": true, "input": true,
+}
+
+// block lists the elements which start a new line
+var block = map[string]bool{
+	"p": true, "div": true, "ul": true, "ol": true, "li": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "blockquote": true, "pre": true,
+}
+
+// ParseHTML returns a Document for s, keeping only the following elements and the href attribute of links
+// (with http, https or mailto URLs, or relative ones):
+//
+//	p br strong em u s a ul ol li h1 h2 h3 blockquote code pre
+//
+// b, i, strike, del and h4-h6 are converted to their equivalent from the list, and a div to a p (as
+// browsers use them for paragraphs when editing).  Scripts, styles, form controls and embedded content
+// are removed, and other elements (e.g. span) are replaced by their contents.  This makes it suitable
+// for sanitizing HTML pasted from other applications or submitted by users.
+func ParseHTML(s string) *Document {
+	context := &vhtml.Node{Type: vhtml.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := vhtml.ParseFragment(strings.NewReader(s), context)
+	if err != nil {
+		// only happens for errors from the reader, which a strings.Reader does not have
+		return &Document{}
+	}
+	d := &Document{}
+	for _, n := range nodes {
+		d.Nodes = append(d.Nodes, convert(n, false)...)
+	}
+	return d
+}
+
+// Sanitize returns s with everything not allowed in a Document removed, see ParseHTML.
+func Sanitize(s string) string {
+	return ParseHTML(s).HTML()
+}
+
+// convert returns the Nodes for n, inBlock is true inside a p, li or heading where another
+// paragraph cannot start.
+func convert(n *vhtml.Node, inBlock bool) []*Node {
+
+	switch n.Type {
+	case vhtml.TextNode:
+		return []*Node{{Type: TextNode, Data: n.Data}}
+	case vhtml.ElementNode:
+	default:
+		return nil
+	}
+
+	tag := n.Data
+	if dropped[tag] {
+		return nil
+	}
+	if r, ok := renamed[tag]; ok {
+		tag = r
+	}
+	if tag == "div" && !inBlock && !hasBlockChild(n) {
+		tag = "p"
+	}
+
+	var href string
+	if tag == "a" {
+		href = attr(n, "href")
+		if !safeURL(href) {
+			tag = "" // keep the text only
+		}
+	}
+
+	childInBlock := inBlock || tag == "p" || tag == "li" || tag == "h1" || tag == "h2" || tag == "h3"
+	var children []*Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		children = append(children, convert(c, childInBlock)...)
+	}
+
+	if !allowed[tag] {
+		return children
+	}
+	return []*Node{{Type: ElementNode, Data: tag, Href: href, Children: children}}
+}
+
+func hasBlockChild(n *vhtml.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == vhtml.ElementNode && block[c.Data] {
+			return true
+		}
+	}
+	return false
+}
+
+func attr(n *vhtml.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// safeURL returns true for http, https and mailto URLs and relative ones.
+func safeURL(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || s == "" {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// HTML returns the document as HTML.
+func (d *Document) HTML() string {
+	if d == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	writeHTML(&buf, d.Nodes)
+	return buf.String()
+}
+
+func writeHTML(buf *bytes.Buffer, nodes []*Node) {
+	for _, n := range nodes {
+		if n.Type == TextNode {
+			buf.WriteString(html.EscapeString(n.Data))
+			continue
+		}
+		buf.WriteString("<" + n.Data)
+		if n.Data == "a" {
+			buf.WriteString(` href="` + html.EscapeString(n.Href) + `"`)
+		}
+		buf.WriteString(">")
+		if n.Data == "br" {
+			continue
+		}
+		writeHTML(buf, n.Children)
+		buf.WriteString("</" + n.Data + ">")
+	}
+}
+
+// Text returns the document as plain text, with a line for each paragraph, heading or list item.
+func (d *Document) Text() string {
+	if d == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	writeText(&buf, d.Nodes)
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+func writeText(buf *bytes.Buffer, nodes []*Node) {
+	for _, n := range nodes {
+		switch {
+		case n.Type == TextNode:
+			buf.WriteString(n.Data)
+		case n.Data == "br":
+			buf.WriteString("\n")
+		default:
+			if block[n.Data] && buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+				buf.WriteString("\n")
+			}
+			writeText(buf, n.Children)
+			if block[n.Data] && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+				buf.WriteString("\n")
+			}
+		}
+	}
+}
+
+// IsEmpty returns true if the document has no text other than whitespace.
+func (d *Document) IsEmpty() bool {
+	return strings.TrimSpace(d.Text()) == ""
+}
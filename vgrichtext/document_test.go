@@ -0,0 +1,37 @@
+package vgrichtext
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	for _, tc := range []struct{ in, out string }{
+		{`<p>Hello <b>bold</b> and <i>it</i></p>`, `<p>Hello <strong>bold</strong> and <em>it</em></p>`},
+		{`<div>one</div><div><br></div><div>two</div>`, `<p>one</p><p><br></p><p>two</p>`},
+		{`<div><p>a</p><p>b</p></div>`, `<p>a</p><p>b</p>`},
+		{`<p><div>nested</div></p>`, `<p></p><p>nested</p><p></p>`},
+		{`<span style="color:red" onclick="x()">text</span>`, `text`},
+		{`<script>alert(1)</script><style>p{}</style>ok`, `ok`},
+		{`<a href="https://example.com/?a=1&b=2" target="_blank">link</a>`, `<a href="https://example.com/?a=1&amp;b=2">link</a>`},
+		{`<a href="javascript:alert(1)">bad</a>`, `bad`},
+		{`<a href=" JavaScript:alert(1)">bad</a>`, `bad`},
+		{`<img src=x onerror=alert(1)>`, ``},
+		{`<ul><li>one</li><li><h4>two</h4></li></ul>`, `<ul><li>one</li><li><h3>two</h3></li></ul>`},
+		{`a &lt; b`, `a &lt; b`},
+	} {
+		if got := Sanitize(tc.in); got != tc.out {
+			t.Errorf("Sanitize(%q): expected %q, got %q", tc.in, tc.out, got)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	d := ParseHTML(`<h2>Title</h2><p>one<br>two</p><ul><li>a</li><li>b</li></ul>`)
+	if got, want := d.Text(), "Title\none\ntwo\na\nb"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if d.IsEmpty() {
+		t.Errorf("expected not empty")
+	}
+	if !ParseHTML(`<p><br></p>`).IsEmpty() || !(*Document)(nil).IsEmpty() {
+		t.Errorf("expected empty")
+	}
+}
@@ -0,0 +1,146 @@
+package vgrichtext
+
+import (
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+)
+
+// Editor is a rich text editor, see the package documentation.
+type Editor struct {
+	Doc         *Document     // the content, a new Document is emitted with each change rather than this one being modified
+	Placeholder string        // shown when the editor is empty
+	Toolbar     bool          // show the built in toolbar
+	Change      ChangeHandler // called when the content is edited
+
+	AttrMap vugu.AttrMap // regular HTML attributes for the outer element like id and class
+
+	el    js.Value // the contenteditable element
+	shown string   // the HTML of Doc when the element was last set to or read from
+}
+
+// toolbar lists the buttons of the built in toolbar
+var toolbar = []struct {
+	cmd   Command
+	label string
+	title string
+}{
+	{Bold, "B", "Bold"},
+	{Italic, "I", "Italic"},
+	{Underline, "U", "Underline"},
+	{Strike, "S", "Strikethrough"},
+	{Heading2, "H", "Heading"},
+	{Paragraph, "P", "Paragraph"},
+	{Quote, "Quote", "Quote"},
+	{BulletList, "List", "Bulleted list"},
+	{NumberedList, "1.", "Numbered list"},
+	{Link, "Link", "Link"},
+	{ClearFormat, "Clear", "Clear formatting"},
+}
+
+// Compute implements vugu.Computer, it updates the element when Doc was changed other than by editing.
+func (c *Editor) Compute(ctx vugu.ComputeCtx) {
+	c.update()
+}
+
+func (c *Editor) update() {
+	if !c.el.Truthy() {
+		return
+	}
+	if h := c.Doc.HTML(); h != c.shown {
+		c.el.Set("innerHTML", h)
+		c.shown = h
+	}
+}
+
+func (c *Editor) handleCreate(el js.Value) {
+	c.el = el
+	// the callback can come again for the same element, which must be left alone unless Doc changed
+	if !el.Get("vgrichtextInit").Truthy() {
+		el.Set("vgrichtextInit", true)
+		h := c.Doc.HTML()
+		el.Set("innerHTML", h)
+		c.shown = h
+	}
+	c.update()
+}
+
+func (c *Editor) handleInput(event vugu.DOMEvent) {
+	h, ok := event.Prop("target", "innerHTML").(string)
+	if !ok {
+		if !c.el.Truthy() {
+			return
+		}
+		h = c.el.Get("innerHTML").String()
+	}
+	doc := ParseHTML(h)
+	c.Doc = doc
+	c.shown = doc.HTML()
+	if c.Change != nil {
+		c.Change.ChangeHandle(ChangeEvent{Doc: doc})
+	}
+}
+
+func (c *Editor) handleToolbar(event vugu.DOMEvent) {
+	c.Exec(Command(event.PropString("dataset", "cmd")), "")
+}
+
+// Exec applies cmd to the selection in the editor, value is the URL for Link.  The browser fires an input
+// event for the change right away, which cannot be handled while another event handler is running, so
+// the command is applied after the current handler returns.
+func (c *Editor) Exec(cmd Command, value string) {
+	el := c.el
+	name, _ := cmd.execCommand(value)
+	if !el.Truthy() || name == "" {
+		return
+	}
+	go func() {
+		if cmd == Link && value == "" {
+			v := js.Global().Call("prompt", "Link URL")
+			if v.Type() != js.TypeString {
+				return
+			}
+			value = v.String()
+		}
+		if cmd == Link && !safeURL(value) {
+			return
+		}
+		name, arg := cmd.execCommand(value)
+		el.Call("focus")
+		js.Global().Get("document").Call("execCommand", name, false, arg)
+	}()
+}
+
+// handlePaste inserts pasted HTML after sanitizing it, or else pasted text.  Files are ignored.
+func (c *Editor) handlePaste(event vugu.DOMEvent) {
+
+	idx, isHTML := -1, false
+	for i, item := range vugu.ClipboardItems(event) {
+		if item.Kind != "string" {
+			continue
+		}
+		if item.Type == "text/html" {
+			idx, isHTML = i, true
+			break
+		}
+		if item.Type == "text/plain" && idx < 0 {
+			idx = i
+		}
+	}
+	if idx < 0 || !c.el.Truthy() {
+		return
+	}
+
+	content := vugu.ReadClipboardItem(event, idx)
+	go func() {
+		b, err := content.Wait()
+		if err != nil {
+			return
+		}
+		doc := js.Global().Get("document")
+		if isHTML {
+			doc.Call("execCommand", "insertHTML", false, Sanitize(string(b)))
+		} else {
+			doc.Call("execCommand", "insertText", false, string(b))
+		}
+	}()
+}
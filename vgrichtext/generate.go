@@ -0,0 +1,3 @@
+package vgrichtext
+
+//go:generate vugugen
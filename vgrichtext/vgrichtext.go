@@ -0,0 +1,80 @@
+/*
+Package vgrichtext provides a rich text Editor component whose document lives in Go.
+
+The editor is a contenteditable element.  Its content is not rendered by Vugu, so editing does not fight
+the DOM diff: each change is read back from the element, sanitized and parsed into a Document (a small
+tree much like VGNode), and emitted as a ChangeEvent.  Setting Doc from Go replaces the content.
+
+	<vgrichtext:Editor :Doc='c.body' :Toolbar='true' Placeholder="Write something..."
+		@Change='c.body = event.Doc'></vgrichtext:Editor>
+
+Formatting is applied to the selection with the browser's editing commands, from the built in toolbar
+or with Editor.Exec.  Pasted HTML is sanitized before it is inserted, see ParseHTML.  The editor needs
+the program to run on the page's main thread (not in a worker or on the server), as it calls the
+browser directly.
+*/
+package vgrichtext
+
+// Command is a formatting command applied to the selection in an Editor.
+type Command string
+
+// Available Commands.
+const (
+	Bold         Command = "bold"
+	Italic       Command = "italic"
+	Underline    Command = "underline"
+	Strike       Command = "strike"
+	Heading1     Command = "h1"
+	Heading2     Command = "h2"
+	Heading3     Command = "h3"
+	Paragraph    Command = "p"
+	Quote        Command = "blockquote"
+	Code         Command = "pre"
+	BulletList   Command = "ul"
+	NumberedList Command = "ol"
+	Link         Command = "link" // the value is the URL, if empty the user is asked for it
+	Unlink       Command = "unlink"
+	ClearFormat  Command = "clear"
+	Undo         Command = "undo"
+	Redo         Command = "redo"
+)
+
+// execCommand returns the document.execCommand name and value for c.
+func (c Command) execCommand(value string) (string, string) {
+	switch c {
+	case Bold, Italic, Underline, Unlink, Undo, Redo:
+		return string(c), ""
+	case Strike:
+		return "strikeThrough", ""
+	case Heading1, Heading2, Heading3, Paragraph, Quote, Code:
+		return "formatBlock", "<" + string(c) + ">"
+	case BulletList:
+		return "insertUnorderedList", ""
+	case NumberedList:
+		return "insertOrderedList", ""
+	case Link:
+		return "createLink", value
+	case ClearFormat:
+		return "removeFormat", ""
+	}
+	return "", ""
+}
+
+// ChangeEvent is emitted by Editor when the content is edited.
+type ChangeEvent struct {
+	Doc *Document // the new content
+}
+
+// ChangeHandler is the interface for things that can handle ChangeEvent.
+type ChangeHandler interface {
+	ChangeHandle(event ChangeEvent)
+}
+
+// ChangeFunc implements ChangeHandler as a function.
+type ChangeFunc func(event ChangeEvent)
+
+// ChangeHandle implements the ChangeHandler interface.
+func (f ChangeFunc) ChangeHandle(event ChangeEvent) { f(event) }
+
+// assert ChangeFunc implements ChangeHandler
+var _ ChangeHandler = ChangeFunc(nil)
@@ -0,0 +1,177 @@
+/*
+Package vgsanitize removes everything but an allowlist of elements and attributes from HTML, so HTML
+from users or other untrusted sources can be shown with vg-html without the risk of cross-site scripting.
+
+It is usually set on the renderer so it applies to all vg-html output which is not static markup:
+
+	renderer.HTMLSanitizer = vgsanitize.DefaultPolicy() // a *domrender.JSRenderer
+
+or used directly:
+
+	<div vg-html="vugu.HTML(vgsanitize.Sanitize(c.Comment.Body))"></div>
+
+A Policy lists the elements which are kept and the attributes kept on each.  Elements not on the list are
+replaced by their contents, except for those whose contents are not text to show (scripts, styles, embedded
+content, form controls and the like), which are removed along with their contents.  Comments are removed.
+URLs in href and src attributes are kept only if they are relative or have one of the allowed schemes.
+*/
+package vgsanitize
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	vhtml "github.com/vugu/html"
+	"github.com/vugu/html/atom"
+)
+
+// Policy is an allowlist of elements and attributes, it implements vugu.HTMLSanitizer.
+type Policy struct {
+	// Elements maps the tag names of the elements which are kept to the attributes kept on them,
+	// in addition to GlobalAttributes.
+	Elements map[string][]string
+
+	// GlobalAttributes are kept on all the elements in Elements.
+	GlobalAttributes []string
+
+	// URLSchemes are the schemes allowed in href and src attributes, e.g. "https".  Relative URLs
+	// are always allowed.
+	URLSchemes []string
+}
+
+// DefaultPolicy returns a new Policy allowing common text formatting, lists, tables, links and images.
+// Links are kept with their href, and images with their src, alt and size.  http, https and mailto URLs
+// are allowed.  Classes and inline styles are removed.
+func DefaultPolicy() *Policy {
+	p := &Policy{
+		Elements: map[string][]string{
+			"a":          {"href", "title"},
+			"img":        {"src", "alt", "title", "width", "height"},
+			"td":         {"colspan", "rowspan"},
+			"th":         {"colspan", "rowspan", "scope"},
+			"ol":         {"start", "reversed"},
+			"li":         {"value"},
+			"time":       {"datetime"},
+			"abbr":       {"title"},
+			"q":          {"cite"},
+			"blockquote": {"cite"},
+			"details":    {"open"},
+		},
+		GlobalAttributes: []string{"lang", "dir"},
+		URLSchemes:       []string{"http", "https", "mailto"},
+	}
+	for _, tag := range strings.Fields(`b blockquote br caption cite code col colgroup dd del details dfn div dl
+		dt em figcaption figure h1 h2 h3 h4 h5 h6 hr i ins kbd mark p pre s samp small span strong sub summary
+		sup table tbody tfoot thead tr u ul var`) {
+		if _, ok := p.Elements[tag]; !ok {
+			p.Elements[tag] = nil
+		}
+	}
+	return p
+}
+
+var defaultPolicy = DefaultPolicy()
+
+// Sanitize returns s with everything not allowed by DefaultPolicy removed.
+func Sanitize(s string) string {
+	return defaultPolicy.SanitizeHTML(s)
+}
+
+// dropped lists the elements which are removed along with their contents when they are not allowed,
+// other elements are replaced by their contents
+var dropped = map[string]bool{
+	"script": true, "style": true, "title": true, "meta": true, "link": true, "base": true, "head": true,
+	"template": true, "iframe": true, "frame": true, "frameset": true, "object": true, "embed": true,
+	"applet": true, "noscript": true, "noembed": true, "noframes": true, "svg": true, "math": true,
+	"textarea": true, "select": true, "button": true, "input": true, "option": true, "form": true,
+	"audio": true, "video": true, "canvas": true, "xmp": true, "plaintext": true,
+}
+
+// SanitizeHTML implements vugu.HTMLSanitizer, it returns s with everything not allowed by p removed.
+func (p *Policy) SanitizeHTML(s string) string {
+	context := &vhtml.Node{Type: vhtml.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := vhtml.ParseFragment(strings.NewReader(s), context)
+	if err != nil {
+		// only happens for errors from the reader, which a strings.Reader does not have
+		return ""
+	}
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		for _, c := range p.clean(n) {
+			vhtml.Render(&buf, c)
+		}
+	}
+	return buf.String()
+}
+
+// clean returns what n is replaced by: a copy of it with only the allowed attributes and its
+// children cleaned, its children cleaned, or nothing.
+func (p *Policy) clean(n *vhtml.Node) []*vhtml.Node {
+
+	switch n.Type {
+	case vhtml.TextNode:
+		return []*vhtml.Node{{Type: vhtml.TextNode, Data: n.Data}}
+	case vhtml.ElementNode:
+	default:
+		return nil
+	}
+
+	attrs, ok := p.Elements[n.Data]
+	if !ok && (dropped[n.Data] || n.Namespace != "") {
+		return nil
+	}
+
+	var children []*vhtml.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		children = append(children, p.clean(c)...)
+	}
+	if !ok {
+		return children
+	}
+
+	ret := &vhtml.Node{Type: vhtml.ElementNode, Data: n.Data, DataAtom: n.DataAtom}
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !(contains(attrs, a.Key) || contains(p.GlobalAttributes, a.Key)) {
+			continue
+		}
+		if (a.Key == "href" || a.Key == "src" || a.Key == "cite") && !p.allowedURL(a.Val) {
+			continue
+		}
+		ret.Attr = append(ret.Attr, vhtml.Attribute{Key: a.Key, Val: a.Val})
+	}
+	for _, c := range children {
+		ret.AppendChild(c)
+	}
+	return []*vhtml.Node{ret}
+}
+
+// allowedURL returns true for relative URLs and those with one of p.URLSchemes.
+func (p *Policy) allowedURL(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" {
+		// browsers ignore some characters in schemes which url.Parse does not, e.g. "java\tscript:"
+		if i := strings.IndexAny(s, "/?#"); i >= 0 {
+			s = s[:i]
+		}
+		return !strings.Contains(s, ":")
+	}
+	for _, scheme := range p.URLSchemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
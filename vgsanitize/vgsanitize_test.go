@@ -0,0 +1,50 @@
+package vgsanitize
+
+import (
+	"testing"
+
+	"github.com/vugu/vugu"
+)
+
+func TestSanitize(t *testing.T) {
+
+	for _, tc := range []struct{ in, out string }{
+		{`<p>Hello <b>world</b></p>`, `<p>Hello <b>world</b></p>`},
+		{`<script>alert(1)</script>text`, `text`},
+		{`<img src="x.png" onerror="alert(1)" alt="x">`, `<img src="x.png" alt="x"/>`},
+		{`<a href="javascript:alert(1)">link</a>`, `<a>link</a>`},
+		{`<a href="JaVaScRiPt:alert(1)">link</a>`, `<a>link</a>`},
+		{"<a href=\"java\tscript:alert(1)\">link</a>", `<a>link</a>`},
+		{`<a href="https://example.com/?q=a:b" target="_blank">link</a>`, `<a href="https://example.com/?q=a:b">link</a>`},
+		{`<a href="/path?q=a:b#c:d">link</a>`, `<a href="/path?q=a:b#c:d">link</a>`},
+		{`<custom-el class="x" style="color:red">kept</custom-el>`, `kept`},
+		{`<iframe src="https://example.com"></iframe><!-- comment --><svg><script>alert(1)</script></svg>`, ``},
+		{`<table><tr><td colspan="2" onclick="x()">a &amp; b</td></tr></table>`, `<table><tbody><tr><td colspan="2">a &amp; b</td></tr></tbody></table>`},
+		{`<p lang="fr" id="x">salut</p>`, `<p lang="fr">salut</p>`},
+		{`&lt;b&gt;not bold&lt;/b&gt;`, `&lt;b&gt;not bold&lt;/b&gt;`},
+	} {
+		if got := Sanitize(tc.in); got != tc.out {
+			t.Errorf("Sanitize(%q): expected %q, got %q", tc.in, tc.out, got)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+
+	p := DefaultPolicy()
+	p.Elements["span"] = []string{"class"}
+	p.URLSchemes = append(p.URLSchemes, "tel")
+
+	in := `<span class="note">call <a href="tel:123">us</a></span>`
+	if got := p.SanitizeHTML(in); got != in {
+		t.Errorf("expected %q, got %q", in, got)
+	}
+
+	// the default is not affected
+	if got := Sanitize(in); got != `<span>call <a>us</a></span>` {
+		t.Errorf("default policy changed: %q", got)
+	}
+}
+
+// assert Policy implements vugu.HTMLSanitizer
+var _ vugu.HTMLSanitizer = (*Policy)(nil)
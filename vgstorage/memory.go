@@ -0,0 +1,73 @@
+package vgstorage
+
+import "sync"
+
+// Memory is a Storage kept in memory, for use outside the browser and in tests.
+type Memory struct {
+	mu       sync.Mutex
+	items    map[string]string
+	watchers map[int]func(key, value string, ok bool)
+	nextID   int
+}
+
+// NewMemory returns an empty Memory.
+func NewMemory() *Memory {
+	return &Memory{items: make(map[string]string), watchers: make(map[int]func(key, value string, ok bool))}
+}
+
+// GetItem implements Storage.
+func (m *Memory) GetItem(key string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.items[key]
+	return v, ok
+}
+
+// SetItem implements Storage.
+func (m *Memory) SetItem(key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[key] = value
+	return nil
+}
+
+// RemoveItem implements Storage.
+func (m *Memory) RemoveItem(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, key)
+}
+
+// Watch implements Storage.  Watchers are only called by Change.
+func (m *Memory) Watch(fn func(key, value string, ok bool)) (stop func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.nextID
+	m.nextID++
+	m.watchers[id] = fn
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.watchers, id)
+	}
+}
+
+// Change sets the value for key, or removes it if ok is false, as another tab would, and calls the
+// watchers before returning.
+func (m *Memory) Change(key, value string, ok bool) {
+	m.mu.Lock()
+	if ok {
+		m.items[key] = value
+	} else {
+		delete(m.items, key)
+	}
+	fns := make([]func(key, value string, ok bool), 0, len(m.watchers))
+	for _, fn := range m.watchers {
+		fns = append(fns, fn)
+	}
+	m.mu.Unlock()
+
+	for _, fn := range fns {
+		fn(key, value, ok)
+	}
+}
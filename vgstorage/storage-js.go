@@ -0,0 +1,93 @@
+// +build js
+
+package vgstorage
+
+import (
+	"errors"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// Local returns the browser's localStorage, which is kept across sessions and shared by the tabs of a site.
+func Local() Storage { return browserStorage("localStorage") }
+
+// Session returns the browser's sessionStorage, which is kept for the tab until it is closed.
+func Session() Storage { return browserStorage("sessionStorage") }
+
+// browserStorage is the Storage of the window property with this name.
+type browserStorage string
+
+func (s browserStorage) area() js.Value {
+	return js.Global().Get(string(s))
+}
+
+// GetItem implements Storage.
+func (s browserStorage) GetItem(key string) (string, bool) {
+	area := s.area()
+	if !area.Truthy() {
+		return "", false
+	}
+	v := area.Call("getItem", key)
+	if v.Type() != js.TypeString {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// SetItem implements Storage.
+func (s browserStorage) SetItem(key, value string) (err error) {
+	area := s.area()
+	if !area.Truthy() {
+		return errors.New("vgstorage: " + string(s) + " is not available")
+	}
+	// setItem throws when the quota is exceeded
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("vgstorage: " + string(s) + ".setItem failed")
+		}
+	}()
+	area.Call("setItem", key, value)
+	return nil
+}
+
+// RemoveItem implements Storage.
+func (s browserStorage) RemoveItem(key string) {
+	if area := s.area(); area.Truthy() {
+		area.Call("removeItem", key)
+	}
+}
+
+// Watch implements Storage with the window's storage event, which fires for changes made in other tabs.
+// fn is called in a new goroutine, so it may block.
+func (s browserStorage) Watch(fn func(key, value string, ok bool)) (stop func()) {
+	area := s.area()
+	if !area.Truthy() {
+		return func() {}
+	}
+	f := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		e := args[0]
+		if !js.Global().Get("Object").Call("is", e.Get("storageArea"), area).Bool() {
+			return nil
+		}
+		k := e.Get("key")
+		if k.Type() != js.TypeString {
+			// the storage was cleared
+			go fn("", "", false)
+			return nil
+		}
+		key := k.String()
+		v := e.Get("newValue")
+		if v.Type() != js.TypeString {
+			go fn(key, "", false)
+			return nil
+		}
+		value := v.String()
+		go fn(key, value, true)
+		return nil
+	})
+	js.Global().Call("addEventListener", "storage", f)
+	return func() {
+		js.Global().Call("removeEventListener", "storage", f)
+		f.Release()
+	}
+}
@@ -0,0 +1,14 @@
+// +build !js
+
+package vgstorage
+
+var (
+	localMemory   = NewMemory()
+	sessionMemory = NewMemory()
+)
+
+// Local returns the browser's localStorage, which is kept across sessions and shared by the tabs of a site.
+func Local() Storage { return localMemory }
+
+// Session returns the browser's sessionStorage, which is kept for the tab until it is closed.
+func Session() Storage { return sessionMemory }
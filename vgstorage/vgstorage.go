@@ -0,0 +1,180 @@
+/*
+Package vgstorage persists values as JSON in the browser's localStorage or sessionStorage.
+
+A Binding restores a component field when it is created and saves it when it changes.  Changes made
+in other tabs are applied to the field with the EventEnv write lock held, and a render requested, so
+all tabs stay in sync:
+
+	func (c *Settings) Init(ctx vugu.InitCtx) {
+		c.binding, _ = vgstorage.Bind(ctx.EventEnv(), vgstorage.Local(), "settings", &c.prefs)
+	}
+
+	func (c *Settings) Rendered(ctx vugu.RenderedCtx) {
+		c.binding.Save() // does nothing unless c.prefs changed
+	}
+
+	func (c *Settings) Destroy(ctx vugu.DestroyCtx) {
+		c.binding.Close()
+	}
+
+The state of a vgstore.Store can be persisted with Load and Save, restoring it with an action and
+saving it from a subscription:
+
+	var saved Todos
+	if ok, _ := vgstorage.Load(vgstorage.Local(), "todos", &saved); ok {
+		store.Dispatch(RestoreTodos{saved})
+	}
+	store.Subscribe(nil, selectTodos, func(v interface{}) {
+		vgstorage.Save(vgstorage.Local(), "todos", v)
+	})
+
+Outside the browser Local and Session return in-memory storage, which lasts as long as the process.
+*/
+package vgstorage
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/vugu/vjson"
+
+	"github.com/vugu/vugu"
+)
+
+// Storage stores strings by key, like the Web Storage API.
+type Storage interface {
+	// GetItem returns the value for key, and false if there is none.
+	GetItem(key string) (string, bool)
+	// SetItem sets the value for key.  It can fail, e.g. when the storage is full.
+	SetItem(key, value string) error
+	// RemoveItem removes the value for key.
+	RemoveItem(key string)
+	// Watch calls fn when a value is changed from elsewhere, i.e. by another tab, with ok false if it
+	// was removed, until stop is called.  key is empty if all values were removed.  fn is not called for
+	// changes made through this Storage.
+	Watch(fn func(key, value string, ok bool)) (stop func())
+}
+
+// Load unmarshals the value stored for key into v.  It returns false if there is none.
+func Load(s Storage, key string, v interface{}) (bool, error) {
+	str, ok := s.GetItem(key)
+	if !ok {
+		return false, nil
+	}
+	return true, vjson.Unmarshal([]byte(str), v)
+}
+
+// Save stores v marshaled as JSON for key.
+func Save(s Storage, key string, v interface{}) error {
+	b, err := vjson.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.SetItem(key, string(b))
+}
+
+// Binding keeps a value in sync with a key in a Storage, see Bind.
+type Binding struct {
+	eventEnv vugu.EventEnv
+	storage  Storage
+	key      string
+	ptr      interface{}
+
+	mu    sync.Mutex
+	saved string // the JSON last saved or restored
+	stop  func()
+}
+
+// Bind restores the value stored for key into what ptr points to, if there is one, and returns a Binding
+// that saves it and applies changes made in other tabs.  Changes are applied with eventEnv locked, and a
+// render requested after, or without locking if it is nil.  If the stored value cannot be restored the
+// error is returned along with the Binding, and the value is left as it was.
+func Bind(eventEnv vugu.EventEnv, s Storage, key string, ptr interface{}) (*Binding, error) {
+
+	b := &Binding{eventEnv: eventEnv, storage: s, key: key, ptr: ptr}
+
+	var err error
+	if str, ok := s.GetItem(key); ok {
+		err = vjson.Unmarshal([]byte(str), ptr)
+		if err == nil {
+			b.saved = str
+		}
+	}
+
+	b.stop = s.Watch(b.changed)
+	return b, err
+}
+
+// Save stores the value, unless it is the same as was last saved or restored.
+func (b *Binding) Save() error {
+	data, err := vjson.Marshal(b.ptr)
+	if err != nil {
+		return err
+	}
+	str := string(data)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if str == b.saved {
+		return nil
+	}
+	err = b.storage.SetItem(b.key, str)
+	if err != nil {
+		return err
+	}
+	b.saved = str
+	return nil
+}
+
+// Remove removes the stored value, leaving the value itself as is.
+func (b *Binding) Remove() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.storage.RemoveItem(b.key)
+	b.saved = ""
+}
+
+// Close stops applying changes made in other tabs.
+func (b *Binding) Close() {
+	b.mu.Lock()
+	stop := b.stop
+	b.stop = nil
+	b.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
+
+// changed is called by Watch, it applies a change to the key made elsewhere to the value.
+// A removed value is set to its zero value.
+func (b *Binding) changed(key, value string, ok bool) {
+	if key != b.key && key != "" {
+		return
+	}
+
+	if b.eventEnv != nil {
+		b.eventEnv.Lock()
+		defer b.eventEnv.UnlockRender()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stop == nil {
+		return
+	}
+
+	// unmarshal into the zero value, so fields missing from the new value do not keep their old ones
+	v := reflect.ValueOf(b.ptr).Elem()
+	old := reflect.New(v.Type()).Elem()
+	old.Set(v)
+	v.Set(reflect.Zero(v.Type()))
+	if !ok {
+		b.saved = ""
+		return
+	}
+	if err := vjson.Unmarshal([]byte(value), b.ptr); err != nil {
+		v.Set(old)
+		return
+	}
+	b.saved = value
+}
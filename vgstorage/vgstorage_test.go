@@ -0,0 +1,89 @@
+package vgstorage
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+type prefs struct {
+	Theme string `json:"theme"`
+	Size  int    `json:"size"`
+}
+
+func TestBinding(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var mu sync.RWMutex
+	renderCh := make(chan bool, 1)
+	ee := vugu.NewEventEnvImpl(&mu, renderCh)
+
+	m := NewMemory()
+	assert.NoError(m.SetItem("prefs", `{"theme":"dark","size":2}`))
+
+	// restored on Bind
+	var p prefs
+	b, err := Bind(ee, m, "prefs", &p)
+	assert.NoError(err)
+	assert.Equal(prefs{Theme: "dark", Size: 2}, p)
+
+	// only saved when changed
+	assert.NoError(m.SetItem("prefs", "untouched"))
+	assert.NoError(b.Save())
+	v, _ := m.GetItem("prefs")
+	assert.Equal("untouched", v)
+	p.Size = 3
+	assert.NoError(b.Save())
+	v, _ = m.GetItem("prefs")
+	assert.Equal(`{"theme":"dark","size":3}`, v)
+
+	// changes from another tab are applied and a render requested, missing fields are zeroed
+	m.Change("prefs", `{"size":4}`, true)
+	assert.Equal(prefs{Size: 4}, p)
+	assert.True(<-renderCh)
+	m.Change("other", `{}`, true)
+	assert.Len(renderCh, 0)
+	m.Change("prefs", `not json`, true)
+	assert.Equal(prefs{Size: 4}, p)
+	<-renderCh
+	m.Change("prefs", "", false)
+	assert.Equal(prefs{}, p)
+	<-renderCh
+
+	// but not after Close
+	b.Close()
+	m.Change("prefs", `{"size":5}`, true)
+	assert.Equal(prefs{}, p)
+
+	// an unreadable value is reported and the value left as is
+	p = prefs{Size: 1}
+	_, err = Bind(nil, m, "bad", &p)
+	assert.NoError(err)
+	assert.NoError(m.SetItem("bad", "{"))
+	_, err = Bind(nil, m, "bad", &p)
+	assert.Error(err)
+	assert.Equal(prefs{Size: 1}, p)
+}
+
+func TestLoadSave(t *testing.T) {
+
+	assert := assert.New(t)
+
+	m := NewMemory()
+	var v []int
+	ok, err := Load(m, "list", &v)
+	assert.False(ok)
+	assert.NoError(err)
+
+	assert.NoError(Save(m, "list", []int{1, 2}))
+	ok, err = Load(m, "list", &v)
+	assert.True(ok)
+	assert.NoError(err)
+	assert.Equal([]int{1, 2}, v)
+
+	assert.Error(Save(m, "fn", func() {}))
+}
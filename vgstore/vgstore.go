@@ -0,0 +1,177 @@
+/*
+Package vgstore holds application state shared between components in one place, changed only by
+dispatching actions, with components subscribing to the parts of it they show and being re-rendered
+when those change.  This replaces shared structs which each component has to lock and request renders
+for on its own.
+
+A Store is created with the initial state and a Reducer, which returns the new state for an action.
+The reducer must not modify the state it is passed, but return a new one (sharing what did not change),
+so subscribers can tell what changed:
+
+	type State struct {
+		Items []string
+		User  string
+	}
+
+	type AddItem struct{ Item string }
+
+	var store = vgstore.New(State{}, func(state, action interface{}) interface{} {
+		s := state.(State)
+		switch a := action.(type) {
+		case AddItem:
+			s.Items = append(s.Items[:len(s.Items):len(s.Items)], a.Item)
+		}
+		return s
+	})
+
+Components subscribe in Init and read the current value with Value, and close the subscription in Destroy:
+
+	func (c *ItemList) Init(ctx vugu.InitCtx) {
+		c.items = store.Subscribe(ctx.EventEnv(), func(state interface{}) interface{} {
+			return state.(State).Items
+		}, nil)
+	}
+
+	func (c *ItemList) Destroy() {
+		c.items.Close()
+	}
+
+Dispatch can be called from event handlers and from other goroutines alike.  When the value selected by
+a subscription changes, its callback (if any) is called with the EventEnv write lock held, and a render
+is requested after, the same as for DOM event handlers.  As event handlers already hold the lock this
+happens in a separate goroutine, once the handler returns.
+*/
+package vgstore
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/vugu/vugu"
+)
+
+// Reducer returns the state after action is applied to state.  It must not modify state, or call
+// Dispatch or State on the store.
+type Reducer func(state, action interface{}) interface{}
+
+// Selector returns the part of the state a subscription is interested in.
+type Selector func(state interface{}) interface{}
+
+// Store holds state which is changed by dispatching actions, see the package documentation.
+type Store struct {
+	mu      sync.Mutex
+	state   interface{}
+	reducer Reducer
+	subs    map[*Subscription]struct{}
+}
+
+// New returns a Store with the initial state, changed by reducer.
+func New(state interface{}, reducer Reducer) *Store {
+	return &Store{
+		state:   state,
+		reducer: reducer,
+		subs:    make(map[*Subscription]struct{}),
+	}
+}
+
+// State returns the current state.
+func (s *Store) State() interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Dispatch applies action to the state and notifies the subscriptions whose selected value changed.
+func (s *Store) Dispatch(action interface{}) {
+
+	s.mu.Lock()
+	s.state = s.reducer(s.state, action)
+	var direct []*Subscription
+	for sub := range s.subs {
+		v := sub.selector(s.state)
+		if reflect.DeepEqual(v, sub.value) {
+			continue
+		}
+		sub.value = v
+		if sub.eventEnv == nil {
+			direct = append(direct, sub)
+		} else if !sub.pending {
+			sub.pending = true
+			go sub.deliver()
+		}
+	}
+	s.mu.Unlock()
+
+	for _, sub := range direct {
+		if sub.fn != nil {
+			sub.fn(sub.Value())
+		}
+	}
+}
+
+// Subscribe returns a subscription to the value selector returns for the state, which is compared with
+// reflect.DeepEqual after each action to find out if it changed.  If it did fn, which may be nil, is
+// called with the new value, with the eventEnv write lock held, and a render is requested.  If eventEnv
+// is nil fn is called by Dispatch directly without locking or rendering.
+func (s *Store) Subscribe(eventEnv vugu.EventEnv, selector Selector, fn func(value interface{})) *Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub := &Subscription{
+		store:    s,
+		eventEnv: eventEnv,
+		selector: selector,
+		fn:       fn,
+		value:    selector(s.state),
+	}
+	s.subs[sub] = struct{}{}
+	return sub
+}
+
+// Subscription is a component's interest in part of a Store's state, see Store.Subscribe.
+type Subscription struct {
+	store    *Store
+	eventEnv vugu.EventEnv
+	selector Selector
+	fn       func(value interface{})
+
+	value   interface{} // most recently selected value
+	pending bool        // a goroutine is on its way to deliver value
+	closed  bool
+}
+
+// Value returns the most recently selected value.
+func (sub *Subscription) Value() interface{} {
+	sub.store.mu.Lock()
+	defer sub.store.mu.Unlock()
+	return sub.value
+}
+
+// Close stops the subscription, after which its callback is not called and no more renders are requested
+// for it.  It is safe to call more than once.
+func (sub *Subscription) Close() {
+	sub.store.mu.Lock()
+	defer sub.store.mu.Unlock()
+	sub.closed = true
+	delete(sub.store.subs, sub)
+}
+
+// deliver calls fn with the latest value with the lock held, and requests a render afterward.  Changes
+// made by further dispatches before it gets the lock are delivered together.
+func (sub *Subscription) deliver() {
+
+	sub.eventEnv.Lock()
+
+	sub.store.mu.Lock()
+	sub.pending = false
+	v, closed := sub.value, sub.closed
+	sub.store.mu.Unlock()
+
+	if closed {
+		sub.eventEnv.UnlockOnly()
+		return
+	}
+	defer sub.eventEnv.UnlockRender()
+	if sub.fn != nil {
+		sub.fn(v)
+	}
+}
@@ -0,0 +1,114 @@
+package vgstore
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+type testState struct {
+	Count int
+	Names []string
+}
+
+type incr struct{}
+
+type addName struct{ Name string }
+
+func testReducer(state, action interface{}) interface{} {
+	s := state.(testState)
+	switch a := action.(type) {
+	case incr:
+		s.Count++
+	case addName:
+		s.Names = append(s.Names[:len(s.Names):len(s.Names)], a.Name)
+	}
+	return s
+}
+
+func TestStore(t *testing.T) {
+
+	assert := assert.New(t)
+
+	s := New(testState{}, testReducer)
+
+	var counts []interface{}
+	countSub := s.Subscribe(nil, func(state interface{}) interface{} { return state.(testState).Count }, func(v interface{}) {
+		counts = append(counts, v)
+	})
+	var names []interface{}
+	s.Subscribe(nil, func(state interface{}) interface{} { return state.(testState).Names }, func(v interface{}) {
+		names = append(names, v)
+	})
+	assert.Equal(0, countSub.Value())
+
+	s.Dispatch(incr{})
+	s.Dispatch(addName{"a"})
+	s.Dispatch(incr{})
+	s.Dispatch("unknown") // no change
+	assert.Equal([]interface{}{1, 2}, counts)
+	assert.Equal([]interface{}{[]string{"a"}}, names)
+	assert.Equal(testState{Count: 2, Names: []string{"a"}}, s.State())
+
+	countSub.Close()
+	countSub.Close()
+	s.Dispatch(incr{})
+	assert.Equal([]interface{}{1, 2}, counts)
+	assert.Equal(2, countSub.Value())
+}
+
+func TestStoreRender(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var mu sync.RWMutex
+	renderCh := make(chan bool, 1)
+	ee := vugu.NewEventEnvImpl(&mu, renderCh)
+
+	s := New(testState{}, testReducer)
+
+	gotCh := make(chan interface{}, 8)
+	sub := s.Subscribe(ee, func(state interface{}) interface{} { return state.(testState).Count }, func(v interface{}) {
+		gotCh <- v
+	})
+
+	// dispatched from an event handler, which holds the lock
+	ee.Lock()
+	s.Dispatch(incr{})
+	s.Dispatch(incr{})
+	select {
+	case <-gotCh:
+		t.Fatal("delivered while the lock is held")
+	case <-time.After(10 * time.Millisecond):
+	}
+	ee.UnlockOnly()
+
+	// both changes are delivered at once
+	assert.Equal(2, <-gotCh)
+	assert.True(<-renderCh)
+
+	// an unrelated change does not render
+	s.Dispatch(addName{"a"})
+	select {
+	case <-renderCh:
+		t.Fatal("unexpected render")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// nor does a closed subscription
+	ee.Lock()
+	s.Dispatch(incr{})
+	sub.Close()
+	ee.UnlockOnly()
+	select {
+	case <-renderCh:
+		t.Fatal("unexpected render")
+	case <-gotCh:
+		t.Fatal("unexpected delivery")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
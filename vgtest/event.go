@@ -0,0 +1,186 @@
+package vgtest
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/vugu/vugu"
+	"github.com/vugu/vugu/js"
+)
+
+// event implements vugu.DOMEvent without a browser.
+type event struct {
+	summary  map[string]interface{}
+	eventEnv vugu.EventEnv
+
+	defaultPrevented bool
+	stopped          bool
+}
+
+var _ vugu.DOMEvent = &event{}
+
+func (e *event) Prop(keys ...string) interface{} {
+	var ret interface{} = e.summary
+	for _, k := range keys {
+		m, ok := ret.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		ret = m[k]
+	}
+	return ret
+}
+
+func (e *event) PropString(keys ...string) string {
+	v, _ := e.Prop(keys...).(string)
+	return v
+}
+
+func (e *event) PropFloat64(keys ...string) float64 {
+	v, _ := e.Prop(keys...).(float64)
+	return v
+}
+
+func (e *event) PropBool(keys ...string) bool {
+	v, _ := e.Prop(keys...).(bool)
+	return v
+}
+
+func (e *event) EventSummary() map[string]interface{} { return e.summary }
+
+// there are no JS objects in tests, these return undefined
+func (e *event) JSEvent() js.Value              { return js.Undefined() }
+func (e *event) JSEventTarget() js.Value        { return js.Undefined() }
+func (e *event) JSEventCurrentTarget() js.Value { return js.Undefined() }
+
+func (e *event) EventEnv() vugu.EventEnv { return e.eventEnv }
+
+func (e *event) PreventDefault()  { e.defaultPrevented = true }
+func (e *event) StopPropagation() { e.stopped = true }
+
+// eventEnv implements vugu.EventEnv, recording requests to render so WaitRender can wait for them.
+type eventEnv struct {
+	*vugu.EventEnvImpl
+	rwmu     sync.RWMutex
+	renderCh chan bool
+}
+
+func newEventEnv() *eventEnv {
+	ee := &eventEnv{renderCh: make(chan bool, 1)}
+	ee.EventEnvImpl = vugu.NewEventEnvImpl(&ee.rwmu, ee.renderCh)
+	return ee
+}
+
+// modifiersMatch returns true if the event satisfies the conditions in mods, as the renderer checks them in the browser.
+func modifiersMatch(mods vugu.DOMEventModifiers, e *event, target, current *Node) bool {
+
+	if mods&vugu.DOMEventModSelf != 0 && target != current {
+		return false
+	}
+	if (mods&vugu.DOMEventModCtrl != 0 && !e.PropBool("ctrlKey")) ||
+		(mods&vugu.DOMEventModShift != 0 && !e.PropBool("shiftKey")) ||
+		(mods&vugu.DOMEventModAlt != 0 && !e.PropBool("altKey")) ||
+		(mods&vugu.DOMEventModMeta != 0 && !e.PropBool("metaKey")) {
+		return false
+	}
+
+	if k, ok := e.Prop("key").(string); ok {
+		keys := map[vugu.DOMEventModifiers][]string{
+			vugu.DOMEventModEnter:  {"Enter"},
+			vugu.DOMEventModTab:    {"Tab"},
+			vugu.DOMEventModEsc:    {"Escape", "Esc"},
+			vugu.DOMEventModSpace:  {" ", "Spacebar"},
+			vugu.DOMEventModDelete: {"Delete", "Backspace"},
+			vugu.DOMEventModUp:     {"ArrowUp"},
+			vugu.DOMEventModDown:   {"ArrowDown"},
+			vugu.DOMEventModLeft:   {"ArrowLeft"},
+			vugu.DOMEventModRight:  {"ArrowRight"},
+		}
+		filtered := false
+		for m, names := range keys {
+			if mods&m == 0 {
+				continue
+			}
+			filtered = true
+			for _, name := range names {
+				if k == name {
+					return true
+				}
+			}
+		}
+		return !filtered
+	} else if b, ok := e.Prop("button").(float64); ok {
+		buttons := map[vugu.DOMEventModifiers]float64{
+			vugu.DOMEventModLeft:   0,
+			vugu.DOMEventModMiddle: 1,
+			vugu.DOMEventModRight:  2,
+		}
+		filtered := false
+		for m, num := range buttons {
+			if mods&m == 0 {
+				continue
+			}
+			filtered = true
+			if b == num {
+				return true
+			}
+		}
+		return !filtered
+	}
+
+	return true
+}
+
+// targetSummary returns the properties of n included in an event summary as "target".
+func targetSummary(n *Node) map[string]interface{} {
+	ret := make(map[string]interface{}, len(n.Attr)+4)
+	for _, a := range n.Attr {
+		ret[a.Key] = a.Val
+	}
+	if n.Type == vugu.ElementNode {
+		ret["tagName"] = strings.ToUpper(n.Data)
+	}
+	ret["textContent"] = n.Text()
+	_, checked := n.AttrValue("checked")
+	ret["checked"] = checked
+	if _, ok := ret["value"]; !ok {
+		ret["value"] = ""
+	}
+	for k, v := range n.Props {
+		ret[k] = v
+	}
+	return ret
+}
+
+// action returns the action of the nearest node from target up to n with a data-vg-action attribute
+// in the format read by vugu.EventAction, or nil if there is none.
+func action(target, n *Node) map[string]interface{} {
+	for a := target; a != nil; a = a.Parent {
+		if name, ok := a.AttrValue("data-vg-action"); ok {
+			value, _ := a.AttrValue("data-vg-value")
+			return map[string]interface{}{"name": name, "value": value}
+		}
+		if a == n {
+			break
+		}
+	}
+	return nil
+}
+
+// dataset returns the data-* attributes of n as the browser's dataset property does, e.g. data-item-id as itemId.
+func dataset(n *Node) map[string]interface{} {
+	ret := make(map[string]interface{})
+	for _, a := range n.Attr {
+		if !strings.HasPrefix(a.Key, "data-") {
+			continue
+		}
+		parts := strings.Split(a.Key[5:], "-")
+		for i := 1; i < len(parts); i++ {
+			if parts[i] != "" {
+				parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+			}
+		}
+		ret[strings.Join(parts, "")] = a.Val
+	}
+	return ret
+}
@@ -0,0 +1,158 @@
+package vgtest
+
+import (
+	"bytes"
+	"html"
+	"strings"
+
+	"github.com/vugu/vugu"
+)
+
+// Node is an element, text or comment node in the output of a Renderer.  The root returned
+// by Renderer.Root is a DocumentNode containing the root component's output.
+type Node struct {
+	Type     vugu.VGNodeType
+	Data     string                 // tag name for elements, content for text and comments
+	Attr     []vugu.VGAttribute     // attributes, including class and style from :class and :style maps and vg-show
+	Props    map[string]interface{} // JS properties set on the element
+	Parent   *Node
+	Children []*Node
+
+	handlers []vugu.DOMEventHandlerSpec
+}
+
+// AttrValue returns the value of the attribute with the specified key and whether it exists.
+func (n *Node) AttrValue(key string) (string, bool) {
+	if n == nil {
+		return "", false
+	}
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// ID returns the id attribute.
+func (n *Node) ID() string {
+	v, _ := n.AttrValue("id")
+	return v
+}
+
+// HasClass returns true if the class attribute contains class.
+func (n *Node) HasClass(class string) bool {
+	v, _ := n.AttrValue("class")
+	for _, c := range strings.Fields(v) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// Text returns the text content of n and its descendants, like textContent in the browser.
+func (n *Node) Text() string {
+	if n == nil {
+		return ""
+	}
+	if n.Type == vugu.TextNode {
+		return n.Data
+	}
+	var buf bytes.Buffer
+	for _, c := range n.Children {
+		if c.Type != vugu.CommentNode {
+			buf.WriteString(c.Text())
+		}
+	}
+	return buf.String()
+}
+
+// HTML returns n and its descendants as HTML, which is handy in test failure messages.
+func (n *Node) HTML() string {
+	var buf bytes.Buffer
+	n.writeHTML(&buf)
+	return buf.String()
+}
+
+func (n *Node) writeHTML(buf *bytes.Buffer) {
+	if n == nil {
+		return
+	}
+	switch n.Type {
+	case vugu.TextNode:
+		buf.WriteString(html.EscapeString(n.Data))
+		return
+	case vugu.CommentNode:
+		buf.WriteString("<!--" + n.Data + "-->")
+		return
+	case vugu.ElementNode:
+		buf.WriteString("<" + n.Data)
+		for _, a := range n.Attr {
+			buf.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+		}
+		buf.WriteString(">")
+	}
+	for _, c := range n.Children {
+		c.writeHTML(buf)
+	}
+	if n.Type == vugu.ElementNode {
+		buf.WriteString("</" + n.Data + ">")
+	}
+}
+
+// Find returns the first element under n (including n) for which f returns true, in document order, or nil.
+func (n *Node) Find(f func(n *Node) bool) *Node {
+	if n == nil {
+		return nil
+	}
+	if n.Type == vugu.ElementNode && f(n) {
+		return n
+	}
+	for _, c := range n.Children {
+		if ret := c.Find(f); ret != nil {
+			return ret
+		}
+	}
+	return nil
+}
+
+// FindAll returns all the elements under n (including n) for which f returns true, in document order.
+func (n *Node) FindAll(f func(n *Node) bool) []*Node {
+	var ret []*Node
+	n.Find(func(n *Node) bool {
+		if f(n) {
+			ret = append(ret, n)
+		}
+		return false
+	})
+	return ret
+}
+
+// FindByID returns the element with the specified id, or nil.
+func (n *Node) FindByID(id string) *Node {
+	return n.Find(func(n *Node) bool { return n.ID() == id })
+}
+
+// FindByTag returns the elements with the specified tag name.
+func (n *Node) FindByTag(tag string) []*Node {
+	return n.FindAll(func(n *Node) bool { return n.Data == tag })
+}
+
+// FindByClass returns the elements with the specified class.
+func (n *Node) FindByClass(class string) []*Node {
+	return n.FindAll(func(n *Node) bool { return n.HasClass(class) })
+}
+
+// FindByText returns the first element whose text, with leading and trailing space removed, is text.
+// The innermost element is returned, e.g. the button rather than the form it is in.
+func (n *Node) FindByText(text string) *Node {
+	var ret *Node
+	n.Find(func(n *Node) bool {
+		if strings.TrimSpace(n.Text()) == text {
+			ret = n // keep going to find a descendant with the same text
+		}
+		return false
+	})
+	return ret
+}
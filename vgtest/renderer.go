@@ -0,0 +1,525 @@
+package vgtest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vugu/html"
+	"github.com/vugu/html/atom"
+	"github.com/vugu/vjson"
+	"github.com/vugu/vugu"
+)
+
+// ErrNoHandler is returned by Dispatch when no handler was called for an event.
+var ErrNoHandler = errors.New("no handler for event")
+
+// Renderer builds a component tree and keeps its output as Nodes, so tests can inspect it
+// and dispatch events to the handlers on it.  A Renderer is not safe for concurrent use,
+// but components may use the EventEnv from goroutines as usual, see WaitRender.
+type Renderer struct {
+	root     vugu.Builder
+	eventEnv *eventEnv
+	buildEnv *vugu.BuildEnv
+
+	doc     *Node
+	globals []*Node // nodes with handlers on "window" or "document"
+
+	lifecycleMap map[interface{}]uint64
+	passNum      uint64
+}
+
+// New returns a Renderer for root and renders it for the first time.
+func New(root vugu.Builder) (*Renderer, error) {
+	ee := newEventEnv()
+	buildEnv, err := vugu.NewBuildEnv(ee)
+	if err != nil {
+		return nil, err
+	}
+	r := &Renderer{
+		root:         root,
+		eventEnv:     ee,
+		buildEnv:     buildEnv,
+		lifecycleMap: make(map[interface{}]uint64),
+	}
+	return r, r.Render()
+}
+
+// EventEnv returns the EventEnv passed to components.
+func (r *Renderer) EventEnv() vugu.EventEnv {
+	return r.eventEnv
+}
+
+// Root returns a DocumentNode containing the output of the root component from the last render.
+// Nodes are rebuilt on each render, so do not keep them across events.
+func (r *Renderer) Root() *Node {
+	return r.doc
+}
+
+// FindByID calls FindByID on Root.
+func (r *Renderer) FindByID(id string) *Node { return r.doc.FindByID(id) }
+
+// FindByTag calls FindByTag on Root.
+func (r *Renderer) FindByTag(tag string) []*Node { return r.doc.FindByTag(tag) }
+
+// FindByClass calls FindByClass on Root.
+func (r *Renderer) FindByClass(class string) []*Node { return r.doc.FindByClass(class) }
+
+// FindByText calls FindByText on Root.
+func (r *Renderer) FindByText(text string) *Node { return r.doc.FindByText(text) }
+
+// HTML returns the output of the last render as HTML.
+func (r *Renderer) HTML() string { return r.doc.HTML() }
+
+// Render builds the component tree and updates Root, and calls the Rendered lifecycle callback on components.
+// This is done automatically after Dispatch.
+func (r *Renderer) Render() error {
+
+	r.eventEnv.RLock()
+	br := r.buildEnv.RunBuild(r.root)
+	r.eventEnv.RUnlock()
+
+	if br.Out == nil {
+		return fmt.Errorf("vgtest: root component returned nil BuildOut")
+	}
+
+	doc := &Node{Type: vugu.DocumentNode}
+	var comps []interface{}
+	r.globals = nil
+	for _, vgn := range br.Out.Out {
+		nl, err := r.convert(br, vgn, &comps)
+		if err != nil {
+			return err
+		}
+		appendChildren(doc, nl)
+	}
+	r.doc = doc
+
+	// handle Rendered lifecycle callback, in the same way as domrender
+	r.passNum++
+	for _, c := range append([]interface{}{r.root}, comps...) {
+		_, ok := r.lifecycleMap[c]
+		r.lifecycleMap[c] = r.passNum
+		invokeRendered(c, &renderedCtx{eventEnv: r.eventEnv, first: !ok})
+	}
+	for k, passNum := range r.lifecycleMap {
+		if passNum != r.passNum {
+			delete(r.lifecycleMap, k)
+		}
+	}
+
+	return nil
+}
+
+// convert returns the Nodes for vgn, expanding components and templates.
+func (r *Renderer) convert(br *vugu.BuildResults, vgn *vugu.VGNode, comps *[]interface{}) ([]*Node, error) {
+
+	if vgn.Component != nil && br == nil {
+		// not expanded, see SnapshotVGNode
+		return []*Node{{Type: vugu.ElementNode, Data: "vg-component", Attr: []vugu.VGAttribute{{Key: "type", Val: fmt.Sprintf("%T", vgn.Component)}}}}, nil
+	}
+
+	if vgn.Component != nil {
+		*comps = append(*comps, vgn.Component)
+		cbo := br.ResultFor(vgn.Component)
+		if cbo == nil {
+			return nil, fmt.Errorf("vgtest: no build output for component %T", vgn.Component)
+		}
+		var ret []*Node
+		for _, c := range cbo.Out {
+			nl, err := r.convert(br, c, comps)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, nl...)
+		}
+		return ret, nil
+	}
+
+	if vgn.IsTemplate() {
+		var ret []*Node
+		for c := vgn.FirstChild; c != nil; c = c.NextSibling {
+			nl, err := r.convert(br, c, comps)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, nl...)
+		}
+		return ret, nil
+	}
+
+	n := &Node{Type: vgn.Type, Data: vgn.Data}
+	for _, a := range vgn.Attr {
+		if a.Key == "class" && vgn.ClassMap != nil {
+			continue // included in ClassList below
+		}
+		if !a.Present() {
+			continue // e.g. disabled="false"
+		}
+		n.Attr = append(n.Attr, a)
+	}
+	if vgn.ClassMap != nil {
+		if cl := vgn.ClassList(); len(cl) > 0 {
+			n.Attr = append(n.Attr, vugu.VGAttribute{Key: "class", Val: strings.Join(cl, " ")})
+		}
+	}
+	if s := vgn.StyleMap.String(); s != "" {
+		n.Attr = appendStyleAttr(n.Attr, s)
+	}
+	if vgn.Hidden {
+		n.Attr = appendStyleAttr(n.Attr, "display:none")
+	}
+
+	for _, p := range vgn.Prop {
+		var v interface{}
+		if err := vjson.Unmarshal(p.JSONVal, &v); err != nil {
+			return nil, fmt.Errorf("vgtest: property %q of <%s>: %w", p.Key, vgn.Data, err)
+		}
+		if n.Props == nil {
+			n.Props = make(map[string]interface{}, len(vgn.Prop))
+		}
+		n.Props[p.Key] = v
+	}
+
+	for _, h := range vgn.DOMEventHandlerSpecList {
+		n.handlers = append(n.handlers, h)
+		if h.Global != "" {
+			r.globals = append(r.globals, n)
+		}
+	}
+
+	if vgn.InnerHTML != nil {
+		context := &html.Node{Type: html.ElementNode, Data: vgn.Data, DataAtom: atom.Lookup([]byte(vgn.Data))}
+		nl, err := html.ParseFragment(strings.NewReader(*vgn.InnerHTML), context)
+		if err != nil {
+			return nil, err
+		}
+		for _, hn := range nl {
+			appendChildren(n, []*Node{convertHTML(hn)})
+		}
+		return []*Node{n}, nil
+	}
+
+	for c := vgn.FirstChild; c != nil; c = c.NextSibling {
+		nl, err := r.convert(br, c, comps)
+		if err != nil {
+			return nil, err
+		}
+		appendChildren(n, nl)
+	}
+
+	return []*Node{n}, nil
+}
+
+// convertHTML returns the Node for content from InnerHTML.
+func convertHTML(hn *html.Node) *Node {
+	n := &Node{Type: vugu.VGNodeType(hn.Type), Data: hn.Data} // type numbers are the same
+	for _, a := range hn.Attr {
+		n.Attr = append(n.Attr, vugu.VGAttribute{Namespace: a.Namespace, Key: a.Key, Val: a.Val})
+	}
+	for c := hn.FirstChild; c != nil; c = c.NextSibling {
+		appendChildren(n, []*Node{convertHTML(c)})
+	}
+	return n
+}
+
+func appendChildren(parent *Node, children []*Node) {
+	for _, c := range children {
+		c.Parent = parent
+		parent.Children = append(parent.Children, c)
+	}
+}
+
+// appendStyleAttr adds css to the style attribute in attrs, creating it if needed.
+func appendStyleAttr(attrs []vugu.VGAttribute, css string) []vugu.VGAttribute {
+	for i := range attrs {
+		if attrs[i].Key == "style" {
+			v := strings.TrimSpace(attrs[i].Val)
+			if v != "" && !strings.HasSuffix(v, ";") {
+				v += ";"
+			}
+			attrs[i].Val = v + css
+			return attrs
+		}
+	}
+	return append(attrs, vugu.VGAttribute{Key: "style", Val: css})
+}
+
+// WaitRender waits for a component to call UnlockRender on the EventEnv, e.g. from a goroutine
+// after loading data, and then renders.  It returns an error if this does not happen within timeout.
+// Renders done by Dispatch do not count.
+func (r *Renderer) WaitRender(timeout time.Duration) error {
+	select {
+	case <-r.eventEnv.renderCh:
+		return r.Render()
+	case <-time.After(timeout):
+		return fmt.Errorf("vgtest: no render requested within %v", timeout)
+	}
+}
+
+// Dispatch sends an event of eventType to target, calling the handlers for it on target and its ancestors
+// as the browser would, first those for the capture phase from the top down and then the others from target
+// up.  Modifiers (e.g. @keydown.enter) are applied.  The event summary contains "type", "target" with
+// target's attributes, textContent and other properties, and the contents of summary, which can be
+// used for things such as "key" or "clientX".  The components are rendered again afterwards.
+// ErrNoHandler is returned if no handler was called, a panic in a handler is returned as an error.
+func (r *Renderer) Dispatch(target *Node, eventType string, summary map[string]interface{}) error {
+	if target == nil {
+		return fmt.Errorf("vgtest: cannot dispatch %q to nil node", eventType)
+	}
+
+	var path []*Node // target first
+	for n := target; n != nil; n = n.Parent {
+		path = append(path, n)
+	}
+
+	var calls []handlerCall
+	for i := len(path) - 1; i >= 0; i-- {
+		for _, h := range path[i].handlers {
+			if h.EventType == eventType && h.Capture && h.Global == "" {
+				calls = append(calls, handlerCall{path[i], h})
+			}
+		}
+	}
+	for _, n := range path {
+		for _, h := range n.handlers {
+			if h.EventType == eventType && !h.Capture && h.Global == "" {
+				calls = append(calls, handlerCall{n, h})
+			}
+		}
+	}
+
+	base := map[string]interface{}{"type": eventType, "target": targetSummary(target)}
+	for k, v := range summary {
+		base[k] = v
+	}
+	return r.dispatch(target, base, calls)
+}
+
+// DispatchGlobal sends an event to the handlers listening on global, "window" or "document"
+// (e.g. with @keydown.document), otherwise it works like Dispatch.
+func (r *Renderer) DispatchGlobal(global, eventType string, summary map[string]interface{}) error {
+	base := map[string]interface{}{"type": eventType}
+	for k, v := range summary {
+		base[k] = v
+	}
+	var calls []handlerCall
+	for _, n := range r.globals {
+		for _, h := range n.handlers {
+			if h.EventType == eventType && h.Global == global {
+				calls = append(calls, handlerCall{n, h})
+			}
+		}
+	}
+	return r.dispatch(nil, base, calls)
+}
+
+// handlerCall is a handler and the node it is on.
+type handlerCall struct {
+	n *Node
+	h vugu.DOMEventHandlerSpec
+}
+
+// dispatch calls each handler in calls whose modifiers match, until one stops propagation, and renders.
+func (r *Renderer) dispatch(target *Node, summary map[string]interface{}, calls []handlerCall) (err error) {
+
+	called := false
+	e := &event{summary: summary, eventEnv: r.eventEnv}
+
+	r.eventEnv.Lock()
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("vgtest: panic in %q handler: %v", summary["type"], p)
+			}
+		}()
+		for _, c := range calls {
+			n, h := c.n, c.h
+			if !modifiersMatch(h.Modifiers, e, target, n) {
+				continue
+			}
+			s := summary
+			if h.Modifiers&(vugu.DOMEventModDataset|vugu.DOMEventModForm|vugu.DOMEventModAction) != 0 {
+				s = make(map[string]interface{}, len(summary)+3)
+				for k, v := range summary {
+					s[k] = v
+				}
+				if h.Modifiers&vugu.DOMEventModDataset != 0 {
+					s["dataset"] = dataset(n)
+				}
+				if h.Modifiers&vugu.DOMEventModForm != 0 {
+					f := formValues(n)
+					if f == nil && target != nil {
+						// as in the browser, the form of the target if the listener is not in one
+						f = formValues(target)
+					}
+					s["form"] = f
+				}
+				if h.Modifiers&vugu.DOMEventModAction != 0 {
+					if a := action(target, n); a != nil {
+						s["action"] = a
+					}
+				}
+			}
+			e.summary = s
+			if h.Modifiers&vugu.DOMEventModPrevent != 0 {
+				e.PreventDefault()
+			}
+			if h.Modifiers&vugu.DOMEventModStop != 0 {
+				e.StopPropagation()
+			}
+			called = true
+			h.Func(e)
+			if e.stopped {
+				return
+			}
+		}
+	}()
+	r.eventEnv.UnlockOnly()
+
+	// drain any render requested by the handler, as we are rendering now anyway
+	select {
+	case <-r.eventEnv.renderCh:
+	default:
+	}
+
+	if err != nil {
+		return err
+	}
+	if !called {
+		return ErrNoHandler
+	}
+	return r.Render()
+}
+
+// formValues returns the fields of the form containing n (or n itself) in the format read by vugu.FormValues.
+func formValues(n *Node) map[string]interface{} {
+	for n != nil && n.Data != "form" {
+		n = n.Parent
+	}
+	if n == nil {
+		return nil
+	}
+	ret := make(map[string]interface{})
+	add := func(name, value string) {
+		l, _ := ret[name].([]interface{})
+		ret[name] = append(l, value)
+	}
+	n.Find(func(f *Node) bool {
+		name, _ := f.AttrValue("name")
+		if name == "" {
+			return false
+		}
+		value := fieldValue(f)
+		switch f.Data {
+		case "input":
+			typ, _ := f.AttrValue("type")
+			if (typ == "checkbox" || typ == "radio") && !fieldChecked(f) {
+				return false
+			}
+			if typ == "checkbox" || typ == "radio" {
+				if _, ok := f.AttrValue("value"); !ok {
+					value = "on"
+				}
+			}
+			add(name, value)
+		case "textarea":
+			if _, ok := f.Props["value"]; !ok {
+				value = f.Text()
+			}
+			add(name, value)
+		case "select":
+			if _, ok := f.Props["value"]; ok {
+				add(name, value)
+				return false
+			}
+			for _, o := range f.FindByTag("option") {
+				if _, ok := o.AttrValue("selected"); ok {
+					v, ok := o.AttrValue("value")
+					if !ok {
+						v = o.Text()
+					}
+					add(name, v)
+				}
+			}
+		}
+		return false
+	})
+	return ret
+}
+
+// fieldValue returns the value property of n if set, otherwise the value attribute.
+func fieldValue(n *Node) string {
+	if v, ok := n.Props["value"].(string); ok {
+		return v
+	}
+	v, _ := n.AttrValue("value")
+	return v
+}
+
+// fieldChecked returns the checked property of n if set, otherwise whether it has the checked attribute.
+func fieldChecked(n *Node) bool {
+	if v, ok := n.Props["checked"].(bool); ok {
+		return v
+	}
+	_, ok := n.AttrValue("checked")
+	return ok
+}
+
+// Click dispatches a click event to n.
+func (r *Renderer) Click(n *Node) error {
+	return r.Dispatch(n, "click", map[string]interface{}{"button": float64(0)})
+}
+
+// Input sets the value of n, as if the user typed it, and dispatches an input event.
+func (r *Renderer) Input(n *Node, value string) error {
+	return r.setAndDispatch(n, "input", "value", value)
+}
+
+// Change sets the value of n, e.g. a select, and dispatches a change event.
+func (r *Renderer) Change(n *Node, value string) error {
+	return r.setAndDispatch(n, "change", "value", value)
+}
+
+// Check sets whether the checkbox or radio button n is checked and dispatches a change event.
+func (r *Renderer) Check(n *Node, checked bool) error {
+	return r.setAndDispatch(n, "change", "checked", checked)
+}
+
+func (r *Renderer) setAndDispatch(n *Node, eventType, key string, value interface{}) error {
+	if n == nil {
+		return fmt.Errorf("vgtest: cannot dispatch %q to nil node", eventType)
+	}
+	if n.Props == nil {
+		n.Props = make(map[string]interface{})
+	}
+	n.Props[key] = value
+	return r.Dispatch(n, eventType, nil)
+}
+
+type renderedCtx struct {
+	eventEnv vugu.EventEnv
+	first    bool
+}
+
+// EventEnv implements RenderedCtx by returning the EventEnv.
+func (c *renderedCtx) EventEnv() vugu.EventEnv { return c.eventEnv }
+
+// First returns true for the first render and otherwise false.
+func (c *renderedCtx) First() bool { return c.first }
+
+type rendered0 interface {
+	Rendered()
+}
+type rendered1 interface {
+	Rendered(ctx vugu.RenderedCtx)
+}
+
+func invokeRendered(c interface{}, rctx *renderedCtx) {
+	if i, ok := c.(rendered0); ok {
+		i.Rendered()
+	} else if i, ok := c.(rendered1); ok {
+		i.Rendered(rctx)
+	}
+}
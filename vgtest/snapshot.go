@@ -0,0 +1,205 @@
+package vgtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/vugu/vugu"
+)
+
+// Snapshot returns n and its descendants in a stable text form for comparing with golden files.
+// It is HTML-like with one node per line, indented by depth.  Attributes are sorted by name, as are
+// JS properties (written as .name=JSON) and event handlers (written as @type with their modifiers).
+// Text is trimmed and whitespace-only text is left out, so formatting in templates does not matter.
+func (n *Node) Snapshot() string {
+	var buf bytes.Buffer
+	if n != nil && n.Type == vugu.DocumentNode {
+		for _, c := range n.Children {
+			c.writeSnapshot(&buf, 0)
+		}
+	} else {
+		n.writeSnapshot(&buf, 0)
+	}
+	return buf.String()
+}
+
+// Snapshot returns the output of the last render as with Node.Snapshot.
+func (r *Renderer) Snapshot() string {
+	return r.doc.Snapshot()
+}
+
+// SnapshotVGNode returns n and its descendants as with Node.Snapshot, without building anything.
+// Components are written as a vg-component element with their type, e.g. <vg-component type="*main.Item">,
+// which makes it suitable for checking the output of a single component's Build.
+func SnapshotVGNode(n *vugu.VGNode) string {
+	var r Renderer
+	nl, err := r.convert(nil, n, new([]interface{}))
+	if err != nil {
+		return "error: " + err.Error() + "\n"
+	}
+	var buf bytes.Buffer
+	for _, c := range nl {
+		c.writeSnapshot(&buf, 0)
+	}
+	return buf.String()
+}
+
+func (n *Node) writeSnapshot(buf *bytes.Buffer, depth int) {
+	if n == nil {
+		return
+	}
+	indent := strings.Repeat("  ", depth)
+
+	switch n.Type {
+	case vugu.TextNode:
+		if t := strings.TrimSpace(n.Data); t != "" {
+			buf.WriteString(indent + escapeText(t) + "\n")
+		}
+		return
+	case vugu.CommentNode:
+		buf.WriteString(indent + "<!--" + n.Data + "-->\n")
+		return
+	case vugu.ElementNode:
+	default:
+		for _, c := range n.Children {
+			c.writeSnapshot(buf, depth)
+		}
+		return
+	}
+
+	var attrs []string
+	for _, a := range n.Attr {
+		key := a.Key
+		if a.Namespace != "" {
+			key = a.Namespace + ":" + key
+		}
+		attrs = append(attrs, fmt.Sprintf("%s=%q", key, a.Val))
+	}
+	for k, v := range n.Props {
+		b, err := json.Marshal(v)
+		if err != nil {
+			b = []byte(fmt.Sprintf("%q", err.Error()))
+		}
+		attrs = append(attrs, fmt.Sprintf(".%s=%s", k, b))
+	}
+	for _, h := range n.handlers {
+		attrs = append(attrs, "@"+handlerName(h))
+	}
+	sort.Strings(attrs)
+
+	buf.WriteString(indent + "<" + n.Data)
+	for _, a := range attrs {
+		buf.WriteString(" " + a)
+	}
+	buf.WriteString(">\n")
+	if voidElements[n.Data] && len(n.Children) == 0 {
+		return
+	}
+	for _, c := range n.Children {
+		c.writeSnapshot(buf, depth+1)
+	}
+	buf.WriteString(indent + "</" + n.Data + ">\n")
+}
+
+// voidElements lists the elements which have no end tag in HTML
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// handlerName returns the event type of h with its modifiers as they would be written in a template.
+func handlerName(h vugu.DOMEventHandlerSpec) string {
+	s := h.EventType
+	if h.Global != "" {
+		s += "." + h.Global
+	}
+	if h.Capture {
+		s += ".capture"
+	}
+	if h.Passive {
+		s += ".passive"
+	}
+	names := []struct {
+		m    vugu.DOMEventModifiers
+		name string
+	}{
+		{vugu.DOMEventModPrevent, "prevent"}, {vugu.DOMEventModStop, "stop"}, {vugu.DOMEventModSelf, "self"},
+		{vugu.DOMEventModCtrl, "ctrl"}, {vugu.DOMEventModShift, "shift"}, {vugu.DOMEventModAlt, "alt"},
+		{vugu.DOMEventModMeta, "meta"}, {vugu.DOMEventModEnter, "enter"}, {vugu.DOMEventModTab, "tab"},
+		{vugu.DOMEventModEsc, "esc"}, {vugu.DOMEventModSpace, "space"}, {vugu.DOMEventModDelete, "delete"},
+		{vugu.DOMEventModUp, "up"}, {vugu.DOMEventModDown, "down"}, {vugu.DOMEventModLeft, "left"},
+		{vugu.DOMEventModRight, "right"}, {vugu.DOMEventModMiddle, "middle"},
+		{vugu.DOMEventModSelection, "selection"}, {vugu.DOMEventModDataset, "dataset"}, {vugu.DOMEventModForm, "form"},
+		{vugu.DOMEventModAction, "action"},
+	}
+	for _, n := range names {
+		if h.Modifiers&n.m != 0 {
+			s += "." + n.name
+		}
+	}
+	return s
+}
+
+// escapeText escapes the characters which would make text look like markup.
+func escapeText(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// UpdateGoldenEnv is the environment variable which makes Golden write the golden files instead of comparing
+// with them, e.g. VGTEST_UPDATE=1 go test ./...
+const UpdateGoldenEnv = "VGTEST_UPDATE"
+
+// Golden compares got with the file testdata/name.golden and reports an error if they differ.
+// If the environment variable in UpdateGoldenEnv is set the file is written instead.
+// It returns true if the check passed.
+func Golden(t testing.TB, name, got string) bool {
+	t.Helper()
+
+	fn := filepath.Join("testdata", name+".golden")
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fn, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return true
+	}
+
+	want, err := ioutil.ReadFile(fn)
+	if err != nil {
+		t.Errorf("reading golden file (run with %s=1 to create it): %v", UpdateGoldenEnv, err)
+		return false
+	}
+	if string(want) != got {
+		t.Errorf("output does not match %s (run with %s=1 to update it):\n%s", fn, UpdateGoldenEnv, lineDiff(string(want), got))
+		return false
+	}
+	return true
+}
+
+// lineDiff returns the lines of want and got from the first one which differs, enough to see what changed.
+func lineDiff(want, got string) string {
+	wl, gl := strings.Split(want, "\n"), strings.Split(got, "\n")
+	i := 0
+	for i < len(wl) && i < len(gl) && wl[i] == gl[i] {
+		i++
+	}
+	const context = 5
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "first difference at line %d\n", i+1)
+	for j := i; j < len(wl) && j < i+context; j++ {
+		fmt.Fprintf(&buf, "- %s\n", wl[j])
+	}
+	for j := i; j < len(gl) && j < i+context; j++ {
+		fmt.Fprintf(&buf, "+ %s\n", gl[j])
+	}
+	return buf.String()
+}
@@ -0,0 +1,89 @@
+/*
+Package vgtest renders components without a browser so their behavior can be tested with go test.
+
+A Renderer builds the component tree the same way as in the browser and keeps the result as a tree
+of Nodes, which can be queried and have events sent to them.  Event handlers run and the components
+are rendered again, so a test can check the output changes as expected:
+
+	func TestCounter(t *testing.T) {
+		r, err := vgtest.New(&Counter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Click(r.FindByID("inc")); err != nil {
+			t.Fatal(err)
+		}
+		vgtest.TextEquals(t, r.FindByID("count"), "1")
+	}
+
+No JS is run.  The JSEvent and similar methods of the DOMEvent passed to handlers return undefined,
+and vg-js-create and vg-js-populate handlers are not called, so components relying on these need
+to be tested in a browser (see wasm-test-suite).
+*/
+package vgtest
+
+import (
+	"strings"
+	"testing"
+)
+
+// TextEquals reports an error if the text of n, with leading and trailing space removed, is not want.
+// It returns true if the check passed.
+func TextEquals(t testing.TB, n *Node, want string) bool {
+	t.Helper()
+	if n == nil {
+		t.Errorf("expected text %q but node is nil", want)
+		return false
+	}
+	if got := strings.TrimSpace(n.Text()); got != want {
+		t.Errorf("expected text %q, got %q in %s", want, got, n.HTML())
+		return false
+	}
+	return true
+}
+
+// TextContains reports an error if the text of n does not contain want.
+// It returns true if the check passed.
+func TextContains(t testing.TB, n *Node, want string) bool {
+	t.Helper()
+	if n == nil {
+		t.Errorf("expected text containing %q but node is nil", want)
+		return false
+	}
+	if !strings.Contains(n.Text(), want) {
+		t.Errorf("expected text containing %q in %s", want, n.HTML())
+		return false
+	}
+	return true
+}
+
+// AttrEquals reports an error if n does not have the attribute key with the value want.
+// It returns true if the check passed.
+func AttrEquals(t testing.TB, n *Node, key, want string) bool {
+	t.Helper()
+	if n == nil {
+		t.Errorf("expected attribute %s=%q but node is nil", key, want)
+		return false
+	}
+	got, ok := n.AttrValue(key)
+	if !ok {
+		t.Errorf("expected attribute %s=%q but it is not set in %s", key, want, n.HTML())
+		return false
+	}
+	if got != want {
+		t.Errorf("expected attribute %s=%q, got %q in %s", key, want, got, n.HTML())
+		return false
+	}
+	return true
+}
+
+// Exists reports an error if n is nil, e.g. when FindByID did not find anything.
+// It returns true if the check passed.
+func Exists(t testing.TB, n *Node, what string) bool {
+	t.Helper()
+	if n == nil {
+		t.Errorf("expected to find %s", what)
+		return false
+	}
+	return true
+}
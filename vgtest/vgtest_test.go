@@ -0,0 +1,170 @@
+package vgtest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/vugu/vugu"
+)
+
+type testItem struct {
+	Name string
+}
+
+func (c *testItem) Build(vgin *vugu.BuildIn) *vugu.BuildOut {
+	n := &vugu.VGNode{Type: vugu.ElementNode, Data: "li", Attr: []vugu.VGAttribute{{Key: "class", Val: "item"}}}
+	n.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: c.Name})
+	return &vugu.BuildOut{Out: []*vugu.VGNode{n}}
+}
+
+type testRoot struct {
+	count    int
+	name     string
+	key      string
+	items    []*testItem
+	rendered int
+}
+
+func (c *testRoot) Rendered(ctx vugu.RenderedCtx) { c.rendered++ }
+
+func (c *testRoot) Build(vgin *vugu.BuildIn) *vugu.BuildOut {
+	out := &vugu.BuildOut{}
+	div := &vugu.VGNode{Type: vugu.ElementNode, Data: "div", Attr: []vugu.VGAttribute{{Key: "id", Val: "root"}}}
+	out.Out = append(out.Out, div)
+
+	button := &vugu.VGNode{Type: vugu.ElementNode, Data: "button", Attr: []vugu.VGAttribute{{Key: "id", Val: "inc"}}}
+	button.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: "Add"})
+	button.DOMEventHandlerSpecList = append(button.DOMEventHandlerSpecList, vugu.DOMEventHandlerSpec{
+		EventType: "click",
+		Func:      func(event vugu.DOMEvent) { c.count++ },
+	})
+	div.AppendChild(button)
+
+	count := &vugu.VGNode{Type: vugu.ElementNode, Data: "span", Attr: []vugu.VGAttribute{{Key: "id", Val: "count"}}}
+	count.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: fmt.Sprint(c.count)})
+	count.ClassMap = vugu.ClassMap{"odd": c.count%2 == 1}
+	div.AppendChild(count)
+
+	input := &vugu.VGNode{Type: vugu.ElementNode, Data: "input", Attr: []vugu.VGAttribute{{Key: "id", Val: "name"}}}
+	input.DOMEventHandlerSpecList = append(input.DOMEventHandlerSpecList, vugu.DOMEventHandlerSpec{
+		EventType: "input",
+		Func:      func(event vugu.DOMEvent) { c.name = event.PropString("target", "value") },
+	}, vugu.DOMEventHandlerSpec{
+		EventType: "keydown",
+		Modifiers: vugu.DOMEventModEnter,
+		Func:      func(event vugu.DOMEvent) { c.key = event.PropString("key") },
+	})
+	div.AppendChild(input)
+
+	greeting := &vugu.VGNode{Type: vugu.ElementNode, Data: "p", Attr: []vugu.VGAttribute{{Key: "id", Val: "greeting"}}}
+	greeting.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: "Hello " + c.name})
+	greeting.Hidden = c.name == ""
+	div.AppendChild(greeting)
+
+	ul := &vugu.VGNode{Type: vugu.ElementNode, Data: "ul"}
+	for _, item := range c.items {
+		ul.AppendChild(&vugu.VGNode{Component: item})
+		out.Components = append(out.Components, item)
+	}
+	div.AppendChild(ul)
+
+	return out
+}
+
+func TestRenderer(t *testing.T) {
+
+	root := &testRoot{items: []*testItem{{Name: "one"}, {Name: "two"}}}
+	r, err := New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	TextEquals(t, r.FindByID("count"), "0")
+	if r.FindByID("count").HasClass("odd") {
+		t.Errorf("unexpected class odd")
+	}
+	AttrEquals(t, r.FindByID("greeting"), "style", "display:none")
+	if items := r.FindByClass("item"); len(items) != 2 || items[1].Text() != "two" {
+		t.Errorf("unexpected items: %s", r.HTML())
+	}
+	if n := r.FindByText("Add"); n == nil || n.ID() != "inc" {
+		t.Errorf("FindByText returned %v", n)
+	}
+
+	if err := r.Click(r.FindByID("inc")); err != nil {
+		t.Fatal(err)
+	}
+	TextEquals(t, r.FindByID("count"), "1")
+	if !r.FindByID("count").HasClass("odd") {
+		t.Errorf("expected class odd")
+	}
+
+	if err := r.Input(r.FindByID("name"), "Joe"); err != nil {
+		t.Fatal(err)
+	}
+	TextEquals(t, r.FindByID("greeting"), "Hello Joe")
+	if _, ok := r.FindByID("greeting").AttrValue("style"); ok {
+		t.Errorf("expected greeting to be shown")
+	}
+
+	// the handler only takes enter
+	if err := r.Dispatch(r.FindByID("name"), "keydown", map[string]interface{}{"key": "a"}); err != ErrNoHandler {
+		t.Errorf("expected ErrNoHandler, got %v", err)
+	}
+	if err := r.Dispatch(r.FindByID("name"), "keydown", map[string]interface{}{"key": "Enter"}); err != nil || root.key != "Enter" {
+		t.Errorf("unexpected result %v %q", err, root.key)
+	}
+
+	// events bubble from the text to the button
+	if err := r.Click(r.FindByID("inc").Children[0]); err != nil {
+		t.Fatal(err)
+	}
+	TextEquals(t, r.FindByID("count"), "2")
+
+	if root.rendered != 5 {
+		t.Errorf("expected 5 renders, got %d", root.rendered)
+	}
+}
+
+func TestWaitRender(t *testing.T) {
+
+	root := &testRoot{}
+	r, err := New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.WaitRender(10 * time.Millisecond); err == nil {
+		t.Errorf("expected timeout")
+	}
+
+	go func() {
+		ee := r.EventEnv()
+		ee.Lock()
+		root.count = 5
+		ee.UnlockRender()
+	}()
+	if err := r.WaitRender(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	TextEquals(t, r.FindByID("count"), "5")
+}
+
+func TestSnapshot(t *testing.T) {
+
+	r, err := New(&testRoot{items: []*testItem{{Name: "one"}, {Name: "<two>"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Golden(t, "root", r.Snapshot())
+
+	n := &vugu.VGNode{Type: vugu.ElementNode, Data: "p", Attr: []vugu.VGAttribute{{Key: "title", Val: "b"}, {Key: "class", Val: "a"}}}
+	n.AppendChild(&vugu.VGNode{Type: vugu.TextNode, Data: "\n  "})
+	n.AppendChild(&vugu.VGNode{Component: &testItem{}})
+	n.DOMEventHandlerSpecList = []vugu.DOMEventHandlerSpec{{EventType: "keydown", Modifiers: vugu.DOMEventModEnter | vugu.DOMEventModPrevent}}
+	want := "<p @keydown.prevent.enter class=\"a\" title=\"b\">\n  <vg-component type=\"*vgtest.testItem\">\n  </vg-component>\n</p>\n"
+	if got := SnapshotVGNode(n); got != want {
+		t.Errorf("unexpected snapshot:\n%s", got)
+	}
+}
@@ -0,0 +1,3 @@
+package vgtour
+
+//go:generate vugugen
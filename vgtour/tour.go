@@ -0,0 +1,225 @@
+package vgtour
+
+import (
+	"fmt"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+	"github.com/vugu/vugu/vgstorage"
+)
+
+// Tour shows a guided tour of the page, see the package documentation.  Its methods must be called
+// with the EventEnv locked, e.g. from an event handler.
+type Tour struct {
+	ID        string            // identifies the tour for recording that it was completed
+	Stops     []Stop            // the stops in order
+	AutoStart bool              // start the tour when the component is created, unless it was completed before
+	Storage   vgstorage.Storage // where completion is recorded, vgstorage.Local() if nil
+
+	NextLabel, BackLabel, SkipLabel, DoneLabel string // button text, "Next", "Back", "Skip" and "Done" if empty
+
+	Done DoneHandler // called when the tour is finished or skipped
+
+	AttrMap vugu.AttrMap // regular HTML attributes for the element left in place of the tour, which is empty
+
+	active bool
+	step   int
+
+	found    bool    // the target of the step was found and rect is its position
+	rect     Rect    // of the target
+	vw, vh   float64 // size of the viewport
+	scrolled int     // the step whose target was last scrolled into view, -1 for none
+}
+
+// Init implements vugu.Initer.
+func (c *Tour) Init(ctx vugu.InitCtx) {
+	c.scrolled = -1
+	if c.AutoStart && !c.Completed() {
+		c.Start()
+	}
+}
+
+// Compute implements vugu.Computer, it finds where the target of the current stop is.
+func (c *Tour) Compute(ctx vugu.ComputeCtx) {
+	c.measure()
+}
+
+// Rendered implements vugu.Rendered, it renders again if the target has moved or only now appeared.
+func (c *Tour) Rendered(ctx vugu.RenderedCtx) {
+	if c.measure() {
+		ee := ctx.EventEnv()
+		go func() {
+			ee.Lock()
+			ee.UnlockRender()
+		}()
+	}
+}
+
+// Start shows the tour from its first stop, even if it was completed before.
+func (c *Tour) Start() {
+	if len(c.Stops) == 0 {
+		return
+	}
+	c.active, c.step, c.scrolled = true, 0, -1
+}
+
+// Active returns true while the tour is shown.
+func (c *Tour) Active() bool { return c.active }
+
+// Step returns the index of the current stop.
+func (c *Tour) Step() int { return c.step }
+
+// Next goes to the next stop, or finishes the tour on the last.
+func (c *Tour) Next() {
+	if !c.active {
+		return
+	}
+	if c.step+1 >= len(c.Stops) {
+		c.finish(false)
+		return
+	}
+	c.step++
+}
+
+// Prev goes back to the previous stop.
+func (c *Tour) Prev() {
+	if c.active && c.step > 0 {
+		c.step--
+	}
+}
+
+// Skip ends the tour before the last stop, it is recorded as completed all the same.
+func (c *Tour) Skip() {
+	if c.active {
+		c.finish(c.step+1 < len(c.Stops))
+	}
+}
+
+// Completed returns true if the tour was finished or skipped before.
+func (c *Tour) Completed() bool {
+	var done bool
+	ok, err := vgstorage.Load(c.storage(), c.key(), &done)
+	return ok && err == nil && done
+}
+
+// Reset forgets that the tour was completed, so AutoStart shows it again.
+func (c *Tour) Reset() {
+	c.storage().RemoveItem(c.key())
+}
+
+func (c *Tour) finish(skipped bool) {
+	c.active = false
+	vgstorage.Save(c.storage(), c.key(), true)
+	if c.Done != nil {
+		c.Done.DoneHandle(DoneEvent{Skipped: skipped, Stop: c.step})
+	}
+}
+
+func (c *Tour) storage() vgstorage.Storage {
+	if c.Storage != nil {
+		return c.Storage
+	}
+	return vgstorage.Local()
+}
+
+func (c *Tour) key() string {
+	return "vgtour:" + c.ID
+}
+
+// measure finds the target of the current stop, scrolling it into view when the stop is new, and
+// returns true if it is not where it was.
+func (c *Tour) measure() bool {
+
+	window := js.Global().Get("window")
+	if !c.active || !window.Truthy() {
+		return false
+	}
+
+	stop := c.Stops[c.step]
+	el := stop.Target
+	if !el.Truthy() && stop.Selector != "" {
+		el = js.Global().Get("document").Call("querySelector", stop.Selector)
+	}
+	if !el.Truthy() {
+		changed := c.found
+		c.found = false
+		return changed
+	}
+
+	if c.scrolled != c.step {
+		c.scrolled = c.step
+		opts := js.Global().Get("Object").New()
+		opts.Set("block", "center")
+		opts.Set("inline", "nearest")
+		el.Call("scrollIntoView", opts)
+	}
+
+	r := el.Call("getBoundingClientRect")
+	rect := Rect{X: r.Get("left").Float(), Y: r.Get("top").Float(), Width: r.Get("width").Float(), Height: r.Get("height").Float()}
+	vw, vh := window.Get("innerWidth").Float(), window.Get("innerHeight").Float()
+
+	changed := !c.found || rect != c.rect || vw != c.vw || vh != c.vh
+	c.found, c.rect, c.vw, c.vh = true, rect, vw, vh
+	return changed
+}
+
+func (c *Tour) stop() Stop {
+	if c.step < len(c.Stops) {
+		return c.Stops[c.step]
+	}
+	return Stop{}
+}
+
+// backdropClass dims the page with the backdrop when there is no cutout to do it.
+func (c *Tour) backdropClass() string {
+	if c.found {
+		return "vgtour-backdrop"
+	}
+	return "vgtour-backdrop vgtour-dim"
+}
+
+func (c *Tour) cutoutStyle() string {
+	if !c.found {
+		return "display:none"
+	}
+	pad := c.stop().Padding
+	if pad == 0 {
+		pad = 6
+	}
+	return fmt.Sprintf("left:%.1fpx;top:%.1fpx;width:%.1fpx;height:%.1fpx",
+		c.rect.X-pad, c.rect.Y-pad, c.rect.Width+2*pad, c.rect.Height+2*pad)
+}
+
+func (c *Tour) popoverClass() string {
+	p := Center
+	if c.found {
+		p, _, _ = place(c.rect, c.vw, c.vh, c.stop().Placement)
+	}
+	return "vgtour-popover vgtour-" + string(p)
+}
+
+func (c *Tour) popoverStyle() string {
+	if !c.found {
+		return ""
+	}
+	_, left, top := place(c.rect, c.vw, c.vh, c.stop().Placement)
+	return fmt.Sprintf("left:%.1fpx;top:%.1fpx", left, top)
+}
+
+func (c *Tour) label(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+func (c *Tour) nextLabel() string {
+	if c.step+1 >= len(c.Stops) {
+		return c.label(c.DoneLabel, "Done")
+	}
+	return c.label(c.NextLabel, "Next")
+}
+
+func (c *Tour) handleResize(event vugu.DOMEvent) {
+	c.measure()
+}
@@ -0,0 +1,129 @@
+/*
+Package vgtour provides a Tour component which walks the user through a page, highlighting one element
+at a time with a popover explaining it, e.g. to introduce a new feature:
+
+	<vgtour:Tour ID="intro" :Stops='c.tourStops' AutoStart @Done='c.tourDone(event)'></vgtour:Tour>
+
+with
+
+	var tourStops = []vgtour.Stop{
+		{Selector: "#search", Title: "Search", Body: "Find anything from here."},
+		{Selector: ".new-button", Title: "Create", Body: "Start a new project.", Placement: vgtour.Right},
+		{Title: "All set", Body: "You can take this tour again from the help menu."},
+	}
+
+The page is dimmed except for a cutout around the target of the current stop, which is scrolled into
+view, and the popover is placed next to it on the side with the most room (or as set by Placement).  A
+Stop without a target shows its popover in the middle of the page.  The overlay is rendered into the body
+with vg-portal so it is above everything else.
+
+When the tour is finished or skipped it is recorded as completed under its ID (in localStorage unless
+Tour.Storage is set), so AutoStart does not show it again.  Start shows it regardless, and Reset forgets
+that it was completed.
+*/
+package vgtour
+
+import js "github.com/vugu/vugu/js"
+
+// Placement is where the popover goes relative to the target.
+type Placement string
+
+// Placements, Auto is used if empty.
+const (
+	Auto   Placement = "auto"
+	Top    Placement = "top"
+	Bottom Placement = "bottom"
+	Left   Placement = "left"
+	Right  Placement = "right"
+	Center Placement = "center" // in the middle of the page, used when there is no target
+)
+
+// Stop is one step of a tour.
+type Stop struct {
+	Target    js.Value  // the element to highlight, e.g. captured with vg-js-create
+	Selector  string    // CSS selector of the element to highlight, if Target is not set
+	Title     string    // heading of the popover
+	Body      string    // text of the popover
+	Placement Placement // where the popover goes, Auto if empty
+	Padding   float64   // space around the target in the cutout, in pixels, 6 if zero
+}
+
+// Rect is the position and size of something on the page, relative to the viewport, in CSS pixels.
+type Rect struct {
+	X, Y, Width, Height float64
+}
+
+// DoneEvent is emitted by Tour when it is finished or skipped.
+type DoneEvent struct {
+	Skipped bool // true if it was skipped before the last stop
+	Stop    int  // the index of the stop it was on
+}
+
+// DoneHandler is the interface for things that can handle DoneEvent.
+type DoneHandler interface {
+	DoneHandle(event DoneEvent)
+}
+
+// DoneFunc implements DoneHandler as a function.
+type DoneFunc func(event DoneEvent)
+
+// DoneHandle implements the DoneHandler interface.
+func (f DoneFunc) DoneHandle(event DoneEvent) { f(event) }
+
+// assert DoneFunc implements DoneHandler
+var _ DoneHandler = DoneFunc(nil)
+
+const (
+	popoverWidth  = 300.0 // matches the CSS
+	popoverHeight = 160.0 // roughly, to decide if it fits above or below
+	popoverGap    = 12.0  // between the target and the popover
+	viewportEdge  = 8.0   // the popover is kept at least this far from the edge of the viewport
+)
+
+// place returns where the popover goes for a target in a viewport of the given size: which side of
+// the target, and the left and top of the popover.  For Top the popover is moved up by its own height,
+// and for Left and Right moved up by half of it, with CSS, so its height need not be known.
+func place(target Rect, vw, vh float64, p Placement) (Placement, float64, float64) {
+
+	below, above := vh-(target.Y+target.Height), target.Y
+	right, left := vw-(target.X+target.Width), target.X
+
+	need := popoverHeight + popoverGap
+	if p == "" || p == Auto {
+		switch {
+		case below >= need:
+			p = Bottom
+		case above >= need:
+			p = Top
+		case right >= popoverWidth+popoverGap:
+			p = Right
+		case left >= popoverWidth+popoverGap:
+			p = Left
+		case below >= above:
+			p = Bottom
+		default:
+			p = Top
+		}
+	}
+
+	clamp := func(v, max float64) float64 {
+		if v > max {
+			v = max
+		}
+		if v < viewportEdge {
+			v = viewportEdge
+		}
+		return v
+	}
+
+	switch p {
+	case Top:
+		return p, clamp(target.X+target.Width/2-popoverWidth/2, vw-popoverWidth-viewportEdge), target.Y - popoverGap
+	case Left:
+		return p, clamp(target.X-popoverGap-popoverWidth, vw-popoverWidth-viewportEdge), target.Y + target.Height/2
+	case Right:
+		return p, clamp(target.X+target.Width+popoverGap, vw-popoverWidth-viewportEdge), target.Y + target.Height/2
+	default:
+		return Bottom, clamp(target.X+target.Width/2-popoverWidth/2, vw-popoverWidth-viewportEdge), target.Y + target.Height + popoverGap
+	}
+}
@@ -0,0 +1,84 @@
+package vgtour
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu/vgstorage"
+	"github.com/vugu/vugu/vgtest"
+)
+
+func TestPlace(t *testing.T) {
+
+	assert := assert.New(t)
+
+	// room below
+	p, left, top := place(Rect{X: 300, Y: 50, Width: 100, Height: 20}, 1000, 800, Auto)
+	assert.Equal(Bottom, p)
+	assert.Equal(300+50-popoverWidth/2, left)
+	assert.Equal(50+20+popoverGap, top)
+
+	// at the bottom of the viewport goes above, kept inside it on the left
+	p, left, top = place(Rect{X: 0, Y: 750, Width: 40, Height: 20}, 1000, 800, Auto)
+	assert.Equal(Top, p)
+	assert.Equal(viewportEdge, left)
+	assert.Equal(750-popoverGap, top)
+
+	// a tall element with room only to the right
+	p, left, top = place(Rect{X: 0, Y: 0, Width: 200, Height: 800}, 1000, 800, Auto)
+	assert.Equal(Right, p)
+	assert.Equal(200+popoverGap, left)
+	assert.Equal(400.0, top)
+
+	// as requested, kept inside the viewport on the right
+	p, left, _ = place(Rect{X: 900, Y: 100, Width: 50, Height: 20}, 1000, 800, Right)
+	assert.Equal(Right, p)
+	assert.Equal(1000-popoverWidth-viewportEdge, left)
+}
+
+func TestTour(t *testing.T) {
+
+	assert := assert.New(t)
+
+	storage := vgstorage.NewMemory()
+	var done []DoneEvent
+	tour := &Tour{
+		ID:        "intro",
+		AutoStart: true,
+		Storage:   storage,
+		Stops:     []Stop{{Selector: "#a", Title: "A", Body: "First"}, {Body: "Second"}},
+		Done:      DoneFunc(func(event DoneEvent) { done = append(done, event) }),
+	}
+
+	r, err := vgtest.New(tour)
+	assert.NoError(err)
+	assert.True(tour.Active())
+	assert.NotNil(r.FindByText("First"))
+	// the target can't be found outside the browser, so the popover is in the middle of the page
+	assert.Len(r.FindByClass("vgtour-center"), 1)
+	assert.Len(r.FindByClass("vgtour-back"), 0)
+
+	assert.NoError(r.Click(r.FindByClass("vgtour-next")[0]))
+	assert.NotNil(r.FindByText("Second"))
+	assert.Equal("Done", r.FindByClass("vgtour-next")[0].Text())
+	assert.Len(r.FindByClass("vgtour-skip"), 0)
+
+	assert.NoError(r.Click(r.FindByClass("vgtour-next")[0]))
+	assert.False(tour.Active())
+	assert.Len(r.FindByClass("vgtour-overlay"), 0)
+	assert.Equal([]DoneEvent{{Skipped: false, Stop: 1}}, done)
+	assert.True(tour.Completed())
+
+	// completed, so not started again
+	tour2 := &Tour{ID: "intro", AutoStart: true, Storage: storage, Stops: tour.Stops}
+	_, err = vgtest.New(tour2)
+	assert.NoError(err)
+	assert.False(tour2.Active())
+
+	tour2.Reset()
+	assert.False(tour2.Completed())
+	tour2.Start()
+	tour2.Skip()
+	assert.True(tour2.Completed())
+}
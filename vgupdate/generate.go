@@ -0,0 +1,3 @@
+package vgupdate
+
+//go:generate vugugen
@@ -0,0 +1,63 @@
+// +build !js
+
+package vgupdate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// HashHandler wraps h, which serves the wasm file at wasmPath, so responses have the HashHeader
+// header with a hash of the file.  The hash is computed again when the file's size or modification
+// time changes.  Only available on the server.
+func HashHandler(h http.Handler, wasmPath string) http.Handler {
+	fh := &fileHash{path: wasmPath}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sum, err := fh.get(); err == nil {
+			w.Header().Set(HashHeader, sum)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// fileHash caches the hash of the file at path.
+type fileHash struct {
+	path string
+
+	mu      sync.Mutex
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+func (fh *fileHash) get() (string, error) {
+
+	st, err := os.Stat(fh.path)
+	if err != nil {
+		return "", err
+	}
+
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	if fh.sum != "" && st.Size() == fh.size && st.ModTime().Equal(fh.modTime) {
+		return fh.sum, nil
+	}
+
+	f, err := os.Open(fh.path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	fh.sum = hex.EncodeToString(hash.Sum(nil))
+	fh.size, fh.modTime = st.Size(), st.ModTime()
+	return fh.sum, nil
+}
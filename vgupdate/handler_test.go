@@ -0,0 +1,51 @@
+// +build !js
+
+package vgupdate
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashHandler(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "vgupdate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "main.wasm")
+
+	h := HashHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, p)
+	}), p)
+	hash := func() string {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("HEAD", "/main.wasm", nil))
+		return w.Header().Get(HashHeader)
+	}
+
+	if err := ioutil.WriteFile(p, []byte("one"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first := hash()
+	if len(first) != 64 || hash() != first {
+		t.Errorf("unexpected hash %q", first)
+	}
+
+	if err := ioutil.WriteFile(p, []byte("two!"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if second := hash(); second == first || len(second) != 64 {
+		t.Errorf("expected a new hash, got %q", second)
+	}
+
+	os.Remove(p)
+	if got := hash(); got != "" {
+		t.Errorf("expected no header for a missing file, got %q", got)
+	}
+}
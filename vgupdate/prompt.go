@@ -0,0 +1,101 @@
+package vgupdate
+
+import (
+	"time"
+
+	"github.com/vugu/vugu"
+)
+
+// Prompt shows a bar offering to reload the app when a Checker finds an update.
+type Prompt struct {
+	URL      string        // the wasm file to check, DefaultURL if empty
+	Interval time.Duration // how often to check, DefaultInterval if zero
+
+	Message      string // defaults to "A new version is available."
+	ReloadLabel  string // defaults to "Reload"
+	DismissLabel string // defaults to "Later"
+
+	// State, if set, returns the state to pass to Reload, to get back with RestoreState after reloading.
+	State func() interface{}
+
+	Error ErrorHandler // called if reloading fails
+
+	AttrMap vugu.AttrMap // regular HTML attributes for the outer element like id and class
+
+	checker   *Checker
+	available bool
+	dismissed bool
+}
+
+// Init implements vugu.Initer, it starts checking for updates.
+func (c *Prompt) Init(ctx vugu.InitCtx) {
+	c.checker = NewChecker(ctx.EventEnv(), c.URL, c.Interval, func() { c.available = true })
+}
+
+// Destroy implements vugu.Destroyer.
+func (c *Prompt) Destroy() {
+	if c.checker != nil {
+		c.checker.Close()
+	}
+}
+
+func (c *Prompt) show() bool {
+	return c.available && !c.dismissed
+}
+
+func (c *Prompt) message() string {
+	if c.Message == "" {
+		return "A new version is available."
+	}
+	return c.Message
+}
+
+func (c *Prompt) reloadLabel() string {
+	if c.ReloadLabel == "" {
+		return "Reload"
+	}
+	return c.ReloadLabel
+}
+
+func (c *Prompt) dismissLabel() string {
+	if c.DismissLabel == "" {
+		return "Later"
+	}
+	return c.DismissLabel
+}
+
+func (c *Prompt) handleReload(event vugu.DOMEvent) {
+	var state interface{}
+	if c.State != nil {
+		state = c.State()
+	}
+	ee := event.EventEnv()
+	go func() {
+		err := Reload(state)
+		if err == nil || c.Error == nil {
+			return
+		}
+		ee.Lock()
+		defer ee.UnlockRender()
+		c.Error.ErrorHandle(ErrorEvent{Err: err})
+	}()
+}
+
+// ErrorEvent is emitted by Prompt when reloading fails.
+type ErrorEvent struct {
+	Err error
+}
+
+// ErrorHandler is the interface for things that can handle ErrorEvent.
+type ErrorHandler interface {
+	ErrorHandle(event ErrorEvent)
+}
+
+// ErrorFunc implements ErrorHandler as a function.
+type ErrorFunc func(event ErrorEvent)
+
+// ErrorHandle implements the ErrorHandler interface.
+func (f ErrorFunc) ErrorHandle(event ErrorEvent) { f(event) }
+
+// assert ErrorFunc implements ErrorHandler
+var _ ErrorHandler = ErrorFunc(nil)
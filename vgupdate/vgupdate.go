@@ -0,0 +1,357 @@
+/*
+Package vgupdate detects when a new build of the app has been deployed while it is running, and
+reloads it keeping the current URL and whatever state the app chooses to save.
+
+Prompt is a ready made "update available" bar, put it in the root component:
+
+	<vgupdate:Prompt :State='c.saveState'></vgupdate:Prompt>
+
+and restore the state when starting up:
+
+	func (c *Root) Init(ctx vugu.InitCtx) {
+		var st savedState
+		if ok, _ := vgupdate.RestoreState(&st); ok {
+			c.draft = st.Draft
+		}
+	}
+
+A Checker can be used directly instead for other UI.  It asks for the headers of the wasm file every
+so often (and when the page becomes visible again) and reports an update when they change.  The
+X-Vugu-Build-Hash header is used if present, see HashHandler, otherwise the ETag or the Last-Modified
+and Content-Length headers.  If the page has a service worker, a new service worker waiting to take
+over is also reported as an update; include ServiceWorkerScript in the service worker so Reload
+can activate it.
+
+Outside of the browser a Checker does nothing and the other functions return ErrNotAvailable.
+*/
+package vgupdate
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vugu/vjson"
+
+	"github.com/vugu/vugu"
+	js "github.com/vugu/vugu/js"
+	"github.com/vugu/vugu/vgbase"
+)
+
+// ErrNotAvailable is returned when the browser API needed is not present (or outside of the browser).
+var ErrNotAvailable = errors.New("vgupdate: not available in this environment")
+
+// HashHeader is the response header with a hash of the wasm file, see HashHandler.
+const HashHeader = "X-Vugu-Build-Hash"
+
+// StateKey is the sessionStorage key the state passed to Reload is kept under.
+const StateKey = "vgupdate-state"
+
+// DefaultURL and DefaultInterval are used by NewChecker for an empty URL and a zero interval.  DefaultURL
+// is relative to the asset base, see package vgbase.
+const (
+	DefaultURL      = "main.wasm"
+	DefaultInterval = 5 * time.Minute
+)
+
+// Checker looks for a new build of the app, see the package documentation.
+type Checker struct {
+	eventEnv vugu.EventEnv
+	url      string
+	handler  func()
+
+	checkCh chan struct{}
+	closeCh chan struct{}
+
+	mu        sync.Mutex
+	closed    bool
+	available bool
+	listeners []listener
+}
+
+type listener struct {
+	target js.Value
+	event  string
+	fn     js.Func
+}
+
+// NewChecker starts checking the wasm file at url for changes every interval, and calls handler once
+// when an update is found.  The handler is called with the EventEnv write lock held and a render is
+// requested when it returns, the same as for DOM event handlers.  If eventEnv is nil the handler is
+// called without locking or rendering.
+func NewChecker(eventEnv vugu.EventEnv, url string, interval time.Duration, handler func()) *Checker {
+
+	if url == "" {
+		url = vgbase.AssetURL(DefaultURL)
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	c := &Checker{
+		eventEnv: eventEnv,
+		url:      url,
+		handler:  handler,
+		checkCh:  make(chan struct{}, 1),
+		closeCh:  make(chan struct{}),
+	}
+
+	if !js.Global().Get("fetch").Truthy() {
+		return c
+	}
+
+	if doc := js.Global().Get("document"); doc.Truthy() {
+		c.listen(doc, "visibilitychange", func(js.Value) {
+			if doc.Get("visibilityState").String() == "visible" {
+				c.Check()
+			}
+		})
+	}
+
+	go c.run(interval)
+	return c
+}
+
+// Available returns true once an update has been found.
+func (c *Checker) Available() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.available
+}
+
+// Check checks for an update now, rather than waiting for the interval.  It does not wait for the result.
+func (c *Checker) Check() {
+	select {
+	case c.checkCh <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops checking.  It is safe to call more than once.
+func (c *Checker) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.closeCh)
+	for _, l := range c.listeners {
+		l.target.Call("removeEventListener", l.event, l.fn)
+		l.fn.Release()
+	}
+	c.listeners = nil
+}
+
+// listen adds an event listener which is removed by Close.
+func (c *Checker) listen(target js.Value, event string, f func(js.Value)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	fn := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		var ev js.Value
+		if len(args) > 0 {
+			ev = args[0]
+		}
+		f(ev)
+		return nil
+	})
+	target.Call("addEventListener", event, fn)
+	c.listeners = append(c.listeners, listener{target: target, event: event, fn: fn})
+}
+
+func (c *Checker) run(interval time.Duration) {
+
+	c.watchServiceWorker()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	var version string
+	for {
+		v, err := fetchVersion(c.url)
+		if err == nil && v != "" {
+			if version == "" {
+				version = v
+			} else if v != version {
+				go c.notify()
+				return
+			}
+		}
+		select {
+		case <-c.closeCh:
+			return
+		case <-t.C:
+		case <-c.checkCh:
+		}
+	}
+}
+
+// watchServiceWorker reports an update when a new service worker is installed and waiting to replace
+// the one controlling the page.
+func (c *Checker) watchServiceWorker() {
+
+	sw := serviceWorker()
+	if !sw.Truthy() {
+		return
+	}
+	reg, err := js.Await(sw.Call("getRegistration"), js.NewDOMError)
+	if err != nil || !reg.Truthy() {
+		return
+	}
+
+	if reg.Get("waiting").Truthy() && sw.Get("controller").Truthy() {
+		go c.notify()
+		return
+	}
+	c.listen(reg, "updatefound", func(js.Value) {
+		installing := reg.Get("installing")
+		if !installing.Truthy() {
+			return
+		}
+		c.listen(installing, "statechange", func(js.Value) {
+			if installing.Get("state").String() == "installed" && sw.Get("controller").Truthy() {
+				go c.notify()
+			}
+		})
+	})
+}
+
+// notify calls the handler the first time an update is found.
+func (c *Checker) notify() {
+	if c.eventEnv != nil {
+		c.eventEnv.Lock()
+		defer c.eventEnv.UnlockRender()
+	}
+	c.mu.Lock()
+	if c.closed || c.available {
+		c.mu.Unlock()
+		return
+	}
+	c.available = true
+	c.mu.Unlock()
+	c.handler()
+}
+
+// fetchVersion returns a string which changes when the file at url changes, from its headers.
+func fetchVersion(url string) (string, error) {
+	opts := js.Global().Get("Object").New()
+	opts.Set("method", "HEAD")
+	opts.Set("cache", "no-store")
+	res, err := js.Await(js.Global().Call("fetch", url, opts), js.NewDOMError)
+	if err != nil {
+		return "", err
+	}
+	if !res.Get("ok").Bool() {
+		return "", errors.New("vgupdate: " + url + ": " + res.Get("statusText").String())
+	}
+	h := res.Get("headers")
+	get := func(name string) string { return js.OptString(h.Call("get", name)) }
+	return versionOf(get(HashHeader), get("ETag"), get("Last-Modified"), get("Content-Length")), nil
+}
+
+// versionOf returns the version from the headers of the wasm file, preferring an explicit hash.
+func versionOf(hash, etag, lastModified, contentLength string) string {
+	switch {
+	case hash != "":
+		return "hash:" + hash
+	case etag != "":
+		return "etag:" + etag
+	case lastModified != "" || contentLength != "":
+		return "modified:" + lastModified + ";" + contentLength
+	}
+	return ""
+}
+
+// Reload saves state (if not nil) as JSON for RestoreState, activates a waiting service worker if
+// there is one, and reloads the page.  It waits for the service worker, so call it in a goroutine.
+func Reload(state interface{}) error {
+
+	window := js.Global().Get("window")
+	if !window.Truthy() {
+		return ErrNotAvailable
+	}
+
+	if state != nil {
+		b, err := vjson.Marshal(state)
+		if err != nil {
+			return err
+		}
+		ss := window.Get("sessionStorage")
+		if !ss.Truthy() {
+			return ErrNotAvailable
+		}
+		ss.Call("setItem", StateKey, string(b))
+	}
+
+	activateWaiting()
+	window.Get("location").Call("reload")
+	return nil
+}
+
+// activateWaiting asks a waiting service worker to take over, see ServiceWorkerScript, and waits
+// (for a few seconds at most) until it has.
+func activateWaiting() {
+
+	sw := serviceWorker()
+	if !sw.Truthy() {
+		return
+	}
+	reg, err := js.Await(sw.Call("getRegistration"), js.NewDOMError)
+	if err != nil || !reg.Truthy() || !reg.Get("waiting").Truthy() {
+		return
+	}
+
+	ch := make(chan struct{}, 1)
+	fn := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	defer fn.Release()
+	sw.Call("addEventListener", "controllerchange", fn)
+	defer sw.Call("removeEventListener", "controllerchange", fn)
+
+	msg := js.Global().Get("Object").New()
+	msg.Set("vgupdate", "skipWaiting")
+	reg.Get("waiting").Call("postMessage", msg)
+
+	select {
+	case <-ch:
+	case <-time.After(3 * time.Second):
+	}
+}
+
+// RestoreState unmarshals the state saved by Reload into v and removes it, returning true if there was any.
+func RestoreState(v interface{}) (bool, error) {
+	window := js.Global().Get("window")
+	if !window.Truthy() || !window.Get("sessionStorage").Truthy() {
+		return false, ErrNotAvailable
+	}
+	ss := window.Get("sessionStorage")
+	s := ss.Call("getItem", StateKey)
+	if s.Type() != js.TypeString {
+		return false, nil
+	}
+	ss.Call("removeItem", StateKey)
+	return true, vjson.Unmarshal([]byte(s.String()), v)
+}
+
+// ServiceWorkerScript is the JS to include in the service worker so Reload can activate a new version of it.
+const ServiceWorkerScript = `self.addEventListener("message", function (e) {
+	if (e.data && e.data.vgupdate === "skipWaiting") {
+		self.skipWaiting();
+	}
+});
+`
+
+func serviceWorker() js.Value {
+	nav := js.Global().Get("navigator")
+	if !nav.Truthy() {
+		return js.Undefined()
+	}
+	return nav.Get("serviceWorker")
+}
@@ -0,0 +1,72 @@
+package vgupdate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vugu/vugu/vgtest"
+)
+
+func TestNotAvailable(t *testing.T) {
+
+	if err := Reload(map[string]string{"a": "b"}); err != ErrNotAvailable {
+		t.Errorf("Reload: expected ErrNotAvailable, got %v", err)
+	}
+	var v map[string]string
+	if ok, err := RestoreState(&v); ok || err != ErrNotAvailable {
+		t.Errorf("RestoreState: expected ErrNotAvailable, got %v, %v", ok, err)
+	}
+
+	c := NewChecker(nil, "", 0, func() { t.Errorf("unexpected handler call") })
+	c.Check()
+	if c.Available() {
+		t.Errorf("unexpected update available")
+	}
+	c.Close()
+	c.Close()
+}
+
+func TestVersionOf(t *testing.T) {
+	for _, tc := range []struct {
+		hash, etag, modified, length string
+		want                         string
+	}{
+		{"abc", `"x"`, "Mon", "10", "hash:abc"},
+		{"", `"x"`, "Mon", "10", `etag:"x"`},
+		{"", "", "Mon", "10", "modified:Mon;10"},
+		{"", "", "", "", ""},
+	} {
+		if got := versionOf(tc.hash, tc.etag, tc.modified, tc.length); got != tc.want {
+			t.Errorf("versionOf(%q, %q, %q, %q) = %q, want %q", tc.hash, tc.etag, tc.modified, tc.length, got, tc.want)
+		}
+	}
+}
+
+func TestPrompt(t *testing.T) {
+
+	p := &Prompt{Message: "Update!"}
+	r, err := vgtest.New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.FindByClass("vgupdate-prompt")) != 0 {
+		t.Fatalf("prompt shown before an update")
+	}
+
+	ee := r.EventEnv()
+	ee.Lock()
+	p.available = true
+	ee.UnlockRender()
+	if err := r.WaitRender(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	vgtest.TextContains(t, r.Root(), "Update!")
+	vgtest.TextContains(t, r.Root(), "Later")
+
+	if err := r.Click(r.FindByClass("vgupdate-dismiss")[0]); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.FindByClass("vgupdate-prompt")) != 0 {
+		t.Errorf("prompt shown after dismissing")
+	}
+}
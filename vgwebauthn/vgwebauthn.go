@@ -0,0 +1,355 @@
+/*
+Package vgwebauthn wraps navigator.credentials for WebAuthn, so passkey registration and login
+can be done from Go.
+
+The option and result types have JSON tags matching the WebAuthn JSON encoding (binary fields are
+base64url), so options sent by a server side WebAuthn library can be unmarshaled directly into
+CreationOptions or RequestOptions, and the resulting credential marshaled and sent back for verification.
+
+Create and Get wait for the user to interact with the browser, so they must not be called from
+an event handler directly.  Call them in a goroutine and lock the EventEnv when updating the component:
+
+	func (c *Login) HandleClick(event vugu.DOMEvent) {
+		ee := event.EventEnv()
+		go func() {
+			cred, err := vgwebauthn.Get(c.options)
+			ee.Lock()
+			defer ee.UnlockRender()
+			c.cred, c.err = cred, err
+		}()
+	}
+*/
+package vgwebauthn
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/vugu/vjson"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// ErrNotAvailable is returned when the browser does not support WebAuthn (or outside of the browser).
+var ErrNotAvailable = errors.New("vgwebauthn: WebAuthn is not available in this environment")
+
+// Error is a failure reported by the browser.  Name is the DOMException name, e.g. "NotAllowedError"
+// when the user cancelled or the operation timed out, or "InvalidStateError" when creating a credential
+// that is already registered (see CreationOptions.ExcludeCredentials).
+type Error = js.DOMError
+
+// Base64URL is binary data that is encoded as unpadded base64url in JSON, as is usual for WebAuthn.
+// Padded and standard base64 are also accepted when unmarshaling.
+type Base64URL []byte
+
+// MarshalJSON implements json.Marshaler.
+func (b Base64URL) MarshalJSON() ([]byte, error) {
+	// base64url has no characters that need escaping
+	return []byte(`"` + base64.RawURLEncoding.EncodeToString(b) + `"`), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *Base64URL) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := vjson.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	s = strings.TrimRight(s, "=")
+	s = strings.NewReplacer("+", "-", "/", "_").Replace(s)
+	v, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// RelyingParty identifies the site the credential is for.
+type RelyingParty struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+// User is the account a credential is created for.
+type User struct {
+	ID          Base64URL `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"displayName"`
+}
+
+// CredentialParameter is an acceptable credential type and algorithm, e.g. {"public-key", -7} for ES256.
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// CredentialDescriptor identifies an existing credential.
+type CredentialDescriptor struct {
+	Type       string    `json:"type"`
+	ID         Base64URL `json:"id"`
+	Transports []string  `json:"transports,omitempty"`
+}
+
+// AuthenticatorSelection restricts which authenticators can be used to create a credential.
+type AuthenticatorSelection struct {
+	AuthenticatorAttachment string `json:"authenticatorAttachment,omitempty"` // "platform" or "cross-platform"
+	ResidentKey             string `json:"residentKey,omitempty"`             // "discouraged", "preferred" or "required", use "required" for passkeys
+	RequireResidentKey      bool   `json:"requireResidentKey,omitempty"`
+	UserVerification        string `json:"userVerification,omitempty"` // "discouraged", "preferred" or "required"
+}
+
+// CreationOptions are the options for Create, corresponding to PublicKeyCredentialCreationOptions.
+type CreationOptions struct {
+	Challenge              Base64URL               `json:"challenge"`
+	RP                     RelyingParty            `json:"rp"`
+	User                   User                    `json:"user"`
+	PubKeyCredParams       []CredentialParameter   `json:"pubKeyCredParams"`
+	Timeout                int                     `json:"timeout,omitempty"` // milliseconds
+	ExcludeCredentials     []CredentialDescriptor  `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection *AuthenticatorSelection `json:"authenticatorSelection,omitempty"`
+	Attestation            string                  `json:"attestation,omitempty"` // "none", "indirect", "direct" or "enterprise"
+}
+
+// RequestOptions are the options for Get, corresponding to PublicKeyCredentialRequestOptions.
+type RequestOptions struct {
+	Challenge        Base64URL              `json:"challenge"`
+	Timeout          int                    `json:"timeout,omitempty"` // milliseconds
+	RPID             string                 `json:"rpId,omitempty"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"` // empty lets the user pick any passkey for the site
+	UserVerification string                 `json:"userVerification,omitempty"`
+
+	// Mediation is passed to navigator.credentials.get alongside the publicKey options,
+	// "conditional" offers passkeys in the autofill of inputs with autocomplete="username webauthn".
+	Mediation string `json:"-"`
+}
+
+// AttestationResponse is the response of a newly created credential.
+type AttestationResponse struct {
+	ClientDataJSON    Base64URL `json:"clientDataJSON"`
+	AttestationObject Base64URL `json:"attestationObject"`
+	Transports        []string  `json:"transports,omitempty"`
+}
+
+// AttestationCredential is the result of Create, to be sent to the server to register the credential.
+type AttestationCredential struct {
+	ID                      string              `json:"id"`
+	RawID                   Base64URL           `json:"rawId"`
+	Type                    string              `json:"type"`
+	AuthenticatorAttachment string              `json:"authenticatorAttachment,omitempty"`
+	Response                AttestationResponse `json:"response"`
+}
+
+// AssertionResponse is the signed response of an existing credential.
+type AssertionResponse struct {
+	ClientDataJSON    Base64URL `json:"clientDataJSON"`
+	AuthenticatorData Base64URL `json:"authenticatorData"`
+	Signature         Base64URL `json:"signature"`
+	UserHandle        Base64URL `json:"userHandle,omitempty"`
+}
+
+// AssertionCredential is the result of Get, to be sent to the server to verify the login.
+type AssertionCredential struct {
+	ID                      string            `json:"id"`
+	RawID                   Base64URL         `json:"rawId"`
+	Type                    string            `json:"type"`
+	AuthenticatorAttachment string            `json:"authenticatorAttachment,omitempty"`
+	Response                AssertionResponse `json:"response"`
+}
+
+// Available returns true if the browser supports WebAuthn.
+func Available() bool {
+	return js.Global().Get("PublicKeyCredential").Truthy() && credentials().Truthy()
+}
+
+// PlatformAuthenticatorAvailable returns true if the device has a built-in authenticator
+// (e.g. fingerprint or face recognition) that can be used with AuthenticatorAttachment "platform".
+func PlatformAuthenticatorAvailable() (bool, error) {
+	if !Available() {
+		return false, ErrNotAvailable
+	}
+	v, err := js.Await(js.Global().Get("PublicKeyCredential").Call("isUserVerifyingPlatformAuthenticatorAvailable"), js.NewDOMError)
+	if err != nil {
+		return false, err
+	}
+	return v.Truthy(), nil
+}
+
+// Create registers a new credential by calling navigator.credentials.create.  It blocks until
+// the user completes or cancels the browser prompt, see the package documentation.
+func Create(opts *CreationOptions) (*AttestationCredential, error) {
+
+	if !Available() {
+		return nil, ErrNotAvailable
+	}
+
+	pk := object()
+	pk.Set("challenge", bytesToJS(opts.Challenge))
+	rp := object()
+	if opts.RP.ID != "" {
+		rp.Set("id", opts.RP.ID)
+	}
+	rp.Set("name", opts.RP.Name)
+	pk.Set("rp", rp)
+	user := object()
+	user.Set("id", bytesToJS(opts.User.ID))
+	user.Set("name", opts.User.Name)
+	user.Set("displayName", opts.User.DisplayName)
+	pk.Set("user", user)
+	params := js.Global().Get("Array").New()
+	for _, p := range opts.PubKeyCredParams {
+		o := object()
+		o.Set("type", p.Type)
+		o.Set("alg", p.Alg)
+		params.Call("push", o)
+	}
+	pk.Set("pubKeyCredParams", params)
+	if opts.Timeout > 0 {
+		pk.Set("timeout", opts.Timeout)
+	}
+	if len(opts.ExcludeCredentials) > 0 {
+		pk.Set("excludeCredentials", descriptorsToJS(opts.ExcludeCredentials))
+	}
+	if as := opts.AuthenticatorSelection; as != nil {
+		o := object()
+		if as.AuthenticatorAttachment != "" {
+			o.Set("authenticatorAttachment", as.AuthenticatorAttachment)
+		}
+		if as.ResidentKey != "" {
+			o.Set("residentKey", as.ResidentKey)
+		}
+		o.Set("requireResidentKey", as.RequireResidentKey)
+		if as.UserVerification != "" {
+			o.Set("userVerification", as.UserVerification)
+		}
+		pk.Set("authenticatorSelection", o)
+	}
+	if opts.Attestation != "" {
+		pk.Set("attestation", opts.Attestation)
+	}
+
+	arg := object()
+	arg.Set("publicKey", pk)
+	v, err := js.Await(credentials().Call("create", arg), js.NewDOMError)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := v.Get("response")
+	ret := &AttestationCredential{
+		ID:                      v.Get("id").String(),
+		RawID:                   bytesFromJS(v.Get("rawId")),
+		Type:                    v.Get("type").String(),
+		AuthenticatorAttachment: js.OptString(v.Get("authenticatorAttachment")),
+		Response: AttestationResponse{
+			ClientDataJSON:    bytesFromJS(resp.Get("clientDataJSON")),
+			AttestationObject: bytesFromJS(resp.Get("attestationObject")),
+		},
+	}
+	if resp.Get("getTransports").Truthy() {
+		tl := resp.Call("getTransports")
+		for i := 0; i < tl.Length(); i++ {
+			ret.Response.Transports = append(ret.Response.Transports, tl.Index(i).String())
+		}
+	}
+	return ret, nil
+}
+
+// Get signs the challenge with an existing credential by calling navigator.credentials.get.
+// It blocks until the user completes or cancels the browser prompt, see the package documentation.
+func Get(opts *RequestOptions) (*AssertionCredential, error) {
+
+	if !Available() {
+		return nil, ErrNotAvailable
+	}
+
+	pk := object()
+	pk.Set("challenge", bytesToJS(opts.Challenge))
+	if opts.Timeout > 0 {
+		pk.Set("timeout", opts.Timeout)
+	}
+	if opts.RPID != "" {
+		pk.Set("rpId", opts.RPID)
+	}
+	if len(opts.AllowCredentials) > 0 {
+		pk.Set("allowCredentials", descriptorsToJS(opts.AllowCredentials))
+	}
+	if opts.UserVerification != "" {
+		pk.Set("userVerification", opts.UserVerification)
+	}
+
+	arg := object()
+	arg.Set("publicKey", pk)
+	if opts.Mediation != "" {
+		arg.Set("mediation", opts.Mediation)
+	}
+	v, err := js.Await(credentials().Call("get", arg), js.NewDOMError)
+	if err != nil {
+		return nil, err
+	}
+	if v.IsNull() {
+		return nil, &Error{Name: "NotAllowedError", Message: "no credential was returned"}
+	}
+
+	resp := v.Get("response")
+	return &AssertionCredential{
+		ID:                      v.Get("id").String(),
+		RawID:                   bytesFromJS(v.Get("rawId")),
+		Type:                    v.Get("type").String(),
+		AuthenticatorAttachment: js.OptString(v.Get("authenticatorAttachment")),
+		Response: AssertionResponse{
+			ClientDataJSON:    bytesFromJS(resp.Get("clientDataJSON")),
+			AuthenticatorData: bytesFromJS(resp.Get("authenticatorData")),
+			Signature:         bytesFromJS(resp.Get("signature")),
+			UserHandle:        bytesFromJS(resp.Get("userHandle")),
+		},
+	}, nil
+}
+
+func credentials() js.Value {
+	nav := js.Global().Get("navigator")
+	if !nav.Truthy() {
+		return js.Undefined()
+	}
+	return nav.Get("credentials")
+}
+
+func object() js.Value {
+	return js.Global().Get("Object").New()
+}
+
+func descriptorsToJS(dl []CredentialDescriptor) js.Value {
+	ret := js.Global().Get("Array").New()
+	for _, d := range dl {
+		o := object()
+		o.Set("type", d.Type)
+		o.Set("id", bytesToJS(d.ID))
+		if len(d.Transports) > 0 {
+			tl := js.Global().Get("Array").New()
+			for _, t := range d.Transports {
+				tl.Call("push", t)
+			}
+			o.Set("transports", tl)
+		}
+		ret.Call("push", o)
+	}
+	return ret
+}
+
+// bytesToJS returns a new ArrayBuffer with the contents of b.
+func bytesToJS(b []byte) js.Value {
+	u := js.Global().Get("Uint8Array").New(len(b))
+	js.CopyBytesToJS(u, b)
+	return u.Get("buffer")
+}
+
+// bytesFromJS copies the contents of an ArrayBuffer, nil if v is null or undefined.
+func bytesFromJS(v js.Value) []byte {
+	if v.IsNull() || v.IsUndefined() {
+		return nil
+	}
+	u := js.Global().Get("Uint8Array").New(v)
+	b := make([]byte, u.Length())
+	js.CopyBytesToGo(b, u)
+	return b
+}
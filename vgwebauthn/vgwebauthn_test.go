@@ -0,0 +1,54 @@
+package vgwebauthn
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCreationOptionsJSON(t *testing.T) {
+
+	// as sent by a typical server side WebAuthn library
+	in := `{"challenge":"3q2-7w","rp":{"id":"example.com","name":"Example"},
+		"user":{"id":"AQID","name":"joe","displayName":"Joe"},
+		"pubKeyCredParams":[{"type":"public-key","alg":-7}],
+		"excludeCredentials":[{"type":"public-key","id":"+/8="}],
+		"authenticatorSelection":{"residentKey":"required"}}`
+
+	var opts CreationOptions
+	if err := json.Unmarshal([]byte(in), &opts); err != nil {
+		t.Fatal(err)
+	}
+	if string(opts.Challenge) != "\xde\xad\xbe\xef" {
+		t.Errorf("unexpected Challenge %x", opts.Challenge)
+	}
+	if string(opts.User.ID) != "\x01\x02\x03" || opts.User.Name != "joe" {
+		t.Errorf("unexpected User %#v", opts.User)
+	}
+	if len(opts.ExcludeCredentials) != 1 || string(opts.ExcludeCredentials[0].ID) != "\xfb\xff" {
+		t.Errorf("unexpected ExcludeCredentials %#v", opts.ExcludeCredentials)
+	}
+	if opts.AuthenticatorSelection == nil || opts.AuthenticatorSelection.ResidentKey != "required" {
+		t.Errorf("unexpected AuthenticatorSelection %#v", opts.AuthenticatorSelection)
+	}
+
+	b, err := json.Marshal(&AssertionCredential{ID: "abc", RawID: Base64URL{0xfb, 0xff}, Type: "public-key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"abc","rawId":"-_8","type":"public-key","response":{"clientDataJSON":"","authenticatorData":"","signature":""}}`
+	if string(b) != want {
+		t.Errorf("unexpected JSON %s", b)
+	}
+}
+
+func TestNotAvailable(t *testing.T) {
+	if Available() {
+		t.Errorf("Available should be false outside the browser")
+	}
+	if _, err := Get(&RequestOptions{}); err != ErrNotAvailable {
+		t.Errorf("unexpected error from Get: %v", err)
+	}
+	if _, err := Create(&CreationOptions{}); err != ErrNotAvailable {
+		t.Errorf("unexpected error from Create: %v", err)
+	}
+}
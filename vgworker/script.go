@@ -0,0 +1,103 @@
+package vgworker
+
+import (
+	"strings"
+
+	"github.com/vugu/vjson"
+)
+
+// ServeScript is the JS for a worker written in JS.  It defines vgworkerServe(methods), which answers calls
+// from Worker with the function of the same name in methods, see the package documentation.  Include it
+// at the start of the worker's script, StartSource does this automatically.
+const ServeScript = `function vgworkerServe(methods) {
+	function toBuffers(list) {
+		var buffers = [], transfer = [];
+		(list || []).forEach(function (b) {
+			if (b instanceof ArrayBuffer) {
+				b = new Uint8Array(b);
+			}
+			buffers.push(b);
+			if (b.buffer instanceof ArrayBuffer && transfer.indexOf(b.buffer) < 0) {
+				transfer.push(b.buffer);
+			}
+		});
+		return [buffers, transfer];
+	}
+	function handle(e) {
+		var m = e.data;
+		if (!m || typeof m.vgworker !== "number") {
+			return;
+		}
+		function fail(err) {
+			self.postMessage({vgworker: m.vgworker, method: m.method, error: String(err && err.message || err)});
+		}
+		function send(r) {
+			var bt = [[], []];
+			if (r instanceof vgworkerServe.Reply) {
+				bt = toBuffers(r.buffers);
+				r = r.result;
+			}
+			var data = JSON.stringify(r === undefined ? null : r);
+			self.postMessage({vgworker: m.vgworker, method: m.method, data: data, buffers: bt[0]}, bt[1]);
+		}
+		var fn = methods[m.method];
+		if (typeof fn !== "function") {
+			fail("no method " + JSON.stringify(m.method));
+			return;
+		}
+		try {
+			Promise.resolve(fn(JSON.parse(m.data), m.buffers)).then(send).catch(fail);
+		} catch (err) {
+			fail(err);
+		}
+	}
+	self.addEventListener("message", handle);
+	var queue = self.vgworkerQueue || [];
+	self.vgworkerQueue = undefined;
+	queue.forEach(handle);
+}
+vgworkerServe.Reply = function (result, buffers) {
+	this.result = result;
+	this.buffers = buffers;
+};
+vgworkerServe.reply = function (result, buffers) {
+	return new vgworkerServe.Reply(result, buffers);
+};
+`
+
+// wasmBootstrap returns the JS for a worker which runs the Go wasm module at wasmURL.  Messages are queued
+// in vgworkerQueue until the module calls Serve.
+func wasmBootstrap(wasmURL, wasmExecURL string) string {
+	r := strings.NewReplacer("WASM_EXEC_URL", jsString(wasmExecURL), "WASM_URL", jsString(wasmURL))
+	return r.Replace(`self.vgworkerQueue = [];
+self.addEventListener("message", function queue(e) {
+	if (self.vgworkerQueue) {
+		self.vgworkerQueue.push(e);
+	} else {
+		self.removeEventListener("message", queue);
+	}
+});
+importScripts(WASM_EXEC_URL);
+var go = new Go();
+fetch(WASM_URL).then(function (r) {
+	if (!r.ok) {
+		throw new Error("fetching " + WASM_URL + ": " + r.status);
+	}
+	return r.arrayBuffer();
+}).then(function (b) {
+	return WebAssembly.instantiate(b, go.importObject);
+}).then(function (r) {
+	return go.run(r.instance);
+}).then(function () {
+	self.postMessage({vgworkerError: "wasm module exited"});
+}).catch(function (err) {
+	self.postMessage({vgworkerError: String(err)});
+});
+`)
+}
+
+// jsString returns s as a JS string literal.
+func jsString(s string) string {
+	b, _ := vjson.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,83 @@
+package vgworker
+
+import (
+	"fmt"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// Handler is a method of a Go worker, see Serve.  A nil reply sends null.
+type Handler func(req *Message) (reply *Message, err error)
+
+// Serve is called by the main function of a Go wasm module started with StartWasm.  It answers calls
+// with the handler for the method named, each in its own goroutine, and does not return unless
+// it is not running in a worker, in which case it returns ErrNotAvailable.
+func Serve(handlers map[string]Handler) error {
+
+	self := js.Global()
+	if !self.Get("importScripts").Truthy() {
+		return ErrNotAvailable
+	}
+
+	fn := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		serveMessage(handlers, args[0].Get("data"))
+		return nil
+	})
+	self.Call("addEventListener", "message", fn)
+
+	// messages which arrived while the module was loading
+	if q := self.Get("vgworkerQueue"); q.Truthy() {
+		self.Set("vgworkerQueue", js.Undefined())
+		for i, n := 0, q.Length(); i < n; i++ {
+			serveMessage(handlers, q.Index(i).Get("data"))
+		}
+	}
+
+	select {}
+}
+
+func serveMessage(handlers map[string]Handler, data js.Value) {
+
+	if !data.Truthy() {
+		return
+	}
+	idv := data.Get("vgworker")
+	if idv.Type() != js.TypeNumber {
+		return
+	}
+	id, method := idv.Int(), js.OptString(data.Get("method"))
+	req := messageFromJS(data)
+
+	go func() {
+		reply, err := callHandler(handlers, method, req)
+		if err != nil {
+			out := js.Global().Get("Object").New()
+			out.Set("vgworker", id)
+			out.Set("method", method)
+			out.Set("error", err.Error())
+			js.Global().Call("postMessage", out)
+			return
+		}
+		if reply == nil {
+			reply = &Message{Data: []byte("null")}
+		}
+		out, transfer := messageToJS(reply)
+		out.Set("vgworker", id)
+		out.Set("method", method)
+		js.Global().Call("postMessage", out, transfer)
+	}()
+}
+
+// callHandler calls the handler for method, turning a panic into an error.
+func callHandler(handlers map[string]Handler, method string, req *Message) (reply *Message, err error) {
+	h := handlers[method]
+	if h == nil {
+		return nil, fmt.Errorf("no method %q", method)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(req)
+}
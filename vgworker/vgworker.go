@@ -0,0 +1,336 @@
+/*
+Package vgworker runs heavy computation (search indexing, image processing and the like) in a Web Worker
+so the page stays responsive.  The worker can be written in JS or be another Go wasm module.
+
+The page starts the worker and calls methods on it by name.  Arguments and results are converted by way
+of JSON, and byte slices can be passed alongside in Buffers.  Each buffer is copied once into JS and then
+transferred to the other thread rather than copied again.  Call waits for the result, so call it in a
+goroutine and take the EventEnv lock to store the result:
+
+	func (c *Root) Init(ctx vugu.InitCtx) {
+		c.worker, c.err = vgworker.StartWasm("/thumbs.wasm", "/wasm_exec.js")
+	}
+
+	func (c *Root) HandleFile(event vugu.DOMEvent) {
+		ee := event.EventEnv()
+		data := c.imageBytes()
+		go func() {
+			reply, err := c.worker.Call("thumbnail", 128, data)
+			ee.Lock()
+			defer ee.UnlockRender()
+			if err != nil {
+				c.err = err
+				return
+			}
+			c.thumb = reply.Buffers[0]
+		}()
+	}
+
+	func (c *Root) Destroy() {
+		c.worker.Terminate()
+	}
+
+A Go worker is an ordinary main package which calls Serve with its methods:
+
+	func main() {
+		vgworker.Serve(map[string]vgworker.Handler{
+			"thumbnail": func(req *vgworker.Message) (*vgworker.Message, error) {
+				var size int
+				if err := req.Decode(&size); err != nil {
+					return nil, err
+				}
+				return vgworker.NewMessage(nil, makeThumbnail(req.Buffers[0], size))
+			},
+		})
+	}
+
+A JS worker calls vgworkerServe from ServeScript with its methods.  Each method gets the arguments and
+the buffers (as Uint8Arrays) and returns the result, a Promise of it, or vgworkerServe.reply(result, buffers)
+to send buffers back.  StartSource starts a worker from JS source with ServeScript included:
+
+	w, err := vgworker.StartSource(`vgworkerServe({
+		sum: function(args) { return args.reduce(function(a, b) { return a + b; }, 0); }
+	});`)
+
+Outside of the browser starting a worker returns ErrNotAvailable.
+*/
+package vgworker
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/vugu/vjson"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// ErrNotAvailable is returned when Web Workers are not supported (or outside of the browser).
+var ErrNotAvailable = errors.New("vgworker: not available in this environment")
+
+// ErrTerminated is returned by calls which were made after, or had not completed before, Terminate.
+var ErrTerminated = errors.New("vgworker: worker terminated")
+
+// Error is an error returned by a method in the worker, or a failure of the worker itself.
+type Error struct {
+	Method  string // empty if the worker failed
+	Message string
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	if e.Method == "" {
+		return "vgworker: " + e.Message
+	}
+	return "vgworker: " + e.Method + ": " + e.Message
+}
+
+// Message is the arguments of a call or its result.
+type Message struct {
+	Data    []byte   // JSON
+	Buffers [][]byte // transferred alongside Data
+}
+
+// NewMessage returns a Message with v encoded as JSON and the buffers given.
+func NewMessage(v interface{}, buffers ...[]byte) (*Message, error) {
+	b, err := vjson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{Data: b, Buffers: buffers}, nil
+}
+
+// Decode unmarshals Data into v.
+func (m *Message) Decode(v interface{}) error {
+	if len(m.Data) == 0 {
+		return vjson.Unmarshal([]byte("null"), v)
+	}
+	return vjson.Unmarshal(m.Data, v)
+}
+
+// Result is the outcome of a call made with Worker.Go.
+type Result struct {
+	Reply *Message
+	Err   error
+}
+
+// Worker is a Web Worker started by Start, StartSource or StartWasm.
+type Worker struct {
+	worker    js.Value
+	url       string // object URL to revoke on Terminate, if any
+	onMessage js.Func
+	onError   js.Func
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan Result
+	err     error // once set, all calls fail with it
+}
+
+// Start starts a worker running the JS at scriptURL, which should call vgworkerServe (see ServeScript).
+func Start(scriptURL string) (*Worker, error) {
+	return start(scriptURL, "")
+}
+
+// StartSource starts a worker running the JS in src, with ServeScript before it.
+func StartSource(src string) (*Worker, error) {
+	url, err := sourceURL(ServeScript + "\n" + src)
+	if err != nil {
+		return nil, err
+	}
+	return start(url, url)
+}
+
+// StartWasm starts a worker running the Go wasm module at wasmURL, which should call Serve.  wasmExecURL
+// is the wasm_exec.js matching the Go version the module was built with.  Relative URLs are resolved
+// against the page.
+func StartWasm(wasmURL, wasmExecURL string) (*Worker, error) {
+	url, err := sourceURL(wasmBootstrap(resolveURL(wasmURL), resolveURL(wasmExecURL)))
+	if err != nil {
+		return nil, err
+	}
+	return start(url, url)
+}
+
+func start(scriptURL, objectURL string) (*Worker, error) {
+
+	workerClass := js.Global().Get("Worker")
+	if !workerClass.Truthy() {
+		return nil, ErrNotAvailable
+	}
+
+	w := &Worker{url: objectURL, pending: make(map[int]chan Result)}
+	w.onMessage = js.FuncOf(w.handleMessage)
+	w.onError = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		msg := "worker error"
+		if len(args) > 0 && args[0].Get("message").Type() == js.TypeString {
+			msg = args[0].Get("message").String()
+		}
+		w.fail(&Error{Message: msg})
+		return nil
+	})
+	w.worker = workerClass.New(scriptURL)
+	w.worker.Call("addEventListener", "message", w.onMessage)
+	w.worker.Call("addEventListener", "error", w.onError)
+	return w, nil
+}
+
+// Call calls method in the worker with args (encoded as JSON) and buffers, and waits for the reply.
+// It must not be called from the JS event loop, e.g. directly from a DOM event handler.
+func (w *Worker) Call(method string, args interface{}, buffers ...[]byte) (*Message, error) {
+	r := <-w.Go(method, args, buffers...)
+	return r.Reply, r.Err
+}
+
+// Go calls method in the worker with args (encoded as JSON) and buffers, the Result is sent on the
+// channel returned.  Unlike Call it does not wait and so may be used from an event handler.
+func (w *Worker) Go(method string, args interface{}, buffers ...[]byte) <-chan Result {
+
+	ch := make(chan Result, 1)
+
+	msg, err := NewMessage(args, buffers...)
+	if err != nil {
+		ch <- Result{Err: err}
+		return ch
+	}
+
+	w.mu.Lock()
+	if w.err != nil {
+		ch <- Result{Err: w.err}
+		w.mu.Unlock()
+		return ch
+	}
+	w.nextID++
+	id := w.nextID
+	w.pending[id] = ch
+	w.mu.Unlock()
+
+	data, transfer := messageToJS(msg)
+	data.Set("vgworker", id)
+	data.Set("method", method)
+	w.worker.Call("postMessage", data, transfer)
+	return ch
+}
+
+// Terminate stops the worker immediately, calls which have not completed fail with ErrTerminated.
+func (w *Worker) Terminate() {
+	if w == nil || !w.worker.Truthy() {
+		return
+	}
+	w.worker.Call("terminate")
+	w.fail(ErrTerminated)
+	w.worker.Call("removeEventListener", "message", w.onMessage)
+	w.worker.Call("removeEventListener", "error", w.onError)
+	w.onMessage.Release()
+	w.onError.Release()
+	if w.url != "" {
+		js.Global().Get("URL").Call("revokeObjectURL", w.url)
+	}
+	w.worker = js.Null()
+}
+
+// fail makes pending and future calls fail with err, unless the worker already failed.
+func (w *Worker) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return
+	}
+	w.err = err
+	for id, ch := range w.pending {
+		ch <- Result{Err: err}
+		delete(w.pending, id)
+	}
+}
+
+func (w *Worker) handleMessage(this js.Value, args []js.Value) interface{} {
+
+	data := args[0].Get("data")
+	if !data.Truthy() {
+		return nil
+	}
+	if e := data.Get("vgworkerError"); e.Type() == js.TypeString {
+		w.fail(&Error{Message: e.String()})
+		return nil
+	}
+	idv := data.Get("vgworker")
+	if idv.Type() != js.TypeNumber {
+		return nil // not ours
+	}
+
+	// copy out of JS now, as the message is only valid during this call
+	var r Result
+	if e := data.Get("error"); e.Type() == js.TypeString {
+		r.Err = &Error{Method: js.OptString(data.Get("method")), Message: e.String()}
+	} else {
+		r.Reply = messageFromJS(data)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if ch, ok := w.pending[idv.Int()]; ok {
+		ch <- r
+		delete(w.pending, idv.Int())
+	}
+	return nil
+}
+
+// messageToJS returns an object with msg as the data and buffers properties, and the transfer list for it.
+func messageToJS(msg *Message) (data, transfer js.Value) {
+	data = js.Global().Get("Object").New()
+	transfer = js.Global().Get("Array").New()
+	data.Set("data", string(msg.Data))
+	buffers := js.Global().Get("Array").New()
+	for _, b := range msg.Buffers {
+		arr := js.Global().Get("Uint8Array").New(len(b))
+		js.CopyBytesToJS(arr, b)
+		buffers.Call("push", arr)
+		transfer.Call("push", arr.Get("buffer"))
+	}
+	data.Set("buffers", buffers)
+	return data, transfer
+}
+
+// messageFromJS copies the data and buffers properties of data into a Message.
+func messageFromJS(data js.Value) *Message {
+	msg := &Message{}
+	if d := data.Get("data"); d.Type() == js.TypeString {
+		msg.Data = []byte(d.String())
+	}
+	if buffers := data.Get("buffers"); buffers.Truthy() {
+		for i, n := 0, buffers.Length(); i < n; i++ {
+			arr := buffers.Index(i)
+			b := make([]byte, arr.Get("length").Int())
+			js.CopyBytesToGo(b, arr)
+			msg.Buffers = append(msg.Buffers, b)
+		}
+	}
+	return msg
+}
+
+// sourceURL returns an object URL for a Blob containing the JS in src.
+func sourceURL(src string) (string, error) {
+	if !js.Global().Get("Worker").Truthy() || !js.Global().Get("Blob").Truthy() {
+		return "", ErrNotAvailable
+	}
+	parts := js.Global().Get("Array").New()
+	parts.Call("push", src)
+	opts := js.Global().Get("Object").New()
+	opts.Set("type", "text/javascript")
+	blob := js.Global().Get("Blob").New(parts, opts)
+	return js.Global().Get("URL").Call("createObjectURL", blob).String(), nil
+}
+
+// resolveURL returns url made absolute against the page's base URL (or the location in a worker), as a
+// worker started from an object URL cannot resolve relative URLs.
+func resolveURL(url string) string {
+	base := js.Global().Get("document")
+	if base.Truthy() {
+		base = base.Get("baseURI")
+	} else if base = js.Global().Get("location"); base.Truthy() {
+		base = base.Get("href")
+	} else {
+		return url
+	}
+	return js.Global().Get("URL").New(url, base).Call("toString").String()
+}
@@ -0,0 +1,92 @@
+package vgworker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNotAvailable(t *testing.T) {
+
+	if _, err := Start("/worker.js"); err != ErrNotAvailable {
+		t.Errorf("Start: expected ErrNotAvailable, got %v", err)
+	}
+	if _, err := StartSource("vgworkerServe({});"); err != ErrNotAvailable {
+		t.Errorf("StartSource: expected ErrNotAvailable, got %v", err)
+	}
+	if _, err := StartWasm("/worker.wasm", "/wasm_exec.js"); err != ErrNotAvailable {
+		t.Errorf("StartWasm: expected ErrNotAvailable, got %v", err)
+	}
+	if err := Serve(nil); err != ErrNotAvailable {
+		t.Errorf("Serve: expected ErrNotAvailable, got %v", err)
+	}
+
+	var w *Worker
+	w.Terminate()
+}
+
+func TestMessage(t *testing.T) {
+
+	m, err := NewMessage(map[string]int{"size": 128}, []byte("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct{ Size int }
+	if err := m.Decode(&v); err != nil || v.Size != 128 {
+		t.Errorf("unexpected decode %#v, %v", v, err)
+	}
+	if len(m.Buffers) != 1 || string(m.Buffers[0]) != "abc" {
+		t.Errorf("unexpected buffers %q", m.Buffers)
+	}
+
+	var p *int
+	if err := (&Message{}).Decode(&p); err != nil || p != nil {
+		t.Errorf("expected empty data to decode as null, got %v, %v", p, err)
+	}
+}
+
+func TestCallHandler(t *testing.T) {
+
+	handlers := map[string]Handler{
+		"double": func(req *Message) (*Message, error) {
+			var n int
+			if err := req.Decode(&n); err != nil {
+				return nil, err
+			}
+			return NewMessage(n * 2)
+		},
+		"panic": func(req *Message) (*Message, error) {
+			panic("oops")
+		},
+	}
+
+	req, _ := NewMessage(21)
+	reply, err := callHandler(handlers, "double", req)
+	if err != nil || string(reply.Data) != "42" {
+		t.Errorf("unexpected reply %v, %v", reply, err)
+	}
+	if _, err := callHandler(handlers, "panic", req); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("expected panic error, got %v", err)
+	}
+	if _, err := callHandler(handlers, "missing", req); err == nil {
+		t.Errorf("expected error for missing method")
+	}
+}
+
+func TestWasmBootstrap(t *testing.T) {
+	s := wasmBootstrap(`https://example.com/a"b.wasm`, "https://example.com/wasm_exec.js")
+	if !strings.Contains(s, `importScripts("https://example.com/wasm_exec.js")`) {
+		t.Errorf("missing importScripts in:\n%s", s)
+	}
+	if !strings.Contains(s, `fetch("https://example.com/a\"b.wasm")`) {
+		t.Errorf("missing escaped fetch in:\n%s", s)
+	}
+}
+
+func TestError(t *testing.T) {
+	if s := (&Error{Method: "index", Message: "bad"}).Error(); s != "vgworker: index: bad" {
+		t.Errorf("unexpected %q", s)
+	}
+	if s := (&Error{Message: "wasm module exited"}).Error(); s != "vgworker: wasm module exited" {
+		t.Errorf("unexpected %q", s)
+	}
+}
@@ -0,0 +1,149 @@
+//go:build js
+// +build js
+
+package vgws
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	js "github.com/vugu/vugu/js"
+)
+
+// dialBrowser opens a connection with the WebSocket API.
+func dialBrowser(ctx context.Context, url string) (Conn, error) {
+
+	ws := js.Global().Get("WebSocket")
+	if !ws.Truthy() {
+		return nil, errors.New("vgws: WebSocket is not available")
+	}
+
+	c := &browserConn{ws: ws.New(url), notify: make(chan struct{}, 1)}
+	c.ws.Set("binaryType", "arraybuffer")
+
+	opened := make(chan error, 1)
+	c.onOpen = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		opened <- nil
+		return nil
+	})
+	c.onMessage = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		data := args[0].Get("data")
+		var msg Message
+		if data.Type() == js.TypeString {
+			msg.Data = []byte(data.String())
+		} else {
+			arr := js.Global().Get("Uint8Array").New(data)
+			msg.Data = make([]byte, arr.Length())
+			js.CopyBytesToGo(msg.Data, arr)
+			msg.Binary = true
+		}
+		c.push(msg, nil)
+		return nil
+	})
+	c.onClose = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		err := errors.New("vgws: connection closed")
+		if reason := args[0].Get("reason").String(); reason != "" {
+			err = errors.New("vgws: connection closed: " + reason)
+		}
+		select {
+		case opened <- err:
+		default:
+		}
+		c.push(Message{}, err)
+		return nil
+	})
+	c.ws.Set("onopen", c.onOpen)
+	c.ws.Set("onmessage", c.onMessage)
+	c.ws.Set("onclose", c.onClose)
+
+	select {
+	case err := <-opened:
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+	case <-ctx.Done():
+		c.Close()
+		return nil, ctx.Err()
+	}
+	return c, nil
+}
+
+// browserConn is a Conn using a JS WebSocket.  Messages are queued by the JS callbacks, which must not block.
+type browserConn struct {
+	ws                         js.Value
+	onOpen, onMessage, onClose js.Func
+
+	closeOnce sync.Once
+
+	mu     sync.Mutex
+	queue  []Message
+	err    error
+	notify chan struct{}
+}
+
+func (c *browserConn) push(msg Message, err error) {
+	c.mu.Lock()
+	if err != nil {
+		if c.err == nil {
+			c.err = err
+		}
+	} else if c.err == nil {
+		c.queue = append(c.queue, msg)
+	}
+	c.mu.Unlock()
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Receive implements Conn.
+func (c *browserConn) Receive() (Message, error) {
+	for {
+		c.mu.Lock()
+		if len(c.queue) > 0 {
+			msg := c.queue[0]
+			c.queue = c.queue[1:]
+			c.mu.Unlock()
+			return msg, nil
+		}
+		err := c.err
+		c.mu.Unlock()
+		if err != nil {
+			return Message{}, err
+		}
+		<-c.notify
+	}
+}
+
+// Send implements Conn.
+func (c *browserConn) Send(msg Message) error {
+	if c.ws.Get("readyState").Int() != 1 {
+		return ErrNotConnected
+	}
+	if !msg.Binary {
+		c.ws.Call("send", string(msg.Data))
+		return nil
+	}
+	arr := js.Global().Get("Uint8Array").New(len(msg.Data))
+	js.CopyBytesToJS(arr, msg.Data)
+	c.ws.Call("send", arr)
+	return nil
+}
+
+// Close implements Conn.
+func (c *browserConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.push(Message{}, errors.New("vgws: connection closed"))
+		c.ws.Set("onopen", js.Null())
+		c.ws.Set("onmessage", js.Null())
+		c.ws.Set("onclose", js.Null())
+		c.ws.Call("close")
+		c.onOpen.Release()
+		c.onMessage.Release()
+		c.onClose.Release()
+	})
+	return nil
+}
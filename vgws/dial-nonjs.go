@@ -0,0 +1,14 @@
+//go:build !js
+// +build !js
+
+package vgws
+
+import (
+	"context"
+	"errors"
+)
+
+// dialBrowser is only available in the browser, elsewhere Client.Dial must be set.
+func dialBrowser(ctx context.Context, url string) (Conn, error) {
+	return nil, errors.New("vgws: Client.Dial must be set outside the browser")
+}
@@ -0,0 +1,247 @@
+/*
+Package vgws connects to a WebSocket server and delivers its messages with the EventEnv write lock held,
+requesting a render afterward, so components can be updated from them directly:
+
+	func (c *Chat) Init(ctx vugu.InitCtx) {
+		c.ws = &vgws.Client{
+			EventEnv: ctx.EventEnv(),
+			URL:      "wss://example.com/chat",
+			OnMessage: func(msg vgws.Message) {
+				var line ChatLine
+				if msg.JSON(&line) == nil {
+					c.lines = append(c.lines, line)
+				}
+			},
+			ReconnectDelay: 2 * time.Second,
+		}
+		c.ws.Connect()
+	}
+
+	func (c *Chat) Destroy(ctx vugu.DestroyCtx) {
+		c.ws.Close()
+	}
+
+In the browser the WebSocket API is used.  Elsewhere there is no built in implementation, Client.Dial
+must be set, e.g. to a fake connection in tests.
+*/
+package vgws
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vugu/vjson"
+
+	"github.com/vugu/vugu"
+)
+
+// ErrNotConnected is returned by Send when there is no open connection.
+var ErrNotConnected = errors.New("vgws: not connected")
+
+// Message is a message sent or received over a connection.
+type Message struct {
+	Data   []byte
+	Binary bool // false for a text message
+}
+
+// JSON unmarshals the message data into v.
+func (m Message) JSON(v interface{}) error {
+	return vjson.Unmarshal(m.Data, v)
+}
+
+// Conn is an open connection, as returned by a dial func.  Receive is only called from one goroutine at a
+// time, and Send and Close may be called at the same time as it.
+type Conn interface {
+	// Receive waits for the next message, and returns an error once the connection is closed.
+	Receive() (Message, error)
+	// Send sends a message.
+	Send(msg Message) error
+	// Close closes the connection, making any Receive in progress return.
+	Close() error
+}
+
+// Client maintains a connection to a WebSocket server, see the package documentation.  The fields
+// must be set before Connect is called.
+type Client struct {
+	// EventEnv is locked while the On funcs are called, and a render requested after.
+	// If nil they are called without locking or rendering.
+	EventEnv vugu.EventEnv
+
+	// URL is the WebSocket URL to connect to.
+	URL string
+
+	// Dial opens a connection to url.  If nil the browser's WebSocket API is used.
+	Dial func(ctx context.Context, url string) (Conn, error)
+
+	// OnOpen, if set, is called each time a connection is opened.
+	OnOpen func()
+
+	// OnMessage is called with each message received.
+	OnMessage func(msg Message)
+
+	// OnClose, if set, is called when a connection could not be opened or was lost, with the reason.
+	// It is not called after Close.
+	OnClose func(err error)
+
+	// ReconnectDelay, if not zero, is how long to wait before connecting again after OnClose.
+	ReconnectDelay time.Duration
+
+	mu     sync.Mutex
+	conn   Conn
+	cancel context.CancelFunc
+	closed bool
+}
+
+// Connect starts connecting in a new goroutine.  Calling it again before Close does nothing.
+func (c *Client) Connect() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		return
+	}
+	var ctx context.Context
+	ctx, c.cancel = context.WithCancel(context.Background())
+	c.closed = false
+	go c.run(ctx)
+}
+
+// Connected returns true if there is an open connection.
+func (c *Client) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
+// Send sends a message, or returns ErrNotConnected if there is no open connection.
+func (c *Client) Send(msg Message) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return ErrNotConnected
+	}
+	return conn.Send(msg)
+}
+
+// SendText sends a text message.
+func (c *Client) SendText(s string) error {
+	return c.Send(Message{Data: []byte(s)})
+}
+
+// SendJSON sends v marshaled as JSON in a text message.
+func (c *Client) SendJSON(v interface{}) error {
+	b, err := vjson.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Send(Message{Data: b})
+}
+
+// Close closes the connection and stops reconnecting.  No On funcs are called after it returns, unless
+// Connect is called again.
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	c.cancel = nil
+	c.closed = true
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+// run connects and receives until ctx is cancelled.
+func (c *Client) run(ctx context.Context) {
+
+	dial := c.Dial
+	if dial == nil {
+		dial = dialBrowser
+	}
+
+	for {
+		conn, err := dial(ctx, c.URL)
+		if err == nil {
+			c.mu.Lock()
+			if ctx.Err() != nil {
+				c.mu.Unlock()
+				conn.Close()
+				return
+			}
+			c.conn = conn
+			c.mu.Unlock()
+
+			c.deliver(ctx, c.OnOpen)
+			err = c.receive(ctx, conn)
+
+			c.mu.Lock()
+			if c.conn == conn {
+				c.conn = nil
+			}
+			c.mu.Unlock()
+			conn.Close()
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+		if c.OnClose != nil {
+			c.deliver(ctx, func() { c.OnClose(err) })
+		}
+		if c.ReconnectDelay <= 0 {
+			c.mu.Lock()
+			if ctx.Err() == nil {
+				c.cancel()
+				c.cancel = nil
+			}
+			c.mu.Unlock()
+			return
+		}
+
+		t := time.NewTimer(c.ReconnectDelay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// receive delivers messages from conn until it returns an error.
+func (c *Client) receive(ctx context.Context, conn Conn) error {
+	for {
+		msg, err := conn.Receive()
+		if err != nil {
+			return err
+		}
+		if c.OnMessage != nil {
+			c.deliver(ctx, func() { c.OnMessage(msg) })
+		}
+	}
+}
+
+// deliver calls fn with the EventEnv locked, unless ctx was cancelled (by Close) first.
+func (c *Client) deliver(ctx context.Context, fn func()) {
+	if fn == nil {
+		return
+	}
+	if c.EventEnv != nil {
+		c.EventEnv.Lock()
+	}
+	if ctx.Err() != nil {
+		if c.EventEnv != nil {
+			c.EventEnv.UnlockOnly()
+		}
+		return
+	}
+	if c.EventEnv != nil {
+		defer c.EventEnv.UnlockRender()
+	}
+	fn()
+}
@@ -0,0 +1,143 @@
+package vgws
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vugu/vugu"
+)
+
+// fakeConn is a Conn whose received messages come from a channel.
+type fakeConn struct {
+	in     chan Message
+	closed chan struct{}
+	once   sync.Once
+
+	mu   sync.Mutex
+	sent []string
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{in: make(chan Message), closed: make(chan struct{})}
+}
+
+func (c *fakeConn) Receive() (Message, error) {
+	select {
+	case msg := <-c.in:
+		return msg, nil
+	case <-c.closed:
+		return Message{}, errors.New("closed")
+	}
+}
+
+func (c *fakeConn) Send(msg Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, string(msg.Data))
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func TestClient(t *testing.T) {
+
+	assert := assert.New(t)
+
+	var mu sync.RWMutex
+	renderCh := make(chan bool, 10)
+	ee := vugu.NewEventEnvImpl(&mu, renderCh)
+
+	conns := make(chan *fakeConn, 10)
+	events := make(chan string, 10)
+	var got []int
+	c := &Client{
+		EventEnv: ee,
+		URL:      "ws://example.com/",
+		Dial: func(ctx context.Context, url string) (Conn, error) {
+			conn := newFakeConn()
+			conns <- conn
+			return conn, nil
+		},
+		OnOpen: func() { events <- "open" },
+		OnMessage: func(msg Message) {
+			var n int
+			assert.NoError(msg.JSON(&n))
+			got = append(got, n)
+			events <- "message"
+		},
+		OnClose:        func(err error) { events <- "close" },
+		ReconnectDelay: time.Millisecond,
+	}
+
+	assert.Equal(ErrNotConnected, c.SendText("early"))
+
+	c.Connect()
+	conn := <-conns
+	assert.Equal("open", <-events)
+	assert.True(c.Connected())
+
+	// messages are delivered with the lock held and a render requested
+	conn.in <- Message{Data: []byte("1")}
+	assert.Equal("message", <-events)
+	mu.Lock()
+	assert.Equal([]int{1}, got)
+	mu.Unlock()
+	<-renderCh
+	<-renderCh
+
+	assert.NoError(c.SendJSON(map[string]int{"a": 1}))
+	assert.Equal([]string{`{"a":1}`}, conn.sent)
+
+	// a lost connection is reported and made again
+	conn.Close()
+	assert.Equal("close", <-events)
+	conn = <-conns
+	assert.Equal("open", <-events)
+	conn.in <- Message{Data: []byte("2")}
+	assert.Equal("message", <-events)
+
+	// nothing is delivered after Close
+	mu.Lock()
+	go func() {
+		select {
+		case conn.in <- Message{Data: []byte("3")}:
+		case <-conn.closed:
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	c.Close()
+	mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	assert.False(c.Connected())
+	assert.Len(events, 0)
+	assert.Equal([]int{1, 2}, got)
+}
+
+func TestClientDialError(t *testing.T) {
+
+	assert := assert.New(t)
+
+	errCh := make(chan error, 1)
+	c := &Client{
+		Dial: func(ctx context.Context, url string) (Conn, error) {
+			return nil, errors.New("refused")
+		},
+		OnClose: func(err error) { errCh <- err },
+	}
+	c.Connect()
+	assert.EqualError(<-errCh, "refused")
+
+	// without ReconnectDelay it can be connected again
+	time.Sleep(10 * time.Millisecond)
+	c.Connect()
+	assert.EqualError(<-errCh, "refused")
+	c.Close()
+}